@@ -21,6 +21,14 @@ const (
 	stageTimePattern                   = "\\(\\d{2}:\\d{2}:\\d{2}\\)"
 	stageFinishedPattern               = "\\.\\.\\. Finished " + stageTimePattern + "$"
 	stageCompiledPackageSkippedPattern = "\\.\\.\\. Skipped \\[Package already compiled\\] " + stageTimePattern + "$"
+
+	// Packages are compiled in parallel, each line prefixed with its package, unless there is only one
+	stageCompilingPackagesPattern = "^(\\s*|  Started compiling packages|  Finished compiling packages " + stageTimePattern +
+		"|    \\[.*\\] Compiling package '.*/.*'" + stageFinishedPattern + "|    [^ ]+" + stageFinishedPattern +
+		"|  Compiling package '.*/.*'" + stageFinishedPattern + ")$"
+	stageSkippingPackagesPattern = "^(\\s*|  Started compiling packages|  Finished compiling packages " + stageTimePattern +
+		"|    \\[.*\\] Compiling package '.*/.*'" + stageCompiledPackageSkippedPattern + "|    [^ ]+" + stageFinishedPattern +
+		"|  Compiling package '.*/.*'" + stageCompiledPackageSkippedPattern + ")$"
 )
 
 var _ = Describe("bosh", func() {
@@ -136,7 +144,7 @@ var _ = Describe("bosh", func() {
 		stdout := &bytes.Buffer{}
 		multiWriter := io.MultiWriter(stdout, GinkgoWriter)
 
-		args = append([]string{testEnv.Path("bosh"), "create-env", "--tty", testEnv.Path(manifestFile)}, args...)
+		args = append([]string{testEnv.Path("bosh"), "create-env", "--tty", "-n", testEnv.Path(manifestFile)}, args...)
 
 		_, _, exitCode, err := cmdRunner.RunStreamingCommand(multiWriter, cmdEnv, args...)
 		Expect(err).ToNot(HaveOccurred())
@@ -151,7 +159,7 @@ var _ = Describe("bosh", func() {
 		stdout := &bytes.Buffer{}
 		multiWriter := io.MultiWriter(stdout, GinkgoWriter)
 
-		_, _, exitCode, err := cmdRunner.RunStreamingCommand(multiWriter, cmdEnv, testEnv.Path("bosh"), "create-env", "--tty", testEnv.Path("test-manifest.yml"))
+		_, _, exitCode, err := cmdRunner.RunStreamingCommand(multiWriter, cmdEnv, testEnv.Path("bosh"), "create-env", "--tty", "-n", testEnv.Path("test-manifest.yml"))
 		Expect(err).To(HaveOccurred())
 		Expect(exitCode).To(Equal(1))
 
@@ -165,7 +173,7 @@ var _ = Describe("bosh", func() {
 		multiWriter := io.MultiWriter(stdout, GinkgoWriter)
 
 		_, _, exitCode, err := cmdRunner.RunStreamingCommand(multiWriter, cmdEnv, testEnv.Path("bosh"),
-			"delete-env", "--tty", testEnv.Path("test-manifest.yml"))
+			"delete-env", "--tty", "-n", testEnv.Path("test-manifest.yml"))
 
 		Expect(err).ToNot(HaveOccurred())
 		Expect(exitCode).To(Equal(0))
@@ -173,6 +181,25 @@ var _ = Describe("bosh", func() {
 		return stdout.String()
 	}
 
+	// runWithFaults runs the CLI with BOSH_FAULT_INJECTION set to faults
+	var runWithFaults = func(faults string, args ...string) (string, int) {
+		fmt.Fprintf(GinkgoWriter, "\n--- %s WITH FAULTS '%s' ---\n", strings.ToUpper(args[0]), faults)
+
+		faultEnv := map[string]string{"BOSH_FAULT_INJECTION": faults}
+		for k, v := range cmdEnv {
+			faultEnv[k] = v
+		}
+
+		stdout := &bytes.Buffer{}
+		multiWriter := io.MultiWriter(stdout, GinkgoWriter)
+
+		args = append([]string{testEnv.Path("bosh")}, append(args, "--tty", "-n", testEnv.Path("test-manifest.yml"))...)
+
+		_, _, exitCode, _ := cmdRunner.RunStreamingCommand(multiWriter, faultEnv, args...)
+
+		return stdout.String(), exitCode
+	}
+
 	var shutdownAgent = func() {
 		_, _, exitCode, err := instanceSSH.RunCommandWithSudo("sv stop agent")
 		Expect(err).ToNot(HaveOccurred())
@@ -286,7 +313,7 @@ var _ = Describe("bosh", func() {
 			flushLog(cmdEnv["BOSH_LOG_PATH"])
 
 			// quietly delete the deployment
-			_, _, exitCode, err := cmdRunner.RunCommand(quietCmdEnv, testEnv.Path("bosh"), "delete-env", "--tty", testEnv.Path("test-compiled-manifest.yml"))
+			_, _, exitCode, err := cmdRunner.RunCommand(quietCmdEnv, testEnv.Path("bosh"), "delete-env", "--tty", "-n", testEnv.Path("test-compiled-manifest.yml"))
 			if exitCode != 0 || err != nil {
 				// only flush the delete log if the delete failed
 				flushLog(quietCmdEnv["BOSH_LOG_PATH"])
@@ -323,7 +350,7 @@ var _ = Describe("bosh", func() {
 			installingSteps, doneIndex := findStage(outputLines, "installing CPI", doneIndex+1)
 			numInstallingSteps := len(installingSteps)
 			for _, line := range installingSteps[:numInstallingSteps-3] {
-				Expect(line).To(MatchRegexp("^  Compiling package '.*/.*'" + stageFinishedPattern))
+				Expect(line).To(MatchRegexp(stageCompilingPackagesPattern))
 			}
 			Expect(installingSteps[numInstallingSteps-3]).To(MatchRegexp("^  Installing packages" + stageFinishedPattern))
 			Expect(installingSteps[numInstallingSteps-2]).To(MatchRegexp("^  Rendering job templates" + stageFinishedPattern))
@@ -341,7 +368,7 @@ var _ = Describe("bosh", func() {
 			Expect(deployingSteps[4]).To(MatchRegexp("^  Rendering job templates" + stageFinishedPattern))
 
 			for _, line := range deployingSteps[5 : numDeployingSteps-3] {
-				Expect(line).To(MatchRegexp("^  Compiling package '.*/.*'" + stageCompiledPackageSkippedPattern))
+				Expect(line).To(MatchRegexp(stageSkippingPackagesPattern))
 			}
 
 			Expect(deployingSteps[numDeployingSteps-3]).To(MatchRegexp("^  Updating instance 'dummy_compiled_job/0'" + stageFinishedPattern))
@@ -386,7 +413,7 @@ var _ = Describe("bosh", func() {
 				append(
 					[]string{
 						testEnv.Path("bosh"),
-						"delete-env", "--tty", testEnv.Path("test-compiled-manifest.yml"),
+						"delete-env", "--tty", "-n", testEnv.Path("test-compiled-manifest.yml"),
 					},
 					extraDeployArgs...,
 				)...,
@@ -424,7 +451,7 @@ var _ = Describe("bosh", func() {
 					append(
 						[]string{
 							testEnv.Path("bosh"),
-							"create-env", "--tty", testEnv.Path("test-compiled-manifest.yml"),
+							"create-env", "--tty", "-n", testEnv.Path("test-compiled-manifest.yml"),
 							"-o", "./assets/use-bogus-mbus-ca.yml",
 						},
 						extraDeployArgs...,
@@ -446,7 +473,7 @@ var _ = Describe("bosh", func() {
 			flushLog(cmdEnv["BOSH_LOG_PATH"])
 
 			// quietly delete the deployment
-			_, _, exitCode, err := cmdRunner.RunCommand(quietCmdEnv, testEnv.Path("bosh"), "delete-env", "--tty", testEnv.Path("test-manifest.yml"))
+			_, _, exitCode, err := cmdRunner.RunCommand(quietCmdEnv, testEnv.Path("bosh"), "delete-env", "--tty", "-n", testEnv.Path("test-manifest.yml"))
 			if exitCode != 0 || err != nil {
 				// only flush the delete log if the delete failed
 				flushLog(quietCmdEnv["BOSH_LOG_PATH"])
@@ -484,7 +511,7 @@ var _ = Describe("bosh", func() {
 			installingSteps, doneIndex := findStage(outputLines, "installing CPI", doneIndex+1)
 			numInstallingSteps := len(installingSteps)
 			for _, line := range installingSteps[:numInstallingSteps-3] {
-				Expect(line).To(MatchRegexp("^  Compiling package '.*/.*'" + stageFinishedPattern))
+				Expect(line).To(MatchRegexp(stageCompilingPackagesPattern))
 			}
 			Expect(installingSteps[numInstallingSteps-3]).To(MatchRegexp("^  Installing packages" + stageFinishedPattern))
 			Expect(installingSteps[numInstallingSteps-2]).To(MatchRegexp("^  Rendering job templates" + stageFinishedPattern))
@@ -502,7 +529,7 @@ var _ = Describe("bosh", func() {
 			Expect(deployingSteps[4]).To(MatchRegexp("^  Rendering job templates" + stageFinishedPattern))
 
 			for _, line := range deployingSteps[5 : numDeployingSteps-3] {
-				Expect(line).To(MatchRegexp("^  Compiling package '.*/.*'" + stageFinishedPattern))
+				Expect(line).To(MatchRegexp(stageCompilingPackagesPattern))
 			}
 
 			Expect(deployingSteps[numDeployingSteps-3]).To(MatchRegexp("^  Updating instance 'dummy_job/0'" + stageFinishedPattern))
@@ -606,12 +633,74 @@ var _ = Describe("bosh", func() {
 		})
 	})
 
+	Context("when CPI and agent calls fail", func() {
+		deploymentManifest := "test-manifest.yml"
+
+		BeforeEach(func() {
+			updateDeploymentManifest("./assets/manifest.yml")
+		})
+
+		AfterEach(func() {
+			flushLog(cmdEnv["BOSH_LOG_PATH"])
+
+			// quietly delete the deployment
+			_, _, exitCode, err := cmdRunner.RunCommand(quietCmdEnv, testEnv.Path("bosh"), "delete-env", "--tty", "-n", testEnv.Path(deploymentManifest))
+			if exitCode != 0 || err != nil {
+				// only flush the delete log if the delete failed
+				flushLog(quietCmdEnv["BOSH_LOG_PATH"])
+			}
+			Expect(err).ToNot(HaveOccurred())
+			Expect(exitCode).To(Equal(0))
+		})
+
+		It("resumes the deploy after creating the disk failed", func() {
+			stdout, exitCode := runWithFaults("cpi:create_disk", "create-env")
+			Expect(exitCode).To(Equal(1))
+			Expect(stdout).To(ContainSubstring("Injected fault for cpi call 'create_disk'"))
+
+			stdout = deploy(deploymentManifest)
+			Expect(stdout).To(ContainSubstring("Creating disk"))
+			Expect(stdout).To(ContainSubstring("Finished deploying"))
+		})
+
+		It("recreates the VM after applying the jobs failed", func() {
+			stdout, exitCode := runWithFaults("agent:apply:1", "create-env")
+			Expect(exitCode).To(Equal(1))
+			Expect(stdout).To(ContainSubstring("Injected fault for agent call 'apply'"))
+
+			stdout = deploy(deploymentManifest)
+			Expect(stdout).To(ContainSubstring("Deleting VM"))
+			Expect(stdout).To(ContainSubstring("Finished deploying"))
+		})
+
+		It("finishes deleting when the CPI no longer finds the VM", func() {
+			deploy(deploymentManifest)
+
+			stdout, exitCode := runWithFaults("cpi:delete_vm=Bosh::Clouds::VMNotFound", "delete-env")
+			Expect(exitCode).To(Equal(0))
+			Expect(stdout).To(ContainSubstring("Deleting disk"))
+			Expect(stdout).To(ContainSubstring("Finished deleting deployment"))
+		})
+
+		It("cleans up the remaining resources when deleting is retried", func() {
+			deploy(deploymentManifest)
+
+			stdout, exitCode := runWithFaults("cpi:delete_disk", "delete-env")
+			Expect(exitCode).To(Equal(1))
+			Expect(stdout).To(ContainSubstring("Injected fault for cpi call 'delete_disk'"))
+
+			stdout = deleteDeployment()
+			Expect(stdout).To(ContainSubstring("Deleting disk"))
+			Expect(stdout).To(ContainSubstring("Finished deleting deployment"))
+		})
+	})
+
 	Context("when deploying with all network types", func() {
 		AfterEach(func() {
 			flushLog(cmdEnv["BOSH_LOG_PATH"])
 
 			// quietly delete the deployment
-			_, _, exitCode, err := cmdRunner.RunCommand(quietCmdEnv, testEnv.Path("bosh"), "delete-env", "--tty", testEnv.Path("test-manifest.yml"))
+			_, _, exitCode, err := cmdRunner.RunCommand(quietCmdEnv, testEnv.Path("bosh"), "delete-env", "--tty", "-n", testEnv.Path("test-manifest.yml"))
 			if exitCode != 0 || err != nil {
 				// only flush the delete log if the delete failed
 				flushLog(quietCmdEnv["BOSH_LOG_PATH"])
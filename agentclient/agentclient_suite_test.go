@@ -0,0 +1,13 @@
+package agentclient_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestAgentClient(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent Client Suite")
+}
@@ -0,0 +1,46 @@
+package fakes
+
+import (
+	"time"
+
+	biagentclient "github.com/cloudfoundry/bosh-cli/agentclient"
+)
+
+type FakeRawAgentClientFactory struct {
+	RawAgentClient biagentclient.RawAgentClient
+	NewErr         error
+}
+
+func NewFakeRawAgentClientFactory(rawAgentClient biagentclient.RawAgentClient) *FakeRawAgentClientFactory {
+	return &FakeRawAgentClientFactory{RawAgentClient: rawAgentClient}
+}
+
+func (f *FakeRawAgentClientFactory) NewRawAgentClient(directorID, mbusURL, caCert string) (biagentclient.RawAgentClient, error) {
+	return f.RawAgentClient, f.NewErr
+}
+
+type SendActionInput struct {
+	Action    string
+	Arguments []interface{}
+	Timeout   time.Duration
+}
+
+type FakeRawAgentClient struct {
+	SendActionInputs   []SendActionInput
+	SendActionResponse []byte
+	SendActionErr      error
+}
+
+func NewFakeRawAgentClient() *FakeRawAgentClient {
+	return &FakeRawAgentClient{}
+}
+
+func (c *FakeRawAgentClient) SendAction(action string, arguments []interface{}) ([]byte, error) {
+	return c.SendActionWithTimeout(action, arguments, 0)
+}
+
+func (c *FakeRawAgentClient) SendActionWithTimeout(action string, arguments []interface{}, timeout time.Duration) ([]byte, error) {
+	c.SendActionInputs = append(c.SendActionInputs, SendActionInput{Action: action, Arguments: arguments, Timeout: timeout})
+
+	return c.SendActionResponse, c.SendActionErr
+}
@@ -0,0 +1,167 @@
+package agentclient
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"sync"
+	"time"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	"github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// CertificatePins stores the SHA-256 fingerprint of the agent certificate.
+// Pinned returns an empty fingerprint until one was recorded.
+type CertificatePins interface {
+	Pinned() (string, error)
+	Record(fingerprint string) error
+}
+
+type stateCertificatePins struct {
+	deploymentStateService biconfig.DeploymentStateService
+}
+
+// NewStateCertificatePins keeps the pin in the deployment state so that it
+// is removed together with the deployment
+func NewStateCertificatePins(deploymentStateService biconfig.DeploymentStateService) CertificatePins {
+	return stateCertificatePins{deploymentStateService: deploymentStateService}
+}
+
+func (p stateCertificatePins) Pinned() (string, error) {
+	deploymentState, err := p.deploymentStateService.Load()
+	if err != nil {
+		return "", bosherr.WrapError(err, "Loading pinned agent certificate fingerprint")
+	}
+
+	return deploymentState.AgentCertFingerprint, nil
+}
+
+func (p stateCertificatePins) Record(fingerprint string) error {
+	err := p.deploymentStateService.Update(func(state *biconfig.DeploymentState) error {
+		state.AgentCertFingerprint = fingerprint
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Recording agent certificate fingerprint")
+	}
+
+	return nil
+}
+
+// CertificateFingerprint returns the hex encoded SHA-256 of a DER certificate
+func CertificateFingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:])
+}
+
+type pinningAgentClientFactory struct {
+	getTaskDelay time.Duration
+	pins         CertificatePins
+	pinned       *cachedPin
+	logger       boshlog.Logger
+	logTag       string
+}
+
+// cachedPin keeps the fingerprint loaded from CertificatePins so that TLS
+// handshakes do not load the deployment state
+type cachedPin struct {
+	mutex       sync.Mutex
+	loaded      bool
+	fingerprint string
+}
+
+// NewPinningAgentClientFactory creates agent clients that refuse to talk to
+// an agent whose certificate does not match the pinned fingerprint. When no
+// fingerprint is pinned yet, the certificate seen on first contact is pinned.
+// Agents reached over plain http are not pinned. The pin is loaded once, when
+// the first client is created, and afterwards only changed by recording a pin.
+func NewPinningAgentClientFactory(getTaskDelay time.Duration, pins CertificatePins, logger boshlog.Logger) bihttpagent.AgentClientFactory {
+	return pinningAgentClientFactory{
+		getTaskDelay: getTaskDelay,
+		pins:         pins,
+		pinned:       &cachedPin{},
+		logger:       logger,
+		logTag:       "pinningAgentClientFactory",
+	}
+}
+
+func (f pinningAgentClientFactory) NewAgentClient(directorID, mbusURL, caCert string) (biagentclient.AgentClient, error) {
+	err := f.loadPin()
+	if err != nil {
+		return nil, err
+	}
+
+	client := httpclient.CreateDefaultClientInsecureSkipVerify()
+
+	if caCert != "" {
+		caCertPool, err := crypto.CertPoolFromPEM([]byte(caCert))
+		if err != nil {
+			return nil, err
+		}
+		client = httpclient.CreateDefaultClient(caCertPool)
+	}
+
+	client.Transport.(*http.Transport).TLSClientConfig.VerifyPeerCertificate = f.verifyPin
+
+	httpClient := httpclient.NewHTTPClient(client, f.logger)
+	return bihttpagent.NewAgentClient(mbusURL, directorID, f.getTaskDelay, 10, httpClient, f.logger), nil
+}
+
+func (f pinningAgentClientFactory) loadPin() error {
+	f.pinned.mutex.Lock()
+	defer f.pinned.mutex.Unlock()
+
+	if f.pinned.loaded {
+		return nil
+	}
+
+	fingerprint, err := f.pins.Pinned()
+	if err != nil {
+		return err
+	}
+
+	f.pinned.fingerprint = fingerprint
+	f.pinned.loaded = true
+
+	return nil
+}
+
+func (f pinningAgentClientFactory) verifyPin(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return bosherr.Error("Agent did not present a certificate")
+	}
+
+	fingerprint := CertificateFingerprint(rawCerts[0])
+
+	f.pinned.mutex.Lock()
+	defer f.pinned.mutex.Unlock()
+
+	pinned := f.pinned.fingerprint
+
+	if pinned == "" {
+		f.logger.Info(f.logTag, "Pinning agent certificate with fingerprint '%s'", fingerprint)
+
+		err := f.pins.Record(fingerprint)
+		if err != nil {
+			return err
+		}
+
+		f.pinned.fingerprint = fingerprint
+		return nil
+	}
+
+	if pinned != fingerprint {
+		return bosherr.Errorf(
+			"Agent certificate fingerprint '%s' does not match the pinned fingerprint '%s'. If the agent certificate was changed on purpose, run again with --reset-pin",
+			fingerprint, pinned)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package agentclient_test
+
+import (
+	"errors"
+	"net/http"
+
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/agentclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type fakeCertificatePins struct {
+	pinned      string
+	pinnedErr   error
+	pinnedCalls int
+	recorded    []string
+}
+
+func (p *fakeCertificatePins) Pinned() (string, error) {
+	p.pinnedCalls++
+	return p.pinned, p.pinnedErr
+}
+
+func (p *fakeCertificatePins) Record(fingerprint string) error {
+	p.pinned = fingerprint
+	p.recorded = append(p.recorded, fingerprint)
+	return nil
+}
+
+var _ = Describe("PinningAgentClientFactory", func() {
+	var (
+		server      *ghttp.Server
+		pins        *fakeCertificatePins
+		factory     bihttpagent.AgentClientFactory
+		fingerprint string
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewTLSServer()
+		server.AllowUnhandledRequests = false
+		server.RouteToHandler("POST", "/agent", ghttp.RespondWith(http.StatusOK, `{"value":"pong"}`))
+
+		fingerprint = CertificateFingerprint(server.HTTPTestServer.Certificate().Raw)
+
+		pins = &fakeCertificatePins{}
+		factory = NewPinningAgentClientFactory(0, pins, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("pins the agent certificate on first contact", func() {
+		client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Ping()
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Ping()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(pins.recorded).To(Equal([]string{fingerprint}))
+	})
+
+	It("talks to agents presenting the pinned certificate", func() {
+		pins.pinned = fingerprint
+
+		client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		response, err := client.Ping()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).To(Equal("pong"))
+		Expect(pins.recorded).To(BeEmpty())
+	})
+
+	It("returns an error when the agent certificate does not match the pin", func() {
+		pins.pinned = "fake-pinned-fingerprint"
+
+		client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Ping()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Agent certificate fingerprint '" + fingerprint + "' does not match the pinned fingerprint 'fake-pinned-fingerprint'"))
+		Expect(err.Error()).To(ContainSubstring("--reset-pin"))
+		Expect(server.ReceivedRequests()).To(BeEmpty())
+	})
+
+	It("loads the pin once instead of on every connection", func() {
+		pins.pinned = fingerprint
+
+		for i := 0; i < 2; i++ {
+			client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = client.Ping()
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(pins.pinnedCalls).To(Equal(1))
+	})
+
+	It("uses the recorded pin for later connections", func() {
+		client, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Ping()
+		Expect(err).ToNot(HaveOccurred())
+
+		pins.pinned = "fake-pinned-fingerprint"
+
+		client, err = factory.NewAgentClient("fake-director-id", server.URL(), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Ping()
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(pins.recorded).To(Equal([]string{fingerprint}))
+		Expect(pins.pinnedCalls).To(Equal(1))
+	})
+
+	It("returns an error when the pin cannot be loaded", func() {
+		pins.pinnedErr = errors.New("fake-pinned-err")
+
+		_, err := factory.NewAgentClient("fake-director-id", server.URL(), "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-pinned-err"))
+	})
+
+	It("returns an error when the CA certificate is invalid", func() {
+		_, err := factory.NewAgentClient("fake-director-id", server.URL(), "fake-ca-cert")
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,235 @@
+package agentclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+	"github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// defaultGetTaskTimeout bounds waiting for asynchronous actions, which
+// include compiling packages on the VM
+const defaultGetTaskTimeout = 30 * time.Minute
+
+// RawAgentClient sends arbitrary actions to an agent and returns the
+// responses as they were received, for debugging agents and CPIs.
+type RawAgentClient interface {
+	// SendAction waits up to the get task timeout for asynchronous actions
+	// to finish and returns the final 'get_task' response for them.
+	SendAction(action string, arguments []interface{}) ([]byte, error)
+
+	// SendActionWithTimeout is SendAction giving up on asynchronous actions
+	// still running after timeout, whose agent task is then cancelled.
+	SendActionWithTimeout(action string, arguments []interface{}, timeout time.Duration) ([]byte, error)
+}
+
+type RawAgentClientFactory interface {
+	NewRawAgentClient(directorID, mbusURL, caCert string) (RawAgentClient, error)
+}
+
+type rawAgentClientFactory struct {
+	getTaskDelay time.Duration
+	timeService  biretrier.Clock
+	logger       boshlog.Logger
+}
+
+func NewRawAgentClientFactory(getTaskDelay time.Duration, logger boshlog.Logger) RawAgentClientFactory {
+	return rawAgentClientFactory{
+		getTaskDelay: getTaskDelay,
+		timeService:  clock.NewClock(),
+		logger:       logger,
+	}
+}
+
+func (f rawAgentClientFactory) NewRawAgentClient(directorID, mbusURL, caCert string) (RawAgentClient, error) {
+	client := httpclient.DefaultClient
+
+	if caCert != "" {
+		caCertPool, err := crypto.CertPoolFromPEM([]byte(caCert))
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Parsing agent CA certificate")
+		}
+		client = httpclient.CreateDefaultClient(caCertPool)
+	}
+
+	return NewRawAgentClient(
+		mbusURL, directorID, f.getTaskDelay, defaultGetTaskTimeout, httpclient.NewHTTPClient(client, f.logger), f.timeService, f.logger), nil
+}
+
+type rawAgentClient struct {
+	endpoint       string
+	directorID     string
+	getTaskDelay   time.Duration
+	getTaskTimeout time.Duration
+	httpClient     *httpclient.HTTPClient
+	timeService    biretrier.Clock
+	logger         boshlog.Logger
+	logTag         string
+}
+
+func NewRawAgentClient(
+	mbusURL string,
+	directorID string,
+	getTaskDelay time.Duration,
+	getTaskTimeout time.Duration,
+	httpClient *httpclient.HTTPClient,
+	timeService biretrier.Clock,
+	logger boshlog.Logger,
+) RawAgentClient {
+	return rawAgentClient{
+		endpoint:       fmt.Sprintf("%s/agent", mbusURL),
+		directorID:     directorID,
+		getTaskDelay:   getTaskDelay,
+		getTaskTimeout: getTaskTimeout,
+		httpClient:     httpClient,
+		timeService:    timeService,
+		logger:         logger,
+		logTag:         "rawAgentClient",
+	}
+}
+
+type rawTaskResponse struct {
+	Value struct {
+		AgentTaskID string `json:"agent_task_id"`
+		State       string `json:"state"`
+	}
+}
+
+func (c rawAgentClient) SendAction(action string, arguments []interface{}) ([]byte, error) {
+	return c.sendAction(action, arguments, c.getTaskTimeout, false)
+}
+
+func (c rawAgentClient) SendActionWithTimeout(action string, arguments []interface{}, timeout time.Duration) ([]byte, error) {
+	return c.sendAction(action, arguments, timeout, true)
+}
+
+func (c rawAgentClient) sendAction(action string, arguments []interface{}, timeout time.Duration, cancelOnTimeout bool) ([]byte, error) {
+	if arguments == nil {
+		arguments = []interface{}{}
+	}
+
+	responseBody, err := c.send(action, arguments)
+	if err != nil {
+		return nil, err
+	}
+
+	agentTaskID, running := c.runningTask(responseBody)
+	if !running {
+		return responseBody, nil
+	}
+
+	c.logger.Debug(c.logTag, "Waiting for agent task '%s' of action '%s'", agentTaskID, action)
+
+	retrier := biretrier.NewRetrier(biretrier.Options{
+		Timeout: timeout,
+		Backoff: biretrier.NewConstantBackoff(c.getTaskDelay),
+	}, c.timeService, c.logger)
+
+	err = retrier.Try(func() (bool, error) {
+		responseBody, err = c.send("get_task", []interface{}{agentTaskID})
+		if err != nil {
+			return false, err
+		}
+
+		if _, running := c.runningTask(responseBody); running {
+			return true, bosherr.Errorf("Agent task '%s' is still running", agentTaskID)
+		}
+
+		return false, nil
+	})
+	if _, exhausted := err.(biretrier.ExhaustedError); exhausted && cancelOnTimeout {
+		return nil, c.cancelTask(action, agentTaskID, timeout)
+	}
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Waiting for agent task '%s' of action '%s'", agentTaskID, action)
+	}
+
+	return responseBody, nil
+}
+
+// cancelTask stops the agent task of an action that timed out, since
+// the agent keeps running it otherwise
+func (c rawAgentClient) cancelTask(action, agentTaskID string, timeout time.Duration) error {
+	c.logger.Debug(c.logTag, "Cancelling agent task '%s' of action '%s'", agentTaskID, action)
+
+	responseBody, err := c.send("cancel_task", []interface{}{agentTaskID})
+	if err == nil {
+		err = ResponseException(responseBody)
+	}
+	if err != nil {
+		return bosherr.Errorf("Timed out after %s waiting for agent task '%s' of action '%s', cancelling it failed: %s", timeout, agentTaskID, action, err.Error())
+	}
+
+	return bosherr.Errorf("Timed out after %s waiting for agent task '%s' of action '%s', cancelled it", timeout, agentTaskID, action)
+}
+
+type rawExceptionResponse struct {
+	Exception *struct {
+		Message string `json:"message"`
+	} `json:"exception"`
+}
+
+// ResponseException returns the exception an agent responded with as an
+// error, or nil for responses without one
+func ResponseException(responseBody []byte) error {
+	var response rawExceptionResponse
+
+	if json.Unmarshal(responseBody, &response) != nil || response.Exception == nil {
+		return nil
+	}
+
+	return bosherr.Errorf("Agent responded with error: %s", response.Exception.Message)
+}
+
+func (c rawAgentClient) runningTask(responseBody []byte) (string, bool) {
+	var response rawTaskResponse
+
+	// Responses of synchronous actions often have non-hash values
+	if json.Unmarshal(responseBody, &response) != nil {
+		return "", false
+	}
+
+	return response.Value.AgentTaskID, response.Value.AgentTaskID != "" && response.Value.State == "running"
+}
+
+func (c rawAgentClient) send(action string, arguments []interface{}) ([]byte, error) {
+	requestBody, err := json.Marshal(bihttpagent.AgentRequestMessage{
+		Method:    action,
+		Arguments: arguments,
+		ReplyTo:   c.directorID,
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshaling agent request")
+	}
+
+	httpResponse, err := c.httpClient.PostCustomized(c.endpoint, requestBody, func(r *http.Request) {
+		r.Header["Content-type"] = []string{"application/json"}
+	})
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Sending '%s' to the agent", action)
+	}
+
+	defer func() {
+		_ = httpResponse.Body.Close()
+	}()
+
+	responseBody, err := ioutil.ReadAll(httpResponse.Body)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Reading agent response to '%s'", action)
+	}
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, bosherr.Errorf("Agent responded to '%s' with non-successful status code: %d", action, httpResponse.StatusCode)
+	}
+
+	return responseBody, nil
+}
@@ -0,0 +1,169 @@
+package agentclient_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/ghttp"
+
+	. "github.com/cloudfoundry/bosh-cli/agentclient"
+	"github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+var _ = Describe("RawAgentClient", func() {
+	var (
+		server *ghttp.Server
+		client RawAgentClient
+	)
+
+	BeforeEach(func() {
+		server = ghttp.NewServer()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		httpClient := httpclient.NewHTTPClient(httpclient.DefaultClient, logger)
+		client = NewRawAgentClient(server.URL(), "fake-director-id", 0, 50*time.Millisecond, httpClient, clock.NewClock(), logger)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the raw response of synchronous actions", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyRequest("POST", "/agent"),
+			ghttp.VerifyJSON(`{"method":"list_disk","arguments":["fake-arg"],"reply_to":"fake-director-id"}`),
+			ghttp.RespondWith(http.StatusOK, `{"value":["fake-disk-cid"]}`),
+		))
+
+		response, err := client.SendAction("list_disk", []interface{}{"fake-arg"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(response)).To(Equal(`{"value":["fake-disk-cid"]}`))
+	})
+
+	It("sends an empty argument list when there are no arguments", func() {
+		server.AppendHandlers(ghttp.CombineHandlers(
+			ghttp.VerifyJSON(`{"method":"ping","arguments":[],"reply_to":"fake-director-id"}`),
+			ghttp.RespondWith(http.StatusOK, `{"value":"pong"}`),
+		))
+
+		response, err := client.SendAction("ping", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(response)).To(Equal(`{"value":"pong"}`))
+	})
+
+	It("waits for asynchronous actions and returns the final task response", func() {
+		server.AppendHandlers(
+			ghttp.CombineHandlers(
+				ghttp.VerifyJSON(`{"method":"stop","arguments":[],"reply_to":"fake-director-id"}`),
+				ghttp.RespondWith(http.StatusOK, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyJSON(`{"method":"get_task","arguments":["fake-task-id"],"reply_to":"fake-director-id"}`),
+				ghttp.RespondWith(http.StatusOK, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`),
+			),
+			ghttp.CombineHandlers(
+				ghttp.VerifyJSON(`{"method":"get_task","arguments":["fake-task-id"],"reply_to":"fake-director-id"}`),
+				ghttp.RespondWith(http.StatusOK, `{"value":"stopped"}`),
+			),
+		)
+
+		response, err := client.SendAction("stop", []interface{}{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(response)).To(Equal(`{"value":"stopped"}`))
+	})
+
+	Context("when an asynchronous action times out", func() {
+		var (
+			cancelResponse string
+			cancelRequests []string
+		)
+
+		BeforeEach(func() {
+			cancelResponse = `{"value":"canceled"}`
+			cancelRequests = nil
+
+			server.RouteToHandler("POST", "/agent", func(w http.ResponseWriter, r *http.Request) {
+				body, err := ioutil.ReadAll(r.Body)
+				Expect(err).ToNot(HaveOccurred())
+
+				if strings.Contains(string(body), `"cancel_task"`) {
+					cancelRequests = append(cancelRequests, string(body))
+					_, _ = w.Write([]byte(cancelResponse))
+					return
+				}
+
+				_, _ = w.Write([]byte(`{"value":{"agent_task_id":"fake-task-id","state":"running"}}`))
+			})
+		})
+
+		It("cancels the agent task", func() {
+			_, err := client.SendActionWithTimeout("run_script", []interface{}{"fake-script", map[string]interface{}{}}, 50*time.Millisecond)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Timed out after 50ms waiting for agent task 'fake-task-id' of action 'run_script', cancelled it"))
+
+			Expect(cancelRequests).To(HaveLen(1))
+			Expect(cancelRequests[0]).To(MatchJSON(`{"method":"cancel_task","arguments":["fake-task-id"],"reply_to":"fake-director-id"}`))
+		})
+
+		It("reports when the agent does not cancel the task", func() {
+			cancelResponse = `{"exception":{"message":"not supported"}}`
+
+			_, err := client.SendActionWithTimeout("run_script", nil, 50*time.Millisecond)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Timed out after 50ms waiting for agent task 'fake-task-id' of action 'run_script', cancelling it failed: Agent responded with error: not supported"))
+		})
+	})
+
+	It("gives up waiting for asynchronous actions after the get task timeout", func() {
+		server.RouteToHandler("POST", "/agent", ghttp.RespondWith(http.StatusOK, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`))
+
+		_, err := client.SendAction("stop", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Waiting for agent task 'fake-task-id' of action 'stop': Giving up after"))
+		Expect(err.Error()).To(ContainSubstring("Agent task 'fake-task-id' is still running"))
+	})
+
+	It("names the agent task when polling it fails", func() {
+		server.AppendHandlers(
+			ghttp.RespondWith(http.StatusOK, `{"value":{"agent_task_id":"fake-task-id","state":"running"}}`),
+			ghttp.RespondWith(http.StatusInternalServerError, ""),
+		)
+
+		_, err := client.SendAction("stop", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Waiting for agent task 'fake-task-id' of action 'stop': Agent responded to 'get_task' with non-successful status code: 500"))
+	})
+
+	It("returns agent exceptions as part of the response", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusOK, `{"exception":{"message":"unknown message"}}`))
+
+		response, err := client.SendAction("fake-action", nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(response)).To(Equal(`{"exception":{"message":"unknown message"}}`))
+	})
+
+	It("returns an error when the agent responds with a non-successful status", func() {
+		server.AppendHandlers(ghttp.RespondWith(http.StatusUnauthorized, ""))
+
+		_, err := client.SendAction("ping", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Agent responded to 'ping' with non-successful status code: 401"))
+	})
+
+	Describe("ResponseException", func() {
+		It("returns the exception of the response as an error", func() {
+			err := ResponseException([]byte(`{"exception":{"message":"fake-message"}}`))
+			Expect(err).To(MatchError("Agent responded with error: fake-message"))
+		})
+
+		It("returns nil for responses without an exception", func() {
+			Expect(ResponseException([]byte(`{"value":"pong"}`))).To(BeNil())
+			Expect(ResponseException([]byte(`{"value":["fake-disk-cid"]}`))).To(BeNil())
+		})
+	})
+})
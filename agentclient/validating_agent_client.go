@@ -0,0 +1,273 @@
+package agentclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// agentResponseSchema is what the CLI expects in the responses of agents
+// speaking one version of the agent API.
+type agentResponseSchema struct {
+	jobStates map[string]struct{}
+}
+
+// legacyAgentAPIVersion stands for agents that predate reporting their API
+// version as bosh_protocol in get_state.
+const legacyAgentAPIVersion = ""
+
+// latestAgentAPIVersion is also used for agents newer than the CLI knows,
+// whose responses are reported as errors naming their version.
+const latestAgentAPIVersion = "1"
+
+var agentResponseSchemas = map[string]agentResponseSchema{
+	legacyAgentAPIVersion: {
+		jobStates: map[string]struct{}{
+			"running": {},
+			"failing": {},
+			"stopped": {},
+			"unknown": {},
+		},
+	},
+	latestAgentAPIVersion: {
+		jobStates: map[string]struct{}{
+			"running":  {},
+			"failing":  {},
+			"starting": {},
+			"stopped":  {},
+			"unknown":  {},
+		},
+	},
+}
+
+type validatingAgentClientFactory struct {
+	factory    bihttpagent.AgentClientFactory
+	rawFactory RawAgentClientFactory
+}
+
+// NewValidatingAgentClientFactory wraps the agent clients created by factory
+// so that responses the CLI does not understand (e.g. from a much newer or older
+// agent) are reported as errors instead of panicking or being silently accepted.
+// rawFactory is used to ask agents for the version of the API they speak.
+func NewValidatingAgentClientFactory(factory bihttpagent.AgentClientFactory, rawFactory RawAgentClientFactory) bihttpagent.AgentClientFactory {
+	return validatingAgentClientFactory{factory: factory, rawFactory: rawFactory}
+}
+
+func (f validatingAgentClientFactory) NewAgentClient(directorID, mbusURL, caCert string) (biagentclient.AgentClient, error) {
+	client, err := f.factory.NewAgentClient(directorID, mbusURL, caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	rawClient, err := f.rawFactory.NewRawAgentClient(directorID, mbusURL, caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewValidatingAgentClient(client, rawClient), nil
+}
+
+type validatingAgentClient struct {
+	client    biagentclient.AgentClient
+	rawClient RawAgentClient
+
+	versionLock sync.Mutex
+	version     *string
+}
+
+func NewValidatingAgentClient(client biagentclient.AgentClient, rawClient RawAgentClient) biagentclient.AgentClient {
+	return &validatingAgentClient{client: client, rawClient: rawClient}
+}
+
+func (c *validatingAgentClient) Ping() (response string, err error) {
+	defer recoverUnexpectedResponse("ping", &err)
+
+	response, err = c.client.Ping()
+	if err != nil {
+		return "", err
+	}
+
+	if response != "pong" {
+		return "", unexpectedResponseError("ping", "expected 'pong' but got '%s'", response)
+	}
+
+	return response, nil
+}
+
+func (c *validatingAgentClient) Stop() (err error) {
+	defer recoverUnexpectedResponse("stop", &err)
+	return c.client.Stop()
+}
+
+func (c *validatingAgentClient) Drain(drainType string) (value int64, err error) {
+	defer recoverUnexpectedResponse("drain", &err)
+	return c.client.Drain(drainType)
+}
+
+func (c *validatingAgentClient) Apply(spec bias.ApplySpec) (err error) {
+	defer recoverUnexpectedResponse("apply", &err)
+	return c.client.Apply(spec)
+}
+
+func (c *validatingAgentClient) Start() (err error) {
+	defer recoverUnexpectedResponse("start", &err)
+	return c.client.Start()
+}
+
+func (c *validatingAgentClient) GetState() (state biagentclient.AgentState, err error) {
+	defer recoverUnexpectedResponse("get_state", &err)
+
+	state, err = c.client.GetState()
+	if err != nil {
+		return biagentclient.AgentState{}, err
+	}
+
+	version, schema, err := c.schema()
+	if err != nil {
+		return biagentclient.AgentState{}, err
+	}
+
+	if _, found := schema.jobStates[state.JobState]; !found {
+		return biagentclient.AgentState{}, unexpectedVersionResponseError("get_state", version, "unknown job_state '%s'", state.JobState)
+	}
+
+	for name, networkSpec := range state.NetworkSpecs {
+		if networkSpec.IP == "" {
+			return biagentclient.AgentState{}, unexpectedVersionResponseError("get_state", version, "network '%s' is missing an ip", name)
+		}
+	}
+
+	return state, nil
+}
+
+func (c *validatingAgentClient) MountDisk(diskCID string) (err error) {
+	defer recoverUnexpectedResponse("mount_disk", &err)
+	return c.client.MountDisk(diskCID)
+}
+
+func (c *validatingAgentClient) UnmountDisk(diskCID string) (err error) {
+	defer recoverUnexpectedResponse("unmount_disk", &err)
+	return c.client.UnmountDisk(diskCID)
+}
+
+func (c *validatingAgentClient) ListDisk() (diskCIDs []string, err error) {
+	defer recoverUnexpectedResponse("list_disk", &err)
+	return c.client.ListDisk()
+}
+
+func (c *validatingAgentClient) MigrateDisk() (err error) {
+	defer recoverUnexpectedResponse("migrate_disk", &err)
+	return c.client.MigrateDisk()
+}
+
+func (c *validatingAgentClient) CompilePackage(packageSource biagentclient.BlobRef, compiledPackageDependencies []biagentclient.BlobRef) (compiledPackageRef biagentclient.BlobRef, err error) {
+	defer recoverUnexpectedResponse("compile_package", &err)
+
+	compiledPackageRef, err = c.client.CompilePackage(packageSource, compiledPackageDependencies)
+	if err != nil {
+		return biagentclient.BlobRef{}, err
+	}
+
+	if compiledPackageRef.BlobstoreID == "" || compiledPackageRef.SHA1 == "" {
+		return biagentclient.BlobRef{}, unexpectedResponseError("compile_package", "missing blobstore_id or sha1 for package '%s'", packageSource.Name)
+	}
+
+	return compiledPackageRef, nil
+}
+
+func (c *validatingAgentClient) DeleteARPEntries(ips []string) (err error) {
+	defer recoverUnexpectedResponse("delete_arp_entries", &err)
+	return c.client.DeleteARPEntries(ips)
+}
+
+func (c *validatingAgentClient) SyncDNS(blobID, sha1 string, version uint64) (value string, err error) {
+	defer recoverUnexpectedResponse("sync_dns", &err)
+	return c.client.SyncDNS(blobID, sha1, version)
+}
+
+func (c *validatingAgentClient) RunScript(scriptName string, options map[string]interface{}) (err error) {
+	defer recoverUnexpectedResponse("run_script", &err)
+	return c.client.RunScript(scriptName, options)
+}
+
+// schema returns the response schema of the agent API version the agent
+// reports in get_state, asking the agent only once.
+func (c *validatingAgentClient) schema() (string, agentResponseSchema, error) {
+	c.versionLock.Lock()
+	defer c.versionLock.Unlock()
+
+	if c.version == nil {
+		version, err := c.fetchVersion()
+		if err != nil {
+			return "", agentResponseSchema{}, err
+		}
+		c.version = &version
+	}
+
+	if schema, found := agentResponseSchemas[*c.version]; found {
+		return *c.version, schema, nil
+	}
+
+	return *c.version, agentResponseSchemas[latestAgentAPIVersion], nil
+}
+
+func (c *validatingAgentClient) fetchVersion() (string, error) {
+	responseBody, err := c.rawClient.SendAction("get_state", nil)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Getting the agent API version")
+	}
+
+	var response struct {
+		Value struct {
+			BoshProtocol interface{} `json:"bosh_protocol"`
+		}
+	}
+
+	err = json.Unmarshal(responseBody, &response)
+	if err != nil {
+		return "", unexpectedResponseError("get_state", "%s", err)
+	}
+
+	switch protocol := response.Value.BoshProtocol.(type) {
+	case nil:
+		return legacyAgentAPIVersion, nil
+	case string:
+		return protocol, nil
+	case float64:
+		return fmt.Sprintf("%g", protocol), nil
+	default:
+		return "", unexpectedResponseError("get_state", "unknown bosh_protocol '%v'", protocol)
+	}
+}
+
+// recoverUnexpectedResponse converts panics raised while decoding agent responses
+// (e.g. failed type assertions on a changed response format) into errors.
+func recoverUnexpectedResponse(method string, err *error) {
+	if r := recover(); r != nil {
+		*err = unexpectedResponseError(method, "%v", r)
+	}
+}
+
+func unexpectedVersionResponseError(method string, version string, msg string, args ...interface{}) error {
+	agent := fmt.Sprintf("Agent of API version '%s'", version)
+	if version == legacyAgentAPIVersion {
+		agent = "Agent predating API versions"
+	}
+
+	return bosherr.Errorf(
+		"%s returned an unexpected '%s' response, the agent may be running an incompatible version: %s",
+		agent, method, fmt.Sprintf(msg, args...),
+	)
+}
+
+func unexpectedResponseError(method string, msg string, args ...interface{}) error {
+	return bosherr.Errorf(
+		"Agent returned an unexpected '%s' response, the agent may be running an incompatible version: %s",
+		method, fmt.Sprintf(msg, args...),
+	)
+}
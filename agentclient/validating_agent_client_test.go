@@ -0,0 +1,180 @@
+package agentclient_test
+
+import (
+	"errors"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	fakeagentclient "github.com/cloudfoundry/bosh-agent/agentclient/fakes"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mock_httpagent "github.com/cloudfoundry/bosh-agent/agentclient/http/mocks"
+	. "github.com/cloudfoundry/bosh-cli/agentclient"
+	fakebiagentclient "github.com/cloudfoundry/bosh-cli/agentclient/fakes"
+)
+
+var _ = Describe("ValidatingAgentClient", func() {
+	var (
+		fakeAgentClient    *fakeagentclient.FakeAgentClient
+		fakeRawAgentClient *fakebiagentclient.FakeRawAgentClient
+		client             biagentclient.AgentClient
+	)
+
+	BeforeEach(func() {
+		fakeAgentClient = &fakeagentclient.FakeAgentClient{}
+		fakeRawAgentClient = fakebiagentclient.NewFakeRawAgentClient()
+		fakeRawAgentClient.SendActionResponse = []byte(`{"value":{"bosh_protocol":"1","job_state":"running"}}`)
+		client = NewValidatingAgentClient(fakeAgentClient, fakeRawAgentClient)
+	})
+
+	Describe("Ping", func() {
+		It("returns the response from the agent", func() {
+			fakeAgentClient.PingReturns("pong", nil)
+
+			response, err := client.Ping()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(response).To(Equal("pong"))
+		})
+
+		It("returns an error for unexpected responses", func() {
+			fakeAgentClient.PingReturns("fake-response", nil)
+
+			_, err := client.Ping()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Agent returned an unexpected 'ping' response, the agent may be running an incompatible version: expected 'pong' but got 'fake-response'"))
+		})
+
+		It("returns errors from the agent", func() {
+			fakeAgentClient.PingReturns("", errors.New("fake-ping-error"))
+
+			_, err := client.Ping()
+			Expect(err).To(MatchError("fake-ping-error"))
+		})
+	})
+
+	Describe("GetState", func() {
+		It("returns known states", func() {
+			state := biagentclient.AgentState{
+				JobState:     "running",
+				NetworkSpecs: map[string]biagentclient.NetworkSpec{"fake-network": {IP: "10.0.0.6"}},
+			}
+			fakeAgentClient.GetStateReturns(state, nil)
+
+			Expect(client.GetState()).To(Equal(state))
+		})
+
+		It("asks the agent for its API version only once", func() {
+			fakeAgentClient.GetStateReturns(biagentclient.AgentState{JobState: "running"}, nil)
+
+			_, err := client.GetState()
+			Expect(err).ToNot(HaveOccurred())
+			_, err = client.GetState()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeRawAgentClient.SendActionInputs).To(Equal([]fakebiagentclient.SendActionInput{
+				{Action: "get_state"},
+			}))
+		})
+
+		It("returns an error for unknown job states", func() {
+			fakeAgentClient.GetStateReturns(biagentclient.AgentState{}, nil)
+
+			_, err := client.GetState()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Agent of API version '1' returned an unexpected 'get_state' response, the agent may be running an incompatible version: unknown job_state ''"))
+		})
+
+		It("validates against the schema of agents predating API versions", func() {
+			fakeRawAgentClient.SendActionResponse = []byte(`{"value":{"job_state":"running"}}`)
+			fakeAgentClient.GetStateReturns(biagentclient.AgentState{JobState: "starting"}, nil)
+
+			_, err := client.GetState()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Agent predating API versions returned an unexpected 'get_state' response"))
+			Expect(err.Error()).To(ContainSubstring("unknown job_state 'starting'"))
+		})
+
+		It("names the version of agents newer than the CLI knows", func() {
+			fakeRawAgentClient.SendActionResponse = []byte(`{"value":{"bosh_protocol":2}}`)
+			fakeAgentClient.GetStateReturns(biagentclient.AgentState{JobState: "fake-state"}, nil)
+
+			_, err := client.GetState()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Agent of API version '2' returned an unexpected 'get_state' response"))
+		})
+
+		It("returns an error if the API version cannot be fetched", func() {
+			fakeRawAgentClient.SendActionErr = errors.New("fake-send-err")
+			fakeAgentClient.GetStateReturns(biagentclient.AgentState{JobState: "running"}, nil)
+
+			_, err := client.GetState()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Getting the agent API version"))
+			Expect(err.Error()).To(ContainSubstring("fake-send-err"))
+		})
+
+		It("returns an error when a network has no ip", func() {
+			fakeAgentClient.GetStateReturns(biagentclient.AgentState{
+				JobState:     "running",
+				NetworkSpecs: map[string]biagentclient.NetworkSpec{"fake-network": {}},
+			}, nil)
+
+			_, err := client.GetState()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("network 'fake-network' is missing an ip"))
+		})
+	})
+
+	Describe("CompilePackage", func() {
+		It("returns an error when the compiled package reference is incomplete", func() {
+			fakeAgentClient.CompilePackageReturns(biagentclient.BlobRef{BlobstoreID: "fake-blob-id"}, nil)
+
+			_, err := client.CompilePackage(biagentclient.BlobRef{Name: "fake-package"}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing blobstore_id or sha1 for package 'fake-package'"))
+		})
+	})
+
+	It("converts panics while decoding responses into errors", func() {
+		fakeAgentClient.StartStub = func() error {
+			var value interface{} = 42
+			_ = value.(string)
+			return nil
+		}
+
+		err := client.Start()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Agent returned an unexpected 'start' response"))
+		Expect(err.Error()).To(ContainSubstring("interface {} is int, not string"))
+	})
+
+	It("passes other calls through", func() {
+		fakeAgentClient.ListDiskReturns([]string{"fake-disk-cid"}, nil)
+
+		Expect(client.ListDisk()).To(Equal([]string{"fake-disk-cid"}))
+		Expect(client.MountDisk("fake-disk-cid")).To(Succeed())
+		Expect(fakeAgentClient.MountDiskArgsForCall(0)).To(Equal("fake-disk-cid"))
+	})
+})
+
+var _ = Describe("ValidatingAgentClientFactory", func() {
+	It("wraps clients created by the underlying factory", func() {
+		mockCtrl := gomock.NewController(GinkgoT())
+		defer mockCtrl.Finish()
+
+		fakeAgentClient := &fakeagentclient.FakeAgentClient{}
+		fakeAgentClient.PingReturns("fake-response", nil)
+
+		mockFactory := mock_httpagent.NewMockAgentClientFactory(mockCtrl)
+		mockFactory.EXPECT().NewAgentClient("fake-director-id", "fake-mbus-url", "fake-ca").Return(fakeAgentClient, nil)
+
+		fakeRawAgentClientFactory := fakebiagentclient.NewFakeRawAgentClientFactory(fakebiagentclient.NewFakeRawAgentClient())
+
+		client, err := NewValidatingAgentClientFactory(mockFactory, fakeRawAgentClientFactory).NewAgentClient("fake-director-id", "fake-mbus-url", "fake-ca")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = client.Ping()
+		Expect(err).To(HaveOccurred())
+	})
+})
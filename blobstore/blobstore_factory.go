@@ -11,15 +11,23 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 )
 
 type Factory interface {
+	// Create returns the blobstore the agent serves at '<mbus URL>/blobs'
 	Create(string, *http.Client) (Blobstore, error)
+	// CreateDav returns a dav blobstore keeping blobs at its endpoint, as
+	// the agent does when it is configured with the same blobstore
+	CreateDav(Config, *http.Client) (Blobstore, error)
 }
 
 type blobstoreFactory struct {
 	uuidGenerator boshuuid.Generator
 	fs            boshsys.FileSystem
+	retryOptions  RetryOptions
+	timeService   biretrier.Clock
 	logger        boshlog.Logger
 }
 
@@ -31,19 +39,49 @@ func NewBlobstoreFactory(uuidGenerator boshuuid.Generator, fs boshsys.FileSystem
 	}
 }
 
+// NewBlobstoreFactoryWithRetries creates blobstores retrying failed
+// uploads and downloads according to retryOptions
+func NewBlobstoreFactoryWithRetries(
+	uuidGenerator boshuuid.Generator,
+	fs boshsys.FileSystem,
+	retryOptions RetryOptions,
+	timeService biretrier.Clock,
+	logger boshlog.Logger,
+) Factory {
+	return blobstoreFactory{
+		uuidGenerator: uuidGenerator,
+		fs:            fs,
+		retryOptions:  retryOptions,
+		timeService:   timeService,
+		logger:        logger,
+	}
+}
+
 func (f blobstoreFactory) Create(blobstoreURL string, httpClient *http.Client) (Blobstore, error) {
 	blobstoreConfig, err := f.parseBlobstoreURL(blobstoreURL)
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Creating blobstore config")
 	}
 
+	blobstoreConfig.Endpoint = fmt.Sprintf("%s/blobs", blobstoreConfig.Endpoint)
+
+	return f.CreateDav(blobstoreConfig, httpClient)
+}
+
+func (f blobstoreFactory) CreateDav(blobstoreConfig Config, httpClient *http.Client) (Blobstore, error) {
 	davClient := boshdavcli.NewClient(boshdavcliconf.Config{
-		Endpoint: fmt.Sprintf("%s/blobs", blobstoreConfig.Endpoint),
+		Endpoint: blobstoreConfig.Endpoint,
 		User:     blobstoreConfig.Username,
 		Password: blobstoreConfig.Password,
 	}, httpClient, f.logger)
 
-	return NewBlobstore(davClient, f.uuidGenerator, f.fs, f.logger), nil
+	blobstore := NewBlobstore(davClient, f.uuidGenerator, f.fs, f.logger)
+
+	if f.retryOptions.MaxAttempts > 1 {
+		blobstore = NewRetryableBlobstore(blobstore, f.retryOptions, f.timeService, f.logger)
+	}
+
+	return blobstore, nil
 }
 
 func (f blobstoreFactory) parseBlobstoreURL(blobstoreURL string) (Config, error) {
@@ -64,4 +64,23 @@ var _ = Describe("BlobstoreFactory", func() {
 			})
 		})
 	})
+
+	Describe("CreateDav", func() {
+		It("returns the blobstore keeping blobs at the endpoint", func() {
+			blobstore, err := blobstoreFactory.CreateDav(Config{
+				Endpoint: "http://10.0.0.6:25250",
+				Username: "fake-user",
+				Password: "fake-password",
+			}, httpClient)
+			Expect(err).ToNot(HaveOccurred())
+
+			davClient := boshdavcli.NewClient(boshdavcliconf.Config{
+				Endpoint: "http://10.0.0.6:25250",
+				User:     "fake-user",
+				Password: "fake-password",
+			}, httpClient, logger)
+			expectedBlobstore := NewBlobstore(davClient, fakeUUIDGenerator, fs, logger)
+			Expect(blobstore).To(Equal(expectedBlobstore))
+		})
+	})
 })
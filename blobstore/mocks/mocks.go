@@ -47,6 +47,19 @@ func (mr *MockFactoryMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockFactory)(nil).Create), arg0, arg1)
 }
 
+// CreateDav mocks base method
+func (m *MockFactory) CreateDav(arg0 blobstore.Config, arg1 *http.Client) (blobstore.Blobstore, error) {
+	ret := m.ctrl.Call(m, "CreateDav", arg0, arg1)
+	ret0, _ := ret[0].(blobstore.Blobstore)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDav indicates an expected call of CreateDav
+func (mr *MockFactoryMockRecorder) CreateDav(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDav", reflect.TypeOf((*MockFactory)(nil).CreateDav), arg0, arg1)
+}
+
 // MockBlobstore is a mock of Blobstore interface
 type MockBlobstore struct {
 	ctrl     *gomock.Controller
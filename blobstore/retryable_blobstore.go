@@ -0,0 +1,59 @@
+package blobstore
+
+import (
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// RetryOptions configure how often blobstore calls are attempted
+type RetryOptions struct {
+	MaxAttempts int
+	Backoff     biretrier.Backoff
+}
+
+type retryableBlobstore struct {
+	blobstore Blobstore
+	retrier   biretrier.Retrier
+}
+
+// NewRetryableBlobstore retries failed uploads and downloads, which are
+// interrupted more easily by flaky networks than the other agent requests
+func NewRetryableBlobstore(blobstore Blobstore, opts RetryOptions, timeService biretrier.Clock, logger boshlog.Logger) Blobstore {
+	return retryableBlobstore{
+		blobstore: blobstore,
+		retrier: biretrier.NewRetrier(biretrier.Options{
+			MaxAttempts: opts.MaxAttempts,
+			Backoff:     opts.Backoff,
+		}, timeService, logger),
+	}
+}
+
+func (b retryableBlobstore) Get(blobID string) (LocalBlob, error) {
+	var localBlob LocalBlob
+
+	err := b.retrier.Try(func() (bool, error) {
+		var err error
+		localBlob, err = b.blobstore.Get(blobID)
+		return err != nil, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return localBlob, nil
+}
+
+func (b retryableBlobstore) Add(sourcePath string) (string, error) {
+	var blobID string
+
+	err := b.retrier.Try(func() (bool, error) {
+		var err error
+		blobID, err = b.blobstore.Add(sourcePath)
+		return err != nil, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return blobID, nil
+}
@@ -0,0 +1,83 @@
+package blobstore_test
+
+import (
+	"errors"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/blobstore"
+	mock_blobstore "github.com/cloudfoundry/bosh-cli/blobstore/mocks"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+type sleepRecordingClock struct {
+	sleeps []time.Duration
+}
+
+func (c *sleepRecordingClock) Sleep(d time.Duration) { c.sleeps = append(c.sleeps, d) }
+func (c *sleepRecordingClock) Now() time.Time        { return time.Time{} }
+
+var _ = Describe("RetryableBlobstore", func() {
+	var (
+		mockCtrl      *gomock.Controller
+		mockBlobstore *mock_blobstore.MockBlobstore
+		timeService   *sleepRecordingClock
+		blobstore     Blobstore
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
+		timeService = &sleepRecordingClock{}
+
+		blobstore = NewRetryableBlobstore(mockBlobstore, RetryOptions{
+			MaxAttempts: 3,
+			Backoff:     biretrier.NewConstantBackoff(time.Second),
+		}, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Add", func() {
+		It("retries failed uploads", func() {
+			gomock.InOrder(
+				mockBlobstore.EXPECT().Add("fake-source-path").Return("", errors.New("fake-add-error")),
+				mockBlobstore.EXPECT().Add("fake-source-path").Return("fake-blob-id", nil),
+			)
+
+			blobID, err := blobstore.Add("fake-source-path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(blobID).To(Equal("fake-blob-id"))
+
+			Expect(timeService.sleeps).To(Equal([]time.Duration{time.Second}))
+		})
+
+		It("returns an error after the last attempt fails", func() {
+			mockBlobstore.EXPECT().Add("fake-source-path").Return("", errors.New("fake-add-error")).Times(3)
+
+			_, err := blobstore.Add("fake-source-path")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Giving up after 3 attempts: fake-add-error"))
+		})
+	})
+
+	Describe("Get", func() {
+		It("retries failed downloads", func() {
+			gomock.InOrder(
+				mockBlobstore.EXPECT().Get("fake-blob-id").Return(nil, errors.New("fake-get-error")),
+				mockBlobstore.EXPECT().Get("fake-blob-id").Return(nil, nil),
+			)
+
+			_, err := blobstore.Get("fake-blob-id")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(timeService.sleeps).To(HaveLen(1))
+		})
+	})
+})
@@ -9,9 +9,11 @@ import (
 )
 
 type Cloud interface {
+	Info() (Info, error)
 	CreateStemcell(imagePath string, cloudProperties biproperty.Map) (stemcellCID string, err error)
 	DeleteStemcell(stemcellCID string) error
 	HasVM(vmCID string) (bool, error)
+	HasDisk(diskCID string) (bool, error)
 	CreateVM(
 		agentID string,
 		stemcellCID string,
@@ -44,15 +46,39 @@ func NewCloud(
 	cpiCmdRunner CPICmdRunner,
 	directorID string,
 	logger boshlog.Logger,
+) Cloud {
+	return NewCloudWithProperties(cpiCmdRunner, directorID, nil, logger)
+}
+
+// NewCloudWithProperties sends cpiProperties in the context of every CPI call
+func NewCloudWithProperties(
+	cpiCmdRunner CPICmdRunner,
+	directorID string,
+	cpiProperties biproperty.Map,
+	logger boshlog.Logger,
 ) Cloud {
 	return cloud{
 		cpiCmdRunner: cpiCmdRunner,
-		context:      CmdContext{DirectorID: directorID},
+		context:      CmdContext{DirectorID: directorID, Properties: cpiProperties},
 		logger:       logger,
 		logTag:       "cloud",
 	}
 }
 
+func (c cloud) Info() (Info, error) {
+	method := "info"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method)
+	if err != nil {
+		return Info{}, bosherr.WrapError(err, "Calling CPI 'info' method")
+	}
+
+	if cmdOutput.Error != nil {
+		return Info{}, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	return newInfo(cmdOutput.Result)
+}
+
 func (c cloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
 	c.logger.Debug(c.logTag, "Creating stemcell")
 
@@ -108,6 +134,24 @@ func (c cloud) HasVM(vmCID string) (bool, error) {
 	return found, nil
 }
 
+func (c cloud) HasDisk(diskCID string) (bool, error) {
+	method := "has_disk"
+	cmdOutput, err := c.cpiCmdRunner.Run(c.context, method, diskCID)
+	if err != nil {
+		return false, err
+	}
+
+	if cmdOutput.Error != nil {
+		return false, NewCPIError(method, *cmdOutput.Error)
+	}
+
+	found, ok := cmdOutput.Result.(bool)
+	if !ok {
+		return false, bosherr.Errorf("Unexpected external CPI command result: '%#v'", cmdOutput.Result)
+	}
+	return found, nil
+}
+
 func (c cloud) CreateVM(
 	agentID string,
 	stemcellCID string,
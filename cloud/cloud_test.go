@@ -49,6 +49,110 @@ var _ = Describe("Cloud", func() {
 		})
 	}
 
+	Describe("Info", func() {
+		It("returns the stemcell formats and cloud_properties schema of the cpi", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: map[string]interface{}{
+					"stemcell_formats": []interface{}{"aws-raw"},
+					"cloud_properties_schema": map[string]interface{}{
+						"vm": map[string]interface{}{
+							"type":                 "object",
+							"required":             []interface{}{"instance_type"},
+							"additionalProperties": false,
+							"properties": map[string]interface{}{
+								"instance_type": map[string]interface{}{"type": "string"},
+							},
+						},
+					},
+				},
+			}
+
+			info, err := cloud.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeCPICmdRunner.RunInputs).To(Equal([]fakebicloud.RunInput{
+				{Context: context, Method: "info"},
+			}))
+
+			additionalProperties := false
+			Expect(info).To(Equal(Info{
+				StemcellFormats: []string{"aws-raw"},
+				CloudPropertiesSchema: CloudPropertiesSchema{
+					VM: PropertiesSchema{
+						Type:                 "object",
+						Required:             []string{"instance_type"},
+						AdditionalProperties: &additionalProperties,
+						Properties: map[string]PropertiesSchema{
+							"instance_type": {Type: "string"},
+						},
+					},
+				},
+			}))
+			Expect(info.CloudPropertiesSchema.IsEmpty()).To(BeFalse())
+		})
+
+		It("returns an empty schema when the cpi does not publish one", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: map[string]interface{}{"stemcell_formats": []interface{}{"aws-raw"}},
+			}
+
+			info, err := cloud.Info()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(info.CloudPropertiesSchema.IsEmpty()).To(BeTrue())
+		})
+
+		It("returns the quotas published by the cpi", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: map[string]interface{}{
+					"quotas": map[string]interface{}{
+						"instances": map[string]interface{}{"limit": 20, "used": 18},
+						"disk_gb":   map[string]interface{}{"used": 100},
+					},
+				},
+			}
+
+			info, err := cloud.Info()
+			Expect(err).NotTo(HaveOccurred())
+
+			available, limited := info.Quotas.Instances.Available()
+			Expect(limited).To(BeTrue())
+			Expect(available).To(Equal(2))
+
+			_, limited = info.Quotas.DiskGB.Available()
+			Expect(limited).To(BeFalse())
+		})
+
+		Context("when the result is of an unexpected type", func() {
+			BeforeEach(func() {
+				fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+					Result: "fake-info",
+				}
+			})
+
+			It("returns an error", func() {
+				_, err := cloud.Info()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Unexpected external CPI command result: '\"fake-info\"'"))
+			})
+		})
+
+		Context("when the cpi command execution fails", func() {
+			BeforeEach(func() {
+				fakeCPICmdRunner.RunErr = errors.New("fake-run-error")
+			})
+
+			It("returns an error", func() {
+				_, err := cloud.Info()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-run-error"))
+			})
+		})
+
+		itHandlesCPIErrors("info", func() error {
+			_, err := cloud.Info()
+			return err
+		})
+	})
+
 	Describe("CreateStemcell", func() {
 		var (
 			stemcellImagePath string
@@ -200,6 +304,41 @@ var _ = Describe("Cloud", func() {
 		})
 	})
 
+	Describe("HasDisk", func() {
+		It("return true when disk exists", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: true,
+			}
+
+			found, err := cloud.HasDisk("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			Expect(fakeCPICmdRunner.RunInputs).To(Equal([]fakebicloud.RunInput{
+				{
+					Context:   context,
+					Method:    "has_disk",
+					Arguments: []interface{}{"fake-disk-cid"},
+				},
+			}))
+		})
+
+		It("return false when disk does not exist", func() {
+			fakeCPICmdRunner.RunCmdOutput = CmdOutput{
+				Result: false,
+			}
+
+			found, err := cloud.HasDisk("fake-disk-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		itHandlesCPIErrors("has_disk", func() error {
+			_, err := cloud.HasDisk("fake-disk-cid")
+			return err
+		})
+	})
+
 	Describe("CreateVM", func() {
 		var (
 			agentID           string
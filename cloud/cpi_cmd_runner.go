@@ -4,10 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biplatform "github.com/cloudfoundry/bosh-cli/common/platform"
 )
 
 type CmdInput struct {
@@ -18,10 +22,25 @@ type CmdInput struct {
 
 type CmdContext struct {
 	DirectorID string `json:"director_uuid"`
+
+	// Properties are merged into the context next to director_uuid,
+	// which is how CPIs receive the properties of a cloud_provider.cpis entry
+	Properties biproperty.Map `json:"-"`
 }
 
+func (c CmdContext) MarshalJSON() ([]byte, error) {
+	context := map[string]interface{}{}
+	for key, value := range c.Properties {
+		context[key] = value
+	}
+	context["director_uuid"] = c.DirectorID
+
+	return json.Marshal(context)
+}
+
+// String leaves out the properties since they usually contain credentials
 func (c CmdContext) String() string {
-	bytes, err := json.Marshal(c)
+	bytes, err := json.Marshal(CmdContext{DirectorID: c.DirectorID})
 	if err != nil {
 		panic(fmt.Sprintf("Error stringifying CmdContext %#v: %s", c, err.Error()))
 	}
@@ -55,6 +74,7 @@ type CPICmdRunner interface {
 type cpiCmdRunner struct {
 	cmdRunner boshsys.CmdRunner
 	cpi       CPI
+	watchdog  Watchdog
 	logger    boshlog.Logger
 	logTag    string
 }
@@ -72,6 +92,23 @@ func NewCPICmdRunner(
 	}
 }
 
+// NewCPICmdRunnerWithWatchdog warns through the watchdog about CPI calls
+// that have not written any output for a while
+func NewCPICmdRunnerWithWatchdog(
+	cmdRunner boshsys.CmdRunner,
+	cpi CPI,
+	watchdog Watchdog,
+	logger boshlog.Logger,
+) CPICmdRunner {
+	return &cpiCmdRunner{
+		cmdRunner: cmdRunner,
+		cpi:       cpi,
+		watchdog:  watchdog,
+		logger:    logger,
+		logTag:    "cpiCmdRunner",
+	}
+}
+
 func (r *cpiCmdRunner) Run(context CmdContext, method string, args ...interface{}) (CmdOutput, error) {
 	cmdInput := CmdInput{
 		Method:    method,
@@ -84,17 +121,20 @@ func (r *cpiCmdRunner) Run(context CmdContext, method string, args ...interface{
 	}
 
 	cmdPath := r.cpi.ExecutablePath()
+
+	env := biplatform.IsolatedEnv("/usr/local/bin:/usr/bin:/bin:/sbin")
+	env["BOSH_PACKAGES_DIR"] = r.cpi.PackagesDir
+	env["BOSH_JOBS_DIR"] = r.cpi.JobsDir
+
+	cmdName, cmdArgs := biplatform.ScriptCommand(cmdPath)
 	cmd := boshsys.Command{
-		Name: cmdPath,
-		Env: map[string]string{
-			"BOSH_PACKAGES_DIR": r.cpi.PackagesDir,
-			"BOSH_JOBS_DIR":     r.cpi.JobsDir,
-			"PATH":              "/usr/local/bin:/usr/bin:/bin:/sbin",
-		},
+		Name:           cmdName,
+		Args:           cmdArgs,
+		Env:            env,
 		UseIsolatedEnv: true,
 		Stdin:          bytes.NewReader(inputBytes),
 	}
-	stdout, stderr, exitCode, err := r.cmdRunner.RunComplexCommand(cmd)
+	stdout, stderr, exitCode, err := r.runCommand(method, cmd)
 	r.logger.Debug(r.logTag, "Exit Code %d when executing external CPI command '%s'\nSTDIN: '%s'\nSTDOUT: '%s'\nSTDERR: '%s'", exitCode, cmdPath, string(inputBytes), stdout, stderr)
 	if err != nil {
 		return CmdOutput{}, bosherr.WrapErrorf(err, "Executing external CPI command: '%s'", cmdPath)
@@ -110,3 +150,18 @@ func (r *cpiCmdRunner) Run(context CmdContext, method string, args ...interface{
 
 	return cmdOutput, err
 }
+
+func (r *cpiCmdRunner) runCommand(method string, cmd boshsys.Command) (string, string, int, error) {
+	if !r.watchdog.IsEnabled() {
+		return r.cmdRunner.RunComplexCommand(cmd)
+	}
+
+	watchdog := r.watchdog
+	onHung := watchdog.OnHung
+	watchdog.OnHung = func(method string, elapsed time.Duration) bool {
+		r.logger.Warn(r.logTag, "CPI '%s' call has not written any output for %s (elapsed: %s)", method, watchdog.HungAfter, elapsed)
+		return onHung != nil && onHung(method, elapsed)
+	}
+
+	return watchdog.run(r.cmdRunner, method, cmd)
+}
@@ -4,9 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
 
 	. "github.com/cloudfoundry/bosh-cli/cloud"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -72,6 +77,22 @@ var _ = Describe("CpiCmdRunner", func() {
 			))
 		})
 
+		It("merges the cpi properties into the context", func() {
+			outputBytes, err := json.Marshal(CmdOutput{})
+			Expect(err).NotTo(HaveOccurred())
+			cmdRunner.AddCmdResult("/jobs/cpi/bin/cpi", fakesys.FakeCmdResult{Stdout: string(outputBytes)})
+
+			context.Properties = biproperty.Map{"region": "us-east-1", "director_uuid": "ignored"}
+
+			_, err = cpiCmdRunner.Run(context, "fake-method")
+			Expect(err).NotTo(HaveOccurred())
+
+			bytes, err := ioutil.ReadAll(cmdRunner.RunComplexCommands[0].Stdin)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(bytes)).To(ContainSubstring(`"context":{"director_uuid":"fake-director-id","region":"us-east-1"}`))
+			Expect(context.String()).To(Equal(`CmdContext{"director_uuid":"fake-director-id"}`))
+		})
+
 		Context("when the command succeeds", func() {
 			BeforeEach(func() {
 				cmdOutput := CmdOutput{
@@ -138,4 +159,111 @@ var _ = Describe("CpiCmdRunner", func() {
 			})
 		})
 	})
+
+	Describe("Run with a watchdog", func() {
+		var (
+			fakeClock   *fakeclock.FakeClock
+			process     *fakesys.FakeProcess
+			abort       bool
+			hungCalls   chan string
+			outputBytes []byte
+		)
+
+		BeforeEach(func() {
+			var err error
+			outputBytes, err = json.Marshal(CmdOutput{Result: "fake-vm-cid"})
+			Expect(err).NotTo(HaveOccurred())
+
+			fakeClock = fakeclock.NewFakeClock(time.Now())
+			abort = false
+			hungCalls = make(chan string, 10)
+
+			process = &fakesys.FakeProcess{
+				WaitResult: boshsys.Result{ExitStatus: 0},
+				TerminatedNicelyCallBack: func(p *fakesys.FakeProcess) {
+					p.WaitCh <- boshsys.Result{ExitStatus: 143}
+				},
+			}
+			cmdRunner.AddProcess("/jobs/cpi/bin/cpi", process)
+
+			watchdog := Watchdog{
+				HungAfter: 10 * time.Minute,
+				OnHung: func(method string, elapsed time.Duration) bool {
+					hungCalls <- method + " " + elapsed.String()
+					return abort
+				},
+				Clock: fakeClock,
+			}
+			cpiCmdRunner = NewCPICmdRunnerWithWatchdog(cmdRunner, cpi, watchdog, boshlog.NewLogger(boshlog.LevelNone))
+		})
+
+		runAsync := func() (chan CmdOutput, chan error) {
+			outputCh := make(chan CmdOutput, 1)
+			errCh := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				cmdOutput, err := cpiCmdRunner.Run(context, "create_vm")
+				outputCh <- cmdOutput
+				errCh <- err
+			}()
+			Eventually(fakeClock.WatcherCount).Should(Equal(1))
+			return outputCh, errCh
+		}
+
+		It("returns the output of the CPI when it finishes", func() {
+			outputCh, errCh := runAsync()
+
+			process.Stdout.Write(outputBytes)
+			process.WaitCh <- process.WaitResult
+
+			Eventually(errCh).Should(Receive(BeNil()))
+			Expect(<-outputCh).To(Equal(CmdOutput{Result: "fake-vm-cid"}))
+			Expect(hungCalls).To(BeEmpty())
+		})
+
+		It("reports the call as hung when the CPI writes no output for the hung timeout", func() {
+			outputCh, errCh := runAsync()
+
+			fakeClock.Increment(10 * time.Minute)
+			Eventually(hungCalls).Should(Receive(Equal("create_vm 10m0s")))
+
+			fakeClock.Increment(10 * time.Minute)
+			Eventually(hungCalls).Should(Receive(Equal("create_vm 20m0s")))
+
+			process.Stdout.Write(outputBytes)
+			process.WaitCh <- process.WaitResult
+
+			Eventually(errCh).Should(Receive(BeNil()))
+			Expect(<-outputCh).To(Equal(CmdOutput{Result: "fake-vm-cid"}))
+			Expect(process.TerminatedNicely).To(BeFalse())
+		})
+
+		It("does not report the call as hung while the CPI writes output", func() {
+			_, errCh := runAsync()
+
+			fakeClock.Increment(9 * time.Minute)
+			process.Stderr.Write([]byte("fake-log-line"))
+			fakeClock.Increment(time.Minute)
+			Consistently(hungCalls).ShouldNot(Receive())
+
+			process.Stdout.Write(outputBytes)
+			process.WaitCh <- process.WaitResult
+			Eventually(errCh).Should(Receive(BeNil()))
+		})
+
+		It("terminates the CPI and returns an error when the call is aborted", func() {
+			abort = true
+			_, errCh := runAsync()
+
+			fakeClock.Increment(10 * time.Minute)
+			Eventually(hungCalls).Should(Receive())
+
+			var err error
+			Eventually(errCh).Should(Receive(&err))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Aborted CPI 'create_vm' call that appeared hung"))
+			Expect(process.TerminatedNicely).To(BeTrue())
+			Expect(process.TerminateNicelyKillGracePeriod).To(Equal(10 * time.Second))
+		})
+	})
 })
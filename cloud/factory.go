@@ -4,16 +4,21 @@ import (
 	biinstall "github.com/cloudfoundry/bosh-cli/installation"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
 type Factory interface {
 	NewCloud(installation biinstall.Installation, directorID string) (Cloud, error)
+
+	// NewCloudWithProperties creates a cloud for one of cloud_provider.cpis
+	NewCloudWithProperties(installation biinstall.Installation, directorID string, cpiProperties biproperty.Map) (Cloud, error)
 }
 
 type factory struct {
 	fs        boshsys.FileSystem
 	cmdRunner boshsys.CmdRunner
+	watchdog  Watchdog
 	logger    boshlog.Logger
 }
 
@@ -29,7 +34,25 @@ func NewFactory(
 	}
 }
 
+func NewFactoryWithWatchdog(
+	fs boshsys.FileSystem,
+	cmdRunner boshsys.CmdRunner,
+	watchdog Watchdog,
+	logger boshlog.Logger,
+) Factory {
+	return &factory{
+		fs:        fs,
+		cmdRunner: cmdRunner,
+		watchdog:  watchdog,
+		logger:    logger,
+	}
+}
+
 func (f *factory) NewCloud(installation biinstall.Installation, directorID string) (Cloud, error) {
+	return f.NewCloudWithProperties(installation, directorID, nil)
+}
+
+func (f *factory) NewCloudWithProperties(installation biinstall.Installation, directorID string, cpiProperties biproperty.Map) (Cloud, error) {
 	cpiJob := installation.Job()
 	target := installation.Target()
 	cpi := CPI{
@@ -43,6 +66,6 @@ func (f *factory) NewCloud(installation biinstall.Installation, directorID strin
 		return nil, bosherr.Errorf("Installed CPI job '%s' does not contain the required executable '%s'", cpiJob.Name, cmdPath)
 	}
 
-	cpiCmdRunner := NewCPICmdRunner(f.cmdRunner, cpi, f.logger)
-	return NewCloud(cpiCmdRunner, directorID, f.logger), nil
+	cpiCmdRunner := NewCPICmdRunnerWithWatchdog(f.cmdRunner, cpi, f.watchdog, f.logger)
+	return NewCloudWithProperties(cpiCmdRunner, directorID, cpiProperties, f.logger), nil
 }
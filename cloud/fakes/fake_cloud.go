@@ -6,6 +6,10 @@ import (
 )
 
 type FakeCloud struct {
+	InfoCalled bool
+	InfoInfo   cloud.Info
+	InfoErr    error
+
 	CreateStemcellInputs []CreateStemcellInput
 	CreateStemcellCID    string
 	CreateStemcellErr    error
@@ -14,6 +18,10 @@ type FakeCloud struct {
 	HasVMFound bool
 	HasVMErr   error
 
+	HasDiskInputs []HasDiskInput
+	HasDiskFound  map[string]bool
+	HasDiskErr    error
+
 	CreateVMInput CreateVMInput
 	CreateVMCID   string
 	CreateVMErr   error
@@ -55,6 +63,10 @@ type HasVMInput struct {
 	VMCID string
 }
 
+type HasDiskInput struct {
+	DiskCID string
+}
+
 type CreateVMInput struct {
 	AgentID            string
 	StemcellCID        string
@@ -98,6 +110,11 @@ func NewFakeCloud() *FakeCloud {
 	}
 }
 
+func (c *FakeCloud) Info() (cloud.Info, error) {
+	c.InfoCalled = true
+	return c.InfoInfo, c.InfoErr
+}
+
 func (c *FakeCloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
 	c.CreateStemcellInputs = append(c.CreateStemcellInputs, CreateStemcellInput{
 		ImagePath:       imagePath,
@@ -121,6 +138,13 @@ func (c *FakeCloud) HasVM(vmCID string) (bool, error) {
 	return c.HasVMFound, c.HasVMErr
 }
 
+func (c *FakeCloud) HasDisk(diskCID string) (bool, error) {
+	c.HasDiskInputs = append(c.HasDiskInputs, HasDiskInput{
+		DiskCID: diskCID,
+	})
+	return c.HasDiskFound[diskCID], c.HasDiskErr
+}
+
 func (c *FakeCloud) CreateVM(
 	agentID string,
 	stemcellCID string,
@@ -8,6 +8,9 @@ type FakeCPICmdRunner struct {
 	RunInputs    []RunInput
 	RunCmdOutput bicloud.CmdOutput
 	RunErr       error
+
+	// RunCmdOutputs overrides RunCmdOutput for the given methods
+	RunCmdOutputs map[string]bicloud.CmdOutput
 }
 
 type RunInput struct {
@@ -26,5 +29,8 @@ func (r *FakeCPICmdRunner) Run(context bicloud.CmdContext, method string, args .
 		Method:    method,
 		Arguments: args,
 	})
+	if output, found := r.RunCmdOutputs[method]; found {
+		return output, r.RunErr
+	}
 	return r.RunCmdOutput, r.RunErr
 }
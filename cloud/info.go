@@ -0,0 +1,49 @@
+package cloud
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Info is the result of the CPI 'info' method
+type Info struct {
+	StemcellFormats []string `json:"stemcell_formats"`
+
+	// CloudPropertiesSchema is empty when the CPI does not publish one
+	CloudPropertiesSchema CloudPropertiesSchema `json:"cloud_properties_schema"`
+
+	// Quotas has no limits when the CPI cannot look them up
+	Quotas Quotas `json:"quotas"`
+}
+
+// CloudPropertiesSchema describes the cloud_properties the CPI accepts
+// for each kind of resource
+type CloudPropertiesSchema struct {
+	VM      PropertiesSchema `json:"vm"`
+	Disk    PropertiesSchema `json:"disk"`
+	Network PropertiesSchema `json:"network"`
+}
+
+func (s CloudPropertiesSchema) IsEmpty() bool {
+	return s.VM.IsEmpty() && s.Disk.IsEmpty() && s.Network.IsEmpty()
+}
+
+func newInfo(result interface{}) (Info, error) {
+	info := Info{}
+	if result == nil {
+		return info, nil
+	}
+
+	bytes, err := json.Marshal(result)
+	if err != nil {
+		return Info{}, bosherr.WrapErrorf(err, "Unexpected external CPI command result: '%#v'", result)
+	}
+
+	err = json.Unmarshal(bytes, &info)
+	if err != nil {
+		return Info{}, bosherr.WrapErrorf(err, "Unexpected external CPI command result: '%#v'", result)
+	}
+
+	return info, nil
+}
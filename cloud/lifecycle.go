@@ -0,0 +1,255 @@
+package cloud
+
+import (
+	"fmt"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// LifecycleSpec describes the resources created while exercising a CPI.
+type LifecycleSpec struct {
+	StemcellImagePath       string
+	StemcellCloudProperties biproperty.Map
+
+	AgentID           string
+	VMCloudProperties biproperty.Map
+	Networks          map[string]biproperty.Map
+	Env               biproperty.Map
+
+	DiskSize            int
+	DiskCloudProperties biproperty.Map
+}
+
+type LifecycleStepResult struct {
+	Method   string
+	Cleanup  bool
+	Duration time.Duration
+	Err      error
+}
+
+type LifecycleReport struct {
+	Steps []LifecycleStepResult
+}
+
+func (r LifecycleReport) Failed() bool {
+	for _, step := range r.Steps {
+		if step.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleTest drives a CPI through the calls the CLI makes during
+// create-env and delete-env, recording the outcome of every call.
+// When a call fails, resources created so far are deleted again.
+type LifecycleTest interface {
+	Run(spec LifecycleSpec, stage biui.Stage) (LifecycleReport, error)
+}
+
+type lifecycleTest struct {
+	cloud       Cloud
+	timeService clock.Clock
+	logger      boshlog.Logger
+	logTag      string
+}
+
+func NewLifecycleTest(cloud Cloud, timeService clock.Clock, logger boshlog.Logger) LifecycleTest {
+	return lifecycleTest{
+		cloud:       cloud,
+		timeService: timeService,
+		logger:      logger,
+		logTag:      "lifecycleTest",
+	}
+}
+
+type lifecycleRun struct {
+	lifecycleTest
+	spec   LifecycleSpec
+	stage  biui.Stage
+	report LifecycleReport
+
+	stemcellCID  string
+	vmCID        string
+	vmDeleted    bool
+	diskCID      string
+	diskAttached bool
+}
+
+func (t lifecycleTest) Run(spec LifecycleSpec, stage biui.Stage) (LifecycleReport, error) {
+	run := &lifecycleRun{lifecycleTest: t, spec: spec, stage: stage}
+
+	err := run.lifecycle()
+	if err != nil {
+		t.logger.Debug(t.logTag, "CPI lifecycle failed, cleaning up: %s", err.Error())
+		run.cleanup()
+		return run.report, bosherr.WrapError(err, "Running CPI lifecycle")
+	}
+
+	return run.report, nil
+}
+
+func (r *lifecycleRun) lifecycle() error {
+	err := r.call("create_stemcell", false, func() (err error) {
+		r.stemcellCID, err = r.cloud.CreateStemcell(r.spec.StemcellImagePath, r.spec.StemcellCloudProperties)
+		return
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.call("create_vm", false, func() (err error) {
+		r.vmCID, err = r.cloud.CreateVM(r.spec.AgentID, r.stemcellCID, r.spec.VMCloudProperties, r.spec.Networks, r.spec.Env)
+		return
+	})
+	if err != nil {
+		return err
+	}
+
+	err = r.call("has_vm", false, func() error { return r.expectVM(true) })
+	if err != nil {
+		return err
+	}
+
+	err = r.call("set_vm_metadata", false, func() error {
+		return r.cloud.SetVMMetadata(r.vmCID, VMMetadata{"deployment": "cpi-lifecycle-test", "name": "cpi-lifecycle-test/0"})
+	})
+	if err != nil {
+		return err
+	}
+
+	if r.spec.DiskSize > 0 {
+		err = r.call("create_disk", false, func() (err error) {
+			r.diskCID, err = r.cloud.CreateDisk(r.spec.DiskSize, r.spec.DiskCloudProperties, r.vmCID)
+			return
+		})
+		if err != nil {
+			return err
+		}
+
+		err = r.call("attach_disk", false, func() error {
+			err := r.cloud.AttachDisk(r.vmCID, r.diskCID)
+			r.diskAttached = err == nil
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		err = r.call("set_disk_metadata", false, func() error {
+			return r.cloud.SetDiskMetadata(r.diskCID, DiskMetadata{"deployment": "cpi-lifecycle-test"})
+		})
+		if err != nil {
+			return err
+		}
+
+		err = r.call("detach_disk", false, r.detachDisk)
+		if err != nil {
+			return err
+		}
+
+		err = r.call("delete_disk", false, r.deleteDisk)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = r.call("delete_vm", false, r.deleteVM)
+	if err != nil {
+		return err
+	}
+
+	err = r.call("has_vm", false, func() error { return r.expectVM(false) })
+	if err != nil {
+		return err
+	}
+
+	return r.call("delete_stemcell", false, r.deleteStemcell)
+}
+
+func (r *lifecycleRun) cleanup() {
+	if r.diskAttached {
+		r.call("detach_disk", true, r.detachDisk)
+	}
+	if r.diskCID != "" && !r.diskAttached {
+		r.call("delete_disk", true, r.deleteDisk)
+	}
+	if r.vmCID != "" && !r.vmDeleted {
+		r.call("delete_vm", true, r.deleteVM)
+	}
+	if r.stemcellCID != "" {
+		r.call("delete_stemcell", true, r.deleteStemcell)
+	}
+}
+
+func (r *lifecycleRun) call(method string, cleanup bool, fn func() error) error {
+	stepName := fmt.Sprintf("Calling '%s'", method)
+	if cleanup {
+		stepName = fmt.Sprintf("Cleaning up with '%s'", method)
+	}
+
+	startTime := r.timeService.Now()
+	err := r.stage.Perform(stepName, fn)
+
+	r.report.Steps = append(r.report.Steps, LifecycleStepResult{
+		Method:   method,
+		Cleanup:  cleanup,
+		Duration: r.timeService.Since(startTime),
+		Err:      err,
+	})
+
+	if err != nil && cleanup {
+		r.logger.Warn(r.logTag, "Cleaning up with '%s' failed: %s", method, err.Error())
+	}
+
+	return err
+}
+
+func (r *lifecycleRun) expectVM(expected bool) error {
+	found, err := r.cloud.HasVM(r.vmCID)
+	if err != nil {
+		return err
+	}
+
+	if found != expected {
+		return bosherr.Errorf("Expected has_vm to return '%t' for VM '%s' but got '%t'", expected, r.vmCID, found)
+	}
+
+	return nil
+}
+
+func (r *lifecycleRun) detachDisk() error {
+	err := r.cloud.DetachDisk(r.vmCID, r.diskCID)
+	if err == nil {
+		r.diskAttached = false
+	}
+	return err
+}
+
+func (r *lifecycleRun) deleteDisk() error {
+	err := r.cloud.DeleteDisk(r.diskCID)
+	if err == nil {
+		r.diskCID = ""
+	}
+	return err
+}
+
+func (r *lifecycleRun) deleteVM() error {
+	err := r.cloud.DeleteVM(r.vmCID)
+	r.vmDeleted = err == nil
+	return err
+}
+
+func (r *lifecycleRun) deleteStemcell() error {
+	err := r.cloud.DeleteStemcell(r.stemcellCID)
+	if err == nil {
+		r.stemcellCID = ""
+	}
+	return err
+}
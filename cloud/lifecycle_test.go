@@ -0,0 +1,170 @@
+package cloud_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
+	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+)
+
+var _ = Describe("LifecycleTest", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		mockCloud *mock_cloud.MockCloud
+		fakeStage *fakebiui.FakeStage
+
+		spec          LifecycleSpec
+		lifecycleTest LifecycleTest
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+		fakeStage = fakebiui.NewFakeStage()
+
+		spec = LifecycleSpec{
+			StemcellImagePath:       "/fake-stemcell/image",
+			StemcellCloudProperties: biproperty.Map{"fake-stemcell-key": "fake-stemcell-value"},
+			AgentID:                 "fake-agent-id",
+			VMCloudProperties:       biproperty.Map{"fake-vm-key": "fake-vm-value"},
+			Networks:                map[string]biproperty.Map{"fake-network": {"type": "dynamic"}},
+			Env:                     biproperty.Map{},
+			DiskSize:                1024,
+			DiskCloudProperties:     biproperty.Map{"fake-disk-key": "fake-disk-value"},
+		}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		lifecycleTest = NewLifecycleTest(mockCloud, fakeclock.NewFakeClock(time.Now()), logger)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	performedSteps := func() []string {
+		names := []string{}
+		for _, call := range fakeStage.PerformCalls {
+			names = append(names, call.Name)
+		}
+		return names
+	}
+
+	It("runs the full lifecycle against the cloud", func() {
+		gomock.InOrder(
+			mockCloud.EXPECT().CreateStemcell("/fake-stemcell/image", spec.StemcellCloudProperties).Return("fake-stemcell-cid", nil),
+			mockCloud.EXPECT().CreateVM("fake-agent-id", "fake-stemcell-cid", spec.VMCloudProperties, spec.Networks, spec.Env).Return("fake-vm-cid", nil),
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Return(true, nil),
+			mockCloud.EXPECT().SetVMMetadata("fake-vm-cid", gomock.Any()).Return(nil),
+			mockCloud.EXPECT().CreateDisk(1024, spec.DiskCloudProperties, "fake-vm-cid").Return("fake-disk-cid", nil),
+			mockCloud.EXPECT().AttachDisk("fake-vm-cid", "fake-disk-cid").Return(nil),
+			mockCloud.EXPECT().SetDiskMetadata("fake-disk-cid", gomock.Any()).Return(nil),
+			mockCloud.EXPECT().DetachDisk("fake-vm-cid", "fake-disk-cid").Return(nil),
+			mockCloud.EXPECT().DeleteDisk("fake-disk-cid").Return(nil),
+			mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(nil),
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Return(false, nil),
+			mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid").Return(nil),
+		)
+
+		report, err := lifecycleTest.Run(spec, fakeStage)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Failed()).To(BeFalse())
+		Expect(report.Steps).To(HaveLen(12))
+
+		Expect(performedSteps()).To(Equal([]string{
+			"Calling 'create_stemcell'",
+			"Calling 'create_vm'",
+			"Calling 'has_vm'",
+			"Calling 'set_vm_metadata'",
+			"Calling 'create_disk'",
+			"Calling 'attach_disk'",
+			"Calling 'set_disk_metadata'",
+			"Calling 'detach_disk'",
+			"Calling 'delete_disk'",
+			"Calling 'delete_vm'",
+			"Calling 'has_vm'",
+			"Calling 'delete_stemcell'",
+		}))
+	})
+
+	It("skips the disk calls when no disk size is given", func() {
+		spec.DiskSize = 0
+
+		gomock.InOrder(
+			mockCloud.EXPECT().CreateStemcell(gomock.Any(), gomock.Any()).Return("fake-stemcell-cid", nil),
+			mockCloud.EXPECT().CreateVM(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("fake-vm-cid", nil),
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Return(true, nil),
+			mockCloud.EXPECT().SetVMMetadata("fake-vm-cid", gomock.Any()).Return(nil),
+			mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(nil),
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Return(false, nil),
+			mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid").Return(nil),
+		)
+
+		report, err := lifecycleTest.Run(spec, fakeStage)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(report.Steps).To(HaveLen(7))
+	})
+
+	It("cleans up created resources when a call fails", func() {
+		attachErr := errors.New("fake-attach-error")
+
+		gomock.InOrder(
+			mockCloud.EXPECT().CreateStemcell(gomock.Any(), gomock.Any()).Return("fake-stemcell-cid", nil),
+			mockCloud.EXPECT().CreateVM(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("fake-vm-cid", nil),
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Return(true, nil),
+			mockCloud.EXPECT().SetVMMetadata("fake-vm-cid", gomock.Any()).Return(nil),
+			mockCloud.EXPECT().CreateDisk(gomock.Any(), gomock.Any(), gomock.Any()).Return("fake-disk-cid", nil),
+			mockCloud.EXPECT().AttachDisk("fake-vm-cid", "fake-disk-cid").Return(attachErr),
+			mockCloud.EXPECT().DeleteDisk("fake-disk-cid").Return(nil),
+			mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(nil),
+			mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid").Return(nil),
+		)
+
+		report, err := lifecycleTest.Run(spec, fakeStage)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-attach-error"))
+		Expect(report.Failed()).To(BeTrue())
+
+		Expect(report.Steps[5]).To(Equal(LifecycleStepResult{Method: "attach_disk", Err: attachErr}))
+		Expect(performedSteps()[6:]).To(Equal([]string{
+			"Cleaning up with 'delete_disk'",
+			"Cleaning up with 'delete_vm'",
+			"Cleaning up with 'delete_stemcell'",
+		}))
+	})
+
+	It("fails when has_vm still finds the deleted VM", func() {
+		spec.DiskSize = 0
+
+		gomock.InOrder(
+			mockCloud.EXPECT().CreateStemcell(gomock.Any(), gomock.Any()).Return("fake-stemcell-cid", nil),
+			mockCloud.EXPECT().CreateVM(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("fake-vm-cid", nil),
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Return(true, nil),
+			mockCloud.EXPECT().SetVMMetadata("fake-vm-cid", gomock.Any()).Return(nil),
+			mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(nil),
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Return(true, nil),
+			mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid").Return(nil),
+		)
+
+		_, err := lifecycleTest.Run(spec, fakeStage)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Expected has_vm to return 'false' for VM 'fake-vm-cid' but got 'true'"))
+	})
+
+	It("does not clean up anything when creating the stemcell fails", func() {
+		mockCloud.EXPECT().CreateStemcell(gomock.Any(), gomock.Any()).Return("", errors.New("fake-create-stemcell-error"))
+
+		report, err := lifecycleTest.Run(spec, fakeStage)
+		Expect(err).To(HaveOccurred())
+		Expect(report.Steps).To(HaveLen(1))
+	})
+})
@@ -134,6 +134,32 @@ func (mr *MockCloudMockRecorder) DetachDisk(arg0, arg1 interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DetachDisk", reflect.TypeOf((*MockCloud)(nil).DetachDisk), arg0, arg1)
 }
 
+// Info mocks base method
+func (m *MockCloud) Info() (cloud.Info, error) {
+	ret := m.ctrl.Call(m, "Info")
+	ret0, _ := ret[0].(cloud.Info)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Info indicates an expected call of Info
+func (mr *MockCloudMockRecorder) Info() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Info", reflect.TypeOf((*MockCloud)(nil).Info))
+}
+
+// HasDisk mocks base method
+func (m *MockCloud) HasDisk(arg0 string) (bool, error) {
+	ret := m.ctrl.Call(m, "HasDisk", arg0)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasDisk indicates an expected call of HasDisk
+func (mr *MockCloudMockRecorder) HasDisk(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasDisk", reflect.TypeOf((*MockCloud)(nil).HasDisk), arg0)
+}
+
 // HasVM mocks base method
 func (m *MockCloud) HasVM(arg0 string) (bool, error) {
 	ret := m.ctrl.Call(m, "HasVM", arg0)
@@ -218,3 +244,16 @@ func (m *MockFactory) NewCloud(arg0 installation.Installation, arg1 string) (clo
 func (mr *MockFactoryMockRecorder) NewCloud(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewCloud", reflect.TypeOf((*MockFactory)(nil).NewCloud), arg0, arg1)
 }
+
+// NewCloudWithProperties mocks base method
+func (m *MockFactory) NewCloudWithProperties(arg0 installation.Installation, arg1 string, arg2 property.Map) (cloud.Cloud, error) {
+	ret := m.ctrl.Call(m, "NewCloudWithProperties", arg0, arg1, arg2)
+	ret0, _ := ret[0].(cloud.Cloud)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NewCloudWithProperties indicates an expected call of NewCloudWithProperties
+func (mr *MockFactoryMockRecorder) NewCloudWithProperties(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewCloudWithProperties", reflect.TypeOf((*MockFactory)(nil).NewCloudWithProperties), arg0, arg1, arg2)
+}
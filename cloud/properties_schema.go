@@ -0,0 +1,207 @@
+package cloud
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+// PropertiesSchema is the subset of JSON Schema that CPIs use to describe
+// cloud_properties: value types, nested properties, required properties and
+// whether properties that are not listed are allowed.
+type PropertiesSchema struct {
+	Type                 string                      `json:"type"`
+	Properties           map[string]PropertiesSchema `json:"properties"`
+	Required             []string                    `json:"required"`
+	AdditionalProperties *bool                       `json:"additionalProperties"`
+}
+
+var typeDescriptions = map[string]string{
+	"string":  "a string",
+	"boolean": "a boolean",
+	"integer": "an integer",
+	"number":  "a number",
+	"object":  "a hash",
+	"array":   "an array",
+}
+
+// maxSuggestionDistance is how many edits away from a known property an
+// unknown property may be to be reported as a probable typo
+const maxSuggestionDistance = 2
+
+func (s PropertiesSchema) IsEmpty() bool {
+	return s.Type == "" && len(s.Properties) == 0 && len(s.Required) == 0 && s.AdditionalProperties == nil
+}
+
+// Validate returns errors for values the CPI does not accept and warnings
+// for properties it does not know about but allows. Messages are prefixed with path.
+func (s PropertiesSchema) Validate(path string, value interface{}) ([]error, []string) {
+	errs := []error{}
+	warnings := []string{}
+
+	if value == nil {
+		return errs, warnings
+	}
+
+	description, knownType := typeDescriptions[s.Type]
+	if knownType && !hasType(value, s.Type) {
+		return append(errs, bosherr.Errorf("%s must be %s", path, description)), warnings
+	}
+
+	properties, isHash := toHash(value)
+	if !isHash {
+		return errs, warnings
+	}
+
+	for _, name := range s.Required {
+		if _, found := properties[name]; !found {
+			errs = append(errs, bosherr.Errorf("%s.%s must be provided", path, name))
+		}
+	}
+
+	allowsAdditional := s.AdditionalProperties == nil || *s.AdditionalProperties
+	if len(s.Properties) == 0 && allowsAdditional {
+		return errs, warnings
+	}
+
+	names := []string{}
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		propertyPath := fmt.Sprintf("%s.%s", path, name)
+
+		propertySchema, found := s.Properties[name]
+		if found {
+			propertyErrs, propertyWarnings := propertySchema.Validate(propertyPath, properties[name])
+			errs = append(errs, propertyErrs...)
+			warnings = append(warnings, propertyWarnings...)
+			continue
+		}
+
+		message := fmt.Sprintf("%s is not a known cloud property", propertyPath)
+		if suggestion, found := s.suggest(name); found {
+			message = fmt.Sprintf("%s (did you mean '%s'?)", message, suggestion)
+		}
+
+		if allowsAdditional {
+			warnings = append(warnings, message)
+		} else {
+			errs = append(errs, bosherr.Error(message))
+		}
+	}
+
+	return errs, warnings
+}
+
+func (s PropertiesSchema) suggest(name string) (string, bool) {
+	known := []string{}
+	for knownName := range s.Properties {
+		known = append(known, knownName)
+	}
+	sort.Strings(known)
+
+	suggestion := ""
+	bestDistance := maxSuggestionDistance + 1
+	for _, knownName := range known {
+		distance := editDistance(name, knownName)
+		if distance < bestDistance && distance < len(knownName) {
+			suggestion = knownName
+			bestDistance = distance
+		}
+	}
+
+	return suggestion, suggestion != ""
+}
+
+func hasType(value interface{}, schemaType string) bool {
+	kind := reflect.TypeOf(value).Kind()
+
+	switch schemaType {
+	case "string":
+		return kind == reflect.String
+	case "boolean":
+		return kind == reflect.Bool
+	case "integer":
+		if kind == reflect.Float32 || kind == reflect.Float64 {
+			float := reflect.ValueOf(value).Float()
+			return float == math.Trunc(float)
+		}
+		return isInteger(kind)
+	case "number":
+		return isInteger(kind) || kind == reflect.Float32 || kind == reflect.Float64
+	case "object":
+		_, isHash := toHash(value)
+		return isHash
+	case "array":
+		return kind == reflect.Slice || kind == reflect.Array
+	}
+
+	return true
+}
+
+func isInteger(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func toHash(value interface{}) (map[string]interface{}, bool) {
+	switch typedValue := value.(type) {
+	case biproperty.Map:
+		hash := map[string]interface{}{}
+		for key, value := range typedValue {
+			hash[key] = value
+		}
+		return hash, true
+	case map[string]interface{}:
+		return typedValue, true
+	case map[interface{}]interface{}:
+		hash := map[string]interface{}{}
+		for key, value := range typedValue {
+			hash[fmt.Sprintf("%v", key)] = value
+		}
+		return hash, true
+	}
+	return nil, false
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			substitution := previous[j-1]
+			if a[i-1] != b[j-1] {
+				substitution++
+			}
+			current[j] = minInt(substitution, minInt(previous[j]+1, current[j-1]+1))
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
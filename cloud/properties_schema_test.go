@@ -0,0 +1,122 @@
+package cloud_test
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+)
+
+var _ = Describe("PropertiesSchema", func() {
+	var (
+		schema               PropertiesSchema
+		additionalProperties *bool
+	)
+
+	BeforeEach(func() {
+		additionalProperties = nil
+	})
+
+	JustBeforeEach(func() {
+		schema = PropertiesSchema{
+			Type:                 "object",
+			Required:             []string{"instance_type"},
+			AdditionalProperties: additionalProperties,
+			Properties: map[string]PropertiesSchema{
+				"instance_type": {Type: "string"},
+				"ephemeral_disk": {
+					Type: "object",
+					Properties: map[string]PropertiesSchema{
+						"size":      {Type: "integer"},
+						"encrypted": {Type: "boolean"},
+					},
+				},
+				"security_groups": {Type: "array"},
+				"spot_bid_price":  {Type: "number"},
+			},
+		}
+	})
+
+	It("accepts cloud properties that match the schema", func() {
+		errs, warnings := schema.Validate("resource_pools[0].cloud_properties", biproperty.Map{
+			"instance_type": "m4.large",
+			"ephemeral_disk": biproperty.Map{
+				"size":      25000,
+				"encrypted": true,
+			},
+			"security_groups": []interface{}{"bosh"},
+			"spot_bid_price":  0.5,
+		})
+		Expect(errs).To(BeEmpty())
+		Expect(warnings).To(BeEmpty())
+	})
+
+	It("accepts whole numbers decoded as floats for integers", func() {
+		errs, _ := schema.Validate("resource_pools[0].cloud_properties", biproperty.Map{
+			"instance_type":  "m4.large",
+			"ephemeral_disk": map[interface{}]interface{}{"size": float64(25000)},
+		})
+		Expect(errs).To(BeEmpty())
+	})
+
+	It("returns an error for each missing required property", func() {
+		errs, _ := schema.Validate("resource_pools[0].cloud_properties", biproperty.Map{})
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError("resource_pools[0].cloud_properties.instance_type must be provided"))
+	})
+
+	It("returns an error for each value of the wrong type", func() {
+		errs, _ := schema.Validate("resource_pools[0].cloud_properties", biproperty.Map{
+			"instance_type":   1,
+			"ephemeral_disk":  biproperty.Map{"size": 1.5, "encrypted": "yes"},
+			"security_groups": "bosh",
+		})
+		Expect(errs).To(HaveLen(4))
+		Expect(errs[0]).To(MatchError("resource_pools[0].cloud_properties.ephemeral_disk.encrypted must be a boolean"))
+		Expect(errs[1]).To(MatchError("resource_pools[0].cloud_properties.ephemeral_disk.size must be an integer"))
+		Expect(errs[2]).To(MatchError("resource_pools[0].cloud_properties.instance_type must be a string"))
+		Expect(errs[3]).To(MatchError("resource_pools[0].cloud_properties.security_groups must be an array"))
+	})
+
+	It("warns about unknown properties and suggests the closest known property", func() {
+		errs, warnings := schema.Validate("resource_pools[0].cloud_properties", biproperty.Map{
+			"instance_type": "m4.large",
+			"instance_typ":  "m4.large",
+			"iam_profile":   "director",
+		})
+		Expect(errs).To(BeEmpty())
+		Expect(warnings).To(Equal([]string{
+			"resource_pools[0].cloud_properties.iam_profile is not a known cloud property",
+			"resource_pools[0].cloud_properties.instance_typ is not a known cloud property (did you mean 'instance_type'?)",
+		}))
+	})
+
+	Context("when the schema does not allow additional properties", func() {
+		BeforeEach(func() {
+			disallowed := false
+			additionalProperties = &disallowed
+		})
+
+		It("returns an error for unknown properties", func() {
+			errs, warnings := schema.Validate("resource_pools[0].cloud_properties", biproperty.Map{
+				"instance_typ": "m4.large",
+			})
+			Expect(warnings).To(BeEmpty())
+			Expect(errs).To(HaveLen(2))
+			Expect(errs[0]).To(MatchError("resource_pools[0].cloud_properties.instance_type must be provided"))
+			Expect(errs[1]).To(MatchError("resource_pools[0].cloud_properties.instance_typ is not a known cloud property (did you mean 'instance_type'?)"))
+		})
+	})
+
+	It("returns an error when the cloud properties are not a hash", func() {
+		errs, _ := schema.Validate("resource_pools[0].cloud_properties", "m4.large")
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0]).To(MatchError("resource_pools[0].cloud_properties must be a hash"))
+	})
+
+	It("is empty when nothing is specified", func() {
+		Expect(PropertiesSchema{}.IsEmpty()).To(BeTrue())
+		Expect(schema.IsEmpty()).To(BeFalse())
+	})
+})
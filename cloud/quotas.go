@@ -0,0 +1,78 @@
+package cloud
+
+// Quota is how much of a resource the account or project may use and
+// already uses. Limit is nil when the CPI did not report one.
+type Quota struct {
+	Limit *int `json:"limit"`
+	Used  int  `json:"used"`
+}
+
+// Available returns how much of the resource is left, and false when the
+// resource is not limited or its limit is unknown
+func (q Quota) Available() (int, bool) {
+	if q.Limit == nil {
+		return 0, false
+	}
+
+	available := *q.Limit - q.Used
+	if available < 0 {
+		available = 0
+	}
+
+	return available, true
+}
+
+// Quotas is published by CPIs that can look up the limits of the target
+// account in the 'info' method
+type Quotas struct {
+	Instances Quota `json:"instances"`
+	Cores     Quota `json:"cores"`
+	RAMMB     Quota `json:"ram_mb"`
+	DiskGB    Quota `json:"disk_gb"`
+	IPs       Quota `json:"ips"`
+}
+
+// ResourceRequirements are the resources a deploy is estimated to create
+type ResourceRequirements struct {
+	Instances int
+	Cores     int
+	RAMMB     int
+	DiskGB    int
+	IPs       int
+}
+
+// QuotaShortfall is a resource whose available quota is less than required
+type QuotaShortfall struct {
+	Resource  string
+	Required  int
+	Available int
+	Limit     int
+}
+
+// Shortfalls returns the limited resources that do not have enough quota
+// left for requirements, in a stable order
+func (q Quotas) Shortfalls(requirements ResourceRequirements) []QuotaShortfall {
+	shortfalls := []QuotaShortfall{}
+
+	check := func(resource string, quota Quota, required int) {
+		available, limited := quota.Available()
+		if !limited || required <= available {
+			return
+		}
+
+		shortfalls = append(shortfalls, QuotaShortfall{
+			Resource:  resource,
+			Required:  required,
+			Available: available,
+			Limit:     *quota.Limit,
+		})
+	}
+
+	check("instances", q.Instances, requirements.Instances)
+	check("cores", q.Cores, requirements.Cores)
+	check("RAM (MB)", q.RAMMB, requirements.RAMMB)
+	check("disk (GB)", q.DiskGB, requirements.DiskGB)
+	check("IPs", q.IPs, requirements.IPs)
+
+	return shortfalls
+}
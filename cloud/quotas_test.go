@@ -0,0 +1,33 @@
+package cloud_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+)
+
+var _ = Describe("Quotas", func() {
+	limit := func(limit int) *int { return &limit }
+
+	Describe("Shortfalls", func() {
+		It("returns the limited resources without enough quota left", func() {
+			quotas := Quotas{
+				Instances: Quota{Limit: limit(20), Used: 20},
+				Cores:     Quota{Limit: limit(64), Used: 60},
+				DiskGB:    Quota{Limit: limit(500), Used: 600},
+				IPs:       Quota{Used: 1000},
+			}
+
+			shortfalls := quotas.Shortfalls(ResourceRequirements{Instances: 1, Cores: 4, RAMMB: 8192, DiskGB: 32, IPs: 1})
+			Expect(shortfalls).To(Equal([]QuotaShortfall{
+				{Resource: "instances", Required: 1, Available: 0, Limit: 20},
+				{Resource: "disk (GB)", Required: 32, Available: 0, Limit: 500},
+			}))
+		})
+
+		It("returns no shortfalls when the CPI reports no limits", func() {
+			Expect(Quotas{}.Shortfalls(ResourceRequirements{Instances: 1, DiskGB: 32})).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,215 @@
+package cloud
+
+import (
+	"regexp"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+// DefaultRetryMethods are the CPI methods retried when a RetryPolicy does
+// not list any, the ones IaaS rate limits usually fail
+var DefaultRetryMethods = []string{"create_vm", "create_disk"}
+
+// RetryPolicy retries CPI calls that fail transiently, e.g. on IaaS rate
+// limits. Errors are retried when the CPI marks them ok_to_retry, or when
+// their type is one of ErrorTypes or their message matches one of
+// ErrorMessages.
+type RetryPolicy struct {
+	MaxAttempts  int
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+
+	Methods       []string
+	ErrorTypes    []string
+	ErrorMessages []*regexp.Regexp
+}
+
+// IsEmpty is true when calls are only attempted once
+func (p RetryPolicy) IsEmpty() bool {
+	return p.MaxAttempts < 2
+}
+
+// Retryable classifies the error of a call to method
+func (p RetryPolicy) Retryable(method string, err error) bool {
+	cpiErr, ok := err.(Error)
+	if !ok {
+		return false
+	}
+
+	methods := p.Methods
+	if len(methods) == 0 {
+		methods = DefaultRetryMethods
+	}
+
+	if !containsString(methods, method) {
+		return false
+	}
+
+	if cpiErr.OkToRetry() || containsString(p.ErrorTypes, cpiErr.Type()) {
+		return true
+	}
+
+	for _, message := range p.ErrorMessages {
+		if message.MatchString(cpiErr.Message()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+type retryingCloud struct {
+	cloud           Cloud
+	policy          RetryPolicy
+	attemptReporter biretrier.AttemptReporter
+	timeService     biretrier.Clock
+	logger          boshlog.Logger
+	logTag          string
+}
+
+// NewRetryingCloud retries the calls of c as policy allows, doubling the
+// delay between attempts. Giving up returns the error of the last attempt
+// so that callers can still tell CPI errors apart. Retries are reported to
+// attemptReporter, when not nil, with the CPI method as operation name.
+func NewRetryingCloud(c Cloud, policy RetryPolicy, attemptReporter biretrier.AttemptReporter, timeService biretrier.Clock, logger boshlog.Logger) Cloud {
+	return retryingCloud{
+		cloud:           c,
+		policy:          policy,
+		attemptReporter: attemptReporter,
+		timeService:     timeService,
+		logger:          logger,
+		logTag:          "retryingCloud",
+	}
+}
+
+func (c retryingCloud) try(method string, call func() error) error {
+	retrier := biretrier.NewRetrier(biretrier.Options{
+		MaxAttempts: c.policy.MaxAttempts,
+		Backoff:     biretrier.NewExponentialBackoff(c.policy.InitialDelay, c.policy.MaxDelay, 2),
+		Name:        method,
+		Reporter:    c.attemptReporter,
+	}, c.timeService, c.logger)
+
+	attempts := 0
+
+	return retrier.Try(func() (bool, error) {
+		attempts++
+
+		err := call()
+		if err == nil || attempts >= c.policy.MaxAttempts || !c.policy.Retryable(method, err) {
+			return false, err
+		}
+
+		c.logger.Warn(c.logTag, "Retrying CPI '%s' method after attempt %d of %d failed: %s", method, attempts, c.policy.MaxAttempts, err.Error())
+
+		return true, err
+	})
+}
+
+func (c retryingCloud) Info() (info Info, err error) {
+	err = c.try("info", func() error {
+		info, err = c.cloud.Info()
+		return err
+	})
+	return info, err
+}
+
+func (c retryingCloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (cid string, err error) {
+	err = c.try("create_stemcell", func() error {
+		cid, err = c.cloud.CreateStemcell(imagePath, cloudProperties)
+		return err
+	})
+	return cid, err
+}
+
+func (c retryingCloud) DeleteStemcell(stemcellCID string) error {
+	return c.try("delete_stemcell", func() error {
+		return c.cloud.DeleteStemcell(stemcellCID)
+	})
+}
+
+func (c retryingCloud) HasVM(vmCID string) (found bool, err error) {
+	err = c.try("has_vm", func() error {
+		found, err = c.cloud.HasVM(vmCID)
+		return err
+	})
+	return found, err
+}
+
+func (c retryingCloud) HasDisk(diskCID string) (found bool, err error) {
+	err = c.try("has_disk", func() error {
+		found, err = c.cloud.HasDisk(diskCID)
+		return err
+	})
+	return found, err
+}
+
+func (c retryingCloud) CreateVM(agentID string, stemcellCID string, cloudProperties biproperty.Map, networksInterfaces map[string]biproperty.Map, env biproperty.Map) (cid string, err error) {
+	err = c.try("create_vm", func() error {
+		cid, err = c.cloud.CreateVM(agentID, stemcellCID, cloudProperties, networksInterfaces, env)
+		return err
+	})
+	return cid, err
+}
+
+func (c retryingCloud) SetVMMetadata(vmCID string, metadata VMMetadata) error {
+	return c.try("set_vm_metadata", func() error {
+		return c.cloud.SetVMMetadata(vmCID, metadata)
+	})
+}
+
+func (c retryingCloud) SetDiskMetadata(diskCID string, metadata DiskMetadata) error {
+	return c.try("set_disk_metadata", func() error {
+		return c.cloud.SetDiskMetadata(diskCID, metadata)
+	})
+}
+
+func (c retryingCloud) DeleteVM(vmCID string) error {
+	return c.try("delete_vm", func() error {
+		return c.cloud.DeleteVM(vmCID)
+	})
+}
+
+func (c retryingCloud) CreateDisk(size int, cloudProperties biproperty.Map, vmCID string) (cid string, err error) {
+	err = c.try("create_disk", func() error {
+		cid, err = c.cloud.CreateDisk(size, cloudProperties, vmCID)
+		return err
+	})
+	return cid, err
+}
+
+func (c retryingCloud) AttachDisk(vmCID, diskCID string) error {
+	return c.try("attach_disk", func() error {
+		return c.cloud.AttachDisk(vmCID, diskCID)
+	})
+}
+
+func (c retryingCloud) DetachDisk(vmCID, diskCID string) error {
+	return c.try("detach_disk", func() error {
+		return c.cloud.DetachDisk(vmCID, diskCID)
+	})
+}
+
+func (c retryingCloud) DeleteDisk(diskCID string) error {
+	return c.try("delete_disk", func() error {
+		return c.cloud.DeleteDisk(diskCID)
+	})
+}
+
+func (c retryingCloud) String() string {
+	return c.cloud.String()
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,142 @@
+package cloud_test
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
+)
+
+type sleepRecordingClock struct {
+	sleeps []time.Duration
+}
+
+func (c *sleepRecordingClock) Sleep(d time.Duration) { c.sleeps = append(c.sleeps, d) }
+func (c *sleepRecordingClock) Now() time.Time        { return time.Time{} }
+
+type recordingAttemptReporter struct {
+	attempts []string
+}
+
+func (r *recordingAttemptReporter) ReportAttempt(name string, attempt int, maxAttempts int) {
+	r.attempts = append(r.attempts, fmt.Sprintf("%s %d/%d", name, attempt, maxAttempts))
+}
+
+var _ = Describe("RetryingCloud", func() {
+	var (
+		mockCtrl    *gomock.Controller
+		mockCloud   *mock_cloud.MockCloud
+		timeService *sleepRecordingClock
+		reporter    *recordingAttemptReporter
+		policy      RetryPolicy
+		cloud       Cloud
+
+		rateLimitErr = NewCPIError("create_vm", CmdError{Type: "Bosh::Clouds::CloudError", Message: "Rate limit exceeded"})
+		retryErr     = NewCPIError("create_vm", CmdError{Type: "Bosh::Clouds::VMCreationFailed", Message: "fake-message", OkToRetry: true})
+		otherErr     = NewCPIError("create_vm", CmdError{Type: "Bosh::Clouds::CloudError", Message: "Invalid flavor"})
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+		timeService = &sleepRecordingClock{}
+		reporter = &recordingAttemptReporter{}
+
+		policy = RetryPolicy{
+			MaxAttempts:   3,
+			InitialDelay:  time.Second,
+			MaxDelay:      10 * time.Second,
+			ErrorMessages: []*regexp.Regexp{regexp.MustCompile("Rate limit")},
+		}
+	})
+
+	JustBeforeEach(func() {
+		cloud = NewRetryingCloud(mockCloud, policy, reporter, timeService, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("retries retryable errors with exponential backoff until the call succeeds", func() {
+		gomock.InOrder(
+			mockCloud.EXPECT().CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil).Return("", rateLimitErr),
+			mockCloud.EXPECT().CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil).Return("", retryErr),
+			mockCloud.EXPECT().CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil).Return("fake-vm-cid", nil),
+		)
+
+		cid, err := cloud.CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cid).To(Equal("fake-vm-cid"))
+		Expect(timeService.sleeps).To(Equal([]time.Duration{time.Second, 2 * time.Second}))
+	})
+
+	It("reports every retry with the CPI method and the attempt counter", func() {
+		mockCloud.EXPECT().CreateDisk(1024, biproperty.Map{}, "fake-vm-cid").Return("", rateLimitErr).Times(3)
+
+		_, err := cloud.CreateDisk(1024, biproperty.Map{}, "fake-vm-cid")
+		Expect(err).To(HaveOccurred())
+		Expect(reporter.attempts).To(Equal([]string{"create_disk 2/3", "create_disk 3/3"}))
+	})
+
+	It("returns the error of the last attempt once the attempts are used up", func() {
+		mockCloud.EXPECT().CreateDisk(1024, biproperty.Map{}, "fake-vm-cid").Return("", rateLimitErr).Times(3)
+
+		_, err := cloud.CreateDisk(1024, biproperty.Map{}, "fake-vm-cid")
+		Expect(err).To(Equal(rateLimitErr))
+	})
+
+	It("does not retry errors that are not retryable", func() {
+		mockCloud.EXPECT().CreateVM(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", otherErr)
+
+		_, err := cloud.CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil)
+		Expect(err).To(Equal(otherErr))
+		Expect(timeService.sleeps).To(BeEmpty())
+	})
+
+	It("only retries create_vm and create_disk by default", func() {
+		mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(retryErr)
+
+		err := cloud.DeleteVM("fake-vm-cid")
+		Expect(err).To(Equal(retryErr))
+	})
+
+	Context("when the policy lists methods and error types", func() {
+		BeforeEach(func() {
+			policy.Methods = []string{"delete_vm"}
+			policy.ErrorTypes = []string{"Bosh::Clouds::CloudError"}
+		})
+
+		It("retries the listed methods on errors of the listed types", func() {
+			gomock.InOrder(
+				mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(otherErr),
+				mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(nil),
+			)
+
+			Expect(cloud.DeleteVM("fake-vm-cid")).To(Succeed())
+		})
+
+		It("does not retry other methods", func() {
+			mockCloud.EXPECT().CreateVM(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", retryErr)
+
+			_, err := cloud.CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil)
+			Expect(err).To(Equal(retryErr))
+		})
+	})
+
+	It("does not retry errors that are not CPI errors", func() {
+		mockCloud.EXPECT().CreateVM(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("", errors.New("fake-exec-err"))
+
+		_, err := cloud.CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil)
+		Expect(err).To(MatchError("fake-exec-err"))
+	})
+})
@@ -0,0 +1,175 @@
+package cloud
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+// ThrottlePolicy limits how many CPI calls run at the same time, e.g. to
+// stay below IaaS rate limits when stages call the CPI in parallel.
+// MaxConcurrent limits all calls, Methods limits the calls of single CPI
+// methods, e.g. {"create_vm": 1}. Zero does not limit.
+type ThrottlePolicy struct {
+	MaxConcurrent int
+	Methods       map[string]int
+}
+
+// IsEmpty is true when no calls are limited
+func (p ThrottlePolicy) IsEmpty() bool {
+	if p.MaxConcurrent > 0 {
+		return false
+	}
+
+	for _, limit := range p.Methods {
+		if limit > 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+type throttledCloud struct {
+	cloud   Cloud
+	all     chan struct{}
+	methods map[string]chan struct{}
+	logger  boshlog.Logger
+	logTag  string
+}
+
+// NewThrottledCloud runs the calls of c once policy allows. Calls wait for
+// the limit of their method before they wait for MaxConcurrent, so that
+// calls waiting for a busy method do not hold up the other methods.
+func NewThrottledCloud(c Cloud, policy ThrottlePolicy, logger boshlog.Logger) Cloud {
+	cloud := throttledCloud{
+		cloud:   c,
+		methods: map[string]chan struct{}{},
+		logger:  logger,
+		logTag:  "throttledCloud",
+	}
+
+	if policy.MaxConcurrent > 0 {
+		cloud.all = make(chan struct{}, policy.MaxConcurrent)
+	}
+
+	for method, limit := range policy.Methods {
+		if limit > 0 {
+			cloud.methods[method] = make(chan struct{}, limit)
+		}
+	}
+
+	return cloud
+}
+
+func (c throttledCloud) throttle(method string, call func() error) error {
+	for _, semaphore := range []chan struct{}{c.methods[method], c.all} {
+		if semaphore == nil {
+			continue
+		}
+
+		select {
+		case semaphore <- struct{}{}:
+		default:
+			c.logger.Debug(c.logTag, "Waiting for running CPI calls before calling '%s'", method)
+			semaphore <- struct{}{}
+		}
+
+		defer func(semaphore chan struct{}) { <-semaphore }(semaphore)
+	}
+
+	return call()
+}
+
+func (c throttledCloud) Info() (info Info, err error) {
+	err = c.throttle("info", func() error {
+		info, err = c.cloud.Info()
+		return err
+	})
+	return info, err
+}
+
+func (c throttledCloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (cid string, err error) {
+	err = c.throttle("create_stemcell", func() error {
+		cid, err = c.cloud.CreateStemcell(imagePath, cloudProperties)
+		return err
+	})
+	return cid, err
+}
+
+func (c throttledCloud) DeleteStemcell(stemcellCID string) error {
+	return c.throttle("delete_stemcell", func() error {
+		return c.cloud.DeleteStemcell(stemcellCID)
+	})
+}
+
+func (c throttledCloud) HasVM(vmCID string) (found bool, err error) {
+	err = c.throttle("has_vm", func() error {
+		found, err = c.cloud.HasVM(vmCID)
+		return err
+	})
+	return found, err
+}
+
+func (c throttledCloud) HasDisk(diskCID string) (found bool, err error) {
+	err = c.throttle("has_disk", func() error {
+		found, err = c.cloud.HasDisk(diskCID)
+		return err
+	})
+	return found, err
+}
+
+func (c throttledCloud) CreateVM(agentID string, stemcellCID string, cloudProperties biproperty.Map, networksInterfaces map[string]biproperty.Map, env biproperty.Map) (cid string, err error) {
+	err = c.throttle("create_vm", func() error {
+		cid, err = c.cloud.CreateVM(agentID, stemcellCID, cloudProperties, networksInterfaces, env)
+		return err
+	})
+	return cid, err
+}
+
+func (c throttledCloud) SetVMMetadata(vmCID string, metadata VMMetadata) error {
+	return c.throttle("set_vm_metadata", func() error {
+		return c.cloud.SetVMMetadata(vmCID, metadata)
+	})
+}
+
+func (c throttledCloud) SetDiskMetadata(diskCID string, metadata DiskMetadata) error {
+	return c.throttle("set_disk_metadata", func() error {
+		return c.cloud.SetDiskMetadata(diskCID, metadata)
+	})
+}
+
+func (c throttledCloud) DeleteVM(vmCID string) error {
+	return c.throttle("delete_vm", func() error {
+		return c.cloud.DeleteVM(vmCID)
+	})
+}
+
+func (c throttledCloud) CreateDisk(size int, cloudProperties biproperty.Map, vmCID string) (cid string, err error) {
+	err = c.throttle("create_disk", func() error {
+		cid, err = c.cloud.CreateDisk(size, cloudProperties, vmCID)
+		return err
+	})
+	return cid, err
+}
+
+func (c throttledCloud) AttachDisk(vmCID, diskCID string) error {
+	return c.throttle("attach_disk", func() error {
+		return c.cloud.AttachDisk(vmCID, diskCID)
+	})
+}
+
+func (c throttledCloud) DetachDisk(vmCID, diskCID string) error {
+	return c.throttle("detach_disk", func() error {
+		return c.cloud.DetachDisk(vmCID, diskCID)
+	})
+}
+
+func (c throttledCloud) DeleteDisk(diskCID string) error {
+	return c.throttle("delete_disk", func() error {
+		return c.cloud.DeleteDisk(diskCID)
+	})
+}
+
+func (c throttledCloud) String() string {
+	return c.cloud.String()
+}
@@ -0,0 +1,176 @@
+package cloud_test
+
+import (
+	"errors"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cloud"
+	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
+)
+
+// concurrencyRecorder records the most calls that were running at once
+type concurrencyRecorder struct {
+	lock    sync.Mutex
+	running int
+	max     int
+	release chan struct{}
+}
+
+func (r *concurrencyRecorder) call() {
+	r.lock.Lock()
+	r.running++
+	if r.running > r.max {
+		r.max = r.running
+	}
+	r.lock.Unlock()
+
+	<-r.release
+
+	r.lock.Lock()
+	r.running--
+	r.lock.Unlock()
+}
+
+func (r *concurrencyRecorder) Running() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.running
+}
+
+func (r *concurrencyRecorder) Max() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.max
+}
+
+var _ = Describe("ThrottledCloud", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		mockCloud *mock_cloud.MockCloud
+		recorder  *concurrencyRecorder
+		policy    ThrottlePolicy
+		cloud     Cloud
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+		recorder = &concurrencyRecorder{release: make(chan struct{})}
+		policy = ThrottlePolicy{}
+	})
+
+	JustBeforeEach(func() {
+		cloud = NewThrottledCloud(mockCloud, policy, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	runConcurrently := func(count int, call func()) *sync.WaitGroup {
+		wg := &sync.WaitGroup{}
+		for i := 0; i < count; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				call()
+			}()
+		}
+		return wg
+	}
+
+	releaseAll := func(count int, wg *sync.WaitGroup) {
+		for i := 0; i < count; i++ {
+			recorder.release <- struct{}{}
+		}
+		wg.Wait()
+	}
+
+	Context("with max_concurrent", func() {
+		BeforeEach(func() {
+			policy = ThrottlePolicy{MaxConcurrent: 2}
+		})
+
+		It("runs at most that many calls of any methods at once", func() {
+			mockCloud.EXPECT().DeleteVM("fake-vm-cid").Do(func(string) { recorder.call() }).Return(nil).Times(2)
+			mockCloud.EXPECT().DeleteDisk("fake-disk-cid").Do(func(string) { recorder.call() }).Return(nil).Times(2)
+
+			wg := runConcurrently(2, func() { Expect(cloud.DeleteVM("fake-vm-cid")).To(Succeed()) })
+			wg2 := runConcurrently(2, func() { Expect(cloud.DeleteDisk("fake-disk-cid")).To(Succeed()) })
+
+			Eventually(recorder.Running).Should(Equal(2))
+			Consistently(recorder.Running).Should(Equal(2))
+
+			releaseAll(4, wg)
+			wg2.Wait()
+
+			Expect(recorder.Max()).To(Equal(2))
+		})
+	})
+
+	Context("with method limits", func() {
+		BeforeEach(func() {
+			policy = ThrottlePolicy{MaxConcurrent: 3, Methods: map[string]int{"create_vm": 1}}
+		})
+
+		It("runs at most that many calls of the method at once", func() {
+			mockCloud.EXPECT().CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil).Do(
+				func(string, string, biproperty.Map, map[string]biproperty.Map, biproperty.Map) { recorder.call() },
+			).Return("fake-vm-cid", nil).Times(3)
+
+			wg := runConcurrently(3, func() {
+				cid, err := cloud.CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, nil, nil)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(cid).To(Equal("fake-vm-cid"))
+			})
+
+			Eventually(recorder.Running).Should(Equal(1))
+			Consistently(recorder.Running).Should(Equal(1))
+
+			releaseAll(3, wg)
+
+			Expect(recorder.Max()).To(Equal(1))
+		})
+
+		It("does not limit other methods by the limit of the method", func() {
+			mockCloud.EXPECT().HasVM("fake-vm-cid").Do(func(string) { recorder.call() }).Return(true, nil).Times(3)
+
+			wg := runConcurrently(3, func() {
+				found, err := cloud.HasVM("fake-vm-cid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+			})
+
+			Eventually(recorder.Running).Should(Equal(3))
+
+			releaseAll(3, wg)
+		})
+	})
+
+	It("releases the limit when calls fail", func() {
+		policy = ThrottlePolicy{Methods: map[string]int{"delete_vm": 1}}
+		cloud = NewThrottledCloud(mockCloud, policy, boshlog.NewLogger(boshlog.LevelNone))
+
+		mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(errors.New("fake-delete-err"))
+		mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(nil)
+
+		Expect(cloud.DeleteVM("fake-vm-cid")).To(MatchError("fake-delete-err"))
+		Expect(cloud.DeleteVM("fake-vm-cid")).To(Succeed())
+	})
+
+	Describe("ThrottlePolicy", func() {
+		It("is empty without positive limits", func() {
+			Expect(ThrottlePolicy{}.IsEmpty()).To(BeTrue())
+			Expect(ThrottlePolicy{Methods: map[string]int{"create_vm": 0}}.IsEmpty()).To(BeTrue())
+			Expect(ThrottlePolicy{MaxConcurrent: 1}.IsEmpty()).To(BeFalse())
+			Expect(ThrottlePolicy{Methods: map[string]int{"create_vm": 1}}.IsEmpty()).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,118 @@
+package cloud
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// hungCPIKillGracePeriod is how long an aborted CPI has to exit after
+// SIGTERM before it is killed
+const hungCPIKillGracePeriod = 10 * time.Second
+
+// Watchdog detects CPI calls that appear hung because the CPI has not
+// written to stdout or stderr for HungAfter. A zero Watchdog is disabled.
+type Watchdog struct {
+	HungAfter time.Duration
+
+	// OnHung is called each HungAfter while a call appears hung,
+	// returning true aborts the call
+	OnHung func(method string, elapsed time.Duration) bool
+
+	Clock clock.Clock
+}
+
+func (w Watchdog) IsEnabled() bool {
+	return w.HungAfter > 0
+}
+
+func (w Watchdog) run(cmdRunner boshsys.CmdRunner, method string, cmd boshsys.Command) (string, string, int, error) {
+	started := w.Clock.Now()
+	activity := &activityTracker{lastActivity: started, clock: w.Clock}
+
+	stdout := &bytes.Buffer{}
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = activity.writer(stdout)
+	cmd.Stderr = activity.writer(stderr)
+
+	process, err := cmdRunner.RunComplexCommandAsync(cmd)
+	if err != nil {
+		return "", "", -1, err
+	}
+
+	resultCh := process.Wait()
+
+	ticker := w.Clock.NewTicker(w.HungAfter)
+	defer ticker.Stop()
+
+	aborted := false
+
+	for {
+		select {
+		case result := <-resultCh:
+			if aborted {
+				return activity.String(stdout), activity.String(stderr), result.ExitStatus, bosherr.Errorf("Aborted CPI '%s' call that appeared hung", method)
+			}
+			return activity.String(stdout), activity.String(stderr), result.ExitStatus, result.Error
+
+		case <-ticker.C():
+			now := w.Clock.Now()
+			if aborted || now.Sub(activity.last()) < w.HungAfter {
+				continue
+			}
+
+			if w.OnHung != nil && w.OnHung(method, now.Sub(started)) {
+				aborted = true
+
+				err := process.TerminateNicely(hungCPIKillGracePeriod)
+				if err != nil {
+					return activity.String(stdout), activity.String(stderr), -1, bosherr.WrapErrorf(err, "Aborting CPI '%s' call", method)
+				}
+			}
+		}
+	}
+}
+
+// activityTracker records when the CPI last wrote output
+type activityTracker struct {
+	lastActivity time.Time
+	clock        clock.Clock
+	lock         sync.Mutex
+}
+
+func (t *activityTracker) writer(w io.Writer) io.Writer {
+	return activityWriter{tracker: t, writer: w}
+}
+
+func (t *activityTracker) last() time.Time {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return t.lastActivity
+}
+
+func (t *activityTracker) String(buffer *bytes.Buffer) string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	return buffer.String()
+}
+
+type activityWriter struct {
+	tracker *activityTracker
+	writer  io.Writer
+}
+
+func (w activityWriter) Write(p []byte) (int, error) {
+	w.tracker.lock.Lock()
+	defer w.tracker.lock.Unlock()
+
+	w.tracker.lastActivity = w.tracker.clock.Now()
+
+	return w.writer.Write(p)
+}
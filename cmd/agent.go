@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type AgentCmd struct {
+	ui          boshui.UI
+	envProvider func(string, string, boshtpl.Variables, patch.Op) AgentActionSender
+}
+
+func NewAgentCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) AgentActionSender) *AgentCmd {
+	return &AgentCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *AgentCmd) Run(opts AgentOpts) error {
+	if !opts.Advanced {
+		return bosherr.Error("Sending raw actions to the agent can break the environment, pass --advanced to continue")
+	}
+
+	arguments := []interface{}{}
+
+	if opts.Args.Arguments != "" {
+		err := json.Unmarshal([]byte(opts.Args.Arguments), &arguments)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Expected action arguments to be a JSON array")
+		}
+	}
+
+	sender := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	response, err := sender.Send(opts.Args.Action, arguments)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending action '%s' to the agent", opts.Args.Action)
+	}
+
+	c.ui.PrintBlock(response)
+	c.ui.PrintBlock([]byte("\n"))
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	biagentclient "github.com/cloudfoundry/bosh-cli/agentclient"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+type AgentActionSender interface {
+	// Send returns the raw agent response to the action
+	Send(action string, arguments []interface{}) ([]byte, error)
+}
+
+func NewAgentActionSender(
+	logTag string,
+	logger boshlog.Logger,
+	deploymentStateService biconfig.DeploymentStateService,
+	rawAgentClientFactory biagentclient.RawAgentClientFactory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+) AgentActionSender {
+	return &agentActionSender{
+		logTag:                                  logTag,
+		logger:                                  logger,
+		deploymentStateService:                  deploymentStateService,
+		rawAgentClientFactory:                   rawAgentClientFactory,
+		deploymentManifestPath:                  deploymentManifestPath,
+		deploymentVars:                          deploymentVars,
+		deploymentOp:                            deploymentOp,
+		releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+	}
+}
+
+type agentActionSender struct {
+	logTag                                  string
+	logger                                  boshlog.Logger
+	deploymentStateService                  biconfig.DeploymentStateService
+	rawAgentClientFactory                   biagentclient.RawAgentClientFactory
+	deploymentManifestPath                  string
+	deploymentVars                          boshtpl.Variables
+	deploymentOp                            patch.Op
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser
+}
+
+func (s *agentActionSender) Send(action string, arguments []interface{}) ([]byte, error) {
+	if !s.deploymentStateService.Exists() {
+		return nil, bosherr.Errorf("Deployment state '%s' does not exist", s.deploymentStateService.Path())
+	}
+
+	deploymentState, err := s.deploymentStateService.Load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	if deploymentState.CurrentVMCID == "" {
+		return nil, bosherr.Error("No deployed VM found in the deployment state")
+	}
+
+	_, installationManifest, err := s.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(s.deploymentManifestPath, s.deploymentVars, s.deploymentOp)
+	if err != nil {
+		return nil, err
+	}
+
+	agentClient, err := s.rawAgentClientFactory.NewRawAgentClient(deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating agent client")
+	}
+
+	s.logger.Debug(s.logTag, "Sending action '%s' to the agent of VM '%s'", action, deploymentState.CurrentVMCID)
+
+	return agentClient.SendAction(action, arguments)
+}
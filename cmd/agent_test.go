@@ -0,0 +1,93 @@
+package cmd_test
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicmd "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+var _ = Describe("AgentCmd", func() {
+	var (
+		mockCtrl              *gomock.Controller
+		mockAgentActionSender *mock_cmd.MockAgentActionSender
+		fakeUI                *fakeui.FakeUI
+		opts                  bicmd.AgentOpts
+		command               *bicmd.AgentCmd
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAgentActionSender = mock_cmd.NewMockAgentActionSender(mockCtrl)
+		fakeUI = &fakeui.FakeUI{}
+
+		envProvider := func(manifestPath, statePath string, vars boshtpl.Variables, op patch.Op) bicmd.AgentActionSender {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			Expect(statePath).To(Equal("/fake-state.json"))
+			return mockAgentActionSender
+		}
+
+		command = bicmd.NewAgentCmd(fakeUI, envProvider)
+
+		opts = bicmd.AgentOpts{
+			Args: bicmd.AgentArgs{
+				Manifest: bicmd.FileBytesWithPathArg{Path: "/fake-manifest.yml"},
+				Action:   "get_state",
+			},
+			StatePath: "/fake-state.json",
+			Advanced:  true,
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("sends the action without arguments and prints the raw response", func() {
+		mockAgentActionSender.EXPECT().Send("get_state", []interface{}{}).Return([]byte(`{"value":{"job_state":"running"}}`), nil)
+
+		err := command.Run(opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeUI.Blocks).To(Equal([]string{`{"value":{"job_state":"running"}}`, "\n"}))
+	})
+
+	It("sends the JSON arguments", func() {
+		opts.Args.Action = "run_script"
+		opts.Args.Arguments = `["pre-start", {}]`
+
+		mockAgentActionSender.EXPECT().Send("run_script", []interface{}{"pre-start", map[string]interface{}{}}).Return([]byte(`{"value":"ok"}`), nil)
+
+		err := command.Run(opts)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("requires --advanced", func() {
+		opts.Advanced = false
+
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("pass --advanced to continue"))
+	})
+
+	It("returns an error when the arguments are not a JSON array", func() {
+		opts.Args.Arguments = `{"key":"value"}`
+
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Expected action arguments to be a JSON array"))
+	})
+
+	It("returns an error when sending fails", func() {
+		mockAgentActionSender.EXPECT().Send("get_state", []interface{}{}).Return(nil, bosherr.Error("fake-send-error"))
+
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Sending action 'get_state' to the agent: fake-send-error"))
+	})
+})
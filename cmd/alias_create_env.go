@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	bistatebackend "github.com/cloudfoundry/bosh-cli/config/statebackend"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type AliasCreateEnvCmd struct {
+	config cmdconf.Config
+	ui     boshui.UI
+	fs     boshsys.FileSystem
+}
+
+func NewAliasCreateEnvCmd(config cmdconf.Config, ui boshui.UI, fs boshsys.FileSystem) AliasCreateEnvCmd {
+	return AliasCreateEnvCmd{config: config, ui: ui, fs: fs}
+}
+
+func (c AliasCreateEnvCmd) Run(opts AliasCreateEnvOpts) error {
+	manifestPath, err := c.absPath(opts.Manifest)
+	if err != nil {
+		return err
+	}
+
+	if !c.fs.FileExists(manifestPath) {
+		return bosherr.Errorf("Expected manifest '%s' to exist", manifestPath)
+	}
+
+	files := cmdconf.EnvironmentFiles{Manifest: manifestPath}
+
+	for _, path := range opts.VarsFiles {
+		absPath, err := c.absPath(path)
+		if err != nil {
+			return err
+		}
+		files.VarsFiles = append(files.VarsFiles, absPath)
+	}
+
+	for _, path := range opts.OpsFiles {
+		absPath, err := c.absPath(path)
+		if err != nil {
+			return err
+		}
+		files.OpsFiles = append(files.OpsFiles, absPath)
+	}
+
+	if bistatebackend.IsRemote(opts.StatePath) {
+		files.State = opts.StatePath
+	} else if opts.StatePath != "" {
+		files.State, err = c.absPath(opts.StatePath)
+		if err != nil {
+			return err
+		}
+	}
+
+	updatedConfig, err := c.config.SetEnvironmentFiles(opts.Args.Alias, files)
+	if err != nil {
+		return err
+	}
+
+	err = updatedConfig.Save()
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintLinef("Environment '%s' uses manifest '%s'", opts.Args.Alias, manifestPath)
+
+	return nil
+}
+
+func (c AliasCreateEnvCmd) absPath(path string) (string, error) {
+	absPath, err := c.fs.ExpandPath(path)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Getting absolute path '%s'", path)
+	}
+
+	return absPath, nil
+}
@@ -0,0 +1,130 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	fakecmdconf "github.com/cloudfoundry/bosh-cli/cmd/config/configfakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("AliasCreateEnvCmd", func() {
+	var (
+		config        *fakecmdconf.FakeConfig
+		updatedConfig *fakecmdconf.FakeConfig
+		ui            *fakeui.FakeUI
+		fs            *fakesys.FakeFileSystem
+		command       AliasCreateEnvCmd
+	)
+
+	BeforeEach(func() {
+		config = &fakecmdconf.FakeConfig{}
+		updatedConfig = &fakecmdconf.FakeConfig{}
+		config.SetEnvironmentFilesReturns(updatedConfig, nil)
+
+		ui = &fakeui.FakeUI{}
+		fs = fakesys.NewFakeFileSystem()
+
+		command = NewAliasCreateEnvCmd(config, ui, fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts AliasCreateEnvOpts
+		)
+
+		BeforeEach(func() {
+			opts = AliasCreateEnvOpts{
+				Args:      AliasEnvArgs{Alias: "prod"},
+				Manifest:  "/manifest.yml",
+				VarsFiles: []string{"/vars.yml", "/creds.yml"},
+				OpsFiles:  []string{"/ops.yml"},
+				StatePath: "/state.json",
+			}
+
+			err := fs.WriteFileString("/manifest.yml", "")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		act := func() error { return command.Run(opts) }
+
+		It("saves environment files and prints a confirmation", func() {
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(config.SetEnvironmentFilesCallCount()).To(Equal(1))
+			alias, files := config.SetEnvironmentFilesArgsForCall(0)
+			Expect(alias).To(Equal("prod"))
+			Expect(files).To(Equal(cmdconf.EnvironmentFiles{
+				Manifest:  "/manifest.yml",
+				VarsFiles: []string{"/vars.yml", "/creds.yml"},
+				OpsFiles:  []string{"/ops.yml"},
+				State:     "/state.json",
+			}))
+
+			Expect(updatedConfig.SaveCallCount()).To(Equal(1))
+			Expect(ui.Said).To(Equal([]string{"Environment 'prod' uses manifest '/manifest.yml'"}))
+		})
+
+		It("leaves state empty when state path is not given", func() {
+			opts.StatePath = ""
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, files := config.SetEnvironmentFilesArgsForCall(0)
+			Expect(files.State).To(BeEmpty())
+		})
+
+		It("keeps remote state locations as given", func() {
+			opts.StatePath = "s3://bucket/env/state.json"
+
+			err := act()
+			Expect(err).ToNot(HaveOccurred())
+
+			_, files := config.SetEnvironmentFilesArgsForCall(0)
+			Expect(files.State).To(Equal("s3://bucket/env/state.json"))
+		})
+
+		It("returns error if manifest does not exist", func() {
+			opts.Manifest = "/missing.yml"
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected manifest '/missing.yml' to exist"))
+
+			Expect(config.SetEnvironmentFilesCallCount()).To(Equal(0))
+		})
+
+		It("returns error if expanding path fails", func() {
+			fs.ExpandPathErr = errors.New("fake-err")
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if setting environment files fails", func() {
+			config.SetEnvironmentFilesReturns(nil, errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+		})
+
+		It("returns error if saving config fails", func() {
+			updatedConfig.SaveReturns(errors.New("fake-err"))
+
+			err := act()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-err"))
+
+			Expect(ui.Said).To(BeEmpty())
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"os"
+
 	"code.cloudfoundry.org/clock"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
@@ -8,22 +10,39 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biarchive "github.com/cloudfoundry/bosh-cli/common/archive"
 	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+	bifault "github.com/cloudfoundry/bosh-cli/faultinjection"
+	bitracing "github.com/cloudfoundry/bosh-cli/tracing"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshi18n "github.com/cloudfoundry/bosh-cli/ui/i18n"
 )
 
 type BasicDeps struct {
-	FS     boshsys.FileSystem
-	UI     *boshui.ConfUI
-	Logger boshlog.Logger
+	FS       boshsys.FileSystem
+	UI       *boshui.ConfUI
+	Messages boshi18n.Catalog
+	Logger   boshlog.Logger
 
 	UUIDGen                  boshuuid.Generator
 	CmdRunner                boshsys.CmdRunner
 	Compressor               boshcmd.Compressor
 	DigestCalculator         bicrypto.DigestCalculator
 	DigestCreationAlgorithms []boshcrypto.Algorithm
+	ChecksumProvider         bicrypto.ChecksumProvider
+
+	Time   clock.Clock
+	Tracer bitracing.Tracer
+
+	// CPIWatchdog is disabled unless --cpi-hung-timeout is given
+	CPIWatchdog bicloud.Watchdog
 
-	Time clock.Clock
+	// FaultInjector is nil unless failures of CPI and agent calls are injected for testing
+	FaultInjector bifault.Injector
+
+	// Parallel limits the number of CPI packages compiled at the same time
+	Parallel int
 }
 
 func NewBasicDeps(ui *boshui.ConfUI, logger boshlog.Logger) BasicDeps {
@@ -33,27 +52,57 @@ func NewBasicDeps(ui *boshui.ConfUI, logger boshlog.Logger) BasicDeps {
 func NewBasicDepsWithFS(ui *boshui.ConfUI, fs boshsys.FileSystem, logger boshlog.Logger) BasicDeps {
 	cmdRunner := boshsys.NewExecCmdRunner(logger)
 
-	digestCreationAlgorithms := []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1}
+	checksumProvider := bicrypto.NewChecksumProvider(false)
+	digestCreationAlgorithms := checksumProvider.CreationAlgorithms()
 	digestCalculator := bicrypto.NewDigestCalculator(fs, digestCreationAlgorithms)
 
 	return BasicDeps{
-		FS:     fs,
-		UI:     ui,
-		Logger: logger,
+		FS:       fs,
+		UI:       ui,
+		Messages: boshi18n.NewLoader(fs, logger).LoadFromEnv(os.Getenv),
+		Logger:   logger,
 
 		UUIDGen:                  boshuuid.NewGenerator(),
 		CmdRunner:                cmdRunner,
-		Compressor:               boshcmd.NewTarballCompressor(cmdRunner, fs),
+		Compressor:               newCompressor(cmdRunner, fs, logger),
 		DigestCalculator:         digestCalculator,
 		DigestCreationAlgorithms: digestCreationAlgorithms,
+		ChecksumProvider:         checksumProvider,
 		Time:                     clock.NewClock(),
+		Tracer:                   bitracing.NewNoopTracer(),
 	}
 }
 
 func (b BasicDeps) WithSha2CheckSumming() BasicDeps {
-	b.DigestCreationAlgorithms = []boshcrypto.Algorithm{
-		boshcrypto.DigestAlgorithmSHA256,
-	}
+	b.ChecksumProvider = bicrypto.NewChecksumProvider(true)
+	b.DigestCreationAlgorithms = b.ChecksumProvider.CreationAlgorithms()
 	b.DigestCalculator = bicrypto.NewDigestCalculator(b.FS, b.DigestCreationAlgorithms)
 	return b
 }
+
+func (b BasicDeps) WithTracer(tracer bitracing.Tracer) BasicDeps {
+	b.Tracer = tracer
+	return b
+}
+
+func (b BasicDeps) WithCPIWatchdog(watchdog bicloud.Watchdog) BasicDeps {
+	b.CPIWatchdog = watchdog
+	return b
+}
+
+func (b BasicDeps) WithFaultInjector(injector bifault.Injector) BasicDeps {
+	b.FaultInjector = injector
+	return b
+}
+
+func (b BasicDeps) WithParallel(parallel int) BasicDeps {
+	b.Parallel = parallel
+	return b
+}
+
+// WithParallelExtraction extracts gzipped tarballs in process with up to
+// workers files written at the same time
+func (b BasicDeps) WithParallelExtraction(workers int) BasicDeps {
+	b.Compressor = biarchive.NewParallelCompressor(b.Compressor, workers, b.Logger)
+	return b
+}
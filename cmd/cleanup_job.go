@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bicliagentclient "github.com/cloudfoundry/bosh-cli/agentclient"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+const defaultCleanupJobTimeout = 300 * time.Second
+
+// cleanupJobRunner runs the cloud_provider.cleanup_job script on the VM
+// before it is deleted so that the environment can remove the state it
+// left in external systems. The script is run through the raw agent client
+// so that it can be cancelled when it times out.
+type cleanupJobRunner struct {
+	agentClient    biagentclient.AgentClient
+	rawAgentClient bicliagentclient.RawAgentClient
+	logger         boshlog.Logger
+	logTag         string
+}
+
+func newCleanupJobRunner(agentClient biagentclient.AgentClient, rawAgentClient bicliagentclient.RawAgentClient, logger boshlog.Logger) cleanupJobRunner {
+	return cleanupJobRunner{
+		agentClient:    agentClient,
+		rawAgentClient: rawAgentClient,
+		logger:         logger,
+		logTag:         "cleanupJobRunner",
+	}
+}
+
+func (r cleanupJobRunner) Run(job biinstallmanifest.CleanupJob, vmCID string, stage biui.Stage) error {
+	stepName := fmt.Sprintf("Running cleanup job script '%s' on VM '%s'", job.Script, vmCID)
+
+	return stage.Perform(stepName, func() error {
+		// the VM of an unreachable agent is deleted anyway, e.g. when it is dead
+		_, err := r.agentClient.Ping()
+		if err != nil {
+			r.logger.Warn(r.logTag, "Skipping cleanup job script '%s', agent unreachable: %s", job.Script, err.Error())
+			return biui.NewSkipStageError(err, "Agent unreachable")
+		}
+
+		err = r.run(job)
+		if err == nil {
+			return nil
+		}
+
+		if job.OnFailure == biinstallmanifest.CleanupJobOnFailureContinue {
+			r.logger.Warn(r.logTag, "Continuing after cleanup job script '%s' failed: %s", job.Script, err.Error())
+			return biui.NewSkipStageError(err, "Cleanup job failed")
+		}
+
+		return bosherr.WrapErrorf(err, "Running cleanup job script '%s'", job.Script)
+	})
+}
+
+func (r cleanupJobRunner) run(job biinstallmanifest.CleanupJob) error {
+	timeout := defaultCleanupJobTimeout
+	if job.Timeout > 0 {
+		timeout = time.Duration(job.Timeout) * time.Second
+	}
+
+	response, err := r.rawAgentClient.SendActionWithTimeout("run_script", []interface{}{job.Script, map[string]interface{}{}}, timeout)
+	if err != nil {
+		return err
+	}
+
+	err = bicliagentclient.ResponseException(response)
+	if err != nil && strings.Contains(err.Error(), "unknown message") {
+		// agents of older stemcells do not know run_script, like for the other scripts
+		r.logger.Warn(r.logTag, "Ignoring run_script 'unknown message' error from the agent: %s", err.Error())
+		return nil
+	}
+
+	return err
+}
@@ -2,18 +2,32 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"time"
 
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	cfgtypes "github.com/cloudfoundry/config-server/types"
 	"github.com/cppforlife/go-patch/patch"
 
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	"github.com/cloudfoundry/bosh-cli/crypto"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	boshreldir "github.com/cloudfoundry/bosh-cli/releasedir"
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	bitracing "github.com/cloudfoundry/bosh-cli/tracing"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	boshuit "github.com/cloudfoundry/bosh-cli/ui/task"
 
@@ -22,6 +36,8 @@ import (
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 )
 
+const otlpExportTimeout = 10 * time.Second
+
 type Cmd struct {
 	BoshOpts BoshOpts
 	Opts     interface{}
@@ -38,6 +54,33 @@ type cmdConveniencePanic struct {
 }
 
 func (c Cmd) Execute() (cmdErr error) {
+	if c.BoshOpts.OTelEndpoint != "" {
+		c.deps = c.deps.WithTracer(c.tracer(c.BoshOpts.OTelEndpoint))
+	}
+
+	if c.BoshOpts.CPIHungTimeoutOpt > 0 {
+		c.deps = c.deps.WithCPIWatchdog(bicloud.Watchdog{
+			HungAfter: c.BoshOpts.CPIHungTimeoutOpt,
+			OnHung:    NewCPIHungPrompt(c.deps.UI, c.deps.Messages).OnHung,
+			Clock:     c.deps.Time,
+		})
+	}
+
+	span := c.deps.Tracer.StartSpan(c.commandName(), bitracing.SpanKindInternal, nil)
+
+	defer func() {
+		span.End(cmdErr)
+
+		err := c.deps.Tracer.Shutdown()
+		if err != nil {
+			c.deps.Logger.Warn("cmd", "Failed to export tracing spans: %s", err.Error())
+		}
+	}()
+
+	return c.execute()
+}
+
+func (c Cmd) execute() (cmdErr error) {
 	// Catch convenience panics from panicIfErr
 	defer func() {
 		if r := recover(); r != nil {
@@ -56,6 +99,9 @@ func (c Cmd) Execute() (cmdErr error) {
 		c.deps = c.deps.WithSha2CheckSumming()
 	}
 
+	c.deps = c.deps.WithParallel(c.BoshOpts.Parallel)
+	c.deps = c.deps.WithParallelExtraction(c.extractionWorkers())
+
 	deps := c.deps
 
 	switch opts := c.Opts.(type) {
@@ -63,23 +109,172 @@ func (c Cmd) Execute() (cmdErr error) {
 		return NewEnvironmentCmd(deps.UI, c.director()).Run()
 
 	case *EnvironmentsOpts:
-		return NewEnvironmentsCmd(c.config(), deps.UI).Run()
+		return NewEnvironmentsCmdWithCurrent(c.config(), deps.UI, deps.FS, c.BoshOpts.EnvironmentOpt).Run(*opts)
 
 	case *CreateEnvOpts:
+		if opts.KeepExtractedArtifacts {
+			keepingFS := NewKeepingFileSystem(deps.FS)
+			deps.FS = keepingFS
+			defer keepingFS.PrintKept(deps.UI)
+		}
+
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentPreparer {
-			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.RecreatePersistentDisks).Preparer()
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, opts.RecreatePersistentDisks).WithConfirmation(c.destructiveConfirmation()).Preparer()
 		}
 
-		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
-		return NewCreateEnvCmd(deps.UI, envProvider).Run(stage, *opts)
+		err := NewEnvironmentFilesResolver(c.config(), deps.FS).Resolve(
+			c.BoshOpts.EnvironmentOpt, &opts.Args.Manifest.FileBytesWithPathArg, &opts.VarFlags, &opts.OpsFlags, &opts.StatePath)
+		if err != nil {
+			return err
+		}
+
+		stage := c.stage()
+		return NewCreateEnvCmdWithOutputs(deps.UI, envProvider, c.destructiveConfirmation(), deps.FS).Run(stage, *opts)
 
 	case *DeleteEnvOpts:
 		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) DeploymentDeleter {
 			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).Deleter()
 		}
 
-		stage := boshui.NewStage(deps.UI, deps.Time, deps.Logger)
-		return NewDeleteEnvCmd(deps.UI, envProvider).Run(stage, *opts)
+		err := NewEnvironmentFilesResolver(c.config(), deps.FS).Resolve(
+			c.BoshOpts.EnvironmentOpt, &opts.Args.Manifest.FileBytesWithPathArg, &opts.VarFlags, &opts.OpsFlags, &opts.StatePath)
+		if err != nil {
+			return err
+		}
+
+		stage := c.stage()
+		return NewDeleteEnvCmd(deps.UI, envProvider, c.destructiveConfirmation()).Run(stage, *opts)
+
+	case *ValidateEnvOpts:
+		var statePath string
+
+		err := NewEnvironmentFilesResolver(c.config(), deps.FS).Resolve(
+			c.BoshOpts.EnvironmentOpt, &opts.Args.Manifest, &opts.VarFlags, &opts.OpsFlags, &statePath)
+		if err != nil {
+			return err
+		}
+
+		return NewValidateEnvCmd(
+			deps.UI,
+			birelsetmanifest.NewParser(deps.FS, deps.Logger, birelsetmanifest.NewValidator(deps.Logger)),
+			biinstallmanifest.NewParser(deps.FS, deps.UUIDGen, deps.Logger, biinstallmanifest.NewValidator(deps.Logger)),
+			bideplmanifest.NewParser(deps.FS, deps.Logger),
+			bideplmanifest.NewValidator(deps.Logger),
+		).Run(*opts)
+
+	case *WatchOpts:
+		validateEnvCmd := NewValidateEnvCmd(
+			deps.UI,
+			birelsetmanifest.NewParser(deps.FS, deps.Logger, birelsetmanifest.NewValidator(deps.Logger)),
+			biinstallmanifest.NewParser(deps.FS, deps.UUIDGen, deps.Logger, biinstallmanifest.NewValidator(deps.Logger)),
+			bideplmanifest.NewParser(deps.FS, deps.Logger),
+			bideplmanifest.NewValidator(deps.Logger),
+		)
+
+		return NewWatchCmd(deps.UI, deps.FS, deps.Time, validateEnvCmd, signal.Notify).Run(*opts)
+
+	case *TestCpiOpts:
+		envProvider := func(manifestPath string, vars boshtpl.Variables, op patch.Op) CpiLifecycleTester {
+			return NewEnvFactory(deps, manifestPath, "", vars, op, false).LifecycleTester()
+		}
+
+		stage := c.stage()
+		return NewTestCpiCmd(deps.UI, envProvider).Run(stage, *opts)
+
+	case *InitEnvOpts:
+		return NewInitEnvCmd(deps.UI, deps.FS).Run(*opts)
+
+	case *GenerateManifestOpts:
+		relProv, _ := c.releaseProviders()
+
+		return NewGenerateManifestCmd(relProv.NewExtractingArchiveReader(), deps.UI).Run(*opts)
+
+	case *OrphanedDisksOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) OrphanedDisksManager {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).OrphanedDisksManager()
+		}
+
+		stage := c.stage()
+		return NewOrphanedDisksCmd(deps.UI, envProvider, c.destructiveConfirmation()).Run(stage, *opts)
+
+	case *EnvStemcellsOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvStemcellsManager {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).EnvStemcellsManager()
+		}
+
+		stage := c.stage()
+		return NewEnvStemcellsCmd(deps.UI, envProvider, c.destructiveConfirmation()).Run(stage, *opts)
+
+	case *PruneStateOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) PruneStateManager {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).PruneStateManager()
+		}
+
+		stage := c.stage()
+		return NewPruneStateCmd(deps.UI, envProvider).Run(stage, *opts)
+
+	case *EnvStatusOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) biconfig.DeploymentStateService {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).DeploymentStateService()
+		}
+
+		err := NewEnvironmentFilesResolver(c.config(), deps.FS).Resolve(
+			c.BoshOpts.EnvironmentOpt, &opts.Args.Manifest, &opts.VarFlags, &opts.OpsFlags, &opts.StatePath)
+		if err != nil {
+			return err
+		}
+
+		return NewEnvStatusCmd(deps.UI, envProvider).Run(*opts)
+
+	case *AgentOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) AgentActionSender {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).AgentActionSender()
+		}
+
+		return NewAgentCmd(deps.UI, envProvider).Run(*opts)
+
+	case *EnvSSHOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) AgentActionSender {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).AgentActionSender()
+		}
+
+		err := NewEnvironmentFilesResolver(c.config(), deps.FS).Resolve(
+			c.BoshOpts.EnvironmentOpt, &opts.Args.Manifest, &opts.VarFlags, &opts.OpsFlags, &opts.StatePath)
+		if err != nil {
+			return err
+		}
+
+		sshProvider := boshssh.NewProvider(deps.CmdRunner, deps.FS, deps.UI, deps.Logger)
+		intSSHRunner := sshProvider.NewSSHRunner(true)
+		nonIntSSHRunner := sshProvider.NewSSHRunner(false)
+		return NewEnvSSHCmd(envProvider, intSSHRunner, nonIntSSHRunner, deps.UI).Run(*opts)
+
+	case *EnvLogsOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) EnvLogsFetcher {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).EnvLogsFetcher()
+		}
+
+		err := NewEnvironmentFilesResolver(c.config(), deps.FS).Resolve(
+			c.BoshOpts.EnvironmentOpt, &opts.Args.Manifest, &opts.VarFlags, &opts.OpsFlags, &opts.StatePath)
+		if err != nil {
+			return err
+		}
+
+		return NewEnvLogsCmd(deps.UI, deps.Time, envProvider).Run(*opts)
+
+	case *RotateCredsOpts:
+		envProvider := func(manifestPath string, statePath string, vars boshtpl.Variables, op patch.Op) CredsRotationEnv {
+			return NewEnvFactory(deps, manifestPath, statePath, vars, op, false).CredsRotationEnv()
+		}
+
+		err := NewEnvironmentFilesResolver(c.config(), deps.FS).Resolve(
+			c.BoshOpts.EnvironmentOpt, &opts.Args.Manifest, &opts.VarFlags, &opts.OpsFlags, &opts.StatePath)
+		if err != nil {
+			return err
+		}
+
+		stage := c.stage()
+		return NewRotateCredsCmd(deps.UI, envProvider, cfgtypes.NewPasswordGenerator(), c.destructiveConfirmation()).Run(stage, *opts)
 
 	case *AliasEnvOpts:
 		sessionFactory := func(config cmdconf.Config) Session {
@@ -88,6 +283,9 @@ func (c Cmd) Execute() (cmdErr error) {
 
 		return NewAliasEnvCmd(sessionFactory, c.config(), deps.UI).Run(*opts)
 
+	case *AliasCreateEnvOpts:
+		return NewAliasCreateEnvCmd(c.config(), deps.UI, deps.FS).Run(*opts)
+
 	case *LogInOpts:
 		sessionFactory := func(config cmdconf.Config) Session {
 			return NewSessionFromOpts(c.BoshOpts, config, deps.UI, true, true, deps.FS, deps.Logger)
@@ -285,6 +483,9 @@ func (c Cmd) Execute() (cmdErr error) {
 	case *InspectReleaseOpts:
 		return NewInspectReleaseCmd(deps.UI, c.director()).Run(*opts)
 
+	case *InspectReleaseTarballOpts:
+		return NewInspectReleaseTarballCmd(boshrel.NewTarballInspector(deps.FS).Inspect, deps.UI).Run(*opts)
+
 	case *VMsOpts:
 		return NewVMsCmd(deps.UI, c.director(), c.BoshOpts.Parallel).Run(*opts)
 
@@ -389,6 +590,10 @@ func (c Cmd) Execute() (cmdErr error) {
 		return err
 
 	case *Sha1ifyReleaseOpts:
+		if !c.deps.ChecksumProvider.Allows(boshcrypto.DigestAlgorithmSHA1) {
+			return bosherr.Error("Creating SHA-1 checksums is not allowed in FIPS mode")
+		}
+
 		relProv, _ := c.releaseProviders()
 
 		return NewRedigestReleaseCmd(
@@ -441,6 +646,34 @@ func (c Cmd) Execute() (cmdErr error) {
 		return fmt.Errorf("Unhandled command: %#v", c.Opts)
 	}
 }
+
+func (c Cmd) tracer(endpoint string) bitracing.Tracer {
+	exporter, err := bitracing.NewOTLPExporter(endpoint, &http.Client{Timeout: otlpExportTimeout}, c.deps.Logger)
+	if err != nil {
+		c.deps.Logger.Warn("cmd", "Disabling tracing: %s", err.Error())
+		return bitracing.NewNoopTracer()
+	}
+
+	return bitracing.NewTracer(exporter, c.deps.Time)
+}
+
+// commandName finds the command name given to the opts in BoshOpts, e.g. 'create-env'
+func (c Cmd) commandName() string {
+	optsType := reflect.TypeOf(c.Opts)
+	if optsType != nil && optsType.Kind() == reflect.Ptr {
+		optsType = optsType.Elem()
+	}
+
+	boshOptsType := reflect.TypeOf(c.BoshOpts)
+	for i := 0; i < boshOptsType.NumField(); i++ {
+		field := boshOptsType.Field(i)
+		if field.Type == optsType && field.Tag.Get("command") != "" {
+			return field.Tag.Get("command")
+		}
+	}
+
+	return "bosh"
+}
 func (c Cmd) configureUI() {
 	c.deps.UI.EnableTTY(c.BoshOpts.TTYOpt)
 
@@ -456,6 +689,32 @@ func (c Cmd) configureUI() {
 		c.deps.UI.EnableNonInteractive()
 	}
 
+	if c.BoshOpts.TTYLogOpt != "" {
+		logPath, err := c.deps.FS.ExpandPath(c.BoshOpts.TTYLogOpt)
+		c.panicIfErr(err)
+
+		// Log file will be closed by process exit
+		logFile, err := c.deps.FS.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			c.panicIfErr(bosherr.WrapErrorf(err, "Opening TTY log file '%s'", logPath))
+		}
+
+		c.deps.UI.EnableOutputLog(boshui.NewTimestampWriter(logFile, c.deps.Time))
+	}
+
+	if c.BoshOpts.EventLogOpt != "" {
+		logPath, err := c.deps.FS.ExpandPath(c.BoshOpts.EventLogOpt)
+		c.panicIfErr(err)
+
+		// Log file will be closed by process exit
+		logFile, err := c.deps.FS.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			c.panicIfErr(bosherr.WrapErrorf(err, "Opening event log file '%s'", logPath))
+		}
+
+		c.deps.UI.EnableEventLog(logFile)
+	}
+
 	if len(c.BoshOpts.ColumnOpt) > 0 {
 		headers := []boshtbl.Header{}
 		for _, columnOpt := range c.BoshOpts.ColumnOpt {
@@ -474,6 +733,36 @@ func (c Cmd) configureFS() {
 	c.panicIfErr(err)
 }
 
+func (c Cmd) stage() boshui.Stage {
+	stage := boshui.NewStageWithKeepalive(c.deps.UI, c.deps.Time, c.deps.Logger, c.BoshOpts.KeepaliveIntervalOpt)
+
+	return bitracing.NewStage(stage, c.deps.Tracer)
+}
+
+func (c Cmd) destructiveConfirmation() DestructiveConfirmation {
+	return NewDestructiveConfirmation(c.deps.UI, c.config().ConfirmationPolicy())
+}
+
+// extractionWorkers limits parallel extraction to the available CPUs,
+// which are capped by --max-cpus
+func (c Cmd) extractionWorkers() int {
+	cpus := runtime.NumCPU()
+
+	if c.BoshOpts.MaxCPUs > 0 {
+		runtime.GOMAXPROCS(c.BoshOpts.MaxCPUs)
+
+		if c.BoshOpts.MaxCPUs < cpus {
+			cpus = c.BoshOpts.MaxCPUs
+		}
+	}
+
+	if c.BoshOpts.Parallel < cpus {
+		return c.BoshOpts.Parallel
+	}
+
+	return cpus
+}
+
 func (c Cmd) config() cmdconf.Config {
 	config, err := cmdconf.NewFSConfigFromPath(c.BoshOpts.ConfigPathOpt, c.deps.FS)
 	c.panicIfErr(err)
@@ -7,6 +7,7 @@ import (
 )
 
 type FakeInstallation struct {
+	RegistrySettingsOverlay []byte
 }
 
 func (f *FakeInstallation) Target() biinstallation.Target {
@@ -28,3 +29,8 @@ func (f *FakeInstallation) StartRegistry() error {
 func (f *FakeInstallation) StopRegistry() error {
 	return nil
 }
+
+func (f *FakeInstallation) SetRegistrySettingsOverlay(overlayJSON []byte) error {
+	f.RegistrySettingsOverlay = overlayJSON
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+// +build !windows
+
+package cmd
+
+import (
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+func newCompressor(cmdRunner boshsys.CmdRunner, fs boshsys.FileSystem, _ boshlog.Logger) boshcmd.Compressor {
+	return boshcmd.NewTarballCompressor(cmdRunner, fs)
+}
@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biarchive "github.com/cloudfoundry/bosh-cli/common/archive"
+)
+
+// Windows has no tar to shell out to
+func newCompressor(_ boshsys.CmdRunner, fs boshsys.FileSystem, logger boshlog.Logger) boshcmd.Compressor {
+	return biarchive.NewCompressor(fs, logger)
+}
@@ -54,6 +54,64 @@ type FakeConfig struct {
 	cACertReturnsOnCall map[int]struct {
 		result1 string
 	}
+	ConfirmationPolicyStub        func() config.ConfirmationPolicy
+	confirmationPolicyMutex       sync.RWMutex
+	confirmationPolicyArgsForCall []struct{}
+	confirmationPolicyReturns     struct {
+		result1 config.ConfirmationPolicy
+	}
+	confirmationPolicyReturnsOnCall map[int]struct {
+		result1 config.ConfirmationPolicy
+	}
+	EnvironmentFilesStub        func(alias string) (config.EnvironmentFiles, bool)
+	environmentFilesMutex       sync.RWMutex
+	environmentFilesArgsForCall []struct {
+		alias string
+	}
+	environmentFilesReturns struct {
+		result1 config.EnvironmentFiles
+		result2 bool
+	}
+	environmentFilesReturnsOnCall map[int]struct {
+		result1 config.EnvironmentFiles
+		result2 bool
+	}
+	SetEnvironmentFilesStub        func(alias string, files config.EnvironmentFiles) (config.Config, error)
+	setEnvironmentFilesMutex       sync.RWMutex
+	setEnvironmentFilesArgsForCall []struct {
+		alias string
+		files config.EnvironmentFiles
+	}
+	setEnvironmentFilesReturns struct {
+		result1 config.Config
+		result2 error
+	}
+	setEnvironmentFilesReturnsOnCall map[int]struct {
+		result1 config.Config
+		result2 error
+	}
+	CurrentCreateEnvEnvironmentStub        func() string
+	currentCreateEnvEnvironmentMutex       sync.RWMutex
+	currentCreateEnvEnvironmentArgsForCall []struct{}
+	currentCreateEnvEnvironmentReturns     struct {
+		result1 string
+	}
+	currentCreateEnvEnvironmentReturnsOnCall map[int]struct {
+		result1 string
+	}
+	SetCurrentCreateEnvEnvironmentStub        func(alias string) (config.Config, error)
+	setCurrentCreateEnvEnvironmentMutex       sync.RWMutex
+	setCurrentCreateEnvEnvironmentArgsForCall []struct {
+		alias string
+	}
+	setCurrentCreateEnvEnvironmentReturns struct {
+		result1 config.Config
+		result2 error
+	}
+	setCurrentCreateEnvEnvironmentReturnsOnCall map[int]struct {
+		result1 config.Config
+		result2 error
+	}
 	CredentialsStub        func(url string) config.Creds
 	credentialsMutex       sync.RWMutex
 	credentialsArgsForCall []struct {
@@ -290,6 +348,244 @@ func (fake *FakeConfig) CACertReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *FakeConfig) ConfirmationPolicy() config.ConfirmationPolicy {
+	fake.confirmationPolicyMutex.Lock()
+	ret, specificReturn := fake.confirmationPolicyReturnsOnCall[len(fake.confirmationPolicyArgsForCall)]
+	fake.confirmationPolicyArgsForCall = append(fake.confirmationPolicyArgsForCall, struct{}{})
+	fake.recordInvocation("ConfirmationPolicy", []interface{}{})
+	fake.confirmationPolicyMutex.Unlock()
+	if fake.ConfirmationPolicyStub != nil {
+		return fake.ConfirmationPolicyStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.confirmationPolicyReturns.result1
+}
+
+func (fake *FakeConfig) ConfirmationPolicyCallCount() int {
+	fake.confirmationPolicyMutex.RLock()
+	defer fake.confirmationPolicyMutex.RUnlock()
+	return len(fake.confirmationPolicyArgsForCall)
+}
+
+func (fake *FakeConfig) ConfirmationPolicyReturns(result1 config.ConfirmationPolicy) {
+	fake.ConfirmationPolicyStub = nil
+	fake.confirmationPolicyReturns = struct {
+		result1 config.ConfirmationPolicy
+	}{result1}
+}
+
+func (fake *FakeConfig) ConfirmationPolicyReturnsOnCall(i int, result1 config.ConfirmationPolicy) {
+	fake.ConfirmationPolicyStub = nil
+	if fake.confirmationPolicyReturnsOnCall == nil {
+		fake.confirmationPolicyReturnsOnCall = make(map[int]struct {
+			result1 config.ConfirmationPolicy
+		})
+	}
+	fake.confirmationPolicyReturnsOnCall[i] = struct {
+		result1 config.ConfirmationPolicy
+	}{result1}
+}
+
+func (fake *FakeConfig) EnvironmentFiles(alias string) (config.EnvironmentFiles, bool) {
+	fake.environmentFilesMutex.Lock()
+	ret, specificReturn := fake.environmentFilesReturnsOnCall[len(fake.environmentFilesArgsForCall)]
+	fake.environmentFilesArgsForCall = append(fake.environmentFilesArgsForCall, struct {
+		alias string
+	}{alias})
+	fake.recordInvocation("EnvironmentFiles", []interface{}{alias})
+	fake.environmentFilesMutex.Unlock()
+	if fake.EnvironmentFilesStub != nil {
+		return fake.EnvironmentFilesStub(alias)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.environmentFilesReturns.result1, fake.environmentFilesReturns.result2
+}
+
+func (fake *FakeConfig) EnvironmentFilesCallCount() int {
+	fake.confirmationPolicyMutex.RLock()
+	defer fake.confirmationPolicyMutex.RUnlock()
+	fake.environmentFilesMutex.RLock()
+	defer fake.environmentFilesMutex.RUnlock()
+	return len(fake.environmentFilesArgsForCall)
+}
+
+func (fake *FakeConfig) EnvironmentFilesArgsForCall(i int) string {
+	fake.confirmationPolicyMutex.RLock()
+	defer fake.confirmationPolicyMutex.RUnlock()
+	fake.environmentFilesMutex.RLock()
+	defer fake.environmentFilesMutex.RUnlock()
+	return fake.environmentFilesArgsForCall[i].alias
+}
+
+func (fake *FakeConfig) EnvironmentFilesReturns(result1 config.EnvironmentFiles, result2 bool) {
+	fake.EnvironmentFilesStub = nil
+	fake.environmentFilesReturns = struct {
+		result1 config.EnvironmentFiles
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeConfig) EnvironmentFilesReturnsOnCall(i int, result1 config.EnvironmentFiles, result2 bool) {
+	fake.EnvironmentFilesStub = nil
+	if fake.environmentFilesReturnsOnCall == nil {
+		fake.environmentFilesReturnsOnCall = make(map[int]struct {
+			result1 config.EnvironmentFiles
+			result2 bool
+		})
+	}
+	fake.environmentFilesReturnsOnCall[i] = struct {
+		result1 config.EnvironmentFiles
+		result2 bool
+	}{result1, result2}
+}
+
+func (fake *FakeConfig) SetEnvironmentFiles(alias string, files config.EnvironmentFiles) (config.Config, error) {
+	fake.setEnvironmentFilesMutex.Lock()
+	ret, specificReturn := fake.setEnvironmentFilesReturnsOnCall[len(fake.setEnvironmentFilesArgsForCall)]
+	fake.setEnvironmentFilesArgsForCall = append(fake.setEnvironmentFilesArgsForCall, struct {
+		alias string
+		files config.EnvironmentFiles
+	}{alias, files})
+	fake.recordInvocation("SetEnvironmentFiles", []interface{}{alias, files})
+	fake.setEnvironmentFilesMutex.Unlock()
+	if fake.SetEnvironmentFilesStub != nil {
+		return fake.SetEnvironmentFilesStub(alias, files)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.setEnvironmentFilesReturns.result1, fake.setEnvironmentFilesReturns.result2
+}
+
+func (fake *FakeConfig) SetEnvironmentFilesCallCount() int {
+	fake.setEnvironmentFilesMutex.RLock()
+	defer fake.setEnvironmentFilesMutex.RUnlock()
+	return len(fake.setEnvironmentFilesArgsForCall)
+}
+
+func (fake *FakeConfig) SetEnvironmentFilesArgsForCall(i int) (string, config.EnvironmentFiles) {
+	fake.setEnvironmentFilesMutex.RLock()
+	defer fake.setEnvironmentFilesMutex.RUnlock()
+	return fake.setEnvironmentFilesArgsForCall[i].alias, fake.setEnvironmentFilesArgsForCall[i].files
+}
+
+func (fake *FakeConfig) SetEnvironmentFilesReturns(result1 config.Config, result2 error) {
+	fake.SetEnvironmentFilesStub = nil
+	fake.setEnvironmentFilesReturns = struct {
+		result1 config.Config
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConfig) SetEnvironmentFilesReturnsOnCall(i int, result1 config.Config, result2 error) {
+	fake.SetEnvironmentFilesStub = nil
+	if fake.setEnvironmentFilesReturnsOnCall == nil {
+		fake.setEnvironmentFilesReturnsOnCall = make(map[int]struct {
+			result1 config.Config
+			result2 error
+		})
+	}
+	fake.setEnvironmentFilesReturnsOnCall[i] = struct {
+		result1 config.Config
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConfig) CurrentCreateEnvEnvironment() string {
+	fake.currentCreateEnvEnvironmentMutex.Lock()
+	ret, specificReturn := fake.currentCreateEnvEnvironmentReturnsOnCall[len(fake.currentCreateEnvEnvironmentArgsForCall)]
+	fake.currentCreateEnvEnvironmentArgsForCall = append(fake.currentCreateEnvEnvironmentArgsForCall, struct{}{})
+	fake.recordInvocation("CurrentCreateEnvEnvironment", []interface{}{})
+	fake.currentCreateEnvEnvironmentMutex.Unlock()
+	if fake.CurrentCreateEnvEnvironmentStub != nil {
+		return fake.CurrentCreateEnvEnvironmentStub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fake.currentCreateEnvEnvironmentReturns.result1
+}
+
+func (fake *FakeConfig) CurrentCreateEnvEnvironmentCallCount() int {
+	fake.currentCreateEnvEnvironmentMutex.RLock()
+	defer fake.currentCreateEnvEnvironmentMutex.RUnlock()
+	return len(fake.currentCreateEnvEnvironmentArgsForCall)
+}
+
+func (fake *FakeConfig) CurrentCreateEnvEnvironmentReturns(result1 string) {
+	fake.CurrentCreateEnvEnvironmentStub = nil
+	fake.currentCreateEnvEnvironmentReturns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) CurrentCreateEnvEnvironmentReturnsOnCall(i int, result1 string) {
+	fake.CurrentCreateEnvEnvironmentStub = nil
+	if fake.currentCreateEnvEnvironmentReturnsOnCall == nil {
+		fake.currentCreateEnvEnvironmentReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.currentCreateEnvEnvironmentReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *FakeConfig) SetCurrentCreateEnvEnvironment(alias string) (config.Config, error) {
+	fake.setCurrentCreateEnvEnvironmentMutex.Lock()
+	ret, specificReturn := fake.setCurrentCreateEnvEnvironmentReturnsOnCall[len(fake.setCurrentCreateEnvEnvironmentArgsForCall)]
+	fake.setCurrentCreateEnvEnvironmentArgsForCall = append(fake.setCurrentCreateEnvEnvironmentArgsForCall, struct {
+		alias string
+	}{alias})
+	fake.recordInvocation("SetCurrentCreateEnvEnvironment", []interface{}{alias})
+	fake.setCurrentCreateEnvEnvironmentMutex.Unlock()
+	if fake.SetCurrentCreateEnvEnvironmentStub != nil {
+		return fake.SetCurrentCreateEnvEnvironmentStub(alias)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.setCurrentCreateEnvEnvironmentReturns.result1, fake.setCurrentCreateEnvEnvironmentReturns.result2
+}
+
+func (fake *FakeConfig) SetCurrentCreateEnvEnvironmentCallCount() int {
+	fake.setCurrentCreateEnvEnvironmentMutex.RLock()
+	defer fake.setCurrentCreateEnvEnvironmentMutex.RUnlock()
+	return len(fake.setCurrentCreateEnvEnvironmentArgsForCall)
+}
+
+func (fake *FakeConfig) SetCurrentCreateEnvEnvironmentArgsForCall(i int) string {
+	fake.setCurrentCreateEnvEnvironmentMutex.RLock()
+	defer fake.setCurrentCreateEnvEnvironmentMutex.RUnlock()
+	return fake.setCurrentCreateEnvEnvironmentArgsForCall[i].alias
+}
+
+func (fake *FakeConfig) SetCurrentCreateEnvEnvironmentReturns(result1 config.Config, result2 error) {
+	fake.SetCurrentCreateEnvEnvironmentStub = nil
+	fake.setCurrentCreateEnvEnvironmentReturns = struct {
+		result1 config.Config
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeConfig) SetCurrentCreateEnvEnvironmentReturnsOnCall(i int, result1 config.Config, result2 error) {
+	fake.SetCurrentCreateEnvEnvironmentStub = nil
+	if fake.setCurrentCreateEnvEnvironmentReturnsOnCall == nil {
+		fake.setCurrentCreateEnvEnvironmentReturnsOnCall = make(map[int]struct {
+			result1 config.Config
+			result2 error
+		})
+	}
+	fake.setCurrentCreateEnvEnvironmentReturnsOnCall[i] = struct {
+		result1 config.Config
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeConfig) Credentials(url string) config.Creds {
 	fake.credentialsMutex.Lock()
 	ret, specificReturn := fake.credentialsReturnsOnCall[len(fake.credentialsArgsForCall)]
@@ -486,6 +782,16 @@ func (fake *FakeConfig) Invocations() map[string][][]interface{} {
 	defer fake.aliasEnvironmentMutex.RUnlock()
 	fake.cACertMutex.RLock()
 	defer fake.cACertMutex.RUnlock()
+	fake.confirmationPolicyMutex.RLock()
+	defer fake.confirmationPolicyMutex.RUnlock()
+	fake.environmentFilesMutex.RLock()
+	defer fake.environmentFilesMutex.RUnlock()
+	fake.setEnvironmentFilesMutex.RLock()
+	defer fake.setEnvironmentFilesMutex.RUnlock()
+	fake.currentCreateEnvEnvironmentMutex.RLock()
+	defer fake.currentCreateEnvEnvironmentMutex.RUnlock()
+	fake.setCurrentCreateEnvEnvironmentMutex.RLock()
+	defer fake.setCurrentCreateEnvEnvironmentMutex.RUnlock()
 	fake.credentialsMutex.RLock()
 	defer fake.credentialsMutex.RUnlock()
 	fake.setCredentialsMutex.RLock()
@@ -52,6 +52,26 @@ func (f *FakeConfig2) CACert(environment string) string {
 	return f.Existing.EnvironmentCACert
 }
 
+func (f *FakeConfig2) ConfirmationPolicy() config.ConfirmationPolicy {
+	panic("Not implemented")
+}
+
+func (f *FakeConfig2) EnvironmentFiles(alias string) (config.EnvironmentFiles, bool) {
+	panic("Not implemented")
+}
+
+func (f *FakeConfig2) SetEnvironmentFiles(alias string, files config.EnvironmentFiles) (config.Config, error) {
+	panic("Not implemented")
+}
+
+func (f *FakeConfig2) CurrentCreateEnvEnvironment() string {
+	panic("Not implemented")
+}
+
+func (f *FakeConfig2) SetCurrentCreateEnvEnvironment(alias string) (config.Config, error) {
+	panic("Not implemented")
+}
+
 func (f *FakeConfig2) Credentials(environment string) config.Creds {
 	panic("Not implemented")
 }
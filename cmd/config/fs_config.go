@@ -9,13 +9,25 @@ import (
 )
 
 /*
+schema_version: 1
 environments:
 - url: https://192.168.50.4:25555
   ca_cert: |...
   username: admin
   password: admin
+- alias: prod
+  manifest: /envs/prod/bosh.yml
+  vars_files: [/envs/prod/vars.yml]
+  state: /envs/prod/state.json
+current_create_env: prod
+confirm_destructive: [delete-env, recreate]
 */
 
+// fsConfigSchemaVersion is recorded in the config when it is saved so that
+// a config written by a newer CLI is not silently read without the keys it
+// adds. Configs without a version were written before it was recorded.
+const fsConfigSchemaVersion = 1
+
 type FSConfig struct {
 	path string
 	fs   boshsys.FileSystem
@@ -24,7 +36,13 @@ type FSConfig struct {
 }
 
 type fsConfigSchema struct {
+	SchemaVersion int `yaml:"schema_version,omitempty"`
+
 	Environments []fsConfigSchema_Environment `yaml:"environments"`
+
+	CurrentCreateEnv string `yaml:"current_create_env,omitempty"`
+
+	ConfirmDestructive []string `yaml:"confirm_destructive,omitempty"`
 }
 
 type fsConfigSchema_Environment struct {
@@ -37,6 +55,12 @@ type fsConfigSchema_Environment struct {
 	Username     string `yaml:"username,omitempty"`
 	Password     string `yaml:"password,omitempty"`
 	RefreshToken string `yaml:"refresh_token,omitempty"`
+
+	// create-env files
+	Manifest  string   `yaml:"manifest,omitempty"`
+	VarsFiles []string `yaml:"vars_files,omitempty"`
+	OpsFiles  []string `yaml:"ops_files,omitempty"`
+	State     string   `yaml:"state,omitempty"`
 }
 
 func NewFSConfigFromPath(path string, fs boshsys.FileSystem) (FSConfig, error) {
@@ -57,6 +81,12 @@ func NewFSConfigFromPath(path string, fs boshsys.FileSystem) (FSConfig, error) {
 		if err != nil {
 			return FSConfig{}, bosherr.WrapError(err, "Unmarshalling config")
 		}
+
+		if schema.SchemaVersion > fsConfigSchemaVersion {
+			return FSConfig{}, bosherr.Errorf(
+				"Config '%s' has schema version %d, but this CLI only supports up to version %d, upgrade the CLI",
+				absPath, schema.SchemaVersion, fsConfigSchemaVersion)
+		}
 	}
 
 	return FSConfig{path: absPath, fs: fs, schema: schema}, nil
@@ -98,12 +128,78 @@ func (c FSConfig) AliasEnvironment(url, alias, caCert string) (Config, error) {
 	return config, nil
 }
 
+func (c FSConfig) ConfirmationPolicy() ConfirmationPolicy {
+	return ConfirmationPolicy{Operations: c.schema.ConfirmDestructive}
+}
+
 func (c FSConfig) CACert(urlOrAlias string) string {
 	_, tg := c.findOrCreateEnvironment(urlOrAlias)
 
 	return tg.CACert
 }
 
+func (c FSConfig) EnvironmentFiles(alias string) (EnvironmentFiles, bool) {
+	if alias == "" {
+		return EnvironmentFiles{}, false
+	}
+
+	for _, tg := range c.schema.Environments {
+		if tg.Alias == alias && tg.Manifest != "" {
+			files := EnvironmentFiles{
+				Manifest:  tg.Manifest,
+				VarsFiles: tg.VarsFiles,
+				OpsFiles:  tg.OpsFiles,
+				State:     tg.State,
+			}
+			return files, true
+		}
+	}
+
+	return EnvironmentFiles{}, false
+}
+
+func (c FSConfig) SetEnvironmentFiles(alias string, files EnvironmentFiles) (Config, error) {
+	if len(alias) == 0 {
+		return nil, bosherr.Error("Expected non-empty environment alias")
+	}
+
+	if len(files.Manifest) == 0 {
+		return nil, bosherr.Error("Expected non-empty environment manifest path")
+	}
+
+	config := c.deepCopy()
+
+	i, tg := config.findOrCreateEnvironmentByAlias(alias)
+	tg.Manifest = files.Manifest
+	tg.VarsFiles = files.VarsFiles
+	tg.OpsFiles = files.OpsFiles
+	tg.State = files.State
+	config.schema.Environments[i] = tg
+
+	return config, nil
+}
+
+func (c FSConfig) CurrentCreateEnvEnvironment() string {
+	// The alias may have lost its create-env files since it was made current,
+	// e.g. when the config was edited by hand
+	if _, found := c.EnvironmentFiles(c.schema.CurrentCreateEnv); !found {
+		return ""
+	}
+
+	return c.schema.CurrentCreateEnv
+}
+
+func (c FSConfig) SetCurrentCreateEnvEnvironment(alias string) (Config, error) {
+	if _, found := c.EnvironmentFiles(alias); !found {
+		return nil, bosherr.Errorf("Expected environment '%s' to have create-env files, save them with 'alias-create-env'", alias)
+	}
+
+	config := c.deepCopy()
+	config.schema.CurrentCreateEnv = alias
+
+	return config, nil
+}
+
 func (c FSConfig) Credentials(urlOrAlias string) Creds {
 	_, tg := c.findOrCreateEnvironment(urlOrAlias)
 
@@ -140,6 +236,8 @@ func (c FSConfig) UnsetCredentials(urlOrAlias string) Config {
 }
 
 func (c FSConfig) Save() error {
+	c.schema.SchemaVersion = fsConfigSchemaVersion
+
 	bytes, err := yaml.Marshal(c.schema)
 	if err != nil {
 		return bosherr.WrapError(err, "Marshalling config")
@@ -182,6 +280,18 @@ func (c *FSConfig) findOrCreateEnvironmentByUrlOrAlias(url, alias string) (int,
 	return i, tg
 }
 
+func (c *FSConfig) findOrCreateEnvironmentByAlias(alias string) (int, fsConfigSchema_Environment) {
+	for i, tg := range c.schema.Environments {
+		if alias == tg.Alias {
+			return i, tg
+		}
+	}
+
+	tg := fsConfigSchema_Environment{Alias: alias}
+	c.schema.Environments = append(c.schema.Environments, tg)
+	return len(c.schema.Environments) - 1, tg
+}
+
 func (c *FSConfig) appendNewEnvironmentWithURL(url string) (int, fsConfigSchema_Environment) {
 	tg := fsConfigSchema_Environment{URL: url}
 	c.schema.Environments = append(c.schema.Environments, tg)
@@ -51,6 +51,24 @@ var _ = Describe("NewFSConfigFromPath", func() {
 		Expect(err.Error()).To(ContainSubstring("fake-err"))
 	})
 
+	It("reads configs written before schema versions were recorded", func() {
+		fs := fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/config", "environments:\n- url: https://fake-url\n")
+
+		config, err := NewFSConfigFromPath("/config", fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.Environments()).To(HaveLen(1))
+	})
+
+	It("returns error if config was written with a newer schema version", func() {
+		fs := fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/config", "schema_version: 2\n")
+
+		_, err := NewFSConfigFromPath("/config", fs)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Config '/config' has schema version 2, but this CLI only supports up to version 1, upgrade the CLI"))
+	})
+
 	It("returns error if config file cannot be unmarshaled", func() {
 		fs := fakesys.NewFakeFileSystem()
 		fs.WriteFileString("/config", "-")
@@ -112,6 +130,31 @@ var _ = Describe("FSConfig", func() {
 		})
 	})
 
+	Describe("ConfirmationPolicy", func() {
+		It("requires nothing when confirm_destructive is not set", func() {
+			Expect(config.ConfirmationPolicy().Requires("delete-env")).To(BeFalse())
+		})
+
+		It("requires the operations listed in confirm_destructive", func() {
+			fs.WriteFileString("/dir/sub-dir/config", "confirm_destructive: [delete-env, recreate]")
+
+			policy := readConfig().ConfirmationPolicy()
+			Expect(policy.Requires("delete-env")).To(BeTrue())
+			Expect(policy.Requires("recreate")).To(BeTrue())
+			Expect(policy.Requires("delete-orphaned-disks")).To(BeFalse())
+		})
+
+		It("keeps confirm_destructive when the config is saved", func() {
+			fs.WriteFileString("/dir/sub-dir/config", "confirm_destructive: [delete-env]")
+
+			updatedConfig, err := readConfig().AliasEnvironment("url1", "alias1", "")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(updatedConfig.Save()).To(Succeed())
+
+			Expect(readConfig().ConfirmationPolicy().Requires("delete-env")).To(BeTrue())
+		})
+	})
+
 	Describe("AliasEnvironment/CACert", func() {
 		It("returns empty if file does not exist", func() {
 			Expect(config.CACert("url")).To(Equal(""))
@@ -389,7 +432,129 @@ var _ = Describe("FSConfig", func() {
 		})
 	})
 
+	Describe("SetEnvironmentFiles/EnvironmentFiles", func() {
+		files := EnvironmentFiles{
+			Manifest:  "/manifest.yml",
+			VarsFiles: []string{"/vars.yml"},
+			OpsFiles:  []string{"/ops.yml"},
+			State:     "/state.json",
+		}
+
+		It("returns not found if environment was not saved", func() {
+			_, found := config.EnvironmentFiles("alias")
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns not found if environment only has a URL", func() {
+			updatedConfig, err := config.AliasEnvironment("url", "alias", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found := updatedConfig.EnvironmentFiles("alias")
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns error if alias is empty", func() {
+			_, err := config.SetEnvironmentFiles("", files)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected non-empty environment alias"))
+		})
+
+		It("returns error if manifest is empty", func() {
+			_, err := config.SetEnvironmentFiles("alias", EnvironmentFiles{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected non-empty environment manifest path"))
+		})
+
+		It("saves files for an alias and keeps an existing URL", func() {
+			updatedConfig, err := config.AliasEnvironment("url", "alias", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedConfig, err = updatedConfig.SetEnvironmentFiles("alias", files)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, found := config.EnvironmentFiles("alias")
+			Expect(found).To(BeFalse())
+
+			err = updatedConfig.Save()
+			Expect(err).ToNot(HaveOccurred())
+
+			reloadedConfig := readConfig()
+			reloadedFiles, found := reloadedConfig.EnvironmentFiles("alias")
+			Expect(found).To(BeTrue())
+			Expect(reloadedFiles).To(Equal(files))
+			Expect(reloadedConfig.Environments()).To(Equal([]Environment{
+				Environment{URL: "url", Alias: "alias"},
+			}))
+		})
+
+		It("overwrites previously saved files", func() {
+			updatedConfig, err := config.SetEnvironmentFiles("alias", files)
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedConfig, err = updatedConfig.SetEnvironmentFiles("alias", EnvironmentFiles{Manifest: "/other.yml"})
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedFiles, found := updatedConfig.EnvironmentFiles("alias")
+			Expect(found).To(BeTrue())
+			Expect(updatedFiles).To(Equal(EnvironmentFiles{Manifest: "/other.yml"}))
+		})
+	})
+
+	Describe("SetCurrentCreateEnvEnvironment/CurrentCreateEnvEnvironment", func() {
+		It("returns empty if no environment was made current", func() {
+			Expect(config.CurrentCreateEnvEnvironment()).To(BeEmpty())
+		})
+
+		It("saves the current environment", func() {
+			updatedConfig, err := config.SetEnvironmentFiles("alias", EnvironmentFiles{Manifest: "/manifest.yml"})
+			Expect(err).ToNot(HaveOccurred())
+
+			updatedConfig, err = updatedConfig.SetCurrentCreateEnvEnvironment("alias")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.CurrentCreateEnvEnvironment()).To(BeEmpty())
+
+			err = updatedConfig.Save()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(readConfig().CurrentCreateEnvEnvironment()).To(Equal("alias"))
+		})
+
+		It("returns empty if the current environment no longer has create-env files", func() {
+			fs := fakesys.NewFakeFileSystem()
+			fs.WriteFileString("/config", "environments:\n- url: https://fake-url\n  alias: alias\ncurrent_create_env: alias\n")
+
+			config, err := NewFSConfigFromPath("/config", fs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.CurrentCreateEnvEnvironment()).To(BeEmpty())
+
+			fs.WriteFileString("/config", "current_create_env: removed-alias\n")
+
+			config, err = NewFSConfigFromPath("/config", fs)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(config.CurrentCreateEnvEnvironment()).To(BeEmpty())
+		})
+
+		It("returns error if environment has no create-env files", func() {
+			updatedConfig, err := config.AliasEnvironment("url", "alias", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = updatedConfig.SetCurrentCreateEnvEnvironment("alias")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected environment 'alias' to have create-env files, save them with 'alias-create-env'"))
+		})
+	})
+
 	Describe("Save", func() {
+		It("records the schema version", func() {
+			config := readConfig()
+			err := config.Save()
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := fs.ReadFileString("/dir/sub-dir/config")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(HavePrefix("schema_version: 1\n"))
+		})
+
 		It("chmods the file to 600", func() {
 			config := readConfig()
 			err := config.Save()
@@ -9,6 +9,19 @@ type Config interface {
 
 	CACert(url string) string
 
+	ConfirmationPolicy() ConfirmationPolicy
+
+	// EnvironmentFiles are the files create-env and delete-env use for an
+	// environment when it is given by alias instead of a manifest path
+	EnvironmentFiles(alias string) (EnvironmentFiles, bool)
+	SetEnvironmentFiles(alias string, files EnvironmentFiles) (Config, error)
+
+	// CurrentCreateEnvEnvironment is the environment with create-env files
+	// that is used when neither a manifest path nor an environment is given.
+	// It is empty when that environment no longer has create-env files.
+	CurrentCreateEnvEnvironment() string
+	SetCurrentCreateEnvEnvironment(alias string) (Config, error)
+
 	Credentials(url string) Creds
 	SetCredentials(url string, creds Creds) Config
 	UnsetCredentials(url string) Config
@@ -20,3 +33,27 @@ type Environment struct {
 	URL   string
 	Alias string
 }
+
+// ConfirmationPolicy lists destructive operations that always have to be
+// confirmed. Listed operations fail instead of being confirmed automatically
+// when input is non-interactive.
+type ConfirmationPolicy struct {
+	Operations []string
+}
+
+func (p ConfirmationPolicy) Requires(operation string) bool {
+	for _, op := range p.Operations {
+		if op == operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+type EnvironmentFiles struct {
+	Manifest  string
+	VarsFiles []string
+	OpsFiles  []string
+	State     string
+}
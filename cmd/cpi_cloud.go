@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"regexp"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+// cpiForDeployment picks the cloud_provider.cpis entry for the AZ of the
+// deployed job. An empty name means the CPI is used without cpis.
+func cpiForDeployment(installationManifest biinstallmanifest.Manifest, deploymentManifest bideplmanifest.Manifest, deploymentState biconfig.DeploymentState) (string, error) {
+	if len(installationManifest.CPIs) == 0 {
+		return "", nil
+	}
+
+	job, _ := deploymentManifest.FindJobByName(deploymentManifest.JobName())
+
+	az := job.AZ()
+	if az == "" {
+		return "", bosherr.Errorf("Expected job '%s' to specify an az to choose one of cloud_provider.cpis", job.Name)
+	}
+
+	cpi, found := installationManifest.FindCPIByAZ(az)
+	if !found {
+		return "", bosherr.Errorf("Expected az '%s' of job '%s' to be listed by one of cloud_provider.cpis", az, job.Name)
+	}
+
+	if deploymentState.CurrentCPI != "" && deploymentState.CurrentCPI != cpi.Name && deploymentState.CurrentVMCID != "" {
+		return "", bosherr.Errorf("Expected az '%s' to use cpi '%s' of the current deployment, but it uses cpi '%s'; delete-env before moving the deployment", az, deploymentState.CurrentCPI, cpi.Name)
+	}
+
+	return cpi.Name, nil
+}
+
+// newCloudForCPI creates a cloud that sends the properties of the named
+// cloud_provider.cpis entry to the installed CPI, limits its concurrent
+// calls as cloud_provider.cpi_throttle asks and retries its calls as
+// cloud_provider.cpi_retries allows, reporting retries to attemptReporter and backing off on timeService.
+// Retries wait for the throttle again instead of holding it while backing off.
+func newCloudForCPI(cloudFactory bicloud.Factory, installation biinstall.Installation, directorID string, installationManifest biinstallmanifest.Manifest, cpiName string, attemptReporter biretrier.AttemptReporter, timeService clock.Clock, logger boshlog.Logger) (bicloud.Cloud, error) {
+	cloud, err := newCloudWithCPIProperties(cloudFactory, installation, directorID, installationManifest, cpiName)
+	if err != nil {
+		return nil, err
+	}
+
+	throttlePolicy := bicloud.ThrottlePolicy{
+		MaxConcurrent: installationManifest.CPIThrottle.MaxConcurrent,
+		Methods:       installationManifest.CPIThrottle.Methods,
+	}
+
+	if !throttlePolicy.IsEmpty() {
+		cloud = bicloud.NewThrottledCloud(cloud, throttlePolicy, logger)
+	}
+
+	policy, err := cpiRetryPolicy(installationManifest.CPIRetries)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.IsEmpty() {
+		return cloud, nil
+	}
+
+	return bicloud.NewRetryingCloud(cloud, policy, attemptReporter, timeService, logger), nil
+}
+
+func newCloudWithCPIProperties(cloudFactory bicloud.Factory, installation biinstall.Installation, directorID string, installationManifest biinstallmanifest.Manifest, cpiName string) (bicloud.Cloud, error) {
+	if cpiName == "" {
+		return cloudFactory.NewCloud(installation, directorID)
+	}
+
+	cpi, found := installationManifest.FindCPIByName(cpiName)
+	if !found {
+		return nil, bosherr.Errorf("Expected cloud_provider.cpis to contain cpi '%s' of the current deployment", cpiName)
+	}
+
+	return cloudFactory.NewCloudWithProperties(installation, directorID, cpi.Properties)
+}
+
+func cpiRetryPolicy(retries biinstallmanifest.CPIRetries) (bicloud.RetryPolicy, error) {
+	policy := bicloud.RetryPolicy{
+		MaxAttempts:  retries.MaxAttempts,
+		InitialDelay: time.Duration(retries.InitialDelay) * time.Second,
+		MaxDelay:     time.Duration(retries.MaxDelay) * time.Second,
+		Methods:      retries.Methods,
+		ErrorTypes:   retries.ErrorTypes,
+	}
+
+	if policy.InitialDelay == 0 {
+		policy.InitialDelay = 5 * time.Second
+	}
+
+	if policy.MaxDelay == 0 {
+		policy.MaxDelay = 60 * time.Second
+	}
+
+	for _, message := range retries.ErrorMessages {
+		messageRegexp, err := regexp.Compile(message)
+		if err != nil {
+			return bicloud.RetryPolicy{}, bosherr.WrapErrorf(err, "Compiling cloud_provider.cpi_retries.error_messages '%s'", message)
+		}
+
+		policy.ErrorMessages = append(policy.ErrorMessages, messageRegexp)
+	}
+
+	return policy, nil
+}
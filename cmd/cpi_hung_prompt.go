@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	bii18n "github.com/cloudfoundry/bosh-cli/ui/i18n"
+)
+
+const (
+	cpiHungKeepWaiting = iota
+	cpiHungAbort
+)
+
+// CPIHungPrompt warns about CPI calls the watchdog considers hung and,
+// when input is interactive, offers to abort them
+type CPIHungPrompt struct {
+	ui       boshui.UI
+	messages bii18n.Catalog
+}
+
+func NewCPIHungPrompt(ui boshui.UI, messages bii18n.Catalog) CPIHungPrompt {
+	return CPIHungPrompt{ui: ui, messages: messages}
+}
+
+func (p CPIHungPrompt) OnHung(method string, elapsed time.Duration) bool {
+	p.ui.ErrorLinef("%s", p.messages.T(bii18n.CPIHungWarning, method, formatHungElapsed(elapsed)))
+
+	if !p.ui.IsInteractive() {
+		return false
+	}
+
+	choice, err := p.ui.AskForChoice("What do you want to do?", []string{"Keep waiting", "Abort the call"})
+	if err != nil {
+		return false
+	}
+
+	return choice == cpiHungAbort
+}
+
+func formatHungElapsed(elapsed time.Duration) string {
+	if elapsed < time.Minute {
+		return fmt.Sprintf("%ds", int(elapsed/time.Second))
+	}
+
+	return fmt.Sprintf("%dm", int(elapsed/time.Minute))
+}
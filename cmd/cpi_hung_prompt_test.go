@@ -0,0 +1,64 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	bii18n "github.com/cloudfoundry/bosh-cli/ui/i18n"
+)
+
+var _ = Describe("CPIHungPrompt", func() {
+	var (
+		ui     *fakeui.FakeUI
+		prompt CPIHungPrompt
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		prompt = NewCPIHungPrompt(ui, bii18n.NewDefaultCatalog())
+	})
+
+	It("warns and keeps waiting when input is non-interactive", func() {
+		Expect(prompt.OnHung("create_vm", 14*time.Minute+20*time.Second)).To(BeFalse())
+		Expect(ui.Errors).To(Equal([]string{"CPI appears hung (call: create_vm, elapsed: 14m)"}))
+		Expect(ui.AskedChoiceCalled).To(BeFalse())
+	})
+
+	It("shows seconds for calls that took less than a minute", func() {
+		prompt.OnHung("info", 45*time.Second)
+		Expect(ui.Errors).To(Equal([]string{"CPI appears hung (call: info, elapsed: 45s)"}))
+	})
+
+	Context("when input is interactive", func() {
+		BeforeEach(func() {
+			ui.Interactive = true
+		})
+
+		It("aborts the call when chosen", func() {
+			ui.AskedChoiceChosens = []int{1}
+			ui.AskedChoiceErrs = []error{nil}
+
+			Expect(prompt.OnHung("create_vm", 14*time.Minute)).To(BeTrue())
+			Expect(ui.AskedChoiceOptions).To(Equal([]string{"Keep waiting", "Abort the call"}))
+		})
+
+		It("keeps waiting when chosen", func() {
+			ui.AskedChoiceChosens = []int{0}
+			ui.AskedChoiceErrs = []error{nil}
+
+			Expect(prompt.OnHung("create_vm", 14*time.Minute)).To(BeFalse())
+		})
+
+		It("keeps waiting when asking fails", func() {
+			ui.AskedChoiceChosens = []int{1}
+			ui.AskedChoiceErrs = []error{errors.New("fake-err")}
+
+			Expect(prompt.OnHung("create_vm", 14*time.Minute)).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type CpiLifecycleTester interface {
+	TestLifecycle(diskSize int, stage biui.Stage) error
+}
+
+func NewCpiLifecycleTester(
+	ui biui.UI,
+	logTag string,
+	logger boshlog.Logger,
+	timeService clock.Clock,
+	uuidGenerator boshuuid.Generator,
+	releaseManager biinstall.ReleaseManager,
+	cloudFactory bicloud.Factory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	cpiInstaller bicpirel.CpiInstaller,
+	cpiUninstaller biinstall.Uninstaller,
+	releaseFetcher biinstall.ReleaseFetcher,
+	stemcellFetcher bistemcell.Fetcher,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	deploymentManifestParser DeploymentManifestParser,
+	tempRootConfigurator TempRootConfigurator,
+	installationsRootPath string,
+) CpiLifecycleTester {
+	return &cpiLifecycleTester{
+		ui:                                      ui,
+		logTag:                                  logTag,
+		logger:                                  logger,
+		timeService:                             timeService,
+		uuidGenerator:                           uuidGenerator,
+		releaseManager:                          releaseManager,
+		cloudFactory:                            cloudFactory,
+		deploymentManifestPath:                  deploymentManifestPath,
+		deploymentVars:                          deploymentVars,
+		deploymentOp:                            deploymentOp,
+		cpiInstaller:                            cpiInstaller,
+		cpiUninstaller:                          cpiUninstaller,
+		releaseFetcher:                          releaseFetcher,
+		stemcellFetcher:                         stemcellFetcher,
+		releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+		deploymentManifestParser:                deploymentManifestParser,
+		tempRootConfigurator:                    tempRootConfigurator,
+		installationsRootPath:                   installationsRootPath,
+	}
+}
+
+type cpiLifecycleTester struct {
+	ui                                      biui.UI
+	logTag                                  string
+	logger                                  boshlog.Logger
+	timeService                             clock.Clock
+	uuidGenerator                           boshuuid.Generator
+	releaseManager                          biinstall.ReleaseManager
+	cloudFactory                            bicloud.Factory
+	deploymentManifestPath                  string
+	deploymentVars                          boshtpl.Variables
+	deploymentOp                            patch.Op
+	cpiInstaller                            bicpirel.CpiInstaller
+	cpiUninstaller                          biinstall.Uninstaller
+	releaseFetcher                          biinstall.ReleaseFetcher
+	stemcellFetcher                         bistemcell.Fetcher
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser
+	deploymentManifestParser                DeploymentManifestParser
+	tempRootConfigurator                    TempRootConfigurator
+	installationsRootPath                   string
+}
+
+func (t *cpiLifecycleTester) TestLifecycle(diskSize int, stage biui.Stage) error {
+	// A throwaway installation keeps the test from touching any existing environment state
+	installationID, err := t.uuidGenerator.Generate()
+	if err != nil {
+		return bosherr.WrapError(err, "Generating installation ID")
+	}
+
+	target := biinstall.NewTarget(filepath.Join(t.installationsRootPath, installationID))
+
+	err = t.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), t.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := t.releaseManager.DeleteAll()
+		if err != nil {
+			t.logger.Warn(t.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	var (
+		extractedStemcell    bistemcell.ExtractedStemcell
+		deploymentManifest   bideplmanifest.Manifest
+		installationManifest biinstallmanifest.Manifest
+	)
+
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = t.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(t.deploymentManifestPath, t.deploymentVars, t.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		for _, releaseRef := range releaseSetManifest.Releases {
+			err = t.releaseFetcher.DownloadAndExtract(releaseRef, stage)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = t.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		deploymentManifest, _, err = t.deploymentManifestParser.GetDeploymentManifest(t.deploymentManifestPath, t.deploymentVars, t.deploymentOp, releaseSetManifest, stage)
+		if err != nil {
+			return err
+		}
+
+		extractedStemcell, err = t.stemcellFetcher.GetStemcell(deploymentManifest, stage)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		err := extractedStemcell.Cleanup()
+		if err != nil {
+			t.logger.Warn(t.logTag, "Failed to delete extracted stemcell: %s", err.Error())
+		}
+	}()
+
+	spec, err := t.lifecycleSpec(deploymentManifest, extractedStemcell, diskSize)
+	if err != nil {
+		return err
+	}
+
+	var report bicloud.LifecycleReport
+
+	err = t.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(installation biinstall.Installation) error {
+		err := installation.WithRunningRegistry(t.logger, stage, func() error {
+			directorID, err := t.uuidGenerator.Generate()
+			if err != nil {
+				return bosherr.WrapError(err, "Generating director ID")
+			}
+
+			cloud, err := t.cloudFactory.NewCloud(installation, directorID)
+			if err != nil {
+				return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+			}
+
+			return stage.PerformComplex("testing CPI lifecycle", func(stage biui.Stage) error {
+				report, err = bicloud.NewLifecycleTest(cloud, t.timeService, t.logger).Run(spec, stage)
+				return err
+			})
+		})
+
+		uninstallErr := t.cpiUninstaller.Uninstall(installation.Target())
+		if uninstallErr != nil {
+			t.logger.Warn(t.logTag, "Uninstalling CPI: %s", uninstallErr.Error())
+		}
+
+		return err
+	})
+
+	if len(report.Steps) > 0 {
+		t.printReport(report)
+	}
+
+	return err
+}
+
+func (t *cpiLifecycleTester) lifecycleSpec(deploymentManifest bideplmanifest.Manifest, extractedStemcell bistemcell.ExtractedStemcell, diskSize int) (bicloud.LifecycleSpec, error) {
+	jobName := deploymentManifest.JobName()
+
+	resourcePool, err := deploymentManifest.ResourcePool(jobName)
+	if err != nil {
+		return bicloud.LifecycleSpec{}, err
+	}
+
+	networkInterfaces, err := deploymentManifest.NetworkInterfaces(jobName)
+	if err != nil {
+		return bicloud.LifecycleSpec{}, bosherr.WrapErrorf(err, "Building network spec")
+	}
+
+	diskPool, err := deploymentManifest.DiskPool(jobName)
+	if err != nil {
+		return bicloud.LifecycleSpec{}, err
+	}
+
+	agentID, err := t.uuidGenerator.Generate()
+	if err != nil {
+		return bicloud.LifecycleSpec{}, bosherr.WrapError(err, "Generating agent ID")
+	}
+
+	image, err := extractedStemcell.Image()
+	if err != nil {
+		return bicloud.LifecycleSpec{}, bosherr.WrapError(err, "Preparing stemcell image")
+	}
+
+	return bicloud.LifecycleSpec{
+		StemcellImagePath:       image.Path,
+		StemcellCloudProperties: image.CloudProperties,
+
+		AgentID:           agentID,
+		VMCloudProperties: resourcePool.CloudProperties,
+		Networks:          networkInterfaces,
+		Env:               resourcePool.Env,
+
+		DiskSize:            diskSize,
+		DiskCloudProperties: diskPool.CloudProperties,
+	}, nil
+}
+
+func (t *cpiLifecycleTester) printReport(report bicloud.LifecycleReport) {
+	table := boshtbl.Table{
+		Content: "CPI calls",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("#"),
+			boshtbl.NewHeader("Method"),
+			boshtbl.NewHeader("Result"),
+			boshtbl.NewHeader("Duration"),
+		},
+	}
+
+	for i, step := range report.Steps {
+		method := step.Method
+		if step.Cleanup {
+			method = fmt.Sprintf("%s (cleanup)", method)
+		}
+
+		result := boshtbl.NewValueFmt(boshtbl.NewValueString("ok"), false)
+		if step.Err != nil {
+			result = boshtbl.NewValueFmt(boshtbl.NewValueString(step.Err.Error()), true)
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueInt(i + 1),
+			boshtbl.NewValueString(method),
+			result,
+			boshtbl.NewValueString(step.Duration.String()),
+		})
+	}
+
+	t.ui.PrintTable(table)
+}
@@ -1,27 +1,98 @@
 package cmd
 
 import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	"github.com/cppforlife/go-patch/patch"
 
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 )
 
 type CreateEnvCmd struct {
-	ui          boshui.UI
-	envProvider EnvProviderFunction
+	ui           boshui.UI
+	envProvider  EnvProviderFunction
+	confirmation DestructiveConfirmation
+	fs           boshsys.FileSystem
 }
 
 type EnvProviderFunction func(string, string, boshtpl.Variables, patch.Op) DeploymentPreparer
 
-func NewCreateEnvCmd(ui boshui.UI, envProvider EnvProviderFunction) *CreateEnvCmd {
-	return &CreateEnvCmd{ui: ui, envProvider: envProvider}
+func NewCreateEnvCmd(ui boshui.UI, envProvider EnvProviderFunction, confirmation DestructiveConfirmation) *CreateEnvCmd {
+	return &CreateEnvCmd{ui: ui, envProvider: envProvider, confirmation: confirmation}
+}
+
+// NewCreateEnvCmdWithOutputs creates a command that can write the outputs
+// of the manifest with --outputs
+func NewCreateEnvCmdWithOutputs(ui boshui.UI, envProvider EnvProviderFunction, confirmation DestructiveConfirmation, fs boshsys.FileSystem) *CreateEnvCmd {
+	return &CreateEnvCmd{ui: ui, envProvider: envProvider, confirmation: confirmation, fs: fs}
 }
 
 func (c *CreateEnvCmd) Run(stage boshui.Stage, opts CreateEnvOpts) error {
 	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
 
+	err := warnInlineSecrets(c.ui, opts.Args.Manifest.Path, opts.Args.Manifest.Bytes, opts.OpsFlags, opts.VarFlags, opts.FailOnInlineSecrets)
+	if err != nil {
+		return err
+	}
+
+	var operations []string
+	if opts.Recreate {
+		operations = append(operations, DestructiveRecreate)
+	}
+	if opts.RecreatePersistentDisks {
+		operations = append(operations, DestructiveRecreatePersistentDisks)
+	}
+
+	adopted := AdoptedResources{VMCID: opts.AdoptVMCID, DiskCID: opts.AdoptDiskCID}
+
+	if opts.DryRun && !adopted.IsEmpty() {
+		return bosherr.Error("Resources cannot be adopted during a dry run")
+	}
+
+	// nothing is destroyed by a dry run. The deployment preparer asks for
+	// the confirmation together with the deletions it plans, fail before
+	// preparing when it could not be asked for.
+	if !opts.DryRun {
+		err := c.confirmation.Check(operations...)
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.Outputs != "" && c.fs == nil && opts.Outputs != EnvOutputsStdout {
+		return bosherr.Error("Writing outputs to a file is not supported")
+	}
+
 	depPreparer := c.envProvider(opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
 
-	return depPreparer.PrepareDeployment(stage, opts.Recreate, opts.RecreatePersistentDisks, opts.SkipDrain)
+	err = depPreparer.PrepareDeployment(stage, opts.Recreate, opts.RecreatePersistentDisks, opts.SkipDrain, opts.StemcellCID, opts.Stemcells, c.convergence(opts), opts.DryRun, opts.ResetPin, opts.CPIReleaseSHA1, opts.StemcellSHA1, adopted)
+	if err != nil {
+		return err
+	}
+
+	if opts.Outputs == "" || opts.DryRun {
+		return nil
+	}
+
+	outputs, err := envOutputs(opts.Args.Manifest.Bytes, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+	if err != nil {
+		return err
+	}
+
+	return writeEnvOutputs(c.ui, c.fs, opts.Outputs, outputs)
+}
+
+// convergence overrides the manifest update.convergence when a skip flag is given
+func (c *CreateEnvCmd) convergence(opts CreateEnvOpts) bideplmanifest.Convergence {
+	if opts.SkipAgentWait {
+		return bideplmanifest.ConvergenceNone
+	}
+
+	if opts.SkipRunningWait {
+		return bideplmanifest.ConvergenceAgent
+	}
+
+	return ""
 }
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type credsRotationEnv struct {
+	AgentActionSender
+
+	preparer               DeploymentPreparer
+	deploymentStateService biconfig.DeploymentStateService
+	timeService            clock.Clock
+}
+
+func NewCredsRotationEnv(
+	agentActionSender AgentActionSender,
+	preparer DeploymentPreparer,
+	deploymentStateService biconfig.DeploymentStateService,
+	timeService clock.Clock,
+) CredsRotationEnv {
+	return &credsRotationEnv{
+		AgentActionSender:      agentActionSender,
+		preparer:               preparer,
+		deploymentStateService: deploymentStateService,
+		timeService:            timeService,
+	}
+}
+
+// Recreate skips draining since the jobs were stopped with the previous
+// credentials, which the agent of the VM being deleted still expects
+func (e *credsRotationEnv) Recreate(stage boshui.Stage) error {
+	return e.preparer.PrepareDeployment(stage, true, false, true, "", nil, "", false, false, "", "", AdoptedResources{})
+}
+
+func (e *credsRotationEnv) RecordCredsRotation() error {
+	err := e.deploymentStateService.Update(func(state *biconfig.DeploymentState) error {
+		rotatedAt := e.timeService.Now()
+		state.CredsRotatedAt = &rotatedAt
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Recording credentials rotation")
+	}
+
+	return nil
+}
@@ -8,12 +8,13 @@ import (
 )
 
 type DeleteEnvCmd struct {
-	ui          boshui.UI
-	envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter
+	ui           boshui.UI
+	envProvider  func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter
+	confirmation DestructiveConfirmation
 }
 
-func NewDeleteEnvCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter) *DeleteEnvCmd {
-	return &DeleteEnvCmd{ui: ui, envProvider: envProvider}
+func NewDeleteEnvCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) DeploymentDeleter, confirmation DestructiveConfirmation) *DeleteEnvCmd {
+	return &DeleteEnvCmd{ui: ui, envProvider: envProvider, confirmation: confirmation}
 }
 
 func (c *DeleteEnvCmd) Run(stage boshui.Stage, opts DeleteEnvOpts) error {
@@ -22,5 +23,14 @@ func (c *DeleteEnvCmd) Run(stage boshui.Stage, opts DeleteEnvOpts) error {
 	depDeleter := c.envProvider(
 		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
 
-	return depDeleter.DeleteDeployment(opts.SkipDrain, stage)
+	if opts.DryRun {
+		return depDeleter.PreviewDeletion(opts.OrphanDisks, stage)
+	}
+
+	err := c.confirmation.ConfirmDeletion(DestructiveDeleteEnv)
+	if err != nil {
+		return err
+	}
+
+	return depDeleter.DeleteDeployment(opts.SkipDrain, opts.OrphanDisks, stage)
 }
@@ -6,6 +6,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -37,6 +38,7 @@ var _ = Describe("DeleteEnvCmd", func() {
 			deploymentManifestPath = "/deployment-dir/fake-deployment-manifest.yml"
 			statePath              string
 			skipDrain              bool
+			confirmationPolicy     cmdconf.ConfirmationPolicy
 		)
 
 		var newDeleteEnvCmd = func() *bicmd.DeleteEnvCmd {
@@ -48,7 +50,7 @@ var _ = Describe("DeleteEnvCmd", func() {
 				return mockDeploymentDeleter
 			}
 
-			return bicmd.NewDeleteEnvCmd(fakeUI, doGetFunc)
+			return bicmd.NewDeleteEnvCmd(fakeUI, doGetFunc, bicmd.NewDestructiveConfirmation(fakeUI, confirmationPolicy))
 		}
 
 		var writeDeploymentManifest = func() {
@@ -62,15 +64,105 @@ var _ = Describe("DeleteEnvCmd", func() {
 			fakeUI = &fakeui.FakeUI{}
 			writeDeploymentManifest()
 			skipDrain = false
+			confirmationPolicy = cmdconf.ConfirmationPolicy{}
+		})
+
+		Context("when the confirmation policy does not require confirming delete-env", func() {
+			var opts bicmd.DeleteEnvOpts
+
+			BeforeEach(func() {
+				opts = bicmd.DeleteEnvOpts{
+					Args: bicmd.DeleteEnvArgs{
+						Manifest: bicmd.EnvManifestArg{FileBytesWithPathArg: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath}},
+					},
+					VarFlags: bicmd.VarFlags{
+						VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}},
+					},
+					OpsFlags: bicmd.OpsFlags{
+						OpsFiles: []bicmd.OpsFileArg{
+							{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})},
+						},
+					},
+				}
+			})
+
+			It("asks for confirmation before deleting", func() {
+				mockDeploymentDeleter.EXPECT().DeleteDeployment(false, false, fakeStage).Return(nil)
+
+				err := newDeleteEnvCmd().Run(fakeStage, opts)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeUI.AskedConfirmationCalled).To(BeTrue())
+			})
+
+			It("does not delete when the user declines", func() {
+				fakeUI.AskedConfirmationErr = bosherr.Error("stopped")
+
+				err := newDeleteEnvCmd().Run(fakeStage, opts)
+				Expect(err).To(MatchError("stopped"))
+			})
+		})
+
+		Context("when the confirmation policy requires confirming delete-env", func() {
+			var opts bicmd.DeleteEnvOpts
+
+			BeforeEach(func() {
+				confirmationPolicy = cmdconf.ConfirmationPolicy{Operations: []string{"delete-env"}}
+				opts = bicmd.DeleteEnvOpts{
+					Args: bicmd.DeleteEnvArgs{
+						Manifest: bicmd.EnvManifestArg{FileBytesWithPathArg: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath}},
+					},
+					VarFlags: bicmd.VarFlags{
+						VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}},
+					},
+					OpsFlags: bicmd.OpsFlags{
+						OpsFiles: []bicmd.OpsFileArg{
+							{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})},
+						},
+					},
+				}
+			})
+
+			It("fails without deleting when input is non-interactive", func() {
+				err := newDeleteEnvCmd().Run(fakeStage, opts)
+				Expect(err).To(MatchError("Confirmation policy requires confirming 'delete-env', but input is non-interactive"))
+				Expect(fakeUI.AskedConfirmationCalled).To(BeFalse())
+			})
+
+			It("does not delete when the user declines", func() {
+				fakeUI.Interactive = true
+				fakeUI.AskedConfirmationErr = bosherr.Error("stopped")
+
+				err := newDeleteEnvCmd().Run(fakeStage, opts)
+				Expect(err).To(MatchError("stopped"))
+			})
+
+			It("deletes once the user confirms", func() {
+				fakeUI.Interactive = true
+				mockDeploymentDeleter.EXPECT().DeleteDeployment(false, false, fakeStage).Return(nil)
+
+				err := newDeleteEnvCmd().Run(fakeStage, opts)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeUI.AskedConfirmationCalled).To(BeTrue())
+				Expect(fakeUI.Said).To(ContainElement("Confirmation policy requires confirming 'delete-env'"))
+			})
+
+			It("does not ask for a dry run", func() {
+				opts.DryRun = true
+				mockDeploymentDeleter.EXPECT().PreviewDeletion(false, fakeStage).Return(nil)
+
+				err := newDeleteEnvCmd().Run(fakeStage, opts)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeUI.AskedConfirmationCalled).To(BeFalse())
+			})
 		})
 
 		Context("when skip drain is specified", func() {
 			It("gets passed to DeleteDeployment", func() {
 				skipDrain = true
-				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, fakeStage).Return(nil)
+				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, false, fakeStage).Return(nil)
 				newDeleteEnvCmd().Run(fakeStage, bicmd.DeleteEnvOpts{
 					Args: bicmd.DeleteEnvArgs{
-						Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+						Manifest: bicmd.EnvManifestArg{FileBytesWithPathArg: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath}},
 					},
 					SkipDrain: skipDrain,
 					VarFlags: bicmd.VarFlags{
@@ -85,12 +177,34 @@ var _ = Describe("DeleteEnvCmd", func() {
 			})
 		})
 
+		Context("when dry run is specified", func() {
+			It("previews the deletion instead of deleting", func() {
+				mockDeploymentDeleter.EXPECT().PreviewDeletion(true, fakeStage).Return(nil)
+				err := newDeleteEnvCmd().Run(fakeStage, bicmd.DeleteEnvOpts{
+					Args: bicmd.DeleteEnvArgs{
+						Manifest: bicmd.EnvManifestArg{FileBytesWithPathArg: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath}},
+					},
+					DryRun:      true,
+					OrphanDisks: true,
+					VarFlags: bicmd.VarFlags{
+						VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}},
+					},
+					OpsFlags: bicmd.OpsFlags{
+						OpsFiles: []bicmd.OpsFileArg{
+							{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})},
+						},
+					},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+
 		Context("state path is NOT specified", func() {
 			It("sends the manifest on to the deleter", func() {
-				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, fakeStage).Return(nil)
+				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, false, fakeStage).Return(nil)
 				newDeleteEnvCmd().Run(fakeStage, bicmd.DeleteEnvOpts{
 					Args: bicmd.DeleteEnvArgs{
-						Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+						Manifest: bicmd.EnvManifestArg{FileBytesWithPathArg: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath}},
 					},
 					SkipDrain: skipDrain,
 					VarFlags: bicmd.VarFlags{
@@ -109,12 +223,12 @@ var _ = Describe("DeleteEnvCmd", func() {
 
 		Context("state path is specified", func() {
 			It("sends the manifest on to the deleter", func() {
-				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, fakeStage).Return(nil)
+				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, false, fakeStage).Return(nil)
 				newDeleteEnvCmd().Run(fakeStage, bicmd.DeleteEnvOpts{
 					StatePath: "/new/state/file/path/state.json",
 					SkipDrain: skipDrain,
 					Args: bicmd.DeleteEnvArgs{
-						Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+						Manifest: bicmd.EnvManifestArg{FileBytesWithPathArg: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath}},
 					},
 					VarFlags: bicmd.VarFlags{
 						VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}},
@@ -133,10 +247,10 @@ var _ = Describe("DeleteEnvCmd", func() {
 		Context("when the deployment deleter returns an error", func() {
 			It("sends the manifest on to the deleter", func() {
 				err := bosherr.Error("boom")
-				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, fakeStage).Return(err)
+				mockDeploymentDeleter.EXPECT().DeleteDeployment(skipDrain, false, fakeStage).Return(err)
 				returnedErr := newDeleteEnvCmd().Run(fakeStage, bicmd.DeleteEnvOpts{
 					Args: bicmd.DeleteEnvArgs{
-						Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+						Manifest: bicmd.EnvManifestArg{FileBytesWithPathArg: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath}},
 					},
 					SkipDrain: skipDrain,
 					VarFlags: bicmd.VarFlags{
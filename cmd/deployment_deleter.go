@@ -1,12 +1,17 @@
 package cmd
 
 import (
+	"fmt"
+
+	"code.cloudfoundry.org/clock"
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/cppforlife/go-patch/patch"
 
+	bicliagentclient "github.com/cloudfoundry/bosh-cli/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
@@ -18,20 +23,24 @@ import (
 	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 )
 
 type DeploymentDeleter interface {
-	DeleteDeployment(skipDrain bool, stage biui.Stage) (err error)
+	DeleteDeployment(skipDrain bool, orphanDisks bool, stage biui.Stage) (err error)
+	PreviewDeletion(orphanDisks bool, stage biui.Stage) error
 }
 
 func NewDeploymentDeleter(
 	ui biui.UI,
 	logTag string,
 	logger boshlog.Logger,
+	timeService clock.Clock,
 	deploymentStateService biconfig.DeploymentStateService,
 	releaseManager boshinst.ReleaseManager,
 	cloudFactory bicloud.Factory,
 	agentClientFactory bihttpagent.AgentClientFactory,
+	rawAgentClientFactory bicliagentclient.RawAgentClientFactory,
 	blobstoreFactory biblobstore.Factory,
 	deploymentManagerFactory bidepl.ManagerFactory,
 	deploymentManifestPath string,
@@ -48,10 +57,12 @@ func NewDeploymentDeleter(
 		ui:                                      ui,
 		logTag:                                  logTag,
 		logger:                                  logger,
+		timeService:                             timeService,
 		deploymentStateService:                  deploymentStateService,
 		releaseManager:                          releaseManager,
 		cloudFactory:                            cloudFactory,
 		agentClientFactory:                      agentClientFactory,
+		rawAgentClientFactory:                   rawAgentClientFactory,
 		blobstoreFactory:                        blobstoreFactory,
 		deploymentManagerFactory:                deploymentManagerFactory,
 		deploymentManifestPath:                  deploymentManifestPath,
@@ -70,10 +81,12 @@ type deploymentDeleter struct {
 	ui                                      biui.UI
 	logTag                                  string
 	logger                                  boshlog.Logger
+	timeService                             clock.Clock
 	deploymentStateService                  biconfig.DeploymentStateService
 	releaseManager                          boshinst.ReleaseManager
 	cloudFactory                            bicloud.Factory
 	agentClientFactory                      bihttpagent.AgentClientFactory
+	rawAgentClientFactory                   bicliagentclient.RawAgentClientFactory
 	blobstoreFactory                        biblobstore.Factory
 	deploymentManagerFactory                bidepl.ManagerFactory
 	deploymentManifestPath                  string
@@ -87,7 +100,113 @@ type deploymentDeleter struct {
 	targetProvider                          biinstall.TargetProvider
 }
 
-func (c *deploymentDeleter) DeleteDeployment(skipDrain bool, stage biui.Stage) (err error) {
+func (c *deploymentDeleter) DeleteDeployment(skipDrain bool, orphanDisks bool, stage biui.Stage) (err error) {
+	return c.withInstalledCpi(stage, func(localCpiInstallation biinstall.Installation, deploymentState biconfig.DeploymentState, installationManifest biinstallmanifest.Manifest) error {
+		return localCpiInstallation.WithRunningRegistry(c.logger, stage, func() error {
+			err := c.findAndDeleteDeployment(skipDrain, orphanDisks, stage, localCpiInstallation, deploymentState, installationManifest)
+
+			if err != nil {
+				return err
+			}
+
+			return stage.Perform("Uninstalling local artifacts for CPI and deployment", func() error {
+				err := c.cpiUninstaller.Uninstall(localCpiInstallation.Target())
+				if err != nil {
+					return err
+				}
+
+				return c.cleanupDeploymentState()
+			})
+		})
+	})
+}
+
+// PreviewDeletion prints the resources recorded in the deployment state that
+// delete-env would remove, without deleting anything
+func (c *deploymentDeleter) PreviewDeletion(orphanDisks bool, stage biui.Stage) error {
+	return c.withInstalledCpi(stage, func(localCpiInstallation biinstall.Installation, deploymentState biconfig.DeploymentState, installationManifest biinstallmanifest.Manifest) error {
+		cloud, err := newCloudForCPI(c.cloudFactory, localCpiInstallation, deploymentState.DirectorID, installationManifest, deploymentState.CurrentCPI, biui.AttemptsOf(c.ui), c.timeService, c.logger)
+		if err != nil {
+			return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		}
+
+		table := boshtbl.Table{
+			Content: "resources",
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("Type"),
+				boshtbl.NewHeader("CID"),
+				boshtbl.NewHeader("Exists"),
+				boshtbl.NewHeader("Action"),
+			},
+			Notes: []string{
+				"Existence is only checked for VMs since the CPI cannot be asked about disks or stemcells",
+				"Disk snapshots are not recorded in the deployment state and are not deleted",
+			},
+		}
+
+		if deploymentState.CurrentVMCID != "" {
+			exists := c.vmExists(cloud, deploymentState.CurrentVMCID, stage)
+			table.Rows = append(table.Rows, c.previewRow("vm", deploymentState.CurrentVMCID, exists, "delete"))
+		}
+
+		diskAction := "delete"
+		if orphanDisks {
+			diskAction = "orphan"
+		}
+
+		for _, disk := range deploymentState.Disks {
+			table.Rows = append(table.Rows, c.previewRow("disk", disk.CID, "-", diskAction))
+		}
+
+		for _, disk := range deploymentState.OrphanedDisks {
+			table.Rows = append(table.Rows, c.previewRow("orphaned disk", disk.CID, "-", "keep"))
+		}
+
+		for _, stemcell := range deploymentState.Stemcells {
+			table.Rows = append(table.Rows, c.previewRow("stemcell", stemcell.CID, "-", "delete"))
+		}
+
+		c.ui.PrintTable(table)
+
+		return nil
+	})
+}
+
+func (c *deploymentDeleter) vmExists(cloud bicloud.Cloud, vmCID string, stage biui.Stage) string {
+	exists := "unknown"
+
+	err := stage.Perform(fmt.Sprintf("Checking existence of VM '%s'", vmCID), func() error {
+		found, err := cloud.HasVM(vmCID)
+		if err != nil {
+			return err
+		}
+
+		exists = "no"
+		if found {
+			exists = "yes"
+		}
+
+		return nil
+	})
+	if err != nil {
+		c.logger.Warn(c.logTag, "Checking existence of VM '%s': %s", vmCID, err.Error())
+	}
+
+	return exists
+}
+
+func (c *deploymentDeleter) previewRow(resourceType, cid, exists, action string) []boshtbl.Value {
+	return []boshtbl.Value{
+		boshtbl.NewValueString(resourceType),
+		boshtbl.NewValueString(cid),
+		boshtbl.NewValueString(exists),
+		boshtbl.NewValueString(action),
+	}
+}
+
+// withInstalledCpi validates the manifest and installs its CPI before calling fn.
+// fn is not called when there is no deployment state to delete.
+func (c *deploymentDeleter) withInstalledCpi(stage biui.Stage, fn func(biinstall.Installation, biconfig.DeploymentState, biinstallmanifest.Manifest) error) error {
 	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
 
 	if !c.deploymentStateService.Exists() {
@@ -145,35 +264,51 @@ func (c *deploymentDeleter) DeleteDeployment(skipDrain bool, stage biui.Stage) (
 		return err
 	}
 
-	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(localCpiInstallation biinstall.Installation) error {
-		return localCpiInstallation.WithRunningRegistry(c.logger, stage, func() error {
-			err = c.findAndDeleteDeployment(skipDrain, stage, localCpiInstallation, deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
+	return c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(localCpiInstallation biinstall.Installation) error {
+		return fn(localCpiInstallation, deploymentState, installationManifest)
+	})
+}
 
-			if err != nil {
-				return err
-			}
+// cleanupDeploymentState removes the deployment state unless orphaned disks
+// are recorded in it, in which case only what is needed to manage them is kept.
+func (c *deploymentDeleter) cleanupDeploymentState() error {
+	deploymentState, err := c.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
 
-			return stage.Perform("Uninstalling local artifacts for CPI and deployment", func() error {
-				err := c.cpiUninstaller.Uninstall(localCpiInstallation.Target())
-				if err != nil {
-					return err
-				}
+	if len(deploymentState.OrphanedDisks) == 0 {
+		return c.deploymentStateService.Cleanup()
+	}
 
-				return c.deploymentStateService.Cleanup()
-			})
-		})
-	})
+	c.logger.Debug(c.logTag, "Keeping deployment state for %d orphaned disks", len(deploymentState.OrphanedDisks))
 
-	return err
+	return c.deploymentStateService.Save(biconfig.DeploymentState{
+		DirectorID:     deploymentState.DirectorID,
+		InstallationID: deploymentState.InstallationID,
+		OrphanedDisks:  deploymentState.OrphanedDisks,
+	})
 }
 
-func (c *deploymentDeleter) findAndDeleteDeployment(skipDrain bool, stage biui.Stage, installation biinstall.Installation, directorID, installationMbus, caCert string) error {
-	deploymentManager, err := c.deploymentManager(installation, directorID, installationMbus, caCert)
+func (c *deploymentDeleter) findAndDeleteDeployment(skipDrain bool, orphanDisks bool, stage biui.Stage, installation biinstall.Installation, deploymentState biconfig.DeploymentState, installationManifest biinstallmanifest.Manifest) error {
+	deploymentManager, agentClient, err := c.deploymentManager(installation, deploymentState, installationManifest)
 	if err != nil {
 		return err
 	}
 
-	err = c.findCurrentDeploymentAndDelete(skipDrain, stage, deploymentManager)
+	beforeDelete := func(biui.Stage) error { return nil }
+	if !installationManifest.CleanupJob.IsEmpty() && deploymentState.CurrentVMCID != "" {
+		rawAgentClient, err := c.rawAgentClientFactory.NewRawAgentClient(deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
+		if err != nil {
+			return bosherr.WrapError(err, "Creating agent client")
+		}
+
+		beforeDelete = func(deleteStage biui.Stage) error {
+			return newCleanupJobRunner(agentClient, rawAgentClient, c.logger).Run(installationManifest.CleanupJob, deploymentState.CurrentVMCID, deleteStage)
+		}
+	}
+
+	err = c.findCurrentDeploymentAndDelete(skipDrain, orphanDisks, stage, deploymentManager, beforeDelete)
 	if err != nil {
 		return bosherr.WrapError(err, "Deleting deployment")
 	}
@@ -181,7 +316,7 @@ func (c *deploymentDeleter) findAndDeleteDeployment(skipDrain bool, stage biui.S
 	return deploymentManager.Cleanup(stage)
 }
 
-func (c *deploymentDeleter) findCurrentDeploymentAndDelete(skipDrain bool, stage biui.Stage, deploymentManager bidepl.Manager) error {
+func (c *deploymentDeleter) findCurrentDeploymentAndDelete(skipDrain bool, orphanDisks bool, stage biui.Stage, deploymentManager bidepl.Manager, beforeDelete func(biui.Stage) error) error {
 	c.logger.Debug(c.logTag, "Finding current deployment...")
 
 	deployment, found, err := deploymentManager.FindCurrent()
@@ -196,16 +331,25 @@ func (c *deploymentDeleter) findCurrentDeploymentAndDelete(skipDrain bool, stage
 			return nil
 		}
 
-		return deployment.Delete(skipDrain, deleteStage)
+		err := beforeDelete(deleteStage)
+		if err != nil {
+			return err
+		}
+
+		return deployment.Delete(skipDrain, orphanDisks, deleteStage)
 	})
 }
 
-func (c *deploymentDeleter) deploymentManager(installation biinstall.Installation, directorID, installationMbus, caCert string) (bidepl.Manager, error) {
+func (c *deploymentDeleter) deploymentManager(installation biinstall.Installation, deploymentState biconfig.DeploymentState, installationManifest biinstallmanifest.Manifest) (bidepl.Manager, biagentclient.AgentClient, error) {
+	directorID := deploymentState.DirectorID
+	installationMbus := installationManifest.Mbus
+	caCert := installationManifest.Cert.CA
+
 	c.logger.Debug(c.logTag, "Creating cloud client...")
 
-	cloud, err := c.cloudFactory.NewCloud(installation, directorID)
+	cloud, err := newCloudForCPI(c.cloudFactory, installation, directorID, installationManifest, deploymentState.CurrentCPI, biui.AttemptsOf(c.ui), c.timeService, c.logger)
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Creating CPI client from CPI installation")
+		return nil, nil, bosherr.WrapError(err, "Creating CPI client from CPI installation")
 	}
 
 	c.logger.Debug(c.logTag, "Creating agent client...")
@@ -216,10 +360,10 @@ func (c *deploymentDeleter) deploymentManager(installation biinstall.Installatio
 
 	blobstore, err := c.blobstoreFactory.Create(installationMbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
 	if err != nil {
-		return nil, bosherr.WrapError(err, "Creating blobstore client")
+		return nil, nil, bosherr.WrapError(err, "Creating blobstore client")
 	}
 
 	c.logger.Debug(c.logTag, "Creating deployment manager...")
 
-	return c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore), nil
+	return c.deploymentManagerFactory.NewManager(cloud, agentClient, blobstore), agentClient, nil
 }
@@ -4,6 +4,7 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"github.com/cppforlife/go-patch/patch"
 
+	bivalidation "github.com/cloudfoundry/bosh-cli/common/validation"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
 	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
@@ -62,12 +63,12 @@ func (y deploymentManifestParser) GetDeploymentManifest(path string, vars boshtp
 
 		err = y.deploymentValidator.Validate(deploymentManifest, releaseSetManifest)
 		if err != nil {
-			return bosherr.WrapError(err, "Validating deployment manifest")
+			return bivalidation.WrapError(err, "Validating deployment manifest")
 		}
 
 		err = y.deploymentValidator.ValidateReleaseJobs(deploymentManifest, y.releaseManager)
 		if err != nil {
-			return bosherr.WrapError(err, "Validating deployment jobs refer to jobs in release")
+			return bivalidation.WrapError(err, "Validating deployment jobs refer to jobs in release")
 		}
 
 		return nil
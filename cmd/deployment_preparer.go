@@ -1,34 +1,51 @@
 package cmd
 
 import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"code.cloudfoundry.org/clock"
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	"github.com/cppforlife/go-patch/patch"
 
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	bivalidation "github.com/cloudfoundry/bosh-cli/common/validation"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bipostdeploy "github.com/cloudfoundry/bosh-cli/deployment/postdeploy"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	biinstall "github.com/cloudfoundry/bosh-cli/installation"
 	boshinst "github.com/cloudfoundry/bosh-cli/installation"
 	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
+	bii18n "github.com/cloudfoundry/bosh-cli/ui/i18n"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 )
 
 func NewDeploymentPreparer(
 	ui biui.UI,
 	logger boshlog.Logger,
 	logTag string,
+	timeService clock.Clock,
 	deploymentStateService biconfig.DeploymentStateService,
 	legacyDeploymentStateMigrator biconfig.LegacyDeploymentStateMigrator,
+	vmRepo biconfig.VMRepo,
+	diskRepo biconfig.DiskRepo,
+	instanceStateRepo biconfig.InstanceStateRepo,
 	releaseManager boshinst.ReleaseManager,
 	deploymentRecord bidepl.Record,
 	cloudFactory bicloud.Factory,
@@ -47,13 +64,21 @@ func NewDeploymentPreparer(
 	deploymentManifestParser DeploymentManifestParser,
 	tempRootConfigurator TempRootConfigurator,
 	targetProvider biinstall.TargetProvider,
+	passwordHasher bicrypto.PasswordHasher,
+	postDeployChecker bipostdeploy.Checker,
+	messages bii18n.Catalog,
+	confirmation DestructiveConfirmation,
 ) DeploymentPreparer {
 	return DeploymentPreparer{
 		ui:                                      ui,
 		logger:                                  logger,
 		logTag:                                  logTag,
+		timeService:                             timeService,
 		deploymentStateService:                  deploymentStateService,
 		legacyDeploymentStateMigrator:           legacyDeploymentStateMigrator,
+		vmRepo:                                  vmRepo,
+		diskRepo:                                diskRepo,
+		instanceStateRepo:                       instanceStateRepo,
 		releaseManager:                          releaseManager,
 		deploymentRecord:                        deploymentRecord,
 		cloudFactory:                            cloudFactory,
@@ -72,6 +97,10 @@ func NewDeploymentPreparer(
 		deploymentManifestParser:                deploymentManifestParser,
 		tempRootConfigurator:                    tempRootConfigurator,
 		targetProvider:                          targetProvider,
+		passwordHasher:                          passwordHasher,
+		postDeployChecker:                       postDeployChecker,
+		messages:                                messages,
+		confirmation:                            confirmation,
 	}
 }
 
@@ -79,8 +108,12 @@ type DeploymentPreparer struct {
 	ui                                      biui.UI
 	logger                                  boshlog.Logger
 	logTag                                  string
+	timeService                             clock.Clock
 	deploymentStateService                  biconfig.DeploymentStateService
 	legacyDeploymentStateMigrator           biconfig.LegacyDeploymentStateMigrator
+	vmRepo                                  biconfig.VMRepo
+	diskRepo                                biconfig.DiskRepo
+	instanceStateRepo                       biconfig.InstanceStateRepo
 	releaseManager                          boshinst.ReleaseManager
 	deploymentRecord                        bidepl.Record
 	cloudFactory                            bicloud.Factory
@@ -99,10 +132,23 @@ type DeploymentPreparer struct {
 	deploymentManifestParser                DeploymentManifestParser
 	tempRootConfigurator                    TempRootConfigurator
 	targetProvider                          biinstall.TargetProvider
+	passwordHasher                          bicrypto.PasswordHasher
+	postDeployChecker                       bipostdeploy.Checker
+	messages                                bii18n.Catalog
+	confirmation                            DestructiveConfirmation
+}
+
+// AdoptedResources are a VM and a persistent disk created outside of the
+// deployment state, for example by a deploy whose state file was lost
+type AdoptedResources struct {
+	VMCID   string
+	DiskCID string
 }
 
-func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool, recreatePersistentDisks bool, skipDrain bool) (err error) {
-	c.ui.BeginLinef("Deployment state: '%s'\n", c.deploymentStateService.Path())
+func (r AdoptedResources) IsEmpty() bool { return r.VMCID == "" && r.DiskCID == "" }
+
+func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool, recreatePersistentDisks bool, skipDrain bool, stemcellCID string, stemcellPaths []string, convergence bideplmanifest.Convergence, dryRun bool, resetPin bool, cpiReleaseDigest string, stemcellDigest string, adopted AdoptedResources) (err error) {
+	c.ui.BeginLinef("%s\n", c.messages.T(bii18n.DeploymentStatePath, c.deploymentStateService.Path()))
 
 	if !c.deploymentStateService.Exists() {
 		migrated, err := c.legacyDeploymentStateMigrator.MigrateIfExists(biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
@@ -110,7 +156,7 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 			return bosherr.WrapError(err, "Migrating legacy deployment state file")
 		}
 		if migrated {
-			c.ui.BeginLinef("Migrated legacy deployments file: '%s'\n", biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath))
+			c.ui.BeginLinef("%s\n", c.messages.T(bii18n.MigratedLegacyDeploymentFile, biconfig.LegacyDeploymentStatePath(c.deploymentManifestPath)))
 		}
 	}
 
@@ -119,6 +165,11 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 		return bosherr.WrapError(err, "Loading deployment state")
 	}
 
+	err = c.checkAdoptable(deploymentState, adopted)
+	if err != nil {
+		return err
+	}
+
 	target, err := c.targetProvider.NewTarget()
 	if err != nil {
 		return bosherr.WrapError(err, "Determining installation target")
@@ -138,9 +189,11 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 
 	var (
 		extractedStemcell    bistemcell.ExtractedStemcell
+		additionalStemcells  []bistemcell.ExtractedStemcell
 		deploymentManifest   bideplmanifest.Manifest
 		installationManifest biinstallmanifest.Manifest
 		manifestSHA          string
+		cpiName              string
 	)
 	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
 		var releaseSetManifest birelsetmanifest.Manifest
@@ -149,8 +202,20 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 			return err
 		}
 
+		if cpiReleaseDigest != "" {
+			_, found := releaseSetManifest.FindByName(installationManifest.Template.Release)
+			if !found {
+				return bosherr.Errorf("CPI release '%s' must be in releases to verify it", installationManifest.Template.Release)
+			}
+		}
+
 		for _, releaseRef := range releaseSetManifest.Releases {
-			err = c.releaseFetcher.DownloadAndExtract(releaseRef, stage)
+			digest := ""
+			if releaseRef.Name == installationManifest.Template.Release {
+				digest = cpiReleaseDigest
+			}
+
+			err = c.releaseFetcher.DownloadVerifyAndExtract(releaseRef, digest, stage)
 			if err != nil {
 				return err
 			}
@@ -166,39 +231,141 @@ func (c *DeploymentPreparer) PrepareDeployment(stage biui.Stage, recreate bool,
 			return err
 		}
 
-		extractedStemcell, err = c.stemcellFetcher.GetStemcell(deploymentManifest, stage)
-		return err
+		if convergence != "" {
+			deploymentManifest.Update.Convergence = convergence
+		}
+
+		cpiName, err = cpiForDeployment(installationManifest, deploymentManifest, deploymentState)
+		if err != nil {
+			return err
+		}
+
+		additionalStemcells, err = c.stemcellFetcher.ExtractStemcells(stemcellPaths, stage)
+		if err != nil {
+			return err
+		}
+
+		if stemcellCID != "" {
+			if stemcellDigest != "" {
+				return bosherr.Error("Stemcells used with --stemcell-cid are not uploaded and cannot be verified")
+			}
+			return nil
+		}
+
+		stemcellRef, err := deploymentManifest.Stemcell(deploymentManifest.JobName())
+		if err != nil {
+			return err
+		}
+
+		if stemcellRef.IsNamed() {
+			if stemcellDigest != "" {
+				return bosherr.Error("Stemcells referred to by name and version cannot be verified with --stemcell-sha1")
+			}
+			extractedStemcell, err = c.findStemcell(additionalStemcells, stemcellRef)
+		} else {
+			extractedStemcell, err = c.stemcellFetcher.GetStemcellWithDigest(deploymentManifest, stemcellDigest, stage)
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(stemcellRef.CloudProperties) > 0 {
+			extractedStemcell.MergeCloudProperties(stemcellRef.CloudProperties)
+		}
+
+		return nil
 	})
+
+	defer func() {
+		for _, additionalStemcell := range additionalStemcells {
+			deleteErr := additionalStemcell.Cleanup()
+			if deleteErr != nil {
+				c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
+			}
+		}
+	}()
+
 	if err != nil {
 		return err
 	}
-	defer func() {
-		deleteErr := extractedStemcell.Cleanup()
-		if deleteErr != nil {
-			c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
+
+	stemcellManifest := bistemcell.Manifest{Name: bistemcell.ExistingStemcellName, Version: stemcellCID}
+
+	if extractedStemcell != nil {
+		stemcellManifest = extractedStemcell.Manifest()
+	}
+
+	if extractedStemcell != nil && !c.containsStemcell(additionalStemcells, extractedStemcell) {
+		defer func() {
+			deleteErr := extractedStemcell.Cleanup()
+			if deleteErr != nil {
+				c.logger.Warn(c.logTag, "Failed to delete extracted stemcell: %s", deleteErr.Error())
+			}
+		}()
+	}
+
+	err = c.hashPlaintextPasswords(deploymentManifest)
+	if err != nil {
+		return err
+	}
+
+	c.configureAgentBlobstore(installationManifest, deploymentManifest)
+
+	if !dryRun {
+		err = c.pinAgentCertificate(installationManifest, deploymentState, resetPin)
+		if err != nil {
+			return err
 		}
-	}()
+	}
 
-	isDeployed, err := c.deploymentRecord.IsDeployed(manifestSHA, c.releaseManager.List(), extractedStemcell)
+	isDeployed, err := c.deploymentRecord.IsDeployed(manifestSHA, c.releaseManager.List(), stemcellManifest)
 	if err != nil {
 		return bosherr.WrapError(err, "Checking if deployment has changed")
 	}
 
-	if isDeployed && !recreate && !recreatePersistentDisks {
-		c.ui.BeginLinef("No deployment, stemcell or release changes. Skipping deploy.\n")
+	// a deploy that did not wait for the jobs may not have attached disks or
+	// applied jobs, so it is deployed again even though nothing changed
+	if isDeployed && deploymentState.UnverifiedConvergence != "" {
+		c.logger.Info(c.logTag, "Deploying again because convergence policy '%s' did not verify the last deploy", deploymentState.UnverifiedConvergence)
+		isDeployed = false
+	}
+
+	if isDeployed && !recreate && !recreatePersistentDisks && adopted.IsEmpty() {
+		c.ui.BeginLinef("%s\n", c.messages.T(bii18n.SkippingUnchangedDeploy))
 		return nil
 	}
 
+	if dryRun {
+		return c.printPlan(deploymentManifest, deploymentState, stemcellManifest, additionalStemcells, cpiName, recreatePersistentDisks)
+	}
+
+	err = c.confirmDeletions(deploymentManifest, deploymentState, stemcellManifest, additionalStemcells, cpiName, recreate, recreatePersistentDisks)
+	if err != nil {
+		return err
+	}
+
+	// fail before installing the CPI rather than when the registry is started
+	if !installationManifest.Registry.IsEmpty() {
+		err = biregistry.CheckPort(installationManifest.Registry.ListenHost(), installationManifest.Registry.Port)
+		if err != nil {
+			return err
+		}
+	}
+
 	err = c.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(installation biinstall.Installation) error {
 		return installation.WithRunningRegistry(c.logger, stage, func() error {
 			return c.deploy(
 				installation,
 				deploymentState,
 				extractedStemcell,
+				additionalStemcells,
+				stemcellCID,
 				installationManifest,
 				deploymentManifest,
 				manifestSHA,
+				cpiName,
 				skipDrain,
+				adopted,
 				stage)
 		})
 	})
@@ -211,31 +378,108 @@ func (c *DeploymentPreparer) deploy(
 	installation biinstall.Installation,
 	deploymentState biconfig.DeploymentState,
 	extractedStemcell bistemcell.ExtractedStemcell,
+	additionalStemcells []bistemcell.ExtractedStemcell,
+	stemcellCID string,
 	installationManifest biinstallmanifest.Manifest,
 	deploymentManifest bideplmanifest.Manifest,
 	manifestSHA string,
+	cpiName string,
 	skipDrain bool,
+	adopted AdoptedResources,
 	stage biui.Stage,
 ) (err error) {
-	cloud, err := c.cloudFactory.NewCloud(installation, deploymentState.DirectorID)
+	cloud, err := newCloudForCPI(c.cloudFactory, installation, deploymentState.DirectorID, installationManifest, cpiName, biui.AttemptsOf(c.ui), c.timeService, c.logger)
 	if err != nil {
 		return bosherr.WrapError(err, "Creating CPI client from CPI installation")
 	}
 
+	err = c.deploymentStateService.Update(func(state *biconfig.DeploymentState) error {
+		state.SwitchCPI(cpiName)
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Recording deployment cpi")
+	}
+
+	info, err := c.fetchCPIInfo(cloud)
+	if err != nil {
+		return err
+	}
+
+	err = c.validateCloudProperties(info, deploymentManifest, stage)
+	if err != nil {
+		return err
+	}
+
+	c.checkQuotas(info, deploymentManifest, deploymentState)
+
+	err = c.adoptResources(cloud, deploymentManifest, adopted, stage)
+	if err != nil {
+		return err
+	}
+
 	stemcellManager := c.stemcellManagerFactory.NewManager(cloud)
 
-	cloudStemcell, err := stemcellManager.Upload(extractedStemcell, stage)
+	var cloudStemcell bistemcell.CloudStemcell
+	if stemcellCID != "" {
+		cloudStemcell, err = stemcellManager.UseExisting(stemcellCID, stage)
+	} else {
+		cloudStemcell, err = stemcellManager.Upload(extractedStemcell, stage)
+	}
 	if err != nil {
 		return err
 	}
 
+	err = c.recordStemcellUploaded(deploymentManifest)
+	if err != nil {
+		return err
+	}
+
+	// stemcells given with --stemcell are recorded in the stemcell repo
+	// and kept even when the instance does not use them
+	inUseStemcells := []bistemcell.CloudStemcell{}
+	for _, additionalStemcell := range additionalStemcells {
+		if additionalStemcell == extractedStemcell {
+			inUseStemcells = append(inUseStemcells, cloudStemcell)
+			continue
+		}
+
+		additionalCloudStemcell, err := stemcellManager.Upload(additionalStemcell, stage)
+		if err != nil {
+			return err
+		}
+		inUseStemcells = append(inUseStemcells, additionalCloudStemcell)
+	}
+
 	agentClient, err := c.agentClientFactory.NewAgentClient(deploymentState.DirectorID, installationManifest.Mbus, installationManifest.Cert.CA)
 	if err != nil {
 		return err
 	}
-	vmManager := c.vmManagerFactory.NewManager(cloud, agentClient)
 
-	blobstore, err := c.blobstoreFactory.Create(installationManifest.Mbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+	var vmManager bivm.Manager
+	if installationManifest.UserData.IsEmpty() {
+		err = c.overlayRegistryDisks(installation, deploymentManifest)
+		if err != nil {
+			return err
+		}
+
+		vmManager = c.vmManagerFactory.NewManager(cloud, agentClient)
+	} else {
+		agentMbus, err := installationManifest.AgentMbus()
+		if err != nil {
+			return err
+		}
+
+		userData := bivm.UserDataConfig{Format: installationManifest.UserData.Format, Mbus: agentMbus}
+		vmManager = c.vmManagerFactory.NewManagerWithUserData(cloud, agentClient, userData)
+	}
+
+	err = c.recordBlobScopes(installationManifest, cloudStemcell, deploymentState)
+	if err != nil {
+		return err
+	}
+
+	blobstore, err := c.agentBlobstore(installationManifest)
 	if err != nil {
 		return bosherr.WrapError(err, "Creating blobstore client")
 	}
@@ -260,12 +504,22 @@ func (c *DeploymentPreparer) deploy(
 			return bosherr.WrapError(err, "Deploying")
 		}
 
+		convergence := deploymentManifest.Update.Convergence
+
+		if len(installationManifest.PostDeployChecks) > 0 && convergence.WaitsForJobs() {
+			defaultHost, _ := deploymentManifest.StaticIP(deploymentManifest.JobName())
+			err = c.postDeployChecker.Check(installationManifest.PostDeployChecks, defaultHost, installationManifest.Cert.CA, deployStage)
+			if err != nil {
+				return bosherr.WrapError(err, "Running post-deploy checks")
+			}
+		}
+
 		err = c.deploymentRecord.Update(manifestSHA, c.releaseManager.List())
 		if err != nil {
 			return bosherr.WrapError(err, "Updating deployment record")
 		}
 
-		return nil
+		return c.recordConvergence(convergence)
 	})
 	if err != nil {
 		return err
@@ -273,10 +527,583 @@ func (c *DeploymentPreparer) deploy(
 
 	// TODO: cleanup unused disks here?
 
-	err = stemcellManager.DeleteUnused(stage)
+	if len(inUseStemcells) > 0 {
+		err = stemcellManager.DeleteUnusedExcept(stage, inUseStemcells)
+	} else {
+		err = stemcellManager.DeleteUnused(stage)
+	}
+	if err != nil {
+		return err
+	}
+
+	return c.recordResults()
+}
+
+// agentBlobstore returns the blobstore packages and rendered jobs are
+// uploaded to for the agent. With a dav agent blobstore they are uploaded
+// into it directly, where they outlive the VM, otherwise into the blobstore
+// the agent serves on the mbus.
+func (c *DeploymentPreparer) agentBlobstore(installationManifest biinstallmanifest.Manifest) (biblobstore.Blobstore, error) {
+	agentBlobstore := installationManifest.AgentBlobstore
+	if agentBlobstore.ProviderDefaulted || agentBlobstore.Provider != biinstallmanifest.AgentBlobstoreDav {
+		return c.blobstoreFactory.Create(installationManifest.Mbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+	}
+
+	var certPool *x509.CertPool
+
+	if ca := agentBlobstore.CACert(); ca != "" {
+		certPool = x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM([]byte(ca)) {
+			return nil, bosherr.Error("Parsing the CA certificate of the dav agent blobstore")
+		}
+	}
+
+	endpoint, _ := agentBlobstore.Options["endpoint"].(string)
+	user, _ := agentBlobstore.Options["user"].(string)
+	password, _ := agentBlobstore.Options["password"].(string)
+
+	return c.blobstoreFactory.CreateDav(biblobstore.Config{
+		Endpoint: endpoint,
+		Username: user,
+		Password: password,
+	}, bihttpclient.CreateDefaultClient(certPool))
+}
+
+// recordBlobScopes records the blobstores package archives are uploaded to
+// and packages are compiled into, with the stemcell packages are compiled
+// for, so that the next VM reuses them when the blobstores outlive the VM
+func (c *DeploymentPreparer) recordBlobScopes(installationManifest biinstallmanifest.Manifest, cloudStemcell bistemcell.CloudStemcell, deploymentState biconfig.DeploymentState) error {
+	var compiledPackagesScope, sourceBlobsScope string
+
+	agentBlobstore := installationManifest.AgentBlobstore
+
+	location := agentBlobstore.Location()
+	if location != "" {
+		compiledPackagesScope = fmt.Sprintf("%s %s/%s", location, cloudStemcell.Name(), cloudStemcell.Version())
+
+		if agentBlobstore.Provider == biinstallmanifest.AgentBlobstoreDav {
+			sourceBlobsScope = location
+		}
+	}
+
+	if compiledPackagesScope == deploymentState.CompiledPackagesScope && sourceBlobsScope == deploymentState.SourceBlobsScope {
+		return nil
+	}
+
+	err := c.deploymentStateService.Update(func(state *biconfig.DeploymentState) error {
+		state.CompiledPackagesScope = compiledPackagesScope
+		state.SourceBlobsScope = sourceBlobsScope
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Recording blobstore scopes")
+	}
+
+	return nil
+}
+
+// recordResults reports the CIDs of the deployed VM and its disks as results
+// so that they can be read from machine readable output
+func (c *DeploymentPreparer) recordResults() error {
+	deploymentState, err := c.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	diskCIDs := []string{}
+	for _, disk := range deploymentState.Disks {
+		diskCIDs = append(diskCIDs, disk.CID)
+	}
+
+	biui.RecordEvent(c.ui, biui.Event{Type: biui.EventTypeResult, Name: "vm_cid", Value: deploymentState.CurrentVMCID})
+	biui.RecordEvent(c.ui, biui.Event{Type: biui.EventTypeResult, Name: "disk_cids", Value: diskCIDs})
+
+	return nil
+}
+
+// printPlan shows the CPI calls a deploy would make without installing
+// the CPI or creating anything
+func (c *DeploymentPreparer) printPlan(
+	deploymentManifest bideplmanifest.Manifest,
+	deploymentState biconfig.DeploymentState,
+	stemcellManifest bistemcell.Manifest,
+	additionalStemcells []bistemcell.ExtractedStemcell,
+	cpiName string,
+	recreatePersistentDisks bool,
+) error {
+	calls, err := c.plan(deploymentManifest, deploymentState, stemcellManifest, additionalStemcells, cpiName, recreatePersistentDisks)
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "CPI calls",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Method"),
+			boshtbl.NewHeader("Target"),
+			boshtbl.NewHeader("Reason"),
+		},
+		Notes: []string{
+			"The CPI was not installed so cloud properties were not validated against its schema",
+			"Calls are planned from the deployment state, VMs or disks changed outside of the CLI are not noticed",
+		},
+	}
+
+	for _, call := range calls {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(call.Method),
+			boshtbl.NewValueString(call.Target),
+			boshtbl.NewValueString(call.Reason),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+func (c *DeploymentPreparer) plan(
+	deploymentManifest bideplmanifest.Manifest,
+	deploymentState biconfig.DeploymentState,
+	stemcellManifest bistemcell.Manifest,
+	additionalStemcells []bistemcell.ExtractedStemcell,
+	cpiName string,
+	recreatePersistentDisks bool,
+) ([]bidepl.PlannedCall, error) {
+	// plan with the stemcells of the CPI the deploy uses
+	deploymentState.SwitchCPI(cpiName)
+
+	stemcellManifests := []bistemcell.Manifest{stemcellManifest}
+	for _, additionalStemcell := range additionalStemcells {
+		manifest := additionalStemcell.Manifest()
+		if manifest.Name != stemcellManifest.Name || manifest.Version != stemcellManifest.Version {
+			stemcellManifests = append(stemcellManifests, manifest)
+		}
+	}
+
+	calls, err := c.deployer.Plan(deploymentManifest, deploymentState, stemcellManifests, recreatePersistentDisks)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Planning deploy")
+	}
+
+	return calls, nil
+}
+
+// confirmDeletions asks once for confirmation before deploying over the
+// current VM or deleting persistent disks, also for the operations the
+// confirmation policy requires confirming. Otherwise non-interactive input,
+// e.g. with --non-interactive, confirms automatically.
+func (c *DeploymentPreparer) confirmDeletions(
+	deploymentManifest bideplmanifest.Manifest,
+	deploymentState biconfig.DeploymentState,
+	stemcellManifest bistemcell.Manifest,
+	additionalStemcells []bistemcell.ExtractedStemcell,
+	cpiName string,
+	recreate bool,
+	recreatePersistentDisks bool,
+) error {
+	if deploymentState.CurrentVMCID == "" && deploymentState.CurrentDiskID == "" {
+		return nil
+	}
+
+	calls, err := c.plan(deploymentManifest, deploymentState, stemcellManifest, additionalStemcells, cpiName, recreatePersistentDisks)
 	if err != nil {
 		return err
 	}
 
+	var deletions []bidepl.PlannedCall
+
+	// deletions forced by the manifest are confirmed like the flags forcing them
+	for _, call := range calls {
+		switch call.Method {
+		case "delete_vm":
+			deletions = append(deletions, call)
+			recreate = recreate || call.ManifestChange
+		case "delete_disk":
+			deletions = append(deletions, call)
+			recreatePersistentDisks = recreatePersistentDisks || call.ManifestChange
+		}
+	}
+
+	if len(deletions) == 0 {
+		return nil
+	}
+
+	for _, call := range deletions {
+		c.ui.PrintLinef("%s", c.messages.T(bii18n.PlannedDeletion, call.Method, call.Target, call.Reason))
+	}
+
+	var operations []string
+	if recreate {
+		operations = append(operations, DestructiveRecreate)
+	}
+	if recreatePersistentDisks {
+		operations = append(operations, DestructiveRecreatePersistentDisks)
+	}
+
+	return c.confirmation.ConfirmDeletion(operations...)
+}
+
+// pinAgentCertificate pins the agent certificate fingerprint given in the
+// manifest. With resetPin and no fingerprint in the manifest the pin is
+// cleared so that the certificate seen on next contact is pinned instead.
+func (c *DeploymentPreparer) pinAgentCertificate(installationManifest biinstallmanifest.Manifest, deploymentState biconfig.DeploymentState, resetPin bool) error {
+	fingerprint := installationManifest.Cert.Fingerprint
+	if fingerprint == "" && !resetPin {
+		return nil
+	}
+
+	if deploymentState.AgentCertFingerprint == fingerprint {
+		return nil
+	}
+
+	err := c.deploymentStateService.Update(func(state *biconfig.DeploymentState) error {
+		state.AgentCertFingerprint = fingerprint
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Pinning agent certificate")
+	}
+
+	return nil
+}
+
+// checkAdoptable makes sure adopted resources do not replace ones the
+// deployment state already tracks, which would leak them in the IaaS
+func (c *DeploymentPreparer) checkAdoptable(deploymentState biconfig.DeploymentState, adopted AdoptedResources) error {
+	if adopted.VMCID != "" && deploymentState.CurrentVMCID != "" {
+		return bosherr.Errorf("Expected no current VM to adopt VM '%s', but found VM '%s'", adopted.VMCID, deploymentState.CurrentVMCID)
+	}
+
+	if adopted.DiskCID != "" && deploymentState.CurrentDiskID != "" {
+		return bosherr.Errorf("Expected no current disk to adopt disk '%s', but found disk '%s'", adopted.DiskCID, deploymentState.CurrentDiskID)
+	}
+
+	return nil
+}
+
+// adoptResources records the adopted VM and disk as the current ones so that
+// the deploy replaces the VM and attaches the disk like for any other deploy
+func (c *DeploymentPreparer) adoptResources(cloud bicloud.Cloud, deploymentManifest bideplmanifest.Manifest, adopted AdoptedResources, stage biui.Stage) error {
+	if adopted.VMCID != "" {
+		err := stage.Perform(fmt.Sprintf("Adopting VM '%s'", adopted.VMCID), func() error {
+			found, err := cloud.HasVM(adopted.VMCID)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Checking existence of VM '%s'", adopted.VMCID)
+			}
+
+			if !found {
+				return bosherr.Errorf("Expected VM '%s' to exist", adopted.VMCID)
+			}
+
+			return c.vmRepo.UpdateCurrent(adopted.VMCID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if adopted.DiskCID != "" {
+		err := stage.Perform(fmt.Sprintf("Adopting disk '%s'", adopted.DiskCID), func() error {
+			// the disk is recorded with the disk pool of the manifest,
+			// otherwise the deploy would migrate it to a new disk
+			diskPool, err := deploymentManifest.DiskPool(deploymentManifest.JobName())
+			if err != nil {
+				return err
+			}
+
+			if diskPool.DiskSize == 0 {
+				return bosherr.Errorf("Expected job '%s' to have a persistent disk to adopt disk '%s'", deploymentManifest.JobName(), adopted.DiskCID)
+			}
+
+			diskRecord, err := c.diskRepo.Save(adopted.DiskCID, diskPool.DiskSize, diskPool.CloudProperties)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Recording disk '%s'", adopted.DiskCID)
+			}
+
+			return c.diskRepo.UpdateCurrent(diskRecord.ID)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// findStemcell returns the stemcell given with --stemcell that a resource
+// pool refers to by name and version
+func (c *DeploymentPreparer) findStemcell(stemcells []bistemcell.ExtractedStemcell, stemcellRef bideplmanifest.StemcellRef) (bistemcell.ExtractedStemcell, error) {
+	for _, stemcell := range stemcells {
+		manifest := stemcell.Manifest()
+		if manifest.Name == stemcellRef.Name && manifest.Version == stemcellRef.Version {
+			return stemcell, nil
+		}
+	}
+
+	return nil, bosherr.Errorf("Stemcell '%s/%s' must be given with --stemcell", stemcellRef.Name, stemcellRef.Version)
+}
+
+func (c *DeploymentPreparer) containsStemcell(stemcells []bistemcell.ExtractedStemcell, stemcell bistemcell.ExtractedStemcell) bool {
+	for _, s := range stemcells {
+		if s == stemcell {
+			return true
+		}
+	}
+
+	return false
+}
+
+// overlayRegistryDisks adds the disks of the resource pool whose device
+// path is known upfront to the settings the registry serves the agent, like
+// user data does
+func (c *DeploymentPreparer) overlayRegistryDisks(installation biinstall.Installation, deploymentManifest bideplmanifest.Manifest) error {
+	if len(deploymentManifest.Jobs) == 0 {
+		return nil
+	}
+
+	jobName := deploymentManifest.JobName()
+
+	resourcePool, err := deploymentManifest.ResourcePool(jobName)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Getting resource pool for job '%s'", jobName)
+	}
+
+	disks := bivm.NewAgentDisks(resourcePool)
+	if disks == nil {
+		return nil
+	}
+
+	overlayJSON, err := json.Marshal(map[string]interface{}{"disks": disks})
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling registry disks settings")
+	}
+
+	return installation.SetRegistrySettingsOverlay(overlayJSON)
+}
+
+// recordStemcellUploaded starts the state transitions of the instances
+// once the stemcell their VMs are created from is in the IaaS
+func (c *DeploymentPreparer) recordStemcellUploaded(deploymentManifest bideplmanifest.Manifest) error {
+	for _, job := range deploymentManifest.Jobs {
+		for id := 0; id < job.Instances; id++ {
+			instanceName := fmt.Sprintf("%s/%d", job.Name, id)
+
+			err := c.instanceStateRepo.Start(instanceName, biconfig.InstanceStateStemcellUploaded)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Recording instance '%s' entering state '%s'", instanceName, biconfig.InstanceStateStemcellUploaded)
+			}
+		}
+	}
+
+	return nil
+}
+
+// recordConvergence keeps track in the deployment state of deploys that
+// did not verify that the jobs are running, and clears it once one does
+func (c *DeploymentPreparer) recordConvergence(convergence bideplmanifest.Convergence) error {
+	unverified := ""
+	if !convergence.WaitsForJobs() {
+		unverified = string(convergence)
+		c.ui.BeginLinef("%s\n", c.messages.T(bii18n.UnverifiedConvergenceWarning, unverified))
+	}
+
+	err := c.deploymentStateService.Update(func(state *biconfig.DeploymentState) error {
+		state.UnverifiedConvergence = unverified
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Recording deployment convergence")
+	}
+
+	return nil
+}
+
+// fetchCPIInfo returns empty info for CPIs that do not implement 'info'
+func (c *DeploymentPreparer) fetchCPIInfo(cloud bicloud.Cloud) (bicloud.Info, error) {
+	info, err := cloud.Info()
+	if err != nil {
+		if cpiErr, ok := err.(bicloud.Error); ok && cpiErr.Type() == bicloud.NotImplementedError {
+			c.logger.Debug(c.logTag, "CPI does not implement 'info', skipping cloud properties validation and quota checks")
+			return bicloud.Info{}, nil
+		}
+		return bicloud.Info{}, bosherr.WrapError(err, "Fetching CPI info")
+	}
+
+	return info, nil
+}
+
+// validateCloudProperties checks the manifest cloud_properties against the
+// schema published by the CPI 'info' method so that typos are caught before
+// any resources are created. CPIs without a schema are not validated.
+func (c *DeploymentPreparer) validateCloudProperties(info bicloud.Info, deploymentManifest bideplmanifest.Manifest, stage biui.Stage) error {
+	schema := info.CloudPropertiesSchema
+	if schema.IsEmpty() {
+		return nil
+	}
+
+	errs := []error{}
+	warnings := []string{}
+
+	validate := func(propertiesSchema bicloud.PropertiesSchema, path string, cloudProperties biproperty.Map) {
+		if propertiesSchema.IsEmpty() {
+			return
+		}
+
+		propertiesErrs, propertiesWarnings := propertiesSchema.Validate(path, cloudProperties)
+		errs = append(errs, propertiesErrs...)
+		warnings = append(warnings, propertiesWarnings...)
+	}
+
+	err := stage.Perform("Validating cloud properties", func() error {
+		for idx, resourcePool := range deploymentManifest.ResourcePools {
+			validate(schema.VM, fmt.Sprintf("resource_pools[%d].cloud_properties", idx), resourcePool.CloudProperties)
+		}
+
+		for idx, diskPool := range deploymentManifest.DiskPools {
+			validate(schema.Disk, fmt.Sprintf("disk_pools[%d].cloud_properties", idx), diskPool.CloudProperties)
+		}
+
+		for idx, network := range deploymentManifest.Networks {
+			validate(schema.Network, fmt.Sprintf("networks[%d].cloud_properties", idx), network.CloudProperties)
+
+			for subnetIdx, subnet := range network.Subnets {
+				validate(schema.Network, fmt.Sprintf("networks[%d].subnets[%d].cloud_properties", idx, subnetIdx), subnet.CloudProperties)
+			}
+		}
+
+		if len(errs) > 0 {
+			return bivalidation.WrapError(bosherr.NewMultiError(errs...), "Validating cloud properties against the CPI schema")
+		}
+
+		return nil
+	})
+
+	for _, warning := range warnings {
+		c.ui.BeginLinef("%s\n", c.messages.T(bii18n.CloudPropertyWarning, warning))
+	}
+
+	return err
+}
+
+// checkQuotas warns before any resources are created when the quotas
+// published by the CPI 'info' method do not leave enough room for the
+// deploy. The estimate is a preflight only, the deploy is attempted anyway.
+func (c *DeploymentPreparer) checkQuotas(info bicloud.Info, deploymentManifest bideplmanifest.Manifest, deploymentState biconfig.DeploymentState) {
+	requirements, err := c.estimateResources(deploymentManifest, deploymentState)
+	if err != nil {
+		c.logger.Debug(c.logTag, "Skipping quota checks: %s", err.Error())
+		return
+	}
+
+	for _, shortfall := range info.Quotas.Shortfalls(requirements) {
+		c.ui.BeginLinef("%s\n", c.messages.T(bii18n.QuotaWarning, shortfall.Resource, shortfall.Required, shortfall.Available, shortfall.Limit))
+	}
+}
+
+// estimateResources counts only what the deploy creates on top of what the
+// deployment already holds: the current VM is deleted before it is replaced,
+// and a persistent disk is only created when there is none or it has to be
+// migrated. VM size is taken from the cpu and ram cloud properties of the
+// resource pool where the CPI uses them.
+func (c *DeploymentPreparer) estimateResources(deploymentManifest bideplmanifest.Manifest, deploymentState biconfig.DeploymentState) (bicloud.ResourceRequirements, error) {
+	requirements := bicloud.ResourceRequirements{}
+
+	var currentDisk *biconfig.DiskRecord
+	for i, record := range deploymentState.Disks {
+		if record.ID == deploymentState.CurrentDiskID {
+			currentDisk = &deploymentState.Disks[i]
+		}
+	}
+
+	for _, job := range deploymentManifest.Jobs {
+		if deploymentState.CurrentVMCID == "" {
+			resourcePool, err := deploymentManifest.ResourcePool(job.Name)
+			if err != nil {
+				return requirements, err
+			}
+
+			requirements.Instances += job.Instances
+			requirements.Cores += job.Instances * intCloudProperty(resourcePool.CloudProperties, "cpu")
+			requirements.RAMMB += job.Instances * intCloudProperty(resourcePool.CloudProperties, "ram")
+			requirements.IPs += job.Instances * len(job.Networks)
+		}
+
+		diskPool, err := deploymentManifest.DiskPool(job.Name)
+		if err != nil {
+			return requirements, err
+		}
+
+		if diskPool.DiskSize == 0 {
+			continue
+		}
+
+		if currentDisk != nil && currentDisk.Size == diskPool.DiskSize && reflect.DeepEqual(currentDisk.CloudProperties, diskPool.CloudProperties) {
+			continue
+		}
+
+		requirements.DiskGB += job.Instances * ((diskPool.DiskSize + 1023) / 1024)
+	}
+
+	return requirements, nil
+}
+
+func intCloudProperty(cloudProperties biproperty.Map, key string) int {
+	switch value := cloudProperties[key].(type) {
+	case int:
+		return value
+	case int64:
+		return int(value)
+	case uint64:
+		return int(value)
+	case float64:
+		return int(value)
+	default:
+		return 0
+	}
+}
+
+// configureAgentBlobstore passes the agent blobstore of the installation
+// manifest to the agent in env.bosh.blobstores, which the agent uses instead
+// of the blobstore the CPI puts into its settings. Blobstores without a
+// provider are left to the CPI job templates that read the flat properties.
+func (c *DeploymentPreparer) configureAgentBlobstore(installationManifest biinstallmanifest.Manifest, deploymentManifest bideplmanifest.Manifest) {
+	agentBlobstore := installationManifest.AgentBlobstore
+	if agentBlobstore.IsEmpty() || agentBlobstore.ProviderDefaulted {
+		return
+	}
+
+	for i := range deploymentManifest.ResourcePools {
+		resourcePool := &deploymentManifest.ResourcePools[i]
+		if resourcePool.HasBlobstores() {
+			continue
+		}
+
+		resourcePool.SetBlobstores([]interface{}{
+			biproperty.Map{
+				"provider": agentBlobstore.Provider,
+				"options":  agentBlobstore.Options,
+			},
+		})
+	}
+}
+
+func (c *DeploymentPreparer) hashPlaintextPasswords(deploymentManifest bideplmanifest.Manifest) error {
+	for _, resourcePool := range deploymentManifest.ResourcePools {
+		password, found := resourcePool.PlaintextPassword()
+		if !found {
+			continue
+		}
+
+		c.ui.BeginLinef("%s\n", c.messages.T(bii18n.PlaintextPasswordWarning, resourcePool.Name))
+
+		hashedPassword, err := c.passwordHasher.Hash(password)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Hashing env.bosh.password for resource pool '%s'", resourcePool.Name)
+		}
+
+		resourcePool.SetPassword(hashedPassword)
+	}
+
 	return nil
 }
@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+const (
+	DestructiveDeleteEnv               = "delete-env"
+	DestructiveRecreate                = "recreate"
+	DestructiveRecreatePersistentDisks = "recreate-persistent-disks"
+	DestructiveDeleteOrphanedDisks     = "delete-orphaned-disks"
+	DestructivePruneStemcells          = "prune-stemcells"
+)
+
+// DestructiveConfirmation asks for confirmation of operations listed in the
+// confirm_destructive config setting. Unlike AskForConfirmation it does not
+// confirm automatically when input is non-interactive.
+type DestructiveConfirmation struct {
+	ui        boshui.UI
+	policy    cmdconf.ConfirmationPolicy
+	confirmed bool
+}
+
+func NewDestructiveConfirmation(ui boshui.UI, policy cmdconf.ConfirmationPolicy) DestructiveConfirmation {
+	return DestructiveConfirmation{ui: ui, policy: policy}
+}
+
+// Confirmed returns a confirmation that does not ask again, for commands
+// that confirmed their operations before going on to perform them
+func (c DestructiveConfirmation) Confirmed() DestructiveConfirmation {
+	c.confirmed = true
+	return c
+}
+
+// Check fails early when the confirmation policy requires confirming one
+// of the operations but the confirmation cannot be asked for
+func (c DestructiveConfirmation) Check(operations ...string) error {
+	required := c.required(operations)
+	if len(required) == 0 || c.confirmed {
+		return nil
+	}
+
+	return c.checkCanAsk(required)
+}
+
+func (c DestructiveConfirmation) Confirm(operations ...string) error {
+	required := c.required(operations)
+	if len(required) == 0 || c.confirmed {
+		return nil
+	}
+
+	err := c.checkCanAsk(required)
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintLinef("Confirmation policy requires confirming '%s'", strings.Join(required, "', '"))
+
+	return c.ui.AskForConfirmation()
+}
+
+// ConfirmDeletion asks once for confirmation of operations deleting VMs or
+// disks. Unless the confirmation policy requires confirming one of them,
+// the operations are confirmed automatically when input is non-interactive,
+// e.g. with --non-interactive.
+func (c DestructiveConfirmation) ConfirmDeletion(operations ...string) error {
+	if c.confirmed {
+		return nil
+	}
+
+	if len(c.required(operations)) > 0 {
+		return c.Confirm(operations...)
+	}
+
+	if c.ui.IsInteractive() && !boshui.CanAskForInput(c.ui) {
+		return bosherr.Error("Cannot ask for confirmation of deleting VMs or disks with --json, confirm with --non-interactive")
+	}
+
+	return c.ui.AskForConfirmation()
+}
+
+func (c DestructiveConfirmation) required(operations []string) []string {
+	var required []string
+
+	for _, op := range operations {
+		if c.policy.Requires(op) {
+			required = append(required, op)
+		}
+	}
+
+	return required
+}
+
+func (c DestructiveConfirmation) checkCanAsk(required []string) error {
+	names := strings.Join(required, "', '")
+
+	if !c.ui.IsInteractive() {
+		return bosherr.Errorf("Confirmation policy requires confirming '%s', but input is non-interactive", names)
+	}
+
+	if !boshui.CanAskForInput(c.ui) {
+		return bosherr.Errorf("Confirmation policy requires confirming '%s', but cannot ask for confirmation with --json", names)
+	}
+
+	return nil
+}
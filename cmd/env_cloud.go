@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// envCloud installs the CPI of an environment to act on the IaaS resources
+// recorded in its deployment state without deploying it. The CPI is
+// uninstalled afterwards.
+type envCloud struct {
+	logTag                                  string
+	logger                                  boshlog.Logger
+	timeService                             clock.Clock
+	deploymentStateService                  biconfig.DeploymentStateService
+	releaseManager                          biinstall.ReleaseManager
+	cloudFactory                            bicloud.Factory
+	deploymentManifestPath                  string
+	deploymentVars                          boshtpl.Variables
+	deploymentOp                            patch.Op
+	cpiInstaller                            bicpirel.CpiInstaller
+	cpiUninstaller                          biinstall.Uninstaller
+	releaseFetcher                          biinstall.ReleaseFetcher
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser
+	tempRootConfigurator                    TempRootConfigurator
+	targetProvider                          biinstall.TargetProvider
+	attempts                                *biui.Attempts
+}
+
+func (e envCloud) Run(stage biui.Stage, fn func(bicloud.Cloud) error) error {
+	deploymentState, err := e.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	target, err := e.targetProvider.NewTarget()
+	if err != nil {
+		return bosherr.WrapError(err, "Determining installation target")
+	}
+
+	err = e.tempRootConfigurator.PrepareAndSetTempRoot(target.TmpPath(), e.logger)
+	if err != nil {
+		return bosherr.WrapError(err, "Setting temp root")
+	}
+
+	defer func() {
+		err := e.releaseManager.DeleteAll()
+		if err != nil {
+			e.logger.Warn(e.logTag, "Deleting all extracted releases: %s", err.Error())
+		}
+	}()
+
+	var installationManifest biinstallmanifest.Manifest
+
+	err = stage.PerformComplex("validating", func(stage biui.Stage) error {
+		var releaseSetManifest birelsetmanifest.Manifest
+		releaseSetManifest, installationManifest, err = e.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(e.deploymentManifestPath, e.deploymentVars, e.deploymentOp)
+		if err != nil {
+			return err
+		}
+
+		cpiReleaseName := installationManifest.Template.Release
+		cpiReleaseRef, found := releaseSetManifest.FindByName(cpiReleaseName)
+		if !found {
+			return bosherr.Errorf("installation release '%s' must refer to a release in releases", cpiReleaseName)
+		}
+
+		err = e.releaseFetcher.DownloadAndExtract(cpiReleaseRef, stage)
+		if err != nil {
+			return err
+		}
+
+		return e.cpiInstaller.ValidateCpiRelease(installationManifest, stage)
+	})
+	if err != nil {
+		return err
+	}
+
+	return e.cpiInstaller.WithInstalledCpiRelease(installationManifest, target, stage, func(installation biinstall.Installation) error {
+		err := installation.WithRunningRegistry(e.logger, stage, func() error {
+			cloud, err := newCloudForCPI(e.cloudFactory, installation, deploymentState.DirectorID, installationManifest, deploymentState.CurrentCPI, e.attempts, e.timeService, e.logger)
+			if err != nil {
+				return bosherr.WrapError(err, "Creating CPI client from CPI installation")
+			}
+
+			return fn(cloud)
+		})
+
+		uninstallErr := e.cpiUninstaller.Uninstall(installation.Target())
+		if uninstallErr != nil {
+			e.logger.Warn(e.logTag, "Uninstalling CPI: %s", uninstallErr.Error())
+		}
+
+		return err
+	})
+}
@@ -8,31 +8,39 @@ import (
 	"github.com/cppforlife/go-patch/patch"
 
 	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+	biagentclient "github.com/cloudfoundry/bosh-cli/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bistatebackend "github.com/cloudfoundry/bosh-cli/config/statebackend"
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	biinstancestate "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bipostdeploy "github.com/cloudfoundry/bosh-cli/deployment/postdeploy"
 	bideplrel "github.com/cloudfoundry/bosh-cli/deployment/release"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
 	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
-	biindex "github.com/cloudfoundry/bosh-cli/index"
+	bifault "github.com/cloudfoundry/bosh-cli/faultinjection"
 	boshinst "github.com/cloudfoundry/bosh-cli/installation"
 	boshinstmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biinstallpkg "github.com/cloudfoundry/bosh-cli/installation/pkg"
 	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
 	bitemplateerb "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
+	bitracing "github.com/cloudfoundry/bosh-cli/tracing"
 	"github.com/cloudfoundry/bosh-utils/httpclient"
 )
 
@@ -42,6 +50,11 @@ type envFactory struct {
 	manifestVars boshtpl.Variables
 	manifestOp   patch.Op
 
+	installationsRootPath      string
+	vmRepo                     biconfig.VMRepo
+	diskRepo                   biconfig.DiskRepo
+	instanceStateRepo          biconfig.InstanceStateRepo
+	stemcellRepo               biconfig.StemcellRepo
 	deploymentStateService     biconfig.DeploymentStateService
 	installationManifestParser ReleaseSetAndInstallationManifestParser
 
@@ -64,6 +77,8 @@ type envFactory struct {
 	blobstoreFactory   biblobstore.Factory
 	deploymentFactory  bidepl.Factory
 	deploymentRecord   bidepl.Record
+
+	confirmation DestructiveConfirmation
 }
 
 func NewEnvFactory(
@@ -81,6 +96,8 @@ func NewEnvFactory(
 		manifestOp:   manifestOp,
 	}
 
+	f.confirmation = NewDestructiveConfirmation(deps.UI, cmdconf.ConfirmationPolicy{})
+
 	f.releaseManager = boshinst.NewReleaseManager(deps.Logger)
 	releaseJobResolver := bideplrel.NewJobResolver(f.releaseManager)
 
@@ -91,35 +108,46 @@ func NewEnvFactory(
 		tarballCacheBasePath := filepath.Join(workspaceRootPath, "downloads")
 		tarballCache := bitarball.NewCache(tarballCacheBasePath, deps.FS, deps.Logger)
 		httpClient := httpclient.NewHTTPClient(httpclient.CreateDefaultClient(nil), deps.Logger)
-		tarballProvider := bitarball.NewProvider(
-			tarballCache, deps.FS, httpClient, 3, 500*time.Millisecond, deps.Logger)
+		tarballProvider := bitarball.NewProviderWithChecksums(
+			tarballCache, deps.FS, httpClient, 3, 500*time.Millisecond, deps.ChecksumProvider, deps.UI.Attempts(), deps.Logger)
 
 		releaseProvider := boshrel.NewProvider(
 			deps.CmdRunner, deps.Compressor, deps.DigestCalculator, deps.FS, deps.Logger)
 
-		f.releaseFetcher = boshinst.NewReleaseFetcher(
+		digestVerifier := bicrypto.NewDigestVerifier(deps.FS, deps.ChecksumProvider)
+
+		f.releaseFetcher = boshinst.NewReleaseFetcherWithDigestVerifier(
 			tarballProvider,
 			releaseProvider.NewExtractingArchiveReader(),
 			f.releaseManager,
+			digestVerifier,
 		)
 
-		stemcellReader := bistemcell.NewReader(deps.Compressor, deps.FS)
-		stemcellExtractor := bistemcell.NewExtractor(stemcellReader, deps.FS)
+		stemcellExtractor := bistemcell.NewStreamingExtractor(deps.Compressor, digestVerifier, deps.FS, deps.Logger)
 
 		f.stemcellFetcher = bistemcell.Fetcher{
 			TarballProvider:   tarballProvider,
 			StemcellExtractor: stemcellExtractor,
+			ImageConverter:    bistemcell.NewQemuImgConverter(deps.CmdRunner, deps.Compressor, deps.FS, deps.Logger),
+			DigestVerifier:    digestVerifier,
 		}
 	}
 
-	f.deploymentStateService = biconfig.NewFileSystemDeploymentStateService(
-		deps.FS, deps.UUIDGen, deps.Logger, biconfig.DeploymentStatePath(manifestPath, statePath))
+	if bistatebackend.IsRemote(statePath) {
+		f.deploymentStateService = biconfig.NewRemoteDeploymentStateService(
+			bistatebackend.NewBackend(statePath, deps.Logger), deps.UUIDGen, deps.Logger)
+	} else {
+		f.deploymentStateService = biconfig.NewFileSystemDeploymentStateService(
+			deps.FS, deps.UUIDGen, deps.Logger, biconfig.ResolveDeploymentStatePath(deps.FS, manifestPath, statePath))
+	}
 
 	{
-		registryServer := biregistry.NewServerManager(deps.Logger)
+		registryServer := biregistry.NewServerManager(deps.FS, deps.Logger)
 		installerFactory := boshinst.NewInstallerFactory(
 			deps.UI, deps.CmdRunner, deps.Compressor, releaseJobResolver,
-			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms)
+			deps.UUIDGen, registryServer, deps.Logger, deps.FS, deps.DigestCreationAlgorithms,
+			biinstallpkg.NewCompiledPackageCache(filepath.Join(workspaceRootPath, "compiled_packages"), deps.FS, deps.Logger),
+			deps.Parallel)
 
 		f.cpiInstaller = bicpirel.CpiInstaller{
 			ReleaseManager:   f.releaseManager,
@@ -128,20 +156,24 @@ func NewEnvFactory(
 		}
 	}
 
-	f.targetProvider = boshinst.NewTargetProvider(
-		f.deploymentStateService, deps.UUIDGen, filepath.Join(workspaceRootPath, "installations"))
+	f.installationsRootPath = filepath.Join(workspaceRootPath, "installations")
+	f.targetProvider = boshinst.NewTargetProviderWithOwners(
+		f.deploymentStateService, deps.UUIDGen, f.installationsRootPath, deps.FS)
 
 	{
 		diskRepo := biconfig.NewDiskRepo(f.deploymentStateService, deps.UUIDGen)
+		f.diskRepo = diskRepo
 		stemcellRepo := biconfig.NewStemcellRepo(f.deploymentStateService, deps.UUIDGen)
+		f.stemcellRepo = stemcellRepo
 		vmRepo := biconfig.NewVMRepo(f.deploymentStateService)
+		f.vmRepo = vmRepo
 
 		f.diskManagerFactory = bidisk.NewManagerFactory(diskRepo, deps.Logger)
 		diskDeployer := bivm.NewDiskDeployer(f.diskManagerFactory, diskRepo, deps.Logger, recreatePersistentDisks)
 
 		f.stemcellManagerFactory = bistemcell.NewManagerFactory(stemcellRepo)
-		f.vmManagerFactory = bivm.NewManagerFactory(
-			vmRepo, stemcellRepo, diskDeployer, deps.UUIDGen, deps.FS, deps.Logger)
+		f.vmManagerFactory = bivm.NewManagerFactoryWithAttemptReporter(
+			vmRepo, stemcellRepo, diskDeployer, deps.UUIDGen, deps.FS, deps.UI.Attempts(), deps.Logger)
 
 		deploymentRepo := biconfig.NewDeploymentRepo(f.deploymentStateService)
 		releaseRepo := biconfig.NewReleaseRepo(f.deploymentStateService, deps.UUIDGen)
@@ -149,10 +181,23 @@ func NewEnvFactory(
 	}
 
 	{
-		f.blobstoreFactory = biblobstore.NewBlobstoreFactory(deps.UUIDGen, deps.FS, deps.Logger)
-		f.deploymentFactory = bidepl.NewFactory(10*time.Second, 500*time.Millisecond)
-		f.agentClientFactory = bihttpagent.NewAgentClientFactory(1*time.Second, deps.Logger)
-		f.cloudFactory = bicloud.NewFactory(deps.FS, deps.CmdRunner, deps.Logger)
+		f.blobstoreFactory = biblobstore.NewBlobstoreFactoryWithRetries(deps.UUIDGen, deps.FS, biblobstore.RetryOptions{
+			MaxAttempts: 3,
+			Backoff:     biretrier.NewExponentialBackoff(1*time.Second, 10*time.Second, 2),
+		}, deps.Time, deps.Logger)
+		f.deploymentFactory = bidepl.NewFactory(10*time.Second, 500*time.Millisecond, deps.Time)
+		var agentClientFactory bihttpagent.AgentClientFactory = biagentclient.NewValidatingAgentClientFactory(
+			biagentclient.NewPinningAgentClientFactory(1*time.Second, biagentclient.NewStateCertificatePins(f.deploymentStateService), deps.Logger),
+			biagentclient.NewRawAgentClientFactory(1*time.Second, deps.Logger))
+		var cloudFactory bicloud.Factory = bicloud.NewFactoryWithWatchdog(deps.FS, deps.CmdRunner, deps.CPIWatchdog, deps.Logger)
+
+		if deps.FaultInjector != nil {
+			agentClientFactory = bifault.NewAgentClientFactory(agentClientFactory, deps.FaultInjector)
+			cloudFactory = bifault.NewCloudFactory(cloudFactory, deps.FaultInjector)
+		}
+
+		f.agentClientFactory = bitracing.NewAgentClientFactory(agentClientFactory, deps.Tracer)
+		f.cloudFactory = bitracing.NewCloudFactory(cloudFactory, deps.Tracer)
 	}
 
 	{
@@ -160,7 +205,9 @@ func NewEnvFactory(
 		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, deps.FS, deps.UUIDGen, deps.Logger)
 
 		builderFactory := biinstancestate.NewBuilderFactory(
-			bistatepkg.NewCompiledPackageRepo(biindex.NewInMemoryIndex()),
+			bistatepkg.NewCompiledPackageRepo(biconfig.NewCompiledPackageIndex(f.deploymentStateService)),
+			bistatepkg.NewSourceBlobRepo(biconfig.NewSourceBlobIndex(f.deploymentStateService)),
+			deps.Parallel,
 			releaseJobResolver,
 			bitemplate.NewJobListRenderer(jobRenderer, deps.Logger),
 			bitemplate.NewRenderedJobListCompressor(deps.FS, deps.Compressor, deps.DigestCalculator, deps.Logger),
@@ -168,10 +215,11 @@ func NewEnvFactory(
 		)
 
 		sshTunnelFactory := bisshtunnel.NewFactory(deps.Logger)
-		instanceFactory := biinstance.NewFactory(builderFactory)
+		f.instanceStateRepo = biconfig.NewInstanceStateRepo(f.deploymentStateService, deps.Time)
+		instanceFactory := biinstance.NewFactory(builderFactory, f.instanceStateRepo)
 
 		f.instanceManagerFactory = biinstance.NewManagerFactory(
-			sshTunnelFactory, instanceFactory, deps.Logger)
+			sshTunnelFactory, instanceFactory, f.instanceStateRepo, deps.Logger)
 	}
 
 	{
@@ -190,11 +238,23 @@ func NewEnvFactory(
 	return &f
 }
 
+// WithConfirmation asks for confirmation of deletions following the
+// confirmation policy of confirmation instead of an empty one
+func (f *envFactory) WithConfirmation(confirmation DestructiveConfirmation) *envFactory {
+	f.confirmation = confirmation
+	return f
+}
+
 func (f *envFactory) Preparer() DeploymentPreparer {
+	return f.preparer(f.confirmation)
+}
+
+func (f *envFactory) preparer(confirmation DestructiveConfirmation) DeploymentPreparer {
 	return NewDeploymentPreparer(
 		f.deps.UI,
 		f.deps.Logger,
 		"DeploymentPreparer",
+		f.deps.Time,
 		f.deploymentStateService,
 		biconfig.NewLegacyDeploymentStateMigrator(
 			f.deploymentStateService,
@@ -202,6 +262,9 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 			f.deps.UUIDGen,
 			f.deps.Logger,
 		),
+		biconfig.NewVMRepo(f.deploymentStateService),
+		f.diskRepo,
+		f.instanceStateRepo,
 		f.releaseManager,
 		f.deploymentRecord,
 		f.cloudFactory,
@@ -230,6 +293,10 @@ func (f *envFactory) Preparer() DeploymentPreparer {
 		),
 		NewTempRootConfigurator(f.deps.FS),
 		f.targetProvider,
+		bicrypto.NewSHA512CryptHasher(),
+		bipostdeploy.NewChecker(f.deps.Time, 1*time.Second, f.deps.Logger),
+		f.deps.Messages,
+		confirmation,
 	)
 }
 
@@ -238,10 +305,12 @@ func (f *envFactory) Deleter() DeploymentDeleter {
 		f.deps.UI,
 		"DeploymentDeleter",
 		f.deps.Logger,
+		f.deps.Time,
 		f.deploymentStateService,
 		f.releaseManager,
 		f.cloudFactory,
 		f.agentClientFactory,
+		biagentclient.NewRawAgentClientFactory(1*time.Second, f.deps.Logger),
 		f.blobstoreFactory,
 		bidepl.NewManagerFactory(
 			f.vmManagerFactory,
@@ -261,3 +330,134 @@ func (f *envFactory) Deleter() DeploymentDeleter {
 		f.targetProvider,
 	)
 }
+
+func (f *envFactory) LifecycleTester() CpiLifecycleTester {
+	return NewCpiLifecycleTester(
+		f.deps.UI,
+		"CpiLifecycleTester",
+		f.deps.Logger,
+		f.deps.Time,
+		f.deps.UUIDGen,
+		f.releaseManager,
+		f.cloudFactory,
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.cpiInstaller,
+		boshinst.NewUninstaller(f.deps.FS, f.deps.Logger),
+		f.releaseFetcher,
+		f.stemcellFetcher,
+		f.installationManifestParser,
+		NewDeploymentManifestParser(
+			bideplmanifest.NewParser(f.deps.FS, f.deps.Logger),
+			bideplmanifest.NewValidator(f.deps.Logger),
+			f.releaseManager,
+			bidepltpl.NewDeploymentTemplateFactory(f.deps.FS),
+		),
+		NewTempRootConfigurator(f.deps.FS),
+		f.installationsRootPath,
+	)
+}
+
+func (f *envFactory) OrphanedDisksManager() OrphanedDisksManager {
+	return NewOrphanedDisksManager(
+		"OrphanedDisksManager",
+		f.deps.Logger,
+		f.deps.Time,
+		f.deploymentStateService,
+		f.diskRepo,
+		f.releaseManager,
+		f.cloudFactory,
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.cpiInstaller,
+		boshinst.NewUninstaller(f.deps.FS, f.deps.Logger),
+		f.releaseFetcher,
+		f.installationManifestParser,
+		NewTempRootConfigurator(f.deps.FS),
+		f.targetProvider,
+		f.deps.UI.Attempts(),
+	)
+}
+
+func (f *envFactory) EnvStemcellsManager() EnvStemcellsManager {
+	return NewEnvStemcellsManager(
+		"EnvStemcellsManager",
+		f.deps.Logger,
+		f.deps.Time,
+		f.deploymentStateService,
+		f.stemcellRepo,
+		f.stemcellManagerFactory,
+		f.releaseManager,
+		f.cloudFactory,
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.cpiInstaller,
+		boshinst.NewUninstaller(f.deps.FS, f.deps.Logger),
+		f.releaseFetcher,
+		f.installationManifestParser,
+		NewTempRootConfigurator(f.deps.FS),
+		f.targetProvider,
+		f.deps.UI.Attempts(),
+	)
+}
+
+func (f *envFactory) PruneStateManager() PruneStateManager {
+	return NewPruneStateManager(
+		"PruneStateManager",
+		f.deps.Logger,
+		f.deps.Time,
+		f.deploymentStateService,
+		f.vmRepo,
+		f.diskRepo,
+		f.releaseManager,
+		f.cloudFactory,
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.cpiInstaller,
+		boshinst.NewUninstaller(f.deps.FS, f.deps.Logger),
+		f.releaseFetcher,
+		f.installationManifestParser,
+		NewTempRootConfigurator(f.deps.FS),
+		f.targetProvider,
+		f.deps.UI.Attempts(),
+	)
+}
+
+func (f *envFactory) DeploymentStateService() biconfig.DeploymentStateService {
+	return f.deploymentStateService
+}
+
+func (f *envFactory) CredsRotationEnv() CredsRotationEnv {
+	// rotate-creds confirms recreating the VM before it stops the jobs
+	return NewCredsRotationEnv(f.AgentActionSender(), f.preparer(f.confirmation.Confirmed()), f.deploymentStateService, f.deps.Time)
+}
+
+func (f *envFactory) EnvLogsFetcher() EnvLogsFetcher {
+	return NewEnvLogsFetcher(
+		f.AgentActionSender(),
+		f.blobstoreFactory,
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.installationManifestParser,
+		f.deps.FS,
+		f.deps.Logger,
+	)
+}
+
+func (f *envFactory) AgentActionSender() AgentActionSender {
+	return NewAgentActionSender(
+		"AgentActionSender",
+		f.deps.Logger,
+		f.deploymentStateService,
+		biagentclient.NewRawAgentClientFactory(1*time.Second, f.deps.Logger),
+		f.manifestPath,
+		f.manifestVars,
+		f.manifestOp,
+		f.installationManifestParser,
+	)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type EnvLogsCmd struct {
+	ui          boshui.UI
+	timeService clock.Clock
+	envProvider func(string, string, boshtpl.Variables, patch.Op) EnvLogsFetcher
+}
+
+func NewEnvLogsCmd(ui boshui.UI, timeService clock.Clock, envProvider func(string, string, boshtpl.Variables, patch.Op) EnvLogsFetcher) EnvLogsCmd {
+	return EnvLogsCmd{ui: ui, timeService: timeService, envProvider: envProvider}
+}
+
+func (c EnvLogsCmd) Run(opts EnvLogsOpts) error {
+	if opts.Agent && len(opts.Jobs) > 0 {
+		return bosherr.Error("Expected either --agent or --job, job logs do not include agent logs")
+	}
+
+	logType := "job"
+	if opts.Agent {
+		logType = "agent"
+	}
+
+	filters := []string{}
+
+	for _, job := range opts.Jobs {
+		filters = append(filters, fmt.Sprintf("%s/**/*", job))
+	}
+
+	filters = append(filters, opts.Filters...)
+
+	manifestName := filepath.Base(opts.Args.Manifest.Path)
+	manifestName = strings.TrimSuffix(manifestName, filepath.Ext(manifestName))
+
+	tsSuffix := strings.Replace(c.timeService.Now().Format("20060102-150405.999999999"), ".", "-", -1)
+
+	dstPath := filepath.Join(opts.Directory.Path, fmt.Sprintf("%s.%s-logs-%s.tgz", manifestName, logType, tsSuffix))
+
+	fetcher := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	c.ui.PrintLinef("Downloading %s logs to '%s'...", logType, dstPath)
+
+	err := fetcher.FetchLogs(logType, filters, dstPath)
+	if err != nil {
+		return bosherr.WrapError(err, "Fetching logs")
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
+	bihttpclient "github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/cppforlife/go-patch/patch"
+
+	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+type EnvLogsFetcher interface {
+	// FetchLogs has the agent package its logs of logType, job or agent,
+	// matching filters and downloads the tarball to dstPath
+	FetchLogs(logType string, filters []string, dstPath string) error
+}
+
+type envLogsResponse struct {
+	Value struct {
+		BlobstoreID string `json:"blobstore_id"`
+		SHA1        string `json:"sha1"`
+	}
+	Exception *struct {
+		Message string
+	}
+}
+
+type envLogsFetcher struct {
+	agentActionSender                       AgentActionSender
+	blobstoreFactory                        biblobstore.Factory
+	deploymentManifestPath                  string
+	deploymentVars                          boshtpl.Variables
+	deploymentOp                            patch.Op
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser
+	fs                                      boshsys.FileSystem
+	logger                                  boshlog.Logger
+	logTag                                  string
+}
+
+func NewEnvLogsFetcher(
+	agentActionSender AgentActionSender,
+	blobstoreFactory biblobstore.Factory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) EnvLogsFetcher {
+	return envLogsFetcher{
+		agentActionSender:                       agentActionSender,
+		blobstoreFactory:                        blobstoreFactory,
+		deploymentManifestPath:                  deploymentManifestPath,
+		deploymentVars:                          deploymentVars,
+		deploymentOp:                            deploymentOp,
+		releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+		fs:                                      fs,
+		logger:                                  logger,
+		logTag:                                  "envLogsFetcher",
+	}
+}
+
+func (f envLogsFetcher) FetchLogs(logType string, filters []string, dstPath string) error {
+	responseBody, err := f.agentActionSender.Send("fetch_logs", []interface{}{logType, filters})
+	if err != nil {
+		return err
+	}
+
+	var response envLogsResponse
+
+	err = json.Unmarshal(responseBody, &response)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling agent response to 'fetch_logs'")
+	}
+
+	if response.Exception != nil {
+		return bosherr.Errorf("Agent responded with error: %s", response.Exception.Message)
+	}
+
+	if response.Value.BlobstoreID == "" {
+		return bosherr.Error("Expected the agent to respond with the blobstore ID of the logs")
+	}
+
+	// the agent serves its blobstore at the mbus URL
+	_, installationManifest, err := f.releaseSetAndInstallationManifestParser.ReleaseSetAndInstallationManifest(f.deploymentManifestPath, f.deploymentVars, f.deploymentOp)
+	if err != nil {
+		return err
+	}
+
+	blobstore, err := f.blobstoreFactory.Create(installationManifest.Mbus, bihttpclient.CreateDefaultClientInsecureSkipVerify())
+	if err != nil {
+		return bosherr.WrapError(err, "Creating blobstore client")
+	}
+
+	f.logger.Debug(f.logTag, "Downloading logs blob '%s' from the agent", response.Value.BlobstoreID)
+
+	localBlob, err := blobstore.Get(response.Value.BlobstoreID)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Downloading logs blob '%s'", response.Value.BlobstoreID)
+	}
+
+	defer localBlob.DeleteSilently()
+
+	if response.Value.SHA1 != "" {
+		digest, err := boshcrypto.ParseMultipleDigest(response.Value.SHA1)
+		if err != nil {
+			return bosherr.WrapError(err, "Parsing logs digest")
+		}
+
+		err = digest.VerifyFilePath(localBlob.Path(), f.fs)
+		if err != nil {
+			return bosherr.WrapError(err, "Verifying logs")
+		}
+	}
+
+	err = boshfu.NewFileMover(f.fs).Move(localBlob.Path(), dstPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Moving to final destination")
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd_test
+
+import (
+	"errors"
+	"path/filepath"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("EnvLogsCmd", func() {
+	var (
+		mockCtrl           *gomock.Controller
+		mockEnvLogsFetcher *mock_cmd.MockEnvLogsFetcher
+		ui                 *fakeui.FakeUI
+		opts               EnvLogsOpts
+		command            EnvLogsCmd
+
+		dstDir = filepath.Join("/", "fake-dir")
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockEnvLogsFetcher = mock_cmd.NewMockEnvLogsFetcher(mockCtrl)
+		ui = &fakeui.FakeUI{}
+
+		timeService := fakeclock.NewFakeClock(time.Date(2009, time.November, 10, 23, 1, 2, 333, time.UTC))
+
+		envProvider := func(manifestPath, statePath string, vars boshtpl.Variables, op patch.Op) EnvLogsFetcher {
+			Expect(manifestPath).To(Equal("/fake-dir/bosh.yml"))
+			Expect(statePath).To(Equal("/fake-state.json"))
+			return mockEnvLogsFetcher
+		}
+
+		command = NewEnvLogsCmd(ui, timeService, envProvider)
+
+		opts = EnvLogsOpts{
+			Args:      EnvLogsArgs{Manifest: FileBytesWithPathArg{Path: "/fake-dir/bosh.yml"}},
+			StatePath: "/fake-state.json",
+			Directory: DirOrCWDArg{Path: dstDir},
+		}
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("downloads all job logs to the directory, named after the manifest", func() {
+		dstPath := filepath.Join(dstDir, "bosh.job-logs-20091110-230102-000000333.tgz")
+
+		mockEnvLogsFetcher.EXPECT().FetchLogs("job", []string{}, dstPath).Return(nil)
+
+		Expect(command.Run(opts)).ToNot(HaveOccurred())
+		Expect(ui.Said).To(Equal([]string{"Downloading job logs to '" + dstPath + "'..."}))
+	})
+
+	It("limits the logs to the given jobs and filters", func() {
+		opts.Jobs = []string{"director", "nats"}
+		opts.Filters = []string{"health_monitor/*.log"}
+
+		mockEnvLogsFetcher.EXPECT().FetchLogs(
+			"job", []string{"director/**/*", "nats/**/*", "health_monitor/*.log"}, gomock.Any()).Return(nil)
+
+		Expect(command.Run(opts)).ToNot(HaveOccurred())
+	})
+
+	It("downloads the agent logs with --agent", func() {
+		opts.Agent = true
+
+		dstPath := filepath.Join(dstDir, "bosh.agent-logs-20091110-230102-000000333.tgz")
+
+		mockEnvLogsFetcher.EXPECT().FetchLogs("agent", []string{}, dstPath).Return(nil)
+
+		Expect(command.Run(opts)).ToNot(HaveOccurred())
+	})
+
+	It("does not combine --agent with --job", func() {
+		opts.Agent = true
+		opts.Jobs = []string{"director"}
+
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Expected either --agent or --job"))
+	})
+
+	It("returns an error when fetching the logs fails", func() {
+		mockEnvLogsFetcher.EXPECT().FetchLogs("job", []string{}, gomock.Any()).Return(errors.New("fake-fetch-err"))
+
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-fetch-err"))
+	})
+})
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// StdinManifestsPath is where manifests read from stdin are persisted so that
+// state files and relative lookups have a stable location across runs. Each
+// deployment gets its own directory so that their state files (and the
+// credentials in them) never mix.
+const StdinManifestsPath = "~/.bosh/manifests"
+
+const (
+	stdinManifestFileName     = "manifest.yml"
+	stdinManifestFallbackName = "stdin"
+)
+
+var unsafeDeploymentNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// EnvManifestArg is the deployment manifest of create-env and delete-env.
+// Unlike other file arguments it also accepts '-' to read the manifest from
+// stdin.
+type EnvManifestArg struct {
+	FileBytesWithPathArg
+
+	// Stdin is read when the path is '-', os.Stdin unless set
+	Stdin io.Reader
+}
+
+func (a *EnvManifestArg) UnmarshalFlag(data string) error {
+	if data == "-" {
+		return a.unmarshalStdin()
+	}
+
+	return a.FileBytesWithPathArg.UnmarshalFlag(data)
+}
+
+func (a *EnvManifestArg) unmarshalStdin() error {
+	stdin := a.Stdin
+	if stdin == nil {
+		stdin = os.Stdin
+	}
+
+	bytes, err := ioutil.ReadAll(stdin)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading from stdin")
+	}
+
+	manifestsPath, err := a.FS.ExpandPath(StdinManifestsPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Getting absolute path '%s'", StdinManifestsPath)
+	}
+
+	absPath := filepath.Join(manifestsPath, stdinDeploymentName(bytes), stdinManifestFileName)
+
+	err = a.FS.MkdirAll(filepath.Dir(absPath), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating directory for '%s'", absPath)
+	}
+
+	err = a.FS.WriteFile(absPath, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Persisting manifest from stdin to '%s'", absPath)
+	}
+
+	(*a).Bytes = bytes
+	(*a).Path = absPath
+
+	return nil
+}
+
+func stdinDeploymentName(bytes []byte) string {
+	var manifest struct {
+		Name string `yaml:"name"`
+	}
+
+	err := yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return stdinManifestFallbackName
+	}
+
+	name := unsafeDeploymentNameChars.ReplaceAllString(manifest.Name, "-")
+	if name == "" || name == "." || name == ".." {
+		return stdinManifestFallbackName
+	}
+
+	return name
+}
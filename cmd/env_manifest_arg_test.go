@@ -0,0 +1,85 @@
+package cmd_test
+
+import (
+	"errors"
+	"strings"
+	"testing/iotest"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+)
+
+var _ = Describe("EnvManifestArg", func() {
+	Describe("UnmarshalFlag", func() {
+		var (
+			fs  *fakesys.FakeFileSystem
+			arg EnvManifestArg
+		)
+
+		BeforeEach(func() {
+			fs = fakesys.NewFakeFileSystem()
+			arg = EnvManifestArg{FileBytesWithPathArg: FileBytesWithPathArg{FS: fs}}
+		})
+
+		Context("when dash is given as path", func() {
+			writeStdin := func(content string) {
+				arg.Stdin = strings.NewReader(content)
+			}
+
+			BeforeEach(func() {
+				fs.ExpandPathExpanded = "/home/user/.bosh/manifests"
+			})
+
+			It("reads bytes from stdin and persists them in the workspace", func() {
+				writeStdin("content")
+
+				err := (&arg).UnmarshalFlag("-")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fs.ExpandPathPath).To(Equal("~/.bosh/manifests"))
+				Expect(arg.Path).To(Equal("/home/user/.bosh/manifests/stdin/manifest.yml"))
+				Expect(arg.Bytes).To(Equal([]byte("content")))
+
+				Expect(fs.ReadFileString("/home/user/.bosh/manifests/stdin/manifest.yml")).To(Equal("content"))
+			})
+
+			It("persists each deployment in its own directory", func() {
+				writeStdin("name: fake/deployment name")
+
+				err := (&arg).UnmarshalFlag("-")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(arg.Path).To(Equal("/home/user/.bosh/manifests/fake-deployment-name/manifest.yml"))
+			})
+
+			It("returns error if reading from stdin fails", func() {
+				arg.Stdin = iotest.ErrReader(errors.New("fake-read-err"))
+
+				err := (&arg).UnmarshalFlag("-")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Reading from stdin"))
+				Expect(err.Error()).To(ContainSubstring("fake-read-err"))
+			})
+
+			It("returns error if persisting the manifest fails", func() {
+				writeStdin("content")
+				fs.WriteFileError = errors.New("fake-err")
+
+				err := (&arg).UnmarshalFlag("-")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Persisting manifest from stdin"))
+				Expect(err.Error()).To(ContainSubstring("fake-err"))
+			})
+		})
+
+		It("sets path and bytes of a manifest file", func() {
+			fs.WriteFileString("/some/path", "content")
+
+			err := (&arg).UnmarshalFlag("/some/path")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(arg.Path).To(Equal("/some/path"))
+			Expect(arg.Bytes).To(Equal([]byte("content")))
+		})
+	})
+})
@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"github.com/cppforlife/go-patch/patch"
+	"gopkg.in/yaml.v2"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// EnvOutputsStdout writes the outputs to stdout instead of a file
+const EnvOutputsStdout = "-"
+
+// envOutputs interpolates the outputs section of an environment manifest.
+// It is interpolated after deploying, so that it can refer to variables
+// generated into the vars store, e.g. ((director_ssl.ca)).
+func envOutputs(manifestBytes []byte, vars boshtpl.Variables, op patch.Op) (biproperty.Map, error) {
+	bytes, err := boshtpl.NewTemplate(manifestBytes).Evaluate(vars, op, boshtpl.EvaluateOpts{ExpectAllKeys: true})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Evaluating manifest outputs")
+	}
+
+	var manifest struct {
+		Outputs map[interface{}]interface{}
+	}
+
+	err = yaml.Unmarshal(bytes, &manifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling manifest outputs")
+	}
+
+	outputs, err := biproperty.BuildMap(manifest.Outputs)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Parsing manifest outputs")
+	}
+
+	return outputs, nil
+}
+
+// writeEnvOutputs writes the outputs as a JSON object to path, readable only
+// by the user since outputs usually contain credentials
+func writeEnvOutputs(ui boshui.UI, fs boshsys.FileSystem, path string, outputs biproperty.Map) error {
+	bytes, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling outputs")
+	}
+
+	bytes = append(bytes, '\n')
+
+	if path == EnvOutputsStdout {
+		ui.PrintBlock(bytes)
+		return nil
+	}
+
+	err = fs.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating outputs '%s' directory", path)
+	}
+
+	file, err := fs.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(0600))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening outputs '%s'", path)
+	}
+
+	defer file.Close()
+
+	// an existing file keeps its permissions when opened, narrow them before
+	// writing the outputs to it
+	err = fs.Chmod(path, os.FileMode(0600))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting outputs '%s' permissions", path)
+	}
+
+	_, err = file.Write(bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing outputs '%s'", path)
+	}
+
+	return nil
+}
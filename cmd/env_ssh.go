@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+
+	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type EnvSSHCmd struct {
+	envProvider     func(string, string, boshtpl.Variables, patch.Op) AgentActionSender
+	intSSHRunner    boshssh.Runner
+	nonIntSSHRunner boshssh.Runner
+	ui              boshui.UI
+}
+
+type envSSHResponse struct {
+	Value struct {
+		Status        string
+		IP            string
+		HostPublicKey string `json:"host_public_key"`
+	}
+	Exception *struct {
+		Message string
+	}
+}
+
+func NewEnvSSHCmd(
+	envProvider func(string, string, boshtpl.Variables, patch.Op) AgentActionSender,
+	intSSHRunner boshssh.Runner,
+	nonIntSSHRunner boshssh.Runner,
+	ui boshui.UI,
+) EnvSSHCmd {
+	return EnvSSHCmd{
+		envProvider:     envProvider,
+		intSSHRunner:    intSSHRunner,
+		nonIntSSHRunner: nonIntSSHRunner,
+		ui:              ui,
+	}
+}
+
+func (c EnvSSHCmd) Run(opts EnvSSHOpts) error {
+	if !c.ui.IsInteractive() && len(opts.Command) == 0 {
+		return bosherr.Errorf("Non-interactive SSH requires non-empty command")
+	}
+
+	sshOpts, connOpts, err := opts.GatewayFlags.AsSSHOpts()
+	if err != nil {
+		return err
+	}
+
+	connOpts.RawOpts = opts.RawOpts.AsStrings()
+
+	sender := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	response, err := c.send(sender, "setup", map[string]interface{}{
+		"user":       sshOpts.Username,
+		"public_key": sshOpts.PublicKey,
+		"password":   "",
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Setting up SSH user")
+	}
+
+	defer func() {
+		_, _ = c.send(sender, "cleanup", map[string]interface{}{
+			"user_regex": "^" + sshOpts.Username + "$",
+		})
+	}()
+
+	if response.Value.IP == "" {
+		return bosherr.Error("Expected the agent to report the VM address")
+	}
+
+	result := boshdir.SSHResult{
+		Hosts: []boshdir.Host{{
+			Job:           "env",
+			IndexOrID:     "0",
+			Username:      sshOpts.Username,
+			Host:          response.Value.IP,
+			HostPublicKey: response.Value.HostPublicKey,
+		}},
+	}
+
+	var runner boshssh.Runner
+
+	if !c.ui.IsInteractive() || len(opts.Command) > 0 {
+		runner = c.nonIntSSHRunner
+	} else {
+		runner = c.intSSHRunner
+	}
+
+	err = runner.Run(connOpts, result, opts.Command)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Running SSH")
+	}
+
+	return nil
+}
+
+// send runs an agent 'ssh' action, which creates the user with 'setup'
+// and removes the users matching 'user_regex' with 'cleanup'
+func (c EnvSSHCmd) send(sender AgentActionSender, command string, params map[string]interface{}) (envSSHResponse, error) {
+	var response envSSHResponse
+
+	responseBody, err := sender.Send("ssh", []interface{}{command, params})
+	if err != nil {
+		return response, err
+	}
+
+	err = json.Unmarshal(responseBody, &response)
+	if err != nil {
+		return response, bosherr.WrapErrorf(err, "Unmarshalling agent response to 'ssh %s'", command)
+	}
+
+	if response.Exception != nil {
+		return response, bosherr.Errorf("Agent responded with error: %s", response.Exception.Message)
+	}
+
+	if response.Value.Status != "success" {
+		return response, bosherr.Errorf("Agent responded to 'ssh %s' with status '%s'", command, response.Value.Status)
+	}
+
+	return response, nil
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// EnvStatusCmd shows what the deployment state records as deployed
+// without calling the CPI or the agent
+type EnvStatusCmd struct {
+	ui          boshui.UI
+	envProvider func(string, string, boshtpl.Variables, patch.Op) biconfig.DeploymentStateService
+}
+
+func NewEnvStatusCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) biconfig.DeploymentStateService) *EnvStatusCmd {
+	return &EnvStatusCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *EnvStatusCmd) Run(opts EnvStatusOpts) error {
+	stateService := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	if !stateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' does not exist", stateService.Path())
+	}
+
+	state, err := stateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading deployment state")
+	}
+
+	releases := []string{}
+	for _, id := range state.CurrentReleaseIDs {
+		for _, release := range state.Releases {
+			if release.ID == id {
+				releases = append(releases, fmt.Sprintf("%s/%s", release.Name, release.Version))
+			}
+		}
+	}
+
+	stemcell := ""
+	for _, record := range state.Stemcells {
+		if record.ID == state.CurrentStemcellID {
+			stemcell = fmt.Sprintf("%s/%s (%s)", record.Name, record.Version, record.CID)
+		}
+	}
+
+	disks := []string{}
+	for _, record := range state.Disks {
+		if record.ID == state.CurrentDiskID {
+			disks = append(disks, fmt.Sprintf("%s (current)", record.CID))
+		} else {
+			disks = append(disks, record.CID)
+		}
+	}
+
+	table := boshtbl.Table{
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Manifest"),
+			boshtbl.NewHeader("State"),
+			boshtbl.NewHeader("Director ID"),
+			boshtbl.NewHeader("Installation ID"),
+			boshtbl.NewHeader("Releases"),
+			boshtbl.NewHeader("Stemcell"),
+			boshtbl.NewHeader("VM CID"),
+			boshtbl.NewHeader("Disk CIDs"),
+		},
+		Rows: [][]boshtbl.Value{
+			{
+				boshtbl.NewValueString(opts.Args.Manifest.Path),
+				boshtbl.NewValueString(stateService.Path()),
+				boshtbl.NewValueString(state.DirectorID),
+				boshtbl.NewValueString(state.InstallationID),
+				boshtbl.NewValueStrings(releases),
+				boshtbl.NewValueString(stemcell),
+				boshtbl.NewValueString(state.CurrentVMCID),
+				boshtbl.NewValueStrings(disks),
+			},
+		},
+		Transpose: true,
+	}
+
+	if len(state.CurrentCPI) > 0 {
+		table = table.AddColumn("CPI", []boshtbl.Value{
+			boshtbl.NewValueString(state.CurrentCPI),
+		})
+	}
+
+	if len(state.OrphanedDisks) > 0 {
+		orphanedDisks := []string{}
+		for _, record := range state.OrphanedDisks {
+			orphanedDisks = append(orphanedDisks, record.CID)
+		}
+
+		table = table.AddColumn("Orphaned Disk CIDs", []boshtbl.Value{
+			boshtbl.NewValueStrings(orphanedDisks),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	if opts.Verbose {
+		c.ui.PrintTable(c.transitionsTable(state.InstanceTransitions))
+	}
+
+	return nil
+}
+
+// transitionsTable shows how long each instance spent in each state
+// until it entered the next one
+func (c *EnvStatusCmd) transitionsTable(records []biconfig.InstanceTransitionRecord) boshtbl.Table {
+	table := boshtbl.Table{
+		Title:   "Instance state transitions",
+		Content: "transitions",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Instance"),
+			boshtbl.NewHeader("Transition"),
+			boshtbl.NewHeader("At"),
+			boshtbl.NewHeader("Time in State"),
+		},
+	}
+
+	for i, record := range records {
+		timeInState := ""
+		for _, next := range records[i+1:] {
+			if next.Instance == record.Instance {
+				timeInState = next.At.Sub(record.At).String()
+				break
+			}
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(record.Instance),
+			boshtbl.NewValueString(fmt.Sprintf("%s -> %s", record.FromState, record.State)),
+			boshtbl.NewValueTime(record.At),
+			boshtbl.NewValueString(timeInState),
+		})
+	}
+
+	return table
+}
@@ -0,0 +1,165 @@
+package cmd_test
+
+import (
+	"time"
+
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicmd "github.com/cloudfoundry/bosh-cli/cmd"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("EnvStatusCmd", func() {
+	var (
+		fs           *fakesys.FakeFileSystem
+		stateService biconfig.DeploymentStateService
+		fakeUI       *fakeui.FakeUI
+		opts         bicmd.EnvStatusOpts
+		command      *bicmd.EnvStatusCmd
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		stateService = biconfig.NewFileSystemDeploymentStateService(
+			fs, fakeuuid.NewFakeGenerator(), boshlog.NewLogger(boshlog.LevelNone), "/fake-state.json")
+		fakeUI = &fakeui.FakeUI{}
+
+		envProvider := func(manifestPath, statePath string, vars boshtpl.Variables, op patch.Op) biconfig.DeploymentStateService {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			Expect(statePath).To(Equal("/fake-state.json"))
+			return stateService
+		}
+
+		command = bicmd.NewEnvStatusCmd(fakeUI, envProvider)
+
+		opts = bicmd.EnvStatusOpts{
+			Args:      bicmd.EnvStatusArgs{Manifest: bicmd.FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+			StatePath: "/fake-state.json",
+		}
+	})
+
+	It("prints the deployed releases, stemcell, VM and disks", func() {
+		err := stateService.Save(biconfig.DeploymentState{
+			DirectorID:        "fake-director-id",
+			InstallationID:    "fake-installation-id",
+			CurrentVMCID:      "fake-vm-cid",
+			CurrentStemcellID: "fake-stemcell-id",
+			CurrentDiskID:     "fake-disk-id",
+			CurrentReleaseIDs: []string{"fake-cpi-release-id"},
+			Releases: []biconfig.ReleaseRecord{
+				{ID: "fake-cpi-release-id", Name: "fake-cpi", Version: "1"},
+				{ID: "fake-old-release-id", Name: "fake-cpi", Version: "0"},
+			},
+			Stemcells: []biconfig.StemcellRecord{
+				{ID: "fake-stemcell-id", Name: "fake-stemcell", Version: "3468", CID: "fake-stemcell-cid"},
+			},
+			Disks: []biconfig.DiskRecord{
+				{ID: "fake-disk-id", CID: "fake-disk-cid"},
+				{ID: "fake-old-disk-id", CID: "fake-old-disk-cid"},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = command.Run(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Table).To(Equal(boshtbl.Table{
+			Header: []boshtbl.Header{
+				boshtbl.NewHeader("Manifest"),
+				boshtbl.NewHeader("State"),
+				boshtbl.NewHeader("Director ID"),
+				boshtbl.NewHeader("Installation ID"),
+				boshtbl.NewHeader("Releases"),
+				boshtbl.NewHeader("Stemcell"),
+				boshtbl.NewHeader("VM CID"),
+				boshtbl.NewHeader("Disk CIDs"),
+			},
+			Rows: [][]boshtbl.Value{
+				{
+					boshtbl.NewValueString("/fake-manifest.yml"),
+					boshtbl.NewValueString("/fake-state.json"),
+					boshtbl.NewValueString("fake-director-id"),
+					boshtbl.NewValueString("fake-installation-id"),
+					boshtbl.NewValueStrings([]string{"fake-cpi/1"}),
+					boshtbl.NewValueString("fake-stemcell/3468 (fake-stemcell-cid)"),
+					boshtbl.NewValueString("fake-vm-cid"),
+					boshtbl.NewValueStrings([]string{"fake-disk-cid (current)", "fake-old-disk-cid"}),
+				},
+			},
+			Transpose: true,
+		}))
+	})
+
+	It("adds the CPI and orphaned disks when they are recorded", func() {
+		err := stateService.Save(biconfig.DeploymentState{
+			DirectorID: "fake-director-id",
+			CurrentCPI: "fake-cpi-name",
+			OrphanedDisks: []biconfig.OrphanedDiskRecord{
+				{DiskRecord: biconfig.DiskRecord{CID: "fake-orphaned-disk-cid"}},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = command.Run(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Table.Header).To(ContainElement(boshtbl.NewHeader("CPI")))
+		Expect(fakeUI.Table.Header).To(ContainElement(boshtbl.NewHeader("Orphaned Disk CIDs")))
+		Expect(fakeUI.Table.Rows[0]).To(ContainElement(boshtbl.NewValueString("fake-cpi-name")))
+		Expect(fakeUI.Table.Rows[0]).To(ContainElement(boshtbl.NewValueStrings([]string{"fake-orphaned-disk-cid"})))
+	})
+
+	It("prints the instance state transitions with --verbose", func() {
+		startedAt := time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+		err := stateService.Save(biconfig.DeploymentState{
+			DirectorID: "fake-director-id",
+			InstanceTransitions: []biconfig.InstanceTransitionRecord{
+				{Instance: "bosh/0", FromState: "unstarted", State: "stemcell_uploaded", At: startedAt},
+				{Instance: "bosh/0", FromState: "stemcell_uploaded", State: "vm_created", At: startedAt.Add(4 * time.Minute)},
+			},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		opts.Verbose = true
+
+		err = command.Run(opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Tables).To(HaveLen(2))
+		Expect(fakeUI.Tables[1].Header).To(Equal([]boshtbl.Header{
+			boshtbl.NewHeader("Instance"),
+			boshtbl.NewHeader("Transition"),
+			boshtbl.NewHeader("At"),
+			boshtbl.NewHeader("Time in State"),
+		}))
+		Expect(fakeUI.Tables[1].Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("bosh/0"),
+				boshtbl.NewValueString("unstarted -> stemcell_uploaded"),
+				boshtbl.NewValueTime(startedAt),
+				boshtbl.NewValueString("4m0s"),
+			},
+			{
+				boshtbl.NewValueString("bosh/0"),
+				boshtbl.NewValueString("stemcell_uploaded -> vm_created"),
+				boshtbl.NewValueTime(startedAt.Add(4 * time.Minute)),
+				boshtbl.NewValueString(""),
+			},
+		}))
+	})
+
+	It("returns an error when there is no deployment state", func() {
+		err := command.Run(opts)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Deployment state '/fake-state.json' does not exist"))
+		Expect(fakeUI.Tables).To(BeEmpty())
+	})
+})
@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/cppforlife/go-patch/patch"
+	"gopkg.in/yaml.v2"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// envStemcellsFile is written by --export and read by --import. Record IDs
+// are left out since they only identify records within one state file.
+type envStemcellsFile struct {
+	Stemcells []envStemcellsFileStemcell `yaml:"stemcells"`
+}
+
+type envStemcellsFileStemcell struct {
+	Name    string `yaml:"name"`
+	Version string `yaml:"version"`
+	CID     string `yaml:"cid"`
+}
+
+type EnvStemcellsCmd struct {
+	ui           boshui.UI
+	envProvider  func(string, string, boshtpl.Variables, patch.Op) EnvStemcellsManager
+	confirmation DestructiveConfirmation
+}
+
+func NewEnvStemcellsCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) EnvStemcellsManager, confirmation DestructiveConfirmation) *EnvStemcellsCmd {
+	return &EnvStemcellsCmd{ui: ui, envProvider: envProvider, confirmation: confirmation}
+}
+
+func (c *EnvStemcellsCmd) Run(stage boshui.Stage, opts EnvStemcellsOpts) error {
+	manager := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	if opts.Prune {
+		err := c.confirmation.ConfirmDeletion(DestructivePruneStemcells)
+		if err != nil {
+			return err
+		}
+
+		return manager.DeleteUnused(stage)
+	}
+
+	if opts.Export.ExpandedPath != "" {
+		return c.export(manager, opts.Export)
+	}
+
+	if opts.Import.Bytes != nil {
+		return c.importFile(manager, opts.Import.Bytes)
+	}
+
+	stemcells, err := manager.List()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "stemcells",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Version"),
+			boshtbl.NewHeader("CID"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 0, Asc: true},
+			{Column: 1, Asc: false},
+		},
+
+		Notes: []string{"(*) Currently deployed", "(i) Imported, never deleted"},
+	}
+
+	for _, stemcell := range stemcells {
+		mark := ""
+		if stemcell.Current {
+			mark = "*"
+		}
+		if stemcell.Imported {
+			mark += "i"
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(stemcell.Name),
+			boshtbl.NewValueSuffix(boshtbl.NewValueString(stemcell.Version), mark),
+			boshtbl.NewValueString(stemcell.CID),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
+
+func (c *EnvStemcellsCmd) export(manager EnvStemcellsManager, file FileArg) error {
+	stemcells, err := manager.List()
+	if err != nil {
+		return err
+	}
+
+	exported := envStemcellsFile{Stemcells: []envStemcellsFileStemcell{}}
+
+	for _, stemcell := range stemcells {
+		exported.Stemcells = append(exported.Stemcells, envStemcellsFileStemcell{
+			Name:    stemcell.Name,
+			Version: stemcell.Version,
+			CID:     stemcell.CID,
+		})
+	}
+
+	bytes, err := yaml.Marshal(exported)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling stemcell records")
+	}
+
+	err = file.FS.WriteFile(file.ExpandedPath, bytes)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing stemcell records to '%s'", file.ExpandedPath)
+	}
+
+	c.ui.PrintLinef("Exported %d stemcell(s) to '%s'", len(exported.Stemcells), file.ExpandedPath)
+
+	return nil
+}
+
+func (c *EnvStemcellsCmd) importFile(manager EnvStemcellsManager, bytes []byte) error {
+	var imported envStemcellsFile
+
+	err := yaml.Unmarshal(bytes, &imported)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling stemcell records")
+	}
+
+	records := []biconfig.StemcellRecord{}
+
+	for _, stemcell := range imported.Stemcells {
+		if stemcell.Name == "" || stemcell.Version == "" || stemcell.CID == "" {
+			return bosherr.Errorf("Expected stemcell records to have a name, version and cid")
+		}
+
+		records = append(records, biconfig.StemcellRecord{
+			Name:    stemcell.Name,
+			Version: stemcell.Version,
+			CID:     stemcell.CID,
+		})
+	}
+
+	savedRecords, err := manager.Import(records)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range savedRecords {
+		c.ui.PrintLinef("Imported stemcell '%s/%s' with CID '%s'", record.Name, record.Version, record.CID)
+	}
+
+	c.ui.PrintLinef("Imported %d stemcell(s), %d already recorded", len(savedRecords), len(records)-len(savedRecords))
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// EnvStemcell is a stemcell uploaded by create-env, Current when the
+// environment VM uses it
+type EnvStemcell struct {
+	biconfig.StemcellRecord
+	Current bool
+}
+
+type EnvStemcellsManager interface {
+	List() ([]EnvStemcell, error)
+	// DeleteUnused deletes the stemcells the environment VM does not use
+	// from the IaaS and from the deployment state, except imported ones
+	DeleteUnused(stage biui.Stage) error
+	// Import records stemcells another workstation uploaded to the same
+	// IaaS account so that create-env reuses them instead of uploading them
+	// again, stemcells that are already recorded are skipped. Imported
+	// stemcells are never deleted from the IaaS.
+	Import(records []biconfig.StemcellRecord) ([]biconfig.StemcellRecord, error)
+}
+
+func NewEnvStemcellsManager(
+	logTag string,
+	logger boshlog.Logger,
+	timeService clock.Clock,
+	deploymentStateService biconfig.DeploymentStateService,
+	stemcellRepo biconfig.StemcellRepo,
+	stemcellManagerFactory bistemcell.ManagerFactory,
+	releaseManager biinstall.ReleaseManager,
+	cloudFactory bicloud.Factory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	cpiInstaller bicpirel.CpiInstaller,
+	cpiUninstaller biinstall.Uninstaller,
+	releaseFetcher biinstall.ReleaseFetcher,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	tempRootConfigurator TempRootConfigurator,
+	targetProvider biinstall.TargetProvider,
+	attempts *biui.Attempts,
+) EnvStemcellsManager {
+	return &envStemcellsManager{
+		deploymentStateService: deploymentStateService,
+		stemcellRepo:           stemcellRepo,
+		stemcellManagerFactory: stemcellManagerFactory,
+		envCloud: envCloud{
+			logTag:                                  logTag,
+			logger:                                  logger,
+			timeService:                             timeService,
+			deploymentStateService:                  deploymentStateService,
+			releaseManager:                          releaseManager,
+			cloudFactory:                            cloudFactory,
+			deploymentManifestPath:                  deploymentManifestPath,
+			deploymentVars:                          deploymentVars,
+			deploymentOp:                            deploymentOp,
+			cpiInstaller:                            cpiInstaller,
+			cpiUninstaller:                          cpiUninstaller,
+			releaseFetcher:                          releaseFetcher,
+			releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+			tempRootConfigurator:                    tempRootConfigurator,
+			targetProvider:                          targetProvider,
+			attempts:                                attempts,
+		},
+	}
+}
+
+type envStemcellsManager struct {
+	deploymentStateService biconfig.DeploymentStateService
+	stemcellRepo           biconfig.StemcellRepo
+	stemcellManagerFactory bistemcell.ManagerFactory
+	envCloud               envCloud
+}
+
+func (m *envStemcellsManager) List() ([]EnvStemcell, error) {
+	stemcells := []EnvStemcell{}
+
+	if !m.deploymentStateService.Exists() {
+		return stemcells, nil
+	}
+
+	records, err := m.stemcellRepo.All()
+	if err != nil {
+		return stemcells, bosherr.WrapError(err, "Getting all stemcell records")
+	}
+
+	currentRecord, found, err := m.stemcellRepo.FindCurrent()
+	if err != nil {
+		return stemcells, bosherr.WrapError(err, "Finding current stemcell record")
+	}
+
+	for _, record := range records {
+		stemcells = append(stemcells, EnvStemcell{
+			StemcellRecord: record,
+			Current:        found && record.ID == currentRecord.ID,
+		})
+	}
+
+	return stemcells, nil
+}
+
+func (m *envStemcellsManager) DeleteUnused(stage biui.Stage) error {
+	if !m.deploymentStateService.Exists() {
+		return bosherr.Errorf("Deployment state '%s' does not exist", m.deploymentStateService.Path())
+	}
+
+	records, err := m.stemcellRepo.AllForCurrentCPI()
+	if err != nil {
+		return bosherr.WrapError(err, "Getting all stemcell records")
+	}
+
+	currentRecord, found, err := m.stemcellRepo.FindCurrent()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding current stemcell record")
+	}
+
+	unused := 0
+	for _, record := range records {
+		if !(found && record.ID == currentRecord.ID) && !record.Imported {
+			unused++
+		}
+	}
+
+	// the CPI is only installed when there is something to delete
+	if unused == 0 {
+		return nil
+	}
+
+	return m.envCloud.Run(stage, func(cloud bicloud.Cloud) error {
+		return m.stemcellManagerFactory.NewManager(cloud).DeleteUnused(stage)
+	})
+}
+
+func (m *envStemcellsManager) Import(records []biconfig.StemcellRecord) ([]biconfig.StemcellRecord, error) {
+	imported := []biconfig.StemcellRecord{}
+	toImport := []biconfig.StemcellRecord{}
+
+	// conflicts are checked before saving so that nothing is imported
+	// from a file that does not match the environment
+	for _, record := range records {
+		existingRecord, found, err := m.stemcellRepo.Find(record.Name, record.Version)
+		if err != nil {
+			return imported, bosherr.WrapErrorf(err, "Finding stemcell record '%s/%s'", record.Name, record.Version)
+		}
+
+		if !found {
+			toImport = append(toImport, record)
+			continue
+		}
+
+		if existingRecord.CID != record.CID {
+			return imported, bosherr.Errorf("Stemcell '%s/%s' is already recorded with CID '%s' instead of '%s'",
+				record.Name, record.Version, existingRecord.CID, record.CID)
+		}
+	}
+
+	for _, record := range toImport {
+		savedRecord, err := m.stemcellRepo.SaveImported(record.Name, record.Version, record.CID)
+		if err != nil {
+			return imported, bosherr.WrapErrorf(err, "Saving stemcell record '%s/%s'", record.Name, record.Version)
+		}
+
+		imported = append(imported, savedRecord)
+	}
+
+	return imported, nil
+}
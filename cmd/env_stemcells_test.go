@@ -0,0 +1,201 @@
+package cmd_test
+
+import (
+	"errors"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("EnvStemcellsCmd", func() {
+	var (
+		mockCtrl                *gomock.Controller
+		mockEnvStemcellsManager *mock_cmd.MockEnvStemcellsManager
+		fakeUI                  *fakeui.FakeUI
+		fakeStage               *fakeui.FakeStage
+		opts                    EnvStemcellsOpts
+		confirmationPolicy      cmdconf.ConfirmationPolicy
+		command                 *EnvStemcellsCmd
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockEnvStemcellsManager = mock_cmd.NewMockEnvStemcellsManager(mockCtrl)
+		fakeUI = &fakeui.FakeUI{}
+		fakeStage = fakeui.NewFakeStage()
+		confirmationPolicy = cmdconf.ConfirmationPolicy{}
+
+		opts = EnvStemcellsOpts{
+			Args:      EnvStemcellsArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+			StatePath: "/fake-state.json",
+		}
+
+		envProvider := func(manifestPath, statePath string, vars boshtpl.Variables, op patch.Op) EnvStemcellsManager {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			Expect(statePath).To(Equal("/fake-state.json"))
+			return mockEnvStemcellsManager
+		}
+
+		command = NewEnvStemcellsCmd(fakeUI, envProvider, NewDestructiveConfirmation(fakeUI, confirmationPolicy))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("lists stemcells and marks the one currently deployed", func() {
+		mockEnvStemcellsManager.EXPECT().List().Return([]EnvStemcell{
+			{StemcellRecord: biconfig.StemcellRecord{Name: "fake-name", Version: "1", CID: "fake-cid-1"}},
+			{StemcellRecord: biconfig.StemcellRecord{Name: "fake-name", Version: "2", CID: "fake-cid-2"}, Current: true},
+		}, nil)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Table.Content).To(Equal("stemcells"))
+		Expect(fakeUI.Table.Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("fake-name"),
+				boshtbl.NewValueSuffix(boshtbl.NewValueString("1"), ""),
+				boshtbl.NewValueString("fake-cid-1"),
+			},
+			{
+				boshtbl.NewValueString("fake-name"),
+				boshtbl.NewValueSuffix(boshtbl.NewValueString("2"), "*"),
+				boshtbl.NewValueString("fake-cid-2"),
+			},
+		}))
+	})
+
+	It("marks imported stemcells", func() {
+		mockEnvStemcellsManager.EXPECT().List().Return([]EnvStemcell{
+			{StemcellRecord: biconfig.StemcellRecord{Name: "fake-name", Version: "1", CID: "fake-cid-1", Imported: true}, Current: true},
+		}, nil)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Table.Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("fake-name"),
+				boshtbl.NewValueSuffix(boshtbl.NewValueString("1"), "*i"),
+				boshtbl.NewValueString("fake-cid-1"),
+			},
+		}))
+		Expect(fakeUI.Table.Notes).To(ContainElement("(i) Imported, never deleted"))
+	})
+
+	It("returns an error if listing fails", func() {
+		mockEnvStemcellsManager.EXPECT().List().Return(nil, errors.New("fake-err"))
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(MatchError("fake-err"))
+	})
+
+	It("deletes the unused stemcells", func() {
+		opts.Prune = true
+		mockEnvStemcellsManager.EXPECT().DeleteUnused(fakeStage).Return(errors.New("fake-err"))
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(MatchError("fake-err"))
+	})
+
+	It("asks for confirmation before deleting the unused stemcells", func() {
+		opts.Prune = true
+		fakeUI.AskedConfirmationErr = errors.New("fake-stopped")
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(MatchError("fake-stopped"))
+		Expect(fakeUI.AskedConfirmationCalled).To(BeTrue())
+	})
+
+	Describe("--export", func() {
+		It("writes the stemcell records without their IDs", func() {
+			fakeFS := fakesys.NewFakeFileSystem()
+			opts.Export = FileArg{FS: fakeFS, ExpandedPath: "/stemcells.yml"}
+
+			mockEnvStemcellsManager.EXPECT().List().Return([]EnvStemcell{
+				{StemcellRecord: biconfig.StemcellRecord{ID: "fake-id", Name: "fake-name", Version: "1", CID: "fake-cid-1"}, Current: true},
+			}, nil)
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := fakeFS.ReadFileString("/stemcells.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(contents).To(Equal("stemcells:\n- name: fake-name\n  version: \"1\"\n  cid: fake-cid-1\n"))
+
+			Expect(fakeUI.Said).To(Equal([]string{"Exported 1 stemcell(s) to '/stemcells.yml'"}))
+		})
+	})
+
+	Describe("--import", func() {
+		It("imports the stemcell records", func() {
+			opts.Import = FileBytesArg{Bytes: []byte("stemcells:\n- name: fake-name\n  version: \"1\"\n  cid: fake-cid-1\n- name: fake-name\n  version: \"2\"\n  cid: fake-cid-2\n")}
+
+			mockEnvStemcellsManager.EXPECT().Import([]biconfig.StemcellRecord{
+				{Name: "fake-name", Version: "1", CID: "fake-cid-1"},
+				{Name: "fake-name", Version: "2", CID: "fake-cid-2"},
+			}).Return([]biconfig.StemcellRecord{{ID: "fake-id", Name: "fake-name", Version: "2", CID: "fake-cid-2"}}, nil)
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeUI.Said).To(Equal([]string{
+				"Imported stemcell 'fake-name/2' with CID 'fake-cid-2'",
+				"Imported 1 stemcell(s), 1 already recorded",
+			}))
+		})
+
+		It("returns an error for incomplete records", func() {
+			opts.Import = FileBytesArg{Bytes: []byte("stemcells:\n- name: fake-name\n  version: \"1\"\n")}
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(MatchError("Expected stemcell records to have a name, version and cid"))
+		})
+
+		It("returns an error if importing fails", func() {
+			opts.Import = FileBytesArg{Bytes: []byte("stemcells: []\n")}
+
+			mockEnvStemcellsManager.EXPECT().Import([]biconfig.StemcellRecord{}).Return(nil, errors.New("fake-err"))
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(MatchError("fake-err"))
+		})
+	})
+
+	Context("when the confirmation policy requires confirming stemcell pruning", func() {
+		BeforeEach(func() {
+			confirmationPolicy = cmdconf.ConfirmationPolicy{Operations: []string{"prune-stemcells"}}
+			command = NewEnvStemcellsCmd(fakeUI, func(string, string, boshtpl.Variables, patch.Op) EnvStemcellsManager {
+				return mockEnvStemcellsManager
+			}, NewDestructiveConfirmation(fakeUI, confirmationPolicy))
+			opts.Prune = true
+		})
+
+		It("does not delete the stemcells when input is non-interactive", func() {
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(MatchError("Confirmation policy requires confirming 'prune-stemcells', but input is non-interactive"))
+		})
+
+		It("deletes the stemcells once confirmed", func() {
+			fakeUI.Interactive = true
+			mockEnvStemcellsManager.EXPECT().DeleteUnused(fakeStage).Return(nil)
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeUI.AskedConfirmationCalled).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+// EnvironmentFilesResolver fills in the files of create-env style commands
+// from an environment saved with alias-create-env when no manifest is given.
+type EnvironmentFilesResolver struct {
+	config cmdconf.Config
+	fs     boshsys.FileSystem
+}
+
+func NewEnvironmentFilesResolver(config cmdconf.Config, fs boshsys.FileSystem) EnvironmentFilesResolver {
+	return EnvironmentFilesResolver{config: config, fs: fs}
+}
+
+// Resolve loads the saved variables and ops files before the ones given as
+// flags so that flags take precedence. A given state path is kept. Without
+// an environment the current create-env environment is used.
+func (r EnvironmentFilesResolver) Resolve(
+	environment string,
+	manifest *FileBytesWithPathArg,
+	varFlags *VarFlags,
+	opsFlags *OpsFlags,
+	statePath *string,
+) error {
+	if manifest.Path != "" {
+		return nil
+	}
+
+	if environment == "" {
+		environment = r.config.CurrentCreateEnvEnvironment()
+	}
+
+	if environment == "" {
+		return bosherr.Error("Expected a manifest path or an environment with create-env files")
+	}
+
+	files, found := r.config.EnvironmentFiles(environment)
+	if !found {
+		return bosherr.Errorf("Expected environment '%s' to have create-env files, save them with 'alias-create-env'", environment)
+	}
+
+	manifestArg := FileBytesWithPathArg{FS: r.fs}
+	err := manifestArg.UnmarshalFlag(files.Manifest)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading manifest of environment '%s'", environment)
+	}
+
+	varsFiles := []boshtpl.VarsFileArg{}
+	for _, path := range files.VarsFiles {
+		varsFile := boshtpl.VarsFileArg{FS: r.fs}
+		err := varsFile.UnmarshalFlag(path)
+		if err != nil {
+			return err
+		}
+		varsFiles = append(varsFiles, varsFile)
+	}
+
+	opsFiles := []OpsFileArg{}
+	for _, path := range files.OpsFiles {
+		opsFile := OpsFileArg{FS: r.fs}
+		err := opsFile.UnmarshalFlag(path)
+		if err != nil {
+			return err
+		}
+		opsFiles = append(opsFiles, opsFile)
+	}
+
+	*manifest = manifestArg
+	varFlags.VarsFiles = append(varsFiles, varFlags.VarsFiles...)
+	opsFlags.OpsFiles = append(opsFiles, opsFlags.OpsFiles...)
+
+	if *statePath == "" {
+		*statePath = files.State
+	}
+
+	return nil
+}
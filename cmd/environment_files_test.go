@@ -0,0 +1,139 @@
+package cmd_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	fakecmdconf "github.com/cloudfoundry/bosh-cli/cmd/config/configfakes"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+)
+
+var _ = Describe("EnvironmentFilesResolver", func() {
+	var (
+		config   *fakecmdconf.FakeConfig
+		fs       *fakesys.FakeFileSystem
+		resolver EnvironmentFilesResolver
+
+		manifest  FileBytesWithPathArg
+		varFlags  VarFlags
+		opsFlags  OpsFlags
+		statePath string
+	)
+
+	BeforeEach(func() {
+		config = &fakecmdconf.FakeConfig{}
+		fs = fakesys.NewFakeFileSystem()
+		resolver = NewEnvironmentFilesResolver(config, fs)
+
+		manifest = FileBytesWithPathArg{}
+		varFlags = VarFlags{}
+		opsFlags = OpsFlags{}
+		statePath = ""
+
+		fs.WriteFileString("/manifest.yml", "name: prod")
+		fs.WriteFileString("/vars.yml", "key: saved")
+		fs.WriteFileString("/ops.yml", "- type: remove\n  path: /name")
+
+		config.EnvironmentFilesReturns(cmdconf.EnvironmentFiles{
+			Manifest:  "/manifest.yml",
+			VarsFiles: []string{"/vars.yml"},
+			OpsFiles:  []string{"/ops.yml"},
+			State:     "/state.json",
+		}, true)
+	})
+
+	act := func(environment string) error {
+		return resolver.Resolve(environment, &manifest, &varFlags, &opsFlags, &statePath)
+	}
+
+	It("leaves options alone when a manifest path is given", func() {
+		manifest = FileBytesWithPathArg{Path: "/other.yml", Bytes: []byte("other")}
+
+		err := act("prod")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(manifest.Path).To(Equal("/other.yml"))
+		Expect(varFlags.VarsFiles).To(BeEmpty())
+		Expect(statePath).To(BeEmpty())
+		Expect(config.EnvironmentFilesCallCount()).To(Equal(0))
+	})
+
+	It("loads saved files for the environment", func() {
+		err := act("prod")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(config.EnvironmentFilesArgsForCall(0)).To(Equal("prod"))
+
+		Expect(manifest.Path).To(Equal("/manifest.yml"))
+		Expect(manifest.Bytes).To(Equal([]byte("name: prod")))
+
+		Expect(varFlags.VarsFiles).To(HaveLen(1))
+		Expect(varFlags.VarsFiles[0].Vars).To(Equal(boshtpl.StaticVariables{"key": "saved"}))
+
+		Expect(opsFlags.OpsFiles).To(HaveLen(1))
+		Expect(statePath).To(Equal("/state.json"))
+	})
+
+	It("puts saved files before given flags so that flags take precedence", func() {
+		given := boshtpl.VarsFileArg{Vars: boshtpl.StaticVariables{"key": "given"}}
+		varFlags.VarsFiles = []boshtpl.VarsFileArg{given}
+		statePath = "/given-state.json"
+
+		err := act("prod")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(varFlags.VarsFiles).To(HaveLen(2))
+		Expect(varFlags.VarsFiles[1]).To(Equal(given))
+		Expect(statePath).To(Equal("/given-state.json"))
+	})
+
+	It("loads saved files for the current create-env environment if no environment is given", func() {
+		config.CurrentCreateEnvEnvironmentReturns("prod")
+
+		err := act("")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.EnvironmentFilesArgsForCall(0)).To(Equal("prod"))
+		Expect(manifest.Path).To(Equal("/manifest.yml"))
+	})
+
+	It("prefers the given environment over the current create-env environment", func() {
+		config.CurrentCreateEnvEnvironmentReturns("staging")
+
+		err := act("prod")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(config.EnvironmentFilesArgsForCall(0)).To(Equal("prod"))
+	})
+
+	It("returns error if no manifest and no environment are given", func() {
+		err := act("")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected a manifest path or an environment with create-env files"))
+	})
+
+	It("returns error if environment has no saved files", func() {
+		config.EnvironmentFilesReturns(cmdconf.EnvironmentFiles{}, false)
+
+		err := act("prod")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Expected environment 'prod' to have create-env files, save them with 'alias-create-env'"))
+	})
+
+	It("returns error if saved manifest cannot be read", func() {
+		fs.RemoveAll("/manifest.yml")
+
+		err := act("prod")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading manifest of environment 'prod'"))
+	})
+
+	It("returns error if saved vars file cannot be read", func() {
+		fs.RemoveAll("/vars.yml")
+
+		err := act("prod")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading variables file '/vars.yml'"))
+	})
+})
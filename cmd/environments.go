@@ -1,21 +1,42 @@
 package cmd
 
 import (
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bistatebackend "github.com/cloudfoundry/bosh-cli/config/statebackend"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
 )
 
 type EnvironmentsCmd struct {
-	config cmdconf.Config
-	ui     boshui.UI
+	config  cmdconf.Config
+	ui      boshui.UI
+	fs      boshsys.FileSystem
+	current string
 }
 
 func NewEnvironmentsCmd(config cmdconf.Config, ui boshui.UI) EnvironmentsCmd {
 	return EnvironmentsCmd{config: config, ui: ui}
 }
 
-func (c EnvironmentsCmd) Run() error {
+// NewEnvironmentsCmdWithCurrent also lists environments with create-env
+// files, marking current, the environment given with --environment, or the
+// current create-env environment from the config when it is empty
+func NewEnvironmentsCmdWithCurrent(config cmdconf.Config, ui boshui.UI, fs boshsys.FileSystem, current string) EnvironmentsCmd {
+	return EnvironmentsCmd{config: config, ui: ui, fs: fs, current: current}
+}
+
+func (c EnvironmentsCmd) Run(opts EnvironmentsOpts) error {
+	if opts.Switch != "" {
+		return c.switchCreateEnv(opts.Switch)
+	}
+
+	if opts.CreateEnv {
+		return c.runCreateEnv()
+	}
+
 	environments := c.config.Environments()
 
 	table := boshtbl.Table{
@@ -38,3 +59,65 @@ func (c EnvironmentsCmd) Run() error {
 
 	return nil
 }
+
+// switchCreateEnv saves alias as the current create-env environment and
+// lists the environments with the switched one marked
+func (c EnvironmentsCmd) switchCreateEnv(alias string) error {
+	updatedConfig, err := c.config.SetCurrentCreateEnvEnvironment(alias)
+	if err != nil {
+		return err
+	}
+
+	err = updatedConfig.Save()
+	if err != nil {
+		return err
+	}
+
+	c.config = updatedConfig
+	c.current = alias
+
+	return c.runCreateEnv()
+}
+
+// runCreateEnv lists the environments saved with alias-create-env. Each of
+// them keeps its own state, and with it its own installation and blobs.
+func (c EnvironmentsCmd) runCreateEnv() error {
+	current := c.current
+	if current == "" {
+		current = c.config.CurrentCreateEnvEnvironment()
+	}
+
+	table := boshtbl.Table{
+		Content: "environments",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Alias"),
+			boshtbl.NewHeader("Current"),
+			boshtbl.NewHeader("Manifest"),
+			boshtbl.NewHeader("State"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, t := range c.config.Environments() {
+		files, found := c.config.EnvironmentFiles(t.Alias)
+		if !found {
+			continue
+		}
+
+		statePath := files.State
+		if !bistatebackend.IsRemote(statePath) {
+			statePath = biconfig.ResolveDeploymentStatePath(c.fs, files.Manifest, files.State)
+		}
+
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(t.Alias),
+			boshtbl.NewValueBool(t.Alias == current),
+			boshtbl.NewValueString(files.Manifest),
+			boshtbl.NewValueString(statePath),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -1,9 +1,13 @@
 package cmd_test
 
 import (
+	"errors"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
 	. "github.com/cloudfoundry/bosh-cli/cmd"
 	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	fakecmdconf "github.com/cloudfoundry/bosh-cli/cmd/config/configfakes"
@@ -25,7 +29,15 @@ var _ = Describe("EnvironmentsCmd", func() {
 	})
 
 	Describe("Run", func() {
-		act := func() error { return command.Run() }
+		var (
+			opts EnvironmentsOpts
+		)
+
+		BeforeEach(func() {
+			opts = EnvironmentsOpts{}
+		})
+
+		act := func() error { return command.Run(opts) }
 
 		It("lists environments", func() {
 			config.EnvironmentsReturns([]cmdconf.Environment{
@@ -58,5 +70,132 @@ var _ = Describe("EnvironmentsCmd", func() {
 				},
 			}))
 		})
+
+		Context("when listing environments with create-env files", func() {
+			BeforeEach(func() {
+				opts.CreateEnv = true
+				command = NewEnvironmentsCmdWithCurrent(config, ui, fakesys.NewFakeFileSystem(), "prod")
+
+				config.EnvironmentsReturns([]cmdconf.Environment{
+					{Alias: "director", URL: "https://10.0.0.6:25555"},
+					{Alias: "prod"},
+					{Alias: "staging"},
+					{Alias: "ci"},
+				})
+
+				config.EnvironmentFilesStub = func(alias string) (cmdconf.EnvironmentFiles, bool) {
+					switch alias {
+					case "prod":
+						return cmdconf.EnvironmentFiles{Manifest: "/envs/prod/bosh.yml"}, true
+					case "staging":
+						return cmdconf.EnvironmentFiles{Manifest: "/envs/staging/bosh.yml", State: "/state/staging.json"}, true
+					case "ci":
+						return cmdconf.EnvironmentFiles{Manifest: "/envs/ci/bosh.yml", State: "s3://bucket/ci.json"}, true
+					default:
+						return cmdconf.EnvironmentFiles{}, false
+					}
+				}
+			})
+
+			It("lists their manifests and state files and marks the current one", func() {
+				err := act()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(ui.Table).To(Equal(boshtbl.Table{
+					Content: "environments",
+
+					Header: []boshtbl.Header{
+						boshtbl.NewHeader("Alias"),
+						boshtbl.NewHeader("Current"),
+						boshtbl.NewHeader("Manifest"),
+						boshtbl.NewHeader("State"),
+					},
+
+					SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+
+					Rows: [][]boshtbl.Value{
+						{
+							boshtbl.NewValueString("prod"),
+							boshtbl.NewValueBool(true),
+							boshtbl.NewValueString("/envs/prod/bosh.yml"),
+							boshtbl.NewValueString("/envs/prod/bosh-state.json"),
+						},
+						{
+							boshtbl.NewValueString("staging"),
+							boshtbl.NewValueBool(false),
+							boshtbl.NewValueString("/envs/staging/bosh.yml"),
+							boshtbl.NewValueString("/state/staging.json"),
+						},
+						{
+							boshtbl.NewValueString("ci"),
+							boshtbl.NewValueBool(false),
+							boshtbl.NewValueString("/envs/ci/bosh.yml"),
+							boshtbl.NewValueString("s3://bucket/ci.json"),
+						},
+					},
+				}))
+			})
+
+			Context("when no environment is given", func() {
+				BeforeEach(func() {
+					command = NewEnvironmentsCmdWithCurrent(config, ui, fakesys.NewFakeFileSystem(), "")
+				})
+
+				It("marks the current create-env environment from the config", func() {
+					config.CurrentCreateEnvEnvironmentReturns("staging")
+
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(ui.Table.Rows[0][1]).To(Equal(boshtbl.NewValueBool(false)))
+					Expect(ui.Table.Rows[1][1]).To(Equal(boshtbl.NewValueBool(true)))
+					Expect(ui.Table.Rows[2][1]).To(Equal(boshtbl.NewValueBool(false)))
+				})
+			})
+
+			Context("when switching the current environment", func() {
+				var updatedConfig *fakecmdconf.FakeConfig
+
+				BeforeEach(func() {
+					opts.CreateEnv = false
+					opts.Switch = "ci"
+
+					updatedConfig = &fakecmdconf.FakeConfig{}
+					updatedConfig.EnvironmentsStub = config.EnvironmentsStub
+					updatedConfig.EnvironmentsReturns(config.Environments())
+					updatedConfig.EnvironmentFilesStub = config.EnvironmentFilesStub
+					config.SetCurrentCreateEnvEnvironmentReturns(updatedConfig, nil)
+				})
+
+				It("saves it as the current one and lists the environments marking it", func() {
+					err := act()
+					Expect(err).ToNot(HaveOccurred())
+
+					Expect(config.SetCurrentCreateEnvEnvironmentArgsForCall(0)).To(Equal("ci"))
+					Expect(updatedConfig.SaveCallCount()).To(Equal(1))
+
+					Expect(ui.Table.Rows[0][1]).To(Equal(boshtbl.NewValueBool(false)))
+					Expect(ui.Table.Rows[2][0]).To(Equal(boshtbl.NewValueString("ci")))
+					Expect(ui.Table.Rows[2][1]).To(Equal(boshtbl.NewValueBool(true)))
+				})
+
+				It("returns error if the environment cannot be made current", func() {
+					config.SetCurrentCreateEnvEnvironmentReturns(nil, errors.New("fake-err"))
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("fake-err"))
+				})
+
+				It("returns error if the config cannot be saved", func() {
+					updatedConfig.SaveReturns(errors.New("fake-save-err"))
+
+					err := act()
+					Expect(err).To(HaveOccurred())
+					Expect(err.Error()).To(Equal("fake-save-err"))
+					Expect(ui.Table.Rows).To(BeEmpty())
+				})
+			})
+		})
 	})
 })
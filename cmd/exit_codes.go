@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	goflags "github.com/jessevdk/go-flags"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	bivalidation "github.com/cloudfoundry/bosh-cli/common/validation"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+// Exit codes let scripts branch on the kind of failure without parsing
+// the error output
+const (
+	ExitCodeFailure      = 1
+	ExitCodeValidation   = 2
+	ExitCodeCPI          = 3
+	ExitCodeAgentTimeout = 4
+	ExitCodeInterrupted  = 130
+)
+
+// ExitCode picks the exit code of a command that failed with err. Wrapped
+// errors are classified by their causes, errors of parallel tasks only when
+// all of them agree.
+func ExitCode(err error) int {
+	switch typedErr := err.(type) {
+	case nil:
+		return ExitCodeFailure
+	case bivalidation.Error:
+		return ExitCodeValidation
+	case *goflags.Error:
+		if typedErr.Type == goflags.ErrHelp {
+			return ExitCodeFailure
+		}
+		return ExitCodeValidation
+	case bivm.AgentTimeoutError:
+		return ExitCodeAgentTimeout
+	case bicloud.Error:
+		return ExitCodeCPI
+	case bosherr.ComplexError:
+		if code := ExitCode(typedErr.Err); code != ExitCodeFailure {
+			return code
+		}
+		return ExitCode(typedErr.Cause)
+	case bosherr.MultiError:
+		return multiErrorExitCode(typedErr.Errors)
+	case biretrier.ExhaustedError:
+		return ExitCode(typedErr.LastErr)
+	default:
+		return ExitCodeFailure
+	}
+}
+
+func multiErrorExitCode(errs []error) int {
+	if len(errs) == 0 {
+		return ExitCodeFailure
+	}
+
+	code := ExitCode(errs[0])
+
+	for _, err := range errs[1:] {
+		if ExitCode(err) != code {
+			return ExitCodeFailure
+		}
+	}
+
+	return code
+}
+
+// HandlesInterrupts is true for commands that stop gracefully on SIGINT and
+// SIGTERM themselves, other commands exit with ExitCodeInterrupted
+func (c Cmd) HandlesInterrupts() bool {
+	switch c.Opts.(type) {
+	case *WatchOpts, *SSHOpts, *EnvSSHOpts, *SCPOpts, *LogsOpts:
+		return true
+	default:
+		return false
+	}
+}
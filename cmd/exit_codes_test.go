@@ -0,0 +1,50 @@
+package cmd_test
+
+import (
+	"errors"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	goflags "github.com/jessevdk/go-flags"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bivalidation "github.com/cloudfoundry/bosh-cli/common/validation"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+var _ = Describe("ExitCode", func() {
+	var (
+		cpiErr = bicloud.NewCPIError("create_vm", bicloud.CmdError{Type: "Bosh::Clouds::CloudError", Message: "fake-message"})
+	)
+
+	It("exits with 1 on errors that are not classified", func() {
+		Expect(ExitCode(errors.New("fake-err"))).To(Equal(ExitCodeFailure))
+		Expect(ExitCode(nil)).To(Equal(ExitCodeFailure))
+	})
+
+	It("exits with 2 on validation errors and invalid arguments", func() {
+		err := bivalidation.WrapError(bosherr.NewMultiError(errors.New("name must be provided")), "Validating deployment manifest")
+		Expect(ExitCode(bosherr.WrapError(err, "Deploying"))).To(Equal(ExitCodeValidation))
+
+		Expect(ExitCode(&goflags.Error{Type: goflags.ErrUnknownFlag})).To(Equal(ExitCodeValidation))
+		Expect(ExitCode(&goflags.Error{Type: goflags.ErrHelp})).To(Equal(ExitCodeFailure))
+	})
+
+	It("exits with 3 on CPI errors, also when retries gave up", func() {
+		Expect(ExitCode(bosherr.WrapError(cpiErr, "Creating vm"))).To(Equal(ExitCodeCPI))
+		Expect(ExitCode(biretrier.ExhaustedError{Attempts: 3, LastErr: cpiErr})).To(Equal(ExitCodeCPI))
+	})
+
+	It("exits with 4 when the agent did not respond in time", func() {
+		err := bivm.AgentTimeoutError{VMCID: "fake-vm-cid", Err: biretrier.ExhaustedError{Attempts: 3}}
+		Expect(ExitCode(bosherr.WrapError(err, "Waiting for the agent"))).To(Equal(ExitCodeAgentTimeout))
+	})
+
+	It("only classifies the errors of parallel tasks when all of them agree", func() {
+		Expect(ExitCode(bosherr.NewMultiError(cpiErr, bosherr.WrapError(cpiErr, "fake-task")))).To(Equal(ExitCodeCPI))
+		Expect(ExitCode(bosherr.NewMultiError(cpiErr, errors.New("fake-err")))).To(Equal(ExitCodeFailure))
+	})
+})
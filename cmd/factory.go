@@ -92,6 +92,7 @@ func (f Factory) New(args []string) (Cmd, error) {
 	boshOpts.SSH.GatewayFlags.UUIDGen = f.deps.UUIDGen
 	boshOpts.SCP.GatewayFlags.UUIDGen = f.deps.UUIDGen
 	boshOpts.Logs.GatewayFlags.UUIDGen = f.deps.UUIDGen
+	boshOpts.EnvSSH.GatewayFlags.UUIDGen = f.deps.UUIDGen
 
 	goflags.FactoryFunc = func(val interface{}) {
 		stype := reflect.Indirect(reflect.ValueOf(val))
@@ -53,6 +53,7 @@ var _ = Describe("Factory", func() {
 			"cloud-check":            []string{},
 			"cloud-config":           []string{},
 			"create-env":             []string{filepath.Join("/", "file")},
+			"env-ssh":                []string{filepath.Join("/", "file")},
 			"sha2ify-release":        []string{filepath.Join("/", "file"), filepath.Join("/", "file2")},
 			"create-release":         []string{filepath.Join("/", "file")},
 			"delete-deployment":      []string{},
@@ -68,6 +69,7 @@ var _ = Describe("Factory", func() {
 			"deployments":            []string{},
 			"disks":                  []string{},
 			"alias-env":              []string{"alias"},
+			"alias-create-env":       []string{"alias", "--manifest", filepath.Join("/", "file")},
 			"environment":            []string{},
 			"environments":           []string{},
 			"errands":                []string{},
@@ -192,6 +194,17 @@ var _ = Describe("Factory", func() {
 			_, _, err = cmd.Opts.(*LogsOpts).GatewayFlags.AsSSHOpts()
 			Expect(err).ToNot(HaveOccurred())
 		})
+
+		It("env-ssh command has configured gateway flags", func() {
+			err := fs.WriteFileString(fakeFilePath, "")
+			Expect(err).ToNot(HaveOccurred())
+
+			cmd, err := factory.New([]string{"env-ssh", fakeFilePath, "--gw-disable"})
+			Expect(err).ToNot(HaveOccurred())
+
+			_, _, err = cmd.Opts.(*EnvSSHOpts).GatewayFlags.AsSSHOpts()
+			Expect(err).ToNot(HaveOccurred())
+		})
 	})
 
 	Describe("deploy command", func() {
@@ -367,6 +380,7 @@ var _ = Describe("Factory", func() {
 			boshOpts.Logs = LogsOpts{}
 			boshOpts.Interpolate = InterpolateOpts{}
 			boshOpts.InitRelease = InitReleaseOpts{}
+			boshOpts.InitEnv = InitEnvOpts{}
 			boshOpts.ResetRelease = ResetReleaseOpts{}
 			boshOpts.GenerateJob = GenerateJobOpts{}
 			boshOpts.GeneratePackage = GeneratePackageOpts{}
@@ -380,6 +394,8 @@ var _ = Describe("Factory", func() {
 			boshOpts.UploadBlobs = UploadBlobsOpts{}
 			boshOpts.SSH = SSHOpts{}
 			boshOpts.SCP = SCPOpts{}
+			boshOpts.EnvSSH = EnvSSHOpts{}
+			boshOpts.EnvLogs = EnvLogsOpts{}
 			boshOpts.Deploy = DeployOpts{}
 			boshOpts.UpdateRuntimeConfig = UpdateRuntimeConfigOpts{}
 			boshOpts.VMs = VMsOpts{}
@@ -389,6 +405,7 @@ var _ = Describe("Factory", func() {
 			boshOpts.UpdateConfig = UpdateConfigOpts{}
 			boshOpts.DeleteConfig = DeleteConfigOpts{}
 			boshOpts.Curl = CurlOpts{}
+			boshOpts.TestCpi = TestCpiOpts{}
 			return boshOpts
 		}
 
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// templateCPIProperties are set by the env template itself and never prompted for
+var templateCPIProperties = []string{"agent", "blobstore", "ntp"}
+
+// GenerateManifestCmd prints an environment manifest for a CPI release,
+// asking for the job properties that have no default
+type GenerateManifestCmd struct {
+	releaseReader boshrel.Reader
+	ui            boshui.UI
+}
+
+func NewGenerateManifestCmd(releaseReader boshrel.Reader, ui boshui.UI) GenerateManifestCmd {
+	return GenerateManifestCmd{releaseReader: releaseReader, ui: ui}
+}
+
+func (c GenerateManifestCmd) Run(opts GenerateManifestOpts) error {
+	tpl, found := envTemplates[opts.CPI]
+	if !found {
+		return bosherr.Errorf("Expected CPI '%s' to be one of: %s", opts.CPI, strings.Join(envTemplateNames(), ", "))
+	}
+
+	releasePath := opts.Args.CPIRelease.ExpandedPath
+
+	release, err := c.releaseReader.Read(releasePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading CPI release '%s'", releasePath)
+	}
+
+	defer release.CleanUp()
+
+	var cpiJob *boshjob.Job
+
+	for _, job := range release.Jobs() {
+		if job.Name() == tpl.CPIJobName {
+			cpiJob = job
+		}
+	}
+
+	if cpiJob == nil {
+		return bosherr.Errorf("Expected release '%s' to contain job '%s'", release.Name(), tpl.CPIJobName)
+	}
+
+	properties, err := c.askForProperties(cpiJob)
+	if err != nil {
+		return err
+	}
+
+	tpl.CPIReleaseName = release.Name()
+	tpl.CPIReleaseURL = "file://" + releasePath
+	tpl.CPIProperties, err = c.indentedYAML(properties)
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintBlock([]byte(tpl.Manifest()))
+
+	return nil
+}
+
+// askForProperties builds the nested properties hash from the job spec.
+// Unanswered properties, and all of them when not interactive, become variables.
+func (c GenerateManifestCmd) askForProperties(job *boshjob.Job) (map[interface{}]interface{}, error) {
+	names := []string{}
+
+	for name, def := range job.Properties {
+		if def.Default == nil && !c.setByTemplate(name) {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	properties := map[interface{}]interface{}{}
+
+	for _, name := range names {
+		value := "((" + strings.Replace(name, ".", "_", -1) + "))"
+
+		if c.ui.IsInteractive() {
+			answer, err := c.askForProperty(name, job.Properties[name].Description)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Asking for property '%s'", name)
+			}
+
+			if answer != "" {
+				value = answer
+			}
+		}
+
+		setNestedProperty(properties, strings.Split(name, "."), value)
+	}
+
+	return properties, nil
+}
+
+func (c GenerateManifestCmd) askForProperty(name, description string) (string, error) {
+	label := name
+	if description != "" {
+		label += " (" + description + ")"
+	}
+
+	if strings.Contains(name, "password") || strings.Contains(name, "secret") {
+		return c.ui.AskForPassword(label)
+	}
+
+	return c.ui.AskForText(label)
+}
+
+func (c GenerateManifestCmd) setByTemplate(name string) bool {
+	for _, prefix := range templateCPIProperties {
+		if name == prefix || strings.HasPrefix(name, prefix+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// indentedYAML renders properties to be placed under `cloud_provider.properties`
+func (c GenerateManifestCmd) indentedYAML(properties map[interface{}]interface{}) (string, error) {
+	if len(properties) == 0 {
+		return "", nil
+	}
+
+	bytes, err := yaml.Marshal(properties)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Marshalling CPI properties")
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(bytes), "\n"), "\n")
+
+	return strings.Join(lines, "\n    "), nil
+}
+
+func setNestedProperty(properties map[interface{}]interface{}, path []string, value interface{}) {
+	if len(path) == 1 {
+		properties[path[0]] = value
+		return
+	}
+
+	nested, ok := properties[path[0]].(map[interface{}]interface{})
+	if !ok {
+		nested = map[interface{}]interface{}{}
+		properties[path[0]] = nested
+	}
+
+	setNestedProperty(nested, path[1:], value)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type InitEnvCmd struct {
+	ui boshui.UI
+	fs boshsys.FileSystem
+}
+
+func NewInitEnvCmd(ui boshui.UI, fs boshsys.FileSystem) InitEnvCmd {
+	return InitEnvCmd{ui: ui, fs: fs}
+}
+
+func (c InitEnvCmd) Run(opts InitEnvOpts) error {
+	tpl, found := envTemplates[opts.CPI]
+	if !found {
+		return bosherr.Errorf("Expected CPI '%s' to be one of: %s", opts.CPI, strings.Join(envTemplateNames(), ", "))
+	}
+
+	files := []struct {
+		Name     string
+		Contents string
+	}{
+		{Name: "bosh.yml", Contents: tpl.Manifest()},
+		{Name: "vars.yml", Contents: tpl.VarsFile()},
+		{Name: ".gitignore", Contents: envGitignore},
+	}
+
+	for _, file := range files {
+		path := filepath.Join(opts.Directory.Path, file.Name)
+		if c.fs.FileExists(path) {
+			return bosherr.Errorf("File '%s' already exists", path)
+		}
+	}
+
+	err := c.fs.MkdirAll(opts.Directory.Path, os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating environment directory '%s'", opts.Directory.Path)
+	}
+
+	for _, file := range files {
+		path := filepath.Join(opts.Directory.Path, file.Name)
+
+		err := c.fs.WriteFileString(path, file.Contents)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating %s", file.Name)
+		}
+	}
+
+	c.ui.PrintLinef("Initialized %s environment in '%s'", opts.CPI, opts.Directory.Path)
+	c.ui.PrintLinef("Fill in vars.yml, then run: bosh create-env bosh.yml --vars-store creds.yml -l vars.yml")
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("InitEnvCmd", func() {
+	var (
+		fs      *fakesys.FakeFileSystem
+		ui      *fakeui.FakeUI
+		command InitEnvCmd
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		ui = &fakeui.FakeUI{}
+		command = NewInitEnvCmd(ui, fs)
+	})
+
+	Describe("Run", func() {
+		var (
+			opts InitEnvOpts
+		)
+
+		BeforeEach(func() {
+			opts = InitEnvOpts{
+				Directory: DirOrCWDArg{Path: "/env"},
+				CPI:       "aws",
+			}
+		})
+
+		It("writes a manifest, a vars file and a .gitignore", func() {
+			err := command.Run(opts)
+			Expect(err).ToNot(HaveOccurred())
+
+			manifest, err := fs.ReadFileString("/env/bosh.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manifest).To(ContainSubstring("name: bosh-aws-cpi"))
+			Expect(manifest).To(ContainSubstring("template: {name: aws_cpi, release: bosh-aws-cpi}"))
+
+			vars, err := fs.ReadFileString("/env/vars.yml")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(vars).To(ContainSubstring("\ninternal_ip: \n"))
+			Expect(vars).To(ContainSubstring("\naccess_key_id: \n"))
+
+			gitignore, err := fs.ReadFileString("/env/.gitignore")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gitignore).To(ContainSubstring("*-state.json\n"))
+			Expect(gitignore).To(ContainSubstring("creds.yml\n"))
+
+			Expect(ui.Said).To(ContainElement("Initialized aws environment in '/env'"))
+		})
+
+		It("writes valid YAML for every CPI", func() {
+			for _, cpi := range []string{"aws", "gcp", "openstack", "vsphere"} {
+				dir := "/env-" + cpi
+				opts = InitEnvOpts{Directory: DirOrCWDArg{Path: dir}, CPI: cpi}
+
+				err := command.Run(opts)
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, name := range []string{"bosh.yml", "vars.yml"} {
+					contents, err := fs.ReadFile(dir + "/" + name)
+					Expect(err).ToNot(HaveOccurred())
+
+					parsed := map[interface{}]interface{}{}
+					Expect(yaml.Unmarshal(contents, &parsed)).To(Succeed(), "%s for %s", name, cpi)
+				}
+			}
+		})
+
+		It("returns an error for unknown CPIs", func() {
+			opts.CPI = "fake-cpi"
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Expected CPI 'fake-cpi' to be one of: aws, gcp, openstack, vsphere"))
+		})
+
+		It("does not overwrite existing files", func() {
+			fs.WriteFileString("/env/vars.yml", "existing")
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("File '/env/vars.yml' already exists"))
+
+			Expect(fs.FileExists("/env/bosh.yml")).To(BeFalse())
+		})
+
+		It("returns an error when writing fails", func() {
+			fs.WriteFileError = errors.New("fake-err")
+
+			err := command.Run(opts)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Creating bosh.yml"))
+		})
+	})
+})
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	bivalidation "github.com/cloudfoundry/bosh-cli/common/validation"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// inlineSecretPatterns match lines of a manifest that contain secrets
+// instead of referring to variables
+var inlineSecretPatterns = []struct {
+	kind   string
+	regexp *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"AWS secret access key", regexp.MustCompile(`secret_access_key\s*:\s*["']?[A-Za-z0-9/+=]{40}["']?\s*$`)},
+	{"password", regexp.MustCompile(`^\s*(-\s+)?[a-z0-9_]*(password|secret)\s*:\s*([^\s"'(#|>]|"[^"(]|'[^'(])`)},
+	{"URL password", regexp.MustCompile(`://[^:/\s@]+:[^@\s(]+@`)},
+}
+
+var inlineSecretVariableRegexp = regexp.MustCompile(`:\s*["']?\(\([^()]+\)\)["']?\s*$`)
+
+type inlineSecret struct {
+	Line int
+	Path string
+	Kind string
+}
+
+// findInlineSecrets scans the lines of a manifest before variables are
+// interpolated, so that secrets coming from vars files and vars stores are
+// not reported
+func findInlineSecrets(content []byte) []inlineSecret {
+	lines := newManifestLines(content)
+
+	var secrets []inlineSecret
+
+	for i, line := range strings.Split(string(content), "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") || inlineSecretVariableRegexp.MatchString(line) {
+			continue
+		}
+
+		for _, pattern := range inlineSecretPatterns {
+			if !pattern.regexp.MatchString(line) {
+				continue
+			}
+
+			secret := inlineSecret{Line: i + 1, Kind: pattern.kind}
+
+			// lines of block scalars, e.g. of private keys, belong to the key above them
+			for l := secret.Line; l > 0 && secret.Path == ""; l-- {
+				secret.Path, _ = lines.Path(l)
+			}
+
+			secrets = append(secrets, secret)
+			break
+		}
+	}
+
+	return secrets
+}
+
+// findOpsInlineSecrets scans the manifest with the ops files applied for
+// secrets that are not in the manifest itself. Variables are left
+// uninterpolated like in the manifest.
+func findOpsInlineSecrets(content []byte, opsFlags OpsFlags, manifestSecrets []inlineSecret) []inlineSecret {
+	if len(opsFlags.OpsFiles) == 0 {
+		return nil
+	}
+
+	bytes, err := boshtpl.NewTemplate(content).Evaluate(boshtpl.StaticVariables{}, opsFlags.AsOp(), boshtpl.EvaluateOpts{})
+	if err != nil {
+		// ops files that do not apply are reported when the manifest is evaluated
+		return nil
+	}
+
+	manifestPaths := map[string]bool{}
+	for _, secret := range manifestSecrets {
+		manifestPaths[secret.Path] = true
+	}
+
+	var secrets []inlineSecret
+
+	for _, secret := range findInlineSecrets(bytes) {
+		if !manifestPaths[secret.Path] {
+			secrets = append(secrets, secret)
+		}
+	}
+
+	return secrets
+}
+
+// findVarInlineSecrets scans --var values, which end up in the shell
+// history, as if each was a key of the manifest
+func findVarInlineSecrets(varFlags VarFlags) []inlineSecret {
+	var secrets []inlineSecret
+
+	for _, varKV := range varFlags.VarKVs {
+		bytes, err := yaml.Marshal(map[string]interface{}{varKV.Name: varKV.Value})
+		if err != nil {
+			continue
+		}
+
+		found := findInlineSecrets(bytes)
+		if len(found) > 0 {
+			secrets = append(secrets, inlineSecret{Path: varKV.Name, Kind: found[0].Kind})
+		}
+	}
+
+	return secrets
+}
+
+// warnInlineSecrets prints a warning for every secret found in the manifest,
+// added by its ops files or given with --var, and fails when asked to
+func warnInlineSecrets(ui boshui.UI, manifestPath string, content []byte, opsFlags OpsFlags, varFlags VarFlags, fail bool) error {
+	secrets := findInlineSecrets(content)
+	opsSecrets := findOpsInlineSecrets(content, opsFlags, secrets)
+	varSecrets := findVarInlineSecrets(varFlags)
+
+	if len(secrets)+len(opsSecrets)+len(varSecrets) == 0 {
+		return nil
+	}
+
+	for _, secret := range secrets {
+		if secret.Path != "" {
+			ui.ErrorLinef("Warning: manifest '%s' line %d (%s) contains an inline %s", manifestPath, secret.Line, secret.Path, secret.Kind)
+		} else {
+			ui.ErrorLinef("Warning: manifest '%s' line %d contains an inline %s", manifestPath, secret.Line, secret.Kind)
+		}
+	}
+
+	for _, secret := range opsSecrets {
+		ui.ErrorLinef("Warning: ops files set manifest '%s' %s to an inline %s", manifestPath, secret.Path, secret.Kind)
+	}
+
+	for _, secret := range varSecrets {
+		ui.ErrorLinef("Warning: --var '%s' contains an inline %s", secret.Path, secret.Kind)
+	}
+
+	ui.ErrorLinef("Move secrets to a vars file or --vars-store and refer to them as ((variables))")
+
+	if fail {
+		return bivalidation.Error("Found inline secrets in manifest '" + manifestPath + "'")
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"sort"
+
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// InspectReleaseTarballCmd shows the jobs, their properties and the packages
+// of a release tarball without extracting it, e.g. for choosing manifest
+// properties before the release is uploaded
+type InspectReleaseTarballCmd struct {
+	inspectRelease func(string) (boshrel.TarballInspection, error)
+	ui             biui.UI
+}
+
+func NewInspectReleaseTarballCmd(
+	inspectRelease func(string) (boshrel.TarballInspection, error),
+	ui biui.UI,
+) InspectReleaseTarballCmd {
+	return InspectReleaseTarballCmd{
+		inspectRelease: inspectRelease,
+		ui:             ui,
+	}
+}
+
+func (c InspectReleaseTarballCmd) Run(opts InspectReleaseTarballOpts) error {
+	inspection, err := c.inspectRelease(opts.Args.PathToRelease)
+	if err != nil {
+		return err
+	}
+
+	c.ui.PrintTable(boshtbl.Table{
+		Content: "release-metadata",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Name"),
+			boshtbl.NewHeader("Version"),
+			boshtbl.NewHeader("Tarball Size"),
+			boshtbl.NewHeader("Extracted Size"),
+		},
+		Rows: [][]boshtbl.Value{
+			{
+				boshtbl.NewValueString(inspection.Name),
+				boshtbl.NewValueString(inspection.Version),
+				boshtbl.NewValueBytes(uint64(inspection.Size)),
+				boshtbl.NewValueBytes(uint64(inspection.ExtractedSize)),
+			},
+		},
+	})
+
+	jobsTable := boshtbl.Table{
+		Content: "jobs",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Job"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("Property"),
+			boshtbl.NewHeader("Default"),
+			boshtbl.NewHeader("Description"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, job := range inspection.Jobs {
+		section := boshtbl.Section{
+			FirstColumn: boshtbl.NewValueString(job.Name),
+		}
+
+		names := []string{}
+		for name := range job.Properties {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		for i, name := range names {
+			size := boshtbl.Value(boshtbl.NewValueString(""))
+			if i == 0 {
+				size = boshtbl.NewValueBytes(uint64(job.Size))
+			}
+
+			property := job.Properties[name]
+
+			section.Rows = append(section.Rows, []boshtbl.Value{
+				boshtbl.NewValueString(""),
+				size,
+				boshtbl.NewValueString(name),
+				boshtbl.NewValueInterface(property.Default),
+				boshtbl.NewValueString(property.Description),
+			})
+		}
+
+		if len(names) == 0 {
+			section.Rows = append(section.Rows, []boshtbl.Value{
+				boshtbl.NewValueString(""),
+				boshtbl.NewValueBytes(uint64(job.Size)),
+				boshtbl.NewValueString(""),
+				boshtbl.NewValueString(""),
+				boshtbl.NewValueString(""),
+			})
+		}
+
+		jobsTable.Sections = append(jobsTable.Sections, section)
+	}
+
+	pkgsTable := boshtbl.Table{
+		Content: "packages",
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Package"),
+			boshtbl.NewHeader("Compiled for"),
+			boshtbl.NewHeader("Dependencies"),
+			boshtbl.NewHeader("Size"),
+		},
+		SortBy: []boshtbl.ColumnSort{{Column: 0, Asc: true}},
+	}
+
+	for _, pkg := range inspection.Packages {
+		compiledFor := pkg.Stemcell
+		if compiledFor == "" {
+			compiledFor = "(source)"
+		}
+
+		pkgsTable.Rows = append(pkgsTable.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(pkg.Name),
+			boshtbl.NewValueString(compiledFor),
+			boshtbl.NewValueStrings(pkg.Dependencies),
+			boshtbl.NewValueBytes(uint64(pkg.Size)),
+		})
+	}
+
+	c.ui.PrintTable(jobsTable)
+	c.ui.PrintTable(pkgsTable)
+
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	boshjobman "github.com/cloudfoundry/bosh-cli/release/job/manifest"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("InspectReleaseTarballCmd", func() {
+	var (
+		ui         *fakeui.FakeUI
+		inspection boshrel.TarballInspection
+		inspectErr error
+		command    InspectReleaseTarballCmd
+	)
+
+	BeforeEach(func() {
+		ui = &fakeui.FakeUI{}
+		inspectErr = nil
+
+		inspection = boshrel.TarballInspection{
+			Name:          "fake-release",
+			Version:       "1",
+			Size:          1024,
+			ExtractedSize: 4096,
+			Jobs: []boshrel.InspectedJob{
+				{
+					Name: "fake-job",
+					Size: 512,
+					Properties: map[string]boshjobman.PropertyDefinition{
+						"port":    {Description: "Port to listen on", Default: 8080},
+						"address": {Description: "Address to listen on"},
+					},
+				},
+			},
+			Packages: []boshrel.InspectedPackage{
+				{Name: "fake-pkg", Dependencies: []string{"fake-dep"}, Size: 2048},
+				{Name: "fake-dep", Stemcell: "ubuntu-trusty/3421", Size: 1536},
+			},
+		}
+
+		inspectRelease := func(path string) (boshrel.TarballInspection, error) {
+			Expect(path).To(Equal("/release.tgz"))
+			return inspection, inspectErr
+		}
+
+		command = NewInspectReleaseTarballCmd(inspectRelease, ui)
+	})
+
+	It("shows the release, its jobs with their properties and its packages", func() {
+		err := command.Run(InspectReleaseTarballOpts{Args: InspectReleaseTarballArgs{PathToRelease: "/release.tgz"}})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(ui.Tables).To(HaveLen(3))
+
+		Expect(ui.Tables[0].Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("fake-release"),
+				boshtbl.NewValueString("1"),
+				boshtbl.NewValueBytes(1024),
+				boshtbl.NewValueBytes(4096),
+			},
+		}))
+
+		Expect(ui.Tables[1].Sections).To(Equal([]boshtbl.Section{
+			{
+				FirstColumn: boshtbl.NewValueString("fake-job"),
+				Rows: [][]boshtbl.Value{
+					{
+						boshtbl.NewValueString(""),
+						boshtbl.NewValueBytes(512),
+						boshtbl.NewValueString("address"),
+						boshtbl.NewValueInterface(nil),
+						boshtbl.NewValueString("Address to listen on"),
+					},
+					{
+						boshtbl.NewValueString(""),
+						boshtbl.NewValueString(""),
+						boshtbl.NewValueString("port"),
+						boshtbl.NewValueInterface(8080),
+						boshtbl.NewValueString("Port to listen on"),
+					},
+				},
+			},
+		}))
+
+		Expect(ui.Tables[2].Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("fake-pkg"),
+				boshtbl.NewValueString("(source)"),
+				boshtbl.NewValueStrings([]string{"fake-dep"}),
+				boshtbl.NewValueBytes(2048),
+			},
+			{
+				boshtbl.NewValueString("fake-dep"),
+				boshtbl.NewValueString("ubuntu-trusty/3421"),
+				boshtbl.NewValueStrings(nil),
+				boshtbl.NewValueBytes(1536),
+			},
+		}))
+	})
+
+	It("returns an error if the tarball cannot be inspected", func() {
+		inspectErr = errors.New("fake-err")
+
+		err := command.Run(InspectReleaseTarballOpts{Args: InspectReleaseTarballArgs{PathToRelease: "/release.tgz"}})
+		Expect(err).To(MatchError("fake-err"))
+	})
+})
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// KeepingFileSystem does not remove what is in the temp root, such as
+// extracted releases and stemcells and rendered templates, so that they can
+// be inspected after a failure. The temp root is still emptied by the next
+// command that sets it.
+type KeepingFileSystem struct {
+	boshsys.FileSystem
+
+	tempRoot string
+	kept     []string
+}
+
+func NewKeepingFileSystem(fs boshsys.FileSystem) *KeepingFileSystem {
+	return &KeepingFileSystem{FileSystem: fs}
+}
+
+func (fs *KeepingFileSystem) ChangeTempRoot(path string) error {
+	err := fs.FileSystem.ChangeTempRoot(path)
+	if err != nil {
+		return err
+	}
+
+	fs.tempRoot = filepath.Clean(path)
+
+	return nil
+}
+
+func (fs *KeepingFileSystem) RemoveAll(path string) error {
+	if fs.tempRoot == "" || !strings.HasPrefix(filepath.Clean(path), fs.tempRoot+string(filepath.Separator)) {
+		return fs.FileSystem.RemoveAll(path)
+	}
+
+	for _, keptPath := range fs.kept {
+		if keptPath == path {
+			return nil
+		}
+	}
+
+	fs.kept = append(fs.kept, path)
+
+	return nil
+}
+
+// Kept returns the paths that were not removed, in the order they would have been
+func (fs *KeepingFileSystem) Kept() []string {
+	return append([]string(nil), fs.kept...)
+}
+
+func (fs *KeepingFileSystem) PrintKept(ui biui.UI) {
+	if len(fs.kept) == 0 {
+		return
+	}
+
+	ui.PrintLinef("Kept extracted artifacts:")
+
+	for _, path := range fs.kept {
+		ui.PrintLinef("  %s", path)
+	}
+}
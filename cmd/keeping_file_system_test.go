@@ -0,0 +1,95 @@
+package cmd_test
+
+import (
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("KeepingFileSystem", func() {
+	var (
+		fakeFS *fakesys.FakeFileSystem
+		fs     *KeepingFileSystem
+	)
+
+	BeforeEach(func() {
+		fakeFS = fakesys.NewFakeFileSystem()
+		fs = NewKeepingFileSystem(fakeFS)
+
+		err := fs.ChangeTempRoot("/fake-tmp")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	Describe("RemoveAll", func() {
+		It("keeps paths in the temp root", func() {
+			err := fakeFS.WriteFileString("/fake-tmp/release-123/release.MF", "fake-release-manifest")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.RemoveAll("/fake-tmp/release-123")
+			Expect(err).ToNot(HaveOccurred())
+			err = fs.RemoveAll("/fake-tmp/release-123")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFS.FileExists("/fake-tmp/release-123/release.MF")).To(BeTrue())
+			Expect(fs.Kept()).To(Equal([]string{"/fake-tmp/release-123"}))
+		})
+
+		It("removes the temp root itself and paths outside of it", func() {
+			err := fakeFS.WriteFileString("/fake-tmp/stale", "")
+			Expect(err).ToNot(HaveOccurred())
+			err = fakeFS.WriteFileString("/fake-installation/packages/fake-package", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.RemoveAll("/fake-tmp")
+			Expect(err).ToNot(HaveOccurred())
+			err = fs.RemoveAll("/fake-installation/packages")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFS.FileExists("/fake-tmp/stale")).To(BeFalse())
+			Expect(fakeFS.FileExists("/fake-installation/packages/fake-package")).To(BeFalse())
+			Expect(fs.Kept()).To(BeEmpty())
+		})
+
+		It("removes everything until the temp root is set", func() {
+			fs = NewKeepingFileSystem(fakeFS)
+
+			err := fakeFS.WriteFileString("/fake-tmp/release-123/release.MF", "")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = fs.RemoveAll("/fake-tmp/release-123")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeFS.FileExists("/fake-tmp/release-123/release.MF")).To(BeFalse())
+		})
+	})
+
+	Describe("PrintKept", func() {
+		It("prints the kept paths", func() {
+			ui := &fakeui.FakeUI{}
+
+			err := fs.RemoveAll("/fake-tmp/release-123")
+			Expect(err).ToNot(HaveOccurred())
+			err = fs.RemoveAll("/fake-tmp/rendered-jobs-456")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.PrintKept(ui)
+
+			Expect(ui.Said).To(Equal([]string{
+				"Kept extracted artifacts:",
+				"  /fake-tmp/release-123",
+				"  /fake-tmp/rendered-jobs-456",
+			}))
+		})
+
+		It("prints nothing when nothing was kept", func() {
+			ui := &fakeui.FakeUI{}
+
+			fs.PrintKept(ui)
+
+			Expect(ui.Said).To(BeEmpty())
+		})
+	})
+})
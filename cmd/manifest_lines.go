@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	manifestLinesKeyRegexp = regexp.MustCompile(`^("[^"]*"|'[^']*'|[^\s#"'][^:#]*?)\s*:(\s|$)`)
+	manifestLinesSegment   = regexp.MustCompile(`(\.[^.\[]+|\[\d+\])$`)
+)
+
+// manifestLines maps paths of a block style YAML document, written like
+// `resource_pools[0].stemcell.url` in validation errors, to the lines
+// defining them. Keys in flow style collections are not indexed.
+type manifestLines struct {
+	lines map[string]int
+	paths map[int]string
+}
+
+type manifestLinesFrame struct {
+	col   int
+	path  string
+	item  bool
+	index int
+}
+
+func newManifestLines(content []byte) manifestLines {
+	ml := manifestLines{lines: map[string]int{}, paths: map[int]string{}}
+
+	var stack []manifestLinesFrame
+
+	parentPath := func() string {
+		if len(stack) == 0 {
+			return ""
+		}
+		return stack[len(stack)-1].path
+	}
+
+	blockScalarCol := -1
+
+	for i, line := range strings.Split(string(content), "\n") {
+		lineNum := i + 1
+
+		trimmed := strings.TrimLeft(line, " ")
+		col := len(line) - len(trimmed)
+		trimmed = strings.TrimRight(trimmed, " \r")
+
+		// lines of literal and folded scalars are more indented than their key
+		if blockScalarCol >= 0 {
+			if trimmed == "" || col > blockScalarCol {
+				continue
+			}
+			blockScalarCol = -1
+		}
+
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+
+		for trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			for len(stack) > 0 && stack[len(stack)-1].col > col {
+				stack = stack[:len(stack)-1]
+			}
+
+			// items may be indented like the key of their sequence
+			index := 0
+			if len(stack) > 0 && stack[len(stack)-1].item && stack[len(stack)-1].col == col {
+				index = stack[len(stack)-1].index + 1
+				stack = stack[:len(stack)-1]
+			}
+
+			path := fmt.Sprintf("%s[%d]", parentPath(), index)
+			stack = append(stack, manifestLinesFrame{col: col, path: path, item: true, index: index})
+			ml.add(path, lineNum)
+
+			rest := strings.TrimLeft(trimmed[1:], " ")
+			col += len(trimmed) - len(rest)
+			trimmed = rest
+		}
+
+		match := manifestLinesKeyRegexp.FindStringSubmatch(trimmed)
+		if match == nil {
+			continue
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].col >= col {
+			stack = stack[:len(stack)-1]
+		}
+
+		path := strings.Trim(match[1], `"'`)
+		if parent := parentPath(); parent != "" {
+			path = parent + "." + path
+		}
+
+		stack = append(stack, manifestLinesFrame{col: col, path: path})
+		ml.add(path, lineNum)
+
+		value := strings.TrimSpace(trimmed[len(match[0]):])
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockScalarCol = col
+		}
+	}
+
+	return ml
+}
+
+func (ml manifestLines) add(path string, line int) {
+	if _, found := ml.lines[path]; !found {
+		ml.lines[path] = line
+	}
+	ml.paths[line] = path
+}
+
+// Line returns the line of the path, or of its closest parent when the
+// path is missing from the document
+func (ml manifestLines) Line(path string) (int, bool) {
+	for {
+		if line, found := ml.lines[path]; found {
+			return line, true
+		}
+
+		parent := manifestLinesSegment.ReplaceAllString(path, "")
+		if parent == path || parent == "" {
+			return 0, false
+		}
+
+		path = parent
+	}
+}
+
+// Path returns the deepest path defined on the line
+func (ml manifestLines) Path(line int) (string, bool) {
+	path, found := ml.paths[line]
+	return path, found
+}
@@ -1,10 +1,12 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/cloudfoundry/bosh-cli/cmd (interfaces: DeploymentDeleter)
+// Source: github.com/cloudfoundry/bosh-cli/cmd (interfaces: DeploymentDeleter,CpiLifecycleTester,OrphanedDisksManager,EnvStemcellsManager,AgentActionSender,EnvLogsFetcher,CredsRotationEnv,PruneStateManager)
 
 // Package mocks is a generated GoMock package.
 package mocks
 
 import (
+	cmd "github.com/cloudfoundry/bosh-cli/cmd"
+	config "github.com/cloudfoundry/bosh-cli/config"
 	ui "github.com/cloudfoundry/bosh-cli/ui"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -34,13 +36,348 @@ func (m *MockDeploymentDeleter) EXPECT() *MockDeploymentDeleterMockRecorder {
 }
 
 // DeleteDeployment mocks base method
-func (m *MockDeploymentDeleter) DeleteDeployment(arg0 bool, arg1 ui.Stage) error {
-	ret := m.ctrl.Call(m, "DeleteDeployment", arg0, arg1)
+func (m *MockDeploymentDeleter) DeleteDeployment(arg0, arg1 bool, arg2 ui.Stage) error {
+	ret := m.ctrl.Call(m, "DeleteDeployment", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteDeployment indicates an expected call of DeleteDeployment
-func (mr *MockDeploymentDeleterMockRecorder) DeleteDeployment(arg0, arg1 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeployment", reflect.TypeOf((*MockDeploymentDeleter)(nil).DeleteDeployment), arg0, arg1)
+func (mr *MockDeploymentDeleterMockRecorder) DeleteDeployment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteDeployment", reflect.TypeOf((*MockDeploymentDeleter)(nil).DeleteDeployment), arg0, arg1, arg2)
+}
+
+// PreviewDeletion mocks base method
+func (m *MockDeploymentDeleter) PreviewDeletion(arg0 bool, arg1 ui.Stage) error {
+	ret := m.ctrl.Call(m, "PreviewDeletion", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// PreviewDeletion indicates an expected call of PreviewDeletion
+func (mr *MockDeploymentDeleterMockRecorder) PreviewDeletion(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PreviewDeletion", reflect.TypeOf((*MockDeploymentDeleter)(nil).PreviewDeletion), arg0, arg1)
+}
+
+// MockCpiLifecycleTester is a mock of CpiLifecycleTester interface
+type MockCpiLifecycleTester struct {
+	ctrl     *gomock.Controller
+	recorder *MockCpiLifecycleTesterMockRecorder
+}
+
+// MockCpiLifecycleTesterMockRecorder is the mock recorder for MockCpiLifecycleTester
+type MockCpiLifecycleTesterMockRecorder struct {
+	mock *MockCpiLifecycleTester
+}
+
+// NewMockCpiLifecycleTester creates a new mock instance
+func NewMockCpiLifecycleTester(ctrl *gomock.Controller) *MockCpiLifecycleTester {
+	mock := &MockCpiLifecycleTester{ctrl: ctrl}
+	mock.recorder = &MockCpiLifecycleTesterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCpiLifecycleTester) EXPECT() *MockCpiLifecycleTesterMockRecorder {
+	return m.recorder
+}
+
+// TestLifecycle mocks base method
+func (m *MockCpiLifecycleTester) TestLifecycle(arg0 int, arg1 ui.Stage) error {
+	ret := m.ctrl.Call(m, "TestLifecycle", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TestLifecycle indicates an expected call of TestLifecycle
+func (mr *MockCpiLifecycleTesterMockRecorder) TestLifecycle(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TestLifecycle", reflect.TypeOf((*MockCpiLifecycleTester)(nil).TestLifecycle), arg0, arg1)
+}
+
+// MockOrphanedDisksManager is a mock of OrphanedDisksManager interface
+type MockOrphanedDisksManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrphanedDisksManagerMockRecorder
+}
+
+// MockOrphanedDisksManagerMockRecorder is the mock recorder for MockOrphanedDisksManager
+type MockOrphanedDisksManagerMockRecorder struct {
+	mock *MockOrphanedDisksManager
+}
+
+// NewMockOrphanedDisksManager creates a new mock instance
+func NewMockOrphanedDisksManager(ctrl *gomock.Controller) *MockOrphanedDisksManager {
+	mock := &MockOrphanedDisksManager{ctrl: ctrl}
+	mock.recorder = &MockOrphanedDisksManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockOrphanedDisksManager) EXPECT() *MockOrphanedDisksManagerMockRecorder {
+	return m.recorder
+}
+
+// Attach mocks base method
+func (m *MockOrphanedDisksManager) Attach(arg0 string) error {
+	ret := m.ctrl.Call(m, "Attach", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Attach indicates an expected call of Attach
+func (mr *MockOrphanedDisksManagerMockRecorder) Attach(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Attach", reflect.TypeOf((*MockOrphanedDisksManager)(nil).Attach), arg0)
+}
+
+// Delete mocks base method
+func (m *MockOrphanedDisksManager) Delete(arg0 string, arg1 ui.Stage) error {
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockOrphanedDisksManagerMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockOrphanedDisksManager)(nil).Delete), arg0, arg1)
+}
+
+// List mocks base method
+func (m *MockOrphanedDisksManager) List() ([]config.OrphanedDiskRecord, error) {
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]config.OrphanedDiskRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockOrphanedDisksManagerMockRecorder) List() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockOrphanedDisksManager)(nil).List))
+}
+
+// MockEnvStemcellsManager is a mock of EnvStemcellsManager interface
+type MockEnvStemcellsManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockEnvStemcellsManagerMockRecorder
+}
+
+// MockEnvStemcellsManagerMockRecorder is the mock recorder for MockEnvStemcellsManager
+type MockEnvStemcellsManagerMockRecorder struct {
+	mock *MockEnvStemcellsManager
+}
+
+// NewMockEnvStemcellsManager creates a new mock instance
+func NewMockEnvStemcellsManager(ctrl *gomock.Controller) *MockEnvStemcellsManager {
+	mock := &MockEnvStemcellsManager{ctrl: ctrl}
+	mock.recorder = &MockEnvStemcellsManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEnvStemcellsManager) EXPECT() *MockEnvStemcellsManagerMockRecorder {
+	return m.recorder
+}
+
+// Import mocks base method
+func (m *MockEnvStemcellsManager) Import(arg0 []config.StemcellRecord) ([]config.StemcellRecord, error) {
+	ret := m.ctrl.Call(m, "Import", arg0)
+	ret0, _ := ret[0].([]config.StemcellRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import
+func (mr *MockEnvStemcellsManagerMockRecorder) Import(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockEnvStemcellsManager)(nil).Import), arg0)
+}
+
+// DeleteUnused mocks base method
+func (m *MockEnvStemcellsManager) DeleteUnused(arg0 ui.Stage) error {
+	ret := m.ctrl.Call(m, "DeleteUnused", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnused indicates an expected call of DeleteUnused
+func (mr *MockEnvStemcellsManagerMockRecorder) DeleteUnused(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnused", reflect.TypeOf((*MockEnvStemcellsManager)(nil).DeleteUnused), arg0)
+}
+
+// List mocks base method
+func (m *MockEnvStemcellsManager) List() ([]cmd.EnvStemcell, error) {
+	ret := m.ctrl.Call(m, "List")
+	ret0, _ := ret[0].([]cmd.EnvStemcell)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// List indicates an expected call of List
+func (mr *MockEnvStemcellsManagerMockRecorder) List() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockEnvStemcellsManager)(nil).List))
+}
+
+// MockAgentActionSender is a mock of AgentActionSender interface
+type MockAgentActionSender struct {
+	ctrl     *gomock.Controller
+	recorder *MockAgentActionSenderMockRecorder
+}
+
+// MockAgentActionSenderMockRecorder is the mock recorder for MockAgentActionSender
+type MockAgentActionSenderMockRecorder struct {
+	mock *MockAgentActionSender
+}
+
+// NewMockAgentActionSender creates a new mock instance
+func NewMockAgentActionSender(ctrl *gomock.Controller) *MockAgentActionSender {
+	mock := &MockAgentActionSender{ctrl: ctrl}
+	mock.recorder = &MockAgentActionSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAgentActionSender) EXPECT() *MockAgentActionSenderMockRecorder {
+	return m.recorder
+}
+
+// Send mocks base method
+func (m *MockAgentActionSender) Send(arg0 string, arg1 []interface{}) ([]byte, error) {
+	ret := m.ctrl.Call(m, "Send", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Send indicates an expected call of Send
+func (mr *MockAgentActionSenderMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockAgentActionSender)(nil).Send), arg0, arg1)
+}
+
+// MockEnvLogsFetcher is a mock of EnvLogsFetcher interface
+type MockEnvLogsFetcher struct {
+	ctrl     *gomock.Controller
+	recorder *MockEnvLogsFetcherMockRecorder
+}
+
+// MockEnvLogsFetcherMockRecorder is the mock recorder for MockEnvLogsFetcher
+type MockEnvLogsFetcherMockRecorder struct {
+	mock *MockEnvLogsFetcher
+}
+
+// NewMockEnvLogsFetcher creates a new mock instance
+func NewMockEnvLogsFetcher(ctrl *gomock.Controller) *MockEnvLogsFetcher {
+	mock := &MockEnvLogsFetcher{ctrl: ctrl}
+	mock.recorder = &MockEnvLogsFetcherMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockEnvLogsFetcher) EXPECT() *MockEnvLogsFetcherMockRecorder {
+	return m.recorder
+}
+
+// FetchLogs mocks base method
+func (m *MockEnvLogsFetcher) FetchLogs(arg0 string, arg1 []string, arg2 string) error {
+	ret := m.ctrl.Call(m, "FetchLogs", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FetchLogs indicates an expected call of FetchLogs
+func (mr *MockEnvLogsFetcherMockRecorder) FetchLogs(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchLogs", reflect.TypeOf((*MockEnvLogsFetcher)(nil).FetchLogs), arg0, arg1, arg2)
+}
+
+// MockCredsRotationEnv is a mock of CredsRotationEnv interface
+type MockCredsRotationEnv struct {
+	ctrl     *gomock.Controller
+	recorder *MockCredsRotationEnvMockRecorder
+}
+
+// MockCredsRotationEnvMockRecorder is the mock recorder for MockCredsRotationEnv
+type MockCredsRotationEnvMockRecorder struct {
+	mock *MockCredsRotationEnv
+}
+
+// NewMockCredsRotationEnv creates a new mock instance
+func NewMockCredsRotationEnv(ctrl *gomock.Controller) *MockCredsRotationEnv {
+	mock := &MockCredsRotationEnv{ctrl: ctrl}
+	mock.recorder = &MockCredsRotationEnvMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCredsRotationEnv) EXPECT() *MockCredsRotationEnvMockRecorder {
+	return m.recorder
+}
+
+// RecordCredsRotation mocks base method
+func (m *MockCredsRotationEnv) RecordCredsRotation() error {
+	ret := m.ctrl.Call(m, "RecordCredsRotation")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordCredsRotation indicates an expected call of RecordCredsRotation
+func (mr *MockCredsRotationEnvMockRecorder) RecordCredsRotation() *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordCredsRotation", reflect.TypeOf((*MockCredsRotationEnv)(nil).RecordCredsRotation))
+}
+
+// Recreate mocks base method
+func (m *MockCredsRotationEnv) Recreate(arg0 ui.Stage) error {
+	ret := m.ctrl.Call(m, "Recreate", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Recreate indicates an expected call of Recreate
+func (mr *MockCredsRotationEnvMockRecorder) Recreate(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Recreate", reflect.TypeOf((*MockCredsRotationEnv)(nil).Recreate), arg0)
+}
+
+// Send mocks base method
+func (m *MockCredsRotationEnv) Send(arg0 string, arg1 []interface{}) ([]byte, error) {
+	ret := m.ctrl.Call(m, "Send", arg0, arg1)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Send indicates an expected call of Send
+func (mr *MockCredsRotationEnvMockRecorder) Send(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Send", reflect.TypeOf((*MockCredsRotationEnv)(nil).Send), arg0, arg1)
+}
+
+// MockPruneStateManager is a mock of PruneStateManager interface
+type MockPruneStateManager struct {
+	ctrl     *gomock.Controller
+	recorder *MockPruneStateManagerMockRecorder
+}
+
+// MockPruneStateManagerMockRecorder is the mock recorder for MockPruneStateManager
+type MockPruneStateManagerMockRecorder struct {
+	mock *MockPruneStateManager
+}
+
+// NewMockPruneStateManager creates a new mock instance
+func NewMockPruneStateManager(ctrl *gomock.Controller) *MockPruneStateManager {
+	mock := &MockPruneStateManager{ctrl: ctrl}
+	mock.recorder = &MockPruneStateManagerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPruneStateManager) EXPECT() *MockPruneStateManagerMockRecorder {
+	return m.recorder
+}
+
+// Prune mocks base method
+func (m *MockPruneStateManager) Prune(arg0 ui.Stage, arg1 bool) (cmd.PruneReport, error) {
+	ret := m.ctrl.Call(m, "Prune", arg0, arg1)
+	ret0, _ := ret[0].(cmd.PruneReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prune indicates an expected call of Prune
+func (mr *MockPruneStateManagerMockRecorder) Prune(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prune", reflect.TypeOf((*MockPruneStateManager)(nil).Prune), arg0, arg1)
 }
@@ -1,10 +1,13 @@
 package cmd
 
 import (
+	"time"
+
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	"github.com/cppforlife/go-patch/patch"
 
 	boshdir "github.com/cloudfoundry/bosh-cli/director"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
 )
 
@@ -19,6 +22,11 @@ type BoshOpts struct {
 	CACertOpt      CACertArg `long:"ca-cert"               description:"Director CA certificate path or value" env:"BOSH_CA_CERT"`
 	Sha2           bool      `long:"sha2"                  description:"Use SHA256 checksums" env:"BOSH_SHA2"`
 	Parallel       int       `long:"parallel" description:"The max number of parallel operations" default:"5"`
+	MaxCPUs        int       `long:"max-cpus" value-name:"N" description:"The max number of CPUs to use, e.g. for extracting tarballs (default: all)" env:"BOSH_MAX_CPUS"`
+	OTelEndpoint   string    `long:"otel-endpoint"         description:"OTLP/HTTP endpoint to export tracing spans to" env:"BOSH_OTEL_ENDPOINT"`
+
+	KeepaliveIntervalOpt time.Duration `long:"keepalive-interval" value-name:"DURATION" description:"Print progress of long running steps at this interval, e.g. 1m (default: disabled)" env:"BOSH_KEEPALIVE_INTERVAL"`
+	CPIHungTimeoutOpt    time.Duration `long:"cpi-hung-timeout" value-name:"DURATION" description:"Warn when a CPI call writes no output for this long, e.g. 10m (default: disabled)" env:"BOSH_CPI_HUNG_TIMEOUT"`
 
 	// Hidden
 	UsernameOpt string `long:"user" hidden:"true" env:"BOSH_USER"`
@@ -35,17 +43,33 @@ type BoshOpts struct {
 	TTYOpt            bool        `long:"tty"                       description:"Force TTY-like output"`
 	NoColorOpt        bool        `long:"no-color"                  description:"Toggle colorized output"`
 	NonInteractiveOpt bool        `long:"non-interactive" short:"n" description:"Don't ask for user input" env:"BOSH_NON_INTERACTIVE"`
+	TTYLogOpt         string      `long:"tty-log" value-name:"PATH" description:"Also write output with timestamps to a file"`
+	EventLogOpt       string      `long:"event-log" value-name:"PATH" description:"Also write stage events with timestamps and durations as JSON lines to a file"`
 
 	Help HelpOpts `command:"help" description:"Show this help message"`
 
 	// -----> Director management
 
 	// Environments
-	Environment  EnvironmentOpts  `command:"environment"  alias:"env"  description:"Show environment"`
-	Environments EnvironmentsOpts `command:"environments" alias:"envs" description:"List environments"`
-	CreateEnv    CreateEnvOpts    `command:"create-env"                description:"Create or update BOSH environment"`
-	DeleteEnv    DeleteEnvOpts    `command:"delete-env"                description:"Delete BOSH environment"`
-	AliasEnv     AliasEnvOpts     `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
+	Environment      EnvironmentOpts      `command:"environment"  alias:"env"  description:"Show environment"`
+	Environments     EnvironmentsOpts     `command:"environments" alias:"envs" description:"List environments"`
+	InitEnv          InitEnvOpts          `command:"init-env"                  description:"Initialize environment directory with a sample manifest"`
+	GenerateManifest GenerateManifestOpts `command:"generate-manifest"         description:"Print an environment manifest for a CPI release, asking for required CPI properties"`
+	CreateEnv        CreateEnvOpts        `command:"create-env"                description:"Create or update BOSH environment"`
+	DeleteEnv        DeleteEnvOpts        `command:"delete-env"                description:"Delete BOSH environment"`
+	ValidateEnv      ValidateEnvOpts      `command:"validate-env"              description:"Validate an environment manifest without calling the CPI"`
+	Watch            WatchOpts            `command:"watch"                     description:"Validate an environment manifest again whenever it or its ops or vars files change"`
+	EnvStatus        EnvStatusOpts        `command:"env-status"                description:"Show what the deployment state of a BOSH environment records as deployed"`
+	TestCpi          TestCpiOpts          `command:"test-cpi"                  description:"Run a create and delete lifecycle against the CPI in a manifest"`
+	OrphanedEnvDisks OrphanedDisksOpts    `command:"orphaned-env-disks"        description:"List, attach or delete persistent disks orphaned by delete-env"`
+	EnvStemcells     EnvStemcellsOpts     `command:"env-stemcells"             description:"List, export or import stemcells uploaded by create-env or delete the unused ones"`
+	PruneEnvState    PruneStateOpts       `command:"prune-env-state"           description:"Remove the records of VMs and disks that no longer exist in the IaaS from the deployment state"`
+	Agent            AgentOpts            `command:"agent"                     description:"Send a raw action to the agent of an environment (advanced)"`
+	EnvSSH           EnvSSHOpts           `command:"env-ssh"                   description:"SSH into the VM of an environment through a temporary user set up by its agent"`
+	EnvLogs          EnvLogsOpts          `command:"env-logs"                  description:"Download the job or agent logs of the VM of an environment"`
+	RotateCreds      RotateCredsOpts      `command:"rotate-creds"              description:"Replace the mbus password of an environment and recreate its VM with it"`
+	AliasEnv         AliasEnvOpts         `command:"alias-env"                 description:"Alias environment to save URL and CA certificate"`
+	AliasCreateEnv   AliasCreateEnvOpts   `command:"alias-create-env"          description:"Alias environment to save create-env manifest, variables and state files"`
 
 	// Authentication
 	LogIn  LogInOpts  `command:"log-in"  alias:"l" alias:"login"  description:"Log in"`
@@ -102,11 +126,12 @@ type BoshOpts struct {
 	RepackStemcell       RepackStemcellOpts         `command:"repack-stemcell"              description:"Repack stemcell"`
 
 	// Releases
-	Releases       ReleasesOpts       `command:"releases"        alias:"rs"   description:"List releases"`
-	UploadRelease  UploadReleaseOpts  `command:"upload-release"  alias:"ur"   description:"Upload release"`
-	ExportRelease  ExportReleaseOpts  `command:"export-release"               description:"Export the compiled release to a tarball"`
-	InspectRelease InspectReleaseOpts `command:"inspect-release"              description:"List release contents such as jobs"`
-	DeleteRelease  DeleteReleaseOpts  `command:"delete-release"  alias:"delr" description:"Delete release"`
+	Releases            ReleasesOpts              `command:"releases"        alias:"rs"   description:"List releases"`
+	UploadRelease       UploadReleaseOpts         `command:"upload-release"  alias:"ur"   description:"Upload release"`
+	ExportRelease       ExportReleaseOpts         `command:"export-release"               description:"Export the compiled release to a tarball"`
+	InspectRelease      InspectReleaseOpts        `command:"inspect-release"              description:"List release contents such as jobs"`
+	InspectLocalRelease InspectReleaseTarballOpts `command:"inspect-local-release"       description:"List jobs with their properties and packages of a release tarball without extracting it"`
+	DeleteRelease       DeleteReleaseOpts         `command:"delete-release"  alias:"delr" description:"Delete release"`
 
 	// Errands
 	Errands   ErrandsOpts   `command:"errands"    alias:"es" description:"List errands"`
@@ -181,33 +206,228 @@ type HelpOpts struct {
 // Original bosh-init
 
 type CreateEnvOpts struct {
-	Args CreateEnvArgs `positional-args:"true" required:"true"`
+	Args CreateEnvArgs `positional-args:"true"`
 	VarFlags
 	OpsFlags
-	SkipDrain               bool   `long:"skip-drain" description:"Skip running drain scripts"`
-	StatePath               string `long:"state" value-name:"PATH" description:"State file path"`
-	Recreate                bool   `long:"recreate" description:"Recreate VM in deployment"`
-	RecreatePersistentDisks bool   `long:"recreate-persistent-disks" description:"Recreate persistent disks in the deployment"`
+	SkipDrain               bool     `long:"skip-drain" description:"Skip running drain scripts"`
+	StatePath               string   `long:"state" value-name:"PATH" description:"State file path"`
+	Recreate                bool     `long:"recreate" description:"Recreate VM in deployment"`
+	RecreatePersistentDisks bool     `long:"recreate-persistent-disks" description:"Recreate persistent disks in the deployment"`
+	StemcellCID             string   `long:"stemcell-cid" value-name:"CID" description:"Use a stemcell already present in the IaaS instead of uploading the manifest stemcell"`
+	Stemcells               []string `long:"stemcell" value-name:"PATH|URL#SHA1" description:"Also upload this stemcell tarball, downloading it first when given a URL, resource pools can refer to it by stemcell name and version (can be used multiple times)"`
+	SkipAgentWait           bool     `long:"skip-agent-wait" description:"Skip waiting for the agent and everything that needs it (useful when mbus is unreachable)"`
+	SkipRunningWait         bool     `long:"skip-running-wait" description:"Skip waiting for jobs to be running"`
+	DryRun                  bool     `long:"dry-run" description:"Validate the manifest, releases and stemcells and show the CPI calls that would be made without making them"`
+	ResetPin                bool     `long:"reset-pin" description:"Forget the pinned agent certificate fingerprint and pin the certificate seen on next contact"`
+	CPIReleaseSHA1          string   `long:"cpi-release-sha1" value-name:"DIGEST" description:"Verify the CPI release tarball against this SHA1 or 'sha256:' prefixed digest before extracting it"`
+	StemcellSHA1            string   `long:"stemcell-sha1" value-name:"DIGEST" description:"Verify the manifest stemcell tarball against this SHA1 or 'sha256:' prefixed digest before extracting it"`
+	AdoptVMCID              string   `long:"adopt-vm-cid" value-name:"CID" description:"Record this existing VM in the deployment state before deploying, to recover an environment whose state file was lost"`
+	AdoptDiskCID            string   `long:"adopt-disk-cid" value-name:"CID" description:"Record this existing persistent disk in the deployment state before deploying and attach it to the environment VM"`
+	KeepExtractedArtifacts  bool     `long:"keep-extracted-artifacts" description:"Keep extracted releases and stemcells and rendered templates and print their locations (useful for debugging)"`
+	FailOnInlineSecrets     bool     `long:"fail-on-inline-secrets" description:"Fail instead of warning when the manifest contains secrets instead of variables"`
+	Outputs                 string   `long:"outputs" value-name:"PATH" description:"Write the interpolated outputs of the manifest as JSON to this file after deploying, '-' prints them"`
 	cmd
 }
 
 type CreateEnvArgs struct {
-	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+	Manifest EnvManifestArg `positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`
 }
 
 type DeleteEnvOpts struct {
-	Args DeleteEnvArgs `positional-args:"true" required:"true"`
+	Args DeleteEnvArgs `positional-args:"true"`
 	VarFlags
 	OpsFlags
-	SkipDrain bool   `long:"skip-drain" description:"Skip running drain scripts"`
-	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	SkipDrain   bool   `long:"skip-drain" description:"Skip running drain scripts"`
+	OrphanDisks bool   `long:"orphan-disks" description:"Keep persistent disks as orphaned disks instead of deleting them"`
+	DryRun      bool   `long:"dry-run" description:"Show the resources that would be deleted without deleting them"`
+	StatePath   string `long:"state" value-name:"PATH" description:"State file path"`
 	cmd
 }
 
 type DeleteEnvArgs struct {
+	Manifest EnvManifestArg `positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`
+}
+
+type ValidateEnvOpts struct {
+	Args ValidateEnvArgs `positional-args:"true"`
+	VarFlags
+	OpsFlags
+	FailOnInlineSecrets bool `long:"fail-on-inline-secrets" description:"Fail instead of warning when the manifest contains secrets instead of variables"`
+	cmd
+}
+
+type ValidateEnvArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`
+}
+
+type WatchOpts struct {
+	Args WatchArgs `positional-args:"true" required:"true"`
+
+	// -v is the global --version, the flags of other commands only get it
+	// through the embedded VarFlags
+	VarKVs    []boshtpl.VarKV `long:"var"                 value-name:"VAR=VALUE" description:"Set variable"`
+	VarsFiles []string        `long:"vars-file" short:"l" value-name:"PATH"      description:"Load variables from a YAML file"`
+	OpsFiles  []string        `long:"ops-file"  short:"o" value-name:"PATH"      description:"Load manifest operations from a YAML file"`
+
+	cmd
+}
+
+type WatchArgs struct {
+	Manifest string `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type EnvStatusOpts struct {
+	Args EnvStatusArgs `positional-args:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	Verbose   bool   `long:"verbose" description:"Show the state transitions of the instances during the last deploy"`
+	cmd
+}
+
+type EnvStatusArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`
+}
+
+type TestCpiOpts struct {
+	Args TestCpiArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	DiskSize int `long:"disk-size" value-name:"MB" description:"Size of the persistent disk to create and attach, 0 skips disk calls" default:"1024"`
+	cmd
+}
+
+type TestCpiArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type InitEnvOpts struct {
+	Directory DirOrCWDArg `long:"dir" description:"Environment directory path if not current working directory" default:"."`
+
+	CPI string `long:"cpi" value-name:"NAME" description:"CPI to generate the manifest for (aws, gcp, openstack, vsphere)" required:"true"`
+
+	cmd
+}
+
+type GenerateManifestOpts struct {
+	Args GenerateManifestArgs `positional-args:"true" required:"true"`
+
+	CPI string `long:"cpi" value-name:"NAME" description:"CPI to generate the manifest for (aws, gcp, openstack, vsphere)" required:"true"`
+
+	cmd
+}
+
+type GenerateManifestArgs struct {
+	CPIRelease FileArg `positional-arg-name:"PATH" description:"Path to the CPI release tarball"`
+}
+
+type OrphanedDisksOpts struct {
+	Args OrphanedDisksArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state"  value-name:"PATH" description:"State file path"`
+	Attach    string `long:"attach" value-name:"CID"  description:"Attach the orphaned disk on the next create-env"`
+	Delete    string `long:"delete" value-name:"CID"  description:"Delete the orphaned disk"`
+	cmd
+}
+
+type OrphanedDisksArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type EnvStemcellsOpts struct {
+	Args EnvStemcellsArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	Prune     bool   `long:"prune"                    description:"Delete the stemcells the environment VM does not use"`
+
+	Export FileArg      `long:"export" value-name:"PATH" description:"Write the stemcell records to a file for importing them on another workstation"`
+	Import FileBytesArg `long:"import" value-name:"PATH" description:"Record the stemcells of a file written with --export so that they are not uploaded again"`
+	cmd
+}
+
+type EnvStemcellsArgs struct {
 	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
 }
 
+type PruneStateOpts struct {
+	Args PruneStateArgs `positional-args:"true" required:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state"   value-name:"PATH" description:"State file path"`
+	DryRun    bool   `long:"dry-run"                   description:"Only list the records that would be removed"`
+	cmd
+}
+
+type PruneStateArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file"`
+}
+
+type AgentOpts struct {
+	Args AgentArgs `positional-args:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state"    value-name:"PATH" description:"State file path"`
+	Advanced  bool   `long:"advanced"                   description:"Confirm sending a raw action to the agent"`
+	cmd
+}
+
+type AgentArgs struct {
+	Manifest  FileBytesWithPathArg `positional-arg-name:"PATH"      description:"Path to a manifest file" required:"true"`
+	Action    string               `positional-arg-name:"ACTION"    description:"Agent action, e.g. get_state" required:"true"`
+	Arguments string               `positional-arg-name:"JSON-ARGS" description:"JSON array of action arguments (default: [])"`
+}
+
+type EnvSSHOpts struct {
+	Args EnvSSHArgs `positional-args:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+
+	Command []string         `long:"command" short:"c" description:"Command"`
+	RawOpts TrimmedSpaceArgs `long:"opts"              description:"Options to pass through to SSH"`
+
+	GatewayFlags
+
+	cmd
+}
+
+type EnvSSHArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`
+}
+
+type EnvLogsOpts struct {
+	Args EnvLogsArgs `positional-args:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+
+	Directory DirOrCWDArg `long:"dir" description:"Destination directory" default:"."`
+
+	Jobs    []string `long:"job"   description:"Limit to only specific jobs"`
+	Filters []string `long:"only"  description:"Filter logs (comma-separated)"`
+	Agent   bool     `long:"agent" description:"Include only agent logs"`
+
+	cmd
+}
+
+type EnvLogsArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`
+}
+
+type RotateCredsOpts struct {
+	Args RotateCredsArgs `positional-args:"true"`
+	VarFlags
+	OpsFlags
+	StatePath string `long:"state" value-name:"PATH" description:"State file path"`
+	cmd
+}
+
+type RotateCredsArgs struct {
+	Manifest FileBytesWithPathArg `positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`
+}
+
 // Environment
 
 type EnvironmentOpts struct {
@@ -215,6 +435,9 @@ type EnvironmentOpts struct {
 }
 
 type EnvironmentsOpts struct {
+	CreateEnv bool   `long:"create-env" description:"List environments saved with alias-create-env and their state files, marking the current one"`
+	Switch    string `long:"switch" value-name:"ALIAS" description:"Make an environment saved with alias-create-env the current one, used when neither a manifest nor --environment is given. Implies --create-env"`
+
 	cmd
 }
 
@@ -231,6 +454,17 @@ type AliasEnvArgs struct {
 	Alias string `positional-arg-name:"ALIAS" description:"Environment alias"`
 }
 
+type AliasCreateEnvOpts struct {
+	Args AliasEnvArgs `positional-args:"true" required:"true"`
+
+	Manifest  string   `long:"manifest"            value-name:"PATH" description:"Path to a manifest file" required:"true"`
+	VarsFiles []string `long:"vars-file" short:"l" value-name:"PATH" description:"Load variables from a YAML file"`
+	OpsFiles  []string `long:"ops-file"  short:"o" value-name:"PATH" description:"Load manifest operations from a YAML file"`
+	StatePath string   `long:"state"               value-name:"PATH" description:"State file path"`
+
+	cmd
+}
+
 type LogInOpts struct {
 	cmd
 }
@@ -615,6 +849,15 @@ type ExportReleaseArgs struct {
 	OSVersionSlug boshdir.OSVersionSlug `positional-arg-name:"OS/VERSION"`
 }
 
+type InspectReleaseTarballOpts struct {
+	Args InspectReleaseTarballArgs `positional-args:"true" required:"true"`
+	cmd
+}
+
+type InspectReleaseTarballArgs struct {
+	PathToRelease string `positional-arg-name:"PATH-TO-RELEASE" description:"Path to release tarball"`
+}
+
 type InspectReleaseOpts struct {
 	Args InspectReleaseArgs `positional-args:"true" required:"true"`
 	cmd
@@ -136,6 +136,38 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("MaxCPUs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("MaxCPUs", opts)).To(Equal(
+					`long:"max-cpus" value-name:"N" description:"The max number of CPUs to use, e.g. for extracting tarballs (default: all)" env:"BOSH_MAX_CPUS"`,
+				))
+			})
+		})
+
+		Describe("OTelEndpoint", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("OTelEndpoint", opts)).To(Equal(
+					`long:"otel-endpoint" description:"OTLP/HTTP endpoint to export tracing spans to" env:"BOSH_OTEL_ENDPOINT"`,
+				))
+			})
+		})
+
+		Describe("KeepaliveIntervalOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("KeepaliveIntervalOpt", opts)).To(Equal(
+					`long:"keepalive-interval" value-name:"DURATION" description:"Print progress of long running steps at this interval, e.g. 1m (default: disabled)" env:"BOSH_KEEPALIVE_INTERVAL"`,
+				))
+			})
+		})
+
+		Describe("CPIHungTimeoutOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CPIHungTimeoutOpt", opts)).To(Equal(
+					`long:"cpi-hung-timeout" value-name:"DURATION" description:"Warn when a CPI call writes no output for this long, e.g. 10m (default: disabled)" env:"BOSH_CPI_HUNG_TIMEOUT"`,
+				))
+			})
+		})
+
 		Describe("CACertOpt", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("CACertOpt", opts)).To(Equal(
@@ -208,6 +240,22 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("TTYLogOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("TTYLogOpt", opts)).To(Equal(
+					`long:"tty-log" value-name:"PATH" description:"Also write output with timestamps to a file"`,
+				))
+			})
+		})
+
+		Describe("EventLogOpt", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EventLogOpt", opts)).To(Equal(
+					`long:"event-log" value-name:"PATH" description:"Also write stage events with timestamps and durations as JSON lines to a file"`,
+				))
+			})
+		})
+
 		Describe("CreateEnv", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("CreateEnv", opts)).To(Equal(
@@ -224,6 +272,110 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("ValidateEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("ValidateEnv", opts)).To(Equal(
+					`command:"validate-env" description:"Validate an environment manifest without calling the CPI"`,
+				))
+			})
+		})
+
+		Describe("Watch", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Watch", opts)).To(Equal(
+					`command:"watch" description:"Validate an environment manifest again whenever it or its ops or vars files change"`,
+				))
+			})
+		})
+
+		Describe("EnvStatus", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvStatus", opts)).To(Equal(
+					`command:"env-status" description:"Show what the deployment state of a BOSH environment records as deployed"`,
+				))
+			})
+		})
+
+		Describe("TestCpi", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("TestCpi", opts)).To(Equal(
+					`command:"test-cpi" description:"Run a create and delete lifecycle against the CPI in a manifest"`,
+				))
+			})
+		})
+
+		Describe("InitEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("InitEnv", opts)).To(Equal(
+					`command:"init-env" description:"Initialize environment directory with a sample manifest"`,
+				))
+			})
+		})
+
+		Describe("GenerateManifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("GenerateManifest", opts)).To(Equal(
+					`command:"generate-manifest" description:"Print an environment manifest for a CPI release, asking for required CPI properties"`,
+				))
+			})
+		})
+
+		Describe("OrphanedEnvDisks", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("OrphanedEnvDisks", opts)).To(Equal(
+					`command:"orphaned-env-disks" description:"List, attach or delete persistent disks orphaned by delete-env"`,
+				))
+			})
+		})
+
+		Describe("EnvStemcells", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvStemcells", opts)).To(Equal(
+					`command:"env-stemcells" description:"List, export or import stemcells uploaded by create-env or delete the unused ones"`,
+				))
+			})
+		})
+
+		Describe("PruneEnvState", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("PruneEnvState", opts)).To(Equal(
+					`command:"prune-env-state" description:"Remove the records of VMs and disks that no longer exist in the IaaS from the deployment state"`,
+				))
+			})
+		})
+
+		Describe("Agent", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Agent", opts)).To(Equal(
+					`command:"agent" description:"Send a raw action to the agent of an environment (advanced)"`,
+				))
+			})
+		})
+
+		Describe("EnvSSH", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvSSH", opts)).To(Equal(
+					`command:"env-ssh" description:"SSH into the VM of an environment through a temporary user set up by its agent"`,
+				))
+			})
+		})
+
+		Describe("EnvLogs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("EnvLogs", opts)).To(Equal(
+					`command:"env-logs" description:"Download the job or agent logs of the VM of an environment"`,
+				))
+			})
+		})
+
+		Describe("RotateCreds", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RotateCreds", opts)).To(Equal(
+					`command:"rotate-creds" description:"Replace the mbus password of an environment and recreate its VM with it"`,
+				))
+			})
+		})
+
 		Describe("Environment", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("Environment", opts)).To(Equal(
@@ -240,6 +392,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("AliasCreateEnv", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("AliasCreateEnv", opts)).To(Equal(
+					`command:"alias-create-env" description:"Alias environment to save create-env manifest, variables and state files"`,
+				))
+			})
+		})
+
 		Describe("LogIn", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("LogIn", opts)).To(Equal(
@@ -496,6 +656,14 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
+		Describe("InspectLocalRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("InspectLocalRelease", opts)).To(Equal(
+					`command:"inspect-local-release" description:"List jobs with their properties and packages of a release tarball without extracting it"`,
+				))
+			})
+		})
+
 		Describe("InspectLocalStemcell", func() {
 			It("contains desired values", func() {
 				Expect(getStructTagForName("InspectLocalStemcell", opts)).To(Equal(
@@ -720,97 +888,557 @@ var _ = Describe("Opts", func() {
 			})
 		})
 
-		Describe("CreateRelease", func() {
+		Describe("CreateRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("CreateRelease", opts)).To(Equal(
+					`command:"create-release" alias:"cr" description:"Create release"`,
+				))
+			})
+		})
+
+		Describe("Sha2ifyRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Sha2ifyRelease", opts)).To(Equal(
+					`command:"sha2ify-release" hidden:"true" description:"Convert release tarball to use SHA256"`,
+				))
+			})
+		})
+
+		Describe("FinalizeRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("FinalizeRelease", opts)).To(Equal(
+					`command:"finalize-release" description:"Create final release from dev release tarball"`,
+				))
+			})
+		})
+
+		Describe("Blobs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Blobs", opts)).To(Equal(
+					`command:"blobs" description:"List blobs"`,
+				))
+			})
+		})
+
+		Describe("AddBlob", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("AddBlob", opts)).To(Equal(
+					`command:"add-blob" description:"Add blob"`,
+				))
+			})
+		})
+
+		Describe("RemoveBlob", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("RemoveBlob", opts)).To(Equal(
+					`command:"remove-blob" description:"Remove blob"`,
+				))
+			})
+		})
+
+		Describe("SyncBlobs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("SyncBlobs", opts)).To(Equal(
+					`command:"sync-blobs" description:"Sync blobs"`,
+				))
+			})
+		})
+
+		Describe("UploadBlobs", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("UploadBlobs", opts)).To(Equal(
+					`command:"upload-blobs" description:"Upload blobs"`,
+				))
+			})
+		})
+
+		Describe("AttachDisk", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("AttachDisk", opts)).To(Equal(
+					`command:"attach-disk" description:"Attach disk to an instance"`,
+				))
+			})
+		})
+
+		Describe("Curl", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Curl", opts)).To(Equal(
+					`command:"curl" description:"Make an HTTP request to the Director" hidden:"true"`,
+				))
+			})
+		})
+	})
+
+	Describe("CreateEnvOpts", func() {
+		var opts *CreateEnvOpts
+
+		BeforeEach(func() {
+			opts = &CreateEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+
+		It("has --recreate", func() {
+			Expect(getStructTagForName("Recreate", opts)).To(Equal(
+				`long:"recreate" description:"Recreate VM in deployment"`,
+			))
+		})
+
+		It("has --recreate-persistent-disks", func() {
+			Expect(getStructTagForName("RecreatePersistentDisks", opts)).To(Equal(
+				`long:"recreate-persistent-disks" description:"Recreate persistent disks in the deployment"`,
+			))
+		})
+
+		It("has --stemcell-cid", func() {
+			Expect(getStructTagForName("StemcellCID", opts)).To(Equal(
+				`long:"stemcell-cid" value-name:"CID" description:"Use a stemcell already present in the IaaS instead of uploading the manifest stemcell"`,
+			))
+		})
+
+		It("has --stemcell", func() {
+			Expect(getStructTagForName("Stemcells", opts)).To(Equal(
+				`long:"stemcell" value-name:"PATH|URL#SHA1" description:"Also upload this stemcell tarball, downloading it first when given a URL, resource pools can refer to it by stemcell name and version (can be used multiple times)"`,
+			))
+		})
+
+		It("has --skip-agent-wait", func() {
+			Expect(getStructTagForName("SkipAgentWait", opts)).To(Equal(
+				`long:"skip-agent-wait" description:"Skip waiting for the agent and everything that needs it (useful when mbus is unreachable)"`,
+			))
+		})
+
+		It("has --skip-running-wait", func() {
+			Expect(getStructTagForName("SkipRunningWait", opts)).To(Equal(
+				`long:"skip-running-wait" description:"Skip waiting for jobs to be running"`,
+			))
+		})
+
+		It("has --dry-run", func() {
+			Expect(getStructTagForName("DryRun", opts)).To(Equal(
+				`long:"dry-run" description:"Validate the manifest, releases and stemcells and show the CPI calls that would be made without making them"`,
+			))
+		})
+
+		It("has --cpi-release-sha1", func() {
+			Expect(getStructTagForName("CPIReleaseSHA1", opts)).To(Equal(
+				`long:"cpi-release-sha1" value-name:"DIGEST" description:"Verify the CPI release tarball against this SHA1 or 'sha256:' prefixed digest before extracting it"`,
+			))
+		})
+
+		It("has --stemcell-sha1", func() {
+			Expect(getStructTagForName("StemcellSHA1", opts)).To(Equal(
+				`long:"stemcell-sha1" value-name:"DIGEST" description:"Verify the manifest stemcell tarball against this SHA1 or 'sha256:' prefixed digest before extracting it"`,
+			))
+		})
+
+		It("has --adopt-vm-cid", func() {
+			Expect(getStructTagForName("AdoptVMCID", opts)).To(Equal(
+				`long:"adopt-vm-cid" value-name:"CID" description:"Record this existing VM in the deployment state before deploying, to recover an environment whose state file was lost"`,
+			))
+		})
+
+		It("has --adopt-disk-cid", func() {
+			Expect(getStructTagForName("AdoptDiskCID", opts)).To(Equal(
+				`long:"adopt-disk-cid" value-name:"CID" description:"Record this existing persistent disk in the deployment state before deploying and attach it to the environment VM"`,
+			))
+		})
+
+		It("has --keep-extracted-artifacts", func() {
+			Expect(getStructTagForName("KeepExtractedArtifacts", opts)).To(Equal(
+				`long:"keep-extracted-artifacts" description:"Keep extracted releases and stemcells and rendered templates and print their locations (useful for debugging)"`,
+			))
+		})
+
+		It("has --fail-on-inline-secrets", func() {
+			Expect(getStructTagForName("FailOnInlineSecrets", opts)).To(Equal(
+				`long:"fail-on-inline-secrets" description:"Fail instead of warning when the manifest contains secrets instead of variables"`,
+			))
+		})
+
+		It("has --outputs", func() {
+			Expect(getStructTagForName("Outputs", opts)).To(Equal(
+				`long:"outputs" value-name:"PATH" description:"Write the interpolated outputs of the manifest as JSON to this file after deploying, '-' prints them"`,
+			))
+		})
+
+		It("has --reset-pin", func() {
+			Expect(getStructTagForName("ResetPin", opts)).To(Equal(
+				`long:"reset-pin" description:"Forget the pinned agent certificate fingerprint and pin the certificate seen on next contact"`,
+			))
+		})
+
+		It("has --skip-drain", func() {
+			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
+				`long:"skip-drain" description:"Skip running drain scripts"`,
+			))
+		})
+	})
+
+	Describe("CreateEnvArgs", func() {
+		var args *CreateEnvArgs
+
+		BeforeEach(func() {
+			args = &CreateEnvArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", args)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`,
+				))
+			})
+		})
+	})
+
+	Describe("DeleteEnvOpts", func() {
+		var opts *DeleteEnvOpts
+
+		BeforeEach(func() {
+			opts = &DeleteEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+
+		It("has --skip-drain", func() {
+			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
+				`long:"skip-drain" description:"Skip running drain scripts"`,
+			))
+		})
+
+		It("has --orphan-disks", func() {
+			Expect(getStructTagForName("OrphanDisks", opts)).To(Equal(
+				`long:"orphan-disks" description:"Keep persistent disks as orphaned disks instead of deleting them"`,
+			))
+		})
+
+		It("has --dry-run", func() {
+			Expect(getStructTagForName("DryRun", opts)).To(Equal(
+				`long:"dry-run" description:"Show the resources that would be deleted without deleting them"`,
+			))
+		})
+	})
+
+	Describe("DeleteEnvArgs", func() {
+		var args *DeleteEnvArgs
+
+		BeforeEach(func() {
+			args = &DeleteEnvArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", args)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`,
+				))
+			})
+		})
+	})
+
+	Describe("ValidateEnvOpts", func() {
+		var opts *ValidateEnvOpts
+
+		BeforeEach(func() {
+			opts = &ValidateEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
+			})
+		})
+
+		It("has --fail-on-inline-secrets", func() {
+			Expect(getStructTagForName("FailOnInlineSecrets", opts)).To(Equal(
+				`long:"fail-on-inline-secrets" description:"Fail instead of warning when the manifest contains secrets instead of variables"`,
+			))
+		})
+	})
+
+	Describe("ValidateEnvArgs", func() {
+		var args *ValidateEnvArgs
+
+		BeforeEach(func() {
+			args = &ValidateEnvArgs{}
+		})
+
+		Describe("Manifest", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Manifest", args)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to a manifest file (defaults to the manifest of --environment)"`,
+				))
+			})
+		})
+	})
+
+	Describe("EnvStatusOpts", func() {
+		var opts *EnvStatusOpts
+
+		BeforeEach(func() {
+			opts = &EnvStatusOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
+			})
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+
+		It("has --verbose", func() {
+			Expect(getStructTagForName("Verbose", opts)).To(Equal(
+				`long:"verbose" description:"Show the state transitions of the instances during the last deploy"`,
+			))
+		})
+	})
+
+	Describe("TestCpiOpts", func() {
+		var opts *TestCpiOpts
+
+		BeforeEach(func() {
+			opts = &TestCpiOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --disk-size", func() {
+			Expect(getStructTagForName("DiskSize", opts)).To(Equal(
+				`long:"disk-size" value-name:"MB" description:"Size of the persistent disk to create and attach, 0 skips disk calls" default:"1024"`,
+			))
+		})
+	})
+
+	Describe("InitEnvOpts", func() {
+		var opts *InitEnvOpts
+
+		BeforeEach(func() {
+			opts = &InitEnvOpts{}
+		})
+
+		Describe("Directory", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("CreateRelease", opts)).To(Equal(
-					`command:"create-release" alias:"cr" description:"Create release"`,
+				Expect(getStructTagForName("Directory", opts)).To(Equal(
+					`long:"dir" description:"Environment directory path if not current working directory" default:"."`,
 				))
 			})
 		})
 
-		Describe("Sha2ifyRelease", func() {
+		Describe("CPI", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Sha2ifyRelease", opts)).To(Equal(
-					`command:"sha2ify-release" hidden:"true" description:"Convert release tarball to use SHA256"`,
+				Expect(getStructTagForName("CPI", opts)).To(Equal(
+					`long:"cpi" value-name:"NAME" description:"CPI to generate the manifest for (aws, gcp, openstack, vsphere)" required:"true"`,
 				))
 			})
 		})
+	})
 
-		Describe("FinalizeRelease", func() {
-			It("contains desired values", func() {
-				Expect(getStructTagForName("FinalizeRelease", opts)).To(Equal(
-					`command:"finalize-release" description:"Create final release from dev release tarball"`,
-				))
-			})
+	Describe("GenerateManifestOpts", func() {
+		var opts *GenerateManifestOpts
+
+		BeforeEach(func() {
+			opts = &GenerateManifestOpts{}
 		})
 
-		Describe("Blobs", func() {
+		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Blobs", opts)).To(Equal(
-					`command:"blobs" description:"List blobs"`,
+				Expect(getStructTagForName("Args", opts)).To(Equal(
+					`positional-args:"true" required:"true"`,
 				))
 			})
 		})
 
-		Describe("AddBlob", func() {
+		Describe("CPI", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("AddBlob", opts)).To(Equal(
-					`command:"add-blob" description:"Add blob"`,
+				Expect(getStructTagForName("CPI", opts)).To(Equal(
+					`long:"cpi" value-name:"NAME" description:"CPI to generate the manifest for (aws, gcp, openstack, vsphere)" required:"true"`,
 				))
 			})
 		})
+	})
 
-		Describe("RemoveBlob", func() {
+	Describe("GenerateManifestArgs", func() {
+		var opts *GenerateManifestArgs
+
+		BeforeEach(func() {
+			opts = &GenerateManifestArgs{}
+		})
+
+		Describe("CPIRelease", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("RemoveBlob", opts)).To(Equal(
-					`command:"remove-blob" description:"Remove blob"`,
+				Expect(getStructTagForName("CPIRelease", opts)).To(Equal(
+					`positional-arg-name:"PATH" description:"Path to the CPI release tarball"`,
 				))
 			})
 		})
+	})
 
-		Describe("SyncBlobs", func() {
+	Describe("WatchOpts", func() {
+		var opts *WatchOpts
+
+		BeforeEach(func() {
+			opts = &WatchOpts{}
+		})
+
+		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("SyncBlobs", opts)).To(Equal(
-					`command:"sync-blobs" description:"Sync blobs"`,
-				))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
 			})
 		})
 
-		Describe("UploadBlobs", func() {
+		It("has --var", func() {
+			Expect(getStructTagForName("VarKVs", opts)).To(Equal(
+				`long:"var" value-name:"VAR=VALUE" description:"Set variable"`,
+			))
+		})
+
+		It("has --vars-file", func() {
+			Expect(getStructTagForName("VarsFiles", opts)).To(Equal(
+				`long:"vars-file" short:"l" value-name:"PATH" description:"Load variables from a YAML file"`,
+			))
+		})
+
+		It("has --ops-file", func() {
+			Expect(getStructTagForName("OpsFiles", opts)).To(Equal(
+				`long:"ops-file" short:"o" value-name:"PATH" description:"Load manifest operations from a YAML file"`,
+			))
+		})
+	})
+
+	Describe("OrphanedDisksOpts", func() {
+		var opts *OrphanedDisksOpts
+
+		BeforeEach(func() {
+			opts = &OrphanedDisksOpts{}
+		})
+
+		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("UploadBlobs", opts)).To(Equal(
-					`command:"upload-blobs" description:"Upload blobs"`,
-				))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
 			})
 		})
 
-		Describe("AttachDisk", func() {
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+
+		It("has --attach", func() {
+			Expect(getStructTagForName("Attach", opts)).To(Equal(
+				`long:"attach" value-name:"CID" description:"Attach the orphaned disk on the next create-env"`,
+			))
+		})
+
+		It("has --delete", func() {
+			Expect(getStructTagForName("Delete", opts)).To(Equal(
+				`long:"delete" value-name:"CID" description:"Delete the orphaned disk"`,
+			))
+		})
+	})
+
+	Describe("EnvStemcellsOpts", func() {
+		var opts *EnvStemcellsOpts
+
+		BeforeEach(func() {
+			opts = &EnvStemcellsOpts{}
+		})
+
+		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("AttachDisk", opts)).To(Equal(
-					`command:"attach-disk" description:"Attach disk to an instance"`,
-				))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
 			})
 		})
 
-		Describe("Curl", func() {
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+
+		It("has --prune", func() {
+			Expect(getStructTagForName("Prune", opts)).To(Equal(
+				`long:"prune" description:"Delete the stemcells the environment VM does not use"`,
+			))
+		})
+
+		It("has --export", func() {
+			Expect(getStructTagForName("Export", opts)).To(Equal(
+				`long:"export" value-name:"PATH" description:"Write the stemcell records to a file for importing them on another workstation"`,
+			))
+		})
+
+		It("has --import", func() {
+			Expect(getStructTagForName("Import", opts)).To(Equal(
+				`long:"import" value-name:"PATH" description:"Record the stemcells of a file written with --export so that they are not uploaded again"`,
+			))
+		})
+	})
+
+	Describe("PruneStateOpts", func() {
+		var opts *PruneStateOpts
+
+		BeforeEach(func() {
+			opts = &PruneStateOpts{}
+		})
+
+		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Curl", opts)).To(Equal(
-					`command:"curl" description:"Make an HTTP request to the Director" hidden:"true"`,
-				))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
 			})
 		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+
+		It("has --dry-run", func() {
+			Expect(getStructTagForName("DryRun", opts)).To(Equal(
+				`long:"dry-run" description:"Only list the records that would be removed"`,
+			))
+		})
 	})
 
-	Describe("CreateEnvOpts", func() {
-		var opts *CreateEnvOpts
+	Describe("AgentOpts", func() {
+		var opts *AgentOpts
 
 		BeforeEach(func() {
-			opts = &CreateEnvOpts{}
+			opts = &AgentOpts{}
 		})
 
 		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
 			})
 		})
 
@@ -820,51 +1448,78 @@ var _ = Describe("Opts", func() {
 			))
 		})
 
-		It("has --recreate", func() {
-			Expect(getStructTagForName("Recreate", opts)).To(Equal(
-				`long:"recreate" description:"Recreate VM in deployment"`,
+		It("has --advanced", func() {
+			Expect(getStructTagForName("Advanced", opts)).To(Equal(
+				`long:"advanced" description:"Confirm sending a raw action to the agent"`,
 			))
 		})
+	})
 
-		It("has --recreate-persistent-disks", func() {
-			Expect(getStructTagForName("RecreatePersistentDisks", opts)).To(Equal(
-				`long:"recreate-persistent-disks" description:"Recreate persistent disks in the deployment"`,
+	Describe("AgentArgs", func() {
+		var opts *AgentArgs
+
+		BeforeEach(func() {
+			opts = &AgentArgs{}
+		})
+
+		It("requires the manifest and the action", func() {
+			Expect(getStructTagForName("Manifest", opts)).To(Equal(
+				`positional-arg-name:"PATH" description:"Path to a manifest file" required:"true"`,
+			))
+			Expect(getStructTagForName("Action", opts)).To(Equal(
+				`positional-arg-name:"ACTION" description:"Agent action, e.g. get_state" required:"true"`,
 			))
 		})
 
-		It("has --skip-drain", func() {
-			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
-				`long:"skip-drain" description:"Skip running drain scripts"`,
+		It("has optional action arguments", func() {
+			Expect(getStructTagForName("Arguments", opts)).To(Equal(
+				`positional-arg-name:"JSON-ARGS" description:"JSON array of action arguments (default: [])"`,
 			))
 		})
 	})
 
-	Describe("CreateEnvArgs", func() {
-		var args *CreateEnvArgs
+	Describe("EnvSSHOpts", func() {
+		var opts *EnvSSHOpts
 
 		BeforeEach(func() {
-			args = &CreateEnvArgs{}
+			opts = &EnvSSHOpts{}
 		})
 
-		Describe("Manifest", func() {
+		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Manifest", args)).To(Equal(
-					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
-				))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
 			})
 		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+
+		It("has --command", func() {
+			Expect(getStructTagForName("Command", opts)).To(Equal(
+				`long:"command" short:"c" description:"Command"`,
+			))
+		})
+
+		It("has --opts", func() {
+			Expect(getStructTagForName("RawOpts", opts)).To(Equal(
+				`long:"opts" description:"Options to pass through to SSH"`,
+			))
+		})
 	})
 
-	Describe("DeleteEnvOpts", func() {
-		var opts *DeleteEnvOpts
+	Describe("EnvLogsOpts", func() {
+		var opts *EnvLogsOpts
 
 		BeforeEach(func() {
-			opts = &DeleteEnvOpts{}
+			opts = &EnvLogsOpts{}
 		})
 
 		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
 			})
 		})
 
@@ -874,27 +1529,49 @@ var _ = Describe("Opts", func() {
 			))
 		})
 
-		It("has --skip-drain", func() {
-			Expect(getStructTagForName("SkipDrain", opts)).To(Equal(
-				`long:"skip-drain" description:"Skip running drain scripts"`,
+		It("has --dir", func() {
+			Expect(getStructTagForName("Directory", opts)).To(Equal(
+				`long:"dir" description:"Destination directory" default:"."`,
+			))
+		})
+
+		It("has --job", func() {
+			Expect(getStructTagForName("Jobs", opts)).To(Equal(
+				`long:"job" description:"Limit to only specific jobs"`,
+			))
+		})
+
+		It("has --only", func() {
+			Expect(getStructTagForName("Filters", opts)).To(Equal(
+				`long:"only" description:"Filter logs (comma-separated)"`,
+			))
+		})
+
+		It("has --agent", func() {
+			Expect(getStructTagForName("Agent", opts)).To(Equal(
+				`long:"agent" description:"Include only agent logs"`,
 			))
 		})
 	})
 
-	Describe("DeleteEnvArgs", func() {
-		var args *DeleteEnvArgs
+	Describe("RotateCredsOpts", func() {
+		var opts *RotateCredsOpts
 
 		BeforeEach(func() {
-			args = &DeleteEnvArgs{}
+			opts = &RotateCredsOpts{}
 		})
 
-		Describe("Manifest", func() {
+		Describe("Args", func() {
 			It("contains desired values", func() {
-				Expect(getStructTagForName("Manifest", args)).To(Equal(
-					`positional-arg-name:"PATH" description:"Path to a manifest file"`,
-				))
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true"`))
 			})
 		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
 	})
 
 	Describe("AliasEnvOpts", func() {
@@ -911,6 +1588,64 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("EnvironmentsOpts", func() {
+		var opts *EnvironmentsOpts
+
+		BeforeEach(func() {
+			opts = &EnvironmentsOpts{}
+		})
+
+		It("has --create-env", func() {
+			Expect(getStructTagForName("CreateEnv", opts)).To(Equal(
+				`long:"create-env" description:"List environments saved with alias-create-env and their state files, marking the current one"`,
+			))
+		})
+
+		It("has --switch", func() {
+			Expect(getStructTagForName("Switch", opts)).To(Equal(
+				`long:"switch" value-name:"ALIAS" description:"Make an environment saved with alias-create-env the current one, used when neither a manifest nor --environment is given. Implies --create-env"`,
+			))
+		})
+	})
+
+	Describe("AliasCreateEnvOpts", func() {
+		var opts *AliasCreateEnvOpts
+
+		BeforeEach(func() {
+			opts = &AliasCreateEnvOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+
+		It("has --manifest", func() {
+			Expect(getStructTagForName("Manifest", opts)).To(Equal(
+				`long:"manifest" value-name:"PATH" description:"Path to a manifest file" required:"true"`,
+			))
+		})
+
+		It("has --vars-file", func() {
+			Expect(getStructTagForName("VarsFiles", opts)).To(Equal(
+				`long:"vars-file" short:"l" value-name:"PATH" description:"Load variables from a YAML file"`,
+			))
+		})
+
+		It("has --ops-file", func() {
+			Expect(getStructTagForName("OpsFiles", opts)).To(Equal(
+				`long:"ops-file" short:"o" value-name:"PATH" description:"Load manifest operations from a YAML file"`,
+			))
+		})
+
+		It("has --state", func() {
+			Expect(getStructTagForName("StatePath", opts)).To(Equal(
+				`long:"state" value-name:"PATH" description:"State file path"`,
+			))
+		})
+	})
+
 	Describe("AliasEnvArgs", func() {
 		var args *AliasEnvArgs
 
@@ -1856,6 +2591,36 @@ var _ = Describe("Opts", func() {
 		})
 	})
 
+	Describe("InspectReleaseTarballOpts", func() {
+		var opts *InspectReleaseTarballOpts
+
+		BeforeEach(func() {
+			opts = &InspectReleaseTarballOpts{}
+		})
+
+		Describe("Args", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("Args", opts)).To(Equal(`positional-args:"true" required:"true"`))
+			})
+		})
+	})
+
+	Describe("InspectReleaseTarballArgs", func() {
+		var opts *InspectReleaseTarballArgs
+
+		BeforeEach(func() {
+			opts = &InspectReleaseTarballArgs{}
+		})
+
+		Describe("PathToRelease", func() {
+			It("contains desired values", func() {
+				Expect(getStructTagForName("PathToRelease", opts)).To(Equal(
+					`positional-arg-name:"PATH-TO-RELEASE" description:"Path to release tarball"`,
+				))
+			})
+		})
+	})
+
 	Describe("InspectReleaseArgs", func() {
 		var opts *InspectReleaseArgs
 
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type OrphanedDisksCmd struct {
+	ui           boshui.UI
+	envProvider  func(string, string, boshtpl.Variables, patch.Op) OrphanedDisksManager
+	confirmation DestructiveConfirmation
+}
+
+func NewOrphanedDisksCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) OrphanedDisksManager, confirmation DestructiveConfirmation) *OrphanedDisksCmd {
+	return &OrphanedDisksCmd{ui: ui, envProvider: envProvider, confirmation: confirmation}
+}
+
+func (c *OrphanedDisksCmd) Run(stage boshui.Stage, opts OrphanedDisksOpts) error {
+	manager := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	switch {
+	case len(opts.Delete) > 0:
+		err := c.confirmation.ConfirmDeletion(DestructiveDeleteOrphanedDisks)
+		if err != nil {
+			return err
+		}
+
+		return manager.Delete(opts.Delete, stage)
+
+	case len(opts.Attach) > 0:
+		err := manager.Attach(opts.Attach)
+		if err != nil {
+			return err
+		}
+
+		c.ui.BeginLinef("Disk '%s' will be attached by the next create-env\n", opts.Attach)
+		return nil
+	}
+
+	orphanedDisks, err := manager.List()
+	if err != nil {
+		return err
+	}
+
+	table := boshtbl.Table{
+		Content: "orphaned disks",
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Disk CID"),
+			boshtbl.NewHeader("Size"),
+			boshtbl.NewHeader("Orphaned At"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{{Column: 2, Asc: true}},
+	}
+
+	for _, disk := range orphanedDisks {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(disk.CID),
+			boshtbl.NewValueMegaBytes(uint64(disk.Size)),
+			boshtbl.NewValueTime(disk.OrphanedAt),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type OrphanedDisksManager interface {
+	List() ([]biconfig.OrphanedDiskRecord, error)
+	// Attach makes the orphaned disk the current disk so that the next
+	// create-env attaches it to the new VM.
+	Attach(cid string) error
+	Delete(cid string, stage biui.Stage) error
+}
+
+func NewOrphanedDisksManager(
+	logTag string,
+	logger boshlog.Logger,
+	timeService clock.Clock,
+	deploymentStateService biconfig.DeploymentStateService,
+	diskRepo biconfig.DiskRepo,
+	releaseManager biinstall.ReleaseManager,
+	cloudFactory bicloud.Factory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	cpiInstaller bicpirel.CpiInstaller,
+	cpiUninstaller biinstall.Uninstaller,
+	releaseFetcher biinstall.ReleaseFetcher,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	tempRootConfigurator TempRootConfigurator,
+	targetProvider biinstall.TargetProvider,
+	attempts *biui.Attempts,
+) OrphanedDisksManager {
+	return &orphanedDisksManager{
+		deploymentStateService: deploymentStateService,
+		diskRepo:               diskRepo,
+		envCloud: envCloud{
+			logTag:                                  logTag,
+			logger:                                  logger,
+			timeService:                             timeService,
+			deploymentStateService:                  deploymentStateService,
+			releaseManager:                          releaseManager,
+			cloudFactory:                            cloudFactory,
+			deploymentManifestPath:                  deploymentManifestPath,
+			deploymentVars:                          deploymentVars,
+			deploymentOp:                            deploymentOp,
+			cpiInstaller:                            cpiInstaller,
+			cpiUninstaller:                          cpiUninstaller,
+			releaseFetcher:                          releaseFetcher,
+			releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+			tempRootConfigurator:                    tempRootConfigurator,
+			targetProvider:                          targetProvider,
+			attempts:                                attempts,
+		},
+	}
+}
+
+type orphanedDisksManager struct {
+	deploymentStateService biconfig.DeploymentStateService
+	diskRepo               biconfig.DiskRepo
+	envCloud               envCloud
+}
+
+func (m *orphanedDisksManager) List() ([]biconfig.OrphanedDiskRecord, error) {
+	if !m.deploymentStateService.Exists() {
+		return []biconfig.OrphanedDiskRecord{}, nil
+	}
+
+	return m.diskRepo.AllOrphaned()
+}
+
+func (m *orphanedDisksManager) Attach(cid string) error {
+	orphanedDisk, err := m.findOrphaned(cid)
+	if err != nil {
+		return err
+	}
+
+	currentDisk, found, err := m.diskRepo.FindCurrent()
+	if err != nil {
+		return bosherr.WrapError(err, "Finding current disk")
+	}
+
+	if found {
+		// The current disk would otherwise be cleaned up as unused by the next deploy
+		return bosherr.Errorf("Expected no current disk, but found disk '%s'; delete the environment with --orphan-disks first", currentDisk.CID)
+	}
+
+	_, err = m.diskRepo.AdoptOrphaned(orphanedDisk)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Attaching orphaned disk '%s'", cid)
+	}
+
+	return nil
+}
+
+func (m *orphanedDisksManager) Delete(cid string, stage biui.Stage) error {
+	orphanedDisk, err := m.findOrphaned(cid)
+	if err != nil {
+		return err
+	}
+
+	return m.envCloud.Run(stage, func(cloud bicloud.Cloud) error {
+		return stage.Perform("Deleting orphaned disk '"+cid+"'", func() error {
+			err := cloud.DeleteDisk(cid)
+			if cloudErr, ok := err.(bicloud.Error); ok && cloudErr.Type() == bicloud.DiskNotFoundError {
+				err = nil
+			}
+			if err != nil {
+				return bosherr.WrapError(err, "Deleting disk in the cloud")
+			}
+
+			return m.diskRepo.DeleteOrphaned(orphanedDisk)
+		})
+	})
+}
+
+func (m *orphanedDisksManager) findOrphaned(cid string) (biconfig.OrphanedDiskRecord, error) {
+	if !m.deploymentStateService.Exists() {
+		return biconfig.OrphanedDiskRecord{}, bosherr.Errorf("Deployment state '%s' does not exist", m.deploymentStateService.Path())
+	}
+
+	orphanedDisk, found, err := m.diskRepo.FindOrphaned(cid)
+	if err != nil {
+		return biconfig.OrphanedDiskRecord{}, bosherr.WrapError(err, "Finding orphaned disk")
+	}
+
+	if !found {
+		return biconfig.OrphanedDiskRecord{}, bosherr.Errorf("Orphaned disk '%s' not found", cid)
+	}
+
+	return orphanedDisk, nil
+}
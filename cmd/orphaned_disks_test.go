@@ -0,0 +1,124 @@
+package cmd_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("OrphanedDisksCmd", func() {
+	var (
+		mockCtrl                 *gomock.Controller
+		mockOrphanedDisksManager *mock_cmd.MockOrphanedDisksManager
+		fakeUI                   *fakeui.FakeUI
+		fakeStage                *fakeui.FakeStage
+		opts                     OrphanedDisksOpts
+		confirmationPolicy       cmdconf.ConfirmationPolicy
+		command                  *OrphanedDisksCmd
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockOrphanedDisksManager = mock_cmd.NewMockOrphanedDisksManager(mockCtrl)
+		fakeUI = &fakeui.FakeUI{}
+		fakeStage = fakeui.NewFakeStage()
+		confirmationPolicy = cmdconf.ConfirmationPolicy{}
+
+		opts = OrphanedDisksOpts{
+			Args:      OrphanedDisksArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+			StatePath: "/fake-state.json",
+		}
+
+		envProvider := func(manifestPath, statePath string, vars boshtpl.Variables, op patch.Op) OrphanedDisksManager {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			Expect(statePath).To(Equal("/fake-state.json"))
+			return mockOrphanedDisksManager
+		}
+
+		command = NewOrphanedDisksCmd(fakeUI, envProvider, NewDestructiveConfirmation(fakeUI, confirmationPolicy))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("lists orphaned disks", func() {
+		orphanedAt := time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+		mockOrphanedDisksManager.EXPECT().List().Return([]biconfig.OrphanedDiskRecord{
+			{DiskRecord: biconfig.DiskRecord{CID: "fake-disk-cid", Size: 1024}, OrphanedAt: orphanedAt},
+		}, nil)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Table.Rows).To(Equal([][]boshtbl.Value{
+			{
+				boshtbl.NewValueString("fake-disk-cid"),
+				boshtbl.NewValueMegaBytes(1024),
+				boshtbl.NewValueTime(orphanedAt),
+			},
+		}))
+	})
+
+	It("attaches the orphaned disk on the next deploy", func() {
+		opts.Attach = "fake-disk-cid"
+		mockOrphanedDisksManager.EXPECT().Attach("fake-disk-cid").Return(nil)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fakeUI.Said).To(ContainElement("Disk 'fake-disk-cid' will be attached by the next create-env\n"))
+	})
+
+	It("deletes the orphaned disk", func() {
+		opts.Delete = "fake-disk-cid"
+		mockOrphanedDisksManager.EXPECT().Delete("fake-disk-cid", fakeStage).Return(errors.New("fake-err"))
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(MatchError("fake-err"))
+	})
+
+	It("asks for confirmation before deleting the orphaned disk", func() {
+		opts.Delete = "fake-disk-cid"
+		fakeUI.AskedConfirmationErr = errors.New("fake-stopped")
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(MatchError("fake-stopped"))
+		Expect(fakeUI.AskedConfirmationCalled).To(BeTrue())
+	})
+
+	Context("when the confirmation policy requires confirming orphaned disk deletion", func() {
+		BeforeEach(func() {
+			confirmationPolicy = cmdconf.ConfirmationPolicy{Operations: []string{"delete-orphaned-disks"}}
+			command = NewOrphanedDisksCmd(fakeUI, func(string, string, boshtpl.Variables, patch.Op) OrphanedDisksManager {
+				return mockOrphanedDisksManager
+			}, NewDestructiveConfirmation(fakeUI, confirmationPolicy))
+			opts.Delete = "fake-disk-cid"
+		})
+
+		It("does not delete the disk when input is non-interactive", func() {
+			err := command.Run(fakeStage, opts)
+			Expect(err).To(MatchError("Confirmation policy requires confirming 'delete-orphaned-disks', but input is non-interactive"))
+		})
+
+		It("deletes the disk once confirmed", func() {
+			fakeUI.Interactive = true
+			mockOrphanedDisksManager.EXPECT().Delete("fake-disk-cid", fakeStage).Return(nil)
+
+			err := command.Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeUI.AskedConfirmationCalled).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type PruneStateCmd struct {
+	ui          boshui.UI
+	envProvider func(string, string, boshtpl.Variables, patch.Op) PruneStateManager
+}
+
+func NewPruneStateCmd(ui boshui.UI, envProvider func(string, string, boshtpl.Variables, patch.Op) PruneStateManager) *PruneStateCmd {
+	return &PruneStateCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *PruneStateCmd) Run(stage boshui.Stage, opts PruneStateOpts) error {
+	manager := c.envProvider(
+		opts.Args.Manifest.Path, opts.StatePath, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	report, err := manager.Prune(stage, opts.DryRun)
+	if err != nil {
+		return err
+	}
+
+	content := "pruned records"
+	if opts.DryRun {
+		content = "records to prune"
+	}
+
+	table := boshtbl.Table{
+		Content: content,
+
+		Header: []boshtbl.Header{
+			boshtbl.NewHeader("Type"),
+			boshtbl.NewHeader("CID"),
+		},
+
+		SortBy: []boshtbl.ColumnSort{
+			{Column: 0, Asc: true},
+			{Column: 1, Asc: true},
+		},
+	}
+
+	if len(report.Unverified) > 0 {
+		table.Notes = []string{"Records of type " + strings.Join(report.Unverified, ", ") + " cannot be verified through the CPI and were kept"}
+	}
+
+	for _, record := range report.Pruned {
+		table.Rows = append(table.Rows, []boshtbl.Value{
+			boshtbl.NewValueString(record.Type),
+			boshtbl.NewValueString(record.CID),
+		})
+	}
+
+	c.ui.PrintTable(table)
+
+	return nil
+}
@@ -0,0 +1,238 @@
+package cmd
+
+import (
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/cppforlife/go-patch/patch"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// PrunedRecord is a record of the deployment state whose IaaS resource the
+// CPI no longer finds
+type PrunedRecord struct {
+	Type string
+	CID  string
+}
+
+// PruneReport lists the pruned records and the types of records the CPI
+// cannot verify, which are kept
+type PruneReport struct {
+	Pruned     []PrunedRecord
+	Unverified []string
+}
+
+type PruneStateManager interface {
+	// Prune removes the records of VMs and disks the CPI no longer finds
+	// from the deployment state, or only reports them when dryRun is set
+	Prune(stage biui.Stage, dryRun bool) (PruneReport, error)
+}
+
+func NewPruneStateManager(
+	logTag string,
+	logger boshlog.Logger,
+	timeService clock.Clock,
+	deploymentStateService biconfig.DeploymentStateService,
+	vmRepo biconfig.VMRepo,
+	diskRepo biconfig.DiskRepo,
+	releaseManager biinstall.ReleaseManager,
+	cloudFactory bicloud.Factory,
+	deploymentManifestPath string,
+	deploymentVars boshtpl.Variables,
+	deploymentOp patch.Op,
+	cpiInstaller bicpirel.CpiInstaller,
+	cpiUninstaller biinstall.Uninstaller,
+	releaseFetcher biinstall.ReleaseFetcher,
+	releaseSetAndInstallationManifestParser ReleaseSetAndInstallationManifestParser,
+	tempRootConfigurator TempRootConfigurator,
+	targetProvider biinstall.TargetProvider,
+	attempts *biui.Attempts,
+) PruneStateManager {
+	return &pruneStateManager{
+		logTag:                 logTag,
+		logger:                 logger,
+		deploymentStateService: deploymentStateService,
+		vmRepo:                 vmRepo,
+		diskRepo:               diskRepo,
+		envCloud: envCloud{
+			logTag:                                  logTag,
+			logger:                                  logger,
+			timeService:                             timeService,
+			deploymentStateService:                  deploymentStateService,
+			releaseManager:                          releaseManager,
+			cloudFactory:                            cloudFactory,
+			deploymentManifestPath:                  deploymentManifestPath,
+			deploymentVars:                          deploymentVars,
+			deploymentOp:                            deploymentOp,
+			cpiInstaller:                            cpiInstaller,
+			cpiUninstaller:                          cpiUninstaller,
+			releaseFetcher:                          releaseFetcher,
+			releaseSetAndInstallationManifestParser: releaseSetAndInstallationManifestParser,
+			tempRootConfigurator:                    tempRootConfigurator,
+			targetProvider:                          targetProvider,
+			attempts:                                attempts,
+		},
+	}
+}
+
+type pruneStateManager struct {
+	logTag                 string
+	logger                 boshlog.Logger
+	deploymentStateService biconfig.DeploymentStateService
+	vmRepo                 biconfig.VMRepo
+	diskRepo               biconfig.DiskRepo
+	envCloud               envCloud
+}
+
+// missingRecord is a pruned record with the removal of its record
+type missingRecord struct {
+	PrunedRecord
+	remove func() error
+}
+
+func (m *pruneStateManager) Prune(stage biui.Stage, dryRun bool) (PruneReport, error) {
+	// stemcells cannot be looked up through the CPI, unused ones are
+	// deleted with env-stemcells --prune instead
+	report := PruneReport{Pruned: []PrunedRecord{}, Unverified: []string{"stemcell"}}
+
+	if !m.deploymentStateService.Exists() {
+		return report, bosherr.Errorf("Deployment state '%s' does not exist", m.deploymentStateService.Path())
+	}
+
+	vmCID, vmFound, err := m.vmRepo.FindCurrent()
+	if err != nil {
+		return report, bosherr.WrapError(err, "Finding current vm record")
+	}
+
+	disks, err := m.diskRepo.All()
+	if err != nil {
+		return report, bosherr.WrapError(err, "Getting all disk records")
+	}
+
+	orphanedDisks, err := m.diskRepo.AllOrphaned()
+	if err != nil {
+		return report, bosherr.WrapError(err, "Getting all orphaned disk records")
+	}
+
+	// the CPI is only installed when there is something to verify
+	if !vmFound && len(disks) == 0 && len(orphanedDisks) == 0 {
+		return report, nil
+	}
+
+	err = m.envCloud.Run(stage, func(cloud bicloud.Cloud) error {
+		var missing []missingRecord
+
+		err := stage.Perform("Verifying recorded VMs and disks", func() error {
+			var err error
+			missing, err = m.findMissing(cloud, &report, vmCID, vmFound, disks, orphanedDisks)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, record := range missing {
+			report.Pruned = append(report.Pruned, record.PrunedRecord)
+		}
+
+		if dryRun || len(missing) == 0 {
+			return nil
+		}
+
+		return stage.Perform("Removing records of missing VMs and disks", func() error {
+			for _, record := range missing {
+				err := record.remove()
+				if err != nil {
+					return bosherr.WrapErrorf(err, "Removing %s record '%s'", record.Type, record.CID)
+				}
+			}
+
+			return nil
+		})
+	})
+
+	return report, err
+}
+
+func (m *pruneStateManager) findMissing(
+	cloud bicloud.Cloud,
+	report *PruneReport,
+	vmCID string,
+	vmFound bool,
+	disks []biconfig.DiskRecord,
+	orphanedDisks []biconfig.OrphanedDiskRecord,
+) ([]missingRecord, error) {
+	missing := []missingRecord{}
+
+	if vmFound {
+		found, err := cloud.HasVM(vmCID)
+		if err != nil {
+			return missing, bosherr.WrapErrorf(err, "Checking existence of VM '%s'", vmCID)
+		}
+
+		if !found {
+			missing = append(missing, missingRecord{
+				PrunedRecord: PrunedRecord{Type: "vm", CID: vmCID},
+				remove:       m.vmRepo.ClearCurrent,
+			})
+		}
+	}
+
+	for _, disk := range disks {
+		disk := disk
+
+		found, err := cloud.HasDisk(disk.CID)
+		if m.isNotImplemented(err) {
+			report.Unverified = append(report.Unverified, "disk")
+			return missing, nil
+		} else if err != nil {
+			return missing, bosherr.WrapErrorf(err, "Checking existence of disk '%s'", disk.CID)
+		}
+
+		if !found {
+			missing = append(missing, missingRecord{
+				PrunedRecord: PrunedRecord{Type: "disk", CID: disk.CID},
+				remove:       func() error { return m.diskRepo.Delete(disk) },
+			})
+		}
+	}
+
+	for _, orphanedDisk := range orphanedDisks {
+		orphanedDisk := orphanedDisk
+
+		found, err := cloud.HasDisk(orphanedDisk.CID)
+		if m.isNotImplemented(err) {
+			report.Unverified = append(report.Unverified, "disk")
+			return missing, nil
+		} else if err != nil {
+			return missing, bosherr.WrapErrorf(err, "Checking existence of orphaned disk '%s'", orphanedDisk.CID)
+		}
+
+		if !found {
+			missing = append(missing, missingRecord{
+				PrunedRecord: PrunedRecord{Type: "orphaned disk", CID: orphanedDisk.CID},
+				remove:       func() error { return m.diskRepo.DeleteOrphaned(orphanedDisk) },
+			})
+		}
+	}
+
+	return missing, nil
+}
+
+// isNotImplemented is true for CPIs without has_disk, their disk records
+// are kept
+func (m *pruneStateManager) isNotImplemented(err error) bool {
+	cloudErr, ok := err.(bicloud.Error)
+	if ok && cloudErr.Type() == bicloud.NotImplementedError {
+		m.logger.Warn(m.logTag, "Keeping disk records since the CPI does not implement has_disk")
+		return true
+	}
+
+	return false
+}
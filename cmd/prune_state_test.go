@@ -0,0 +1,94 @@
+package cmd_test
+
+import (
+	"errors"
+
+	"github.com/cppforlife/go-patch/patch"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	boshtbl "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+var _ = Describe("PruneStateCmd", func() {
+	var (
+		mockCtrl              *gomock.Controller
+		mockPruneStateManager *mock_cmd.MockPruneStateManager
+		fakeUI                *fakeui.FakeUI
+		fakeStage             *fakeui.FakeStage
+		opts                  PruneStateOpts
+		command               *PruneStateCmd
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockPruneStateManager = mock_cmd.NewMockPruneStateManager(mockCtrl)
+		fakeUI = &fakeui.FakeUI{}
+		fakeStage = fakeui.NewFakeStage()
+
+		opts = PruneStateOpts{
+			Args:      PruneStateArgs{Manifest: FileBytesWithPathArg{Path: "/fake-manifest.yml"}},
+			StatePath: "/fake-state.json",
+		}
+
+		envProvider := func(manifestPath, statePath string, vars boshtpl.Variables, op patch.Op) PruneStateManager {
+			Expect(manifestPath).To(Equal("/fake-manifest.yml"))
+			Expect(statePath).To(Equal("/fake-state.json"))
+			return mockPruneStateManager
+		}
+
+		command = NewPruneStateCmd(fakeUI, envProvider)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("reports the pruned records and the record types that were not verified", func() {
+		mockPruneStateManager.EXPECT().Prune(fakeStage, false).Return(PruneReport{
+			Pruned: []PrunedRecord{
+				{Type: "vm", CID: "fake-vm-cid"},
+				{Type: "disk", CID: "fake-disk-cid"},
+			},
+			Unverified: []string{"stemcell"},
+		}, nil)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Table.Content).To(Equal("pruned records"))
+		Expect(fakeUI.Table.Notes).To(Equal([]string{"Records of type stemcell cannot be verified through the CPI and were kept"}))
+		Expect(fakeUI.Table.Rows).To(Equal([][]boshtbl.Value{
+			{boshtbl.NewValueString("vm"), boshtbl.NewValueString("fake-vm-cid")},
+			{boshtbl.NewValueString("disk"), boshtbl.NewValueString("fake-disk-cid")},
+		}))
+	})
+
+	It("only lists the records to prune with --dry-run", func() {
+		opts.DryRun = true
+		mockPruneStateManager.EXPECT().Prune(fakeStage, true).Return(PruneReport{
+			Pruned: []PrunedRecord{{Type: "orphaned disk", CID: "fake-disk-cid"}},
+		}, nil)
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(fakeUI.Table.Content).To(Equal("records to prune"))
+		Expect(fakeUI.Table.Notes).To(BeEmpty())
+		Expect(fakeUI.Table.Rows).To(Equal([][]boshtbl.Value{
+			{boshtbl.NewValueString("orphaned disk"), boshtbl.NewValueString("fake-disk-cid")},
+		}))
+	})
+
+	It("returns the error of pruning", func() {
+		mockPruneStateManager.EXPECT().Prune(fakeStage, false).Return(PruneReport{}, errors.New("fake-err"))
+
+		err := command.Run(fakeStage, opts)
+		Expect(err).To(MatchError("fake-err"))
+	})
+})
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/cppforlife/go-patch/patch"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type TestCpiCmd struct {
+	ui          boshui.UI
+	envProvider func(string, boshtpl.Variables, patch.Op) CpiLifecycleTester
+}
+
+func NewTestCpiCmd(ui boshui.UI, envProvider func(string, boshtpl.Variables, patch.Op) CpiLifecycleTester) *TestCpiCmd {
+	return &TestCpiCmd{ui: ui, envProvider: envProvider}
+}
+
+func (c *TestCpiCmd) Run(stage boshui.Stage, opts TestCpiOpts) error {
+	c.ui.BeginLinef("Deployment manifest: '%s'\n", opts.Args.Manifest.Path)
+
+	tester := c.envProvider(opts.Args.Manifest.Path, opts.VarFlags.AsVariables(), opts.OpsFlags.AsOp())
+
+	return tester.TestLifecycle(opts.DiskSize, stage)
+}
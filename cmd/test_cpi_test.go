@@ -0,0 +1,87 @@
+package cmd_test
+
+import (
+	bicmd "github.com/cloudfoundry/bosh-cli/cmd"
+	"github.com/cppforlife/go-patch/patch"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mock_cmd "github.com/cloudfoundry/bosh-cli/cmd/mocks"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"github.com/golang/mock/gomock"
+
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("TestCpiCmd", func() {
+	var mockCtrl *gomock.Controller
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	Describe("Run", func() {
+		var (
+			mockCpiLifecycleTester *mock_cmd.MockCpiLifecycleTester
+
+			fakeUI                 *fakeui.FakeUI
+			fakeStage              *fakebiui.FakeStage
+			deploymentManifestPath = "/deployment-dir/fake-deployment-manifest.yml"
+			opts                   bicmd.TestCpiOpts
+		)
+
+		var newTestCpiCmd = func() *bicmd.TestCpiCmd {
+			doGetFunc := func(manifestPath string, vars boshtpl.Variables, op patch.Op) bicmd.CpiLifecycleTester {
+				Expect(manifestPath).To(Equal(deploymentManifestPath))
+				Expect(vars).To(Equal(boshtpl.NewMultiVars([]boshtpl.Variables{boshtpl.StaticVariables{"key": "value"}})))
+				Expect(op).To(Equal(patch.Ops{patch.ErrOp{}}))
+				return mockCpiLifecycleTester
+			}
+
+			return bicmd.NewTestCpiCmd(fakeUI, doGetFunc)
+		}
+
+		BeforeEach(func() {
+			mockCpiLifecycleTester = mock_cmd.NewMockCpiLifecycleTester(mockCtrl)
+			fakeUI = &fakeui.FakeUI{}
+			fakeStage = fakebiui.NewFakeStage()
+
+			opts = bicmd.TestCpiOpts{
+				Args: bicmd.TestCpiArgs{
+					Manifest: bicmd.FileBytesWithPathArg{Path: deploymentManifestPath},
+				},
+				DiskSize: 2048,
+				VarFlags: bicmd.VarFlags{
+					VarKVs: []boshtpl.VarKV{{Name: "key", Value: "value"}},
+				},
+				OpsFlags: bicmd.OpsFlags{
+					OpsFiles: []bicmd.OpsFileArg{
+						{Ops: patch.Ops([]patch.Op{patch.ErrOp{}})},
+					},
+				},
+			}
+		})
+
+		It("runs the lifecycle with the requested disk size", func() {
+			mockCpiLifecycleTester.EXPECT().TestLifecycle(2048, fakeStage).Return(nil)
+
+			err := newTestCpiCmd().Run(fakeStage, opts)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeUI.Said).To(ContainElement("Deployment manifest: '/deployment-dir/fake-deployment-manifest.yml'\n"))
+		})
+
+		It("returns an error when the lifecycle fails", func() {
+			err := bosherr.Error("boom")
+			mockCpiLifecycleTester.EXPECT().TestLifecycle(2048, fakeStage).Return(err)
+
+			returnedErr := newTestCpiCmd().Run(fakeStage, opts)
+			Expect(returnedErr).To(Equal(err))
+		})
+	})
+})
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"gopkg.in/yaml.v2"
+
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+// requiredEnvSections must be present for create-env to deploy anything
+var requiredEnvSections = []string{"networks", "resource_pools", "cloud_provider"}
+
+var (
+	validateEnvTypeErrorRegexp = regexp.MustCompile(`^line (\d+): (.*)$`)
+	validateEnvPathRegexp      = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\[\d+\]|\.[a-z0-9_]+)*$`)
+)
+
+// ValidateEnvCmd checks an environment manifest without installing the CPI,
+// downloading releases and stemcells or calling the IaaS, and reports all
+// the problems found instead of the first one
+type ValidateEnvCmd struct {
+	ui                  boshui.UI
+	releaseSetParser    birelsetmanifest.Parser
+	installationParser  biinstallmanifest.Parser
+	deploymentParser    bideplmanifest.Parser
+	deploymentValidator bideplmanifest.Validator
+}
+
+type manifestProblem struct {
+	Line    int
+	Message string
+}
+
+func NewValidateEnvCmd(
+	ui boshui.UI,
+	releaseSetParser birelsetmanifest.Parser,
+	installationParser biinstallmanifest.Parser,
+	deploymentParser bideplmanifest.Parser,
+	deploymentValidator bideplmanifest.Validator,
+) ValidateEnvCmd {
+	return ValidateEnvCmd{
+		ui:                  ui,
+		releaseSetParser:    releaseSetParser,
+		installationParser:  installationParser,
+		deploymentParser:    deploymentParser,
+		deploymentValidator: deploymentValidator,
+	}
+}
+
+func (c ValidateEnvCmd) Run(opts ValidateEnvOpts) error {
+	path := opts.Args.Manifest.Path
+	vars := opts.VarFlags.AsVariables()
+	op := opts.OpsFlags.AsOp()
+
+	err := warnInlineSecrets(c.ui, path, opts.Args.Manifest.Bytes, opts.OpsFlags, opts.VarFlags, opts.FailOnInlineSecrets)
+	if err != nil {
+		return err
+	}
+
+	interpolatedTemplate, err := bidepltpl.NewDeploymentTemplate(opts.Args.Manifest.Bytes).Evaluate(vars, op)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Evaluating manifest '%s'", path)
+	}
+
+	var errs []error
+
+	errs = append(errs, c.checkRequiredSections(interpolatedTemplate.Content())...)
+
+	releaseSetManifest, err := c.releaseSetParser.Parse(path, vars, op)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	_, err = c.installationParser.Parse(path, vars, op, releaseSetManifest)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	deploymentManifest, err := c.deploymentParser.Parse(interpolatedTemplate, path)
+	if err != nil {
+		errs = append(errs, err)
+	} else {
+		err = c.deploymentValidator.Validate(deploymentManifest, releaseSetManifest)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	problems := c.locateProblems(errs, opts.Args.Manifest.Bytes, interpolatedTemplate.Content())
+
+	if len(problems) == 0 {
+		c.ui.PrintLinef("Manifest '%s' is valid", path)
+		return nil
+	}
+
+	for _, problem := range problems {
+		if problem.Line > 0 {
+			c.ui.ErrorLinef("  line %d: %s", problem.Line, problem.Message)
+		} else {
+			c.ui.ErrorLinef("  %s", problem.Message)
+		}
+	}
+
+	return bosherr.Errorf("Found %d problems in manifest '%s'", len(problems), path)
+}
+
+func (c ValidateEnvCmd) checkRequiredSections(content []byte) []error {
+	sections := map[string]interface{}{}
+
+	err := yaml.Unmarshal(content, &sections)
+	if err != nil {
+		return []error{bosherr.WrapError(err, "Unmarshalling manifest")}
+	}
+
+	var errs []error
+
+	for _, name := range requiredEnvSections {
+		if sections[name] == nil {
+			errs = append(errs, bosherr.Errorf("%s must be provided", name))
+		}
+	}
+
+	return errs
+}
+
+// locateProblems flattens the parse and validation errors, which are
+// reported by each parser of the manifest, and finds their lines. Unmarshal
+// errors refer to lines of the interpolated manifest and are mapped back to
+// the manifest through their path.
+func (c ValidateEnvCmd) locateProblems(errs []error, manifest, interpolated []byte) []manifestProblem {
+	manifestLines := newManifestLines(manifest)
+	interpolatedLines := newManifestLines(interpolated)
+
+	problems := []manifestProblem{}
+	seen := map[string]bool{}
+
+	for _, err := range errs {
+		for _, message := range c.flattenErrors(err) {
+			problem := manifestProblem{Message: message}
+
+			if match := validateEnvTypeErrorRegexp.FindStringSubmatch(message); match != nil {
+				problem.Message = match[2]
+
+				line, _ := strconv.Atoi(match[1])
+				if path, found := interpolatedLines.Path(line); found {
+					problem.Message = fmt.Sprintf("%s: %s", path, match[2])
+					problem.Line, _ = manifestLines.Line(path)
+				}
+			} else if path := strings.SplitN(message, " ", 2)[0]; validateEnvPathRegexp.MatchString(path) {
+				problem.Line, _ = manifestLines.Line(path)
+			}
+
+			if !seen[problem.Message] {
+				seen[problem.Message] = true
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	// problems without a line are listed last
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].Line == 0 || problems[j].Line == 0 {
+			return problems[j].Line == 0 && problems[i].Line != 0
+		}
+		return problems[i].Line < problems[j].Line
+	})
+
+	return problems
+}
+
+func (c ValidateEnvCmd) flattenErrors(err error) []string {
+	switch typedErr := err.(type) {
+	case bosherr.ComplexError:
+		return c.flattenErrors(typedErr.Cause)
+
+	case bosherr.MultiError:
+		var messages []string
+		for _, err := range typedErr.Errors {
+			messages = append(messages, c.flattenErrors(err)...)
+		}
+		return messages
+
+	case *yaml.TypeError:
+		return typedErr.Errors
+	}
+
+	return []string{err.Error()}
+}
@@ -0,0 +1,276 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+const (
+	// watchPollInterval is short enough for feedback within a second of
+	// saving a file and works with editors that replace files on save
+	watchPollInterval = 250 * time.Millisecond
+
+	// watchDiffContext is the number of unchanged lines shown around changes
+	watchDiffContext = 2
+)
+
+// WatchCmd re-runs validate-env whenever the manifest or one of its ops or
+// vars files changes, and shows how the interpolated manifest changed since
+// the previous run. Nothing is deployed and the CPI is not called.
+type WatchCmd struct {
+	ui               boshui.UI
+	fs               boshsys.FileSystem
+	timeService      clock.Clock
+	validateEnvCmd   ValidateEnvCmd
+	signalNotifyFunc func(chan<- os.Signal, ...os.Signal)
+}
+
+func NewWatchCmd(
+	ui boshui.UI,
+	fs boshsys.FileSystem,
+	timeService clock.Clock,
+	validateEnvCmd ValidateEnvCmd,
+	signalNotifyFunc func(chan<- os.Signal, ...os.Signal),
+) WatchCmd {
+	return WatchCmd{
+		ui:               ui,
+		fs:               fs,
+		timeService:      timeService,
+		validateEnvCmd:   validateEnvCmd,
+		signalNotifyFunc: signalNotifyFunc,
+	}
+}
+
+// Run validates until it is interrupted
+func (c WatchCmd) Run(opts WatchOpts) error {
+	if opts.Args.Manifest == "-" {
+		return bosherr.Error("Watching requires a manifest file instead of stdin")
+	}
+
+	paths, err := c.expandPaths(opts)
+	if err != nil {
+		return err
+	}
+
+	signalCh := make(chan os.Signal, 1)
+	c.signalNotifyFunc(signalCh, os.Interrupt, syscall.SIGTERM)
+
+	c.ui.PrintLinef("Watching %s", strings.Join(paths, ", "))
+
+	var lastContents [][]byte
+	var lastInterpolated []byte
+
+	for {
+		contents := c.readAll(paths)
+
+		if lastContents == nil || c.changed(lastContents, contents) {
+			lastInterpolated = c.check(opts, lastInterpolated)
+			lastContents = contents
+		}
+
+		select {
+		case <-signalCh:
+			return nil
+		case <-c.timeService.After(watchPollInterval):
+		}
+	}
+}
+
+func (c WatchCmd) expandPaths(opts WatchOpts) ([]string, error) {
+	var paths []string
+
+	for _, path := range append(append([]string{opts.Args.Manifest}, opts.OpsFiles...), opts.VarsFiles...) {
+		absPath, err := c.fs.ExpandPath(path)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Getting absolute path '%s'", path)
+		}
+
+		paths = append(paths, absPath)
+	}
+
+	return paths, nil
+}
+
+// readAll returns nil contents for files that cannot be read, e.g. while an
+// editor replaces them, so that they are checked again once they are back
+func (c WatchCmd) readAll(paths []string) [][]byte {
+	contents := make([][]byte, len(paths))
+
+	for i, path := range paths {
+		content, err := c.fs.ReadFile(path)
+		if err == nil {
+			contents[i] = content
+		}
+	}
+
+	return contents
+}
+
+func (c WatchCmd) changed(last, current [][]byte) bool {
+	for i := range current {
+		if (last[i] == nil) != (current[i] == nil) || !bytes.Equal(last[i], current[i]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// check validates the manifest and returns its interpolated content, or
+// lastInterpolated when it cannot be interpolated
+func (c WatchCmd) check(opts WatchOpts, lastInterpolated []byte) []byte {
+	c.ui.PrintLinef("\nValidating at %s", c.timeService.Now().Format("15:04:05"))
+
+	validateOpts, err := c.validateEnvOpts(opts)
+	if err != nil {
+		c.ui.ErrorLinef("%s", err.Error())
+		return lastInterpolated
+	}
+
+	interpolated := lastInterpolated
+
+	interpolatedTemplate, err := bidepltpl.NewDeploymentTemplate(validateOpts.Args.Manifest.Bytes).Evaluate(
+		validateOpts.VarFlags.AsVariables(), validateOpts.OpsFlags.AsOp())
+	if err == nil {
+		interpolated = interpolatedTemplate.Content()
+
+		if lastInterpolated != nil && !bytes.Equal(lastInterpolated, interpolated) {
+			c.ui.PrintLinef("Interpolated manifest changes:")
+			newLinesDiff(lastInterpolated, interpolated, watchDiffContext).Print(c.ui)
+		}
+	}
+
+	err = c.validateEnvCmd.Run(validateOpts)
+	if err != nil {
+		c.ui.ErrorLinef("%s", err.Error())
+	}
+
+	return interpolated
+}
+
+// validateEnvOpts reads the watched files again, like the flag parser does
+// for validate-env
+func (c WatchCmd) validateEnvOpts(opts WatchOpts) (ValidateEnvOpts, error) {
+	validateOpts := ValidateEnvOpts{
+		VarFlags: VarFlags{VarKVs: opts.VarKVs},
+	}
+
+	validateOpts.Args.Manifest.FS = c.fs
+
+	err := validateOpts.Args.Manifest.UnmarshalFlag(opts.Args.Manifest)
+	if err != nil {
+		return ValidateEnvOpts{}, err
+	}
+
+	for _, path := range opts.OpsFiles {
+		opsFile := OpsFileArg{FS: c.fs}
+
+		err = opsFile.UnmarshalFlag(path)
+		if err != nil {
+			return ValidateEnvOpts{}, err
+		}
+
+		validateOpts.OpsFiles = append(validateOpts.OpsFiles, opsFile)
+	}
+
+	for _, path := range opts.VarsFiles {
+		varsFile := boshtpl.VarsFileArg{FS: c.fs}
+
+		err = varsFile.UnmarshalFlag(path)
+		if err != nil {
+			return ValidateEnvOpts{}, err
+		}
+
+		validateOpts.VarsFiles = append(validateOpts.VarsFiles, varsFile)
+	}
+
+	return validateOpts, nil
+}
+
+// newLinesDiff compares before and after line by line and keeps the changed
+// lines with context unchanged lines around them, in the format of the
+// director manifest diffs
+func newLinesDiff(before, after []byte, context int) Diff {
+	beforeLines := strings.Split(strings.TrimSuffix(string(before), "\n"), "\n")
+	afterLines := strings.Split(strings.TrimSuffix(string(after), "\n"), "\n")
+
+	// common[i][j] is the length of the longest common subsequence of
+	// beforeLines[i:] and afterLines[j:]
+	common := make([][]int, len(beforeLines)+1)
+	for i := range common {
+		common[i] = make([]int, len(afterLines)+1)
+	}
+
+	for i := len(beforeLines) - 1; i >= 0; i-- {
+		for j := len(afterLines) - 1; j >= 0; j-- {
+			if beforeLines[i] == afterLines[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else if common[i+1][j] >= common[i][j+1] {
+				common[i][j] = common[i+1][j]
+			} else {
+				common[i][j] = common[i][j+1]
+			}
+		}
+	}
+
+	var lines [][]interface{}
+
+	i, j := 0, 0
+	for i < len(beforeLines) || j < len(afterLines) {
+		switch {
+		case i < len(beforeLines) && j < len(afterLines) && beforeLines[i] == afterLines[j]:
+			lines = append(lines, []interface{}{beforeLines[i], ""})
+			i++
+			j++
+		case i < len(beforeLines) && (j == len(afterLines) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, []interface{}{beforeLines[i], "removed"})
+			i++
+		default:
+			lines = append(lines, []interface{}{afterLines[j], "added"})
+			j++
+		}
+	}
+
+	return NewDiff(withDiffContext(lines, context))
+}
+
+func withDiffContext(lines [][]interface{}, context int) [][]interface{} {
+	var kept [][]interface{}
+
+	lastKept := -1
+
+	for i := range lines {
+		near := false
+
+		for j := i - context; j <= i+context; j++ {
+			if j >= 0 && j < len(lines) && lines[j][1] != "" {
+				near = true
+				break
+			}
+		}
+
+		if !near {
+			continue
+		}
+
+		if lastKept >= 0 && i > lastKept+1 {
+			kept = append(kept, []interface{}{"...", ""})
+		}
+
+		kept = append(kept, lines[i])
+		lastKept = i
+	}
+
+	return kept
+}
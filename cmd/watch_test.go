@@ -0,0 +1,213 @@
+package cmd_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/cmd"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("WatchCmd", func() {
+	const manifest = `---
+name: fake-deployment-name
+
+releases:
+- name: fake-cpi-release-name
+  url: file:///fake-cpi-release.tgz
+
+networks:
+- name: network-1
+  type: dynamic
+
+resource_pools:
+- name: resource-pool-1
+  network: network-1
+  stemcell:
+    url: file:///fake-stemcell.tgz
+
+jobs:
+- name: fake-job-name
+  instances: 1
+  resource_pool: resource-pool-1
+  networks:
+  - name: network-1
+  templates:
+  - {name: fake-cpi-job-name, release: fake-cpi-release-name}
+
+cloud_provider:
+  template:
+    name: fake-cpi-job-name
+    release: fake-cpi-release-name
+  mbus: ((mbus_url))
+`
+
+	var (
+		fs        *fakesys.FakeFileSystem
+		ui        *fakeui.FakeUI
+		fakeClock *fakeclock.FakeClock
+		signalCh  chan<- os.Signal
+		signalSet chan struct{}
+		opts      WatchOpts
+		command   WatchCmd
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs = fakesys.NewFakeFileSystem()
+		ui = &fakeui.FakeUI{}
+		fakeClock = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC))
+		signalSet = make(chan struct{})
+
+		validateEnvCmd := NewValidateEnvCmd(
+			ui,
+			birelsetmanifest.NewParser(fs, logger, birelsetmanifest.NewValidator(logger)),
+			biinstallmanifest.NewParser(fs, &fakeuuid.FakeGenerator{}, logger, biinstallmanifest.NewValidator(logger)),
+			bideplmanifest.NewParser(fs, logger),
+			bideplmanifest.NewValidator(logger),
+		)
+
+		signalNotifyFunc := func(ch chan<- os.Signal, sig ...os.Signal) {
+			signalCh = ch
+			close(signalSet)
+		}
+
+		command = NewWatchCmd(ui, fs, fakeClock, validateEnvCmd, signalNotifyFunc)
+
+		fs.WriteFileString("/manifest.yml", manifest)
+		fs.WriteFileString("/ops.yml", "[]")
+		fs.WriteFileString("/vars.yml", "mbus_url: http://fake-mbus-url\n")
+
+		opts = WatchOpts{
+			Args:      WatchArgs{Manifest: "/manifest.yml"},
+			OpsFiles:  []string{"/ops.yml"},
+			VarsFiles: []string{"/vars.yml"},
+		}
+	})
+
+	run := func() chan error {
+		errCh := make(chan error, 1)
+
+		go func() {
+			errCh <- command.Run(opts)
+		}()
+
+		Eventually(signalSet).Should(BeClosed())
+
+		return errCh
+	}
+
+	stop := func(errCh chan error) {
+		signalCh <- os.Interrupt
+		Eventually(errCh).Should(Receive(BeNil()))
+	}
+
+	said := func() []string {
+		return append([]string(nil), ui.Said...)
+	}
+
+	errs := func() []string {
+		return append([]string(nil), ui.Errors...)
+	}
+
+	It("validates the manifest until it is interrupted", func() {
+		errCh := run()
+
+		Eventually(said).Should(ContainElement("Manifest '/manifest.yml' is valid"))
+		Expect(said()).To(ContainElement("Watching /manifest.yml, /ops.yml, /vars.yml"))
+		Expect(said()).To(ContainElement("\nValidating at 12:00:00"))
+
+		stop(errCh)
+	})
+
+	It("validates again and shows the interpolated changes when a vars file changes", func() {
+		errCh := run()
+		Eventually(said).Should(ContainElement("Manifest '/manifest.yml' is valid"))
+
+		fs.WriteFileString("/vars.yml", "mbus_url: http://other-mbus-url\n")
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+		Eventually(said).Should(ContainElement("- " + "  mbus: http://fake-mbus-url\n"))
+		Expect(said()).To(ContainElement("+ " + "  mbus: http://other-mbus-url\n"))
+		Expect(said()).To(ContainElement("Interpolated manifest changes:"))
+
+		stop(errCh)
+	})
+
+	It("reports problems introduced by an ops file and keeps watching", func() {
+		errCh := run()
+		Eventually(said).Should(ContainElement("Manifest '/manifest.yml' is valid"))
+
+		fs.WriteFileString("/ops.yml", "- {type: remove, path: /networks}")
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+		Eventually(errs).Should(ContainElement(HaveSuffix("networks must be provided")))
+
+		fs.WriteFileString("/ops.yml", "[]")
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+		Eventually(func() int {
+			count := 0
+			for _, line := range said() {
+				if line == "Manifest '/manifest.yml' is valid" {
+					count++
+				}
+			}
+			return count
+		}).Should(Equal(2))
+
+		stop(errCh)
+	})
+
+	It("does not validate again when nothing changed", func() {
+		errCh := run()
+		Eventually(said).Should(ContainElement("Manifest '/manifest.yml' is valid"))
+
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+		fakeClock.WaitForWatcherAndIncrement(time.Second)
+
+		Consistently(said, 100*time.Millisecond).Should(HaveLen(3))
+
+		stop(errCh)
+	})
+
+	It("reports files that cannot be read", func() {
+		fs.RegisterReadFileError("/ops.yml", errors.New("fake-read-err"))
+
+		errCh := run()
+
+		Eventually(errs).Should(ContainElement(ContainSubstring("fake-read-err")))
+
+		stop(errCh)
+	})
+
+	It("passes variables set with --var", func() {
+		fs.WriteFileString("/vars.yml", "{}")
+		opts.VarKVs = []boshtpl.VarKV{{Name: "mbus_url", Value: "http://fake-mbus-url"}}
+
+		errCh := run()
+
+		Eventually(said).Should(ContainElement("Manifest '/manifest.yml' is valid"))
+
+		stop(errCh)
+	})
+
+	It("does not watch stdin", func() {
+		opts.Args.Manifest = "-"
+
+		err := command.Run(opts)
+		Expect(err).To(MatchError("Watching requires a manifest file instead of stdin"))
+	})
+})
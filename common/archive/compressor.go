@@ -0,0 +1,147 @@
+package archive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+type compressor struct {
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+// NewCompressor creates and extracts tarballs in process instead of shelling
+// out to tar, which is not available everywhere, e.g. on Windows
+func NewCompressor(fs boshsys.FileSystem, logger boshlog.Logger) boshcmd.Compressor {
+	return compressor{fs: fs, logger: logger, logTag: "compressor"}
+}
+
+func (c compressor) CompressFilesInDir(dir string) (string, error) {
+	return c.CompressSpecificFilesInDir(dir, []string{"."})
+}
+
+func (c compressor) CompressSpecificFilesInDir(dir string, files []string) (string, error) {
+	tarball, err := c.fs.TempFile("bosh-archive-Compressor-CompressSpecificFilesInDir")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating temporary file for tarball")
+	}
+
+	defer tarball.Close()
+
+	tarballPath := tarball.Name()
+
+	c.logger.Debug(c.logTag, "Compressing '%s' in '%s' to '%s'", files, dir, tarballPath)
+
+	gzipWriter := gzip.NewWriter(tarball)
+	tarWriter := tar.NewWriter(gzipWriter)
+
+	for _, file := range files {
+		err = c.addFiles(tarWriter, dir, file)
+		if err != nil {
+			_ = c.fs.RemoveAll(tarballPath)
+			return "", bosherr.WrapErrorf(err, "Compressing '%s' in '%s'", file, dir)
+		}
+	}
+
+	err = tarWriter.Close()
+	if err == nil {
+		err = gzipWriter.Close()
+	}
+	if err != nil {
+		_ = c.fs.RemoveAll(tarballPath)
+		return "", bosherr.WrapErrorf(err, "Writing tarball '%s'", tarballPath)
+	}
+
+	return tarballPath, nil
+}
+
+// addFiles adds file and, for directories, everything below it, named
+// relative to dir with slashes like tar does
+func (c compressor) addFiles(tarWriter *tar.Writer, dir, file string) error {
+	return filepath.Walk(filepath.Join(dir, file), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if name == "." {
+			return nil
+		}
+
+		var link string
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			link, err = os.Readlink(path)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Reading symlink '%s'", path)
+			}
+		}
+
+		header, err := tar.FileInfoHeader(info, link)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating tarball entry for '%s'", path)
+		}
+
+		header.Name = filepath.ToSlash(name)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+
+		err = tarWriter.WriteHeader(header)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing tarball entry for '%s'", path)
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		content, err := os.Open(path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Opening file '%s'", path)
+		}
+
+		defer content.Close()
+
+		_, err = io.Copy(tarWriter, content)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Writing file '%s' to tarball", path)
+		}
+
+		return nil
+	})
+}
+
+func (c compressor) DecompressFileToDir(path string, dir string, options boshcmd.CompressorOptions) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening tarball '%s'", path)
+	}
+	defer file.Close()
+
+	c.logger.Debug(c.logTag, "Extracting tarball '%s' to '%s'", path, dir)
+
+	err = extractTarball(file, dir, options, 1)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Extracting tarball '%s' to '%s'", path, dir)
+	}
+
+	return nil
+}
+
+func (c compressor) CleanUp(path string) error {
+	return c.fs.RemoveAll(path)
+}
@@ -0,0 +1,135 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/common/archive"
+)
+
+var _ = Describe("Compressor", func() {
+	var (
+		tmpDir     string
+		srcDir     string
+		extractDir string
+		compressor boshcmd.Compressor
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "compressor")
+		Expect(err).ToNot(HaveOccurred())
+
+		srcDir = filepath.Join(tmpDir, "src")
+		Expect(os.MkdirAll(filepath.Join(srcDir, "packages"), 0700)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(srcDir, "packages", "pkg1.tgz"), []byte("fake-pkg1"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(srcDir, "release.MF"), []byte("fake-manifest"), 0644)).To(Succeed())
+
+		extractDir = filepath.Join(tmpDir, "extracted")
+		Expect(os.Mkdir(extractDir, 0755)).To(Succeed())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		compressor = NewCompressor(boshsys.NewOsFileSystem(logger), logger)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	entryNames := func(path string) []string {
+		file, err := os.Open(path)
+		Expect(err).ToNot(HaveOccurred())
+		defer file.Close()
+
+		gzipReader, err := gzip.NewReader(file)
+		Expect(err).ToNot(HaveOccurred())
+
+		var names []string
+
+		tarReader := tar.NewReader(gzipReader)
+		for {
+			header, err := tarReader.Next()
+			if err == io.EOF {
+				return names
+			}
+			Expect(err).ToNot(HaveOccurred())
+			names = append(names, header.Name)
+		}
+	}
+
+	Describe("CompressFilesInDir", func() {
+		It("creates a gzipped tarball of the directory with slash separated names", func() {
+			path, err := compressor.CompressFilesInDir(srcDir)
+			Expect(err).ToNot(HaveOccurred())
+			defer compressor.CleanUp(path)
+
+			Expect(entryNames(path)).To(Equal([]string{"packages/", "packages/pkg1.tgz", "release.MF"}))
+		})
+
+		It("creates tarballs that extract to the same files", func() {
+			path, err := compressor.CompressFilesInDir(srcDir)
+			Expect(err).ToNot(HaveOccurred())
+			defer compressor.CleanUp(path)
+
+			err = compressor.DecompressFileToDir(path, extractDir, boshcmd.CompressorOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := ioutil.ReadFile(filepath.Join(extractDir, "packages", "pkg1.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-pkg1"))
+
+			info, err := os.Stat(filepath.Join(extractDir, "packages"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		})
+	})
+
+	Describe("CompressSpecificFilesInDir", func() {
+		It("only adds the given files", func() {
+			path, err := compressor.CompressSpecificFilesInDir(srcDir, []string{"release.MF"})
+			Expect(err).ToNot(HaveOccurred())
+			defer compressor.CleanUp(path)
+
+			Expect(entryNames(path)).To(Equal([]string{"release.MF"}))
+		})
+
+		It("returns an error when a file does not exist", func() {
+			_, err := compressor.CompressSpecificFilesInDir(srcDir, []string{"missing"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Compressing 'missing'"))
+		})
+	})
+
+	Describe("DecompressFileToDir", func() {
+		It("extracts tarballs that are not gzipped", func() {
+			path := filepath.Join(tmpDir, "plain.tar")
+
+			file, err := os.Create(path)
+			Expect(err).ToNot(HaveOccurred())
+
+			tarWriter := tar.NewWriter(file)
+			Expect(tarWriter.WriteHeader(&tar.Header{Name: "./file", Typeflag: tar.TypeReg, Mode: 0644, Size: 12})).To(Succeed())
+			_, err = tarWriter.Write([]byte("fake-content"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(tarWriter.Close()).To(Succeed())
+			Expect(file.Close()).To(Succeed())
+
+			err = compressor.DecompressFileToDir(path, extractDir, boshcmd.CompressorOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			content, err := ioutil.ReadFile(filepath.Join(extractDir, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-content"))
+		})
+	})
+})
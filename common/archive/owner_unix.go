@@ -0,0 +1,12 @@
+//go:build !windows
+// +build !windows
+
+package archive
+
+import (
+	"os"
+)
+
+func lchown(path string, uid, gid int) error {
+	return os.Lchown(path, uid, gid)
+}
@@ -0,0 +1,483 @@
+package archive
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	readBufferSize = 1 << 20
+
+	// blockSize is the size of the decompressed blocks read ahead of the tar reader
+	blockSize = 1 << 20
+
+	// maxBufferedFileSize bounds the files handed over to the writers, larger
+	// files are written while they are read
+	maxBufferedFileSize = 4 << 20
+)
+
+// ReaderDecompressor extracts tarballs from a stream instead of a file, so
+// that the stream can be used for more than the extraction in the same read,
+// e.g. for computing its digest
+type ReaderDecompressor interface {
+	DecompressReaderToDir(reader io.Reader, dir string, options boshcmd.CompressorOptions) error
+}
+
+type parallelCompressor struct {
+	compressor boshcmd.Compressor
+	workers    int
+	logger     boshlog.Logger
+	logTag     string
+}
+
+// NewParallelCompressor extracts gzipped tarballs in process, decompressing
+// ahead of the tar reader and writing up to workers files at the same time.
+// Other operations, non gzipped tarballs and less than 2 workers are left to
+// compressor.
+func NewParallelCompressor(compressor boshcmd.Compressor, workers int, logger boshlog.Logger) boshcmd.Compressor {
+	return parallelCompressor{
+		compressor: compressor,
+		workers:    workers,
+		logger:     logger,
+		logTag:     "parallelCompressor",
+	}
+}
+
+func (c parallelCompressor) CompressFilesInDir(dir string) (string, error) {
+	return c.compressor.CompressFilesInDir(dir)
+}
+
+func (c parallelCompressor) CompressSpecificFilesInDir(dir string, files []string) (string, error) {
+	return c.compressor.CompressSpecificFilesInDir(dir, files)
+}
+
+func (c parallelCompressor) CleanUp(path string) error {
+	return c.compressor.CleanUp(path)
+}
+
+func (c parallelCompressor) DecompressFileToDir(path string, dir string, options boshcmd.CompressorOptions) error {
+	if c.workers < 2 {
+		return c.compressor.DecompressFileToDir(path, dir, options)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening tarball '%s'", path)
+	}
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(bufio.NewReaderSize(file, readBufferSize))
+	if err != nil {
+		if err == gzip.ErrHeader || err == io.EOF {
+			c.logger.Debug(c.logTag, "Tarball '%s' is not gzipped, extracting it with tar", path)
+			return c.compressor.DecompressFileToDir(path, dir, options)
+		}
+		return bosherr.WrapErrorf(err, "Reading gzip header of tarball '%s'", path)
+	}
+
+	c.logger.Debug(c.logTag, "Extracting tarball '%s' to '%s' with %d workers", path, dir, c.workers)
+
+	err = extractGzipped(gzipReader, dir, options, c.workers)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Extracting tarball '%s' to '%s'", path, dir)
+	}
+
+	return nil
+}
+
+// DecompressReaderToDir extracts a gzipped or plain tarball read from reader
+// in process, also with less than 2 workers since there is no file to leave
+// to the compressor
+func (c parallelCompressor) DecompressReaderToDir(reader io.Reader, dir string, options boshcmd.CompressorOptions) error {
+	workers := c.workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	c.logger.Debug(c.logTag, "Extracting tarball to '%s' with %d workers", dir, workers)
+
+	err := extractTarball(reader, dir, options, workers)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Extracting tarball to '%s'", dir)
+	}
+
+	return nil
+}
+
+// extractTarball extracts a gzipped or plain tarball, telling them apart by
+// the gzip magic number
+func extractTarball(reader io.Reader, dir string, options boshcmd.CompressorOptions, workers int) error {
+	bufferedReader := bufio.NewReaderSize(reader, readBufferSize)
+
+	magic, err := bufferedReader.Peek(2)
+	if err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return newExtraction(dir, options, workers).run(tar.NewReader(bufferedReader))
+	}
+
+	gzipReader, err := gzip.NewReader(bufferedReader)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading gzip header of tarball")
+	}
+
+	return extractGzipped(gzipReader, dir, options, workers)
+}
+
+func extractGzipped(gzipReader io.Reader, dir string, options boshcmd.CompressorOptions, workers int) error {
+	readAhead := newReadAheadReader(gzipReader, workers)
+	defer readAhead.Close()
+
+	err := newExtraction(dir, options, workers).run(tar.NewReader(readAhead))
+	if err != nil {
+		return err
+	}
+
+	// the gzip checksum is only verified once the stream is read to the end
+	_, err = io.Copy(ioutil.Discard, readAhead)
+	if err != nil {
+		return bosherr.WrapError(err, "Reading tarball")
+	}
+
+	return nil
+}
+
+// readAheadReader decompresses blocks in its own goroutine so that
+// decompression is not held up by parsing and writing files
+type readAheadReader struct {
+	blocks  chan []byte
+	errs    chan error
+	stop    chan struct{}
+	current []byte
+	err     error
+}
+
+func newReadAheadReader(reader io.Reader, queued int) *readAheadReader {
+	r := &readAheadReader{
+		blocks: make(chan []byte, queued),
+		errs:   make(chan error, 1),
+		stop:   make(chan struct{}),
+	}
+
+	go func() {
+		defer close(r.blocks)
+
+		for {
+			block := make([]byte, blockSize)
+
+			// io.ReadFull would not tell a short last block from a truncated stream
+			var n int
+			var err error
+
+			for n < len(block) && err == nil {
+				var read int
+				read, err = reader.Read(block[n:])
+				n += read
+			}
+
+			if n > 0 {
+				select {
+				case r.blocks <- block[:n]:
+				case <-r.stop:
+					return
+				}
+			}
+
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				r.errs <- err
+				return
+			}
+		}
+	}()
+
+	return r
+}
+
+func (r *readAheadReader) Read(p []byte) (int, error) {
+	for len(r.current) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		block, ok := <-r.blocks
+		if !ok {
+			select {
+			case r.err = <-r.errs:
+			default:
+				r.err = io.EOF
+			}
+			continue
+		}
+
+		r.current = block
+	}
+
+	n := copy(p, r.current)
+	r.current = r.current[n:]
+
+	return n, nil
+}
+
+func (r *readAheadReader) Close() {
+	close(r.stop)
+}
+
+type extractedFile struct {
+	path   string
+	header *tar.Header
+	data   []byte
+}
+
+type extraction struct {
+	dir     string
+	options boshcmd.CompressorOptions
+	workers int
+
+	files chan extractedFile
+
+	errLock sync.Mutex
+	err     error
+
+	// links and directory attributes are set once all files are written
+	links []*tar.Header
+	dirs  []*tar.Header
+}
+
+func newExtraction(dir string, options boshcmd.CompressorOptions, workers int) *extraction {
+	return &extraction{
+		dir:     filepath.Clean(dir),
+		options: options,
+		workers: workers,
+		files:   make(chan extractedFile, workers),
+	}
+}
+
+func (e *extraction) run(reader *tar.Reader) error {
+	var wg sync.WaitGroup
+
+	for i := 0; i < e.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for file := range e.files {
+				e.setErr(e.writeFile(file.path, file.header, bytes.NewReader(file.data)))
+			}
+		}()
+	}
+
+	err := e.readEntries(reader)
+
+	close(e.files)
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	if e.err != nil {
+		return e.err
+	}
+
+	for _, header := range e.links {
+		err := e.writeLink(header)
+		if err != nil {
+			return err
+		}
+	}
+
+	// deepest directories first so that read-only parents do not prevent
+	// setting the attributes of their children
+	for i := len(e.dirs) - 1; i >= 0; i-- {
+		err := e.setAttributes(e.dirs[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *extraction) readEntries(reader *tar.Reader) error {
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return bosherr.WrapError(err, "Reading tarball entry")
+		}
+
+		if e.failed() {
+			return nil
+		}
+
+		path, err := e.entryPath(header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(path, 0755)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Creating directory '%s'", path)
+			}
+			e.dirs = append(e.dirs, header)
+
+		case tar.TypeReg, tar.TypeRegA:
+			err = os.MkdirAll(filepath.Dir(path), 0755)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Creating directory '%s'", filepath.Dir(path))
+			}
+
+			if header.Size > maxBufferedFileSize {
+				err = e.writeFile(path, header, reader)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+
+			data := make([]byte, header.Size)
+
+			_, err = io.ReadFull(reader, data)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Reading tarball entry '%s'", header.Name)
+			}
+
+			e.files <- extractedFile{path: path, header: header, data: data}
+
+		case tar.TypeSymlink, tar.TypeLink:
+			e.links = append(e.links, header)
+
+		default:
+			// tar only extracts devices and fifos as root, skip them like
+			// other entries without content
+		}
+	}
+}
+
+func (e *extraction) entryPath(name string) (string, error) {
+	path := filepath.Join(e.dir, name)
+
+	if path != e.dir && !strings.HasPrefix(path, e.dir+string(filepath.Separator)) {
+		return "", bosherr.Errorf("Tarball entry '%s' is outside of the extraction directory", name)
+	}
+
+	return path, nil
+}
+
+func (e *extraction) writeFile(path string, header *tar.Header, content io.Reader) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, header.FileInfo().Mode().Perm())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating file '%s'", path)
+	}
+
+	_, err = io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		return bosherr.WrapErrorf(err, "Writing file '%s'", path)
+	}
+
+	err = file.Close()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Closing file '%s'", path)
+	}
+
+	return e.setAttributes(header)
+}
+
+func (e *extraction) writeLink(header *tar.Header) error {
+	path, err := e.entryPath(header.Name)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating directory '%s'", filepath.Dir(path))
+	}
+
+	_ = os.Remove(path)
+
+	if header.Typeflag == tar.TypeSymlink {
+		err = os.Symlink(header.Linkname, path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Creating symlink '%s'", path)
+		}
+		return nil
+	}
+
+	target, err := e.entryPath(header.Linkname)
+	if err != nil {
+		return err
+	}
+
+	err = os.Link(target, path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating hard link '%s'", path)
+	}
+
+	return nil
+}
+
+func (e *extraction) setAttributes(header *tar.Header) error {
+	path, err := e.entryPath(header.Name)
+	if err != nil {
+		return err
+	}
+
+	if e.options.SameOwner {
+		err = lchown(path, header.Uid, header.Gid)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Changing owner of '%s'", path)
+		}
+	}
+
+	if header.Typeflag == tar.TypeDir {
+		err = os.Chmod(path, header.FileInfo().Mode().Perm())
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Changing mode of '%s'", path)
+		}
+	}
+
+	err = os.Chtimes(path, header.ModTime, header.ModTime)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Changing modification time of '%s'", path)
+	}
+
+	return nil
+}
+
+func (e *extraction) setErr(err error) {
+	if err == nil {
+		return
+	}
+
+	e.errLock.Lock()
+	defer e.errLock.Unlock()
+
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+func (e *extraction) failed() bool {
+	e.errLock.Lock()
+	defer e.errLock.Unlock()
+
+	return e.err != nil
+}
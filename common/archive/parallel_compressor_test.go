@@ -0,0 +1,238 @@
+package archive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/common/archive"
+)
+
+var _ = Describe("ParallelCompressor", func() {
+	var (
+		tmpDir         string
+		extractDir     string
+		fakeCompressor *fakecmd.FakeCompressor
+		logger         boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "parallel-compressor")
+		Expect(err).ToNot(HaveOccurred())
+
+		extractDir = filepath.Join(tmpDir, "extracted")
+		err = os.Mkdir(extractDir, 0755)
+		Expect(err).ToNot(HaveOccurred())
+
+		fakeCompressor = fakecmd.NewFakeCompressor()
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tmpDir)
+	})
+
+	type entry struct {
+		header  tar.Header
+		content string
+	}
+
+	writeTarball := func(gzipped bool, entries ...entry) string {
+		buffer := &bytes.Buffer{}
+		tarWriter := tar.NewWriter(buffer)
+
+		for _, e := range entries {
+			header := e.header
+			header.Size = int64(len(e.content))
+			header.ModTime = time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+			Expect(tarWriter.WriteHeader(&header)).To(Succeed())
+			_, err := tarWriter.Write([]byte(e.content))
+			Expect(err).ToNot(HaveOccurred())
+		}
+		Expect(tarWriter.Close()).To(Succeed())
+
+		content := buffer.Bytes()
+
+		if gzipped {
+			gzipped := &bytes.Buffer{}
+			gzipWriter := gzip.NewWriter(gzipped)
+			_, err := gzipWriter.Write(content)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gzipWriter.Close()).To(Succeed())
+
+			content = gzipped.Bytes()
+		}
+
+		path := filepath.Join(tmpDir, "archive.tgz")
+		Expect(ioutil.WriteFile(path, content, 0644)).To(Succeed())
+
+		return path
+	}
+
+	Describe("DecompressFileToDir", func() {
+		It("extracts directories, files and links of a gzipped tarball", func() {
+			largeContent := strings.Repeat("fake-large-content", 300000)
+
+			path := writeTarball(true,
+				entry{header: tar.Header{Name: "./", Typeflag: tar.TypeDir, Mode: 0755}},
+				entry{header: tar.Header{Name: "./packages/", Typeflag: tar.TypeDir, Mode: 0700}},
+				entry{header: tar.Header{Name: "./packages/pkg1.tgz", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-pkg1"},
+				entry{header: tar.Header{Name: "./packages/pkg2.tgz", Typeflag: tar.TypeReg, Mode: 0755}, content: "fake-pkg2"},
+				entry{header: tar.Header{Name: "./image", Typeflag: tar.TypeReg, Mode: 0644}, content: largeContent},
+				entry{header: tar.Header{Name: "./jobs/job1.tgz", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-job1"},
+				entry{header: tar.Header{Name: "./pkg-link", Typeflag: tar.TypeSymlink, Linkname: "packages/pkg1.tgz"}},
+				entry{header: tar.Header{Name: "./pkg-hard-link", Typeflag: tar.TypeLink, Linkname: "./packages/pkg2.tgz"}},
+			)
+
+			err := NewParallelCompressor(fakeCompressor, 4, logger).DecompressFileToDir(path, extractDir, boshcmd.CompressorOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+
+			content, err := ioutil.ReadFile(filepath.Join(extractDir, "packages", "pkg1.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-pkg1"))
+
+			content, err = ioutil.ReadFile(filepath.Join(extractDir, "image"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal(largeContent))
+
+			content, err = ioutil.ReadFile(filepath.Join(extractDir, "jobs", "job1.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-job1"))
+
+			info, err := os.Stat(filepath.Join(extractDir, "packages"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm()).To(Equal(os.FileMode(0700)))
+			Expect(info.ModTime().UTC()).To(Equal(time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)))
+
+			info, err = os.Stat(filepath.Join(extractDir, "packages", "pkg2.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(info.Mode().Perm() & 0100).To(Equal(os.FileMode(0100)))
+
+			linkname, err := os.Readlink(filepath.Join(extractDir, "pkg-link"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(linkname).To(Equal("packages/pkg1.tgz"))
+
+			content, err = ioutil.ReadFile(filepath.Join(extractDir, "pkg-hard-link"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-pkg2"))
+		})
+
+		It("returns an error for entries outside of the directory", func() {
+			path := writeTarball(true,
+				entry{header: tar.Header{Name: "../escaped", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-content"},
+			)
+
+			err := NewParallelCompressor(fakeCompressor, 4, logger).DecompressFileToDir(path, extractDir, boshcmd.CompressorOptions{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Tarball entry '../escaped' is outside of the extraction directory"))
+
+			_, err = os.Stat(filepath.Join(tmpDir, "escaped"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("returns an error when the tarball is truncated", func() {
+			path := writeTarball(true,
+				entry{header: tar.Header{Name: "./file", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-content"},
+			)
+
+			content, err := ioutil.ReadFile(path)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ioutil.WriteFile(path, content[:len(content)/2], 0644)).To(Succeed())
+
+			err = NewParallelCompressor(fakeCompressor, 4, logger).DecompressFileToDir(path, extractDir, boshcmd.CompressorOptions{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("leaves tarballs that are not gzipped to the compressor", func() {
+			path := writeTarball(false,
+				entry{header: tar.Header{Name: "./file", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-content"},
+			)
+
+			options := boshcmd.CompressorOptions{SameOwner: true}
+
+			err := NewParallelCompressor(fakeCompressor, 4, logger).DecompressFileToDir(path, extractDir, options)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(Equal([]string{path}))
+			Expect(fakeCompressor.DecompressFileToDirDirs).To(Equal([]string{extractDir}))
+			Expect(fakeCompressor.DecompressFileToDirOptions).To(Equal([]boshcmd.CompressorOptions{options}))
+		})
+
+		It("leaves extraction to the compressor with less than 2 workers", func() {
+			path := writeTarball(true,
+				entry{header: tar.Header{Name: "./file", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-content"},
+			)
+
+			err := NewParallelCompressor(fakeCompressor, 1, logger).DecompressFileToDir(path, extractDir, boshcmd.CompressorOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(Equal([]string{path}))
+
+			_, err = os.Stat(filepath.Join(extractDir, "file"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
+	Describe("DecompressReaderToDir", func() {
+		decompressReader := func(path string, workers int) error {
+			file, err := os.Open(path)
+			Expect(err).ToNot(HaveOccurred())
+			defer file.Close()
+
+			compressor := NewParallelCompressor(fakeCompressor, workers, logger).(ReaderDecompressor)
+
+			return compressor.DecompressReaderToDir(file, extractDir, boshcmd.CompressorOptions{})
+		}
+
+		It("extracts gzipped tarballs in process", func() {
+			path := writeTarball(true,
+				entry{header: tar.Header{Name: "./packages/pkg1.tgz", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-pkg1"},
+			)
+
+			Expect(decompressReader(path, 4)).To(Succeed())
+			Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+
+			content, err := ioutil.ReadFile(filepath.Join(extractDir, "packages", "pkg1.tgz"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-pkg1"))
+		})
+
+		It("extracts tarballs that are not gzipped and with less than 2 workers in process", func() {
+			path := writeTarball(false,
+				entry{header: tar.Header{Name: "./file", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-content"},
+			)
+
+			Expect(decompressReader(path, 1)).To(Succeed())
+			Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+
+			content, err := ioutil.ReadFile(filepath.Join(extractDir, "file"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(content)).To(Equal("fake-content"))
+		})
+
+		It("returns an error for entries outside of the directory", func() {
+			path := writeTarball(true,
+				entry{header: tar.Header{Name: "../escaped", Typeflag: tar.TypeReg, Mode: 0644}, content: "fake-content"},
+			)
+
+			err := decompressReader(path, 4)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Tarball entry '../escaped' is outside of the extraction directory"))
+		})
+	})
+})
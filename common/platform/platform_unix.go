@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package platform
+
+// IsolatedEnv returns the variables commands run with an isolated
+// environment need to find executables in path
+func IsolatedEnv(path string) map[string]string {
+	return map[string]string{"PATH": path}
+}
+
+// ScriptCommand returns the name and arguments running the script at path,
+// which is executed directly
+func ScriptCommand(path string) (string, []string) {
+	return path, nil
+}
@@ -0,0 +1,29 @@
+package platform
+
+import (
+	"os"
+)
+
+// windowsEnvKeys are kept from the environment of the CLI since Windows
+// programs cannot start without some of them
+var windowsEnvKeys = []string{"PATH", "PATHEXT", "SYSTEMROOT", "COMSPEC", "TEMP", "TMP"}
+
+// IsolatedEnv keeps the PATH of the CLI instead of the Unix path, which does
+// not exist on Windows
+func IsolatedEnv(string) map[string]string {
+	env := map[string]string{}
+
+	for _, key := range windowsEnvKeys {
+		if value, found := os.LookupEnv(key); found {
+			env[key] = value
+		}
+	}
+
+	return env
+}
+
+// ScriptCommand runs the script at path with bash, e.g. from Git for
+// Windows, since Windows cannot execute scripts by their shebang
+func ScriptCommand(path string) (string, []string) {
+	return "bash", []string{path}
+}
@@ -12,7 +12,7 @@ func AbsolutifyPath(pathToManifest string, pathToFile string, fs boshsys.FileSys
 		return pathToFile, nil
 	}
 
-	if strings.HasPrefix(pathToFile, "file:///") || strings.HasPrefix(pathToFile, "/") {
+	if strings.HasPrefix(pathToFile, "file:///") || strings.HasPrefix(pathToFile, "/") || filepath.IsAbs(pathToFile) {
 		return pathToFile, nil
 	}
 
@@ -30,7 +30,7 @@ func AbsolutifyPath(pathToManifest string, pathToFile string, fs boshsys.FileSys
 		absPath = filepath.Join(filepath.Dir(pathToManifest), pathToFile)
 	} else {
 		pathToFile = strings.Replace(pathToFile, "file://", "", 1)
-		absPath = gopath.Join(gopath.Dir(pathToManifest), pathToFile)
+		absPath = gopath.Join(filepath.ToSlash(filepath.Dir(pathToManifest)), pathToFile)
 		absPath = "file://" + absPath
 	}
 
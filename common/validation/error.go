@@ -0,0 +1,19 @@
+package validation
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// Error is the message of errors that report invalid manifests, so that the
+// CLI can tell them apart from failures of the IaaS or of the agent
+type Error string
+
+func (e Error) Error() string {
+	return string(e)
+}
+
+// WrapError wraps the errors found while validating like bosherr.WrapError
+// does, keeping their message and multiline layout
+func WrapError(cause error, msg string) error {
+	return bosherr.WrapComplexError(cause, Error(msg))
+}
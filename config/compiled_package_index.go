@@ -0,0 +1,159 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	biindex "github.com/cloudfoundry/bosh-cli/index"
+)
+
+type compiledPackageIndex struct {
+	deploymentStateService DeploymentStateService
+	records                func(*DeploymentState) *[]CompiledPackageRecord
+	scope                  func(*DeploymentState) string
+}
+
+// NewCompiledPackageIndex keeps compiled packages in the deployment state so
+// that the next deploy only recompiles packages whose fingerprint (or a
+// dependency's fingerprint) changed. When the agent compiles into a blobstore
+// that outlives the VM, entries are kept per blobstore and stemcell as
+// recorded in CompiledPackagesScope. Otherwise the blobs live in the
+// blobstore of the VM's agent, so entries of other VMs are never found and
+// are dropped on save.
+func NewCompiledPackageIndex(deploymentStateService DeploymentStateService) biindex.Index {
+	return compiledPackageIndex{
+		deploymentStateService: deploymentStateService,
+		records: func(deploymentState *DeploymentState) *[]CompiledPackageRecord {
+			return &deploymentState.CompiledPackages
+		},
+		scope: func(deploymentState *DeploymentState) string {
+			return deploymentState.CompiledPackagesScope
+		},
+	}
+}
+
+// NewSourceBlobIndex keeps release package archives uploaded for the agent
+// in the deployment state, so that unchanged packages are not uploaded again.
+// Like compiled packages, entries are kept per blobstore as recorded in
+// SourceBlobsScope when the blobstore outlives the VM, and entries of other
+// VMs are dropped on save otherwise.
+func NewSourceBlobIndex(deploymentStateService DeploymentStateService) biindex.Index {
+	return compiledPackageIndex{
+		deploymentStateService: deploymentStateService,
+		records: func(deploymentState *DeploymentState) *[]CompiledPackageRecord {
+			return &deploymentState.SourceBlobs
+		},
+		scope: func(deploymentState *DeploymentState) string {
+			return deploymentState.SourceBlobsScope
+		},
+	}
+}
+
+func (i compiledPackageIndex) Find(key interface{}, value interface{}) error {
+	rawKey, err := json.Marshal(key)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling index key")
+	}
+
+	deploymentState, err := i.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	scope := i.scope(&deploymentState)
+
+	if scope == "" && deploymentState.CurrentVMCID == "" {
+		return biindex.ErrNotFound
+	}
+
+	for _, record := range *i.records(&deploymentState) {
+		if i.inScope(record, scope, deploymentState.CurrentVMCID) && i.sameKey(record.Key, rawKey) {
+			err := json.Unmarshal(record.Value, value)
+			if err != nil {
+				return bosherr.WrapError(err, "Unmarshalling index record")
+			}
+
+			return nil
+		}
+	}
+
+	return biindex.ErrNotFound
+}
+
+func (i compiledPackageIndex) Save(key interface{}, value interface{}) error {
+	rawKey, err := json.Marshal(key)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling index key")
+	}
+
+	rawValue, err := json.Marshal(value)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling index record")
+	}
+
+	deploymentState, err := i.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	scope := i.scope(&deploymentState)
+	records := []CompiledPackageRecord{}
+
+	for _, record := range *i.records(&deploymentState) {
+		// records of other scopes are kept since their blobs outlive the VM
+		if record.Scope != "" && record.Scope != scope {
+			records = append(records, record)
+			continue
+		}
+
+		if i.inScope(record, scope, deploymentState.CurrentVMCID) && !i.sameKey(record.Key, rawKey) {
+			records = append(records, record)
+		}
+	}
+
+	if scope != "" {
+		records = append(records, CompiledPackageRecord{
+			Scope: scope,
+			Key:   rawKey,
+			Value: rawValue,
+		})
+	} else if deploymentState.CurrentVMCID != "" {
+		records = append(records, CompiledPackageRecord{
+			VMCID: deploymentState.CurrentVMCID,
+			Key:   rawKey,
+			Value: rawValue,
+		})
+	}
+
+	*i.records(&deploymentState) = records
+
+	err = i.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+// inScope is true for records of the scope, or of the VM when there is no scope
+func (i compiledPackageIndex) inScope(record CompiledPackageRecord, scope, vmCID string) bool {
+	if scope != "" {
+		return record.Scope == scope
+	}
+
+	return record.Scope == "" && record.VMCID == vmCID
+}
+
+// sameKey ignores formatting since the deployment state is saved indented
+func (i compiledPackageIndex) sameKey(recordKey, rawKey []byte) bool {
+	compactKey := &bytes.Buffer{}
+
+	err := json.Compact(compactKey, recordKey)
+	if err != nil {
+		return false
+	}
+
+	return bytes.Equal(compactKey.Bytes(), rawKey)
+}
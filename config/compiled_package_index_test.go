@@ -0,0 +1,234 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/config"
+	biindex "github.com/cloudfoundry/bosh-cli/index"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CompiledPackageIndex", func() {
+	type packageKey struct {
+		Name        string
+		Fingerprint string
+	}
+
+	type packageRecord struct {
+		BlobID string
+	}
+
+	var (
+		index                  biindex.Index
+		deploymentStateService DeploymentStateService
+		vmRepo                 VMRepo
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, &fakeuuid.FakeGenerator{}, logger, "/fake/path")
+		vmRepo = NewVMRepo(deploymentStateService)
+		index = NewCompiledPackageIndex(deploymentStateService)
+
+		err := vmRepo.UpdateCurrent("fake-vm-cid")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("finds records saved for the current vm", func() {
+		err := index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		var record packageRecord
+		err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record).To(Equal(packageRecord{BlobID: "blob-id"}))
+
+		deploymentState, err := deploymentStateService.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentState.CompiledPackages).To(HaveLen(1))
+		Expect(deploymentState.CompiledPackages[0].VMCID).To(Equal("fake-vm-cid"))
+	})
+
+	It("does not find records with a different key", func() {
+		err := index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		var record packageRecord
+		err = index.Find(packageKey{Name: "pkg", Fingerprint: "other-fp"}, &record)
+		Expect(err).To(Equal(biindex.ErrNotFound))
+	})
+
+	It("overwrites records with the same key", func() {
+		err := index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "new-blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		var record packageRecord
+		err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(record).To(Equal(packageRecord{BlobID: "new-blob-id"}))
+
+		deploymentState, err := deploymentStateService.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentState.CompiledPackages).To(HaveLen(1))
+	})
+
+	It("does not find records of a previous vm and drops them on save", func() {
+		err := index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		err = vmRepo.UpdateCurrent("fake-new-vm-cid")
+		Expect(err).ToNot(HaveOccurred())
+
+		var record packageRecord
+		err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+		Expect(err).To(Equal(biindex.ErrNotFound))
+
+		err = index.Save(packageKey{Name: "other-pkg", Fingerprint: "fp"}, packageRecord{BlobID: "other-blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		deploymentState, err := deploymentStateService.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentState.CompiledPackages).To(HaveLen(1))
+		Expect(deploymentState.CompiledPackages[0].VMCID).To(Equal("fake-new-vm-cid"))
+	})
+
+	Context("when packages are compiled into a blobstore that outlives the vm", func() {
+		setScope := func(scope string) {
+			err := deploymentStateService.Update(func(deploymentState *DeploymentState) error {
+				deploymentState.CompiledPackagesScope = scope
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		BeforeEach(func() {
+			setScope("dav http://10.0.0.6:25250 fake-stemcell/1")
+		})
+
+		It("finds records of a previous vm", func() {
+			err := index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = vmRepo.UpdateCurrent("fake-new-vm-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			var record packageRecord
+			err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record).To(Equal(packageRecord{BlobID: "blob-id"}))
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.CompiledPackages).To(HaveLen(1))
+			Expect(deploymentState.CompiledPackages[0].Scope).To(Equal("dav http://10.0.0.6:25250 fake-stemcell/1"))
+			Expect(deploymentState.CompiledPackages[0].VMCID).To(BeEmpty())
+		})
+
+		It("does not find records of another stemcell or blobstore but keeps them on save", func() {
+			err := index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			setScope("dav http://10.0.0.6:25250 fake-stemcell/2")
+
+			var record packageRecord
+			err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+			Expect(err).To(Equal(biindex.ErrNotFound))
+
+			err = index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "other-blob-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			setScope("dav http://10.0.0.6:25250 fake-stemcell/1")
+
+			err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record).To(Equal(packageRecord{BlobID: "blob-id"}))
+		})
+
+		It("does not find records of the vm's own blobstore", func() {
+			setScope("")
+
+			err := index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			setScope("dav http://10.0.0.6:25250 fake-stemcell/1")
+
+			var record packageRecord
+			err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+			Expect(err).To(Equal(biindex.ErrNotFound))
+		})
+	})
+
+	It("does not keep records when there is no current vm", func() {
+		err := vmRepo.ClearCurrent()
+		Expect(err).ToNot(HaveOccurred())
+
+		err = index.Save(packageKey{Name: "pkg", Fingerprint: "fp"}, packageRecord{BlobID: "blob-id"})
+		Expect(err).ToNot(HaveOccurred())
+
+		var record packageRecord
+		err = index.Find(packageKey{Name: "pkg", Fingerprint: "fp"}, &record)
+		Expect(err).To(Equal(biindex.ErrNotFound))
+	})
+})
+
+var _ = Describe("SourceBlobIndex", func() {
+	It("keeps records of the current vm apart from compiled packages", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		deploymentStateService := NewFileSystemDeploymentStateService(fs, &fakeuuid.FakeGenerator{}, logger, "/fake/path")
+
+		err := NewVMRepo(deploymentStateService).UpdateCurrent("fake-vm-cid")
+		Expect(err).ToNot(HaveOccurred())
+
+		index := NewSourceBlobIndex(deploymentStateService)
+
+		err = index.Save("fake-key", "fake-blob-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		var blobID string
+		err = index.Find("fake-key", &blobID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("fake-blob-id"))
+
+		err = NewCompiledPackageIndex(deploymentStateService).Find("fake-key", &blobID)
+		Expect(err).To(Equal(biindex.ErrNotFound))
+
+		deploymentState, err := deploymentStateService.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentState.SourceBlobs).To(HaveLen(1))
+		Expect(deploymentState.SourceBlobs[0].VMCID).To(Equal("fake-vm-cid"))
+		Expect(deploymentState.CompiledPackages).To(BeEmpty())
+	})
+
+	It("keeps records of a blobstore that outlives the vm for the next vm", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		deploymentStateService := NewFileSystemDeploymentStateService(fs, &fakeuuid.FakeGenerator{}, logger, "/fake/path")
+
+		err := deploymentStateService.Update(func(deploymentState *DeploymentState) error {
+			deploymentState.CurrentVMCID = "fake-vm-cid"
+			deploymentState.SourceBlobsScope = "dav http://10.0.0.6:25250"
+			return nil
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		index := NewSourceBlobIndex(deploymentStateService)
+
+		err = index.Save("fake-key", "fake-blob-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = NewVMRepo(deploymentStateService).UpdateCurrent("fake-new-vm-cid")
+		Expect(err).ToNot(HaveOccurred())
+
+		var blobID string
+		err = index.Find("fake-key", &blobID)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(blobID).To(Equal("fake-blob-id"))
+	})
+})
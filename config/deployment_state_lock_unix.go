@@ -0,0 +1,37 @@
+//go:build !windows
+// +build !windows
+
+package config
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+type fileDescriptor interface {
+	Fd() uintptr
+}
+
+// tryLockFile takes an exclusive advisory lock without blocking.
+// Files without a descriptor (e.g. in-memory test file systems) are not locked.
+func tryLockFile(file interface{}) (bool, error) {
+	f, ok := file.(fileDescriptor)
+	if !ok {
+		return true, nil
+	}
+
+	err := unix.Flock(int(f.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if err == unix.EWOULDBLOCK {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+func unlockFile(file interface{}) error {
+	f, ok := file.(fileDescriptor)
+	if !ok {
+		return nil
+	}
+
+	return unix.Flock(int(f.Fd()), unix.LOCK_UN)
+}
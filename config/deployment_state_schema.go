@@ -0,0 +1,84 @@
+package config
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// DeploymentStateSchemaVersion is the schema version of the deployment state
+// written by this CLI. Bump it together with a migration in
+// deploymentStateMigrations whenever existing state has to be rewritten to
+// be understood.
+const DeploymentStateSchemaVersion = 1
+
+// deploymentStateMigration rewrites the top level keys of a deployment state
+// from the schema version before it
+type deploymentStateMigration func(state map[string]json.RawMessage) error
+
+// deploymentStateMigrations[i] upgrades a deployment state from schema
+// version i to i+1
+var deploymentStateMigrations = []deploymentStateMigration{
+	// version 0 is the state written before the schema version was recorded,
+	// its keys are read as they are
+	func(map[string]json.RawMessage) error { return nil },
+}
+
+// versionedDeploymentState is the deployment state as it is stored
+type versionedDeploymentState struct {
+	SchemaVersion int `json:"schema_version"`
+	DeploymentState
+}
+
+func marshalDeploymentState(deploymentState DeploymentState) ([]byte, error) {
+	return json.MarshalIndent(versionedDeploymentState{
+		SchemaVersion:   DeploymentStateSchemaVersion,
+		DeploymentState: deploymentState,
+	}, "", "    ")
+}
+
+// migrateDeploymentState upgrades the contents of the deployment state stored
+// at location to DeploymentStateSchemaVersion and reports whether it had to
+// be upgraded. State written by a newer CLI is rejected rather than read
+// without the keys this CLI does not know about.
+func migrateDeploymentState(contents []byte, location string) ([]byte, bool, error) {
+	var state map[string]json.RawMessage
+
+	err := json.Unmarshal(contents, &state)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", location)
+	}
+
+	schemaVersion := 0
+
+	if rawSchemaVersion, found := state["schema_version"]; found {
+		err = json.Unmarshal(rawSchemaVersion, &schemaVersion)
+		if err != nil {
+			return nil, false, bosherr.WrapErrorf(err, "Unmarshalling schema version of deployment state file '%s'", location)
+		}
+	}
+
+	if schemaVersion > DeploymentStateSchemaVersion {
+		return nil, false, bosherr.Errorf(
+			"Deployment state file '%s' has schema version %d, but this CLI only supports up to version %d, upgrade the CLI",
+			location, schemaVersion, DeploymentStateSchemaVersion)
+	}
+
+	if schemaVersion == DeploymentStateSchemaVersion {
+		return contents, false, nil
+	}
+
+	for version := schemaVersion; version < DeploymentStateSchemaVersion; version++ {
+		err = deploymentStateMigrations[version](state)
+		if err != nil {
+			return nil, false, bosherr.WrapErrorf(err, "Migrating deployment state file '%s' from schema version %d to %d", location, version, version+1)
+		}
+	}
+
+	migratedContents, err := json.Marshal(state)
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Marshalling migrated deployment state file '%s'", location)
+	}
+
+	return migratedContents, true, nil
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"encoding/json"
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
 type DeploymentState struct {
@@ -15,6 +20,73 @@ type DeploymentState struct {
 	Disks              []DiskRecord     `json:"disks"`
 	Stemcells          []StemcellRecord `json:"stemcells"`
 	Releases           []ReleaseRecord  `json:"releases"`
+
+	OrphanedDisks []OrphanedDiskRecord `json:"orphaned_disks,omitempty"`
+
+	CurrentVMNetworkCloudProperties map[string]biproperty.Map `json:"current_vm_network_cloud_properties,omitempty"`
+
+	// UnverifiedConvergence is the convergence policy of the last deploy
+	// when it did not wait for the jobs to be running
+	UnverifiedConvergence string `json:"unverified_convergence,omitempty"`
+
+	CompiledPackages []CompiledPackageRecord `json:"compiled_packages,omitempty"`
+
+	// CompiledPackagesScope names the agent blobstore and the stemcell that
+	// packages of the current deploy are compiled into and for. It is empty
+	// when the agent keeps compiled packages in its own blobstore, which is
+	// deleted with the VM.
+	CompiledPackagesScope string `json:"compiled_packages_scope,omitempty"`
+
+	// SourceBlobs are release package archives uploaded for the agent,
+	// keyed by archive digest
+	SourceBlobs []CompiledPackageRecord `json:"source_blobs,omitempty"`
+
+	// SourceBlobsScope names the blobstore package archives of the current
+	// deploy are uploaded to. It is empty when they are uploaded to the
+	// agent's own blobstore, which is deleted with the VM.
+	SourceBlobsScope string `json:"source_blobs_scope,omitempty"`
+
+	// CurrentCPI names the cloud_provider.cpis entry that created the current
+	// VM and that stemcells are uploaded with
+	CurrentCPI string `json:"current_cpi,omitempty"`
+
+	// InstanceTransitions are the states the instances entered during their last deploy
+	InstanceTransitions []InstanceTransitionRecord `json:"instance_transitions,omitempty"`
+
+	// AgentCertFingerprint is the pinned SHA-256 fingerprint of the agent
+	// mbus certificate, recorded on first contact
+	AgentCertFingerprint string `json:"agent_cert_fingerprint,omitempty"`
+
+	// CredsRotatedAt is when rotate-creds last replaced the mbus credentials
+	CredsRotatedAt *time.Time `json:"creds_rotated_at,omitempty"`
+}
+
+// CompiledPackageRecord is a package compiled by the agent of the VM with
+// VMCID, or into the blobstore and for the stemcell named by Scope when the
+// blob outlives the VM. Key captures the package fingerprint and the
+// fingerprints of its dependencies.
+type CompiledPackageRecord struct {
+	VMCID string          `json:"vm_cid,omitempty"`
+	Scope string          `json:"scope,omitempty"`
+	Key   json.RawMessage `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// SwitchCPI makes the named cloud_provider.cpis entry the current one.
+// Stemcells recorded before records named their CPI were uploaded with the
+// previous current CPI.
+func (s *DeploymentState) SwitchCPI(cpiName string) {
+	if s.Stemcells != nil {
+		stemcells := append([]StemcellRecord{}, s.Stemcells...)
+		for i := range stemcells {
+			if stemcells[i].CPI == "" {
+				stemcells[i].CPI = s.CurrentCPI
+			}
+		}
+		s.Stemcells = stemcells
+	}
+
+	s.CurrentCPI = cpiName
 }
 
 type StemcellRecord struct {
@@ -22,6 +94,15 @@ type StemcellRecord struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
 	CID     string `json:"cid"`
+
+	// Imported is set for stemcells recorded with env-stemcells --import,
+	// which another environment uploaded and owns, so they are never deleted
+	// from the IaaS
+	Imported bool `json:"imported,omitempty"`
+
+	// CPI names the cloud_provider.cpis entry the stemcell was uploaded
+	// with, since its CID means nothing to the other CPIs
+	CPI string `json:"cpi,omitempty"`
 }
 
 type DiskRecord struct {
@@ -31,6 +112,13 @@ type DiskRecord struct {
 	CloudProperties biproperty.Map `json:"cloud_properties"`
 }
 
+// OrphanedDiskRecord is a persistent disk that was kept in the cloud when its
+// deployment was deleted, so that it can be attached to a later deployment.
+type OrphanedDiskRecord struct {
+	DiskRecord
+	OrphanedAt time.Time `json:"orphaned_at"`
+}
+
 type ReleaseRecord struct {
 	ID      string `json:"id"`
 	Name    string `json:"name"`
@@ -42,5 +130,45 @@ type DeploymentStateService interface {
 	Exists() bool
 	Load() (DeploymentState, error)
 	Save(DeploymentState) error
+
+	// Update loads, modifies and saves the deployment state as one step
+	// that is safe against other processes updating the same state file.
+	Update(func(*DeploymentState) error) error
+
 	Cleanup() error
 }
+
+// parseDeploymentState unmarshals the contents of the deployment state stored
+// at location and reports whether it has to be saved because it was migrated
+// from an older schema version or defaults had to be initialized. Missing
+// (nil) contents result in a new deployment state.
+func parseDeploymentState(contents []byte, location string, uuidGenerator boshuuid.Generator) (DeploymentState, bool, error) {
+	deploymentState := DeploymentState{}
+	migrated := false
+
+	if contents != nil {
+		var err error
+
+		contents, migrated, err = migrateDeploymentState(contents, location)
+		if err != nil {
+			return DeploymentState{}, false, err
+		}
+
+		err = json.Unmarshal(contents, &deploymentState)
+		if err != nil {
+			return DeploymentState{}, false, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", location)
+		}
+	}
+
+	if deploymentState.DirectorID != "" {
+		return deploymentState, migrated, nil
+	}
+
+	uuid, err := uuidGenerator.Generate()
+	if err != nil {
+		return DeploymentState{}, false, bosherr.WrapErrorf(bosherr.WrapError(err, "Generating DirectorID"), "Initializing deployment state defaults")
+	}
+	deploymentState.DirectorID = uuid
+
+	return deploymentState, true, nil
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
@@ -14,6 +16,16 @@ type DiskRepo interface {
 	Find(cid string) (DiskRecord, bool, error)
 	All() ([]DiskRecord, error)
 	Delete(DiskRecord) error
+
+	// Orphan moves the disk record to the orphaned disks, which are not
+	// cleaned up with unused disks and survive deleting the deployment.
+	Orphan(record DiskRecord, orphanedAt time.Time) error
+	AllOrphaned() ([]OrphanedDiskRecord, error)
+	FindOrphaned(cid string) (OrphanedDiskRecord, bool, error)
+	DeleteOrphaned(OrphanedDiskRecord) error
+	// AdoptOrphaned makes an orphaned disk the current disk again so that
+	// the next deploy attaches it.
+	AdoptOrphaned(OrphanedDiskRecord) (DiskRecord, error)
 }
 
 type diskRepo struct {
@@ -165,6 +177,121 @@ func (r diskRepo) ClearCurrent() error {
 	return nil
 }
 
+func (r diskRepo) Orphan(diskRecord DiskRecord, orphanedAt time.Time) error {
+	config, records, err := r.load()
+	if err != nil {
+		return err
+	}
+
+	newRecords := []DiskRecord{}
+	for _, record := range records {
+		if record.ID != diskRecord.ID {
+			newRecords = append(newRecords, record)
+		}
+	}
+
+	config.Disks = newRecords
+
+	if config.CurrentDiskID == diskRecord.ID {
+		config.CurrentDiskID = ""
+	}
+
+	config.OrphanedDisks = append(config.OrphanedDisks, OrphanedDiskRecord{
+		DiskRecord: diskRecord,
+		OrphanedAt: orphanedAt,
+	})
+
+	err = r.deploymentStateService.Save(config)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+func (r diskRepo) AllOrphaned() ([]OrphanedDiskRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return []OrphanedDiskRecord{}, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	if deploymentState.OrphanedDisks == nil {
+		return []OrphanedDiskRecord{}, nil
+	}
+
+	return deploymentState.OrphanedDisks, nil
+}
+
+func (r diskRepo) FindOrphaned(cid string) (OrphanedDiskRecord, bool, error) {
+	records, err := r.AllOrphaned()
+	if err != nil {
+		return OrphanedDiskRecord{}, false, err
+	}
+
+	for _, record := range records {
+		if record.CID == cid {
+			return record, true, nil
+		}
+	}
+
+	return OrphanedDiskRecord{}, false, nil
+}
+
+func (r diskRepo) DeleteOrphaned(orphanedRecord OrphanedDiskRecord) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	deploymentState.OrphanedDisks = r.withoutOrphaned(deploymentState.OrphanedDisks, orphanedRecord)
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+
+	return nil
+}
+
+func (r diskRepo) AdoptOrphaned(orphanedRecord OrphanedDiskRecord) (DiskRecord, error) {
+	config, records, err := r.load()
+	if err != nil {
+		return DiskRecord{}, err
+	}
+
+	if existingRecord, found := r.find(records, orphanedRecord.CID); found {
+		return DiskRecord{}, bosherr.Errorf("Failed to adopt disk cid '%s', existing record found '%#v'", orphanedRecord.CID, existingRecord)
+	}
+
+	diskRecord := orphanedRecord.DiskRecord
+
+	config.Disks = append(records, diskRecord)
+	config.CurrentDiskID = diskRecord.ID
+	config.OrphanedDisks = r.withoutOrphaned(config.OrphanedDisks, orphanedRecord)
+
+	err = r.deploymentStateService.Save(config)
+	if err != nil {
+		return DiskRecord{}, bosherr.WrapError(err, "Saving new config")
+	}
+
+	return diskRecord, nil
+}
+
+func (r diskRepo) withoutOrphaned(records []OrphanedDiskRecord, orphanedRecord OrphanedDiskRecord) []OrphanedDiskRecord {
+	newRecords := []OrphanedDiskRecord{}
+	for _, record := range records {
+		if record.ID != orphanedRecord.ID {
+			newRecords = append(newRecords, record)
+		}
+	}
+
+	if len(newRecords) == 0 {
+		return nil
+	}
+
+	return newRecords
+}
+
 func (r diskRepo) load() (DeploymentState, []DiskRecord, error) {
 	deploymentState, err := r.deploymentStateService.Load()
 	if err != nil {
@@ -1,6 +1,8 @@
 package config_test
 
 import (
+	"time"
+
 	. "github.com/cloudfoundry/bosh-cli/config"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -263,4 +265,84 @@ var _ = Describe("DiskRepo", func() {
 			Expect(found).To(BeFalse())
 		})
 	})
+
+	Describe("orphaned disks", func() {
+		var (
+			firstDisk  DiskRecord
+			secondDisk DiskRecord
+			orphanedAt time.Time
+		)
+
+		BeforeEach(func() {
+			var err error
+			firstDisk, err = repo.Save("fake-cid-1", 1024, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			secondDisk, err = repo.Save("fake-cid-2", 2048, cloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.UpdateCurrent(firstDisk.ID)
+			Expect(err).ToNot(HaveOccurred())
+
+			orphanedAt = time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+		})
+
+		It("moves an orphaned disk out of the disks and clears the current disk", func() {
+			err := repo.Orphan(firstDisk, orphanedAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			disks, err := repo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(disks).To(Equal([]DiskRecord{secondDisk}))
+
+			_, found, err := repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeFalse())
+
+			orphanedDisks, err := repo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedDisks).To(Equal([]OrphanedDiskRecord{{DiskRecord: firstDisk, OrphanedAt: orphanedAt}}))
+
+			orphanedDisk, found, err := repo.FindOrphaned("fake-cid-1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(orphanedDisk.ID).To(Equal(firstDisk.ID))
+		})
+
+		It("deletes orphaned disk records", func() {
+			err := repo.Orphan(firstDisk, orphanedAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.DeleteOrphaned(OrphanedDiskRecord{DiskRecord: firstDisk})
+			Expect(err).ToNot(HaveOccurred())
+
+			orphanedDisks, err := repo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedDisks).To(BeEmpty())
+		})
+
+		It("adopts an orphaned disk as the current disk", func() {
+			err := repo.Orphan(firstDisk, orphanedAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			adoptedDisk, err := repo.AdoptOrphaned(OrphanedDiskRecord{DiskRecord: firstDisk, OrphanedAt: orphanedAt})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(adoptedDisk).To(Equal(firstDisk))
+
+			currentDisk, found, err := repo.FindCurrent()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(currentDisk).To(Equal(firstDisk))
+
+			orphanedDisks, err := repo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedDisks).To(BeEmpty())
+		})
+
+		It("does not adopt a disk that is still recorded as a disk", func() {
+			_, err := repo.AdoptOrphaned(OrphanedDiskRecord{DiskRecord: secondDisk})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to adopt disk cid 'fake-cid-2'"))
+		})
+	})
 })
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
@@ -20,6 +22,21 @@ type FakeDiskRepo struct {
 	DeleteErr    error
 
 	allOutput diskRepoAllOutput
+
+	OrphanInputs []DiskRepoOrphanInput
+	OrphanErr    error
+
+	OrphanedDisks        []biconfig.OrphanedDiskRecord
+	AllOrphanedErr       error
+	DeleteOrphanedInputs []biconfig.OrphanedDiskRecord
+	DeleteOrphanedErr    error
+	AdoptOrphanedInputs  []biconfig.OrphanedDiskRecord
+	AdoptOrphanedErr     error
+}
+
+type DiskRepoOrphanInput struct {
+	DiskRecord biconfig.DiskRecord
+	OrphanedAt time.Time
 }
 
 type DiskRepoUpdateCurrentInput struct {
@@ -141,3 +158,36 @@ func (r *FakeDiskRepo) SetAllBehavior(diskRecords []biconfig.DiskRecord, err err
 		err:         err,
 	}
 }
+
+func (r *FakeDiskRepo) Orphan(diskRecord biconfig.DiskRecord, orphanedAt time.Time) error {
+	r.OrphanInputs = append(r.OrphanInputs, DiskRepoOrphanInput{
+		DiskRecord: diskRecord,
+		OrphanedAt: orphanedAt,
+	})
+
+	return r.OrphanErr
+}
+
+func (r *FakeDiskRepo) AllOrphaned() ([]biconfig.OrphanedDiskRecord, error) {
+	return r.OrphanedDisks, r.AllOrphanedErr
+}
+
+func (r *FakeDiskRepo) FindOrphaned(cid string) (biconfig.OrphanedDiskRecord, bool, error) {
+	for _, record := range r.OrphanedDisks {
+		if record.CID == cid {
+			return record, true, r.AllOrphanedErr
+		}
+	}
+
+	return biconfig.OrphanedDiskRecord{}, false, r.AllOrphanedErr
+}
+
+func (r *FakeDiskRepo) DeleteOrphaned(record biconfig.OrphanedDiskRecord) error {
+	r.DeleteOrphanedInputs = append(r.DeleteOrphanedInputs, record)
+	return r.DeleteOrphanedErr
+}
+
+func (r *FakeDiskRepo) AdoptOrphaned(record biconfig.OrphanedDiskRecord) (biconfig.DiskRecord, error) {
+	r.AdoptOrphanedInputs = append(r.AdoptOrphanedInputs, record)
+	return record.DiskRecord, r.AdoptOrphanedErr
+}
@@ -0,0 +1,34 @@
+package fakes
+
+import (
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+)
+
+type FakeInstanceStateRepo struct {
+	// Transitions are the states each instance entered, in order
+	Transitions map[string][]string
+
+	StartErr            error
+	RecordTransitionErr error
+
+	FindTransitionsRecords []biconfig.InstanceTransitionRecord
+	FindTransitionsErr     error
+}
+
+func NewFakeInstanceStateRepo() *FakeInstanceStateRepo {
+	return &FakeInstanceStateRepo{Transitions: map[string][]string{}}
+}
+
+func (r *FakeInstanceStateRepo) Start(instance string, state string) error {
+	r.Transitions[instance] = []string{state}
+	return r.StartErr
+}
+
+func (r *FakeInstanceStateRepo) RecordTransition(instance string, state string) error {
+	r.Transitions[instance] = append(r.Transitions[instance], state)
+	return r.RecordTransitionErr
+}
+
+func (r *FakeInstanceStateRepo) FindTransitions(instance string) ([]biconfig.InstanceTransitionRecord, error) {
+	return r.FindTransitionsRecords, r.FindTransitionsErr
+}
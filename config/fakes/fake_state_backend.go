@@ -0,0 +1,92 @@
+package fakes
+
+import (
+	"strconv"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+)
+
+// FakeStateBackend behaves like an object store with conditional writes.
+// Versions are increasing numbers starting at "1".
+type FakeStateBackend struct {
+	Contents []byte
+	Version  int
+
+	GetErr    error
+	PutErr    error
+	DeleteErr error
+
+	PutVersions    []string
+	DeleteVersions []string
+
+	// BeforePut is called before the precondition of a Put is checked,
+	// e.g. to simulate another process writing the state
+	BeforePut func()
+}
+
+func NewFakeStateBackend() *FakeStateBackend {
+	return &FakeStateBackend{}
+}
+
+func (b *FakeStateBackend) Location() string {
+	return "s3://fake-bucket/fake-state.json"
+}
+
+func (b *FakeStateBackend) Get() ([]byte, string, error) {
+	if b.GetErr != nil {
+		return nil, "", b.GetErr
+	}
+
+	if b.Contents == nil {
+		return nil, "", nil
+	}
+
+	return b.Contents, strconv.Itoa(b.Version), nil
+}
+
+func (b *FakeStateBackend) Put(contents []byte, version string) (string, error) {
+	b.PutVersions = append(b.PutVersions, version)
+
+	if b.BeforePut != nil {
+		beforePut := b.BeforePut
+		b.BeforePut = nil
+		beforePut()
+	}
+
+	if b.PutErr != nil {
+		return "", b.PutErr
+	}
+
+	if version != b.currentVersion() {
+		return "", biconfig.StateVersionConflictError{Location: b.Location()}
+	}
+
+	b.Contents = contents
+	b.Version++
+
+	return strconv.Itoa(b.Version), nil
+}
+
+func (b *FakeStateBackend) Delete(version string) error {
+	b.DeleteVersions = append(b.DeleteVersions, version)
+
+	if b.DeleteErr != nil {
+		return b.DeleteErr
+	}
+
+	if version != b.currentVersion() {
+		return biconfig.StateVersionConflictError{Location: b.Location()}
+	}
+
+	b.Contents = nil
+
+	return nil
+}
+
+func (b *FakeStateBackend) currentVersion() string {
+	if b.Contents == nil {
+		return ""
+	}
+
+	return strconv.Itoa(b.Version)
+}
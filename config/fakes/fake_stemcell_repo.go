@@ -85,6 +85,10 @@ func (fr *FakeStemcellRepo) Delete(stemcellRecord biconfig.StemcellRecord) error
 	return fr.DeleteErr
 }
 
+func (fr *FakeStemcellRepo) AllForCurrentCPI() ([]biconfig.StemcellRecord, error) {
+	return fr.All()
+}
+
 func (fr *FakeStemcellRepo) All() ([]biconfig.StemcellRecord, error) {
 	return fr.AllStemcellRecords, fr.AllErr
 }
@@ -110,6 +114,11 @@ func (fr *FakeStemcellRepo) Save(name, version, cid string) (biconfig.StemcellRe
 	return output.stemcellRecord, output.err
 }
 
+// SaveImported behaves like Save, the saved record is set by SetSaveBehavior
+func (fr *FakeStemcellRepo) SaveImported(name, version, cid string) (biconfig.StemcellRecord, error) {
+	return fr.Save(name, version, cid)
+}
+
 func (fr *FakeStemcellRepo) SetSaveBehavior(name, version, cid string, stemcellRecord biconfig.StemcellRecord, err error) error {
 	input := StemcellRepoSaveInput{
 		Name:    name,
@@ -1,5 +1,9 @@
 package fakes
 
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
 type FakeVMRepo struct {
 	UpdateCurrentCID string
 	UpdateCurrentErr error
@@ -7,6 +11,10 @@ type FakeVMRepo struct {
 	ClearCurrentCalled bool
 	ClearCurrentErr    error
 
+	NetworkCloudProperties                 map[string]biproperty.Map
+	FindCurrentNetworkCloudPropertiesErr   error
+	UpdateCurrentNetworkCloudPropertiesErr error
+
 	findCurrentOutput vmRepoFindCurrentOutput
 }
 
@@ -41,3 +49,15 @@ func (r *FakeVMRepo) ClearCurrent() error {
 	r.ClearCurrentCalled = true
 	return r.ClearCurrentErr
 }
+
+func (r *FakeVMRepo) FindCurrentNetworkCloudProperties() (map[string]biproperty.Map, error) {
+	return r.NetworkCloudProperties, r.FindCurrentNetworkCloudPropertiesErr
+}
+
+func (r *FakeVMRepo) UpdateCurrentNetworkCloudProperties(cloudProperties map[string]biproperty.Map) error {
+	if r.UpdateCurrentNetworkCloudPropertiesErr != nil {
+		return r.UpdateCurrentNetworkCloudPropertiesErr
+	}
+	r.NetworkCloudProperties = cloudProperties
+	return nil
+}
@@ -1,21 +1,33 @@
 package config
 
 import (
-	"encoding/json"
+	"bytes"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"code.cloudfoundry.org/clock"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
+const (
+	lockAttempts     = 40
+	lockInitialDelay = 50 * time.Millisecond
+	lockMaxDelay     = 2 * time.Second
+	updateAttempts   = 5
+)
+
 type fileSystemDeploymentStateService struct {
 	configPath    string
 	fs            boshsys.FileSystem
 	uuidGenerator boshuuid.Generator
+	timeService   biretrier.Clock
 	logger        boshlog.Logger
 	logTag        string
 }
@@ -25,6 +37,7 @@ func NewFileSystemDeploymentStateService(fs boshsys.FileSystem, uuidGenerator bo
 		configPath:    deploymentStatePath,
 		fs:            fs,
 		uuidGenerator: uuidGenerator,
+		timeService:   clock.NewClock(),
 		logger:        logger,
 		logTag:        "config",
 	}
@@ -39,6 +52,30 @@ func DeploymentStatePath(deploymentManifestPath string, deploymentStatePath stri
 	return filepath.Join(filepath.Dir(deploymentManifestPath), fmt.Sprintf("%s-state.json", baseFileName))
 }
 
+// ResolveDeploymentStatePath is DeploymentStatePath for a deployment state
+// path that may be a directory, e.g. a directory of CI artifacts. The state
+// file is then kept in that directory under the name it would have next to
+// the manifest. Directories that do not exist yet are only recognized by a
+// trailing separator.
+func ResolveDeploymentStatePath(fs boshsys.FileSystem, deploymentManifestPath string, deploymentStatePath string) string {
+	if deploymentStatePath == "" {
+		return DeploymentStatePath(deploymentManifestPath, deploymentStatePath)
+	}
+
+	isDir := strings.HasSuffix(deploymentStatePath, string(filepath.Separator))
+
+	if !isDir && fs.FileExists(deploymentStatePath) {
+		fileInfo, err := fs.Stat(deploymentStatePath)
+		isDir = err == nil && fileInfo.IsDir()
+	}
+
+	if isDir {
+		return filepath.Join(deploymentStatePath, filepath.Base(DeploymentStatePath(deploymentManifestPath, "")))
+	}
+
+	return deploymentStatePath
+}
+
 func (s *fileSystemDeploymentStateService) Path() string {
 	return s.configPath
 }
@@ -54,27 +91,90 @@ func (s *fileSystemDeploymentStateService) Load() (DeploymentState, error) {
 
 	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.configPath)
 
-	deploymentState := &DeploymentState{}
+	deploymentStateFileContents, err := s.read()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	deploymentState, changed, err := s.parse(deploymentStateFileContents)
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	if changed {
+		err = s.Save(deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Saving loaded deployment state")
+		}
+	}
+
+	return deploymentState, nil
+}
+
+// Update applies updateFunc to the latest deployment state and saves it while
+// holding a lock next to the state file, so that concurrent invocations sharing
+// the state do not overwrite each other's records. If the file still changes
+// underneath (e.g. a writer that does not take the lock), the update is re-applied
+// to the new contents.
+func (s *fileSystemDeploymentStateService) Update(updateFunc func(*DeploymentState) error) error {
+	if s.configPath == "" {
+		panic("configPath not yet set!")
+	}
+
+	lockFile, err := s.lock()
+	if err != nil {
+		return err
+	}
+
+	defer s.unlock(lockFile)
+
+	retrier := biretrier.NewRetrier(biretrier.Options{MaxAttempts: updateAttempts}, s.timeService, s.logger)
+
+	return retrier.Try(func() (bool, error) {
+		originalContents, err := s.read()
+		if err != nil {
+			return false, err
+		}
 
-	if s.fs.FileExists(s.configPath) {
-		deploymentStateFileContents, err := s.fs.ReadFile(s.configPath)
+		deploymentState, _, err := s.parse(originalContents)
 		if err != nil {
-			return DeploymentState{}, bosherr.WrapErrorf(err, "Reading deployment state file '%s'", s.configPath)
+			return false, err
 		}
-		s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentStateFileContents)
 
-		err = json.Unmarshal(deploymentStateFileContents, deploymentState)
+		err = updateFunc(&deploymentState)
 		if err != nil {
-			return DeploymentState{}, bosherr.WrapErrorf(err, "Unmarshalling deployment state file '%s'", s.configPath)
+			return false, err
 		}
+
+		currentContents, err := s.read()
+		if err != nil {
+			return false, err
+		}
+
+		if !bytes.Equal(originalContents, currentContents) {
+			return true, bosherr.Errorf("Deployment state file '%s' changed while updating it", s.configPath)
+		}
+
+		return false, s.Save(deploymentState)
+	})
+}
+
+func (s *fileSystemDeploymentStateService) read() ([]byte, error) {
+	if !s.fs.FileExists(s.configPath) {
+		return nil, nil
 	}
 
-	err := s.initDefaults(deploymentState)
+	deploymentStateFileContents, err := s.fs.ReadFile(s.configPath)
 	if err != nil {
-		return DeploymentState{}, bosherr.WrapErrorf(err, "Initializing deployment state defaults")
+		return nil, bosherr.WrapErrorf(err, "Reading deployment state file '%s'", s.configPath)
 	}
+	s.logger.Debug(s.logTag, "Deployment File Contents %#s", deploymentStateFileContents)
 
-	return *deploymentState, nil
+	return deploymentStateFileContents, nil
+}
+
+func (s *fileSystemDeploymentStateService) parse(deploymentStateFileContents []byte) (DeploymentState, bool, error) {
+	return parseDeploymentState(deploymentStateFileContents, s.configPath, s.uuidGenerator)
 }
 
 func (s *fileSystemDeploymentStateService) Save(deploymentState DeploymentState) error {
@@ -84,7 +184,7 @@ func (s *fileSystemDeploymentStateService) Save(deploymentState DeploymentState)
 
 	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
 
-	jsonContent, err := json.MarshalIndent(deploymentState, "", "    ")
+	jsonContent, err := marshalDeploymentState(deploymentState)
 	if err != nil {
 		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
 	}
@@ -97,21 +197,56 @@ func (s *fileSystemDeploymentStateService) Save(deploymentState DeploymentState)
 	return nil
 }
 
-func (s *fileSystemDeploymentStateService) initDefaults(deploymentState *DeploymentState) error {
-	if deploymentState.DirectorID == "" {
-		uuid, err := s.uuidGenerator.Generate()
+func (s *fileSystemDeploymentStateService) lockPath() string {
+	return s.configPath + ".lock"
+}
+
+func (s *fileSystemDeploymentStateService) lock() (boshsys.File, error) {
+	err := s.fs.MkdirAll(filepath.Dir(s.lockPath()), os.ModePerm)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Creating deployment state lock directory")
+	}
+
+	lockFile, err := s.fs.OpenFile(s.lockPath(), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Opening deployment state lock file '%s'", s.lockPath())
+	}
+
+	retrier := biretrier.NewRetrier(biretrier.Options{
+		MaxAttempts: lockAttempts,
+		Backoff:     biretrier.NewExponentialBackoff(lockInitialDelay, lockMaxDelay, 2),
+	}, s.timeService, s.logger)
+
+	err = retrier.Try(func() (bool, error) {
+		locked, err := tryLockFile(lockFile)
 		if err != nil {
-			return bosherr.WrapError(err, "Generating DirectorID")
+			return false, bosherr.WrapErrorf(err, "Locking deployment state lock file '%s'", s.lockPath())
 		}
-		deploymentState.DirectorID = uuid
 
-		err = s.Save(*deploymentState)
-		if err != nil {
-			return bosherr.WrapError(err, "Saving deployment state")
+		if !locked {
+			return true, bosherr.Errorf("Deployment state '%s' is locked by another process", s.configPath)
 		}
+
+		return false, nil
+	})
+	if err != nil {
+		_ = lockFile.Close()
+		return nil, err
 	}
 
-	return nil
+	return lockFile, nil
+}
+
+func (s *fileSystemDeploymentStateService) unlock(lockFile boshsys.File) {
+	err := unlockFile(lockFile)
+	if err != nil {
+		s.logger.Warn(s.logTag, "Failed to unlock deployment state lock file '%s': %s", s.lockPath(), err.Error())
+	}
+
+	err = lockFile.Close()
+	if err != nil {
+		s.logger.Warn(s.logTag, "Failed to close deployment state lock file '%s': %s", s.lockPath(), err.Error())
+	}
 }
 
 func (s *fileSystemDeploymentStateService) Cleanup() error {
@@ -119,5 +254,10 @@ func (s *fileSystemDeploymentStateService) Cleanup() error {
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Could not delete deployment state file %s", s.configPath)
 	}
+
+	err = s.fs.RemoveAll(s.lockPath())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Could not delete deployment state lock file %s", s.lockPath())
+	}
 	return nil
 }
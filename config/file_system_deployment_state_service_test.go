@@ -7,6 +7,7 @@ import (
 
 	"encoding/json"
 	"errors"
+	"fmt"
 	"path/filepath"
 
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -49,6 +50,30 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 		})
 	})
 
+	Describe("ResolveDeploymentStatePath", func() {
+		It("is based on the manifest path and name when statePath is NOT specified", func() {
+			Expect(ResolveDeploymentStatePath(fakeFs, "/path/to/some-manifest.yml", "")).To(Equal(filepath.Join("/", "path", "to", "some-manifest-state.json")))
+		})
+
+		It("is statePath when it is a file", func() {
+			fakeFs.WriteFileString("/artifacts/state.json", "{}")
+			Expect(ResolveDeploymentStatePath(fakeFs, "/path/to/some-manifest.yml", "/artifacts/state.json")).To(Equal("/artifacts/state.json"))
+		})
+
+		It("is statePath when it does not exist", func() {
+			Expect(ResolveDeploymentStatePath(fakeFs, "/path/to/some-manifest.yml", "/artifacts/state.json")).To(Equal("/artifacts/state.json"))
+		})
+
+		It("is in statePath when it is a directory", func() {
+			fakeFs.MkdirAll("/artifacts", 0755)
+			Expect(ResolveDeploymentStatePath(fakeFs, "/path/to/some-manifest.yml", "/artifacts")).To(Equal(filepath.Join("/", "artifacts", "some-manifest-state.json")))
+		})
+
+		It("is in statePath when it ends with a separator", func() {
+			Expect(ResolveDeploymentStatePath(fakeFs, "/path/to/some-manifest.yml", "/artifacts/")).To(Equal(filepath.Join("/", "artifacts", "some-manifest-state.json")))
+		})
+	})
+
 	Describe("Exists", func() {
 		It("returns true if the config file exists", func() {
 			fakeFs.WriteFileString(deploymentStatePath, "")
@@ -115,6 +140,45 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 			})
 		})
 
+		Context("when the config was written before schema versions were recorded", func() {
+			It("upgrades the config file to the current schema version", func() {
+				fakeFs.WriteFileString(deploymentStatePath, `{"director_id":"fake-director-id","current_vm_cid":"fake-vm-cid"}`)
+
+				deploymentState, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deploymentState.DirectorID).To(Equal("fake-director-id"))
+				Expect(deploymentState.CurrentVMCID).To(Equal("fake-vm-cid"))
+
+				deploymentStateFileContents, err := fakeFs.ReadFileString(deploymentStatePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deploymentStateFileContents).To(ContainSubstring(`"schema_version": 1`))
+				Expect(deploymentStateFileContents).To(ContainSubstring(`"current_vm_cid": "fake-vm-cid"`))
+			})
+		})
+
+		Context("when the config has the current schema version", func() {
+			It("does not rewrite the config file", func() {
+				fakeFs.WriteFileString(deploymentStatePath, `{"schema_version":1,"director_id":"fake-director-id"}`)
+
+				_, err := service.Load()
+				Expect(err).NotTo(HaveOccurred())
+
+				deploymentStateFileContents, err := fakeFs.ReadFileString(deploymentStatePath)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(deploymentStateFileContents).To(Equal(`{"schema_version":1,"director_id":"fake-director-id"}`))
+			})
+		})
+
+		Context("when the config was written by a CLI with a newer schema version", func() {
+			It("returns an error", func() {
+				fakeFs.WriteFileString(deploymentStatePath, `{"schema_version":2,"director_id":"fake-director-id"}`)
+
+				_, err := service.Load()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Deployment state file '/some/deployment.json' has schema version 2, but this CLI only supports up to version 1, upgrade the CLI"))
+			})
+		})
+
 		Context("when reading config file fails", func() {
 			BeforeEach(func() {
 				fakeFs.WriteFileString(deploymentStatePath, "{}")
@@ -186,7 +250,10 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 					},
 				},
 			}
-			expectedDeploymentStateFileContents, err := json.MarshalIndent(deploymentState, "", "    ")
+			expectedDeploymentStateFileContents, err := json.MarshalIndent(struct {
+				SchemaVersion int `json:"schema_version"`
+				DeploymentState
+			}{1, deploymentState}, "", "    ")
 			Expect(deploymentStateFileContents).To(Equal(string(expectedDeploymentStateFileContents)))
 		})
 
@@ -206,6 +273,104 @@ var _ = Describe("fileSystemDeploymentStateService", func() {
 		})
 	})
 
+	Describe("Update", func() {
+		It("saves the changes made to the loaded deployment state", func() {
+			fakeFs.WriteFileString(deploymentStatePath, `{"director_id":"fake-director-id","current_vm_cid":"fake-vm-cid"}`)
+
+			err := service.Update(func(state *DeploymentState) error {
+				Expect(state.CurrentVMCID).To(Equal("fake-vm-cid"))
+				state.CurrentStemcellID = "fake-stemcell-id"
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("fake-director-id"))
+			Expect(deploymentState.CurrentVMCID).To(Equal("fake-vm-cid"))
+			Expect(deploymentState.CurrentStemcellID).To(Equal("fake-stemcell-id"))
+		})
+
+		It("initializes the defaults with the same save", func() {
+			fakeUUIDGenerator.GeneratedUUID = "fake-director-id"
+
+			err := service.Update(func(state *DeploymentState) error {
+				state.CurrentStemcellID = "fake-stemcell-id"
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("fake-director-id"))
+			Expect(deploymentState.CurrentStemcellID).To(Equal("fake-stemcell-id"))
+		})
+
+		It("re-applies the update when the state file changes during the update", func() {
+			fakeFs.WriteFileString(deploymentStatePath, `{"director_id":"fake-director-id"}`)
+
+			calls := 0
+			err := service.Update(func(state *DeploymentState) error {
+				calls++
+				if calls == 1 {
+					fakeFs.WriteFileString(deploymentStatePath, `{"director_id":"fake-director-id","current_vm_cid":"fake-other-vm-cid"}`)
+				}
+				state.CurrentStemcellID = "fake-stemcell-id"
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.CurrentVMCID).To(Equal("fake-other-vm-cid"))
+			Expect(deploymentState.CurrentStemcellID).To(Equal("fake-stemcell-id"))
+		})
+
+		It("gives up when the state file keeps changing", func() {
+			fakeFs.WriteFileString(deploymentStatePath, `{"director_id":"fake-director-id"}`)
+
+			calls := 0
+			err := service.Update(func(state *DeploymentState) error {
+				calls++
+				fakeFs.WriteFileString(deploymentStatePath, fmt.Sprintf(`{"director_id":"fake-director-id","current_vm_cid":"fake-vm-cid-%d"}`, calls))
+				return nil
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Giving up after 5 attempts: Deployment state file '/some/deployment.json' changed while updating it"))
+			Expect(calls).To(Equal(5))
+		})
+
+		It("does not save when the update fails", func() {
+			fakeFs.WriteFileString(deploymentStatePath, `{"director_id":"fake-director-id"}`)
+
+			err := service.Update(func(state *DeploymentState) error {
+				state.CurrentStemcellID = "fake-stemcell-id"
+				return errors.New("fake-update-error")
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("fake-update-error"))
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.CurrentStemcellID).To(BeEmpty())
+		})
+
+		It("creates a lock file next to the state file", func() {
+			err := service.Update(func(*DeploymentState) error { return nil })
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeFs.FileExists("/some/deployment.json.lock")).To(BeTrue())
+		})
+
+		It("returns an error when the lock file cannot be opened", func() {
+			fakeFs.OpenFileErr = errors.New("fake-open-error")
+
+			err := service.Update(func(*DeploymentState) error { return nil })
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Opening deployment state lock file '/some/deployment.json.lock'"))
+		})
+	})
+
 	Describe("Cleanup", func() {
 		It("returns true if deployment state file deleted", func() {
 			fakeFs.WriteFileString(deploymentStatePath, "")
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// States an instance passes through while it is deployed. An instance
+// without recorded transitions is unstarted.
+const (
+	InstanceStateUnstarted        = "unstarted"
+	InstanceStateStemcellUploaded = "stemcell_uploaded"
+	InstanceStateVMCreated        = "vm_created"
+	InstanceStateDiskAttached     = "disk_attached"
+	InstanceStateApplied          = "applied"
+	InstanceStateRunning          = "running"
+)
+
+// InstanceTransitionRecord is an instance entering State from FromState at At
+type InstanceTransitionRecord struct {
+	Instance  string    `json:"instance"`
+	FromState string    `json:"from_state"`
+	State     string    `json:"state"`
+	At        time.Time `json:"at"`
+}
+
+type InstanceStateRepo interface {
+	// Start forgets the transitions of earlier deploys of the instance
+	// and records it entering state from unstarted
+	Start(instance string, state string) error
+	RecordTransition(instance string, state string) error
+	FindTransitions(instance string) ([]InstanceTransitionRecord, error)
+}
+
+type instanceStateRepo struct {
+	deploymentStateService DeploymentStateService
+	timeService            clock.Clock
+}
+
+func NewInstanceStateRepo(deploymentStateService DeploymentStateService, timeService clock.Clock) InstanceStateRepo {
+	return instanceStateRepo{
+		deploymentStateService: deploymentStateService,
+		timeService:            timeService,
+	}
+}
+
+func (r instanceStateRepo) Start(instance string, state string) error {
+	return r.update(func(deploymentState *DeploymentState) {
+		records := []InstanceTransitionRecord{}
+		for _, record := range deploymentState.InstanceTransitions {
+			if record.Instance != instance {
+				records = append(records, record)
+			}
+		}
+
+		deploymentState.InstanceTransitions = append(records, r.newRecord(instance, InstanceStateUnstarted, state))
+	})
+}
+
+func (r instanceStateRepo) RecordTransition(instance string, state string) error {
+	return r.update(func(deploymentState *DeploymentState) {
+		fromState := InstanceStateUnstarted
+		for _, record := range deploymentState.InstanceTransitions {
+			if record.Instance == instance {
+				fromState = record.State
+			}
+		}
+
+		deploymentState.InstanceTransitions = append(deploymentState.InstanceTransitions, r.newRecord(instance, fromState, state))
+	})
+}
+
+func (r instanceStateRepo) FindTransitions(instance string) ([]InstanceTransitionRecord, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	records := []InstanceTransitionRecord{}
+	for _, record := range deploymentState.InstanceTransitions {
+		if record.Instance == instance {
+			records = append(records, record)
+		}
+	}
+
+	return records, nil
+}
+
+func (r instanceStateRepo) newRecord(instance, fromState, state string) InstanceTransitionRecord {
+	return InstanceTransitionRecord{
+		Instance:  instance,
+		FromState: fromState,
+		State:     state,
+		At:        r.timeService.Now().UTC(),
+	}
+}
+
+func (r instanceStateRepo) update(updateFunc func(*DeploymentState)) error {
+	err := r.deploymentStateService.Update(func(deploymentState *DeploymentState) error {
+		updateFunc(deploymentState)
+		return nil
+	})
+	if err != nil {
+		return bosherr.WrapError(err, "Saving instance state transition")
+	}
+
+	return nil
+}
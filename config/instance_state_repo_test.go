@@ -0,0 +1,86 @@
+package config_test
+
+import (
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/config"
+)
+
+var _ = Describe("InstanceStateRepo", func() {
+	var (
+		repo                   InstanceStateRepo
+		deploymentStateService DeploymentStateService
+		timeService            *fakeclock.FakeClock
+		startTime              time.Time
+	)
+
+	BeforeEach(func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		fs := fakesys.NewFakeFileSystem()
+		deploymentStateService = NewFileSystemDeploymentStateService(fs, &fakeuuid.FakeGenerator{}, logger, "/fake/path")
+		startTime = time.Date(2017, time.March, 1, 10, 0, 0, 0, time.UTC)
+		timeService = fakeclock.NewFakeClock(startTime)
+		repo = NewInstanceStateRepo(deploymentStateService, timeService)
+	})
+
+	It("records the transitions of an instance with the state it came from", func() {
+		err := repo.Start("bosh/0", InstanceStateStemcellUploaded)
+		Expect(err).ToNot(HaveOccurred())
+
+		timeService.Increment(2 * time.Minute)
+		err = repo.RecordTransition("bosh/0", InstanceStateVMCreated)
+		Expect(err).ToNot(HaveOccurred())
+
+		records, err := repo.FindTransitions("bosh/0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(Equal([]InstanceTransitionRecord{
+			{Instance: "bosh/0", FromState: "unstarted", State: "stemcell_uploaded", At: startTime},
+			{Instance: "bosh/0", FromState: "stemcell_uploaded", State: "vm_created", At: startTime.Add(2 * time.Minute)},
+		}))
+
+		deploymentState, err := deploymentStateService.Load()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(deploymentState.InstanceTransitions).To(Equal(records))
+	})
+
+	It("forgets the transitions of the earlier deploy when an instance starts again", func() {
+		err := repo.Start("bosh/0", InstanceStateStemcellUploaded)
+		Expect(err).ToNot(HaveOccurred())
+		err = repo.RecordTransition("bosh/0", InstanceStateVMCreated)
+		Expect(err).ToNot(HaveOccurred())
+		err = repo.Start("other/0", InstanceStateStemcellUploaded)
+		Expect(err).ToNot(HaveOccurred())
+
+		timeService.Increment(time.Hour)
+		err = repo.Start("bosh/0", InstanceStateStemcellUploaded)
+		Expect(err).ToNot(HaveOccurred())
+
+		records, err := repo.FindTransitions("bosh/0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(Equal([]InstanceTransitionRecord{
+			{Instance: "bosh/0", FromState: "unstarted", State: "stemcell_uploaded", At: startTime.Add(time.Hour)},
+		}))
+
+		records, err = repo.FindTransitions("other/0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(HaveLen(1))
+	})
+
+	It("records transitions of instances that were not started from unstarted", func() {
+		err := repo.RecordTransition("bosh/0", InstanceStateApplied)
+		Expect(err).ToNot(HaveOccurred())
+
+		records, err := repo.FindTransitions("bosh/0")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(records).To(Equal([]InstanceTransitionRecord{
+			{Instance: "bosh/0", FromState: "unstarted", State: "applied", At: startTime},
+		}))
+	})
+})
@@ -0,0 +1,193 @@
+package config
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/clock"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
+)
+
+// StateBackend stores the deployment state outside of the local file system.
+// Versions are opaque to the caller (e.g. an S3 ETag or a GCS generation) and
+// are used as preconditions so that concurrent writers cannot silently
+// overwrite each other.
+type StateBackend interface {
+	Location() string
+
+	// Get returns nil contents and an empty version when nothing is stored yet
+	Get() (contents []byte, version string, err error)
+
+	// Put stores contents only if the stored version is still version.
+	// An empty version requires that nothing is stored yet.
+	// A failed precondition returns a StateVersionConflictError.
+	Put(contents []byte, version string) (newVersion string, err error)
+
+	// Delete removes the stored state if it is still at version
+	Delete(version string) error
+}
+
+type StateVersionConflictError struct {
+	Location string
+}
+
+func (e StateVersionConflictError) Error() string {
+	return fmt.Sprintf("Deployment state '%s' was changed by another process", e.Location)
+}
+
+type remoteDeploymentStateService struct {
+	backend       StateBackend
+	uuidGenerator boshuuid.Generator
+	timeService   biretrier.Clock
+	logger        boshlog.Logger
+	logTag        string
+
+	// version is the version of the state last read or written by this
+	// process; saves fail if the stored state moved on since then
+	version string
+	read    bool
+}
+
+func NewRemoteDeploymentStateService(backend StateBackend, uuidGenerator boshuuid.Generator, logger boshlog.Logger) DeploymentStateService {
+	return &remoteDeploymentStateService{
+		backend:       backend,
+		uuidGenerator: uuidGenerator,
+		timeService:   clock.NewClock(),
+		logger:        logger,
+		logTag:        "remoteDeploymentStateService",
+	}
+}
+
+func (s *remoteDeploymentStateService) Path() string {
+	return s.backend.Location()
+}
+
+func (s *remoteDeploymentStateService) Exists() bool {
+	contents, _, err := s.get()
+	if err != nil {
+		s.logger.Warn(s.logTag, "Checking existence of deployment state '%s': %s", s.backend.Location(), err.Error())
+		return false
+	}
+
+	return contents != nil
+}
+
+func (s *remoteDeploymentStateService) Load() (DeploymentState, error) {
+	s.logger.Debug(s.logTag, "Loading deployment state: %s", s.backend.Location())
+
+	contents, _, err := s.get()
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	deploymentState, changed, err := parseDeploymentState(contents, s.backend.Location(), s.uuidGenerator)
+	if err != nil {
+		return DeploymentState{}, err
+	}
+
+	if changed {
+		err = s.Save(deploymentState)
+		if err != nil {
+			return DeploymentState{}, bosherr.WrapError(err, "Saving loaded deployment state")
+		}
+	}
+
+	return deploymentState, nil
+}
+
+func (s *remoteDeploymentStateService) Save(deploymentState DeploymentState) error {
+	if !s.read {
+		_, _, err := s.get()
+		if err != nil {
+			return err
+		}
+	}
+
+	s.logger.Debug(s.logTag, "Saving deployment state %#v", deploymentState)
+
+	return s.put(deploymentState)
+}
+
+// Update re-applies updateFunc to the latest stored state whenever another
+// process saved the state between reading and writing it.
+func (s *remoteDeploymentStateService) Update(updateFunc func(*DeploymentState) error) error {
+	retrier := biretrier.NewRetrier(biretrier.Options{MaxAttempts: updateAttempts}, s.timeService, s.logger)
+
+	return retrier.Try(func() (bool, error) {
+		contents, _, err := s.get()
+		if err != nil {
+			return false, err
+		}
+
+		deploymentState, _, err := parseDeploymentState(contents, s.backend.Location(), s.uuidGenerator)
+		if err != nil {
+			return false, err
+		}
+
+		err = updateFunc(&deploymentState)
+		if err != nil {
+			return false, err
+		}
+
+		err = s.put(deploymentState)
+		if _, ok := err.(StateVersionConflictError); ok {
+			return true, err
+		}
+
+		return false, err
+	})
+}
+
+func (s *remoteDeploymentStateService) Cleanup() error {
+	if !s.read {
+		_, _, err := s.get()
+		if err != nil {
+			return err
+		}
+	}
+
+	err := s.backend.Delete(s.version)
+	if err != nil {
+		if _, ok := err.(StateVersionConflictError); ok {
+			return err
+		}
+		return bosherr.WrapErrorf(err, "Could not delete deployment state %s", s.backend.Location())
+	}
+
+	s.version = ""
+
+	return nil
+}
+
+func (s *remoteDeploymentStateService) get() ([]byte, string, error) {
+	contents, version, err := s.backend.Get()
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Reading deployment state '%s'", s.backend.Location())
+	}
+
+	s.version = version
+	s.read = true
+
+	return contents, version, nil
+}
+
+func (s *remoteDeploymentStateService) put(deploymentState DeploymentState) error {
+	jsonContent, err := marshalDeploymentState(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling deployment state into JSON")
+	}
+
+	version, err := s.backend.Put(jsonContent, s.version)
+	if err != nil {
+		if _, ok := err.(StateVersionConflictError); ok {
+			return err
+		}
+		return bosherr.WrapErrorf(err, "Writing deployment state '%s'", s.backend.Location())
+	}
+
+	s.version = version
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package config_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+
+	fakeconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+)
+
+var _ = Describe("remoteDeploymentStateService", func() {
+	var (
+		service           DeploymentStateService
+		backend           *fakeconfig.FakeStateBackend
+		fakeUUIDGenerator *fakeuuid.FakeGenerator
+	)
+
+	BeforeEach(func() {
+		backend = fakeconfig.NewFakeStateBackend()
+		fakeUUIDGenerator = fakeuuid.NewFakeGenerator()
+		fakeUUIDGenerator.GeneratedUUID = "fake-director-id"
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		service = NewRemoteDeploymentStateService(backend, fakeUUIDGenerator, logger)
+	})
+
+	Describe("Path", func() {
+		It("returns the location of the backend", func() {
+			Expect(service.Path()).To(Equal("s3://fake-bucket/fake-state.json"))
+		})
+	})
+
+	Describe("Exists", func() {
+		It("is true when state is stored", func() {
+			backend.Contents = []byte(`{}`)
+			backend.Version = 1
+			Expect(service.Exists()).To(BeTrue())
+		})
+
+		It("is false when nothing is stored", func() {
+			Expect(service.Exists()).To(BeFalse())
+		})
+
+		It("is false when the state cannot be read", func() {
+			backend.GetErr = errors.New("fake-get-error")
+			Expect(service.Exists()).To(BeFalse())
+		})
+	})
+
+	Describe("Load", func() {
+		It("initializes and stores a new state when nothing is stored", func() {
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("fake-director-id"))
+
+			Expect(backend.PutVersions).To(Equal([]string{""}))
+			Expect(string(backend.Contents)).To(ContainSubstring(`"director_id": "fake-director-id"`))
+		})
+
+		It("returns the stored state", func() {
+			backend.Contents = []byte(`{"schema_version":1,"director_id":"stored-director-id","current_vm_cid":"fake-vm-cid"}`)
+			backend.Version = 3
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("stored-director-id"))
+			Expect(deploymentState.CurrentVMCID).To(Equal("fake-vm-cid"))
+			Expect(backend.PutVersions).To(BeEmpty())
+		})
+
+		It("returns an error when the state cannot be read", func() {
+			backend.GetErr = errors.New("fake-get-error")
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Reading deployment state 's3://fake-bucket/fake-state.json'"))
+			Expect(err.Error()).To(ContainSubstring("fake-get-error"))
+		})
+
+		It("stores a state written before schema versions were recorded with the current schema version", func() {
+			backend.Contents = []byte(`{"director_id":"stored-director-id"}`)
+			backend.Version = 3
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.DirectorID).To(Equal("stored-director-id"))
+
+			Expect(backend.PutVersions).To(Equal([]string{"3"}))
+			Expect(string(backend.Contents)).To(ContainSubstring(`"schema_version": 1`))
+		})
+
+		It("returns an error when the stored state is invalid", func() {
+			backend.Contents = []byte(`not-json`)
+			backend.Version = 1
+
+			_, err := service.Load()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling deployment state file 's3://fake-bucket/fake-state.json'"))
+		})
+	})
+
+	Describe("Save", func() {
+		BeforeEach(func() {
+			backend.Contents = []byte(`{"schema_version":1,"director_id":"stored-director-id"}`)
+			backend.Version = 1
+		})
+
+		It("writes on top of the version that was loaded", func() {
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState.CurrentVMCID = "fake-vm-cid"
+			err = service.Save(deploymentState)
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState.CurrentDiskID = "fake-disk-id"
+			err = service.Save(deploymentState)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(backend.PutVersions).To(Equal([]string{"1", "2"}))
+			Expect(string(backend.Contents)).To(ContainSubstring("fake-disk-id"))
+		})
+
+		It("reads the current version when the state was not loaded before", func() {
+			err := service.Save(DeploymentState{DirectorID: "new-director-id"})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(backend.PutVersions).To(Equal([]string{"1"}))
+		})
+
+		It("fails without overwriting when another process saved the state since it was loaded", func() {
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+
+			backend.Contents = []byte(`{"director_id":"other-director-id"}`)
+			backend.Version = 2
+
+			err = service.Save(deploymentState)
+			Expect(err).To(Equal(StateVersionConflictError{Location: "s3://fake-bucket/fake-state.json"}))
+			Expect(err.Error()).To(Equal("Deployment state 's3://fake-bucket/fake-state.json' was changed by another process"))
+			Expect(string(backend.Contents)).To(ContainSubstring("other-director-id"))
+		})
+
+		It("returns an error when writing fails", func() {
+			backend.PutErr = errors.New("fake-put-error")
+
+			err := service.Save(DeploymentState{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Writing deployment state 's3://fake-bucket/fake-state.json'"))
+			Expect(err.Error()).To(ContainSubstring("fake-put-error"))
+		})
+	})
+
+	Describe("Update", func() {
+		BeforeEach(func() {
+			backend.Contents = []byte(`{"director_id":"stored-director-id"}`)
+			backend.Version = 1
+		})
+
+		It("saves the updated state", func() {
+			err := service.Update(func(deploymentState *DeploymentState) error {
+				deploymentState.CurrentVMCID = "fake-vm-cid"
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.CurrentVMCID).To(Equal("fake-vm-cid"))
+		})
+
+		It("re-applies the update to the latest state when another process saved it in between", func() {
+			backend.BeforePut = func() {
+				backend.Contents = []byte(`{"director_id":"stored-director-id","current_disk_id":"other-disk-id"}`)
+				backend.Version = 2
+			}
+
+			calls := 0
+			err := service.Update(func(deploymentState *DeploymentState) error {
+				calls++
+				deploymentState.CurrentVMCID = "fake-vm-cid"
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal(2))
+			Expect(backend.PutVersions).To(Equal([]string{"1", "2"}))
+
+			deploymentState, err := service.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.CurrentVMCID).To(Equal("fake-vm-cid"))
+			Expect(deploymentState.CurrentDiskID).To(Equal("other-disk-id"))
+		})
+
+		It("returns the error of the update without saving", func() {
+			err := service.Update(func(*DeploymentState) error {
+				return errors.New("fake-update-error")
+			})
+			Expect(err).To(MatchError("fake-update-error"))
+			Expect(backend.PutVersions).To(BeEmpty())
+		})
+	})
+
+	Describe("Cleanup", func() {
+		BeforeEach(func() {
+			backend.Contents = []byte(`{"director_id":"stored-director-id"}`)
+			backend.Version = 4
+		})
+
+		It("deletes the stored state at the current version", func() {
+			err := service.Cleanup()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(backend.DeleteVersions).To(Equal([]string{"4"}))
+			Expect(backend.Contents).To(BeNil())
+		})
+
+		It("returns an error when deleting fails", func() {
+			backend.DeleteErr = errors.New("fake-delete-error")
+
+			err := service.Cleanup()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-delete-error"))
+		})
+	})
+})
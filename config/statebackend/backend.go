@@ -0,0 +1,63 @@
+// Package statebackend stores the deployment state in object stores so that
+// machines without persistent disks, e.g. CI workers, can share it.
+package statebackend
+
+import (
+	"net/url"
+	"strings"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	S3Scheme  = "s3"
+	GCSScheme = "gs"
+)
+
+// IsRemote is true for state paths that name an object in a supported
+// object store, e.g. 's3://bucket/env/state.json' or 'gs://bucket/state.json'
+func IsRemote(statePath string) bool {
+	return strings.HasPrefix(statePath, S3Scheme+"://") || strings.HasPrefix(statePath, GCSScheme+"://")
+}
+
+// NewBackend returns the backend for a remote state path. The path is
+// validated when the backend is first used. Credentials are taken from the
+// environment in the same way as the AWS and Google Cloud CLIs do.
+func NewBackend(statePath string, logger boshlog.Logger) biconfig.StateBackend {
+	if strings.HasPrefix(statePath, GCSScheme+"://") {
+		return NewGCSBackend(statePath, logger)
+	}
+
+	return NewS3Backend(statePath, logger)
+}
+
+type objectLocation struct {
+	Bucket string
+	Key    string
+	Query  url.Values
+}
+
+func parseObjectLocation(statePath, scheme string) (objectLocation, error) {
+	objectURL, err := url.Parse(statePath)
+	if err != nil {
+		return objectLocation{}, bosherr.WrapErrorf(err, "Parsing remote deployment state location '%s'", statePath)
+	}
+
+	if objectURL.Scheme != scheme {
+		return objectLocation{}, bosherr.Errorf("Expected remote deployment state location '%s' to start with '%s://'", statePath, scheme)
+	}
+
+	location := objectLocation{
+		Bucket: objectURL.Host,
+		Key:    strings.TrimPrefix(objectURL.Path, "/"),
+		Query:  objectURL.Query(),
+	}
+
+	if location.Bucket == "" || location.Key == "" || strings.HasSuffix(location.Key, "/") {
+		return objectLocation{}, bosherr.Errorf("Expected remote deployment state location '%s' to include a bucket and an object key", statePath)
+	}
+
+	return location, nil
+}
@@ -0,0 +1,167 @@
+package statebackend
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"cloud.google.com/go/storage"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+)
+
+const gcsUserAgent = "bosh-cli"
+
+// gcsBackend uses object generations as versions and makes writes
+// conditional on them with generation preconditions.
+type gcsBackend struct {
+	statePath string
+	logger    boshlog.Logger
+	logTag    string
+
+	client   *storage.Client
+	location objectLocation
+	lock     sync.Mutex
+}
+
+func NewGCSBackend(statePath string, logger boshlog.Logger) biconfig.StateBackend {
+	return &gcsBackend{
+		statePath: statePath,
+		logger:    logger,
+		logTag:    "gcsStateBackend",
+	}
+}
+
+func (b *gcsBackend) Location() string {
+	return b.statePath
+}
+
+func (b *gcsBackend) Get() ([]byte, string, error) {
+	object, location, err := b.object()
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader, err := object.NewReader(context.Background())
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil, "", nil
+		}
+		return nil, "", bosherr.WrapErrorf(err, "Getting object '%s' from bucket '%s'", location.Key, location.Bucket)
+	}
+
+	defer reader.Close()
+
+	contents, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Reading object '%s' from bucket '%s'", location.Key, location.Bucket)
+	}
+
+	return contents, strconv.FormatInt(reader.Attrs.Generation, 10), nil
+}
+
+func (b *gcsBackend) Put(contents []byte, version string) (string, error) {
+	object, location, err := b.object()
+	if err != nil {
+		return "", err
+	}
+
+	conditions, err := b.conditions(version)
+	if err != nil {
+		return "", err
+	}
+
+	writer := object.If(conditions).NewWriter(context.Background())
+	writer.ContentType = "application/json"
+
+	_, err = writer.Write(contents)
+	if err != nil {
+		_ = writer.Close()
+		return "", b.wrapWriteErr(err, location)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return "", b.wrapWriteErr(err, location)
+	}
+
+	generation := strconv.FormatInt(writer.Attrs().Generation, 10)
+
+	b.logger.Debug(b.logTag, "Saved deployment state '%s' with generation %s", b.statePath, generation)
+
+	return generation, nil
+}
+
+func (b *gcsBackend) Delete(version string) error {
+	object, location, err := b.object()
+	if err != nil {
+		return err
+	}
+
+	if version != "" {
+		conditions, err := b.conditions(version)
+		if err != nil {
+			return err
+		}
+		object = object.If(conditions)
+	}
+
+	err = object.Delete(context.Background())
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil
+		}
+		return b.wrapWriteErr(err, location)
+	}
+
+	return nil
+}
+
+func (b *gcsBackend) conditions(version string) (storage.Conditions, error) {
+	if version == "" {
+		return storage.Conditions{DoesNotExist: true}, nil
+	}
+
+	generation, err := strconv.ParseInt(version, 10, 64)
+	if err != nil {
+		return storage.Conditions{}, bosherr.WrapErrorf(err, "Parsing generation '%s' of deployment state '%s'", version, b.statePath)
+	}
+
+	return storage.Conditions{GenerationMatch: generation}, nil
+}
+
+func (b *gcsBackend) wrapWriteErr(err error, location objectLocation) error {
+	if apiErr, ok := err.(*googleapi.Error); ok && apiErr.Code == http.StatusPreconditionFailed {
+		return biconfig.StateVersionConflictError{Location: b.statePath}
+	}
+
+	return bosherr.WrapErrorf(err, "Writing object '%s' to bucket '%s'", location.Key, location.Bucket)
+}
+
+func (b *gcsBackend) object() (*storage.ObjectHandle, objectLocation, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.client == nil {
+		location, err := parseObjectLocation(b.statePath, GCSScheme)
+		if err != nil {
+			return nil, objectLocation{}, err
+		}
+
+		client, err := storage.NewClient(context.Background(), option.WithUserAgent(gcsUserAgent))
+		if err != nil {
+			return nil, objectLocation{}, bosherr.WrapError(err, "Building GCS client")
+		}
+
+		b.client = client
+		b.location = location
+	}
+
+	return b.client.Bucket(b.location.Bucket).Object(b.location.Key), b.location, nil
+}
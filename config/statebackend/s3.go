@@ -0,0 +1,202 @@
+package statebackend
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+)
+
+const s3DefaultRegion = "us-east-1"
+
+// s3Backend uses ETags as versions. Writes are made conditional with the
+// If-Match and If-None-Match headers, which S3 and most S3 compatible
+// stores answer with '412 Precondition Failed' when the object changed.
+//
+// The region and an endpoint for S3 compatible stores can be given as
+// query parameters, e.g. 's3://bucket/state.json?region=eu-west-1'.
+type s3Backend struct {
+	statePath string
+	logger    boshlog.Logger
+	logTag    string
+
+	client   *s3.S3
+	location objectLocation
+	lock     sync.Mutex
+}
+
+func NewS3Backend(statePath string, logger boshlog.Logger) biconfig.StateBackend {
+	return &s3Backend{
+		statePath: statePath,
+		logger:    logger,
+		logTag:    "s3StateBackend",
+	}
+}
+
+func (b *s3Backend) Location() string {
+	return b.statePath
+}
+
+func (b *s3Backend) Get() ([]byte, string, error) {
+	client, location, err := b.s3Client()
+	if err != nil {
+		return nil, "", err
+	}
+
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(location.Bucket),
+		Key:    aws.String(location.Key),
+	})
+	if err != nil {
+		if b.isNotFound(err) {
+			return nil, "", nil
+		}
+		return nil, "", bosherr.WrapErrorf(err, "Getting object '%s' from bucket '%s'", location.Key, location.Bucket)
+	}
+
+	defer output.Body.Close()
+
+	contents, err := ioutil.ReadAll(output.Body)
+	if err != nil {
+		return nil, "", bosherr.WrapErrorf(err, "Reading object '%s' from bucket '%s'", location.Key, location.Bucket)
+	}
+
+	return contents, aws.StringValue(output.ETag), nil
+}
+
+func (b *s3Backend) Put(contents []byte, version string) (string, error) {
+	client, location, err := b.s3Client()
+	if err != nil {
+		return "", err
+	}
+
+	req, output := client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket:      aws.String(location.Bucket),
+		Key:         aws.String(location.Key),
+		Body:        bytes.NewReader(contents),
+		ContentType: aws.String("application/json"),
+	})
+
+	if version == "" {
+		b.setHeader(req, "If-None-Match", "*")
+	} else {
+		b.setHeader(req, "If-Match", version)
+	}
+
+	err = req.Send()
+	if err != nil {
+		if b.isConflict(err) {
+			return "", biconfig.StateVersionConflictError{Location: b.statePath}
+		}
+		return "", bosherr.WrapErrorf(err, "Putting object '%s' into bucket '%s'", location.Key, location.Bucket)
+	}
+
+	b.logger.Debug(b.logTag, "Saved deployment state '%s' with ETag %s", b.statePath, aws.StringValue(output.ETag))
+
+	return aws.StringValue(output.ETag), nil
+}
+
+func (b *s3Backend) Delete(version string) error {
+	client, location, err := b.s3Client()
+	if err != nil {
+		return err
+	}
+
+	req, _ := client.DeleteObjectRequest(&s3.DeleteObjectInput{
+		Bucket: aws.String(location.Bucket),
+		Key:    aws.String(location.Key),
+	})
+
+	if version != "" {
+		b.setHeader(req, "If-Match", version)
+	}
+
+	err = req.Send()
+	if err != nil {
+		if b.isConflict(err) {
+			return biconfig.StateVersionConflictError{Location: b.statePath}
+		}
+		if b.isNotFound(err) {
+			return nil
+		}
+		return bosherr.WrapErrorf(err, "Deleting object '%s' from bucket '%s'", location.Key, location.Bucket)
+	}
+
+	return nil
+}
+
+func (b *s3Backend) s3Client() (*s3.S3, objectLocation, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.client != nil {
+		return b.client, b.location, nil
+	}
+
+	location, err := parseObjectLocation(b.statePath, S3Scheme)
+	if err != nil {
+		return nil, objectLocation{}, err
+	}
+
+	config := aws.NewConfig().WithLogLevel(aws.LogOff)
+
+	if region := location.Query.Get("region"); region != "" {
+		config = config.WithRegion(region)
+	}
+
+	if endpoint := location.Query.Get("endpoint"); endpoint != "" {
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, objectLocation{}, bosherr.WrapError(err, "Building S3 session")
+	}
+
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String(s3DefaultRegion)
+	}
+
+	b.client = s3.New(sess)
+	b.location = location
+
+	return b.client, b.location, nil
+}
+
+// setHeader adds headers that the vendored SDK does not model for the
+// request, before the request is signed
+func (b *s3Backend) setHeader(req *request.Request, name, value string) {
+	req.Handlers.Build.PushBack(func(r *request.Request) {
+		r.HTTPRequest.Header.Set(name, value)
+	})
+}
+
+func (b *s3Backend) isConflict(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		// 409 is returned when a conditional write races another one
+		return reqErr.StatusCode() == http.StatusPreconditionFailed || reqErr.StatusCode() == http.StatusConflict
+	}
+
+	return false
+}
+
+func (b *s3Backend) isNotFound(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() == http.StatusNotFound || reqErr.Code() == s3.ErrCodeNoSuchKey
+	}
+
+	return false
+}
@@ -0,0 +1,169 @@
+package statebackend_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/config/statebackend"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+// fakeS3 stores a single object and answers conditional requests like S3
+type fakeS3 struct {
+	contents []byte
+	etag     string
+	requests []*http.Request
+	lock     sync.Mutex
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.requests = append(s.requests, r)
+
+	if r.URL.Path != "/fake-bucket/env/state.json" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch r.Method {
+	case "GET":
+		if s.contents == nil {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		w.Header().Set("ETag", s.etag)
+		w.Write(s.contents)
+
+	case "PUT", "DELETE":
+		if !s.preconditionsMet(r) {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(`<Error><Code>PreconditionFailed</Code></Error>`))
+			return
+		}
+
+		if r.Method == "DELETE" {
+			s.contents = nil
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		s.contents, _ = ioutil.ReadAll(r.Body)
+		s.etag = fmt.Sprintf(`"etag-%d"`, len(s.requests))
+		w.Header().Set("ETag", s.etag)
+	}
+}
+
+func (s *fakeS3) preconditionsMet(r *http.Request) bool {
+	if r.Header.Get("If-None-Match") == "*" && s.contents != nil {
+		return false
+	}
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && (s.contents == nil || ifMatch != s.etag) {
+		return false
+	}
+
+	return true
+}
+
+var _ = Describe("S3 backend", func() {
+	var (
+		server  *httptest.Server
+		store   *fakeS3
+		backend biconfig.StateBackend
+	)
+
+	BeforeEach(func() {
+		os.Setenv("AWS_ACCESS_KEY_ID", "fake-access-key")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "fake-secret-key")
+
+		store = &fakeS3{}
+		server = httptest.NewServer(store)
+
+		backend = NewBackend("s3://fake-bucket/env/state.json?region=fake-region&endpoint="+server.URL, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Unsetenv("AWS_ACCESS_KEY_ID")
+		os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	})
+
+	It("returns no contents when the object does not exist", func() {
+		contents, version, err := backend.Get()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(BeNil())
+		Expect(version).To(BeEmpty())
+	})
+
+	It("only creates the object if it does not exist yet", func() {
+		version, err := backend.Put([]byte(`{"director_id":"fake"}`), "")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(version).ToNot(BeEmpty())
+		Expect(store.requests[0].Header.Get("If-None-Match")).To(Equal("*"))
+
+		_, err = backend.Put([]byte(`{}`), "")
+		Expect(err).To(Equal(biconfig.StateVersionConflictError{Location: backend.Location()}))
+	})
+
+	It("writes with the ETag of the last read as precondition", func() {
+		_, err := backend.Put([]byte(`{"director_id":"first"}`), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		contents, version, err := backend.Get()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal(`{"director_id":"first"}`))
+
+		newVersion, err := backend.Put([]byte(`{"director_id":"second"}`), version)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(newVersion).ToNot(Equal(version))
+		Expect(store.requests[2].Header.Get("If-Match")).To(Equal(version))
+
+		_, err = backend.Put([]byte(`{"director_id":"stale"}`), version)
+		Expect(err).To(Equal(biconfig.StateVersionConflictError{Location: backend.Location()}))
+		Expect(string(store.contents)).To(Equal(`{"director_id":"second"}`))
+	})
+
+	It("deletes the object if it is still at the version", func() {
+		version, err := backend.Put([]byte(`{}`), "")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = backend.Delete(`"stale-etag"`)
+		Expect(err).To(Equal(biconfig.StateVersionConflictError{Location: backend.Location()}))
+
+		err = backend.Delete(version)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(store.contents).To(BeNil())
+	})
+
+	It("returns an error for locations without an object key", func() {
+		backend = NewBackend("s3://fake-bucket/", boshlog.NewLogger(boshlog.LevelNone))
+
+		_, _, err := backend.Get()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Expected remote deployment state location 's3://fake-bucket/' to include a bucket and an object key"))
+	})
+})
+
+var _ = Describe("IsRemote", func() {
+	It("is true for S3 and GCS URLs", func() {
+		Expect(IsRemote("s3://bucket/state.json")).To(BeTrue())
+		Expect(IsRemote("gs://bucket/state.json")).To(BeTrue())
+	})
+
+	It("is false for file paths", func() {
+		Expect(IsRemote("/tmp/state.json")).To(BeFalse())
+		Expect(IsRemote("state.json")).To(BeFalse())
+		Expect(IsRemote("")).To(BeFalse())
+	})
+})
@@ -0,0 +1,13 @@
+package statebackend_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestStateBackend(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "State Backend Suite")
+}
@@ -11,7 +11,12 @@ type StemcellRepo interface // StemcellRepo persists stemcells metadata
 	FindCurrent() (StemcellRecord, bool, error)
 	ClearCurrent() error
 	Save(name, version, cid string) (StemcellRecord, error)
+	// SaveImported saves a record of a stemcell another environment owns
+	SaveImported(name, version, cid string) (StemcellRecord, error)
+	// Find and AllForCurrentCPI only return stemcells uploaded with the
+	// current cloud_provider.cpis entry
 	Find(name, version string) (StemcellRecord, bool, error)
+	AllForCurrentCPI() ([]StemcellRecord, error)
 	All() ([]StemcellRecord, error)
 	Delete(StemcellRecord) error
 }
@@ -29,6 +34,14 @@ func NewStemcellRepo(deploymentStateService DeploymentStateService, uuidGenerato
 }
 
 func (r stemcellRepo) Save(name, version, cid string) (StemcellRecord, error) {
+	return r.save(StemcellRecord{Name: name, Version: version, CID: cid})
+}
+
+func (r stemcellRepo) SaveImported(name, version, cid string) (StemcellRecord, error) {
+	return r.save(StemcellRecord{Name: name, Version: version, CID: cid, Imported: true})
+}
+
+func (r stemcellRepo) save(newRecord StemcellRecord) (StemcellRecord, error) {
 	stemcellRecord := StemcellRecord{}
 
 	err := r.updateConfig(func(config *DeploymentState) error {
@@ -37,19 +50,16 @@ func (r stemcellRepo) Save(name, version, cid string) (StemcellRecord, error) {
 			records = []StemcellRecord{}
 		}
 
-		newRecord := StemcellRecord{
-			Name:    name,
-			Version: version,
-			CID:     cid,
-		}
 		var err error
 		newRecord.ID, err = r.uuidGenerator.Generate()
 		if err != nil {
 			return bosherr.WrapError(err, "Generating stemcell id")
 		}
 
+		newRecord.CPI = config.CurrentCPI
+
 		for _, oldRecord := range records {
-			if oldRecord.Name == newRecord.Name && oldRecord.Version == newRecord.Version {
+			if oldRecord.Name == newRecord.Name && oldRecord.Version == newRecord.Version && oldRecord.CPI == newRecord.CPI {
 				return bosherr.Errorf("Failed to save stemcell record '%s' (duplicate name/version), existing record found '%s'", newRecord, oldRecord)
 			}
 		}
@@ -66,7 +76,7 @@ func (r stemcellRepo) Save(name, version, cid string) (StemcellRecord, error) {
 }
 
 func (r stemcellRepo) Find(name, version string) (StemcellRecord, bool, error) {
-	_, records, err := r.load()
+	records, err := r.AllForCurrentCPI()
 	if err != nil {
 		return StemcellRecord{}, false, err
 	}
@@ -79,6 +89,22 @@ func (r stemcellRepo) Find(name, version string) (StemcellRecord, bool, error) {
 	return StemcellRecord{}, false, nil
 }
 
+func (r stemcellRepo) AllForCurrentCPI() ([]StemcellRecord, error) {
+	deploymentState, records, err := r.load()
+	if err != nil {
+		return []StemcellRecord{}, err
+	}
+
+	cpiRecords := []StemcellRecord{}
+	for _, record := range records {
+		if record.CPI == deploymentState.CurrentCPI {
+			cpiRecords = append(cpiRecords, record)
+		}
+	}
+
+	return cpiRecords, nil
+}
+
 func (r stemcellRepo) FindCurrent() (StemcellRecord, bool, error) {
 	deploymentState, err := r.deploymentStateService.Load()
 	if err != nil {
@@ -109,30 +135,22 @@ func (r stemcellRepo) All() ([]StemcellRecord, error) {
 }
 
 func (r stemcellRepo) Delete(stemcellRecord StemcellRecord) error {
-	config, records, err := r.load()
-	if err != nil {
-		return err
-	}
-
-	newRecords := []StemcellRecord{}
-	for _, record := range records {
-		if stemcellRecord.ID != record.ID {
-			newRecords = append(newRecords, record)
+	return r.updateConfig(func(config *DeploymentState) error {
+		newRecords := []StemcellRecord{}
+		for _, record := range config.Stemcells {
+			if stemcellRecord.ID != record.ID {
+				newRecords = append(newRecords, record)
+			}
 		}
-	}
-
-	config.Stemcells = newRecords
 
-	if config.CurrentStemcellID == stemcellRecord.ID {
-		config.CurrentStemcellID = ""
-	}
+		config.Stemcells = newRecords
 
-	err = r.deploymentStateService.Save(config)
-	if err != nil {
-		return bosherr.WrapError(err, "Saving config")
-	}
+		if config.CurrentStemcellID == stemcellRecord.ID {
+			config.CurrentStemcellID = ""
+		}
 
-	return nil
+		return nil
+	})
 }
 
 func (r stemcellRepo) UpdateCurrent(recordID string) error {
@@ -162,22 +180,7 @@ func (r stemcellRepo) ClearCurrent() error {
 }
 
 func (r stemcellRepo) updateConfig(updateFunc func(*DeploymentState) error) error {
-	deploymentState, err := r.deploymentStateService.Load()
-	if err != nil {
-		return bosherr.WrapError(err, "Loading existing config")
-	}
-
-	err = updateFunc(&deploymentState)
-	if err != nil {
-		return err
-	}
-
-	err = r.deploymentStateService.Save(deploymentState)
-	if err != nil {
-		return bosherr.WrapError(err, "Saving new config")
-	}
-
-	return nil
+	return r.deploymentStateService.Update(updateFunc)
 }
 
 func (r stemcellRepo) load() (DeploymentState, []StemcellRecord, error) {
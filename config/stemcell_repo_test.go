@@ -1,9 +1,17 @@
 package config_test
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
 	. "github.com/cloudfoundry/bosh-cli/config"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -128,6 +136,18 @@ var _ = Describe("StemcellRepo", func() {
 		})
 	})
 
+	Describe("SaveImported", func() {
+		It("saves the stemcell record as imported", func() {
+			record, err := repo.SaveImported("fake-name", "fake-version", "fake-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(record.Imported).To(BeTrue())
+
+			deploymentState, err := deploymentStateService.Load()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(deploymentState.Stemcells).To(Equal([]StemcellRecord{record}))
+		})
+	})
+
 	Describe("Find", func() {
 		Context("when a stemcell record with the same name and version exists", func() {
 			BeforeEach(func() {
@@ -155,6 +175,84 @@ var _ = Describe("StemcellRepo", func() {
 				Expect(found).To(BeFalse())
 			})
 		})
+
+		Context("when the stemcell was uploaded with another cpi", func() {
+			BeforeEach(func() {
+				switchCPI := func(cpiName string) {
+					err := deploymentStateService.Update(func(state *DeploymentState) error {
+						state.SwitchCPI(cpiName)
+						return nil
+					})
+					Expect(err).ToNot(HaveOccurred())
+				}
+
+				switchCPI("fake-other-cpi")
+				_, err := repo.Save("fake-name", "fake-version", "fake-other-cid")
+				Expect(err).ToNot(HaveOccurred())
+
+				switchCPI("fake-cpi")
+			})
+
+			It("does not find it", func() {
+				_, found, err := repo.Find("fake-name", "fake-version")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+
+				records, err := repo.AllForCurrentCPI()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(BeEmpty())
+			})
+
+			It("saves the stemcell uploaded with the current cpi", func() {
+				record, err := repo.Save("fake-name", "fake-version", "fake-cid")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(record.CPI).To(Equal("fake-cpi"))
+
+				foundRecord, found, err := repo.Find("fake-name", "fake-version")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeTrue())
+				Expect(foundRecord).To(Equal(record))
+
+				records, err := repo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records).To(HaveLen(2))
+			})
+		})
+
+		Context("when the stemcell was recorded before records named their cpi", func() {
+			BeforeEach(func() {
+				err := deploymentStateService.Update(func(state *DeploymentState) error {
+					state.CurrentCPI = "fake-cpi"
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = repo.Save("fake-name", "fake-version", "fake-cid")
+				Expect(err).ToNot(HaveOccurred())
+
+				err = deploymentStateService.Update(func(state *DeploymentState) error {
+					state.Stemcells[0].CPI = ""
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("finds it once the cpi is switched, as uploaded with the previous cpi", func() {
+				err := deploymentStateService.Update(func(state *DeploymentState) error {
+					state.SwitchCPI("fake-other-cpi")
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				records, err := repo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(records[0].CPI).To(Equal("fake-cpi"))
+
+				_, found, err := repo.Find("fake-name", "fake-version")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+			})
+		})
 	})
 
 	Describe("UpdateCurrent", func() {
@@ -320,4 +418,64 @@ var _ = Describe("StemcellRepo", func() {
 			})
 		})
 	})
+
+	Context("when several invocations save stemcells at the same time", func() {
+		var tmpDir string
+
+		BeforeEach(func() {
+			var err error
+			tmpDir, err = ioutil.TempDir("", "stemcell-repo")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(tmpDir)
+		})
+
+		It("keeps every saved record", func() {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			statePath := filepath.Join(tmpDir, "state.json")
+
+			const savers = 10
+			errs := make(chan error, savers)
+			wg := sync.WaitGroup{}
+
+			for i := 0; i < savers; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+
+					// Each saver has its own services, just like separate CLI processes
+					realFs := boshsys.NewOsFileSystem(logger)
+					uuidGenerator := boshuuid.NewGenerator()
+					stateService := NewFileSystemDeploymentStateService(realFs, uuidGenerator, logger, statePath)
+
+					_, err := NewStemcellRepo(stateService, uuidGenerator).Save("fake-name", fmt.Sprintf("fake-version-%d", i), fmt.Sprintf("fake-cid-%d", i))
+					errs <- err
+				}(i)
+			}
+
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				Expect(err).ToNot(HaveOccurred())
+			}
+
+			realFs := boshsys.NewOsFileSystem(logger)
+			records, err := NewStemcellRepo(NewFileSystemDeploymentStateService(realFs, boshuuid.NewGenerator(), logger, statePath), boshuuid.NewGenerator()).All()
+			Expect(err).ToNot(HaveOccurred())
+
+			versions := []string{}
+			for _, record := range records {
+				versions = append(versions, record.Version)
+			}
+
+			Expect(versions).To(HaveLen(savers))
+			for i := 0; i < savers; i++ {
+				Expect(versions).To(ContainElement(fmt.Sprintf("fake-version-%d", i)))
+			}
+		})
+	})
 })
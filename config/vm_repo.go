@@ -2,12 +2,18 @@ package config
 
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
 type VMRepo interface {
 	FindCurrent() (cid string, found bool, err error)
 	UpdateCurrent(cid string) error
 	ClearCurrent() error
+
+	// Network cloud properties of the current VM are kept after the VM is
+	// cleared so the next deploy can explain why it recreates the VM.
+	FindCurrentNetworkCloudProperties() (map[string]biproperty.Map, error)
+	UpdateCurrentNetworkCloudProperties(map[string]biproperty.Map) error
 }
 
 type vMRepo struct {
@@ -63,3 +69,27 @@ func (r vMRepo) ClearCurrent() error {
 	}
 	return nil
 }
+
+func (r vMRepo) FindCurrentNetworkCloudProperties() (map[string]biproperty.Map, error) {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading existing config")
+	}
+
+	return deploymentState.CurrentVMNetworkCloudProperties, nil
+}
+
+func (r vMRepo) UpdateCurrentNetworkCloudProperties(cloudProperties map[string]biproperty.Map) error {
+	deploymentState, err := r.deploymentStateService.Load()
+	if err != nil {
+		return bosherr.WrapError(err, "Loading existing config")
+	}
+
+	deploymentState.CurrentVMNetworkCloudProperties = cloudProperties
+
+	err = r.deploymentStateService.Save(deploymentState)
+	if err != nil {
+		return bosherr.WrapError(err, "Saving new config")
+	}
+	return nil
+}
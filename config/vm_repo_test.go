@@ -3,6 +3,7 @@ package config_test
 import (
 	. "github.com/cloudfoundry/bosh-cli/config"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 	. "github.com/onsi/ginkgo"
@@ -84,4 +85,28 @@ var _ = Describe("VMRepo", func() {
 			Expect(found).To(BeFalse())
 		})
 	})
+
+	Describe("network cloud properties", func() {
+		It("returns nothing when no VM has been recorded", func() {
+			cloudProperties, err := repo.FindCurrentNetworkCloudProperties()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProperties).To(BeEmpty())
+		})
+
+		It("returns the recorded cloud properties, even after the vm cid is cleared", func() {
+			err := repo.UpdateCurrentNetworkCloudProperties(map[string]biproperty.Map{
+				"fake-network": {"subnet": "fake-subnet"},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = repo.ClearCurrent()
+			Expect(err).ToNot(HaveOccurred())
+
+			cloudProperties, err := repo.FindCurrentNetworkCloudProperties()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudProperties).To(Equal(map[string]biproperty.Map{
+				"fake-network": {"subnet": "fake-subnet"},
+			}))
+		})
+	})
 })
@@ -45,7 +45,12 @@ func (i CpiInstaller) installCpiRelease(installer biinstall.Installer, installat
 }
 
 func (i CpiInstaller) WithInstalledCpiRelease(installationManifest biinstallmanifest.Manifest, target biinstall.Target, stage biui.Stage, fn func(biinstall.Installation) error) (errToReturn error) {
-	installer := i.InstallerFactory.NewInstaller(target)
+	var installer biinstall.Installer
+	if installationManifest.CompiledPackagesBlobstore != "" {
+		installer = i.InstallerFactory.NewInstallerWithCompiledPackagesBlobstore(target, installationManifest.CompiledPackagesBlobstore)
+	} else {
+		installer = i.InstallerFactory.NewInstaller(target)
+	}
 
 	installation, err := i.installCpiRelease(installer, installationManifest, target, stage)
 	if err != nil {
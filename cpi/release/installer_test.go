@@ -110,6 +110,26 @@ var _ = Describe("Installer", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 
+		It("shares compiled packages through the blobstore of the manifest", func() {
+			cpiInstaller := release.CpiInstaller{
+				InstallerFactory: mockInstallerFactory,
+			}
+
+			sharingManifest := biinstallationmanifest.Manifest{CompiledPackagesBlobstore: "s3://fake-bucket/compiled-packages"}
+			sharingInstaller := mocks.NewMockInstaller(mockCtrl)
+
+			mockInstallerFactory.EXPECT().NewInstallerWithCompiledPackagesBlobstore(target, "s3://fake-bucket/compiled-packages").Return(sharingInstaller)
+			sharingInstaller.EXPECT().Install(sharingManifest, gomock.Any()).Return(installation, nil)
+			sharingInstaller.EXPECT().Cleanup(installation).Return(nil)
+			expectInstall.Times(0)
+			expectCleanup.Times(0)
+
+			err := cpiInstaller.WithInstalledCpiRelease(sharingManifest, target, installStage, func(installation biinstallation.Installation) error {
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
 		It("creates a stage for the cleanup", func() {
 			cpiInstaller := release.CpiInstaller{
 				InstallerFactory: mockInstallerFactory,
@@ -0,0 +1,43 @@
+package crypto_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("GenerateSelfSignedCertificate", func() {
+	It("generates a key pair valid for the hosts", func() {
+		now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+		certificate, err := GenerateSelfSignedCertificate([]string{"127.0.0.1", "localhost"}, now, time.Hour)
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = tls.X509KeyPair([]byte(certificate.Certificate), []byte(certificate.PrivateKey))
+		Expect(err).ToNot(HaveOccurred())
+
+		block, _ := pem.Decode([]byte(certificate.Certificate))
+		Expect(block).ToNot(BeNil())
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cert.IPAddresses).To(HaveLen(1))
+		Expect(cert.IPAddresses[0].Equal(net.ParseIP("127.0.0.1"))).To(BeTrue())
+		Expect(cert.DNSNames).To(Equal([]string{"localhost"}))
+		Expect(cert.NotAfter).To(Equal(now.Add(time.Hour)))
+
+		roots := x509.NewCertPool()
+		roots.AddCert(cert)
+
+		_, err = cert.Verify(x509.VerifyOptions{DNSName: "127.0.0.1", Roots: roots, CurrentTime: now})
+		Expect(err).ToNot(HaveOccurred())
+	})
+})
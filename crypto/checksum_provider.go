@@ -0,0 +1,92 @@
+package crypto
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// ChecksumProvider decides which digest algorithms are used to create
+// checksums and which may be used to verify them
+type ChecksumProvider interface {
+	CreationAlgorithms() []boshcrypto.Algorithm
+	Allows(boshcrypto.Algorithm) bool
+
+	// Verifiable drops the digests of algorithms that are not allowed and
+	// returns an error when none are left
+	Verifiable(boshcrypto.MultipleDigest) (boshcrypto.MultipleDigest, error)
+}
+
+// NewChecksumProvider returns the FIPS provider in binaries built with the
+// 'fips' tag, otherwise a provider creating SHA-1 or, with sha2, SHA-256
+// checksums and verifying any algorithm
+func NewChecksumProvider(sha2 bool) ChecksumProvider {
+	if FIPSBuild {
+		return NewFIPSChecksumProvider()
+	}
+
+	if sha2 {
+		return standardChecksumProvider{algorithm: boshcrypto.DigestAlgorithmSHA256}
+	}
+
+	return standardChecksumProvider{algorithm: boshcrypto.DigestAlgorithmSHA1}
+}
+
+// ParseVerifiableDigest parses a digest string and drops the digests the
+// build does not allow verifying, for code verifying digests recorded in
+// releases and release directories without a checksum provider at hand
+func ParseVerifiableDigest(digestString string) (boshcrypto.MultipleDigest, error) {
+	digest, err := boshcrypto.ParseMultipleDigest(digestString)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, err
+	}
+
+	return NewChecksumProvider(false).Verifiable(digest)
+}
+
+type standardChecksumProvider struct {
+	algorithm boshcrypto.Algorithm
+}
+
+func (p standardChecksumProvider) CreationAlgorithms() []boshcrypto.Algorithm {
+	return []boshcrypto.Algorithm{p.algorithm}
+}
+
+func (standardChecksumProvider) Allows(boshcrypto.Algorithm) bool { return true }
+
+func (standardChecksumProvider) Verifiable(digest boshcrypto.MultipleDigest) (boshcrypto.MultipleDigest, error) {
+	return digest, nil
+}
+
+type fipsChecksumProvider struct{}
+
+// NewFIPSChecksumProvider only creates and verifies SHA-2 checksums so that
+// no non-approved algorithm runs during verification
+func NewFIPSChecksumProvider() ChecksumProvider {
+	return fipsChecksumProvider{}
+}
+
+func (fipsChecksumProvider) CreationAlgorithms() []boshcrypto.Algorithm {
+	return []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA256}
+}
+
+func (fipsChecksumProvider) Allows(algorithm boshcrypto.Algorithm) bool {
+	name := algorithm.Name()
+	return name == boshcrypto.DigestAlgorithmSHA256.Name() || name == boshcrypto.DigestAlgorithmSHA512.Name()
+}
+
+func (p fipsChecksumProvider) Verifiable(digest boshcrypto.MultipleDigest) (boshcrypto.MultipleDigest, error) {
+	allowed := []boshcrypto.Digest{}
+	for _, algorithm := range []boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA512, boshcrypto.DigestAlgorithmSHA256} {
+		algorithmDigest, err := digest.DigestFor(algorithm)
+		if err == nil {
+			allowed = append(allowed, algorithmDigest)
+		}
+	}
+
+	if len(allowed) == 0 {
+		return boshcrypto.MultipleDigest{}, bosherr.Errorf(
+			"Checksum '%s' has no SHA-256 or SHA-512 digest which are the only algorithms allowed in FIPS mode", digest.String())
+	}
+
+	return boshcrypto.MustNewMultipleDigest(allowed...), nil
+}
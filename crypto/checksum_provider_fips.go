@@ -0,0 +1,7 @@
+//go:build fips
+// +build fips
+
+package crypto
+
+// FIPSBuild is set in binaries built with the 'fips' tag
+const FIPSBuild = true
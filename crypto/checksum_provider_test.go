@@ -0,0 +1,93 @@
+package crypto_test
+
+import (
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("ChecksumProvider", func() {
+	Describe("NewChecksumProvider", func() {
+		BeforeEach(func() {
+			if FIPSBuild {
+				Skip("FIPS builds always use the FIPS checksum provider")
+			}
+		})
+
+		It("creates SHA-1 checksums by default", func() {
+			Expect(NewChecksumProvider(false).CreationAlgorithms()).To(Equal([]boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA1}))
+		})
+
+		It("creates SHA-256 checksums with sha2", func() {
+			Expect(NewChecksumProvider(true).CreationAlgorithms()).To(Equal([]boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA256}))
+		})
+
+		It("verifies checksums of any algorithm", func() {
+			digest := boshcrypto.MustParseMultipleDigest("sha1:fakesha1")
+
+			verifiable, err := NewChecksumProvider(false).Verifiable(digest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verifiable).To(Equal(digest))
+			Expect(NewChecksumProvider(false).Allows(boshcrypto.DigestAlgorithmSHA1)).To(BeTrue())
+		})
+	})
+
+	Describe("NewFIPSChecksumProvider", func() {
+		var provider ChecksumProvider
+
+		BeforeEach(func() {
+			provider = NewFIPSChecksumProvider()
+		})
+
+		It("creates SHA-256 checksums", func() {
+			Expect(provider.CreationAlgorithms()).To(Equal([]boshcrypto.Algorithm{boshcrypto.DigestAlgorithmSHA256}))
+		})
+
+		It("only allows SHA-2 algorithms", func() {
+			Expect(provider.Allows(boshcrypto.DigestAlgorithmSHA1)).To(BeFalse())
+			Expect(provider.Allows(boshcrypto.DigestAlgorithmSHA256)).To(BeTrue())
+			Expect(provider.Allows(boshcrypto.DigestAlgorithmSHA512)).To(BeTrue())
+		})
+
+		It("drops SHA-1 digests from checksums", func() {
+			verifiable, err := provider.Verifiable(boshcrypto.MustParseMultipleDigest("sha1:fakesha1;sha256:fakesha256"))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(verifiable.String()).To(Equal("sha256:fakesha256"))
+		})
+
+		It("returns an error for checksums without a SHA-2 digest", func() {
+			_, err := provider.Verifiable(boshcrypto.MustParseMultipleDigest("fakesha1"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Checksum 'fakesha1' has no SHA-256 or SHA-512 digest which are the only algorithms allowed in FIPS mode"))
+		})
+	})
+
+	Describe("ParseVerifiableDigest", func() {
+		It("keeps the digests the build allows verifying", func() {
+			verifiable, err := ParseVerifiableDigest("sha1:fakesha1;sha256:fakesha256")
+			Expect(err).ToNot(HaveOccurred())
+
+			if FIPSBuild {
+				Expect(verifiable.String()).To(Equal("sha256:fakesha256"))
+			} else {
+				Expect(verifiable.String()).To(Equal("fakesha1;sha256:fakesha256"))
+			}
+		})
+
+		It("returns an error for SHA-1 only digests in FIPS builds", func() {
+			_, err := ParseVerifiableDigest("fakesha1")
+			if FIPSBuild {
+				Expect(err).To(HaveOccurred())
+			} else {
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("returns an error for digests that cannot be parsed", func() {
+			_, err := ParseVerifiableDigest("")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
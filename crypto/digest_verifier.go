@@ -0,0 +1,139 @@
+package crypto
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+
+	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// DigestVerifier checks a file against a digest given by the user, either
+// a plain SHA-1 or an algorithm prefixed digest such as 'sha256:abc...'
+type DigestVerifier interface {
+	Verify(filePath string, expectedDigest string) error
+
+	// NewVerifyingReader checks what is read from reader against
+	// expectedDigest, for verifying a file while it is being processed
+	// instead of reading it once more beforehand
+	NewVerifyingReader(reader io.Reader, expectedDigest string) (VerifyingReader, error)
+}
+
+// VerifyingReader computes the digest of what is read on another goroutine
+// so that hashing overlaps with processing the reads. Either Verify or Close
+// has to be called to stop the goroutine.
+type VerifyingReader interface {
+	io.Reader
+
+	// Verify reads what has not been read yet and checks the digest
+	Verify() error
+
+	// Close stops computing the digest without checking it
+	Close() error
+}
+
+type digestVerifier struct {
+	fs        boshsys.FileSystem
+	checksums ChecksumProvider
+}
+
+// NewDigestVerifier only verifies digests of algorithms checksums allows
+func NewDigestVerifier(fs boshsys.FileSystem, checksums ChecksumProvider) DigestVerifier {
+	return digestVerifier{fs: fs, checksums: checksums}
+}
+
+func (v digestVerifier) Verify(filePath string, expectedDigest string) error {
+	digest, err := v.verifiable(expectedDigest)
+	if err != nil {
+		return err
+	}
+
+	err = digest.VerifyFilePath(filePath, v.fs)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Verifying digest of '%s'", filePath)
+	}
+
+	return nil
+}
+
+func (v digestVerifier) NewVerifyingReader(reader io.Reader, expectedDigest string) (VerifyingReader, error) {
+	digest, err := v.verifiable(expectedDigest)
+	if err != nil {
+		return nil, err
+	}
+
+	pipeReader, pipeWriter := io.Pipe()
+
+	r := &verifyingReader{
+		reader:     io.TeeReader(reader, pipeWriter),
+		pipeWriter: pipeWriter,
+		result:     make(chan error, 1),
+	}
+
+	go func() {
+		err := digest.Verify(pipeReader)
+
+		// unblocks the reads if the digest stopped reading early
+		_ = pipeReader.CloseWithError(io.ErrClosedPipe)
+
+		r.result <- err
+	}()
+
+	return r, nil
+}
+
+func (v digestVerifier) verifiable(expectedDigest string) (boshcrypto.MultipleDigest, error) {
+	digest, err := boshcrypto.ParseMultipleDigest(expectedDigest)
+	if err != nil {
+		return boshcrypto.MultipleDigest{}, bosherr.WrapErrorf(err, "Parsing digest '%s'", expectedDigest)
+	}
+
+	return v.checksums.Verifiable(digest)
+}
+
+type verifyingReader struct {
+	reader     io.Reader
+	pipeWriter *io.PipeWriter
+	result     chan error
+
+	resultOnce sync.Once
+	err        error
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	return r.reader.Read(p)
+}
+
+func (r *verifyingReader) Verify() error {
+	_, err := io.Copy(ioutil.Discard, r.reader)
+	if err != nil {
+		_ = r.Close()
+		return bosherr.WrapError(err, "Reading the rest to verify its digest")
+	}
+
+	_ = r.pipeWriter.Close()
+
+	err = r.wait()
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying digest")
+	}
+
+	return nil
+}
+
+func (r *verifyingReader) Close() error {
+	_ = r.pipeWriter.CloseWithError(io.ErrClosedPipe)
+	_ = r.wait()
+
+	return nil
+}
+
+func (r *verifyingReader) wait() error {
+	r.resultOnce.Do(func() {
+		r.err = <-r.result
+	})
+
+	return r.err
+}
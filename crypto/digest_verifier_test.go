@@ -0,0 +1,101 @@
+package crypto_test
+
+import (
+	"io/ioutil"
+	"strings"
+
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("DigestVerifier", func() {
+	var (
+		fs       *fakesys.FakeFileSystem
+		verifier DigestVerifier
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		fs.WriteFileString("/fake-tarball", "fake-archive-contents")
+		verifier = NewDigestVerifier(fs, NewChecksumProvider(false))
+	})
+
+	It("verifies plain SHA-1 digests", func() {
+		err := verifier.Verify("/fake-tarball", "4603db250d7b5b78dfe17869649784353177b549")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("verifies sha256 prefixed digests", func() {
+		err := verifier.Verify("/fake-tarball", "sha256:7fc7c4986b7c2167816f3f1459755c3e9488014455ef06a77b96cf27e40f09e7")
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	It("returns an error when the digest does not match", func() {
+		err := verifier.Verify("/fake-tarball", "sha256:fakesha256")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Verifying digest of '/fake-tarball'"))
+	})
+
+	It("returns an error when the digest cannot be parsed", func() {
+		err := verifier.Verify("/fake-tarball", "sha256:")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing digest 'sha256:'"))
+	})
+
+	It("returns an error when the algorithm is not allowed", func() {
+		verifier = NewDigestVerifier(fs, NewFIPSChecksumProvider())
+
+		err := verifier.Verify("/fake-tarball", "4603db250d7b5b78dfe17869649784353177b549")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("only algorithms allowed in FIPS mode"))
+	})
+
+	Describe("NewVerifyingReader", func() {
+		It("verifies what was read", func() {
+			reader, err := verifier.NewVerifyingReader(strings.NewReader("fake-archive-contents"), "sha256:7fc7c4986b7c2167816f3f1459755c3e9488014455ef06a77b96cf27e40f09e7")
+			Expect(err).ToNot(HaveOccurred())
+
+			contents, err := ioutil.ReadAll(reader)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(string(contents)).To(Equal("fake-archive-contents"))
+
+			Expect(reader.Verify()).To(Succeed())
+		})
+
+		It("reads the rest before verifying", func() {
+			reader, err := verifier.NewVerifyingReader(strings.NewReader("fake-archive-contents"), "4603db250d7b5b78dfe17869649784353177b549")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = reader.Read(make([]byte, 4))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(reader.Verify()).To(Succeed())
+		})
+
+		It("returns an error when the digest does not match", func() {
+			reader, err := verifier.NewVerifyingReader(strings.NewReader("other-contents"), "4603db250d7b5b78dfe17869649784353177b549")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = reader.Verify()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying digest"))
+		})
+
+		It("stops computing the digest when closed", func() {
+			reader, err := verifier.NewVerifyingReader(strings.NewReader("fake-archive-contents"), "4603db250d7b5b78dfe17869649784353177b549")
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(reader.Close()).To(Succeed())
+			Expect(reader.Close()).To(Succeed())
+		})
+
+		It("returns an error when the digest cannot be parsed", func() {
+			_, err := verifier.NewVerifyingReader(strings.NewReader("fake-archive-contents"), "sha256:")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing digest 'sha256:'"))
+		})
+	})
+})
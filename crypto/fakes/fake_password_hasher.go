@@ -0,0 +1,16 @@
+package fakes
+
+type FakePasswordHasher struct {
+	HashInputs []string
+	HashResult string
+	HashErr    error
+}
+
+func NewFakePasswordHasher() *FakePasswordHasher {
+	return &FakePasswordHasher{}
+}
+
+func (h *FakePasswordHasher) Hash(password string) (string, error) {
+	h.HashInputs = append(h.HashInputs, password)
+	return h.HashResult, h.HashErr
+}
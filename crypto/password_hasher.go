@@ -0,0 +1,160 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"io"
+	"regexp"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	sha512CryptPrefix     = "$6$"
+	sha512CryptRounds     = 5000
+	sha512CryptSaltLength = 16
+	cryptAlphabet         = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
+
+var cryptHashRegexp = regexp.MustCompile(`^\$(1|2a|2b|2y|5|6)\$[^$]+\$[./0-9A-Za-z$=]+$`)
+
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+}
+
+type sha512CryptHasher struct {
+	random io.Reader
+}
+
+// NewSHA512CryptHasher returns a hasher that produces crypt(3) compatible
+// sha512-crypt hashes ($6$...) with a random salt, as expected by the agent.
+func NewSHA512CryptHasher() PasswordHasher {
+	return sha512CryptHasher{random: rand.Reader}
+}
+
+func (h sha512CryptHasher) Hash(password string) (string, error) {
+	randomBytes := make([]byte, sha512CryptSaltLength)
+
+	_, err := io.ReadFull(h.random, randomBytes)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Generating password salt")
+	}
+
+	salt := make([]byte, sha512CryptSaltLength)
+	for i, b := range randomBytes {
+		salt[i] = cryptAlphabet[int(b)%len(cryptAlphabet)]
+	}
+
+	return SHA512Crypt(password, string(salt)), nil
+}
+
+// IsCryptHash reports whether value already looks like a crypt(3) hash
+// (md5, bcrypt, sha256 or sha512 variants) rather than a plaintext password.
+func IsCryptHash(value string) bool {
+	return cryptHashRegexp.MatchString(value)
+}
+
+// SHA512Crypt implements the sha512-crypt algorithm with the default
+// number of rounds. Salts longer than 16 characters are truncated.
+func SHA512Crypt(password, salt string) string {
+	if len(salt) > sha512CryptSaltLength {
+		salt = salt[:sha512CryptSaltLength]
+	}
+
+	pass := []byte(password)
+	saltBytes := []byte(salt)
+
+	alternate := sha512.New()
+	alternate.Write(pass)
+	alternate.Write(saltBytes)
+	alternate.Write(pass)
+	alternateSum := alternate.Sum(nil)
+
+	a := sha512.New()
+	a.Write(pass)
+	a.Write(saltBytes)
+	a.Write(repeatToLength(alternateSum, len(pass)))
+	for i := len(pass); i > 0; i >>= 1 {
+		if i&1 != 0 {
+			a.Write(alternateSum)
+		} else {
+			a.Write(pass)
+		}
+	}
+	aSum := a.Sum(nil)
+
+	dp := sha512.New()
+	for i := 0; i < len(pass); i++ {
+		dp.Write(pass)
+	}
+	pSeq := repeatToLength(dp.Sum(nil), len(pass))
+
+	ds := sha512.New()
+	for i := 0; i < 16+int(aSum[0]); i++ {
+		ds.Write(saltBytes)
+	}
+	sSeq := repeatToLength(ds.Sum(nil), len(saltBytes))
+
+	c := aSum
+	for i := 0; i < sha512CryptRounds; i++ {
+		round := sha512.New()
+		if i&1 != 0 {
+			round.Write(pSeq)
+		} else {
+			round.Write(c)
+		}
+		if i%3 != 0 {
+			round.Write(sSeq)
+		}
+		if i%7 != 0 {
+			round.Write(pSeq)
+		}
+		if i&1 != 0 {
+			round.Write(c)
+		} else {
+			round.Write(pSeq)
+		}
+		c = round.Sum(nil)
+	}
+
+	return sha512CryptPrefix + salt + "$" + encodeSHA512CryptDigest(c)
+}
+
+func repeatToLength(sequence []byte, length int) []byte {
+	result := make([]byte, 0, length)
+	for len(result) < length {
+		remaining := length - len(result)
+		if remaining > len(sequence) {
+			remaining = len(sequence)
+		}
+		result = append(result, sequence[:remaining]...)
+	}
+	return result
+}
+
+var sha512CryptPermutation = [][3]int{
+	{0, 21, 42}, {22, 43, 1}, {44, 2, 23}, {3, 24, 45}, {25, 46, 4},
+	{47, 5, 26}, {6, 27, 48}, {28, 49, 7}, {50, 8, 29}, {9, 30, 51},
+	{31, 52, 10}, {53, 11, 32}, {12, 33, 54}, {34, 55, 13}, {56, 14, 35},
+	{15, 36, 57}, {37, 58, 16}, {59, 17, 38}, {18, 39, 60}, {40, 61, 19},
+	{62, 20, 41},
+}
+
+func encodeSHA512CryptDigest(digest []byte) string {
+	encoded := make([]byte, 0, 86)
+
+	encode := func(b2, b1, b0 byte, n int) {
+		w := uint(b2)<<16 | uint(b1)<<8 | uint(b0)
+		for i := 0; i < n; i++ {
+			encoded = append(encoded, cryptAlphabet[w&0x3f])
+			w >>= 6
+		}
+	}
+
+	for _, group := range sha512CryptPermutation {
+		encode(digest[group[0]], digest[group[1]], digest[group[2]], 4)
+	}
+	encode(0, 0, digest[63], 2)
+
+	return string(encoded)
+}
@@ -0,0 +1,45 @@
+package crypto_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+var _ = Describe("SHA512Crypt", func() {
+	It("matches the reference implementation", func() {
+		Expect(SHA512Crypt("Hello world!", "saltstring")).To(Equal(
+			"$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"))
+	})
+
+	It("truncates salts longer than 16 characters", func() {
+		Expect(SHA512Crypt("Hello world!", "saltstringsaltstring")).To(HavePrefix("$6$saltstringsaltst$"))
+	})
+})
+
+var _ = Describe("IsCryptHash", func() {
+	It("recognizes crypt hashes", func() {
+		Expect(IsCryptHash("$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1")).To(BeTrue())
+		Expect(IsCryptHash("$1$saltsalt$qjXMvbEw8oaL.CzflDugX/")).To(BeTrue())
+	})
+
+	It("rejects plaintext passwords", func() {
+		Expect(IsCryptHash("fake-password")).To(BeFalse())
+		Expect(IsCryptHash("$6$")).To(BeFalse())
+	})
+})
+
+var _ = Describe("sha512CryptHasher", func() {
+	It("hashes with a random salt that can be used to verify the password", func() {
+		hash, err := NewSHA512CryptHasher().Hash("fake-password")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(IsCryptHash(hash)).To(BeTrue())
+
+		salt := strings.Split(hash, "$")[2]
+		Expect(salt).To(HaveLen(16))
+		Expect(SHA512Crypt("fake-password", salt)).To(Equal(hash))
+	})
+})
@@ -1,10 +1,13 @@
 package deployment
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -27,6 +30,13 @@ type Deployer interface {
 		bool,
 		biui.Stage,
 	) (Deployment, error)
+
+	Plan(
+		bideplmanifest.Manifest,
+		biconfig.DeploymentState,
+		[]bistemcell.Manifest,
+		bool,
+	) ([]PlannedCall, error)
 }
 
 type deployer struct {
@@ -64,9 +74,25 @@ func (d *deployer) Deploy(
 ) (Deployment, error) {
 	instanceManager := d.instanceManagerFactory.NewManager(cloud, vmManager, blobstore)
 
+	networkChanges, err := vmManager.NetworkChanges(deploymentManifest)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Checking for network changes")
+	}
+
 	pingTimeout := 10 * time.Second
 	pingDelay := 500 * time.Millisecond
-	if err := instanceManager.DeleteAll(pingTimeout, pingDelay, skipDrain, deployStage); err != nil {
+
+	if len(networkChanges) > 0 {
+		d.logger.Info(d.logTag, "Recreating VM because of network changes: %s", strings.Join(networkChanges, ", "))
+
+		stageName := fmt.Sprintf("recreating VM for network changes (%s)", strings.Join(networkChanges, ", "))
+		err = deployStage.PerformComplex(stageName, func(stage biui.Stage) error {
+			return instanceManager.DeleteAll(pingTimeout, pingDelay, skipDrain, stage)
+		})
+	} else {
+		err = instanceManager.DeleteAll(pingTimeout, pingDelay, skipDrain, deployStage)
+	}
+	if err != nil {
 		return nil, err
 	}
 
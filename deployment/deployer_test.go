@@ -4,6 +4,8 @@ import (
 	"errors"
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
+
 	. "github.com/cloudfoundry/bosh-cli/deployment"
 
 	mock_httpagent "github.com/cloudfoundry/bosh-agent/agentclient/http/mocks"
@@ -17,6 +19,7 @@ import (
 
 	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
@@ -29,6 +32,7 @@ import (
 	"github.com/cloudfoundry/bosh-agent/agentclient"
 	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
 	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
+	fakebidisk "github.com/cloudfoundry/bosh-cli/deployment/disk/fakes"
 	fakebisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel/fakes"
 	fakebivm "github.com/cloudfoundry/bosh-cli/deployment/vm/fakes"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
@@ -70,6 +74,8 @@ var _ = Describe("Deployer", func() {
 		mockState               *mock_instance_state.MockState
 
 		mockBlobstore *mock_blobstore.MockBlobstore
+
+		fakeInstanceStateRepo *fakebiconfig.FakeInstanceStateRepo
 	)
 
 	BeforeEach(func() {
@@ -128,6 +134,7 @@ var _ = Describe("Deployer", func() {
 		fakeVMManager.CreateVM = fakeVM
 
 		fakeVM.AgentClientReturn = mockAgentClient
+		fakeVM.UpdateDisksDisks = []bidisk.Disk{fakebidisk.NewFakeDisk("fake-disk-cid")}
 
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fakeStage = fakebiui.NewFakeStage()
@@ -148,14 +155,15 @@ var _ = Describe("Deployer", func() {
 		mockStateBuilder = mock_instance_state.NewMockBuilder(mockCtrl)
 		mockState = mock_instance_state.NewMockState(mockCtrl)
 
-		instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-		instanceManagerFactory := biinstance.NewManagerFactory(fakeSSHTunnelFactory, instanceFactory, logger)
+		fakeInstanceStateRepo = fakebiconfig.NewFakeInstanceStateRepo()
+		instanceFactory := biinstance.NewFactory(mockStateBuilderFactory, fakeInstanceStateRepo)
+		instanceManagerFactory := biinstance.NewManagerFactory(fakeSSHTunnelFactory, instanceFactory, fakeInstanceStateRepo, logger)
 
 		mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
 		pingTimeout := 10 * time.Second
 		pingDelay := 500 * time.Millisecond
-		deploymentFactory := NewFactory(pingTimeout, pingDelay)
+		deploymentFactory := NewFactory(pingTimeout, pingDelay, fakeclock.NewFakeClock(time.Now()))
 
 		deployer = NewDeployer(
 			mockVMManagerFactory,
@@ -221,6 +229,37 @@ var _ = Describe("Deployer", func() {
 				}))
 			})
 		})
+
+		Context("when network cloud properties changed", func() {
+			BeforeEach(func() {
+				fakeVMManager.NetworkChangesChanges = []string{"network 'fake-network-name' cloud_properties.subnet changed"}
+			})
+
+			It("explains why the existing vm is recreated", func() {
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVMManager.NetworkChangesManifest).To(Equal(deploymentManifest))
+				Expect(fakeExistingVM.DeleteCalled).To(Equal(1))
+
+				recreateCall := fakeStage.PerformCalls[0]
+				Expect(recreateCall.Name).To(Equal("recreating VM for network changes (network 'fake-network-name' cloud_properties.subnet changed)"))
+				Expect(recreateCall.Stage.PerformCalls[3].Name).To(Equal("Deleting VM 'existing-vm-cid'"))
+			})
+		})
+
+		Context("when checking for network changes fails", func() {
+			BeforeEach(func() {
+				fakeVMManager.NetworkChangesErr = errors.New("fake-network-changes-error")
+			})
+
+			It("returns an error", func() {
+				_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-network-changes-error"))
+				Expect(fakeExistingVM.DeleteCalled).To(Equal(0))
+			})
+		})
 	})
 
 	It("creates a vm", func() {
@@ -260,6 +299,7 @@ var _ = Describe("Deployer", func() {
 				Password:          "fake-password",
 				Host:              "fake-ssh-host",
 				Port:              124,
+				LocalForwardHost:  "fake-host",
 				LocalForwardPort:  123,
 				RemoteForwardPort: 123,
 			}))
@@ -344,6 +384,31 @@ var _ = Describe("Deployer", func() {
 		}))
 	})
 
+	It("records the states the instance goes through", func() {
+		_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeInstanceStateRepo.Transitions).To(Equal(map[string][]string{
+			"fake-job-name/0": {"vm_created", "disk_attached", "applied", "running"},
+		}))
+	})
+
+	Context("when the instance has no persistent disk", func() {
+		BeforeEach(func() {
+			deploymentManifest.Jobs[0].PersistentDiskPool = ""
+			fakeVM.UpdateDisksDisks = []bidisk.Disk{}
+		})
+
+		It("does not record the instance entering disk_attached", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeInstanceStateRepo.Transitions).To(Equal(map[string][]string{
+				"fake-job-name/0": {"vm_created", "applied", "running"},
+			}))
+		})
+	})
+
 	Context("when the deployment has an invalid disk pool specification", func() {
 		BeforeEach(func() {
 			deploymentManifest.Jobs[0].PersistentDiskPool = "fake-non-existent-persistent-disk-pool-name"
@@ -412,5 +477,14 @@ var _ = Describe("Deployer", func() {
 				Error: waitError,
 			}))
 		})
+
+		It("records that the instance was applied but is not running", func() {
+			_, err := deployer.Deploy(cloud, deploymentManifest, cloudStemcell, registryConfig, fakeVMManager, mockBlobstore, skipDrain, fakeStage)
+			Expect(err).To(HaveOccurred())
+
+			Expect(fakeInstanceStateRepo.Transitions["fake-job-name/0"]).To(Equal([]string{
+				"vm_created", "disk_attached", "applied",
+			}))
+		})
 	})
 })
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"time"
 
+	"code.cloudfoundry.org/clock"
+
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
@@ -12,7 +14,10 @@ import (
 )
 
 type Deployment interface {
-	Delete(bool, biui.Stage) error
+	// Delete deletes the instances, disks and stemcells of the deployment.
+	// With orphanDisks the persistent disks are kept in the cloud and
+	// recorded as orphaned disks instead of being deleted.
+	Delete(skipDrain bool, orphanDisks bool, deleteStage biui.Stage) error
 }
 
 type deployment struct {
@@ -21,6 +26,7 @@ type deployment struct {
 	stemcells   []bistemcell.CloudStemcell
 	pingTimeout time.Duration
 	pingDelay   time.Duration
+	timeService clock.Clock
 }
 
 func NewDeployment(
@@ -29,6 +35,7 @@ func NewDeployment(
 	stemcells []bistemcell.CloudStemcell,
 	pingTimeout time.Duration,
 	pingDelay time.Duration,
+	timeService clock.Clock,
 ) Deployment {
 	return &deployment{
 		instances:   instances,
@@ -36,10 +43,11 @@ func NewDeployment(
 		stemcells:   stemcells,
 		pingTimeout: pingTimeout,
 		pingDelay:   pingDelay,
+		timeService: timeService,
 	}
 }
 
-func (d *deployment) Delete(skipDrain bool, deleteStage biui.Stage) error {
+func (d *deployment) Delete(skipDrain bool, orphanDisks bool, deleteStage biui.Stage) error {
 	// le sigh... consuming from an array sucks without generics
 	for len(d.instances) > 0 {
 		lastIdx := len(d.instances) - 1
@@ -56,8 +64,14 @@ func (d *deployment) Delete(skipDrain bool, deleteStage biui.Stage) error {
 		lastIdx := len(d.disks) - 1
 		disk := d.disks[lastIdx]
 
-		if err := d.deleteDisk(deleteStage, disk); err != nil {
-			return err
+		if orphanDisks {
+			if err := d.orphanDisk(deleteStage, disk); err != nil {
+				return err
+			}
+		} else {
+			if err := d.deleteDisk(deleteStage, disk); err != nil {
+				return err
+			}
 		}
 
 		d.disks = d.disks[:lastIdx]
@@ -89,6 +103,13 @@ func (d *deployment) deleteDisk(deleteStage biui.Stage, disk bidisk.Disk) error
 	})
 }
 
+func (d *deployment) orphanDisk(deleteStage biui.Stage, disk bidisk.Disk) error {
+	stepName := fmt.Sprintf("Orphaning disk '%s'", disk.CID())
+	return deleteStage.Perform(stepName, func() error {
+		return disk.Orphan(d.timeService.Now())
+	})
+}
+
 func (d *deployment) deleteStemcell(deleteStage biui.Stage, stemcell bistemcell.CloudStemcell) error {
 	stepName := fmt.Sprintf("Deleting stemcell '%s'", stemcell.CID())
 	return deleteStage.Perform(stepName, func() error {
@@ -5,6 +5,7 @@ import (
 
 	"time"
 
+	"code.cloudfoundry.org/clock/fakeclock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -17,6 +18,7 @@ import (
 	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
@@ -70,6 +72,7 @@ var _ = Describe("Deployment", func() {
 			deployment Deployment
 
 			skipDrain bool
+			fakeClock *fakeclock.FakeClock
 		)
 
 		var expectNormalFlow = func() {
@@ -144,7 +147,8 @@ var _ = Describe("Deployment", func() {
 
 			pingTimeout := 10 * time.Second
 			pingDelay := 500 * time.Millisecond
-			deploymentFactory = NewFactory(pingTimeout, pingDelay)
+			fakeClock = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC))
+			deploymentFactory = NewFactory(pingTimeout, pingDelay, fakeClock)
 
 			skipDrain = false
 		})
@@ -161,8 +165,9 @@ var _ = Describe("Deployment", func() {
 			mockStateBuilder = mock_instance_state.NewMockBuilder(mockCtrl)
 			mockState = mock_instance_state.NewMockState(mockCtrl)
 
-			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, logger)
+			fakeInstanceStateRepo := fakebiconfig.NewFakeInstanceStateRepo()
+			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory, fakeInstanceStateRepo)
+			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, fakeInstanceStateRepo, logger)
 			stemcellManagerFactory := bistemcell.NewManagerFactory(stemcellRepo)
 
 			mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
@@ -206,7 +211,7 @@ var _ = Describe("Deployment", func() {
 			It("stops agent, unmounts disk, deletes vm, deletes disk, deletes stemcell", func() {
 				expectNormalFlow()
 
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -214,14 +219,48 @@ var _ = Describe("Deployment", func() {
 				skipDrain = true
 				expectDrainlessFlow()
 
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
+			It("orphans the disk instead of deleting it if specified", func() {
+				gomock.InOrder(
+					mockCloud.EXPECT().HasVM("fake-vm-cid").Return(true, nil),
+					mockAgentClient.EXPECT().Ping().Return("any-state", nil),
+					mockAgentClient.EXPECT().Drain("shutdown"),
+					mockAgentClient.EXPECT().Stop(),
+					mockAgentClient.EXPECT().ListDisk().Return([]string{"fake-disk-cid"}, nil),
+					mockAgentClient.EXPECT().UnmountDisk("fake-disk-cid"),
+					mockCloud.EXPECT().DeleteVM("fake-vm-cid"),
+					mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid"),
+				)
+
+				err := deployment.Delete(skipDrain, true, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeStage.PerformCalls[5:]).To(Equal([]*fakebiui.PerformCall{
+					{Name: "Orphaning disk 'fake-disk-cid'"},
+					{Name: "Deleting stemcell 'fake-stemcell-cid'"},
+				}))
+
+				diskRecords, err := diskRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diskRecords).To(BeEmpty())
+
+				orphanedDisks, err := diskRepo.AllOrphaned()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(orphanedDisks).To(Equal([]biconfig.OrphanedDiskRecord{
+					{
+						DiskRecord: biconfig.DiskRecord{ID: "fake-disk-guid", CID: "fake-disk-cid", Size: 100},
+						OrphanedAt: time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC),
+					},
+				}))
+			})
+
 			It("logs validation stages", func() {
 				expectNormalFlow()
 
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
@@ -238,7 +277,7 @@ var _ = Describe("Deployment", func() {
 			It("clears current vm, disk and stemcell", func() {
 				expectNormalFlow()
 
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 
 				_, found, err := vmRepo.FindCurrent()
@@ -265,7 +304,7 @@ var _ = Describe("Deployment", func() {
 					// reduce timout & delay to reduce test duration
 					pingTimeout := 1 * time.Second
 					pingDelay := 100 * time.Millisecond
-					deploymentFactory = NewFactory(pingTimeout, pingDelay)
+					deploymentFactory = NewFactory(pingTimeout, pingDelay, fakeClock)
 				})
 
 				It("times out pinging agent, deletes vm, deletes disk, deletes stemcell", func() {
@@ -277,7 +316,7 @@ var _ = Describe("Deployment", func() {
 						mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid"),
 					)
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 				})
 			})
@@ -286,7 +325,7 @@ var _ = Describe("Deployment", func() {
 				JustBeforeEach(func() {
 					expectNormalFlow()
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 
 					// reset event log recording
@@ -294,7 +333,7 @@ var _ = Describe("Deployment", func() {
 				})
 
 				It("does not delete anything", func() {
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 
 					Expect(fakeStage.PerformCalls).To(BeEmpty())
@@ -315,7 +354,7 @@ var _ = Describe("Deployment", func() {
 			})
 
 			It("does not delete anything", func() {
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(fakeStage.PerformCalls).To(BeEmpty())
@@ -343,7 +382,7 @@ var _ = Describe("Deployment", func() {
 					mockCloud.EXPECT().DeleteVM("fake-vm-cid"),
 				)
 
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -355,7 +394,7 @@ var _ = Describe("Deployment", func() {
 				It("skips agent shutdown & deletes the VM (to ensure related resources are released by the CPI)", func() {
 					mockCloud.EXPECT().DeleteVM("fake-vm-cid")
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 				})
 
@@ -365,7 +404,7 @@ var _ = Describe("Deployment", func() {
 						Message: "fake-vm-not-found-message",
 					}))
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 				})
 			})
@@ -382,7 +421,7 @@ var _ = Describe("Deployment", func() {
 			It("deletes the disk", func() {
 				mockCloud.EXPECT().DeleteDisk("fake-disk-cid")
 
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -390,7 +429,7 @@ var _ = Describe("Deployment", func() {
 				It("deletes the disk (to ensure related resources are released by the CPI)", func() {
 					mockCloud.EXPECT().DeleteDisk("fake-disk-cid")
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 				})
 
@@ -400,7 +439,7 @@ var _ = Describe("Deployment", func() {
 						Message: "fake-disk-not-found-message",
 					}))
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 				})
 			})
@@ -417,7 +456,7 @@ var _ = Describe("Deployment", func() {
 			It("deletes the stemcell", func() {
 				mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid")
 
-				err := deployment.Delete(skipDrain, fakeStage)
+				err := deployment.Delete(skipDrain, false, fakeStage)
 				Expect(err).ToNot(HaveOccurred())
 			})
 
@@ -425,7 +464,7 @@ var _ = Describe("Deployment", func() {
 				It("deletes the stemcell (to ensure related resources are released by the CPI)", func() {
 					mockCloud.EXPECT().DeleteStemcell("fake-stemcell-cid")
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 				})
 
@@ -435,7 +474,7 @@ var _ = Describe("Deployment", func() {
 						Message: "fake-stemcell-not-found-message",
 					}))
 
-					err := deployment.Delete(skipDrain, fakeStage)
+					err := deployment.Delete(skipDrain, false, fakeStage)
 					Expect(err).ToNot(HaveOccurred())
 				})
 			})
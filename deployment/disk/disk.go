@@ -2,6 +2,7 @@ package disk
 
 import (
 	"reflect"
+	"time"
 
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
@@ -13,6 +14,7 @@ type Disk interface {
 	CID() string
 	NeedsMigration(newSize int, newCloudProperties biproperty.Map) bool
 	Delete() error
+	Orphan(orphanedAt time.Time) error
 }
 
 type disk struct {
@@ -73,3 +75,23 @@ func (d *disk) Delete() error {
 	// returns bicloud.Error only if it is a DiskNotFoundError
 	return deleteErr
 }
+
+// Orphan keeps the disk in the cloud but stops tracking it as a deployment
+// disk, recording it as orphaned instead.
+func (d *disk) Orphan(orphanedAt time.Time) error {
+	diskRecord, found, err := d.repo.Find(d.cid)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Finding disk record (cid=%s)", d.cid)
+	}
+
+	if !found {
+		return bosherr.Errorf("Disk record for cid '%s' not found", d.cid)
+	}
+
+	err = d.repo.Orphan(diskRecord, orphanedAt)
+	if err != nil {
+		return bosherr.WrapError(err, "Orphaning disk record")
+	}
+
+	return nil
+}
@@ -2,6 +2,8 @@ package disk_test
 
 import (
 	"errors"
+	"time"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -182,4 +184,31 @@ var _ = Describe("Disk", func() {
 			})
 		})
 	})
+
+	Describe("Orphan", func() {
+		It("moves the disk record to the orphaned disks without deleting the disk in the cloud", func() {
+			diskRecord, err := diskRepo.Save("fake-disk-cid", 1024, diskCloudProperties)
+			Expect(err).ToNot(HaveOccurred())
+
+			orphanedAt := time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)
+			err = disk.Orphan(orphanedAt)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.DeleteDiskInputs).To(BeEmpty())
+
+			diskRecords, err := diskRepo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(diskRecords).To(BeEmpty())
+
+			orphanedDisks, err := diskRepo.AllOrphaned()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(orphanedDisks).To(Equal([]biconfig.OrphanedDiskRecord{{DiskRecord: diskRecord, OrphanedAt: orphanedAt}}))
+		})
+
+		It("returns an error when the disk record does not exist", func() {
+			err := disk.Orphan(time.Now())
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Disk record for cid 'fake-disk-cid' not found"))
+		})
+	})
 })
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
@@ -12,6 +14,9 @@ type FakeDisk struct {
 
 	DeleteCalledTimes int
 	deleteErr         error
+
+	OrphanInputs []time.Time
+	OrphanErr    error
 }
 
 type NeedsMigrationInput struct {
@@ -48,6 +53,11 @@ func (d *FakeDisk) Delete() error {
 	return d.deleteErr
 }
 
+func (d *FakeDisk) Orphan(orphanedAt time.Time) error {
+	d.OrphanInputs = append(d.OrphanInputs, orphanedAt)
+	return d.OrphanErr
+}
+
 func (d *FakeDisk) SetNeedsMigrationBehavior(needsMigration bool) {
 	d.needsMigrationOutput = needsMigrationOutput{
 		needsMigration: needsMigration,
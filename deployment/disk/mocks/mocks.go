@@ -11,6 +11,7 @@ import (
 	property "github.com/cloudfoundry/bosh-utils/property"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockDisk is a mock of Disk interface
@@ -60,6 +61,18 @@ func (mr *MockDiskMockRecorder) Delete() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDisk)(nil).Delete))
 }
 
+// Orphan mocks base method
+func (m *MockDisk) Orphan(arg0 time.Time) error {
+	ret := m.ctrl.Call(m, "Orphan", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Orphan indicates an expected call of Orphan
+func (mr *MockDiskMockRecorder) Orphan(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Orphan", reflect.TypeOf((*MockDisk)(nil).Orphan), arg0)
+}
+
 // NeedsMigration mocks base method
 func (m *MockDisk) NeedsMigration(arg0 int, arg1 property.Map) bool {
 	ret := m.ctrl.Call(m, "NeedsMigration", arg0, arg1)
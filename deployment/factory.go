@@ -3,6 +3,8 @@ package deployment
 import (
 	"time"
 
+	"code.cloudfoundry.org/clock"
+
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
@@ -19,15 +21,18 @@ type Factory interface {
 type factory struct {
 	pingTimeout time.Duration
 	pingDelay   time.Duration
+	timeService clock.Clock
 }
 
 func NewFactory(
 	pingTimeout time.Duration,
 	pingDelay time.Duration,
+	timeService clock.Clock,
 ) Factory {
 	return &factory{
 		pingTimeout: pingTimeout,
 		pingDelay:   pingDelay,
+		timeService: timeService,
 	}
 }
 
@@ -42,5 +47,6 @@ func (f *factory) NewDeployment(
 		stemcells,
 		f.pingTimeout,
 		f.pingDelay,
+		f.timeService,
 	)
 }
@@ -2,6 +2,7 @@ package instance
 
 import (
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	biinstancestate "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
@@ -22,13 +23,16 @@ type Factory interface {
 
 type factory struct {
 	stateBuilderFactory biinstancestate.BuilderFactory
+	stateRepo           biconfig.InstanceStateRepo
 }
 
 func NewFactory(
 	stateBuilderFactory biinstancestate.BuilderFactory,
+	stateRepo biconfig.InstanceStateRepo,
 ) Factory {
 	return &factory{
 		stateBuilderFactory: stateBuilderFactory,
+		stateRepo:           stateRepo,
 	}
 }
 
@@ -50,6 +54,7 @@ func (f *factory) NewInstance(
 		vmManager,
 		sshTunnelFactory,
 		stateBuilder,
+		f.stateRepo,
 		logger,
 	)
 }
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstancestate "github.com/cloudfoundry/bosh-cli/deployment/instance/state"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
@@ -38,6 +39,7 @@ type instance struct {
 	vmManager        bivm.Manager
 	sshTunnelFactory bisshtunnel.Factory
 	stateBuilder     biinstancestate.Builder
+	stateRepo        biconfig.InstanceStateRepo
 	logger           boshlog.Logger
 	logTag           string
 }
@@ -49,6 +51,7 @@ func NewInstance(
 	vmManager bivm.Manager,
 	sshTunnelFactory bisshtunnel.Factory,
 	stateBuilder biinstancestate.Builder,
+	stateRepo biconfig.InstanceStateRepo,
 	logger boshlog.Logger,
 ) Instance {
 	return &instance{
@@ -58,6 +61,7 @@ func NewInstance(
 		vmManager:        vmManager,
 		sshTunnelFactory: sshTunnelFactory,
 		stateBuilder:     stateBuilder,
+		stateRepo:        stateRepo,
 		logger:           logger,
 		logTag:           "instance",
 	}
@@ -85,7 +89,7 @@ func (i *instance) WaitUntilReady(
 ) error {
 	stepName := fmt.Sprintf("Waiting for the agent on VM '%s' to be ready", i.vm.CID())
 	err := stage.Perform(stepName, func() error {
-		if !registryConfig.IsEmpty() {
+		if registryConfig.HasSSHTunnel() {
 			sshReadyErrCh := make(chan error)
 			sshErrCh := make(chan error)
 
@@ -95,8 +99,10 @@ func (i *instance) WaitUntilReady(
 				User:              registryConfig.SSHTunnel.User,
 				Password:          registryConfig.SSHTunnel.Password,
 				PrivateKey:        registryConfig.SSHTunnel.PrivateKey,
+				LocalForwardHost:  registryConfig.LocalHost(),
 				LocalForwardPort:  registryConfig.Port,
 				RemoteForwardPort: registryConfig.Port,
+				KeepAliveInterval: time.Duration(registryConfig.SSHTunnel.KeepAliveInterval) * time.Second,
 			}
 			sshTunnel := i.sshTunnelFactory.NewSSHTunnel(sshTunnelOptions)
 			go sshTunnel.Start(sshReadyErrCh, sshErrCh)
@@ -137,6 +143,12 @@ func (i *instance) UpdateJobs(
 	deploymentManifest bideplmanifest.Manifest,
 	stage biui.Stage,
 ) error {
+	convergence := deploymentManifest.Update.Convergence
+
+	if !convergence.WaitsForAgent() {
+		return i.skipStep(fmt.Sprintf("Updating instance '%s/%d'", i.jobName, i.id), convergence, stage)
+	}
+
 	initialAgentState, err := i.stateBuilder.BuildInitialState(i.jobName, i.id, deploymentManifest)
 	if err != nil {
 		return bosherr.WrapErrorf(err, "Building initial state for instance '%s/%d'", i.jobName, i.id)
@@ -186,6 +198,15 @@ func (i *instance) UpdateJobs(
 		return err
 	}
 
+	err = i.recordTransition(biconfig.InstanceStateApplied)
+	if err != nil {
+		return err
+	}
+
+	if !convergence.WaitsForJobs() {
+		return i.skipStep(fmt.Sprintf("Waiting for instance '%s/%d' to be running", i.jobName, i.id), convergence, stage)
+	}
+
 	err = i.waitUntilJobsAreRunning(deploymentManifest.Update.UpdateWatchTime, stage)
 	if err != nil {
 		return err
@@ -199,8 +220,20 @@ func (i *instance) UpdateJobs(
 		}
 		return nil
 	})
+	if err != nil {
+		return err
+	}
 
-	return err
+	return i.recordTransition(biconfig.InstanceStateRunning)
+}
+
+func (i *instance) recordTransition(state string) error {
+	err := i.stateRepo.RecordTransition(fmt.Sprintf("%s/%d", i.jobName, i.id), state)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Recording instance '%s/%d' entering state '%s'", i.jobName, i.id, state)
+	}
+
+	return nil
 }
 
 func (i *instance) Delete(
@@ -278,6 +311,12 @@ func (i *instance) waitUntilJobsAreRunning(updateWatchTime bideplmanifest.WatchT
 	})
 }
 
+func (i *instance) skipStep(stepName string, convergence bideplmanifest.Convergence, stage biui.Stage) error {
+	return stage.Perform(stepName, func() error {
+		return biui.NewSkipStageError(bosherr.Errorf("Convergence policy is '%s'", convergence), "Skipped by convergence policy")
+	})
+}
+
 func (i *instance) drainJobs(stage biui.Stage) error {
 	stepName := fmt.Sprintf("Draining jobs on instance '%s/%d'", i.jobName, i.id)
 	return stage.Perform(stepName, func() error {
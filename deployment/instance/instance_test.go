@@ -21,6 +21,7 @@ import (
 	"github.com/cloudfoundry/bosh-utils/logger/loggerfakes"
 
 	"github.com/cloudfoundry/bosh-agent/agentclient"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	fakebidisk "github.com/cloudfoundry/bosh-cli/deployment/disk/fakes"
 	fakebisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel/fakes"
 	fakebivm "github.com/cloudfoundry/bosh-cli/deployment/vm/fakes"
@@ -50,6 +51,8 @@ var _ = Describe("Instance", func() {
 		fakeStage            *fakebiui.FakeStage
 		logger               *loggerfakes.FakeLogger
 
+		fakeInstanceStateRepo *fakebiconfig.FakeInstanceStateRepo
+
 		instance Instance
 
 		pingTimeout = 1 * time.Second
@@ -77,6 +80,8 @@ var _ = Describe("Instance", func() {
 
 		skipDrain = false
 
+		fakeInstanceStateRepo = fakebiconfig.NewFakeInstanceStateRepo()
+
 		instance = NewInstance(
 			jobName,
 			jobIndex,
@@ -84,6 +89,7 @@ var _ = Describe("Instance", func() {
 			fakeVMManager,
 			fakeSSHTunnelFactory,
 			mockStateBuilder,
+			fakeInstanceStateRepo,
 			logger,
 		)
 
@@ -369,6 +375,66 @@ var _ = Describe("Instance", func() {
 			}))
 		})
 
+		It("records the instance entering the applied and running states", func() {
+			err := instance.UpdateJobs(deploymentManifest, fakeStage)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeInstanceStateRepo.Transitions["fake-job-name/0"]).To(Equal([]string{"applied", "running"}))
+		})
+
+		Context("when recording a state transition fails", func() {
+			BeforeEach(func() {
+				fakeInstanceStateRepo.RecordTransitionErr = bosherr.Error("fake-record-err")
+			})
+
+			It("returns an error", func() {
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("Recording instance 'fake-job-name/0' entering state 'applied': fake-record-err"))
+			})
+		})
+
+		Context("when the convergence policy does not wait for running jobs", func() {
+			BeforeEach(func() {
+				deploymentManifest.Update.Convergence = bideplmanifest.ConvergenceAgent
+			})
+
+			It("applies the jobs without waiting for them or running post-start scripts", func() {
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.StartCalled).To(Equal(1))
+				Expect(fakeVM.RunScriptInputs).To(Equal([]string{"pre-start"}))
+				Expect(fakeVM.WaitToBeRunningInputs).To(BeEmpty())
+
+				Expect(fakeStage.PerformCalls).To(HaveLen(2))
+				Expect(fakeStage.PerformCalls[1].Name).To(Equal("Waiting for instance 'fake-job-name/0' to be running"))
+				Expect(fakeStage.PerformCalls[1].SkipError.Error()).To(Equal("Skipped by convergence policy: Convergence policy is 'agent'"))
+
+				Expect(fakeInstanceStateRepo.Transitions["fake-job-name/0"]).To(Equal([]string{"applied"}))
+			})
+		})
+
+		Context("when the convergence policy does not wait for the agent", func() {
+			BeforeEach(func() {
+				deploymentManifest.Update.Convergence = bideplmanifest.ConvergenceNone
+			})
+
+			It("does not contact the agent", func() {
+				expectStateBuildInitialState.Times(0)
+
+				err := instance.UpdateJobs(deploymentManifest, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeVM.ApplyInputs).To(BeEmpty())
+				Expect(fakeVM.StopCalled).To(Equal(0))
+
+				Expect(fakeStage.PerformCalls).To(HaveLen(1))
+				Expect(fakeStage.PerformCalls[0].Name).To(Equal("Updating instance 'fake-job-name/0'"))
+				Expect(fakeStage.PerformCalls[0].SkipError.Error()).To(Equal("Skipped by convergence policy: Convergence policy is 'none'"))
+			})
+		})
+
 		Context("when instance state building fails", func() {
 			JustBeforeEach(func() {
 				expectStateBuild.Return(nil, bosherr.Error("fake-template-err")).Times(1)
@@ -550,6 +616,22 @@ var _ = Describe("Instance", func() {
 				})
 			})
 
+			Context("when agents reach the registry without an SSH tunnel", func() {
+				BeforeEach(func() {
+					registryConfig = biinstallmanifest.Registry{
+						Host:        "10.0.0.5",
+						Port:        125,
+						BindAddress: "10.0.0.5",
+					}
+				})
+
+				It("does not start ssh tunnel", func() {
+					err := instance.WaitUntilReady(registryConfig, fakeStage)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(fakeSSHTunnel.Started).To(BeFalse())
+				})
+			})
+
 			Context("when starting SSH tunnel fails", func() {
 				BeforeEach(func() {
 					fakeSSHTunnel.SetStartBehavior(bosherr.Error("fake-ssh-tunnel-start-error"), nil)
@@ -630,5 +712,48 @@ var _ = Describe("Instance", func() {
 			})
 
 		})
+
+		Context("when the registry listens on all IPv6 interfaces", func() {
+			BeforeEach(func() {
+				registryConfig = biinstallmanifest.Registry{
+					Host:        "127.0.0.1",
+					Port:        125,
+					BindAddress: "::",
+					SSHTunnel: biinstallmanifest.SSHTunnel{
+						Host:     "fake-ssh-host",
+						Port:     124,
+						User:     "fake-ssh-username",
+						Password: "fake-password",
+					},
+				}
+			})
+
+			It("forwards the tunnel to the IPv6 loopback address", func() {
+				err := instance.WaitUntilReady(registryConfig, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeSSHTunnelFactory.NewSSHTunnelOptions.LocalForwardHost).To(Equal("::1"))
+			})
+		})
+
+		Context("when the ssh tunnel has a keepalive interval", func() {
+			BeforeEach(func() {
+				registryConfig = biinstallmanifest.Registry{
+					Port: 125,
+					SSHTunnel: biinstallmanifest.SSHTunnel{
+						Host:              "fake-ssh-host",
+						Port:              124,
+						User:              "fake-ssh-username",
+						Password:          "fake-password",
+						KeepAliveInterval: 15,
+					},
+				}
+			})
+
+			It("sets the keepalive interval in seconds", func() {
+				err := instance.WaitUntilReady(registryConfig, fakeStage)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(fakeSSHTunnelFactory.NewSSHTunnelOptions.KeepAliveInterval).To(Equal(15 * time.Second))
+			})
+		})
 	})
 })
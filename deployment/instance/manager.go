@@ -6,6 +6,7 @@ import (
 
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
@@ -41,6 +42,7 @@ type manager struct {
 	blobstore        biblobstore.Blobstore
 	sshTunnelFactory bisshtunnel.Factory
 	instanceFactory  Factory
+	stateRepo        biconfig.InstanceStateRepo
 	logger           boshlog.Logger
 	logTag           string
 }
@@ -51,6 +53,7 @@ func NewManager(
 	blobstore biblobstore.Blobstore,
 	sshTunnelFactory bisshtunnel.Factory,
 	instanceFactory Factory,
+	stateRepo biconfig.InstanceStateRepo,
 	logger boshlog.Logger,
 ) Manager {
 	return &manager{
@@ -59,6 +62,7 @@ func NewManager(
 		blobstore:        blobstore,
 		sshTunnelFactory: sshTunnelFactory,
 		instanceFactory:  instanceFactory,
+		stateRepo:        stateRepo,
 		logger:           logger,
 		logTag:           "vmDeployer",
 	}
@@ -101,6 +105,8 @@ func (m *manager) Create(
 	registryConfig biinstallmanifest.Registry,
 	eventLoggerStage biui.Stage,
 ) (Instance, []bidisk.Disk, error) {
+	instanceName := fmt.Sprintf("%s/%d", jobName, id)
+
 	var vm bivm.VM
 	stepName := fmt.Sprintf("Creating VM for instance '%s/%d' from stemcell '%s'", jobName, id, cloudStemcell.CID())
 	err := eventLoggerStage.Perform(stepName, func() error {
@@ -120,8 +126,22 @@ func (m *manager) Create(
 		return nil, []bidisk.Disk{}, err
 	}
 
+	err = m.stateRepo.RecordTransition(instanceName, biconfig.InstanceStateVMCreated)
+	if err != nil {
+		return nil, []bidisk.Disk{}, m.wrapTransitionErr(err, instanceName, biconfig.InstanceStateVMCreated)
+	}
+
 	instance := m.instanceFactory.NewInstance(jobName, id, vm, m.vmManager, m.sshTunnelFactory, m.blobstore, m.logger)
 
+	// Disks are mounted through the agent, so they are left for a later deploy
+	if convergence := deploymentManifest.Update.Convergence; !convergence.WaitsForAgent() {
+		stepName = fmt.Sprintf("Waiting for the agent on VM '%s' to be ready", vm.CID())
+		err = eventLoggerStage.Perform(stepName, func() error {
+			return biui.NewSkipStageError(bosherr.Errorf("Convergence policy is '%s'", convergence), "Skipped by convergence policy")
+		})
+		return instance, []bidisk.Disk{}, err
+	}
+
 	if err := instance.WaitUntilReady(registryConfig, eventLoggerStage); err != nil {
 		return instance, []bidisk.Disk{}, bosherr.WrapError(err, "Waiting until instance is ready")
 	}
@@ -131,7 +151,18 @@ func (m *manager) Create(
 		return instance, disks, bosherr.WrapError(err, "Updating instance disks")
 	}
 
-	return instance, disks, err
+	if len(disks) > 0 {
+		err = m.stateRepo.RecordTransition(instanceName, biconfig.InstanceStateDiskAttached)
+		if err != nil {
+			return instance, disks, m.wrapTransitionErr(err, instanceName, biconfig.InstanceStateDiskAttached)
+		}
+	}
+
+	return instance, disks, nil
+}
+
+func (m *manager) wrapTransitionErr(err error, instanceName, state string) error {
+	return bosherr.WrapErrorf(err, "Recording instance '%s' entering state '%s'", instanceName, state)
 }
 
 func (m *manager) DeleteAll(
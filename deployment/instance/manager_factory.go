@@ -3,6 +3,7 @@ package instance
 import (
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -15,17 +16,20 @@ type ManagerFactory interface {
 type managerFactory struct {
 	sshTunnelFactory bisshtunnel.Factory
 	instanceFactory  Factory
+	stateRepo        biconfig.InstanceStateRepo
 	logger           boshlog.Logger
 }
 
 func NewManagerFactory(
 	sshTunnelFactory bisshtunnel.Factory,
 	instanceFactory Factory,
+	stateRepo biconfig.InstanceStateRepo,
 	logger boshlog.Logger,
 ) ManagerFactory {
 	return &managerFactory{
 		sshTunnelFactory: sshTunnelFactory,
 		instanceFactory:  instanceFactory,
+		stateRepo:        stateRepo,
 		logger:           logger,
 	}
 }
@@ -37,6 +41,7 @@ func (f *managerFactory) NewManager(cloud bicloud.Cloud, vmManager bivm.Manager,
 		blobstore,
 		f.sshTunnelFactory,
 		f.instanceFactory,
+		f.stateRepo,
 		f.logger,
 	)
 }
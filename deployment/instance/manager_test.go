@@ -24,6 +24,7 @@ import (
 
 	"github.com/cloudfoundry/bosh-agent/agentclient"
 	fakebicloud "github.com/cloudfoundry/bosh-cli/cloud/fakes"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	fakebidisk "github.com/cloudfoundry/bosh-cli/deployment/disk/fakes"
 	fakebisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel/fakes"
 	fakebivm "github.com/cloudfoundry/bosh-cli/deployment/vm/fakes"
@@ -51,12 +52,13 @@ var _ = Describe("Manager", func() {
 
 		mockBlobstore *mock_blobstore.MockBlobstore
 
-		fakeVMManager        *fakebivm.FakeManager
-		fakeSSHTunnelFactory *fakebisshtunnel.FakeFactory
-		fakeSSHTunnel        *fakebisshtunnel.FakeTunnel
-		instanceFactory      Factory
-		logger               boshlog.Logger
-		fakeStage            *fakebiui.FakeStage
+		fakeVMManager         *fakebivm.FakeManager
+		fakeSSHTunnelFactory  *fakebisshtunnel.FakeFactory
+		fakeSSHTunnel         *fakebisshtunnel.FakeTunnel
+		instanceFactory       Factory
+		fakeInstanceStateRepo *fakebiconfig.FakeInstanceStateRepo
+		logger                boshlog.Logger
+		fakeStage             *fakebiui.FakeStage
 
 		manager Manager
 	)
@@ -75,7 +77,8 @@ var _ = Describe("Manager", func() {
 		mockStateBuilder = mock_instance_state.NewMockBuilder(mockCtrl)
 		mockState = mock_instance_state.NewMockState(mockCtrl)
 
-		instanceFactory = NewFactory(mockStateBuilderFactory)
+		fakeInstanceStateRepo = fakebiconfig.NewFakeInstanceStateRepo()
+		instanceFactory = NewFactory(mockStateBuilderFactory, fakeInstanceStateRepo)
 
 		mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
 
@@ -89,6 +92,7 @@ var _ = Describe("Manager", func() {
 			mockBlobstore,
 			fakeSSHTunnelFactory,
 			instanceFactory,
+			fakeInstanceStateRepo,
 			logger,
 		)
 	})
@@ -181,6 +185,7 @@ var _ = Describe("Manager", func() {
 				fakeVMManager,
 				fakeSSHTunnelFactory,
 				mockStateBuilder,
+				fakeInstanceStateRepo,
 				logger,
 			)
 
@@ -302,6 +307,32 @@ var _ = Describe("Manager", func() {
 			}))
 		})
 
+		Context("when the convergence policy does not wait for the agent", func() {
+			BeforeEach(func() {
+				deploymentManifest.Update.Convergence = bideplmanifest.ConvergenceNone
+			})
+
+			It("creates the VM without waiting for the agent or updating disks", func() {
+				_, disks, err := manager.Create(
+					"fake-job-name",
+					0,
+					deploymentManifest,
+					fakeCloudStemcell,
+					registry,
+					fakeStage,
+				)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(disks).To(BeEmpty())
+
+				Expect(fakeVM.WaitUntilReadyInputs).To(BeEmpty())
+				Expect(fakeVM.UpdateDisksInputs).To(BeEmpty())
+
+				Expect(fakeStage.PerformCalls).To(HaveLen(2))
+				Expect(fakeStage.PerformCalls[1].Name).To(Equal("Waiting for the agent on VM 'fake-vm-cid' to be ready"))
+				Expect(fakeStage.PerformCalls[1].SkipError.Error()).To(Equal("Skipped by convergence policy: Convergence policy is 'none'"))
+			})
+		})
+
 		Context("when registry or sshTunnelConfig are not empty", func() {
 			BeforeEach(func() {
 				registry = biinstallmanifest.Registry{
@@ -335,6 +366,7 @@ var _ = Describe("Manager", func() {
 					Port:              123,
 					Password:          "fake-ssh-password",
 					PrivateKey:        "---BEGIN PRIVATE KEY--- im a real key ---END PRIVATE KEY---",
+					LocalForwardHost:  "fake-registry-host",
 					LocalForwardPort:  124,
 					RemoteForwardPort: 124,
 				}))
@@ -74,8 +74,10 @@ func (b *builder) Build(jobName string, instanceID int, deploymentManifest bidep
 	}
 
 	releaseJobProperties := make(map[string]*biproperty.Map)
+	releaseJobLinks := make(map[string]map[string]biproperty.Map)
 	for _, releaseJob := range deploymentJob.Templates {
 		releaseJobProperties[releaseJob.Name] = releaseJob.Properties
+		releaseJobLinks[releaseJob.Name] = releaseJob.Consumes
 	}
 
 	defaultAddress, err := b.defaultAddress(initialState.NetworkInterfaces(), agentState)
@@ -83,7 +85,7 @@ func (b *builder) Build(jobName string, instanceID int, deploymentManifest bidep
 		return nil, err
 	}
 
-	renderedJobTemplates, err := b.renderJobTemplates(releaseJobs, releaseJobProperties, deploymentJob.Properties, deploymentManifest.Properties, deploymentManifest.Name, defaultAddress, stage)
+	renderedJobTemplates, err := b.renderJobTemplates(releaseJobs, releaseJobProperties, releaseJobLinks, deploymentJob.Properties, deploymentManifest.Properties, deploymentManifest.Name, defaultAddress, stage)
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Rendering job templates for instance '%s/%d'", jobName, instanceID)
 	}
@@ -183,6 +185,7 @@ func (b *builder) resolveJobs(jobRefs []bideplmanifest.ReleaseJobRef) ([]bireljo
 func (b *builder) renderJobTemplates(
 	releaseJobs []bireljob.Job,
 	releaseJobProperties map[string]*biproperty.Map,
+	releaseJobLinks map[string]map[string]biproperty.Map,
 	jobProperties biproperty.Map,
 	globalProperties biproperty.Map,
 	deploymentName string,
@@ -194,7 +197,7 @@ func (b *builder) renderJobTemplates(
 		blobID                 string
 	)
 	err := stage.Perform("Rendering job templates", func() error {
-		renderedJobList, err := b.jobListRenderer.Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, deploymentName, address)
+		renderedJobList, err := b.jobListRenderer.Render(releaseJobs, releaseJobProperties, releaseJobLinks, jobProperties, globalProperties, deploymentName, address)
 		if err != nil {
 			return err
 		}
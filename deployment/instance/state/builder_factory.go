@@ -16,6 +16,8 @@ type BuilderFactory interface {
 
 type builderFactory struct {
 	packageRepo               bistatepkg.CompiledPackageRepo
+	sourceBlobRepo            bistatepkg.SourceBlobRepo
+	compileConcurrency        int
 	releaseJobResolver        bideplrel.JobResolver
 	jobRenderer               bitemplate.JobListRenderer
 	renderedJobListCompressor bitemplate.RenderedJobListCompressor
@@ -24,6 +26,8 @@ type builderFactory struct {
 
 func NewBuilderFactory(
 	packageRepo bistatepkg.CompiledPackageRepo,
+	sourceBlobRepo bistatepkg.SourceBlobRepo,
+	compileConcurrency int,
 	releaseJobResolver bideplrel.JobResolver,
 	jobRenderer bitemplate.JobListRenderer,
 	renderedJobListCompressor bitemplate.RenderedJobListCompressor,
@@ -31,6 +35,8 @@ func NewBuilderFactory(
 ) BuilderFactory {
 	return &builderFactory{
 		packageRepo:               packageRepo,
+		sourceBlobRepo:            sourceBlobRepo,
+		compileConcurrency:        compileConcurrency,
 		releaseJobResolver:        releaseJobResolver,
 		jobRenderer:               jobRenderer,
 		renderedJobListCompressor: renderedJobListCompressor,
@@ -39,8 +45,8 @@ func NewBuilderFactory(
 }
 
 func (f *builderFactory) NewBuilder(blobstore biblobstore.Blobstore, agentClient biagentclient.AgentClient) Builder {
-	packageCompiler := NewRemotePackageCompiler(blobstore, agentClient, f.packageRepo)
-	jobDependencyCompiler := bistatejob.NewDependencyCompiler(packageCompiler, f.logger)
+	packageCompiler := NewRemotePackageCompilerWithSourceBlobs(blobstore, agentClient, f.packageRepo, f.sourceBlobRepo)
+	jobDependencyCompiler := bistatejob.NewDependencyCompilerWithConcurrency(packageCompiler, f.compileConcurrency, f.logger)
 
 	return NewBuilder(
 		f.releaseJobResolver,
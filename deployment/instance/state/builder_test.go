@@ -284,7 +284,11 @@ var _ = Describe("Builder", func() {
 				"fake-job-property": "fake-global-property-value",
 			}
 
-			mockJobListRenderer.EXPECT().Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", expectedIP).Return(mockRenderedJobList, nil)
+			releaseJobLinks := map[string]map[string]biproperty.Map{
+				"job-name": nil,
+			}
+
+			mockJobListRenderer.EXPECT().Render(releaseJobs, releaseJobProperties, releaseJobLinks, jobProperties, globalProperties, "fake-deployment-name", expectedIP).Return(mockRenderedJobList, nil)
 
 			mockRenderedJobList.EXPECT().DeleteSilently()
 
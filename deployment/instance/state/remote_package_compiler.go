@@ -1,6 +1,8 @@
 package state
 
 import (
+	"sync"
+
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	biblobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
@@ -9,29 +11,58 @@ import (
 )
 
 type remotePackageCompiler struct {
-	blobstore   biblobstore.Blobstore
-	agentClient biagentclient.AgentClient
-	packageRepo bistatepkg.CompiledPackageRepo
+	blobstore      biblobstore.Blobstore
+	agentClient    biagentclient.AgentClient
+	packageRepo    bistatepkg.CompiledPackageRepo
+	sourceBlobRepo bistatepkg.SourceBlobRepo
+
+	// lock serializes access to the repos, which are backed by the
+	// deployment state, when packages are compiled concurrently
+	lock sync.Mutex
 }
 
 func NewRemotePackageCompiler(
 	blobstore biblobstore.Blobstore,
 	agentClient biagentclient.AgentClient,
 	packageRepo bistatepkg.CompiledPackageRepo,
+) bistatepkg.Compiler {
+	return NewRemotePackageCompilerWithSourceBlobs(blobstore, agentClient, packageRepo, nil)
+}
+
+// NewRemotePackageCompilerWithSourceBlobs only uploads package archives
+// missing from the source blob repo, and records the uploaded ones
+func NewRemotePackageCompilerWithSourceBlobs(
+	blobstore biblobstore.Blobstore,
+	agentClient biagentclient.AgentClient,
+	packageRepo bistatepkg.CompiledPackageRepo,
+	sourceBlobRepo bistatepkg.SourceBlobRepo,
 ) bistatepkg.Compiler {
 	return &remotePackageCompiler{
-		blobstore:   blobstore,
-		agentClient: agentClient,
-		packageRepo: packageRepo,
+		blobstore:      blobstore,
+		agentClient:    agentClient,
+		packageRepo:    packageRepo,
+		sourceBlobRepo: sourceBlobRepo,
 	}
 }
 
 func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
 	var record bistatepkg.CompiledPackageRecord
 
-	blobID, err := c.blobstore.Add(pkg.ArchivePath())
+	if !pkg.IsCompiled() {
+		// Packages are found by their fingerprint and the fingerprints of their
+		// dependencies, so only changed packages and their dependents are compiled
+		record, found, err := c.findCompiledPackage(pkg)
+		if err != nil {
+			return record, false, bosherr.WrapErrorf(err, "Finding compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+		}
+		if found {
+			return record, true, nil
+		}
+	}
+
+	blobID, err := c.addArchive(pkg)
 	if err != nil {
-		return bistatepkg.CompiledPackageRecord{}, false, bosherr.WrapErrorf(err, "Adding release package archive '%s' to blobstore", pkg.ArchivePath())
+		return bistatepkg.CompiledPackageRecord{}, false, err
 	}
 
 	packageSource := biagentclient.BlobRef{
@@ -49,7 +80,7 @@ func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.Com
 		packageDependencies := make([]biagentclient.BlobRef, len(pkg.Deps()), len(pkg.Deps()))
 
 		for i, pkgDep := range pkg.Deps() {
-			compiledPackageRecord, found, err := c.packageRepo.Find(pkgDep)
+			compiledPackageRecord, found, err := c.findCompiledPackage(pkgDep)
 			if err != nil {
 				return record, false, bosherr.WrapErrorf(
 					err,
@@ -95,10 +126,54 @@ func (c *remotePackageCompiler) Compile(pkg birelpkg.Compilable) (bistatepkg.Com
 		}
 	}
 
+	c.lock.Lock()
 	err = c.packageRepo.Save(pkg, record)
+	c.lock.Unlock()
+
 	if err != nil {
 		return record, isAlreadyCompiled, bosherr.WrapErrorf(err, "Saving compiled package record '%#v' of package '%#v'", record, pkg)
 	}
 
 	return record, isAlreadyCompiled, nil
 }
+
+func (c *remotePackageCompiler) findCompiledPackage(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageRecord, bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.packageRepo.Find(pkg)
+}
+
+// addArchive uploads the package archive unless an archive with the same
+// digest was already uploaded to the blobstore
+func (c *remotePackageCompiler) addArchive(pkg birelpkg.Compilable) (string, error) {
+	if c.sourceBlobRepo != nil {
+		c.lock.Lock()
+		blobID, found, err := c.sourceBlobRepo.Find(pkg)
+		c.lock.Unlock()
+
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Finding source blob of package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+		}
+		if found {
+			return blobID, nil
+		}
+	}
+
+	blobID, err := c.blobstore.Add(pkg.ArchivePath())
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Adding release package archive '%s' to blobstore", pkg.ArchivePath())
+	}
+
+	if c.sourceBlobRepo != nil {
+		c.lock.Lock()
+		err = c.sourceBlobRepo.Save(pkg, blobID)
+		c.lock.Unlock()
+
+		if err != nil {
+			return "", bosherr.WrapErrorf(err, "Saving source blob of package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+		}
+	}
+
+	return blobID, nil
+}
@@ -132,6 +132,102 @@ var _ = Describe("RemotePackageCompiler", func() {
 				Expect(record).To(Equal(compiledPackageRecord))
 			})
 
+			Context("when the package was compiled before with the same dependencies", func() {
+				BeforeEach(func() {
+					previousRecord := bistatepkg.CompiledPackageRecord{
+						BlobID:   "fake-previous-compiled-package-blob-id",
+						BlobSHA1: "fake-previous-compiled-package-sha1",
+					}
+					compiledPackages[previousRecord] = pkg
+				})
+
+				It("returns the previously compiled package without uploading or compiling it", func() {
+					expectBlobstoreAdd.Times(0)
+					expectAgentCompile.Times(0)
+
+					compiledPackageRecord, isAlreadyCompiled, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(isAlreadyCompiled).To(BeTrue())
+					Expect(compiledPackageRecord).To(Equal(bistatepkg.CompiledPackageRecord{
+						BlobID:   "fake-previous-compiled-package-blob-id",
+						BlobSHA1: "fake-previous-compiled-package-sha1",
+					}))
+				})
+			})
+
+			Context("when the package was compiled before with a different dependency", func() {
+				BeforeEach(func() {
+					changedDependency := boshpkg.NewPackage(NewResource(
+						"fake-package-name-dep", "fake-package-fingerprint-dep-changed", nil), nil)
+
+					previousPkg := boshpkg.NewPackage(NewResourceWithBuiltArchive(
+						"fake-package-name", "fake-package-fingerprint", archivePath, "fake-source-package-sha1"), []string{"fake-package-name-dep"})
+					previousPkg.AttachDependencies([]*boshpkg.Package{changedDependency})
+
+					previousRecord := bistatepkg.CompiledPackageRecord{
+						BlobID:   "fake-previous-compiled-package-blob-id",
+						BlobSHA1: "fake-previous-compiled-package-sha1",
+					}
+					compiledPackages[previousRecord] = previousPkg
+				})
+
+				It("compiles the package again", func() {
+					expectBlobstoreAdd.Times(1)
+					expectAgentCompile.Times(1)
+
+					compiledPackageRecord, isAlreadyCompiled, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(isAlreadyCompiled).To(BeFalse())
+					Expect(compiledPackageRecord.BlobID).To(Equal("fake-compiled-package-blob-id"))
+				})
+			})
+
+			Context("when uploaded package archives are tracked", func() {
+				var (
+					sourceBlobRepo bistatepkg.SourceBlobRepo
+				)
+
+				BeforeEach(func() {
+					sourceBlobRepo = bistatepkg.NewSourceBlobRepo(biindex.NewInMemoryIndex())
+					remotePackageCompiler = NewRemotePackageCompilerWithSourceBlobs(mockBlobstore, mockAgentClient, packageRepo, sourceBlobRepo)
+				})
+
+				It("records the uploaded package archive", func() {
+					_, _, err := remotePackageCompiler.Compile(pkg)
+					Expect(err).ToNot(HaveOccurred())
+
+					blobID, found, err := sourceBlobRepo.Find(pkg)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(found).To(BeTrue())
+					Expect(blobID).To(Equal("fake-source-package-blob-id"))
+				})
+
+				Context("when the package archive was uploaded before", func() {
+					BeforeEach(func() {
+						err := sourceBlobRepo.Save(pkg, "fake-previous-source-package-blob-id")
+						Expect(err).ToNot(HaveOccurred())
+
+						mockAgentClient.EXPECT().CompilePackage(biagentclient.BlobRef{
+							Name:        "fake-package-name",
+							Version:     "fake-package-fingerprint",
+							BlobstoreID: "fake-previous-source-package-blob-id",
+							SHA1:        "fake-source-package-sha1",
+						}, gomock.Any()).Return(biagentclient.BlobRef{
+							BlobstoreID: "fake-compiled-package-blob-id",
+							SHA1:        "fake-compiled-package-sha1",
+						}, nil)
+					})
+
+					It("compiles the package from the uploaded archive without uploading it again", func() {
+						expectBlobstoreAdd.Times(0)
+
+						compiledPackageRecord, _, err := remotePackageCompiler.Compile(pkg)
+						Expect(err).ToNot(HaveOccurred())
+						Expect(compiledPackageRecord.BlobID).To(Equal("fake-compiled-package-blob-id"))
+					})
+				})
+			})
+
 			Context("when the dependencies are not in the repo", func() {
 				BeforeEach(func() {
 					compiledPackages = map[bistatepkg.CompiledPackageRecord]*boshpkg.Package{}
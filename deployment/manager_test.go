@@ -17,6 +17,7 @@ import (
 
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
@@ -192,8 +193,9 @@ var _ = Describe("Manager", func() {
 
 			mockStateBuilderFactory = mock_instance_state.NewMockBuilderFactory(mockCtrl)
 
-			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, logger)
+			fakeInstanceStateRepo := fakebiconfig.NewFakeInstanceStateRepo()
+			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory, fakeInstanceStateRepo)
+			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, fakeInstanceStateRepo, logger)
 			stemcellManagerFactory := bistemcell.NewManagerFactory(stemcellRepo)
 
 			mockBlobstore = mock_blobstore.NewMockBlobstore(mockCtrl)
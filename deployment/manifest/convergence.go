@@ -0,0 +1,41 @@
+package manifest
+
+// Convergence controls how much of the deployed instance is verified
+// through the agent before a deploy is considered done. An empty
+// Convergence behaves like ConvergenceRunning.
+type Convergence string
+
+const (
+	// ConvergenceRunning waits for the agent and for all jobs to be running
+	ConvergenceRunning Convergence = "running"
+
+	// ConvergenceAgent applies the jobs through the agent without waiting
+	// for them to be running and without running post-start scripts
+	ConvergenceAgent Convergence = "agent"
+
+	// ConvergenceNone only creates the VM; nothing is sent to the agent,
+	// so disks are not attached and jobs are not applied
+	ConvergenceNone Convergence = "none"
+)
+
+var convergences = []Convergence{ConvergenceRunning, ConvergenceAgent, ConvergenceNone}
+
+func (c Convergence) IsValid() bool {
+	for _, convergence := range convergences {
+		if c == convergence {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WaitsForAgent is false when the agent may be unreachable from the workstation
+func (c Convergence) WaitsForAgent() bool {
+	return c != ConvergenceNone
+}
+
+// WaitsForJobs is false when the deploy does not verify that jobs are running
+func (c Convergence) WaitsForJobs() bool {
+	return c == ConvergenceRunning || c == ""
+}
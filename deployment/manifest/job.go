@@ -14,6 +14,19 @@ type Job struct {
 	PersistentDiskPool string
 	ResourcePool       string
 	Properties         biproperty.Map
+
+	// AZs picks the CPI from cloud_provider.cpis; create-env deploys a
+	// single instance, so at most one AZ is allowed
+	AZs []string
+}
+
+// AZ returns the AZ the job is deployed to, if any
+func (j Job) AZ() string {
+	if len(j.AZs) == 0 {
+		return ""
+	}
+
+	return j.AZs[0]
 }
 
 type JobLifecycle string
@@ -27,6 +40,9 @@ type ReleaseJobRef struct {
 	Name       string
 	Release    string
 	Properties *biproperty.Map
+
+	// Consumes holds manually wired link values keyed by link name
+	Consumes map[string]biproperty.Map
 }
 
 type JobNetwork struct {
@@ -18,6 +18,7 @@ type Manifest struct {
 
 type Update struct {
 	UpdateWatchTime WatchTime
+	Convergence     Convergence
 }
 
 // NetworkInterfaces returns a map of network names to network interfaces.
@@ -52,6 +53,22 @@ func (d Manifest) JobName() string {
 	return d.Jobs[0].Name
 }
 
+// StaticIP returns the first static IP assigned to the job, if any
+func (d Manifest) StaticIP(jobName string) (string, bool) {
+	job, found := d.FindJobByName(jobName)
+	if !found {
+		return "", false
+	}
+
+	for _, jobNetwork := range job.Networks {
+		if len(jobNetwork.StaticIPs) > 0 {
+			return jobNetwork.StaticIPs[0], true
+		}
+	}
+
+	return "", false
+}
+
 func (d Manifest) Stemcell(jobName string) (StemcellRef, error) {
 	resourcePool, err := d.ResourcePool(jobName)
 	if err != nil {
@@ -186,6 +186,40 @@ var _ = Describe("Manifest", func() {
 		})
 	})
 
+	Describe("StaticIP", func() {
+		BeforeEach(func() {
+			deploymentManifest = Manifest{
+				Jobs: []Job{
+					{
+						Name: "fake-job-name",
+						Networks: []JobNetwork{
+							{Name: "fake-dynamic-network-name"},
+							{Name: "fake-manual-network-name", StaticIPs: []string{"10.0.0.6", "10.0.0.7"}},
+						},
+					},
+				},
+			}
+		})
+
+		It("returns the first static IP of the job", func() {
+			ip, found := deploymentManifest.StaticIP("fake-job-name")
+			Expect(found).To(BeTrue())
+			Expect(ip).To(Equal("10.0.0.6"))
+		})
+
+		It("returns false when the job has no static IPs", func() {
+			deploymentManifest.Jobs[0].Networks = deploymentManifest.Jobs[0].Networks[:1]
+
+			_, found := deploymentManifest.StaticIP("fake-job-name")
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns false when the job does not exist", func() {
+			_, found := deploymentManifest.StaticIP("fake-missing-job-name")
+			Expect(found).To(BeFalse())
+		})
+	})
+
 	Describe("ResourcePool", func() {
 		BeforeEach(func() {
 			deploymentManifest = Manifest{
@@ -228,6 +262,37 @@ var _ = Describe("Manifest", func() {
 		})
 	})
 
+	Describe("ResourcePool PlaintextPassword", func() {
+		It("returns a plaintext env.bosh.password", func() {
+			resourcePool := ResourcePool{Env: biproperty.Map{"bosh": biproperty.Map{"password": "fake-password"}}}
+
+			password, found := resourcePool.PlaintextPassword()
+			Expect(found).To(BeTrue())
+			Expect(password).To(Equal("fake-password"))
+		})
+
+		It("ignores passwords that are already hashed", func() {
+			resourcePool := ResourcePool{Env: biproperty.Map{"bosh": biproperty.Map{"password": "$6$saltstring$svn8UoSVapNtMuq1ukKS4tPQd8iKwSMHWjl/O817G3uBnIFNjnQJuesI68u4OTLiBFdcbYEdFCoEOfaS35inz1"}}}
+
+			_, found := resourcePool.PlaintextPassword()
+			Expect(found).To(BeFalse())
+		})
+
+		It("ignores resource pools without a password", func() {
+			_, found := ResourcePool{Env: biproperty.Map{}}.PlaintextPassword()
+			Expect(found).To(BeFalse())
+		})
+
+		It("replaces the password in the env", func() {
+			resourcePool := ResourcePool{Env: biproperty.Map{"bosh": biproperty.Map{"password": "fake-password", "other": "value"}}}
+
+			resourcePool.SetPassword("fake-hashed-password")
+			Expect(resourcePool.Env).To(Equal(biproperty.Map{
+				"bosh": biproperty.Map{"password": "fake-hashed-password", "other": "value"},
+			}))
+		})
+	})
+
 	Describe("DiskPool", func() {
 		Context("when the deployment has disk_pools", func() {
 			BeforeEach(func() {
@@ -34,6 +34,7 @@ type manifest struct {
 
 type UpdateSpec struct {
 	UpdateWatchTime *string `yaml:"update_watch_time"`
+	Convergence     *string `yaml:"convergence"`
 }
 
 type network struct {
@@ -60,6 +61,21 @@ type resourcePool struct {
 	CloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
 	Env             map[interface{}]interface{} `yaml:"env"`
 	Stemcell        stemcellRef                 `yaml:"stemcell"`
+	EphemeralDisk   ephemeralDisk               `yaml:"ephemeral_disk"`
+	RawDisks        []rawDisk                   `yaml:"raw_disks"`
+}
+
+type ephemeralDisk struct {
+	Size            int                         `yaml:"size"`
+	Path            string                      `yaml:"path"`
+	CloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
+}
+
+type rawDisk struct {
+	Name            string                      `yaml:"name"`
+	Size            int                         `yaml:"size"`
+	Path            string                      `yaml:"path"`
+	CloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
 }
 
 type diskPool struct {
@@ -79,6 +95,7 @@ type job struct {
 	PersistentDiskPool string `yaml:"persistent_disk_pool"`
 	ResourcePool       string `yaml:"resource_pool"`
 	Properties         map[interface{}]interface{}
+	AZs                []string `yaml:"azs"`
 }
 
 type releaseJobRef struct {
@@ -88,11 +105,18 @@ type releaseJobRef struct {
 	// This is a pointer so we can differentiate between `properties: {}`
 	// and not specifying the key at all.
 	Properties *map[interface{}]interface{}
+
+	Consumes map[string]interface{}
 }
 
 type stemcellRef struct {
-	URL  string
-	SHA1 string
+	URL        string
+	SHA1       string
+	Name       string
+	Version    string
+	DiskFormat string `yaml:"disk_format"`
+
+	CloudProperties map[interface{}]interface{} `yaml:"cloud_properties"`
 }
 
 type jobNetwork struct {
@@ -188,14 +212,42 @@ func (p *parser) parseDeploymentManifest(depManifest manifest, path string) (Man
 			return Manifest{}, bosherr.WrapError(err, "Parsing update watch time")
 		}
 
-		deployment.Update = Update{
-			UpdateWatchTime: updateWatchTime,
-		}
+		deployment.Update.UpdateWatchTime = updateWatchTime
+	}
+
+	if depManifest.Update.Convergence != nil {
+		deployment.Update.Convergence = Convergence(*depManifest.Update.Convergence)
 	}
 
 	return deployment, nil
 }
 
+// parseConsumedLinks only supports manually specified link values since
+// there is no director to resolve links between jobs
+func (p *parser) parseConsumedLinks(rawJobRef releaseJobRef) (map[string]biproperty.Map, error) {
+	consumes := map[string]biproperty.Map{}
+
+	for linkName, rawLink := range rawJobRef.Consumes {
+		if rawLink == nil {
+			continue
+		}
+
+		rawLinkMap, ok := rawLink.(map[interface{}]interface{})
+		if !ok {
+			return nil, bosherr.Errorf("Expected consumed link '%s' of release job '%s' to be a hash of manual link values", linkName, rawJobRef.Name)
+		}
+
+		link, err := biproperty.BuildMap(rawLinkMap)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Parsing consumed link '%s' of release job '%s'", linkName, rawJobRef.Name)
+		}
+
+		consumes[linkName] = link
+	}
+
+	return consumes, nil
+}
+
 func (p *parser) parseJobManifests(rawJobs []job) ([]Job, error) {
 	jobs := make([]Job, len(rawJobs), len(rawJobs))
 	for i, rawJob := range rawJobs {
@@ -206,6 +258,7 @@ func (p *parser) parseJobManifests(rawJobs []job) ([]Job, error) {
 			PersistentDisk:     rawJob.PersistentDisk,
 			PersistentDiskPool: rawJob.PersistentDiskPool,
 			ResourcePool:       rawJob.ResourcePool,
+			AZs:                rawJob.AZs,
 		}
 
 		if len(rawJob.Templates) > 0 && len(rawJob.Jobs) > 0 {
@@ -234,6 +287,15 @@ func (p *parser) parseJobManifests(rawJobs []job) ([]Job, error) {
 					ref.Properties = &properties
 				}
 
+				if rawJobRef.Consumes != nil {
+					consumes, err := p.parseConsumedLinks(rawJobRef)
+					if err != nil {
+						return []Job{}, err
+					}
+
+					ref.Consumes = consumes
+				}
+
 				releaseJobRefs[i] = ref
 			}
 			job.Templates = releaseJobRefs
@@ -313,9 +375,15 @@ func (p *parser) parseResourcePoolManifests(rawResourcePools []resourcePool, pat
 	resourcePools := make([]ResourcePool, len(rawResourcePools), len(rawResourcePools))
 	for i, rawResourcePool := range rawResourcePools {
 		resourcePool := ResourcePool{
-			Name:     rawResourcePool.Name,
-			Network:  rawResourcePool.Network,
-			Stemcell: StemcellRef(rawResourcePool.Stemcell),
+			Name:    rawResourcePool.Name,
+			Network: rawResourcePool.Network,
+			Stemcell: StemcellRef{
+				URL:        rawResourcePool.Stemcell.URL,
+				SHA1:       rawResourcePool.Stemcell.SHA1,
+				Name:       rawResourcePool.Stemcell.Name,
+				Version:    rawResourcePool.Stemcell.Version,
+				DiskFormat: rawResourcePool.Stemcell.DiskFormat,
+			},
 		}
 
 		cloudProperties, err := biproperty.BuildMap(rawResourcePool.CloudProperties)
@@ -330,9 +398,48 @@ func (p *parser) parseResourcePoolManifests(rawResourcePools []resourcePool, pat
 		}
 		resourcePool.Env = env
 
-		resourcePool.Stemcell.URL, err = biutil.AbsolutifyPath(path, resourcePool.Stemcell.URL, p.fs)
-		if err != nil {
-			return resourcePools, bosherr.WrapErrorf(err, "Resolving stemcell path '%s", resourcePool.Stemcell.URL)
+		if rawResourcePool.Stemcell.CloudProperties != nil {
+			stemcellCloudProperties, err := biproperty.BuildMap(rawResourcePool.Stemcell.CloudProperties)
+			if err != nil {
+				return resourcePools, bosherr.WrapErrorf(err, "Parsing resource_pool '%s' stemcell cloud_properties: %#v", rawResourcePool.Name, rawResourcePool.Stemcell.CloudProperties)
+			}
+			resourcePool.Stemcell.CloudProperties = stemcellCloudProperties
+		}
+
+		resourcePool.EphemeralDisk = EphemeralDisk{
+			Size: rawResourcePool.EphemeralDisk.Size,
+			Path: rawResourcePool.EphemeralDisk.Path,
+		}
+
+		if rawResourcePool.EphemeralDisk.CloudProperties != nil {
+			resourcePool.EphemeralDisk.CloudProperties, err = biproperty.BuildMap(rawResourcePool.EphemeralDisk.CloudProperties)
+			if err != nil {
+				return resourcePools, bosherr.WrapErrorf(err, "Parsing resource_pool '%s' ephemeral_disk cloud_properties: %#v", rawResourcePool.Name, rawResourcePool.EphemeralDisk.CloudProperties)
+			}
+		}
+
+		for _, rawDisk := range rawResourcePool.RawDisks {
+			disk := RawDisk{
+				Name: rawDisk.Name,
+				Size: rawDisk.Size,
+				Path: rawDisk.Path,
+			}
+
+			if rawDisk.CloudProperties != nil {
+				disk.CloudProperties, err = biproperty.BuildMap(rawDisk.CloudProperties)
+				if err != nil {
+					return resourcePools, bosherr.WrapErrorf(err, "Parsing resource_pool '%s' raw disk '%s' cloud_properties: %#v", rawResourcePool.Name, rawDisk.Name, rawDisk.CloudProperties)
+				}
+			}
+
+			resourcePool.RawDisks = append(resourcePool.RawDisks, disk)
+		}
+
+		if !resourcePool.Stemcell.IsNamed() {
+			resourcePool.Stemcell.URL, err = biutil.AbsolutifyPath(path, resourcePool.Stemcell.URL, p.fs)
+			if err != nil {
+				return resourcePools, bosherr.WrapErrorf(err, "Resolving stemcell path '%s", resourcePool.Stemcell.URL)
+			}
 		}
 
 		resourcePools[i] = resourcePool
@@ -47,6 +47,10 @@ resource_pools:
       password: secret
   stemcell:
     url: http://fake-stemcell-url
+    disk_format: raw
+    cloud_properties:
+      image:
+        family: fake-image-family
 networks:
 - name: fake-network-name
   type: dynamic
@@ -140,7 +144,13 @@ properties:
 							},
 						},
 						Stemcell: StemcellRef{
-							URL: "http://fake-stemcell-url",
+							URL:        "http://fake-stemcell-url",
+							DiskFormat: "raw",
+							CloudProperties: biproperty.Map{
+								"image": biproperty.Map{
+									"family": "fake-image-family",
+								},
+							},
 						},
 					},
 				},
@@ -361,6 +371,70 @@ resource_pools:
 			})
 		})
 
+		Context("when the stemcell is referred to by name and version", func() {
+			BeforeEach(func() {
+				contents := `
+---
+name: fake-deployment-manifest
+
+resource_pools:
+- name: fake-resource-pool-name
+  stemcell:
+    name: fake-stemcell-name
+    version: fake-stemcell-version
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("does not resolve a stemcell path", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentManifest.ResourcePools[0].Stemcell).To(Equal(StemcellRef{
+					Name:    "fake-stemcell-name",
+					Version: "fake-stemcell-version",
+				}))
+				Expect(deploymentManifest.ResourcePools[0].Stemcell.IsNamed()).To(BeTrue())
+			})
+		})
+
+		Context("when the resource pool declares ephemeral and raw disks", func() {
+			BeforeEach(func() {
+				contents := `
+---
+name: fake-deployment-manifest
+
+resource_pools:
+- name: fake-resource-pool-name
+  ephemeral_disk:
+    size: 10240
+    path: /dev/sdb
+    cloud_properties:
+      type: gp2
+  raw_disks:
+  - name: fake-raw-disk
+    size: 1024
+    cloud_properties:
+      type: local-ssd
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("parses them", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				resourcePool := deploymentManifest.ResourcePools[0]
+				Expect(resourcePool.EphemeralDisk).To(Equal(EphemeralDisk{
+					Size:            10240,
+					Path:            "/dev/sdb",
+					CloudProperties: biproperty.Map{"type": "gp2"},
+				}))
+				Expect(resourcePool.RawDisks).To(Equal([]RawDisk{
+					{Name: "fake-raw-disk", Size: 1024, CloudProperties: biproperty.Map{"type": "local-ssd"}},
+				}))
+			})
+		})
+
 		Context("when global property keys are not strings", func() {
 			BeforeEach(func() {
 				contents := `
@@ -453,6 +527,49 @@ name: fake-deployment-name
 			})
 		})
 
+		Context("when a job sets azs", func() {
+			BeforeEach(func() {
+				contents := `
+---
+name: fake-deployment-name
+jobs:
+- name: fake-db-job
+- name: fake-director-job
+  azs: [z1]
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("parses the azs", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deploymentManifest.Jobs[0].AZ()).To(BeEmpty())
+				Expect(deploymentManifest.Jobs[1].AZ()).To(Equal("z1"))
+			})
+		})
+
+		Context("when update convergence is set", func() {
+			BeforeEach(func() {
+				contents := `
+---
+name: fake-deployment-name
+update:
+  convergence: agent
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("parses the convergence policy", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(deploymentManifest.Update.Convergence).To(Equal(ConvergenceAgent))
+				Expect(deploymentManifest.Update.Convergence.WaitsForAgent()).To(BeTrue())
+				Expect(deploymentManifest.Update.Convergence.WaitsForJobs()).To(BeFalse())
+			})
+		})
+
 		Context("when instance_groups is defined, treats it as jobs", func() {
 			BeforeEach(func() {
 				contents := `
@@ -550,6 +667,56 @@ instance_groups:
 			})
 		})
 
+		Context("when a job consumes manually specified links", func() {
+			BeforeEach(func() {
+				contents := `
+---
+instance_groups:
+- name: jobby
+  jobs:
+  - name: job1
+    consumes:
+      db:
+        address: db.example.com
+        properties: {port: 5432}
+      unused: ~
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("parses the link values", func() {
+				deploymentManifest, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentManifest.Jobs[0].Templates[0].Consumes).To(Equal(map[string]biproperty.Map{
+					"db": biproperty.Map{
+						"address":    "db.example.com",
+						"properties": biproperty.Map{"port": 5432},
+					},
+				}))
+			})
+		})
+
+		Context("when a job consumes a link that is not a hash", func() {
+			BeforeEach(func() {
+				contents := `
+---
+instance_groups:
+- name: jobby
+  jobs:
+  - name: job1
+    consumes:
+      db: other-deployment
+`
+				interpolatedTemplate = bidepltpl.NewInterpolatedTemplate([]byte(contents), "fake-sha")
+			})
+
+			It("throws an error", func() {
+				_, err := parser.Parse(interpolatedTemplate, manifestPath)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Expected consumed link 'db' of release job 'job1' to be a hash of manual link values"))
+			})
+		})
+
 		Context("when both instance_groups and jobs are present at root level in deployment manifest", func() {
 			BeforeEach(func() {
 				contents := `
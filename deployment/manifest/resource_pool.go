@@ -1,6 +1,7 @@
 package manifest
 
 import (
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
@@ -10,13 +11,55 @@ type ResourcePool struct {
 	CloudProperties biproperty.Map
 	Env             biproperty.Map
 	Stemcell        StemcellRef
+
+	EphemeralDisk EphemeralDisk
+	RawDisks      []RawDisk
+}
+
+// EphemeralDisk asks the CPI for an ephemeral disk separate from the root
+// disk, for VMs whose root disk is too small to hold the ephemeral data
+type EphemeralDisk struct {
+	Size int
+
+	// Path is the device path of the disk given to the agent in its
+	// settings, for CPIs that leave it to the registry otherwise
+	Path            string
+	CloudProperties biproperty.Map
+}
+
+func (d EphemeralDisk) IsEmpty() bool {
+	return d.Size == 0 && d.Path == "" && len(d.CloudProperties) == 0
+}
+
+// RawDisk is an additional disk attached to the VM that the agent leaves
+// unformatted, e.g. instance storage used by a job directly
+type RawDisk struct {
+	Name            string
+	Size            int
+	Path            string
+	CloudProperties biproperty.Map
 }
 
 type StemcellRef struct {
 	URL  string
 	SHA1 string
+
+	// Name and Version refer to a stemcell given with --stemcell instead of a URL
+	Name    string
+	Version string
+
+	// DiskFormat is the image format the CPI expects, e.g. 'raw'. The stemcell
+	// image is converted to it before it is uploaded when it differs.
+	DiskFormat string
+
+	// CloudProperties are deep merged over the cloud_properties of the
+	// stemcell.MF before the stemcell is uploaded
+	CloudProperties biproperty.Map
 }
 
+// DiskFormats are the stemcell image formats that can be converted between
+var DiskFormats = []string{"qcow2", "raw"}
+
 func (s StemcellRef) GetURL() string {
 	return s.URL
 }
@@ -28,3 +71,104 @@ func (s StemcellRef) GetSHA1() string {
 func (s StemcellRef) Description() string {
 	return "stemcell"
 }
+
+// IsNamed is true for stemcells referred to by name and version
+func (s StemcellRef) IsNamed() bool {
+	return s.URL == "" && s.Name != ""
+}
+
+// PlaintextPassword returns env.bosh.password when it is set and is not already a crypt hash
+func (r ResourcePool) PlaintextPassword() (string, bool) {
+	boshEnv, ok := r.Env["bosh"].(biproperty.Map)
+	if !ok {
+		return "", false
+	}
+
+	password, ok := boshEnv["password"].(string)
+	if !ok || password == "" || bicrypto.IsCryptHash(password) {
+		return "", false
+	}
+
+	return password, true
+}
+
+// SetPassword replaces env.bosh.password, which is sent to the agent via create_vm
+func (r ResourcePool) SetPassword(password string) {
+	boshEnv, ok := r.Env["bosh"].(biproperty.Map)
+	if !ok {
+		boshEnv = biproperty.Map{}
+		r.Env["bosh"] = boshEnv
+	}
+
+	boshEnv["password"] = password
+}
+
+// HasBlobstores is true when env.bosh.blobstores is set, which the agent
+// uses instead of the blobstore in its settings
+func (r ResourcePool) HasBlobstores() bool {
+	boshEnv, ok := r.Env["bosh"].(biproperty.Map)
+	if !ok {
+		return false
+	}
+
+	_, found := boshEnv["blobstores"]
+	return found
+}
+
+// SetBlobstores replaces env.bosh.blobstores, which is sent to the agent via create_vm
+func (r *ResourcePool) SetBlobstores(blobstores []interface{}) {
+	if r.Env == nil {
+		r.Env = biproperty.Map{}
+	}
+
+	boshEnv, ok := r.Env["bosh"].(biproperty.Map)
+	if !ok {
+		boshEnv = biproperty.Map{}
+		r.Env["bosh"] = boshEnv
+	}
+
+	boshEnv["blobstores"] = blobstores
+}
+
+// VMCloudProperties are the cloud_properties passed to create_vm. The
+// ephemeral and raw disks are added under the 'ephemeral_disk' and
+// 'raw_disks' keys with their cloud_properties merged into them.
+func (r ResourcePool) VMCloudProperties() biproperty.Map {
+	if r.EphemeralDisk.IsEmpty() && len(r.RawDisks) == 0 {
+		return r.CloudProperties
+	}
+
+	cloudProperties := biproperty.Map{}
+	for key, value := range r.CloudProperties {
+		cloudProperties[key] = value
+	}
+
+	if !r.EphemeralDisk.IsEmpty() {
+		ephemeralDisk := biproperty.Map{}
+		for key, value := range r.EphemeralDisk.CloudProperties {
+			ephemeralDisk[key] = value
+		}
+		ephemeralDisk["size"] = r.EphemeralDisk.Size
+
+		cloudProperties["ephemeral_disk"] = ephemeralDisk
+	}
+
+	if len(r.RawDisks) > 0 {
+		rawDisks := []interface{}{}
+
+		for _, disk := range r.RawDisks {
+			rawDisk := biproperty.Map{}
+			for key, value := range disk.CloudProperties {
+				rawDisk[key] = value
+			}
+			rawDisk["name"] = disk.Name
+			rawDisk["size"] = disk.Size
+
+			rawDisks = append(rawDisks, rawDisk)
+		}
+
+		cloudProperties["raw_disks"] = rawDisks
+	}
+
+	return cloudProperties
+}
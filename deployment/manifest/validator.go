@@ -47,18 +47,30 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 			errs = append(errs, bosherr.Errorf("resource_pools[%d].network must be the name of a network", idx))
 		}
 
-		if v.isBlank(resourcePool.Stemcell.URL) {
-			errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.url must be provided", idx))
-		}
+		if resourcePool.Stemcell.IsNamed() {
+			if v.isBlank(resourcePool.Stemcell.Version) {
+				errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.version must be provided with stemcell.name", idx))
+			}
+		} else {
+			if v.isBlank(resourcePool.Stemcell.URL) {
+				errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.url must be provided", idx))
+			}
+
+			matched, err := regexp.MatchString("^(file|http|https)://", resourcePool.Stemcell.URL)
+			if err != nil || !matched {
+				errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.url must be a valid URL (file:// or http(s)://)", idx))
+			}
 
-		matched, err := regexp.MatchString("^(file|http|https)://", resourcePool.Stemcell.URL)
-		if err != nil || !matched {
-			errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.url must be a valid URL (file:// or http(s)://)", idx))
+			if strings.HasPrefix(resourcePool.Stemcell.URL, "http") && v.isBlank(resourcePool.Stemcell.SHA1) {
+				errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.sha1 must be provided for http URL", idx))
+			}
 		}
 
-		if strings.HasPrefix(resourcePool.Stemcell.URL, "http") && v.isBlank(resourcePool.Stemcell.SHA1) {
-			errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.sha1 must be provided for http URL", idx))
+		if resourcePool.Stemcell.DiskFormat != "" && !v.isValidDiskFormat(resourcePool.Stemcell.DiskFormat) {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].stemcell.disk_format must be one of: %s", idx, strings.Join(DiskFormats, ", ")))
 		}
+
+		errs = append(errs, v.validateResourcePoolDisks(idx, resourcePool)...)
 	}
 
 	for idx, diskPool := range deploymentManifest.DiskPools {
@@ -85,6 +97,10 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 			if _, ok := v.diskPoolNames(deploymentManifest)[job.PersistentDiskPool]; !ok {
 				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk_pool must be the name of a disk pool", idx))
 			}
+			// the disk pool would silently win over persistent_disk
+			if job.PersistentDisk > 0 {
+				errs = append(errs, bosherr.Errorf("jobs[%d].persistent_disk and jobs[%d].persistent_disk_pool must not both be provided", idx, idx))
+			}
 		}
 		if job.Instances < 0 {
 			errs = append(errs, bosherr.Errorf("jobs[%d].instances must be >= 0", idx))
@@ -102,6 +118,16 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 
 		errs = append(errs, v.validateJobNetworks(job.Networks, deploymentManifest.Networks, idx)...)
 
+		if len(job.AZs) > 1 {
+			errs = append(errs, bosherr.Errorf("jobs[%d].azs must not contain more than one az", idx))
+		}
+
+		for azIdx, az := range job.AZs {
+			if v.isBlank(az) {
+				errs = append(errs, bosherr.Errorf("jobs[%d].azs[%d] must be provided", idx, azIdx))
+			}
+		}
+
 		if job.Lifecycle != "" && job.Lifecycle != JobLifecycleService {
 			errs = append(errs, bosherr.Errorf("jobs[%d].lifecycle must be 'service' ('%s' not supported)", idx, job.Lifecycle))
 		}
@@ -127,6 +153,10 @@ func (v *validator) Validate(deploymentManifest Manifest, releaseSetManifest bir
 		}
 	}
 
+	if convergence := deploymentManifest.Update.Convergence; convergence != "" && !convergence.IsValid() {
+		errs = append(errs, bosherr.Errorf("update.convergence must be one of: %s", v.convergenceNames()))
+	}
+
 	if len(errs) > 0 {
 		return bosherr.NewMultiError(errs...)
 	}
@@ -158,10 +188,28 @@ func (v *validator) ValidateReleaseJobs(deploymentManifest Manifest, releaseMana
 	return nil
 }
 
+func (v *validator) convergenceNames() string {
+	names := []string{}
+	for _, convergence := range convergences {
+		names = append(names, string(convergence))
+	}
+	return strings.Join(names, ", ")
+}
+
 func (v *validator) isBlank(str string) bool {
 	return str == "" || strings.TrimSpace(str) == ""
 }
 
+func (v *validator) isValidDiskFormat(diskFormat string) bool {
+	for _, format := range DiskFormats {
+		if diskFormat == format {
+			return true
+		}
+	}
+
+	return false
+}
+
 func (v *validator) networkNames(deploymentManifest Manifest) map[string]struct{} {
 	names := make(map[string]struct{})
 	for _, network := range deploymentManifest.Networks {
@@ -372,3 +420,39 @@ func (v *validator) validateGateway(idx int, gateway string, ipNet maybeIPNet) [
 
 	return errors
 }
+
+func (v *validator) validateResourcePoolDisks(idx int, resourcePool ResourcePool) []error {
+	errs := []error{}
+
+	if !resourcePool.EphemeralDisk.IsEmpty() {
+		if resourcePool.EphemeralDisk.Size <= 0 {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].ephemeral_disk.size must be > 0", idx))
+		}
+		if _, ok := resourcePool.CloudProperties["ephemeral_disk"]; ok {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].cloud_properties.ephemeral_disk must not be set with resource_pools[%d].ephemeral_disk", idx, idx))
+		}
+	}
+
+	if len(resourcePool.RawDisks) > 0 {
+		if _, ok := resourcePool.CloudProperties["raw_disks"]; ok {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].cloud_properties.raw_disks must not be set with resource_pools[%d].raw_disks", idx, idx))
+		}
+	}
+
+	names := map[string]struct{}{}
+
+	for diskIdx, disk := range resourcePool.RawDisks {
+		if v.isBlank(disk.Name) {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].raw_disks[%d].name must be provided", idx, diskIdx))
+		} else if _, found := names[disk.Name]; found {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].raw_disks[%d].name '%s' must be unique", idx, diskIdx, disk.Name))
+		}
+		names[disk.Name] = struct{}{}
+
+		if disk.Size <= 0 {
+			errs = append(errs, bosherr.Errorf("resource_pools[%d].raw_disks[%d].size must be > 0", idx, diskIdx))
+		}
+	}
+
+	return errs
+}
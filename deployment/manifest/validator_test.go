@@ -174,6 +174,30 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("resource_pools[1].name must be provided"))
 		})
 
+		It("validates resource pool ephemeral and raw disks", func() {
+			deploymentManifest := Manifest{
+				ResourcePools: []ResourcePool{
+					{
+						CloudProperties: biproperty.Map{"ephemeral_disk": biproperty.Map{"size": 1024}},
+						EphemeralDisk:   EphemeralDisk{Path: "/dev/sdb"},
+						RawDisks: []RawDisk{
+							{Name: "fake-raw-disk", Size: 1024},
+							{Name: "fake-raw-disk"},
+							{Size: 1024},
+						},
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].ephemeral_disk.size must be > 0"))
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].cloud_properties.ephemeral_disk must not be set with resource_pools[0].ephemeral_disk"))
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].raw_disks[1].name 'fake-raw-disk' must be unique"))
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].raw_disks[1].size must be > 0"))
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].raw_disks[2].name must be provided"))
+		})
+
 		It("validates resource pool network", func() {
 			deploymentManifest := Manifest{
 				ResourcePools: []ResourcePool{
@@ -247,6 +271,52 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("resource_pools[0].stemcell.sha1 must be provided for http URL"))
 		})
 
+		It("validates resource pool stemcells referred to by name", func() {
+			deploymentManifest := Manifest{
+				ResourcePools: []ResourcePool{
+					{
+						Stemcell: StemcellRef{
+							Name: "fake-stemcell-name",
+						},
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].stemcell.version must be provided with stemcell.name"))
+			Expect(err.Error()).ToNot(ContainSubstring("stemcell.url"))
+
+			deploymentManifest.ResourcePools[0].Stemcell.Version = "fake-stemcell-version"
+
+			err = validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).ToNot(ContainSubstring("stemcell"))
+		})
+
+		It("validates resource pool stemcell disk format", func() {
+			deploymentManifest := Manifest{
+				ResourcePools: []ResourcePool{
+					{
+						Stemcell: StemcellRef{
+							URL:        "file://fake-stemcell",
+							DiskFormat: "vmdk",
+						},
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("resource_pools[0].stemcell.disk_format must be one of: qcow2, raw"))
+
+			deploymentManifest.ResourcePools[0].Stemcell.DiskFormat = "raw"
+
+			err = validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).ToNot(ContainSubstring("disk_format"))
+		})
+
 		It("validates disk pool name", func() {
 			deploymentManifest := Manifest{
 				DiskPools: []DiskPool{
@@ -572,6 +642,29 @@ var _ = Describe("Validator", func() {
 			})
 		})
 
+		It("validates that jobs are deployed to at most one az", func() {
+			deploymentManifest := Manifest{
+				Jobs: []Job{
+					{Name: "fake-job", AZs: []string{"z1", ""}},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].azs must not contain more than one az"))
+			Expect(err.Error()).To(ContainSubstring("jobs[0].azs[1] must be provided"))
+		})
+
+		It("validates that update convergence is known", func() {
+			deploymentManifest := Manifest{
+				Update: Update{Convergence: "fake-convergence"},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("update.convergence must be one of: running, agent, none"))
+		})
+
 		It("validates that there is only one job", func() {
 			deploymentManifest := Manifest{
 				Jobs: []Job{
@@ -647,6 +740,26 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disk_pool must be the name of a disk pool"))
 		})
 
+		It("validates job persistent_disk and persistent_disk_pool are not both provided", func() {
+			deploymentManifest := Manifest{
+				Jobs: []Job{
+					{
+						PersistentDisk:     1024,
+						PersistentDiskPool: "fake-disk-pool",
+					},
+				},
+				DiskPools: []DiskPool{
+					{
+						Name: "fake-disk-pool",
+					},
+				},
+			}
+
+			err := validator.Validate(deploymentManifest, validReleaseSetManifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("jobs[0].persistent_disk and jobs[0].persistent_disk_pool must not both be provided"))
+		})
+
 		It("validates job resource pool is provided", func() {
 			deploymentManifest := Manifest{
 				Jobs: []Job{{}},
@@ -8,6 +8,7 @@ import (
 	agentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	blobstore "github.com/cloudfoundry/bosh-cli/blobstore"
 	cloud "github.com/cloudfoundry/bosh-cli/cloud"
+	config "github.com/cloudfoundry/bosh-cli/config"
 	deployment "github.com/cloudfoundry/bosh-cli/deployment"
 	disk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	instance "github.com/cloudfoundry/bosh-cli/deployment/instance"
@@ -44,15 +45,15 @@ func (m *MockDeployment) EXPECT() *MockDeploymentMockRecorder {
 }
 
 // Delete mocks base method
-func (m *MockDeployment) Delete(arg0 bool, arg1 ui.Stage) error {
-	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+func (m *MockDeployment) Delete(arg0, arg1 bool, arg2 ui.Stage) error {
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete
-func (mr *MockDeploymentMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDeployment)(nil).Delete), arg0, arg1)
+func (mr *MockDeploymentMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockDeployment)(nil).Delete), arg0, arg1, arg2)
 }
 
 // MockFactory is a mock of Factory interface
@@ -126,6 +127,19 @@ func (mr *MockDeployerMockRecorder) Deploy(arg0, arg1, arg2, arg3, arg4, arg5, a
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deploy", reflect.TypeOf((*MockDeployer)(nil).Deploy), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7)
 }
 
+// Plan mocks base method
+func (m *MockDeployer) Plan(arg0 manifest.Manifest, arg1 config.DeploymentState, arg2 []stemcell.Manifest, arg3 bool) ([]deployment.PlannedCall, error) {
+	ret := m.ctrl.Call(m, "Plan", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]deployment.PlannedCall)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Plan indicates an expected call of Plan
+func (mr *MockDeployerMockRecorder) Plan(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Plan", reflect.TypeOf((*MockDeployer)(nil).Plan), arg0, arg1, arg2, arg3)
+}
+
 // MockManager is a mock of Manager interface
 type MockManager struct {
 	ctrl     *gomock.Controller
@@ -0,0 +1,208 @@
+package deployment
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// PlannedCall is a CPI call that Deploy would make. ManifestChange marks
+// deletions forced by a changed manifest: VMs recreated for network changes
+// and persistent disks migrated or removed.
+type PlannedCall struct {
+	Method         string
+	Target         string
+	Reason         string
+	ManifestChange bool
+}
+
+// Plan returns the CPI calls that deploying the manifest with the given
+// stemcells would make, in order, based only on the deployment state.
+// With recreatePersistentDisks the current disk is planned to be migrated
+// to a new one like --recreate-persistent-disks does.
+// Nothing is created and the CPI is not called, so VMs that no longer exist
+// in the IaaS or disks that were changed outside of the CLI are not noticed.
+func (d *deployer) Plan(
+	deploymentManifest bideplmanifest.Manifest,
+	deploymentState biconfig.DeploymentState,
+	stemcells []bistemcell.Manifest,
+	recreatePersistentDisks bool,
+) ([]PlannedCall, error) {
+	calls := []PlannedCall{}
+
+	keptStemcells := map[string]bool{}
+	for _, stemcell := range stemcells {
+		if stemcell.Name == bistemcell.ExistingStemcellName {
+			keptStemcells[stemcell.Version] = true
+			continue
+		}
+
+		record, found := d.findStemcellRecord(deploymentState, stemcell)
+		if found {
+			keptStemcells[record.CID] = true
+			continue
+		}
+
+		calls = append(calls, PlannedCall{
+			Method: "create_stemcell",
+			Target: fmt.Sprintf("%s/%s", stemcell.Name, stemcell.Version),
+			Reason: "stemcell is not uploaded yet",
+		})
+	}
+
+	if len(deploymentManifest.Jobs) != 1 {
+		return calls, bosherr.Errorf("There must only be one job, found %d", len(deploymentManifest.Jobs))
+	}
+
+	if deploymentState.CurrentVMCID != "" {
+		deleteVM, err := d.planDeleteVM(deploymentManifest, deploymentState)
+		if err != nil {
+			return calls, err
+		}
+		calls = append(calls, deleteVM)
+	}
+
+	for _, jobSpec := range deploymentManifest.Jobs {
+		if jobSpec.Instances != 1 {
+			return calls, bosherr.Errorf("Job '%s' must have only one instance, found %d", jobSpec.Name, jobSpec.Instances)
+		}
+
+		for instanceID := 0; instanceID < jobSpec.Instances; instanceID++ {
+			instanceName := fmt.Sprintf("%s/%d", jobSpec.Name, instanceID)
+
+			calls = append(calls, PlannedCall{Method: "create_vm", Target: instanceName})
+
+			diskCalls, err := d.planDisk(deploymentManifest, deploymentState, jobSpec.Name, instanceName, recreatePersistentDisks)
+			if err != nil {
+				return calls, err
+			}
+			calls = append(calls, diskCalls...)
+		}
+	}
+
+	for _, record := range deploymentState.Stemcells {
+		if record.CPI != deploymentState.CurrentCPI || record.Imported {
+			continue
+		}
+
+		if !keptStemcells[record.CID] {
+			calls = append(calls, PlannedCall{
+				Method: "delete_stemcell",
+				Target: record.CID,
+				Reason: "stemcell is no longer used",
+			})
+		}
+	}
+
+	return calls, nil
+}
+
+// planDeleteVM mirrors the deployer, which deletes the current VM on every
+// deploy and names the network changes that would require it anyway
+func (d *deployer) planDeleteVM(deploymentManifest bideplmanifest.Manifest, deploymentState biconfig.DeploymentState) (PlannedCall, error) {
+	call := PlannedCall{
+		Method: "delete_vm",
+		Target: deploymentState.CurrentVMCID,
+		Reason: "VMs are recreated on every deploy",
+	}
+
+	// VMs created before network cloud properties were recorded cannot be compared
+	if len(deploymentState.CurrentVMNetworkCloudProperties) == 0 {
+		return call, nil
+	}
+
+	networkInterfaces, err := deploymentManifest.NetworkInterfaces(deploymentManifest.JobName())
+	if err != nil {
+		return call, bosherr.WrapError(err, "Getting network spec")
+	}
+
+	changes := bivm.NetworkCloudPropertiesChanges(deploymentState.CurrentVMNetworkCloudProperties, bivm.NetworkCloudProperties(networkInterfaces))
+	if len(changes) > 0 {
+		call.Reason = fmt.Sprintf("network changes (%s)", strings.Join(changes, ", "))
+		call.ManifestChange = true
+	}
+
+	return call, nil
+}
+
+// planDisk mirrors the disk deployer: the current disk is attached again
+// unless its size or cloud properties changed or persistent disks are
+// recreated, which migrates the data to a new disk
+func (d *deployer) planDisk(
+	deploymentManifest bideplmanifest.Manifest,
+	deploymentState biconfig.DeploymentState,
+	jobName string,
+	instanceName string,
+	recreatePersistentDisks bool,
+) ([]PlannedCall, error) {
+	calls := []PlannedCall{}
+
+	diskPool, err := deploymentManifest.DiskPool(jobName)
+	if err != nil {
+		return calls, err
+	}
+
+	var currentDisk *biconfig.DiskRecord
+	for i, record := range deploymentState.Disks {
+		if record.ID == deploymentState.CurrentDiskID {
+			currentDisk = &deploymentState.Disks[i]
+		}
+	}
+
+	if diskPool.DiskSize == 0 {
+		if currentDisk != nil {
+			calls = append(calls, PlannedCall{Method: "delete_disk", Target: currentDisk.CID, Reason: "persistent disk was removed from the manifest", ManifestChange: true})
+		}
+		return calls, nil
+	}
+
+	if currentDisk == nil {
+		calls = append(calls,
+			PlannedCall{Method: "create_disk", Target: instanceName, Reason: fmt.Sprintf("%d MB", diskPool.DiskSize)},
+			PlannedCall{Method: "attach_disk", Target: instanceName},
+		)
+		return calls, nil
+	}
+
+	unchanged := currentDisk.Size == diskPool.DiskSize && reflect.DeepEqual(currentDisk.CloudProperties, diskPool.CloudProperties)
+	if unchanged && !recreatePersistentDisks {
+		calls = append(calls, PlannedCall{Method: "attach_disk", Target: currentDisk.CID, Reason: "existing persistent disk"})
+		return calls, nil
+	}
+
+	createDisk := PlannedCall{Method: "create_disk", Target: instanceName, Reason: fmt.Sprintf("migrating from %d MB to %d MB", currentDisk.Size, diskPool.DiskSize)}
+	deleteDisk := PlannedCall{Method: "delete_disk", Target: currentDisk.CID, Reason: "data was migrated to the new disk", ManifestChange: true}
+	if unchanged {
+		createDisk.Reason = fmt.Sprintf("recreating %d MB", diskPool.DiskSize)
+		deleteDisk.Reason = "persistent disks are recreated"
+		deleteDisk.ManifestChange = false
+	}
+
+	calls = append(calls,
+		PlannedCall{Method: "attach_disk", Target: currentDisk.CID, Reason: "existing persistent disk"},
+		createDisk,
+		PlannedCall{Method: "attach_disk", Target: instanceName},
+		PlannedCall{Method: "detach_disk", Target: currentDisk.CID},
+		deleteDisk,
+	)
+
+	return calls, nil
+}
+
+// findStemcellRecord matches stemcells uploaded with the current CPI by
+// name and version like the stemcell manager does
+func (d *deployer) findStemcellRecord(deploymentState biconfig.DeploymentState, stemcell bistemcell.Manifest) (biconfig.StemcellRecord, bool) {
+	for _, record := range deploymentState.Stemcells {
+		if record.CPI == deploymentState.CurrentCPI && record.Name == stemcell.Name && record.Version == stemcell.Version {
+			return record, true
+		}
+	}
+
+	return biconfig.StemcellRecord{}, false
+}
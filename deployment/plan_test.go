@@ -0,0 +1,156 @@
+package deployment_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	. "github.com/cloudfoundry/bosh-cli/deployment"
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+var _ = Describe("Deployer.Plan", func() {
+	var (
+		deployer           Deployer
+		deploymentManifest bideplmanifest.Manifest
+		deploymentState    biconfig.DeploymentState
+		stemcells          []bistemcell.Manifest
+	)
+
+	BeforeEach(func() {
+		deployer = NewDeployer(nil, nil, nil, boshlog.NewLogger(boshlog.LevelNone))
+
+		deploymentManifest = bideplmanifest.Manifest{
+			Name: "fake-deployment-name",
+			Jobs: []bideplmanifest.Job{
+				{Name: "fake-job-name", Instances: 1, PersistentDisk: 1024},
+			},
+		}
+
+		deploymentState = biconfig.DeploymentState{}
+		stemcells = []bistemcell.Manifest{{Name: "fake-stemcell-name", Version: "fake-stemcell-version"}}
+	})
+
+	It("plans uploading the stemcell and creating the VM and disk of a new deployment", func() {
+		calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(calls).To(Equal([]PlannedCall{
+			{Method: "create_stemcell", Target: "fake-stemcell-name/fake-stemcell-version", Reason: "stemcell is not uploaded yet"},
+			{Method: "create_vm", Target: "fake-job-name/0"},
+			{Method: "create_disk", Target: "fake-job-name/0", Reason: "1024 MB"},
+			{Method: "attach_disk", Target: "fake-job-name/0"},
+		}))
+	})
+
+	Context("when the deployment exists", func() {
+		BeforeEach(func() {
+			deploymentState = biconfig.DeploymentState{
+				CurrentVMCID:  "fake-vm-cid",
+				CurrentDiskID: "fake-disk-id",
+				Disks: []biconfig.DiskRecord{
+					{ID: "fake-disk-id", CID: "fake-disk-cid", Size: 1024, CloudProperties: biproperty.Map{}},
+				},
+				Stemcells: []biconfig.StemcellRecord{
+					{ID: "fake-stemcell-id", Name: "fake-stemcell-name", Version: "fake-stemcell-version", CID: "fake-stemcell-cid"},
+					{ID: "fake-old-stemcell-id", Name: "fake-stemcell-name", Version: "fake-old-version", CID: "fake-old-stemcell-cid"},
+				},
+			}
+		})
+
+		It("plans recreating the VM with the existing disk and deleting unused stemcells", func() {
+			calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal([]PlannedCall{
+				{Method: "delete_vm", Target: "fake-vm-cid", Reason: "VMs are recreated on every deploy"},
+				{Method: "create_vm", Target: "fake-job-name/0"},
+				{Method: "attach_disk", Target: "fake-disk-cid", Reason: "existing persistent disk"},
+				{Method: "delete_stemcell", Target: "fake-old-stemcell-cid", Reason: "stemcell is no longer used"},
+			}))
+		})
+
+		It("plans migrating the disk when its size changed", func() {
+			deploymentManifest.Jobs[0].PersistentDisk = 2048
+
+			calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(ContainElement(PlannedCall{Method: "create_disk", Target: "fake-job-name/0", Reason: "migrating from 1024 MB to 2048 MB"}))
+			Expect(calls).To(ContainElement(PlannedCall{Method: "delete_disk", Target: "fake-disk-cid", Reason: "data was migrated to the new disk", ManifestChange: true}))
+		})
+
+		It("plans migrating the unchanged disk when persistent disks are recreated", func() {
+			calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, true)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(Equal([]PlannedCall{
+				{Method: "delete_vm", Target: "fake-vm-cid", Reason: "VMs are recreated on every deploy"},
+				{Method: "create_vm", Target: "fake-job-name/0"},
+				{Method: "attach_disk", Target: "fake-disk-cid", Reason: "existing persistent disk"},
+				{Method: "create_disk", Target: "fake-job-name/0", Reason: "recreating 1024 MB"},
+				{Method: "attach_disk", Target: "fake-job-name/0"},
+				{Method: "detach_disk", Target: "fake-disk-cid"},
+				{Method: "delete_disk", Target: "fake-disk-cid", Reason: "persistent disks are recreated"},
+				{Method: "delete_stemcell", Target: "fake-old-stemcell-cid", Reason: "stemcell is no longer used"},
+			}))
+		})
+
+		It("marks deleting the disk removed from the manifest as forced by the manifest", func() {
+			deploymentManifest.Jobs[0].PersistentDisk = 0
+
+			calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(ContainElement(PlannedCall{Method: "delete_disk", Target: "fake-disk-cid", Reason: "persistent disk was removed from the manifest", ManifestChange: true}))
+		})
+
+		It("names the network changes that recreate the VM", func() {
+			deploymentManifest.Networks = []bideplmanifest.Network{
+				{Name: "fake-network-name", Type: bideplmanifest.Dynamic, CloudProperties: biproperty.Map{"subnet": "fake-subnet"}},
+			}
+			deploymentManifest.Jobs[0].Networks = []bideplmanifest.JobNetwork{{Name: "fake-network-name"}}
+			deploymentState.CurrentVMNetworkCloudProperties = map[string]biproperty.Map{
+				"fake-network-name": {"subnet": "fake-old-subnet"},
+			}
+
+			calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls[0]).To(Equal(PlannedCall{
+				Method:         "delete_vm",
+				Target:         "fake-vm-cid",
+				Reason:         "network changes (network 'fake-network-name' cloud_properties.subnet changed)",
+				ManifestChange: true,
+			}))
+		})
+
+		It("plans uploading stemcells again for another cpi and keeps the ones of the previous cpi", func() {
+			for i := range deploymentState.Stemcells {
+				deploymentState.Stemcells[i].CPI = "fake-old-cpi"
+			}
+			deploymentState.CurrentCPI = "fake-cpi"
+
+			calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(ContainElement(PlannedCall{Method: "create_stemcell", Target: "fake-stemcell-name/fake-stemcell-version", Reason: "stemcell is not uploaded yet"}))
+			for _, call := range calls {
+				Expect(call.Method).ToNot(Equal("delete_stemcell"))
+			}
+		})
+
+		It("keeps stemcells used with --stemcell-cid", func() {
+			stemcells = []bistemcell.Manifest{{Name: bistemcell.ExistingStemcellName, Version: "fake-old-stemcell-cid"}}
+
+			calls, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(calls).To(ContainElement(PlannedCall{Method: "delete_stemcell", Target: "fake-stemcell-cid", Reason: "stemcell is no longer used"}))
+			Expect(calls).ToNot(ContainElement(PlannedCall{Method: "delete_stemcell", Target: "fake-old-stemcell-cid", Reason: "stemcell is no longer used"}))
+		})
+	})
+
+	It("returns an error when there is more than one job", func() {
+		deploymentManifest.Jobs = append(deploymentManifest.Jobs, bideplmanifest.Job{Name: "other-job-name", Instances: 1})
+
+		_, err := deployer.Plan(deploymentManifest, deploymentState, stemcells, false)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("There must only be one job, found 2"))
+	})
+})
@@ -0,0 +1,186 @@
+package postdeploy
+
+import (
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+	"github.com/cloudfoundry/bosh-utils/crypto"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshhttp "github.com/cloudfoundry/bosh-utils/httpclient"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	proxy "github.com/cloudfoundry/socks5-proxy"
+)
+
+const (
+	defaultTimeout        = 300 * time.Second
+	defaultExpectedStatus = http.StatusOK
+	defaultScheme         = "http"
+	dialTimeout           = 5 * time.Second
+	maxDelay              = 10 * time.Second
+	delayMultiplier       = 2
+)
+
+type Clock interface {
+	Sleep(time.Duration)
+	Now() time.Time
+}
+
+//go:generate counterfeiter . Checker
+
+// Checker verifies that the endpoints listed in cloud_provider.post_deploy_checks
+// are serving on the deployed VM. https endpoints are verified with caCert,
+// cloud_provider.cert.ca, or the system CAs when it is empty.
+type Checker interface {
+	Check(checks []biinstallmanifest.PostDeployCheck, defaultHost string, caCert string, stage biui.Stage) error
+}
+
+type checker struct {
+	dial        boshhttp.DialFunc
+	timeService Clock
+	delay       time.Duration
+	logger      boshlog.Logger
+	logTag      string
+}
+
+// NewChecker makes tcp checks through BOSH_ALL_PROXY when it is set, like
+// the http checks made with a default http client
+func NewChecker(timeService Clock, delay time.Duration, logger boshlog.Logger) Checker {
+	dialer := &net.Dialer{Timeout: dialTimeout}
+	socks5Proxy := proxy.NewSocks5Proxy(proxy.NewHostKey(), nil)
+
+	return &checker{
+		dial:        boshhttp.SOCKS5DialFuncFromEnvironment(dialer.Dial, socks5Proxy),
+		timeService: timeService,
+		delay:       delay,
+		logger:      logger,
+		logTag:      "postDeployChecker",
+	}
+}
+
+func (c *checker) Check(checks []biinstallmanifest.PostDeployCheck, defaultHost string, caCert string, stage biui.Stage) error {
+	// a nil pool verifies with the system CAs
+	var caCertPool *x509.CertPool
+
+	if caCert != "" {
+		var err error
+		caCertPool, err = crypto.CertPoolFromPEM([]byte(caCert))
+		if err != nil {
+			return bosherr.WrapError(err, "Parsing post-deploy checks CA certificate")
+		}
+	}
+
+	httpClient := boshhttp.CreateDefaultClient(caCertPool)
+
+	for _, check := range checks {
+		host := check.Host
+		if host == "" {
+			host = defaultHost
+		}
+		if host == "" {
+			return bosherr.Errorf("Post-deploy %s check on port %d requires a host, but the instance has no static IP", check.Type, check.Port)
+		}
+
+		address := net.JoinHostPort(host, strconv.Itoa(check.Port))
+
+		var stepName string
+		var attempt func() error
+
+		switch check.Type {
+		case biinstallmanifest.PostDeployCheckTCP:
+			stepName = fmt.Sprintf("Waiting for tcp port '%s'", address)
+			attempt = func() error { return c.checkTCP(address) }
+		case biinstallmanifest.PostDeployCheckHTTP:
+			url := c.checkURL(check, address)
+			expectedStatus := check.ExpectedStatus
+			if expectedStatus == 0 {
+				expectedStatus = defaultExpectedStatus
+			}
+			client := httpClient
+			if check.InsecureSkipVerify {
+				client = boshhttp.CreateDefaultClientInsecureSkipVerify()
+			}
+			stepName = fmt.Sprintf("Waiting for http endpoint '%s'", url)
+			attempt = func() error { return c.checkHTTP(client, url, expectedStatus) }
+		default:
+			return bosherr.Errorf("Unsupported post-deploy check type '%s'", check.Type)
+		}
+
+		timeout := defaultTimeout
+		if check.Timeout > 0 {
+			timeout = time.Duration(check.Timeout) * time.Second
+		}
+
+		err := stage.Perform(stepName, func() error {
+			return c.waitFor(attempt, timeout)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *checker) waitFor(attempt func() error, timeout time.Duration) error {
+	endpointRetrier := biretrier.NewRetrier(biretrier.Options{
+		Timeout: timeout,
+		Backoff: biretrier.NewExponentialBackoff(c.delay, maxDelay, delayMultiplier),
+	}, c.timeService, c.logger)
+
+	err := endpointRetrier.Try(func() (bool, error) {
+		err := attempt()
+		return err != nil, err
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Endpoint not ready after %s", timeout)
+	}
+
+	return nil
+}
+
+func (c *checker) checkTCP(address string) error {
+	conn, err := c.dial("tcp", address)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Connecting to '%s'", address)
+	}
+
+	_ = conn.Close()
+
+	return nil
+}
+
+func (c *checker) checkHTTP(client *http.Client, url string, expectedStatus int) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Requesting '%s'", url)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != expectedStatus {
+		return bosherr.Errorf("Expected status %d from '%s' but got %d", expectedStatus, url, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (c *checker) checkURL(check biinstallmanifest.PostDeployCheck, address string) string {
+	scheme := check.Scheme
+	if scheme == "" {
+		scheme = defaultScheme
+	}
+
+	path := check.Path
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, address, path)
+}
@@ -0,0 +1,225 @@
+package postdeploy_test
+
+import (
+	"encoding/pem"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"code.cloudfoundry.org/clock"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-cli/deployment/postdeploy"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("Checker", func() {
+	var (
+		fakeStage *fakebiui.FakeStage
+		checker   Checker
+	)
+
+	BeforeEach(func() {
+		fakeStage = fakebiui.NewFakeStage()
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		checker = NewChecker(clock.NewClock(), 10*time.Millisecond, logger)
+	})
+
+	hostPort := func(address string) (string, int) {
+		host, portStr, err := net.SplitHostPort(address)
+		Expect(err).ToNot(HaveOccurred())
+		port, err := strconv.Atoi(portStr)
+		Expect(err).ToNot(HaveOccurred())
+		return host, port
+	}
+
+	Context("tcp checks", func() {
+		var listener net.Listener
+
+		BeforeEach(func() {
+			var err error
+			listener, err = net.Listen("tcp", "127.0.0.1:0")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			listener.Close()
+		})
+
+		It("succeeds when the port accepts connections, using the default host", func() {
+			_, port := hostPort(listener.Addr().String())
+
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "tcp", Port: port},
+			}, "127.0.0.1", "", fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+				{Name: "Waiting for tcp port '127.0.0.1:" + strconv.Itoa(port) + "'"},
+			}))
+		})
+
+		It("fails when the port does not accept connections before the timeout", func() {
+			_, port := hostPort(listener.Addr().String())
+			listener.Close()
+
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "tcp", Host: "127.0.0.1", Port: port, Timeout: 1},
+			}, "", "", fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Endpoint not ready after 1s"))
+			Expect(err.Error()).To(ContainSubstring("Giving up after"))
+			Expect(err.Error()).To(ContainSubstring("Connecting to '127.0.0.1:" + strconv.Itoa(port) + "'"))
+			Expect(fakeStage.PerformCalls[0].Error).To(Equal(err))
+		})
+
+		Context("when BOSH_ALL_PROXY is set", func() {
+			var proxyPort int
+
+			BeforeEach(func() {
+				proxyListener, err := net.Listen("tcp", "127.0.0.1:0")
+				Expect(err).ToNot(HaveOccurred())
+				_, proxyPort = hostPort(proxyListener.Addr().String())
+				proxyListener.Close()
+
+				os.Setenv("BOSH_ALL_PROXY", fmt.Sprintf("socks5://127.0.0.1:%d", proxyPort))
+				checker = NewChecker(clock.NewClock(), 10*time.Millisecond, boshlog.NewLogger(boshlog.LevelNone))
+			})
+
+			AfterEach(func() {
+				os.Unsetenv("BOSH_ALL_PROXY")
+			})
+
+			It("connects through the proxy", func() {
+				_, port := hostPort(listener.Addr().String())
+
+				err := checker.Check([]biinstallmanifest.PostDeployCheck{
+					{Type: "tcp", Host: "127.0.0.1", Port: port, Timeout: 1},
+				}, "", "", fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring(fmt.Sprintf("127.0.0.1:%d", proxyPort)))
+			})
+		})
+	})
+
+	Context("http checks", func() {
+		var (
+			server     *httptest.Server
+			statusCode int
+			paths      []string
+		)
+
+		BeforeEach(func() {
+			statusCode = http.StatusOK
+			paths = []string{}
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				w.WriteHeader(statusCode)
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("succeeds when the endpoint returns the expected status", func() {
+			host, port := hostPort(server.Listener.Addr().String())
+			statusCode = http.StatusNoContent
+
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "http", Port: port, Path: "info", ExpectedStatus: http.StatusNoContent},
+			}, host, "", fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(paths).To(Equal([]string{"/info"}))
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+				{Name: "Waiting for http endpoint 'http://" + server.Listener.Addr().String() + "/info'"},
+			}))
+		})
+
+		It("fails when the endpoint keeps returning an unexpected status", func() {
+			host, port := hostPort(server.Listener.Addr().String())
+			statusCode = http.StatusServiceUnavailable
+
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "http", Host: host, Port: port, Timeout: 1},
+			}, "", "", fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Expected status 200"))
+			Expect(err.Error()).To(ContainSubstring("but got 503"))
+		})
+	})
+
+	Context("https checks", func() {
+		var (
+			server *httptest.Server
+			caCert string
+		)
+
+		BeforeEach(func() {
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			caCert = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("verifies the certificate of the endpoint with the CA", func() {
+			host, port := hostPort(server.Listener.Addr().String())
+
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "http", Scheme: "https", Port: port},
+			}, host, caCert, fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("fails when the certificate of the endpoint is not signed by the CA", func() {
+			host, port := hostPort(server.Listener.Addr().String())
+
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "http", Scheme: "https", Port: port, Timeout: 1},
+			}, host, "", fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("certificate"))
+		})
+
+		It("skips verifying the certificate only for checks asking for it", func() {
+			host, port := hostPort(server.Listener.Addr().String())
+
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "http", Scheme: "https", Port: port, InsecureSkipVerify: true},
+			}, host, "", fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns an error when the CA cannot be parsed", func() {
+			err := checker.Check([]biinstallmanifest.PostDeployCheck{
+				{Type: "http", Scheme: "https", Port: 443},
+			}, "127.0.0.1", "fake-ca-cert", fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Parsing post-deploy checks CA certificate"))
+			Expect(fakeStage.PerformCalls).To(BeEmpty())
+		})
+	})
+
+	It("returns an error when no host is available", func() {
+		err := checker.Check([]biinstallmanifest.PostDeployCheck{
+			{Type: "tcp", Port: 22},
+		}, "", "", fakeStage)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("requires a host"))
+		Expect(fakeStage.PerformCalls).To(BeEmpty())
+	})
+})
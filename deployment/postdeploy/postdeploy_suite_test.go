@@ -0,0 +1,13 @@
+package postdeploy_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestPostDeploy(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Post Deploy Suite")
+}
@@ -8,7 +8,7 @@ import (
 )
 
 type Record interface {
-	IsDeployed(manifestSHA string, releases []birel.Release, stemcell bistemcell.ExtractedStemcell) (bool, error)
+	IsDeployed(manifestSHA string, releases []birel.Release, stemcell bistemcell.Manifest) (bool, error)
 	Clear() error
 	Update(manifestSHA string, releases []birel.Release) error
 }
@@ -31,7 +31,7 @@ func NewRecord(
 	}
 }
 
-func (v *deploymentRecord) IsDeployed(newManifestSHA string, releases []birel.Release, stemcell bistemcell.ExtractedStemcell) (bool, error) {
+func (v *deploymentRecord) IsDeployed(newManifestSHA string, releases []birel.Release, stemcell bistemcell.Manifest) (bool, error) {
 	deployedManifestSHA, found, err := v.deploymentRepo.FindCurrent()
 	if err != nil {
 		return false, bosherr.WrapError(err, "Finding sha of currently deployed manifest")
@@ -54,7 +54,7 @@ func (v *deploymentRecord) IsDeployed(newManifestSHA string, releases []birel.Re
 		return false, nil
 	}
 
-	if currentStemcell.Name != stemcell.Manifest().Name || currentStemcell.Version != stemcell.Manifest().Version {
+	if currentStemcell.Name != stemcell.Name || currentStemcell.Version != stemcell.Version {
 		return false, nil
 	}
 
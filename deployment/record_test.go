@@ -68,7 +68,7 @@ var _ = Describe("Record", func() {
 				})
 
 				It("returns false", func() {
-					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 					Expect(err).ToNot(HaveOccurred())
 					Expect(isDeployed).To(BeFalse())
 				})
@@ -85,7 +85,7 @@ var _ = Describe("Record", func() {
 				})
 
 				It("returns true", func() {
-					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 					Expect(err).ToNot(HaveOccurred())
 					Expect(isDeployed).To(BeTrue())
 				})
@@ -103,7 +103,7 @@ var _ = Describe("Record", func() {
 				})
 
 				It("returns false", func() {
-					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 					Expect(err).ToNot(HaveOccurred())
 					Expect(isDeployed).To(BeFalse())
 				})
@@ -121,7 +121,7 @@ var _ = Describe("Record", func() {
 				})
 
 				It("returns false", func() {
-					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+					isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 					Expect(err).ToNot(HaveOccurred())
 					Expect(isDeployed).To(BeFalse())
 				})
@@ -160,7 +160,7 @@ var _ = Describe("Record", func() {
 						})
 
 						It("returns true", func() {
-							isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+							isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 							Expect(err).ToNot(HaveOccurred())
 							Expect(isDeployed).To(BeTrue())
 						})
@@ -175,7 +175,7 @@ var _ = Describe("Record", func() {
 						})
 
 						It("returns true", func() {
-							isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+							isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 							Expect(err).ToNot(HaveOccurred())
 							Expect(isDeployed).To(BeTrue())
 						})
@@ -189,7 +189,7 @@ var _ = Describe("Record", func() {
 						})
 
 						It("returns false", func() {
-							isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+							isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 							Expect(err).ToNot(HaveOccurred())
 							Expect(isDeployed).To(BeFalse())
 						})
@@ -204,7 +204,7 @@ var _ = Describe("Record", func() {
 			})
 
 			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 				Expect(err).ToNot(HaveOccurred())
 				Expect(isDeployed).To(BeFalse())
 			})
@@ -216,7 +216,7 @@ var _ = Describe("Record", func() {
 			})
 
 			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 				Expect(err).ToNot(HaveOccurred())
 				Expect(isDeployed).To(BeFalse())
 			})
@@ -228,7 +228,7 @@ var _ = Describe("Record", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+				_, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-find-error"))
 			})
@@ -240,7 +240,7 @@ var _ = Describe("Record", func() {
 			})
 
 			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 				Expect(err).ToNot(HaveOccurred())
 				Expect(isDeployed).To(BeFalse())
 			})
@@ -258,7 +258,7 @@ var _ = Describe("Record", func() {
 			})
 
 			It("returns false", func() {
-				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+				isDeployed, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 				Expect(err).ToNot(HaveOccurred())
 				Expect(isDeployed).To(BeFalse())
 			})
@@ -270,7 +270,7 @@ var _ = Describe("Record", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell)
+				_, err := deploymentRecord.IsDeployed("fake-manifest-sha1", releases, stemcell.Manifest())
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-find-error"))
 			})
@@ -4,13 +4,23 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strconv"
+	"sync"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
 )
 
+const (
+	defaultKeepAliveInterval = 30 * time.Second
+	defaultReconnectAttempts = 5
+	defaultReconnectDelay    = 5 * time.Second
+)
+
 type SSHTunnel interface {
 	Start(chan<- error, chan<- error)
 }
@@ -18,84 +28,175 @@ type SSHTunnel interface {
 type sshTunnel struct {
 	client boshssh.Client
 
+	localForwardHost  string
 	localForwardPort  int
 	remoteForwardPort int
+	keepAliveInterval time.Duration
+	reconnectAttempts int
+	reconnectDelay    time.Duration
 
-	remoteListener net.Listener
-
-	logTag string
-	logger boshlog.Logger
+	timeService biretrier.Clock
+	logTag      string
+	logger      boshlog.Logger
 }
 
+// Start connects and reports on readyErrCh whether the tunnel is up. It then
+// forwards connections until the tunnel cannot be reconnected. A connection
+// that is closed, or that does not reply to keepalives, is reconnected and
+// reported on errCh. Reconnecting gives up after reconnectAttempts attempts.
 func (s *sshTunnel) Start(readyErrCh chan<- error, errCh chan<- error) {
-	err := s.client.Start()
+	remoteListener, err := s.connect()
 	if err != nil {
-		readyErrCh <- bosherr.WrapError(err, "Starting SSH tunnel")
+		readyErrCh <- err
 		return
 	}
 
+	readyErrCh <- nil
+
+	for {
+		err = s.forward(remoteListener, errCh)
+		errCh <- bosherr.WrapError(err, "SSH tunnel disconnected, reconnecting")
+
+		remoteListener, err = s.reconnect()
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Reconnecting SSH tunnel")
+			return
+		}
+	}
+}
+
+func (s *sshTunnel) reconnect() (net.Listener, error) {
+	var remoteListener net.Listener
+
+	retrier := biretrier.NewRetrier(biretrier.Options{
+		MaxAttempts: s.reconnectAttempts,
+		Backoff:     biretrier.NewConstantBackoff(s.reconnectDelay),
+	}, s.timeService, s.logger)
+
+	err := retrier.Try(func() (bool, error) {
+		var err error
+		remoteListener, err = s.connect()
+		return err != nil, err
+	})
+
+	return remoteListener, err
+}
+
+func (s *sshTunnel) connect() (net.Listener, error) {
+	err := s.client.Start()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Starting SSH tunnel")
+	}
+
 	remoteListenAddr := fmt.Sprintf("127.0.0.1:%d", s.remoteForwardPort)
 	s.logger.Debug(s.logTag, "Listening on remote server %s", remoteListenAddr)
-	s.remoteListener, err = s.client.Listen("tcp", remoteListenAddr)
+
+	remoteListener, err := s.client.Listen("tcp", remoteListenAddr)
 	if err != nil {
-		readyErrCh <- bosherr.WrapError(err, "Listening on remote server")
-		return
+		return nil, bosherr.WrapError(err, "Listening on remote server")
 	}
 
-	readyErrCh <- nil
+	return remoteListener, nil
+}
 
-	for {
-		remoteConn, err := s.remoteListener.Accept()
-		s.logger.Debug(s.logTag, "Received connection")
+// forward accepts connections on the remote listener until it fails and
+// stops the client
+func (s *sshTunnel) forward(remoteListener net.Listener, errCh chan<- error) error {
+	done := make(chan struct{})
+	keepAliveWG := &sync.WaitGroup{}
+
+	keepAliveWG.Add(1)
+	go func() {
+		defer keepAliveWG.Done()
+		s.keepAlive(remoteListener, done)
+	}()
+
+	defer func() {
+		close(done)
+
+		// stopping the client interrupts a keepalive waiting for a reply
+		err := s.client.Stop()
 		if err != nil {
-			errCh <- bosherr.WrapError(err, "Accepting connection on remote server")
-			return
+			s.logger.Debug(s.logTag, "Failed to stop disconnected SSH client: %s", err.Error())
 		}
 
-		defer func() {
-			if err = remoteConn.Close(); err != nil {
-				s.logger.Warn(s.logTag, "Failed to close remote listener connection: %s", err.Error())
-			}
-		}()
+		keepAliveWG.Wait()
+	}()
 
-		s.logger.Debug(s.logTag, "Dialing local server")
-		localDialAddr := fmt.Sprintf("127.0.0.1:%d", s.localForwardPort)
-		localConn, err := net.Dial("tcp", localDialAddr)
+	for {
+		remoteConn, err := remoteListener.Accept()
 		if err != nil {
-			errCh <- bosherr.WrapError(err, "Dialing local server")
-			return
+			return bosherr.WrapError(err, "Accepting connection on remote server")
 		}
 
-		go func(localConn, remoteConn net.Conn) {
-			defer func() {
-				if err = localConn.Close(); err != nil {
-					s.logger.Warn(s.logTag, "Failed to close local dial connection: %s", err.Error())
-				}
-			}()
+		s.logger.Debug(s.logTag, "Received connection")
+
+		go s.proxy(remoteConn, errCh)
+	}
+}
+
+// keepAlive closes the remote listener when the server stops replying so
+// that the tunnel is reconnected
+func (s *sshTunnel) keepAlive(remoteListener net.Listener, done <-chan struct{}) {
+	ticker := time.NewTicker(s.keepAliveInterval)
+	defer ticker.Stop()
 
-			bytesNum, err := io.Copy(remoteConn, localConn)
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
 
-			s.logger.Debug(s.logTag, "Copying bytes from local to remote %d", bytesNum)
+		err := s.client.KeepAlive()
+		if err != nil {
+			s.logger.Warn(s.logTag, "SSH tunnel keepalive failed: %s", err.Error())
 
+			err = remoteListener.Close()
 			if err != nil {
-				errCh <- bosherr.WrapError(err, "Copying bytes from local to remote")
+				s.logger.Debug(s.logTag, "Failed to close remote listener: %s", err.Error())
 			}
-		}(localConn, remoteConn)
+			return
+		}
+	}
+}
 
-		go func(localConn, remoteConn net.Conn) {
-			defer func() {
-				if err = localConn.Close(); err != nil {
-					s.logger.Warn(s.logTag, "Failed to close local dial connection: %s", err.Error())
-				}
-			}()
+// proxy copies between a remote connection and a connection to the local
+// server, each connection is closed once its copy is done
+func (s *sshTunnel) proxy(remoteConn net.Conn, errCh chan<- error) {
+	s.logger.Debug(s.logTag, "Dialing local server")
+	localDialAddr := net.JoinHostPort(s.localForwardHost, strconv.Itoa(s.localForwardPort))
+	localConn, err := net.Dial("tcp", localDialAddr)
+	if err != nil {
+		s.closeConn(remoteConn, "remote listener")
+		errCh <- bosherr.WrapError(err, "Dialing local server")
+		return
+	}
 
-			bytesNum, err := io.Copy(localConn, remoteConn)
+	go func() {
+		defer s.closeConn(remoteConn, "remote listener")
 
-			s.logger.Debug(s.logTag, "Copying bytes from remote to local %d", bytesNum)
+		bytesNum, err := io.Copy(remoteConn, localConn)
+		s.logger.Debug(s.logTag, "Copying bytes from local to remote %d", bytesNum)
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Copying bytes from local to remote")
+		}
+	}()
 
-			if err != nil {
-				errCh <- bosherr.WrapError(err, "Copying bytes from remote to local")
-			}
-		}(localConn, remoteConn)
+	go func() {
+		defer s.closeConn(localConn, "local dial")
+
+		bytesNum, err := io.Copy(localConn, remoteConn)
+		s.logger.Debug(s.logTag, "Copying bytes from remote to local %d", bytesNum)
+		if err != nil {
+			errCh <- bosherr.WrapError(err, "Copying bytes from remote to local")
+		}
+	}()
+}
+
+func (s *sshTunnel) closeConn(conn net.Conn, description string) {
+	err := conn.Close()
+	if err != nil {
+		s.logger.Debug(s.logTag, "Failed to close %s connection: %s", description, err.Error())
 	}
 }
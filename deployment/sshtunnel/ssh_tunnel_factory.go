@@ -1,6 +1,9 @@
 package sshtunnel
 
 import (
+	"time"
+
+	"code.cloudfoundry.org/clock"
 	boshssh "github.com/cloudfoundry/bosh-cli/ssh"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
@@ -13,8 +16,20 @@ type Options struct {
 	Password   string
 	PrivateKey string
 
+	// LocalForwardHost is dialed for connections to the remote port,
+	// 127.0.0.1 when empty
+	LocalForwardHost  string
 	LocalForwardPort  int
 	RemoteForwardPort int
+
+	// KeepAliveInterval is how often the connection is checked,
+	// 30 seconds when zero
+	KeepAliveInterval time.Duration
+
+	// ReconnectAttempts and ReconnectDelay bound reconnecting a dropped
+	// connection, 5 attempts 5 seconds apart when zero
+	ReconnectAttempts int
+	ReconnectDelay    time.Duration
 }
 
 func (o Options) IsEmpty() bool {
@@ -45,15 +60,44 @@ func (f *factory) NewSSHTunnel(opts Options) SSHTunnel {
 		PrivateKey: opts.PrivateKey,
 	}
 
-	tunnel := &sshTunnel{
-		client: clientFactory.New(clientOpts),
+	return NewSSHTunnelWithClient(clientFactory.New(clientOpts), opts, f.logger)
+}
+
+// NewSSHTunnelWithClient creates a tunnel that connects with client instead
+// of the SSH client for the host and credentials of opts
+func NewSSHTunnelWithClient(client boshssh.Client, opts Options, logger boshlog.Logger) SSHTunnel {
+	localForwardHost := opts.LocalForwardHost
+	if localForwardHost == "" {
+		localForwardHost = "127.0.0.1"
+	}
+
+	keepAliveInterval := opts.KeepAliveInterval
+	if keepAliveInterval == 0 {
+		keepAliveInterval = defaultKeepAliveInterval
+	}
+
+	reconnectAttempts := opts.ReconnectAttempts
+	if reconnectAttempts == 0 {
+		reconnectAttempts = defaultReconnectAttempts
+	}
 
+	reconnectDelay := opts.ReconnectDelay
+	if reconnectDelay == 0 {
+		reconnectDelay = defaultReconnectDelay
+	}
+
+	return &sshTunnel{
+		client: client,
+
+		localForwardHost:  localForwardHost,
 		localForwardPort:  opts.LocalForwardPort,
 		remoteForwardPort: opts.RemoteForwardPort,
+		keepAliveInterval: keepAliveInterval,
+		reconnectAttempts: reconnectAttempts,
+		reconnectDelay:    reconnectDelay,
 
-		logTag: "sshTunnel",
-		logger: f.logger,
+		timeService: clock.NewClock(),
+		logTag:      "sshTunnel",
+		logger:      logger,
 	}
-
-	return tunnel
 }
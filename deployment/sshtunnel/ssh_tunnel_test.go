@@ -0,0 +1,200 @@
+package sshtunnel_test
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
+)
+
+type fakeClient struct {
+	lock sync.Mutex
+
+	startErrs    []error
+	keepAliveErr error
+
+	startCount int
+	listeners  []net.Listener
+}
+
+func (c *fakeClient) Start() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.startCount++
+	if len(c.startErrs) >= c.startCount {
+		return c.startErrs[c.startCount-1]
+	}
+	return nil
+}
+
+func (c *fakeClient) Stop() error { return nil }
+
+func (c *fakeClient) Dial(n, addr string) (net.Conn, error) { return net.Dial(n, addr) }
+
+func (c *fakeClient) Listen(n, _ string) (net.Listener, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	listener, err := net.Listen(n, "127.0.0.1:0")
+	if err == nil {
+		c.listeners = append(c.listeners, listener)
+	}
+	return listener, err
+}
+
+func (c *fakeClient) KeepAlive() error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	err := c.keepAliveErr
+	c.keepAliveErr = nil
+	return err
+}
+
+func (c *fakeClient) StartCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.startCount
+}
+
+func (c *fakeClient) LastListenerAddr() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.listeners[len(c.listeners)-1].Addr().String()
+}
+
+var _ = Describe("SSHTunnel", func() {
+	var (
+		client      *fakeClient
+		localServer net.Listener
+		tunnel      SSHTunnel
+		readyErrCh  chan error
+		errCh       chan error
+	)
+
+	BeforeEach(func() {
+		client = &fakeClient{}
+
+		var err error
+		localServer, err = net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).ToNot(HaveOccurred())
+
+		go func(localServer net.Listener) {
+			for {
+				conn, err := localServer.Accept()
+				if err != nil {
+					return
+				}
+				go func() {
+					defer conn.Close()
+					line, _ := bufio.NewReader(conn).ReadString('\n')
+					_, _ = conn.Write([]byte("echo: " + line))
+				}()
+			}
+		}(localServer)
+
+		readyErrCh = make(chan error, 1)
+		errCh = make(chan error, 10)
+	})
+
+	AfterEach(func() {
+		localServer.Close()
+	})
+
+	start := func(keepAliveInterval time.Duration) {
+		tunnel = NewSSHTunnelWithClient(client, Options{
+			LocalForwardPort:  localServer.Addr().(*net.TCPAddr).Port,
+			RemoteForwardPort: 6901,
+			KeepAliveInterval: keepAliveInterval,
+			ReconnectAttempts: 3,
+			ReconnectDelay:    time.Millisecond,
+		}, boshlog.NewLogger(boshlog.LevelNone))
+
+		go tunnel.Start(readyErrCh, errCh)
+	}
+
+	request := func(addr string) (string, error) {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+
+		_, err = conn.Write([]byte("fake-request\n"))
+		if err != nil {
+			return "", err
+		}
+
+		return bufio.NewReader(conn).ReadString('\n')
+	}
+
+	It("forwards connections on the remote server to the local server", func() {
+		start(time.Minute)
+		Expect(<-readyErrCh).ToNot(HaveOccurred())
+
+		Expect(request(client.LastListenerAddr())).To(Equal("echo: fake-request\n"))
+	})
+
+	It("reports an error when the tunnel cannot be started", func() {
+		client.startErrs = []error{errors.New("fake-start-err")}
+
+		start(time.Minute)
+
+		err := <-readyErrCh
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("fake-start-err"))
+	})
+
+	It("reconnects when a keepalive fails", func() {
+		client.keepAliveErr = errors.New("fake-keepalive-err")
+
+		start(10 * time.Millisecond)
+		Expect(<-readyErrCh).ToNot(HaveOccurred())
+
+		var err error
+		Eventually(errCh).Should(Receive(&err))
+		Expect(err.Error()).To(ContainSubstring("SSH tunnel disconnected, reconnecting"))
+
+		Eventually(client.StartCount).Should(Equal(2))
+		Eventually(func() (string, error) { return request(client.LastListenerAddr()) }).Should(Equal("echo: fake-request\n"))
+	})
+
+	It("retries reconnecting after a failed attempt", func() {
+		client.startErrs = []error{nil, errors.New("fake-reconnect-err")}
+		client.keepAliveErr = errors.New("fake-keepalive-err")
+
+		start(10 * time.Millisecond)
+		Expect(<-readyErrCh).ToNot(HaveOccurred())
+
+		Eventually(client.StartCount).Should(Equal(3))
+		Eventually(func() (string, error) { return request(client.LastListenerAddr()) }).Should(Equal("echo: fake-request\n"))
+	})
+
+	It("stops when the tunnel cannot be reconnected within the attempts", func() {
+		reconnectErr := errors.New("fake-reconnect-err")
+		client.startErrs = []error{nil, reconnectErr, reconnectErr, reconnectErr}
+		client.keepAliveErr = errors.New("fake-keepalive-err")
+
+		start(10 * time.Millisecond)
+		Expect(<-readyErrCh).ToNot(HaveOccurred())
+
+		var err error
+		Eventually(errCh).Should(Receive(&err))
+		Expect(err.Error()).To(ContainSubstring("SSH tunnel disconnected, reconnecting"))
+
+		Eventually(errCh).Should(Receive(&err))
+		Expect(err.Error()).To(ContainSubstring("Reconnecting SSH tunnel: Giving up after 3 attempts"))
+		Expect(err.Error()).To(ContainSubstring("fake-reconnect-err"))
+		Expect(client.StartCount()).To(Equal(4))
+	})
+})
@@ -0,0 +1,12 @@
+package sshtunnel_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+)
+
+func TestSSHTunnel(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "SSH Tunnel Suite")
+}
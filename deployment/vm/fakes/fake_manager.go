@@ -16,6 +16,10 @@ type FakeManager struct {
 	CreateVM    bivm.VM
 	CreateErr   error
 
+	NetworkChangesManifest bideplmanifest.Manifest
+	NetworkChangesChanges  []string
+	NetworkChangesErr      error
+
 	findCurrentBehaviour findCurrentOutput
 }
 
@@ -50,3 +54,8 @@ func (m *FakeManager) SetFindCurrentBehavior(vm bivm.VM, found bool, err error)
 		err:   err,
 	}
 }
+
+func (m *FakeManager) NetworkChanges(deploymentManifest bideplmanifest.Manifest) ([]string, error) {
+	m.NetworkChangesManifest = deploymentManifest
+	return m.NetworkChangesChanges, m.NetworkChangesErr
+}
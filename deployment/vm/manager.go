@@ -7,6 +7,7 @@ import (
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	bistemcell "github.com/cloudfoundry/bosh-cli/stemcell"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -19,6 +20,7 @@ import (
 type Manager interface {
 	FindCurrent() (VM, bool, error)
 	Create(bistemcell.CloudStemcell, bideplmanifest.Manifest) (VM, error)
+	NetworkChanges(bideplmanifest.Manifest) ([]string, error)
 }
 
 type manager struct {
@@ -33,6 +35,8 @@ type manager struct {
 	logger             boshlog.Logger
 	logTag             string
 	timeService        Clock
+	userData           UserDataConfig
+	attemptReporter    biretrier.AttemptReporter
 }
 
 func NewManager(
@@ -45,6 +49,22 @@ func NewManager(
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
 	timeService Clock,
+) Manager {
+	return NewManagerWithUserData(vmRepo, stemcellRepo, diskDeployer, agentClient, cloud, uuidGenerator, fs, logger, timeService, UserDataConfig{}, nil)
+}
+
+func NewManagerWithUserData(
+	vmRepo biconfig.VMRepo,
+	stemcellRepo biconfig.StemcellRepo,
+	diskDeployer DiskDeployer,
+	agentClient biagentclient.AgentClient,
+	cloud bicloud.Cloud,
+	uuidGenerator boshuuid.Generator,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+	timeService Clock,
+	userData UserDataConfig,
+	attemptReporter biretrier.AttemptReporter,
 ) Manager {
 	return &manager{
 		cloud:         cloud,
@@ -57,6 +77,9 @@ func NewManager(
 		logger:        logger,
 		logTag:        "vmManager",
 		timeService:   timeService,
+		userData:      userData,
+
+		attemptReporter: attemptReporter,
 	}
 }
 
@@ -79,12 +102,43 @@ func (m *manager) FindCurrent() (VM, bool, error) {
 		m.cloud,
 		clock.NewClock(),
 		m.fs,
+		m.attemptReporter,
 		m.logger,
 	)
 
 	return vm, true, err
 }
 
+// NetworkChanges lists network cloud_properties changes that require the
+// current VM to be recreated. It is empty when there is no current VM.
+func (m *manager) NetworkChanges(deploymentManifest bideplmanifest.Manifest) ([]string, error) {
+	_, found, err := m.vmRepo.FindCurrent()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding currently deployed vm")
+	}
+
+	if !found {
+		return []string{}, nil
+	}
+
+	previous, err := m.vmRepo.FindCurrentNetworkCloudProperties()
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Finding currently deployed vm networks")
+	}
+
+	// VMs created before network cloud properties were recorded cannot be compared
+	if len(previous) == 0 {
+		return []string{}, nil
+	}
+
+	networkInterfaces, err := deploymentManifest.NetworkInterfaces(deploymentManifest.JobName())
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Getting network spec")
+	}
+
+	return NetworkCloudPropertiesChanges(previous, NetworkCloudProperties(networkInterfaces)), nil
+}
+
 func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest bideplmanifest.Manifest) (VM, error) {
 	jobName := deploymentManifest.JobName()
 	networkInterfaces, err := deploymentManifest.NetworkInterfaces(jobName)
@@ -140,6 +194,7 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 		m.cloud,
 		clock.NewClock(),
 		m.fs,
+		m.attemptReporter,
 		m.logger,
 		metadata,
 	)
@@ -148,7 +203,12 @@ func (m *manager) Create(stemcell bistemcell.CloudStemcell, deploymentManifest b
 }
 
 func (m *manager) createAndRecordVM(agentID string, stemcell bistemcell.CloudStemcell, resourcePool bideplmanifest.ResourcePool, networkInterfaces map[string]biproperty.Map) (string, error) {
-	cid, err := m.cloud.CreateVM(agentID, stemcell.CID(), resourcePool.CloudProperties, networkInterfaces, resourcePool.Env)
+	env, err := m.createVMEnv(agentID, networkInterfaces, resourcePool)
+	if err != nil {
+		return "", err
+	}
+
+	cid, err := m.cloud.CreateVM(agentID, stemcell.CID(), resourcePool.VMCloudProperties(), networkInterfaces, env)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Creating vm with stemcell cid '%s'", stemcell.CID())
 	}
@@ -159,5 +219,31 @@ func (m *manager) createAndRecordVM(agentID string, stemcell bistemcell.CloudSte
 		return "", bosherr.WrapError(err, "Updating current vm record")
 	}
 
+	err = m.vmRepo.UpdateCurrentNetworkCloudProperties(NetworkCloudProperties(networkInterfaces))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Updating current vm network record")
+	}
+
 	return cid, nil
 }
+
+func (m *manager) createVMEnv(agentID string, networkInterfaces map[string]biproperty.Map, resourcePool bideplmanifest.ResourcePool) (biproperty.Map, error) {
+	env := resourcePool.Env
+
+	if m.userData.IsEmpty() {
+		return env, nil
+	}
+
+	userData, err := m.userData.Encode(agentID, networkInterfaces, resourcePool)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building VM user data")
+	}
+
+	userDataEnv := biproperty.Map{}
+	for key, value := range env {
+		userDataEnv[key] = value
+	}
+	userDataEnv[UserDataEnvKey] = userData
+
+	return userDataEnv, nil
+}
@@ -5,6 +5,7 @@ import (
 	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
@@ -12,6 +13,7 @@ import (
 
 type ManagerFactory interface {
 	NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient) Manager
+	NewManagerWithUserData(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, userData UserDataConfig) Manager
 }
 
 type managerFactory struct {
@@ -21,6 +23,8 @@ type managerFactory struct {
 	uuidGenerator boshuuid.Generator
 	fs            boshsys.FileSystem
 	logger        boshlog.Logger
+
+	attemptReporter biretrier.AttemptReporter
 }
 
 func NewManagerFactory(
@@ -30,6 +34,20 @@ func NewManagerFactory(
 	uuidGenerator boshuuid.Generator,
 	fs boshsys.FileSystem,
 	logger boshlog.Logger,
+) ManagerFactory {
+	return NewManagerFactoryWithAttemptReporter(vmRepo, stemcellRepo, diskDeployer, uuidGenerator, fs, nil, logger)
+}
+
+// NewManagerFactoryWithAttemptReporter creates managers whose VMs report
+// agent ping retries to attemptReporter
+func NewManagerFactoryWithAttemptReporter(
+	vmRepo biconfig.VMRepo,
+	stemcellRepo biconfig.StemcellRepo,
+	diskDeployer DiskDeployer,
+	uuidGenerator boshuuid.Generator,
+	fs boshsys.FileSystem,
+	attemptReporter biretrier.AttemptReporter,
+	logger boshlog.Logger,
 ) ManagerFactory {
 	return &managerFactory{
 		vmRepo:        vmRepo,
@@ -38,11 +56,17 @@ func NewManagerFactory(
 		uuidGenerator: uuidGenerator,
 		fs:            fs,
 		logger:        logger,
+
+		attemptReporter: attemptReporter,
 	}
 }
 
 func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclient.AgentClient) Manager {
-	return NewManager(
+	return f.NewManagerWithUserData(cloud, agentClient, UserDataConfig{})
+}
+
+func (f *managerFactory) NewManagerWithUserData(cloud bicloud.Cloud, agentClient biagentclient.AgentClient, userData UserDataConfig) Manager {
+	return NewManagerWithUserData(
 		f.vmRepo,
 		f.stemcellRepo,
 		f.diskDeployer,
@@ -52,5 +76,7 @@ func (f *managerFactory) NewManager(cloud bicloud.Cloud, agentClient biagentclie
 		f.fs,
 		f.logger,
 		clock.NewClock(),
+		userData,
+		f.attemptReporter,
 	)
 }
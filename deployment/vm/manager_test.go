@@ -1,6 +1,7 @@
 package vm_test
 
 import (
+	"encoding/json"
 	"errors"
 
 	"code.cloudfoundry.org/clock"
@@ -134,6 +135,7 @@ var _ = Describe("Manager", func() {
 				fakeCloud,
 				clock.NewClock(),
 				fs,
+				nil,
 				logger,
 				bicloud.VMMetadata{
 					"deployment":     "fake-deployment",
@@ -157,6 +159,28 @@ var _ = Describe("Manager", func() {
 			))
 		})
 
+		It("passes ephemeral and raw disks in the create_vm cloud_properties", func() {
+			deploymentManifest.ResourcePools[0].EphemeralDisk = bideplmanifest.EphemeralDisk{
+				Size:            10240,
+				CloudProperties: biproperty.Map{"type": "gp2"},
+			}
+			deploymentManifest.ResourcePools[0].RawDisks = []bideplmanifest.RawDisk{
+				{Name: "fake-raw-disk", Size: 1024, CloudProperties: biproperty.Map{"type": "local-ssd"}},
+			}
+
+			_, err := manager.Create(stemcell, deploymentManifest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.CreateVMInput.CloudProperties).To(Equal(biproperty.Map{
+				"fake-cloud-property-key": "fake-cloud-property-value",
+				"ephemeral_disk":          biproperty.Map{"size": 10240, "type": "gp2"},
+				"raw_disks": []interface{}{
+					biproperty.Map{"name": "fake-raw-disk", "size": 1024, "type": "local-ssd"},
+				},
+			}))
+			Expect(deploymentManifest.ResourcePools[0].CloudProperties).ToNot(HaveKey("ephemeral_disk"))
+		})
+
 		It("sets the vm metadata", func() {
 			_, err := manager.Create(stemcell, deploymentManifest)
 			Expect(err).ToNot(HaveOccurred())
@@ -172,6 +196,41 @@ var _ = Describe("Manager", func() {
 			}))
 		})
 
+		Context("when user data is configured", func() {
+			BeforeEach(func() {
+				manager = NewManagerWithUserData(
+					fakeVMRepo,
+					stemcellRepo,
+					fakeDiskDeployer,
+					fakeAgentClient,
+					fakeCloud,
+					&fakeuuid.FakeGenerator{GeneratedUUID: "fake-agent-id"},
+					fs,
+					logger,
+					fakeTimeService,
+					UserDataConfig{Format: "gcp", Mbus: "https://fake-mbus"},
+					nil,
+				)
+			})
+
+			It("passes agent settings as user data in the create_vm env", func() {
+				_, err := manager.Create(stemcell, deploymentManifest)
+				Expect(err).ToNot(HaveOccurred())
+
+				env := fakeCloud.CreateVMInput.Env
+				Expect(env["fake-env-key"]).To(Equal("fake-env-value"))
+
+				var userData map[string]interface{}
+				err = json.Unmarshal([]byte(env["user_data"].(string)), &userData)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(userData["agent_id"]).To(Equal("fake-agent-id"))
+				Expect(userData["mbus"]).To(Equal("https://fake-mbus"))
+				Expect(userData["env"]).To(Equal(map[string]interface{}{"fake-env-key": "fake-env-value"}))
+
+				Expect(deploymentManifest.ResourcePools[0].Env).ToNot(HaveKey("user_data"))
+			})
+		})
+
 		Context("deployment-configured tags", func() {
 			It("sets additional tags on vms", func() {
 				deploymentManifest.Tags = map[string]string{
@@ -226,6 +285,17 @@ var _ = Describe("Manager", func() {
 			Expect(fakeVMRepo.UpdateCurrentCID).To(Equal("fake-vm-cid"))
 		})
 
+		It("records the network cloud properties of the vm", func() {
+			deploymentManifest.Networks[0].CloudProperties = biproperty.Map{"subnet": "fake-subnet"}
+
+			_, err := manager.Create(stemcell, deploymentManifest)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeVMRepo.NetworkCloudProperties).To(Equal(map[string]biproperty.Map{
+				"fake-network-name": {"subnet": "fake-subnet"},
+			}))
+		})
+
 		Context("when setting vm metadata fails", func() {
 			BeforeEach(func() {
 				fakeCloud.SetVMMetadataError = errors.New("fake-set-metadata-error")
@@ -268,4 +338,50 @@ var _ = Describe("Manager", func() {
 			})
 		})
 	})
+
+	Describe("NetworkChanges", func() {
+		BeforeEach(func() {
+			deploymentManifest.Networks[0].CloudProperties = biproperty.Map{"subnet": "fake-new-subnet"}
+		})
+
+		It("returns nothing when there is no current vm", func() {
+			fakeVMRepo.NetworkCloudProperties = map[string]biproperty.Map{
+				"fake-network-name": {"subnet": "fake-old-subnet"},
+			}
+
+			changes, err := manager.NetworkChanges(deploymentManifest)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(changes).To(BeEmpty())
+		})
+
+		Context("when there is a current vm", func() {
+			BeforeEach(func() {
+				fakeVMRepo.SetFindCurrentBehavior("fake-vm-cid", true, nil)
+			})
+
+			It("describes changed network cloud properties", func() {
+				fakeVMRepo.NetworkCloudProperties = map[string]biproperty.Map{
+					"fake-network-name": {"subnet": "fake-old-subnet"},
+				}
+
+				changes, err := manager.NetworkChanges(deploymentManifest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(changes).To(Equal([]string{"network 'fake-network-name' cloud_properties.subnet changed"}))
+			})
+
+			It("returns nothing when the vm networks were never recorded", func() {
+				changes, err := manager.NetworkChanges(deploymentManifest)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(changes).To(BeEmpty())
+			})
+
+			It("returns an error when loading the recorded networks fails", func() {
+				fakeVMRepo.FindCurrentNetworkCloudPropertiesErr = errors.New("fake-find-error")
+
+				_, err := manager.NetworkChanges(deploymentManifest)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-find-error"))
+			})
+		})
+	})
 })
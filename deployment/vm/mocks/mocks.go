@@ -46,3 +46,15 @@ func (m *MockManagerFactory) NewManager(arg0 cloud.Cloud, arg1 agentclient.Agent
 func (mr *MockManagerFactoryMockRecorder) NewManager(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewManager", reflect.TypeOf((*MockManagerFactory)(nil).NewManager), arg0, arg1)
 }
+
+// NewManagerWithUserData mocks base method
+func (m *MockManagerFactory) NewManagerWithUserData(arg0 cloud.Cloud, arg1 agentclient.AgentClient, arg2 vm.UserDataConfig) vm.Manager {
+	ret := m.ctrl.Call(m, "NewManagerWithUserData", arg0, arg1, arg2)
+	ret0, _ := ret[0].(vm.Manager)
+	return ret0
+}
+
+// NewManagerWithUserData indicates an expected call of NewManagerWithUserData
+func (mr *MockManagerFactoryMockRecorder) NewManagerWithUserData(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewManagerWithUserData", reflect.TypeOf((*MockManagerFactory)(nil).NewManagerWithUserData), arg0, arg1, arg2)
+}
@@ -0,0 +1,99 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+// NetworkCloudProperties extracts the cloud_properties of each network interface.
+func NetworkCloudProperties(networkInterfaces map[string]biproperty.Map) map[string]biproperty.Map {
+	cloudProperties := map[string]biproperty.Map{}
+
+	for name, networkInterface := range networkInterfaces {
+		properties, _ := networkInterface["cloud_properties"].(biproperty.Map)
+		if properties == nil {
+			properties = biproperty.Map{}
+		}
+		cloudProperties[name] = properties
+	}
+
+	return cloudProperties
+}
+
+// NetworkCloudPropertiesChanges describes which network cloud_properties
+// differ between the previously deployed VM and the new manifest.
+// Values are compared by their JSON encoding since previous values
+// were read back from the deployment state file.
+func NetworkCloudPropertiesChanges(previous, current map[string]biproperty.Map) []string {
+	changes := []string{}
+
+	for _, name := range sortedNetworkNames(previous, current) {
+		previousProperties, wasPresent := previous[name]
+		currentProperties, isPresent := current[name]
+
+		switch {
+		case !wasPresent:
+			changes = append(changes, fmt.Sprintf("network '%s' was added", name))
+		case !isPresent:
+			changes = append(changes, fmt.Sprintf("network '%s' was removed", name))
+		default:
+			for _, key := range sortedPropertyKeys(previousProperties, currentProperties) {
+				if encodeProperty(previousProperties[key]) != encodeProperty(currentProperties[key]) {
+					changes = append(changes, fmt.Sprintf("network '%s' cloud_properties.%s changed", name, key))
+				}
+			}
+		}
+	}
+
+	return changes
+}
+
+func sortedNetworkNames(maps ...map[string]biproperty.Map) []string {
+	seen := map[string]struct{}{}
+	names := []string{}
+
+	for _, m := range maps {
+		for name := range m {
+			if _, found := seen[name]; !found {
+				seen[name] = struct{}{}
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+func sortedPropertyKeys(maps ...biproperty.Map) []string {
+	seen := map[string]struct{}{}
+	keys := []string{}
+
+	for _, m := range maps {
+		for key := range m {
+			if _, found := seen[key]; !found {
+				seen[key] = struct{}{}
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func encodeProperty(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	bytes, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%#v", value)
+	}
+
+	return string(bytes)
+}
@@ -0,0 +1,48 @@
+package vm_test
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/deployment/vm"
+)
+
+var _ = Describe("NetworkCloudPropertiesChanges", func() {
+	It("returns nothing when cloud properties are unchanged", func() {
+		previous := map[string]biproperty.Map{
+			"private": {"subnet": "subnet-1", "security_groups": []interface{}{"sg-1"}, "mtu": float64(9001)},
+		}
+		current := map[string]biproperty.Map{
+			"private": {"subnet": "subnet-1", "security_groups": []interface{}{"sg-1"}, "mtu": 9001},
+		}
+
+		Expect(NetworkCloudPropertiesChanges(previous, current)).To(BeEmpty())
+	})
+
+	It("lists each changed, added and removed cloud property", func() {
+		previous := map[string]biproperty.Map{
+			"private": {"subnet": "subnet-1", "security_groups": []interface{}{"sg-1"}, "vlan": "10"},
+		}
+		current := map[string]biproperty.Map{
+			"private": {"subnet": "subnet-2", "security_groups": []interface{}{"sg-1", "sg-2"}, "zone": "z1"},
+		}
+
+		Expect(NetworkCloudPropertiesChanges(previous, current)).To(Equal([]string{
+			"network 'private' cloud_properties.security_groups changed",
+			"network 'private' cloud_properties.subnet changed",
+			"network 'private' cloud_properties.vlan changed",
+			"network 'private' cloud_properties.zone changed",
+		}))
+	})
+
+	It("lists added and removed networks", func() {
+		previous := map[string]biproperty.Map{"old": {}}
+		current := map[string]biproperty.Map{"new": {}}
+
+		Expect(NetworkCloudPropertiesChanges(previous, current)).To(Equal([]string{
+			"network 'new' was added",
+			"network 'old' was removed",
+		}))
+	})
+})
@@ -0,0 +1,143 @@
+package vm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+
+	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+)
+
+// UserDataEnvKey is the create_vm env key holding the encoded user data
+const UserDataEnvKey = "user_data"
+
+// UserDataConfig makes the VM manager pass agent settings to the CPI as
+// IaaS user data, so the agent can start without a registry.
+type UserDataConfig struct {
+	Format string
+
+	// Mbus is the URL the agent listens on, not the one the CLI connects to
+	Mbus string
+}
+
+func (c UserDataConfig) IsEmpty() bool {
+	return c == UserDataConfig{}
+}
+
+type userDataContents struct {
+	AgentID  string                    `json:"agent_id"`
+	Mbus     string                    `json:"mbus"`
+	Server   userDataServer            `json:"server"`
+	DNS      userDataDNS               `json:"dns"`
+	Networks map[string]biproperty.Map `json:"networks"`
+	Env      biproperty.Map            `json:"env"`
+	Disks    *AgentDisks               `json:"disks,omitempty"`
+}
+
+// AgentDisks is the disks section of the agent settings
+type AgentDisks struct {
+	Ephemeral    string              `json:"ephemeral,omitempty"`
+	RawEphemeral []AgentRawEphemeral `json:"raw_ephemeral,omitempty"`
+}
+
+type AgentRawEphemeral struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+}
+
+type userDataServer struct {
+	Name string `json:"name"`
+}
+
+type userDataDNS struct {
+	Nameserver []string `json:"nameserver"`
+}
+
+// Encode returns the user data of the agent with agentID. AWS expects user
+// data to be base64 encoded, while GCP metadata values are plain strings.
+func (c UserDataConfig) Encode(agentID string, networks map[string]biproperty.Map, resourcePool bideplmanifest.ResourcePool) (string, error) {
+	contents := userDataContents{
+		AgentID:  agentID,
+		Mbus:     c.Mbus,
+		Server:   userDataServer{Name: fmt.Sprintf("vm-%s", agentID)},
+		DNS:      userDataDNS{Nameserver: c.nameservers(networks)},
+		Networks: networks,
+		Env:      resourcePool.Env,
+		Disks:    NewAgentDisks(resourcePool),
+	}
+
+	bytes, err := json.Marshal(contents)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Marshalling user data")
+	}
+
+	switch c.Format {
+	case biinstallmanifest.UserDataFormatAWS:
+		return base64.StdEncoding.EncodeToString(bytes), nil
+	case biinstallmanifest.UserDataFormatGCP:
+		return string(bytes), nil
+	default:
+		return "", bosherr.Errorf("Unknown user data format '%s'", c.Format)
+	}
+}
+
+// NewAgentDisks lists the ephemeral and raw disks of resourcePool whose
+// device path is known upfront, the CPI or the agent find the others. It is
+// nil when there are none.
+func NewAgentDisks(resourcePool bideplmanifest.ResourcePool) *AgentDisks {
+	disks := AgentDisks{Ephemeral: resourcePool.EphemeralDisk.Path}
+
+	for _, disk := range resourcePool.RawDisks {
+		if disk.Path != "" {
+			disks.RawEphemeral = append(disks.RawEphemeral, AgentRawEphemeral{ID: disk.Name, Path: disk.Path})
+		}
+	}
+
+	if disks.Ephemeral == "" && len(disks.RawEphemeral) == 0 {
+		return nil
+	}
+
+	return &disks
+}
+
+// nameservers of the network providing the DNS default, or of all networks
+func (c UserDataConfig) nameservers(networks map[string]biproperty.Map) []string {
+	names := []string{}
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	nameservers := []string{}
+
+	for _, name := range names {
+		dns, ok := networks[name]["dns"].([]string)
+		if !ok {
+			continue
+		}
+
+		if c.isDNSDefault(networks[name]) {
+			return dns
+		}
+
+		nameservers = append(nameservers, dns...)
+	}
+
+	return nameservers
+}
+
+func (c UserDataConfig) isDNSDefault(network biproperty.Map) bool {
+	defaults, _ := network["default"].([]bideplmanifest.NetworkDefault)
+	for _, networkDefault := range defaults {
+		if networkDefault == bideplmanifest.NetworkDefaultDNS {
+			return true
+		}
+	}
+
+	return false
+}
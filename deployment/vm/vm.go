@@ -1,6 +1,7 @@
 package vm
 
 import (
+	"fmt"
 	"math"
 	"time"
 
@@ -10,13 +11,18 @@ import (
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
-	boshretry "github.com/cloudfoundry/bosh-utils/retrystrategy"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 )
 
+const (
+	mountDiskAttempts = 5
+	mountDiskDelay    = 2 * time.Second
+)
+
 type Clock interface {
 	Sleep(time.Duration)
 	Now() time.Time
@@ -57,6 +63,20 @@ type vm struct {
 	logger       boshlog.Logger
 	logTag       string
 	metadata     bicloud.VMMetadata
+
+	// attemptReporter is told about agent ping retries, it may be nil
+	attemptReporter biretrier.AttemptReporter
+}
+
+// AgentTimeoutError is returned when the agent on a VM did not respond to
+// pings before the timeout
+type AgentTimeoutError struct {
+	VMCID string
+	Err   biretrier.ExhaustedError
+}
+
+func (e AgentTimeoutError) Error() string {
+	return fmt.Sprintf("Timed out pinging the agent on VM '%s': %s", e.VMCID, e.Err.Error())
 }
 
 func NewVM(
@@ -68,6 +88,7 @@ func NewVM(
 	cloud bicloud.Cloud,
 	timeService Clock,
 	fs boshsys.FileSystem,
+	attemptReporter biretrier.AttemptReporter,
 	logger boshlog.Logger,
 ) VM {
 	return &vm{
@@ -81,6 +102,8 @@ func NewVM(
 		fs:           fs,
 		logger:       logger,
 		logTag:       "vm",
+
+		attemptReporter: attemptReporter,
 	}
 }
 
@@ -93,6 +116,7 @@ func NewVMWithMetadata(
 	cloud bicloud.Cloud,
 	timeService Clock,
 	fs boshsys.FileSystem,
+	attemptReporter biretrier.AttemptReporter,
 	logger boshlog.Logger,
 	metadata bicloud.VMMetadata,
 ) VM {
@@ -108,6 +132,8 @@ func NewVMWithMetadata(
 		logger:       logger,
 		logTag:       "vm",
 		metadata:     metadata,
+
+		attemptReporter: attemptReporter,
 	}
 }
 
@@ -129,8 +155,19 @@ func (vm *vm) AgentClient() biagentclient.AgentClient {
 
 func (vm *vm) WaitUntilReady(timeout time.Duration, delay time.Duration) error {
 	agentPingRetryable := biagentclient.NewPingRetryable(vm.agentClient)
-	agentPingRetryStrategy := boshretry.NewTimeoutRetryStrategy(timeout, delay, agentPingRetryable, vm.timeService, vm.logger)
-	return agentPingRetryStrategy.Try()
+	agentPingRetrier := biretrier.NewRetrier(biretrier.Options{
+		Timeout:  timeout,
+		Backoff:  biretrier.NewConstantBackoff(delay),
+		Name:     "agent ping",
+		Reporter: vm.attemptReporter,
+	}, vm.timeService, vm.logger)
+
+	err := agentPingRetrier.Try(agentPingRetryable.Attempt)
+	if exhaustedErr, ok := err.(biretrier.ExhaustedError); ok {
+		return AgentTimeoutError{VMCID: vm.cid, Err: exhaustedErr}
+	}
+
+	return err
 }
 
 func (vm *vm) Start() error {
@@ -192,8 +229,11 @@ func (vm *vm) UpdateDisks(diskPool bideplmanifest.DiskPool, eventLoggerStage biu
 
 func (vm *vm) WaitToBeRunning(maxAttempts int, delay time.Duration) error {
 	agentGetStateRetryable := biagentclient.NewGetStateRetryable(vm.agentClient)
-	agentGetStateRetryStrategy := boshretry.NewAttemptRetryStrategy(maxAttempts, delay, agentGetStateRetryable, vm.logger)
-	return agentGetStateRetryStrategy.Try()
+	agentGetStateRetrier := biretrier.NewRetrier(biretrier.Options{
+		MaxAttempts: maxAttempts,
+		Backoff:     biretrier.NewConstantBackoff(delay),
+	}, vm.timeService, vm.logger)
+	return agentGetStateRetrier.Try(agentGetStateRetryable.Attempt)
 }
 
 func (vm *vm) AttachDisk(disk bidisk.Disk) error {
@@ -217,9 +257,39 @@ func (vm *vm) AttachDisk(disk bidisk.Disk) error {
 		return bosherr.WrapError(err, "Waiting for agent to be accessible after attaching disk")
 	}
 
-	err = vm.agentClient.MountDisk(disk.CID())
+	return vm.mountDisk(disk)
+}
+
+// mountDisk mounts the disk and verifies that the agent reports it as mounted.
+// Some IaaSes report attach success before the device is visible to the VM,
+// so mounting is retried until the agent lists the disk.
+func (vm *vm) mountDisk(disk bidisk.Disk) error {
+	mountRetrier := biretrier.NewRetrier(biretrier.Options{
+		MaxAttempts: mountDiskAttempts,
+		Backoff:     biretrier.NewConstantBackoff(mountDiskDelay),
+	}, vm.timeService, vm.logger)
+
+	err := mountRetrier.Try(func() (bool, error) {
+		err := vm.agentClient.MountDisk(disk.CID())
+		if err != nil {
+			return true, bosherr.WrapError(err, "Mounting disk")
+		}
+
+		mountedDiskCIDs, err := vm.agentClient.ListDisk()
+		if err != nil {
+			return true, bosherr.WrapError(err, "Listing mounted disks")
+		}
+
+		for _, mountedDiskCID := range mountedDiskCIDs {
+			if mountedDiskCID == disk.CID() {
+				return false, nil
+			}
+		}
+
+		return true, bosherr.Errorf("Agent does not report disk '%s' as mounted", disk.CID())
+	})
 	if err != nil {
-		return bosherr.WrapError(err, "Mounting disk")
+		return bosherr.WrapErrorf(err, "Verifying disk '%s' is mounted", disk.CID())
 	}
 
 	return nil
@@ -73,10 +73,21 @@ var _ = Describe("VM", func() {
 			fakeCloud,
 			timeService,
 			fs,
+			nil,
 			logger,
 		)
 	})
 
+	Describe("WaitUntilReady", func() {
+		It("returns an agent timeout error when the agent does not respond in time", func() {
+			fakeAgentClient.PingReturns("", errors.New("fake-ping-error"))
+
+			err := vm.WaitUntilReady(time.Second, time.Second)
+			Expect(err).To(BeAssignableToTypeOf(AgentTimeoutError{}))
+			Expect(err.Error()).To(ContainSubstring("Timed out pinging the agent on VM 'fake-vm-cid': Giving up after 1 attempt"))
+		})
+	})
+
 	Describe("Exists", func() {
 		It("returns true when the vm exists", func() {
 			fakeCloud.HasVMFound = true
@@ -275,6 +286,7 @@ var _ = Describe("VM", func() {
 			fakeTime := time.Date(2016, time.November, 10, 23, 0, 0, 0, time.UTC)
 			timeService = &FakeClock{Times: []time.Time{fakeTime, time.Now(), time.Now().Add(10 * time.Minute)}}
 			disk = fakebidisk.NewFakeDisk("fake-disk-cid")
+			fakeAgentClient.ListDiskReturns([]string{"fake-disk-cid"}, nil)
 
 			metadata := bicloud.VMMetadata{
 				"director":       "bosh-init",
@@ -293,6 +305,7 @@ var _ = Describe("VM", func() {
 				fakeCloud,
 				timeService,
 				fs,
+				nil,
 				logger,
 				metadata,
 			)
@@ -388,10 +401,39 @@ var _ = Describe("VM", func() {
 				fakeAgentClient.MountDiskReturns(errors.New("fake-mount-error"))
 			})
 
-			It("returns an error", func() {
+			It("retries and returns an error", func() {
 				err := vm.AttachDisk(disk)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-mount-error"))
+				Expect(fakeAgentClient.MountDiskCallCount()).To(Equal(5))
+			})
+		})
+
+		Context("when the agent does not report the disk as mounted right away", func() {
+			BeforeEach(func() {
+				fakeAgentClient.ListDiskReturnsOnCall(0, []string{}, nil)
+				fakeAgentClient.ListDiskReturnsOnCall(1, []string{"fake-other-disk-cid", "fake-disk-cid"}, nil)
+			})
+
+			It("retries mounting until the disk is listed", func() {
+				err := vm.AttachDisk(disk)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeAgentClient.MountDiskCallCount()).To(Equal(2))
+				Expect(timeService.SleepCalls).To(Equal([]time.Duration{2 * time.Second}))
+			})
+		})
+
+		Context("when the agent never reports the disk as mounted", func() {
+			BeforeEach(func() {
+				fakeAgentClient.ListDiskReturns([]string{"fake-other-disk-cid"}, nil)
+			})
+
+			It("returns an error", func() {
+				err := vm.AttachDisk(disk)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Verifying disk 'fake-disk-cid' is mounted: Giving up after 5 attempts"))
+				Expect(err.Error()).To(ContainSubstring("Agent does not report disk 'fake-disk-cid' as mounted"))
+				Expect(fakeAgentClient.MountDiskCallCount()).To(Equal(5))
 			})
 		})
 
@@ -0,0 +1,137 @@
+package faultinjection
+
+import (
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+)
+
+type agentClientFactory struct {
+	factory  bihttpagent.AgentClientFactory
+	injector Injector
+}
+
+// NewAgentClientFactory fails agent requests of the created clients as the injector decides.
+func NewAgentClientFactory(factory bihttpagent.AgentClientFactory, injector Injector) bihttpagent.AgentClientFactory {
+	return agentClientFactory{factory: factory, injector: injector}
+}
+
+func (f agentClientFactory) NewAgentClient(directorID, mbusURL, caCert string) (biagentclient.AgentClient, error) {
+	client, err := f.factory.NewAgentClient(directorID, mbusURL, caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAgentClient(client, f.injector), nil
+}
+
+type agentClient struct {
+	client   biagentclient.AgentClient
+	injector Injector
+}
+
+func NewAgentClient(client biagentclient.AgentClient, injector Injector) biagentclient.AgentClient {
+	return agentClient{client: client, injector: injector}
+}
+
+func (c agentClient) fault(method string) error {
+	return c.injector.Fault(TargetAgent, method)
+}
+
+func (c agentClient) Ping() (string, error) {
+	if err := c.fault("ping"); err != nil {
+		return "", err
+	}
+	return c.client.Ping()
+}
+
+func (c agentClient) Stop() error {
+	if err := c.fault("stop"); err != nil {
+		return err
+	}
+	return c.client.Stop()
+}
+
+func (c agentClient) Drain(drainType string) (int64, error) {
+	if err := c.fault("drain"); err != nil {
+		return 0, err
+	}
+	return c.client.Drain(drainType)
+}
+
+func (c agentClient) Apply(spec bias.ApplySpec) error {
+	if err := c.fault("apply"); err != nil {
+		return err
+	}
+	return c.client.Apply(spec)
+}
+
+func (c agentClient) Start() error {
+	if err := c.fault("start"); err != nil {
+		return err
+	}
+	return c.client.Start()
+}
+
+func (c agentClient) GetState() (biagentclient.AgentState, error) {
+	if err := c.fault("get_state"); err != nil {
+		return biagentclient.AgentState{}, err
+	}
+	return c.client.GetState()
+}
+
+func (c agentClient) MountDisk(diskCID string) error {
+	if err := c.fault("mount_disk"); err != nil {
+		return err
+	}
+	return c.client.MountDisk(diskCID)
+}
+
+func (c agentClient) UnmountDisk(diskCID string) error {
+	if err := c.fault("unmount_disk"); err != nil {
+		return err
+	}
+	return c.client.UnmountDisk(diskCID)
+}
+
+func (c agentClient) ListDisk() ([]string, error) {
+	if err := c.fault("list_disk"); err != nil {
+		return nil, err
+	}
+	return c.client.ListDisk()
+}
+
+func (c agentClient) MigrateDisk() error {
+	if err := c.fault("migrate_disk"); err != nil {
+		return err
+	}
+	return c.client.MigrateDisk()
+}
+
+func (c agentClient) CompilePackage(packageSource biagentclient.BlobRef, compiledPackageDependencies []biagentclient.BlobRef) (biagentclient.BlobRef, error) {
+	if err := c.fault("compile_package"); err != nil {
+		return biagentclient.BlobRef{}, err
+	}
+	return c.client.CompilePackage(packageSource, compiledPackageDependencies)
+}
+
+func (c agentClient) DeleteARPEntries(ips []string) error {
+	if err := c.fault("delete_arp_entries"); err != nil {
+		return err
+	}
+	return c.client.DeleteARPEntries(ips)
+}
+
+func (c agentClient) SyncDNS(blobID, sha1 string, version uint64) (string, error) {
+	if err := c.fault("sync_dns"); err != nil {
+		return "", err
+	}
+	return c.client.SyncDNS(blobID, sha1, version)
+}
+
+func (c agentClient) RunScript(scriptName string, options map[string]interface{}) error {
+	if err := c.fault("run_script"); err != nil {
+		return err
+	}
+	return c.client.RunScript(scriptName, options)
+}
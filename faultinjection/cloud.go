@@ -0,0 +1,157 @@
+package faultinjection
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+)
+
+const cpiCloudError = "Bosh::Clouds::CloudError"
+
+type cloudFactory struct {
+	factory  bicloud.Factory
+	injector Injector
+}
+
+// NewCloudFactory fails CPI calls of the created clouds as the injector decides.
+// Faults respond like a CPI error so callers take their usual error paths.
+func NewCloudFactory(factory bicloud.Factory, injector Injector) bicloud.Factory {
+	return cloudFactory{factory: factory, injector: injector}
+}
+
+func (f cloudFactory) NewCloud(installation biinstall.Installation, directorID string) (bicloud.Cloud, error) {
+	cloud, err := f.factory.NewCloud(installation, directorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCloud(cloud, f.injector), nil
+}
+
+func (f cloudFactory) NewCloudWithProperties(installation biinstall.Installation, directorID string, cpiProperties biproperty.Map) (bicloud.Cloud, error) {
+	cloud, err := f.factory.NewCloudWithProperties(installation, directorID, cpiProperties)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCloud(cloud, f.injector), nil
+}
+
+type cloud struct {
+	cloud    bicloud.Cloud
+	injector Injector
+}
+
+func NewCloud(c bicloud.Cloud, injector Injector) bicloud.Cloud {
+	return cloud{cloud: c, injector: injector}
+}
+
+func (c cloud) fault(method string) error {
+	err := c.injector.Fault(TargetCPI, method)
+	if err == nil {
+		return nil
+	}
+
+	cmdError := bicloud.CmdError{Type: cpiCloudError, Message: err.Error()}
+	if fault, ok := err.(FaultError); ok && fault.ErrorType != "" {
+		cmdError.Type = fault.ErrorType
+	}
+
+	return bicloud.NewCPIError(method, cmdError)
+}
+
+func (c cloud) Info() (bicloud.Info, error) {
+	if err := c.fault("info"); err != nil {
+		return bicloud.Info{}, err
+	}
+	return c.cloud.Info()
+}
+
+func (c cloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
+	if err := c.fault("create_stemcell"); err != nil {
+		return "", err
+	}
+	return c.cloud.CreateStemcell(imagePath, cloudProperties)
+}
+
+func (c cloud) DeleteStemcell(stemcellCID string) error {
+	if err := c.fault("delete_stemcell"); err != nil {
+		return err
+	}
+	return c.cloud.DeleteStemcell(stemcellCID)
+}
+
+func (c cloud) HasVM(vmCID string) (bool, error) {
+	if err := c.fault("has_vm"); err != nil {
+		return false, err
+	}
+	return c.cloud.HasVM(vmCID)
+}
+
+func (c cloud) HasDisk(diskCID string) (bool, error) {
+	if err := c.fault("has_disk"); err != nil {
+		return false, err
+	}
+	return c.cloud.HasDisk(diskCID)
+}
+
+func (c cloud) CreateVM(agentID string, stemcellCID string, cloudProperties biproperty.Map, networksInterfaces map[string]biproperty.Map, env biproperty.Map) (string, error) {
+	if err := c.fault("create_vm"); err != nil {
+		return "", err
+	}
+	return c.cloud.CreateVM(agentID, stemcellCID, cloudProperties, networksInterfaces, env)
+}
+
+func (c cloud) SetVMMetadata(vmCID string, metadata bicloud.VMMetadata) error {
+	if err := c.fault("set_vm_metadata"); err != nil {
+		return err
+	}
+	return c.cloud.SetVMMetadata(vmCID, metadata)
+}
+
+func (c cloud) SetDiskMetadata(diskCID string, metadata bicloud.DiskMetadata) error {
+	if err := c.fault("set_disk_metadata"); err != nil {
+		return err
+	}
+	return c.cloud.SetDiskMetadata(diskCID, metadata)
+}
+
+func (c cloud) DeleteVM(vmCID string) error {
+	if err := c.fault("delete_vm"); err != nil {
+		return err
+	}
+	return c.cloud.DeleteVM(vmCID)
+}
+
+func (c cloud) CreateDisk(size int, cloudProperties biproperty.Map, vmCID string) (string, error) {
+	if err := c.fault("create_disk"); err != nil {
+		return "", err
+	}
+	return c.cloud.CreateDisk(size, cloudProperties, vmCID)
+}
+
+func (c cloud) AttachDisk(vmCID, diskCID string) error {
+	if err := c.fault("attach_disk"); err != nil {
+		return err
+	}
+	return c.cloud.AttachDisk(vmCID, diskCID)
+}
+
+func (c cloud) DetachDisk(vmCID, diskCID string) error {
+	if err := c.fault("detach_disk"); err != nil {
+		return err
+	}
+	return c.cloud.DetachDisk(vmCID, diskCID)
+}
+
+func (c cloud) DeleteDisk(diskCID string) error {
+	if err := c.fault("delete_disk"); err != nil {
+		return err
+	}
+	return c.cloud.DeleteDisk(diskCID)
+}
+
+func (c cloud) String() string {
+	return c.cloud.String()
+}
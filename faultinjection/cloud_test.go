@@ -0,0 +1,87 @@
+package faultinjection_test
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mock_agentclient "github.com/cloudfoundry/bosh-cli/agentclient/mocks"
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
+
+	. "github.com/cloudfoundry/bosh-cli/faultinjection"
+)
+
+var _ = Describe("Cloud", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		mockCloud *mock_cloud.MockCloud
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("responds to faulted calls with a CPI error instead of calling the CPI", func() {
+		cloud := NewCloud(mockCloud, NewInjector([]Rule{{Target: "cpi", Method: "create_disk"}}))
+
+		_, err := cloud.CreateDisk(1024, biproperty.Map{}, "fake-vm-cid")
+		Expect(err).To(HaveOccurred())
+
+		cloudErr, ok := err.(bicloud.Error)
+		Expect(ok).To(BeTrue())
+		Expect(cloudErr.Method()).To(Equal("create_disk"))
+		Expect(cloudErr.Type()).To(Equal("Bosh::Clouds::CloudError"))
+		Expect(cloudErr.Message()).To(Equal("Injected fault for cpi call 'create_disk' (call #1)"))
+	})
+
+	It("uses the error type of the rule", func() {
+		cloud := NewCloud(mockCloud, NewInjector([]Rule{{Target: "cpi", Method: "delete_vm", ErrorType: bicloud.VMNotFoundError}}))
+
+		err := cloud.DeleteVM("fake-vm-cid")
+		cloudErr, ok := err.(bicloud.Error)
+		Expect(ok).To(BeTrue())
+		Expect(cloudErr.Type()).To(Equal(bicloud.VMNotFoundError))
+	})
+
+	It("passes calls that are not faulted to the CPI", func() {
+		mockCloud.EXPECT().DeleteVM("fake-vm-cid").Return(nil)
+
+		cloud := NewCloud(mockCloud, NewInjector([]Rule{{Target: "agent", Method: "delete_vm"}}))
+		Expect(cloud.DeleteVM("fake-vm-cid")).To(Succeed())
+	})
+})
+
+var _ = Describe("AgentClient", func() {
+	var (
+		mockCtrl        *gomock.Controller
+		mockAgentClient *mock_agentclient.MockAgentClient
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockAgentClient = mock_agentclient.NewMockAgentClient(mockCtrl)
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("fails faulted requests and passes the others to the agent", func() {
+		mockAgentClient.EXPECT().Ping().Return("fake-pong", nil)
+
+		client := NewAgentClient(mockAgentClient, NewInjector([]Rule{{Target: "agent", Method: "mount_disk", Call: 1}}))
+
+		Expect(client.MountDisk("fake-disk-cid")).To(MatchError("Injected fault for agent call 'mount_disk' (call #1)"))
+
+		response, err := client.Ping()
+		Expect(err).ToNot(HaveOccurred())
+		Expect(response).To(Equal("fake-pong"))
+	})
+})
@@ -0,0 +1,138 @@
+package faultinjection
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// EnvVar enables fault injection, e.g. BOSH_FAULT_INJECTION=cpi:create_disk,agent:apply:2
+const EnvVar = "BOSH_FAULT_INJECTION"
+
+const (
+	TargetCPI   = "cpi"
+	TargetAgent = "agent"
+
+	anyMethod = "*"
+)
+
+type Injector interface {
+	// Fault returns the error the call should fail with, or nil
+	Fault(target, method string) error
+}
+
+// Rule fails calls of Method on Target. Call is the 1-based call to fail,
+// zero fails every call. ErrorType is the CPI error type to respond with.
+type Rule struct {
+	Target    string
+	Method    string
+	Call      int
+	ErrorType string
+}
+
+type FaultError struct {
+	Target    string
+	Method    string
+	Call      int
+	ErrorType string
+}
+
+func (e FaultError) Error() string {
+	return "Injected fault for " + e.Target + " call '" + e.Method + "' (call #" + strconv.Itoa(e.Call) + ")"
+}
+
+type injector struct {
+	rules []Rule
+	calls map[string]int
+	lock  sync.Mutex
+}
+
+func NewInjector(rules []Rule) Injector {
+	return &injector{rules: rules, calls: map[string]int{}}
+}
+
+// NewInjectorFromEnv returns nil when fault injection is not enabled
+func NewInjectorFromEnv(getenv func(string) string) (Injector, error) {
+	spec := getenv(EnvVar)
+	if spec == "" {
+		return nil, nil
+	}
+
+	rules, err := ParseRules(spec)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Invalid %s value", EnvVar)
+	}
+
+	return NewInjector(rules), nil
+}
+
+// ParseRules parses comma separated 'target:method[:call][=error_type]' rules,
+// where method may be '*' to match all methods of the target
+func ParseRules(spec string) ([]Rule, error) {
+	var rules []Rule
+
+	for _, rawRule := range strings.Split(spec, ",") {
+		rawRule = strings.TrimSpace(rawRule)
+		if rawRule == "" {
+			continue
+		}
+
+		rule := Rule{}
+
+		if idx := strings.Index(rawRule, "="); idx >= 0 {
+			rule.ErrorType = rawRule[idx+1:]
+			rawRule = rawRule[:idx]
+		}
+
+		pieces := strings.Split(rawRule, ":")
+		if len(pieces) < 2 || len(pieces) > 3 {
+			return nil, bosherr.Errorf("Expected rule '%s' to be in the form 'target:method[:call]'", rawRule)
+		}
+
+		rule.Target = pieces[0]
+		rule.Method = pieces[1]
+
+		if rule.Target != TargetCPI && rule.Target != TargetAgent {
+			return nil, bosherr.Errorf("Expected rule '%s' to target '%s' or '%s'", rawRule, TargetCPI, TargetAgent)
+		}
+
+		if rule.Method == "" {
+			return nil, bosherr.Errorf("Expected rule '%s' to name a method", rawRule)
+		}
+
+		if len(pieces) == 3 {
+			call, err := strconv.Atoi(pieces[2])
+			if err != nil || call < 1 {
+				return nil, bosherr.Errorf("Expected call of rule '%s' to be a positive number", rawRule)
+			}
+			rule.Call = call
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func (i *injector) Fault(target, method string) error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+
+	key := target + ":" + method
+	i.calls[key]++
+	call := i.calls[key]
+
+	for _, rule := range i.rules {
+		if rule.Target != target || (rule.Method != method && rule.Method != anyMethod) {
+			continue
+		}
+
+		if rule.Call == 0 || rule.Call == call {
+			return FaultError{Target: target, Method: method, Call: call, ErrorType: rule.ErrorType}
+		}
+	}
+
+	return nil
+}
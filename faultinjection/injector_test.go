@@ -0,0 +1,77 @@
+package faultinjection_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/faultinjection"
+)
+
+var _ = Describe("ParseRules", func() {
+	It("parses targets, methods, calls and error types", func() {
+		rules, err := ParseRules("cpi:create_vm, agent:apply:2,cpi:delete_vm=Bosh::Clouds::VMNotFound,")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(rules).To(Equal([]Rule{
+			{Target: "cpi", Method: "create_vm"},
+			{Target: "agent", Method: "apply", Call: 2},
+			{Target: "cpi", Method: "delete_vm", ErrorType: "Bosh::Clouds::VMNotFound"},
+		}))
+	})
+
+	It("returns an error for malformed rules", func() {
+		_, err := ParseRules("cpi")
+		Expect(err).To(MatchError("Expected rule 'cpi' to be in the form 'target:method[:call]'"))
+
+		_, err = ParseRules("registry:get")
+		Expect(err).To(MatchError("Expected rule 'registry:get' to target 'cpi' or 'agent'"))
+
+		_, err = ParseRules("cpi:")
+		Expect(err).To(MatchError("Expected rule 'cpi:' to name a method"))
+
+		_, err = ParseRules("cpi:create_vm:0")
+		Expect(err).To(MatchError("Expected call of rule 'cpi:create_vm:0' to be a positive number"))
+	})
+})
+
+var _ = Describe("NewInjectorFromEnv", func() {
+	It("returns no injector when the env var is not set", func() {
+		injector, err := NewInjectorFromEnv(func(string) string { return "" })
+		Expect(err).ToNot(HaveOccurred())
+		Expect(injector).To(BeNil())
+	})
+
+	It("returns an error for an invalid env var", func() {
+		_, err := NewInjectorFromEnv(func(name string) string {
+			Expect(name).To(Equal("BOSH_FAULT_INJECTION"))
+			return "cpi"
+		})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Invalid BOSH_FAULT_INJECTION value"))
+	})
+})
+
+var _ = Describe("Injector", func() {
+	It("fails every call of a method without a call number", func() {
+		injector := NewInjector([]Rule{{Target: "cpi", Method: "create_vm"}})
+
+		Expect(injector.Fault("cpi", "create_vm")).To(MatchError("Injected fault for cpi call 'create_vm' (call #1)"))
+		Expect(injector.Fault("cpi", "create_vm")).To(MatchError("Injected fault for cpi call 'create_vm' (call #2)"))
+		Expect(injector.Fault("cpi", "delete_vm")).ToNot(HaveOccurred())
+		Expect(injector.Fault("agent", "create_vm")).ToNot(HaveOccurred())
+	})
+
+	It("fails only the given call of a method", func() {
+		injector := NewInjector([]Rule{{Target: "agent", Method: "apply", Call: 2}})
+
+		Expect(injector.Fault("agent", "apply")).ToNot(HaveOccurred())
+		Expect(injector.Fault("agent", "apply")).To(Equal(FaultError{Target: "agent", Method: "apply", Call: 2}))
+		Expect(injector.Fault("agent", "apply")).ToNot(HaveOccurred())
+	})
+
+	It("fails all methods of a target with '*'", func() {
+		injector := NewInjector([]Rule{{Target: "agent", Method: "*", ErrorType: "fake-type"}})
+
+		Expect(injector.Fault("agent", "ping")).To(Equal(FaultError{Target: "agent", Method: "ping", Call: 1, ErrorType: "fake-type"}))
+		Expect(injector.Fault("cpi", "ping")).ToNot(HaveOccurred())
+	})
+})
@@ -0,0 +1,37 @@
+// Package blobstore keeps artifacts of installations, e.g. compiled
+// packages, under keys in a directory or an S3 compatible bucket so that
+// they can be shared between workstations and CI workers.
+package blobstore
+
+import (
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const S3Scheme = "s3"
+
+type Blobstore interface {
+	// Get copies the blob to destinationPath, found is false when there is
+	// no blob with the key
+	Get(key string, destinationPath string) (found bool, err error)
+	Put(key string, sourcePath string) error
+	Location() string
+}
+
+// IsRemote is true for locations in an S3 compatible bucket, e.g.
+// 's3://bucket/compiled-packages'
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, S3Scheme+"://")
+}
+
+// NewBlobstore returns the blobstore of an S3 location or a directory. The
+// location is validated when the blobstore is first used.
+func NewBlobstore(location string, fs boshsys.FileSystem, logger boshlog.Logger) Blobstore {
+	if IsRemote(location) {
+		return NewS3Blobstore(location, fs, logger)
+	}
+
+	return NewLocalBlobstore(location, fs, logger)
+}
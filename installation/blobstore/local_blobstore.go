@@ -0,0 +1,74 @@
+package blobstore
+
+import (
+	"os"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// localBlobstore keeps blobs as files named by their keys, e.g. in a
+// directory shared over NFS
+type localBlobstore struct {
+	dir    string
+	fs     boshsys.FileSystem
+	logger boshlog.Logger
+	logTag string
+}
+
+func NewLocalBlobstore(dir string, fs boshsys.FileSystem, logger boshlog.Logger) Blobstore {
+	return localBlobstore{
+		dir:    dir,
+		fs:     fs,
+		logger: logger,
+		logTag: "localBlobstore",
+	}
+}
+
+func (b localBlobstore) Location() string {
+	return b.dir
+}
+
+func (b localBlobstore) Get(key string, destinationPath string) (bool, error) {
+	path := filepath.Join(b.dir, key)
+	if !b.fs.FileExists(path) {
+		return false, nil
+	}
+
+	err := b.fs.CopyFile(path, destinationPath)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Copying blob '%s' from '%s'", key, b.dir)
+	}
+
+	b.logger.Debug(b.logTag, "Got blob '%s' from '%s'", key, b.dir)
+
+	return true, nil
+}
+
+// Put writes the blob under a temporary name first so that readers never
+// see partially written blobs
+func (b localBlobstore) Put(key string, sourcePath string) error {
+	err := b.fs.MkdirAll(b.dir, os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating blobstore directory '%s'", b.dir)
+	}
+
+	path := filepath.Join(b.dir, key)
+	tmpPath := path + ".tmp"
+
+	err = b.fs.CopyFile(sourcePath, tmpPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying blob '%s' into '%s'", key, b.dir)
+	}
+
+	err = b.fs.Rename(tmpPath, path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Renaming blob '%s' in '%s'", key, b.dir)
+	}
+
+	b.logger.Debug(b.logTag, "Put blob '%s' into '%s'", key, b.dir)
+
+	return nil
+}
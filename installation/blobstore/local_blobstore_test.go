@@ -0,0 +1,49 @@
+package blobstore_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/installation/blobstore"
+)
+
+var _ = Describe("LocalBlobstore", func() {
+	var (
+		fs        *fakesys.FakeFileSystem
+		blobstore Blobstore
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		blobstore = NewBlobstore("/shared/compiled-packages", fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		fs.WriteFileString("/source.tgz", "fake-blob")
+	})
+
+	It("gets the blobs it puts", func() {
+		err := blobstore.Put("fake-key", "/source.tgz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.ReadFileString("/shared/compiled-packages/fake-key")).To(Equal("fake-blob"))
+		Expect(fs.FileExists("/shared/compiled-packages/fake-key.tmp")).To(BeFalse())
+
+		found, err := blobstore.Get("fake-key", "/destination.tgz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(fs.ReadFileString("/destination.tgz")).To(Equal("fake-blob"))
+	})
+
+	It("does not find blobs that were not put", func() {
+		found, err := blobstore.Get("missing-key", "/destination.tgz")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+		Expect(fs.FileExists("/destination.tgz")).To(BeFalse())
+	})
+
+	It("returns an error when the source cannot be copied", func() {
+		err := blobstore.Put("fake-key", "/missing.tgz")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Copying blob 'fake-key' into '/shared/compiled-packages'"))
+	})
+})
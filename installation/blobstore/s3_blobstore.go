@@ -0,0 +1,171 @@
+package blobstore
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const s3DefaultRegion = "us-east-1"
+
+// s3Blobstore keeps blobs as objects under the prefix of its location. The
+// region and an endpoint for S3 compatible stores can be given as query
+// parameters, e.g. 's3://bucket/compiled-packages?region=eu-west-1', as
+// for remote deployment state. Credentials are taken from the environment.
+type s3Blobstore struct {
+	location string
+	fs       boshsys.FileSystem
+	logger   boshlog.Logger
+	logTag   string
+
+	client *s3.S3
+	bucket string
+	prefix string
+	lock   sync.Mutex
+}
+
+func NewS3Blobstore(location string, fs boshsys.FileSystem, logger boshlog.Logger) Blobstore {
+	return &s3Blobstore{
+		location: location,
+		fs:       fs,
+		logger:   logger,
+		logTag:   "s3Blobstore",
+	}
+}
+
+func (b *s3Blobstore) Location() string {
+	return b.location
+}
+
+func (b *s3Blobstore) Get(key string, destinationPath string) (bool, error) {
+	client, err := b.s3Client()
+	if err != nil {
+		return false, err
+	}
+
+	output, err := client.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+	})
+	if err != nil {
+		if b.isNotFound(err) {
+			return false, nil
+		}
+		return false, bosherr.WrapErrorf(err, "Getting object '%s' from bucket '%s'", b.objectKey(key), b.bucket)
+	}
+
+	defer output.Body.Close()
+
+	file, err := b.fs.OpenFile(destinationPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Creating file '%s'", destinationPath)
+	}
+
+	defer file.Close()
+
+	_, err = io.Copy(file, output.Body)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Downloading object '%s' from bucket '%s'", b.objectKey(key), b.bucket)
+	}
+
+	b.logger.Debug(b.logTag, "Got blob '%s' from '%s'", key, b.location)
+
+	return true, nil
+}
+
+func (b *s3Blobstore) Put(key string, sourcePath string) error {
+	client, err := b.s3Client()
+	if err != nil {
+		return err
+	}
+
+	file, err := b.fs.OpenFile(sourcePath, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening file '%s'", sourcePath)
+	}
+
+	defer file.Close()
+
+	_, err = client.PutObject(&s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(b.objectKey(key)),
+		Body:   file,
+	})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Putting object '%s' into bucket '%s'", b.objectKey(key), b.bucket)
+	}
+
+	b.logger.Debug(b.logTag, "Put blob '%s' into '%s'", key, b.location)
+
+	return nil
+}
+
+func (b *s3Blobstore) objectKey(key string) string {
+	return path.Join(b.prefix, key)
+}
+
+func (b *s3Blobstore) s3Client() (*s3.S3, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if b.client != nil {
+		return b.client, nil
+	}
+
+	locationURL, err := url.Parse(b.location)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing blobstore location '%s'", b.location)
+	}
+
+	if locationURL.Scheme != S3Scheme || locationURL.Host == "" {
+		return nil, bosherr.Errorf("Expected blobstore location '%s' to be 's3://<bucket>/<prefix>'", b.location)
+	}
+
+	config := aws.NewConfig().WithLogLevel(aws.LogOff)
+
+	if region := locationURL.Query().Get("region"); region != "" {
+		config = config.WithRegion(region)
+	}
+
+	if endpoint := locationURL.Query().Get("endpoint"); endpoint != "" {
+		config = config.WithEndpoint(endpoint).WithS3ForcePathStyle(true)
+	}
+
+	sess, err := session.NewSessionWithOptions(session.Options{
+		Config:            *config,
+		SharedConfigState: session.SharedConfigEnable,
+	})
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Building S3 session")
+	}
+
+	if aws.StringValue(sess.Config.Region) == "" {
+		sess.Config.Region = aws.String(s3DefaultRegion)
+	}
+
+	b.client = s3.New(sess)
+	b.bucket = locationURL.Host
+	b.prefix = strings.Trim(locationURL.Path, "/")
+
+	return b.client, nil
+}
+
+func (b *s3Blobstore) isNotFound(err error) bool {
+	if reqErr, ok := err.(awserr.RequestFailure); ok {
+		return reqErr.StatusCode() == http.StatusNotFound || reqErr.Code() == s3.ErrCodeNoSuchKey
+	}
+
+	return false
+}
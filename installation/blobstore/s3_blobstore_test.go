@@ -0,0 +1,108 @@
+package blobstore_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/installation/blobstore"
+)
+
+// fakeS3 keeps objects by path like a path-style S3 endpoint
+type fakeS3 struct {
+	objects map[string][]byte
+	lock    sync.Mutex
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch r.Method {
+	case "GET":
+		contents, found := s.objects[r.URL.Path]
+		if !found {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`<Error><Code>NoSuchKey</Code></Error>`))
+			return
+		}
+		w.Write(contents)
+
+	case "PUT":
+		s.objects[r.URL.Path], _ = ioutil.ReadAll(r.Body)
+	}
+}
+
+var _ = Describe("S3Blobstore", func() {
+	var (
+		server    *httptest.Server
+		store     *fakeS3
+		tmpDir    string
+		blobstore Blobstore
+	)
+
+	BeforeEach(func() {
+		os.Setenv("AWS_ACCESS_KEY_ID", "fake-access-key")
+		os.Setenv("AWS_SECRET_ACCESS_KEY", "fake-secret-key")
+
+		store = &fakeS3{objects: map[string][]byte{}}
+		server = httptest.NewServer(store)
+
+		var err error
+		tmpDir, err = ioutil.TempDir("", "s3-blobstore")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = ioutil.WriteFile(filepath.Join(tmpDir, "source.tgz"), []byte("fake-blob"), 0644)
+		Expect(err).ToNot(HaveOccurred())
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		blobstore = NewBlobstore(
+			"s3://fake-bucket/compiled-packages?region=fake-region&endpoint="+server.URL,
+			boshsys.NewOsFileSystem(logger),
+			logger,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.RemoveAll(tmpDir)
+		os.Unsetenv("AWS_ACCESS_KEY_ID")
+		os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	})
+
+	It("puts blobs under the prefix of the location and gets them", func() {
+		err := blobstore.Put("fake-key", filepath.Join(tmpDir, "source.tgz"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(store.objects["/fake-bucket/compiled-packages/fake-key"])).To(Equal("fake-blob"))
+
+		found, err := blobstore.Get("fake-key", filepath.Join(tmpDir, "destination.tgz"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+
+		contents, err := ioutil.ReadFile(filepath.Join(tmpDir, "destination.tgz"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(string(contents)).To(Equal("fake-blob"))
+	})
+
+	It("does not find blobs that were not put", func() {
+		found, err := blobstore.Get("missing-key", filepath.Join(tmpDir, "destination.tgz"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns an error for locations without a bucket", func() {
+		blobstore = NewBlobstore("s3:///compiled-packages", boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)), boshlog.NewLogger(boshlog.LevelNone))
+
+		_, err := blobstore.Get("fake-key", filepath.Join(tmpDir, "destination.tgz"))
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Expected blobstore location 's3:///compiled-packages' to be 's3://<bucket>/<prefix>'"))
+	})
+})
@@ -1,6 +1,8 @@
 package installation
 
 import (
+	"time"
+
 	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
 	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
@@ -14,6 +16,10 @@ type Installation interface {
 	WithRunningRegistry(boshlog.Logger, biui.Stage, func() error) error
 	StartRegistry() error
 	StopRegistry() error
+
+	// SetRegistrySettingsOverlay merges the JSON hash overlayJSON into the
+	// settings the running registry serves agents
+	SetRegistrySettingsOverlay(overlayJSON []byte) error
 }
 
 type installation struct {
@@ -21,6 +27,7 @@ type installation struct {
 	job                   InstalledJob
 	manifest              biinstallmanifest.Manifest
 	registryServerManager biregistry.ServerManager
+	registryStore         biregistry.Store
 
 	registryServer biregistry.Server
 }
@@ -30,12 +37,14 @@ func NewInstallation(
 	job InstalledJob,
 	manifest biinstallmanifest.Manifest,
 	registryServerManager biregistry.ServerManager,
+	registryStore biregistry.Store,
 ) Installation {
 	return &installation{
 		target:                target,
 		job:                   job,
 		manifest:              manifest,
 		registryServerManager: registryServerManager,
+		registryStore:         registryStore,
 	}
 }
 
@@ -73,7 +82,13 @@ func (i *installation) StartRegistry() error {
 			return bosherr.Error("Registry already started")
 		}
 		config := i.manifest.Registry
-		registryServer, err := i.registryServerManager.Start(config.Username, config.Password, config.Host, config.Port)
+		limits := biregistry.Limits{
+			MaxSettingsSize: config.MaxSettingsSize,
+			MaxInstances:    config.MaxInstances,
+			ShutdownTimeout: time.Duration(config.ShutdownTimeout) * time.Second,
+		}
+		registryTLS := biregistry.TLS{Certificate: config.TLS.Certificate, PrivateKey: config.TLS.PrivateKey, ClientCA: config.TLS.ClientCA}
+		registryServer, err := i.registryServerManager.Start(config.Username, config.Password, config.ListenHost(), config.Port, limits, registryTLS, i.registryStore, config.AuditLog, biregistry.SettingsEnvelope(config.SettingsEnvelope))
 		if err != nil {
 			return bosherr.WrapError(err, "Starting registry")
 		}
@@ -82,6 +97,25 @@ func (i *installation) StartRegistry() error {
 	return nil
 }
 
+// SetRegistrySettingsOverlay does nothing without a registry, the agent
+// then gets its settings from elsewhere
+func (i *installation) SetRegistrySettingsOverlay(overlayJSON []byte) error {
+	if i.manifest.Registry.IsEmpty() {
+		return nil
+	}
+
+	if i.registryServer == nil {
+		return bosherr.Error("Registry must be started before its settings can be overlaid")
+	}
+
+	err := i.registryServer.SetSettingsOverlay(overlayJSON)
+	if err != nil {
+		return bosherr.WrapError(err, "Overlaying registry settings")
+	}
+
+	return nil
+}
+
 func (i *installation) StopRegistry() error {
 	if !i.manifest.Registry.IsEmpty() {
 		if i.registryServer == nil {
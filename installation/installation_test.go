@@ -3,10 +3,12 @@ package installation_test
 import (
 	"errors"
 	"log"
+	"time"
 
 	. "github.com/cloudfoundry/bosh-cli/installation"
 
 	biinstallmanifest "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	mock_registry "github.com/cloudfoundry/bosh-cli/registry/mocks"
 	test_support_mocks "github.com/cloudfoundry/bosh-cli/test_support/mocks"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
@@ -32,13 +34,14 @@ var _ = Describe("Installation", func() {
 		manifest                  biinstallmanifest.Manifest
 		mockRegistryServerManager *mock_registry.MockServerManager
 		mockRegistryServer        *mock_registry.MockServer
+		registryStore             biregistry.Store
 
 		target       Target
 		installedJob InstalledJob
 	)
 
 	var newInstalation = func() Installation {
-		return NewInstallation(target, installedJob, manifest, mockRegistryServerManager)
+		return NewInstallation(target, installedJob, manifest, mockRegistryServerManager, registryStore)
 	}
 
 	BeforeEach(func() {
@@ -46,6 +49,7 @@ var _ = Describe("Installation", func() {
 
 		mockRegistryServerManager = mock_registry.NewMockServerManager(mockCtrl)
 		mockRegistryServer = mock_registry.NewMockServer(mockCtrl)
+		registryStore = biregistry.NewMemoryStore()
 
 		target = NewTarget("fake-installation-path")
 
@@ -70,7 +74,7 @@ var _ = Describe("Installation", func() {
 			}
 
 			spy = test_support_mocks.NewMockSpy(mockCtrl)
-			startCall = mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+			startCall = mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{}, biregistry.TLS{}, registryStore, "", biregistry.SettingsEnvelope("")).Return(mockRegistryServer, nil)
 			spyCall = spy.EXPECT().Record()
 			stopCall = mockRegistryServer.EXPECT().Stop().Return(nil)
 
@@ -184,7 +188,33 @@ var _ = Describe("Installation", func() {
 			})
 
 			It("starts the registry", func() {
-				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{}, biregistry.TLS{}, registryStore, "", biregistry.SettingsEnvelope("")).Return(mockRegistryServer, nil)
+
+				err := newInstalation().StartRegistry()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("starts the registry with the configured TLS", func() {
+				manifest.Registry.TLS = biinstallmanifest.RegistryTLS{Certificate: "fake-cert", PrivateKey: "fake-key", CA: "fake-ca", ClientCA: "fake-client-ca"}
+				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{}, biregistry.TLS{Certificate: "fake-cert", PrivateKey: "fake-key", ClientCA: "fake-client-ca"}, registryStore, "", biregistry.SettingsEnvelope("")).Return(mockRegistryServer, nil)
+
+				err := newInstalation().StartRegistry()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("starts the registry with the configured limits", func() {
+				manifest.Registry.MaxSettingsSize = 4096
+				manifest.Registry.MaxInstances = 3
+				manifest.Registry.ShutdownTimeout = 30
+				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{MaxSettingsSize: 4096, MaxInstances: 3, ShutdownTimeout: 30 * time.Second}, biregistry.TLS{}, registryStore, "", biregistry.SettingsEnvelope("")).Return(mockRegistryServer, nil)
+
+				err := newInstalation().StartRegistry()
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("starts the registry with the audit log", func() {
+				manifest.Registry.AuditLog = "/fake-audit.log"
+				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{}, biregistry.TLS{}, registryStore, "/fake-audit.log", biregistry.SettingsEnvelope("")).Return(mockRegistryServer, nil)
 
 				err := newInstalation().StartRegistry()
 				Expect(err).NotTo(HaveOccurred())
@@ -192,7 +222,7 @@ var _ = Describe("Installation", func() {
 
 			Context("when starting registry fails", func() {
 				BeforeEach(func() {
-					mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(nil, errors.New("fake-registry-start-error"))
+					mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{}, biregistry.TLS{}, registryStore, "", biregistry.SettingsEnvelope("")).Return(nil, errors.New("fake-registry-start-error"))
 				})
 
 				It("returns an error", func() {
@@ -229,7 +259,7 @@ var _ = Describe("Installation", func() {
 
 				installation = newInstalation()
 
-				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123).Return(mockRegistryServer, nil)
+				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{}, biregistry.TLS{}, registryStore, "", biregistry.SettingsEnvelope("")).Return(mockRegistryServer, nil)
 				err := installation.StartRegistry()
 				Expect(err).ToNot(HaveOccurred())
 			})
@@ -270,4 +300,43 @@ var _ = Describe("Installation", func() {
 			})
 		})
 	})
+
+	Describe("SetRegistrySettingsOverlay", func() {
+		Context("when registry is configured", func() {
+			BeforeEach(func() {
+				manifest.Registry = biinstallmanifest.Registry{
+					Username: "fake-username",
+					Password: "fake-password",
+					Host:     "fake-host",
+					Port:     123,
+				}
+			})
+
+			It("sets the overlay of the running registry", func() {
+				installation := newInstalation()
+
+				mockRegistryServerManager.EXPECT().Start("fake-username", "fake-password", "fake-host", 123, biregistry.Limits{}, biregistry.TLS{}, registryStore, "", biregistry.SettingsEnvelope("")).Return(mockRegistryServer, nil)
+				err := installation.StartRegistry()
+				Expect(err).ToNot(HaveOccurred())
+
+				mockRegistryServer.EXPECT().SetSettingsOverlay([]byte(`{"disks":{}}`)).Return(nil)
+
+				err = installation.SetRegistrySettingsOverlay([]byte(`{"disks":{}}`))
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("returns an error when the registry has not been started", func() {
+				err := newInstalation().SetRegistrySettingsOverlay([]byte(`{"disks":{}}`))
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Registry must be started before its settings can be overlaid"))
+			})
+		})
+
+		Context("when registry config is empty", func() {
+			It("does nothing", func() {
+				err := newInstalation().SetRegistrySettingsOverlay([]byte(`{"disks":{}}`))
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
 })
@@ -33,6 +33,7 @@ type installer struct {
 	packageCompiler       PackageCompiler
 	blobExtractor         blobextract.Extractor
 	registryServerManager biregistry.ServerManager
+	registryStore         biregistry.Store
 	logger                boshlog.Logger
 	logTag                string
 }
@@ -44,6 +45,7 @@ func NewInstaller(
 	packageCompiler PackageCompiler,
 	blobExtractor blobextract.Extractor,
 	registryServerManager biregistry.ServerManager,
+	registryStore biregistry.Store,
 	logger boshlog.Logger,
 ) Installer {
 	return &installer{
@@ -53,6 +55,7 @@ func NewInstaller(
 		packageCompiler:       packageCompiler,
 		blobExtractor:         blobExtractor,
 		registryServerManager: registryServerManager,
+		registryStore:         registryStore,
 		logger:                logger,
 		logTag:                "installer",
 	}
@@ -95,6 +98,7 @@ func (i *installer) Install(manifest biinstallmanifest.Manifest, stage biui.Stag
 		installedJob,
 		manifest,
 		i.registryServerManager,
+		i.registryStore,
 	), nil
 }
 
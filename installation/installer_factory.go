@@ -4,6 +4,7 @@ import (
 	bideplrel "github.com/cloudfoundry/bosh-cli/deployment/release"
 	biindex "github.com/cloudfoundry/bosh-cli/index"
 	"github.com/cloudfoundry/bosh-cli/installation/blobextract"
+	biinstallblob "github.com/cloudfoundry/bosh-cli/installation/blobstore"
 	biinstallpkg "github.com/cloudfoundry/bosh-cli/installation/pkg"
 	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	bistatejob "github.com/cloudfoundry/bosh-cli/state/job"
@@ -21,6 +22,9 @@ import (
 
 type InstallerFactory interface {
 	NewInstaller(Target) Installer
+	// NewInstallerWithCompiledPackagesBlobstore also shares compiled packages
+	// through the blobstore at location, a directory or an S3 location
+	NewInstallerWithCompiledPackagesBlobstore(target Target, location string) Installer
 }
 
 type installerFactory struct {
@@ -34,6 +38,8 @@ type installerFactory struct {
 	logTag                 string
 	fs                     boshsys.FileSystem
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	compiledPackageCache   biinstallpkg.CompiledPackageCache
+	compileConcurrency     int
 }
 
 func NewInstallerFactory(
@@ -46,6 +52,8 @@ func NewInstallerFactory(
 	logger boshlog.Logger,
 	fs boshsys.FileSystem,
 	digestCreateAlgorithms []boshcrypto.Algorithm,
+	compiledPackageCache biinstallpkg.CompiledPackageCache,
+	compileConcurrency int,
 ) InstallerFactory {
 	return &installerFactory{
 		ui:                     ui,
@@ -58,10 +66,26 @@ func NewInstallerFactory(
 		logTag:                 "installer",
 		fs:                     fs,
 		digestCreateAlgorithms: digestCreateAlgorithms,
+		compiledPackageCache:   compiledPackageCache,
+		compileConcurrency:     compileConcurrency,
 	}
 }
 
 func (f *installerFactory) NewInstaller(target Target) Installer {
+	return f.newInstaller(target, f.compiledPackageCache)
+}
+
+func (f *installerFactory) NewInstallerWithCompiledPackagesBlobstore(target Target, location string) Installer {
+	compiledPackageCache := f.compiledPackageCache
+
+	if compiledPackageCache != nil && location != "" {
+		compiledPackageCache = compiledPackageCache.WithBlobstore(biinstallblob.NewBlobstore(location, f.fs, f.logger))
+	}
+
+	return f.newInstaller(target, compiledPackageCache)
+}
+
+func (f *installerFactory) newInstaller(target Target, compiledPackageCache biinstallpkg.CompiledPackageCache) Installer {
 	context := &installerFactoryContext{
 		target:                 target,
 		runner:                 f.runner,
@@ -71,6 +95,8 @@ func (f *installerFactory) NewInstaller(target Target) Installer {
 		releaseJobResolver:     f.releaseJobResolver,
 		fs:                     f.fs,
 		digestCreateAlgorithms: f.digestCreateAlgorithms,
+		compiledPackageCache:   compiledPackageCache,
+		compileConcurrency:     f.compileConcurrency,
 	}
 
 	return NewInstaller(
@@ -80,6 +106,7 @@ func (f *installerFactory) NewInstaller(target Target) Installer {
 		context.PackageCompiler(),
 		context.BlobExtractor(),
 		f.registryServerManager,
+		biregistry.NewFileStore(f.fs, target.RegistryPath(), f.logger),
 		f.logger,
 	)
 }
@@ -99,6 +126,8 @@ type installerFactoryContext struct {
 	blobExtractor          blobextract.Extractor
 	compiledPackageRepo    bistatepkg.CompiledPackageRepo
 	digestCreateAlgorithms []boshcrypto.Algorithm
+	compiledPackageCache   biinstallpkg.CompiledPackageCache
+	compileConcurrency     int
 }
 
 func (c *installerFactoryContext) JobRenderer() JobRenderer {
@@ -130,8 +159,9 @@ func (c *installerFactoryContext) JobDependencyCompiler() bistatejob.DependencyC
 		return c.jobDependencyCompiler
 	}
 
-	c.jobDependencyCompiler = bistatejob.NewDependencyCompiler(
+	c.jobDependencyCompiler = bistatejob.NewDependencyCompilerWithConcurrency(
 		c.InstallationStatePackageCompiler(),
+		c.compileConcurrency,
 		c.logger,
 	)
 
@@ -151,6 +181,7 @@ func (c *installerFactoryContext) InstallationStatePackageCompiler() bistatepkg.
 		c.Blobstore(),
 		c.CompiledPackageRepo(),
 		c.BlobExtractor(),
+		c.compiledPackageCache,
 		c.logger,
 	)
 
@@ -9,6 +9,7 @@ import (
 
 	"github.com/cloudfoundry/bosh-cli/installation/blobextract/fakeblobextract"
 	mock_install "github.com/cloudfoundry/bosh-cli/installation/mocks"
+	biregistry "github.com/cloudfoundry/bosh-cli/registry"
 	mock_registry "github.com/cloudfoundry/bosh-cli/registry/mocks"
 	"github.com/golang/mock/gomock"
 
@@ -72,6 +73,7 @@ var _ = Describe("Installer", func() {
 			mockPackageCompiler,
 			fakeExtractor,
 			mockRegistryServerManager,
+			biregistry.NewMemoryStore(),
 			logger,
 		)
 	})
@@ -146,6 +148,7 @@ var _ = Describe("Installer", func() {
 				installedJob,
 				installationManifest,
 				mockRegistryServerManager,
+				biregistry.NewMemoryStore(),
 			)
 		})
 
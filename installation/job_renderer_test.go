@@ -1,11 +1,18 @@
 package installation_test
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
 	fakeboshblob "github.com/cloudfoundry/bosh-utils/blobstore/fakes"
 	fakeboshcmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakeboshsys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -16,6 +23,7 @@ import (
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
 	. "github.com/cloudfoundry/bosh-cli/release/resource"
 	bitemplate "github.com/cloudfoundry/bosh-cli/templatescompiler"
+	bierbrenderer "github.com/cloudfoundry/bosh-cli/templatescompiler/erbrenderer"
 	mock_template "github.com/cloudfoundry/bosh-cli/templatescompiler/mocks"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
@@ -106,7 +114,7 @@ var _ = Describe("JobRenderer", func() {
 		renderedJobList = bitemplate.NewRenderedJobList()
 		renderedJobList.Add(bitemplate.NewRenderedJob(releaseJob, "/fake-rendered-job-cpi", fs, logger))
 
-		mockJobListRenderer.EXPECT().Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, deploymentName, address).Return(renderedJobList, nil).AnyTimes()
+		mockJobListRenderer.EXPECT().Render(releaseJobs, releaseJobProperties, nil, jobProperties, globalProperties, deploymentName, address).Return(renderedJobList, nil).AnyTimes()
 
 		fakeCompressor.CompressFilesInDirTarballPath = "/fake-rendered-job-tarball-cpi.tgz"
 		multiDigest := boshcrypto.MustParseMultipleDigest("fakerenderedjobtarballsha1cpi")
@@ -150,3 +158,140 @@ var _ = Describe("JobRenderer", func() {
 		})
 	})
 })
+
+var _ = Describe("JobRenderer with job templates", func() {
+	var (
+		tmpDir         string
+		fs             boshsys.FileSystem
+		logger         boshlog.Logger
+		fakeCompressor *fakeboshcmd.FakeCompressor
+		fakeBlobstore  *fakeboshblob.FakeDigestBlobstore
+		fakeStage      *fakebiui.FakeStage
+
+		releaseJob bireljob.Job
+		manifest   biinstallmanifest.Manifest
+		rendered   string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "installation-job-renderer")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+
+		jobPath := filepath.Join(tmpDir, "cpi")
+		Expect(os.MkdirAll(filepath.Join(jobPath, "templates"), 0755)).To(Succeed())
+		Expect(ioutil.WriteFile(filepath.Join(jobPath, "monit"), []byte{}, 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(
+			filepath.Join(jobPath, "templates", "cpi.conf.erb"),
+			[]byte("region=<%= p('aws.region') %>\ntimeout=<%= p('aws.timeout') %>\n"),
+			0644,
+		)).To(Succeed())
+
+		job := bireljob.NewExtractedJob(NewResource("cpi", "fake-release-job-fingerprint", nil), jobPath, fs)
+		job.Templates = map[string]string{"cpi.conf.erb": "config/cpi.conf"}
+		job.Properties = map[string]bireljob.PropertyDefinition{
+			"aws.region":  {},
+			"aws.timeout": {Default: 30},
+		}
+		releaseJob = *job
+
+		manifest = biinstallmanifest.Manifest{
+			Name: "fake-installation-name",
+			Properties: biproperty.Map{
+				"aws": biproperty.Map{"region": "us-east-1"},
+			},
+		}
+
+		fakeCompressor = fakeboshcmd.NewFakeCompressor()
+		fakeCompressor.CompressFilesInDirTarballPath = "/fake-rendered-job-tarball-cpi.tgz"
+		fakeCompressor.CompressFilesInDirCallBack = func() {
+			contents, err := ioutil.ReadFile(filepath.Join(fakeCompressor.CompressFilesInDirDir, "config", "cpi.conf"))
+			Expect(err).ToNot(HaveOccurred())
+			rendered = string(contents)
+		}
+
+		fakeBlobstore = &fakeboshblob.FakeDigestBlobstore{}
+		fakeBlobstore.CreateReturns("fake-blob-id", boshcrypto.MustParseMultipleDigest("fakesha1"), nil)
+
+		fakeStage = fakebiui.NewFakeStage()
+		rendered = ""
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	newRenderer := func(erbRenderer bierbrenderer.ERBRenderer) installation.JobRenderer {
+		jobRenderer := bitemplate.NewJobRenderer(erbRenderer, fs, fakeuuid.NewFakeGenerator(), logger)
+		return installation.NewJobRenderer(bitemplate.NewJobListRenderer(jobRenderer, logger), fakeCompressor, fakeBlobstore)
+	}
+
+	It("hands cloud_provider.properties and the job spec defaults to the templates", func() {
+		renderer := newRenderer(contextWritingERBRenderer{})
+
+		_, err := renderer.RenderAndUploadFrom(manifest, []bireljob.Job{releaseJob}, fakeStage)
+		Expect(err).ToNot(HaveOccurred())
+
+		var context bitemplate.RootContext
+		Expect(json.Unmarshal([]byte(rendered), &context)).To(Succeed())
+		Expect(context.ClusterProperties).To(Equal(biproperty.Map{
+			"aws": map[string]interface{}{"region": "us-east-1"},
+		}))
+		Expect(context.DefaultProperties).To(Equal(biproperty.Map{
+			"aws.region":  nil,
+			"aws.timeout": float64(30),
+		}))
+		Expect(context.GlobalProperties).To(BeEmpty())
+	})
+
+	Context("when rendering with ruby", func() {
+		var renderer installation.JobRenderer
+
+		BeforeEach(func() {
+			renderer = newRenderer(bierbrenderer.NewERBRenderer(fs, boshsys.NewExecCmdRunner(logger), logger))
+		})
+
+		It("renders properties from cloud_provider.properties, defaulting from the job spec", func() {
+			_, err := renderer.RenderAndUploadFrom(manifest, []bireljob.Job{releaseJob}, fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rendered).To(Equal("region=us-east-1\ntimeout=30\n"))
+		})
+
+		It("prefers cloud_provider.properties over the job spec defaults", func() {
+			manifest.Properties = biproperty.Map{
+				"aws": biproperty.Map{"region": "us-east-1", "timeout": 60},
+			}
+
+			_, err := renderer.RenderAndUploadFrom(manifest, []bireljob.Job{releaseJob}, fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(rendered).To(Equal("region=us-east-1\ntimeout=60\n"))
+		})
+
+		It("returns an error naming a required property missing from cloud_provider.properties", func() {
+			manifest.Properties = biproperty.Map{}
+
+			_, err := renderer.RenderAndUploadFrom(manifest, []bireljob.Job{releaseJob}, fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Rendering job templates for installation"))
+			Expect(err.Error()).To(ContainSubstring("Rendering template src: cpi.conf.erb, dst: config/cpi.conf"))
+			Expect(err.Error()).To(ContainSubstring("Can't find property 'aws.region'"))
+			Expect(fakeBlobstore.CreateCallCount()).To(Equal(0))
+		})
+	})
+})
+
+// contextWritingERBRenderer writes the evaluation context instead of
+// rendering the template, so that tests see what templates are rendered with
+type contextWritingERBRenderer struct{}
+
+func (contextWritingERBRenderer) Render(_, dstPath string, context bierbrenderer.TemplateEvaluationContext) error {
+	contextBytes, err := json.Marshal(context)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(dstPath, contextBytes, 0644)
+}
@@ -0,0 +1,189 @@
+package manifest
+
+import (
+	"path"
+	"sort"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+const (
+	AgentBlobstoreLocal = "local"
+	AgentBlobstoreDav   = "dav"
+	AgentBlobstoreS3    = "s3"
+	AgentBlobstoreGCS   = "gcs"
+)
+
+// AgentBlobstore is the blobstore the deployed agent is configured with,
+// in the {provider, options} structure the agent expects in its settings.
+type AgentBlobstore struct {
+	Provider string
+	Options  biproperty.Map
+
+	// ProviderDefaulted is set when the manifest gave no provider, e.g. for
+	// flat properties read by CPI job templates, so that the options are only
+	// checked as far as they are known
+	ProviderDefaulted bool
+}
+
+func (b AgentBlobstore) IsEmpty() bool {
+	return b.Provider == "" && len(b.Options) == 0
+}
+
+// Location identifies the blobstore for the providers that keep blobs apart
+// from the VM, e.g. 'dav http://10.0.0.6:25250'. It is empty for the local
+// blobstore and for blobstores without a provider, which are left to the CPI
+// job templates, since their blobs may be deleted with the VM.
+func (b AgentBlobstore) Location() string {
+	if b.ProviderDefaulted {
+		return ""
+	}
+
+	var location string
+
+	switch b.Provider {
+	case AgentBlobstoreDav:
+		location, _ = b.Options["endpoint"].(string)
+	case AgentBlobstoreS3:
+		host, _ := b.Options["host"].(string)
+		bucketName, _ := b.Options["bucket_name"].(string)
+		location = path.Join(host, bucketName)
+	case AgentBlobstoreGCS:
+		location, _ = b.Options["bucket_name"].(string)
+	}
+
+	if location == "" {
+		return ""
+	}
+
+	return b.Provider + " " + location
+}
+
+// CACert is the tls.cert.ca option of a dav blobstore
+func (b AgentBlobstore) CACert() string {
+	tls, _ := b.Options["tls"].(biproperty.Map)
+	cert, _ := tls["cert"].(biproperty.Map)
+	ca, _ := cert["ca"].(string)
+	return ca
+}
+
+type agentBlobstoreOptionType int
+
+const (
+	agentBlobstoreString agentBlobstoreOptionType = iota
+	agentBlobstoreBool
+	agentBlobstoreInt
+	agentBlobstoreMap
+)
+
+type agentBlobstoreOption struct {
+	Type     agentBlobstoreOptionType
+	Required bool
+}
+
+var agentBlobstoreOptions = map[string]map[string]agentBlobstoreOption{
+	AgentBlobstoreLocal: {
+		"blobstore_path": {Type: agentBlobstoreString, Required: true},
+	},
+	AgentBlobstoreDav: {
+		"endpoint": {Type: agentBlobstoreString, Required: true},
+		"user":     {Type: agentBlobstoreString},
+		"password": {Type: agentBlobstoreString},
+		"tls":      {Type: agentBlobstoreMap},
+	},
+	AgentBlobstoreS3: {
+		"bucket_name":            {Type: agentBlobstoreString, Required: true},
+		"credentials_source":     {Type: agentBlobstoreString},
+		"access_key_id":          {Type: agentBlobstoreString},
+		"secret_access_key":      {Type: agentBlobstoreString},
+		"region":                 {Type: agentBlobstoreString},
+		"host":                   {Type: agentBlobstoreString},
+		"port":                   {Type: agentBlobstoreInt},
+		"use_ssl":                {Type: agentBlobstoreBool},
+		"ssl_verify_peer":        {Type: agentBlobstoreBool},
+		"signature_version":      {Type: agentBlobstoreString},
+		"server_side_encryption": {Type: agentBlobstoreString},
+		"sse_kms_key_id":         {Type: agentBlobstoreString},
+	},
+	AgentBlobstoreGCS: {
+		"bucket_name":        {Type: agentBlobstoreString, Required: true},
+		"credentials_source": {Type: agentBlobstoreString},
+		"json_key":           {Type: agentBlobstoreString},
+		"storage_class":      {Type: agentBlobstoreString},
+		"encryption_key":     {Type: agentBlobstoreString},
+	},
+}
+
+func agentBlobstoreProviders() []string {
+	providers := []string{}
+	for provider := range agentBlobstoreOptions {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// defaultAgentBlobstoreProvider guesses the provider from the options
+// when the manifest does not name it
+func defaultAgentBlobstoreProvider(options biproperty.Map) string {
+	if _, found := options["endpoint"]; found {
+		return AgentBlobstoreDav
+	}
+
+	if _, found := options["bucket_name"]; found {
+		for _, name := range []string{"json_key", "storage_class", "encryption_key"} {
+			if _, found := options[name]; found {
+				return AgentBlobstoreGCS
+			}
+		}
+		return AgentBlobstoreS3
+	}
+
+	return AgentBlobstoreLocal
+}
+
+// agentBlobstoreFromProperties reads cloud_provider.properties.agent.blobstore.
+// Options may either be nested under 'options' or given next to 'provider';
+// both forms are normalized to the nested form in a copy, the properties
+// are left as they are for the CPI job templates.
+func agentBlobstoreFromProperties(properties biproperty.Map) (AgentBlobstore, bool) {
+	agent, ok := properties["agent"].(biproperty.Map)
+	if !ok {
+		return AgentBlobstore{}, false
+	}
+
+	blobstore, ok := agent["blobstore"].(biproperty.Map)
+	if !ok {
+		return AgentBlobstore{}, false
+	}
+
+	provider, _ := blobstore["provider"].(string)
+	options := biproperty.Map{}
+
+	if nested, ok := blobstore["options"].(biproperty.Map); ok {
+		for key, value := range nested {
+			options[key] = value
+		}
+	}
+
+	for key, value := range blobstore {
+		if key != "provider" && key != "options" {
+			options[key] = value
+		}
+	}
+
+	if provider == "" {
+		return AgentBlobstore{Provider: defaultAgentBlobstoreProvider(options), Options: options, ProviderDefaulted: true}, true
+	}
+
+	return AgentBlobstore{Provider: provider, Options: options}, true
+}
+
+func (m *Manifest) populateAgentBlobstore() {
+	blobstore, found := agentBlobstoreFromProperties(m.Properties)
+	if !found {
+		return
+	}
+
+	m.AgentBlobstore = blobstore
+}
@@ -0,0 +1,28 @@
+package manifest_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/installation/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+var _ = Describe("AgentBlobstore", func() {
+	Describe("Location", func() {
+		It("identifies blobstores that keep blobs apart from the VM", func() {
+			Expect(AgentBlobstore{Provider: "dav", Options: biproperty.Map{"endpoint": "http://10.0.0.6:25250"}}.Location()).To(Equal("dav http://10.0.0.6:25250"))
+			Expect(AgentBlobstore{Provider: "s3", Options: biproperty.Map{"bucket_name": "fake-bucket"}}.Location()).To(Equal("s3 fake-bucket"))
+			Expect(AgentBlobstore{Provider: "s3", Options: biproperty.Map{"bucket_name": "fake-bucket", "host": "s3.example.com"}}.Location()).To(Equal("s3 s3.example.com/fake-bucket"))
+			Expect(AgentBlobstore{Provider: "gcs", Options: biproperty.Map{"bucket_name": "fake-bucket"}}.Location()).To(Equal("gcs fake-bucket"))
+		})
+
+		It("is empty for the local blobstore", func() {
+			Expect(AgentBlobstore{Provider: "local", Options: biproperty.Map{"blobstore_path": "/var/vcap/micro_bosh/data/cache"}}.Location()).To(BeEmpty())
+		})
+
+		It("is empty for blobstores without a provider", func() {
+			Expect(AgentBlobstore{Provider: "dav", ProviderDefaulted: true, Options: biproperty.Map{"endpoint": "http://10.0.0.6:25250"}}.Location()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,36 @@
+package manifest
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+// CPI configures the installed CPI for the listed AZs. Properties are sent
+// in the context of every CPI call, so one installed CPI can talk to
+// different endpoints or use different credentials per AZ.
+type CPI struct {
+	Name       string
+	AZs        []string
+	Properties biproperty.Map
+}
+
+func (m Manifest) FindCPIByAZ(az string) (CPI, bool) {
+	for _, cpi := range m.CPIs {
+		for _, cpiAZ := range cpi.AZs {
+			if cpiAZ == az {
+				return cpi, true
+			}
+		}
+	}
+
+	return CPI{}, false
+}
+
+func (m Manifest) FindCPIByName(name string) (CPI, bool) {
+	for _, cpi := range m.CPIs {
+		if cpi.Name == name {
+			return cpi, true
+		}
+	}
+
+	return CPI{}, false
+}
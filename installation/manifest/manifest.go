@@ -1,20 +1,107 @@
 package manifest
 
 import (
+	"net"
+
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
 type Manifest struct {
-	Name       string
-	Template   ReleaseJobRef
-	Properties biproperty.Map
-	Mbus       string
-	Cert       Certificate
-	Registry   Registry
+	Name             string
+	Template         ReleaseJobRef
+	Properties       biproperty.Map
+	Mbus             string
+	Cert             Certificate
+	Registry         Registry
+	PostDeployChecks []PostDeployCheck
+	CleanupJob       CleanupJob
+	AgentBlobstore   AgentBlobstore
+	UserData         UserData
+	CPIs             []CPI
+	CPIRetries       CPIRetries
+	CPIThrottle      CPIThrottle
+
+	// CompiledPackagesBlobstore shares compiled packages between
+	// workstations, a directory or an S3 location
+	CompiledPackagesBlobstore string
 }
 
 type Certificate struct {
 	CA string
+
+	// Fingerprint pins the SHA-256 fingerprint of the agent certificate
+	// instead of pinning the one seen on first contact
+	Fingerprint string
+}
+
+const (
+	PostDeployCheckTCP  = "tcp"
+	PostDeployCheckHTTP = "http"
+)
+
+// PostDeployCheck describes an endpoint on the deployed VM that must be
+// reachable before the deploy is considered successful.
+// Host defaults to the first static IP of the deployed instance.
+// InsecureSkipVerify skips verifying the certificate of an https endpoint.
+type PostDeployCheck struct {
+	Type               string
+	Host               string
+	Port               int
+	Scheme             string
+	Path               string
+	ExpectedStatus     int `yaml:"expected_status"`
+	Timeout            int
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+const (
+	CleanupJobOnFailureFail     = "fail"
+	CleanupJobOnFailureContinue = "continue"
+)
+
+// CleanupJob is run on the VM before delete-env deletes it, e.g. to
+// deregister the environment from external systems. The agent runs
+// bin/<Script> of every job on the VM that has one. Timeout defaults to
+// 300 seconds and OnFailure to 'fail'.
+type CleanupJob struct {
+	Script    string
+	Timeout   int
+	OnFailure string `yaml:"on_failure"`
+}
+
+func (j CleanupJob) IsEmpty() bool {
+	return j == CleanupJob{}
+}
+
+// CPIRetries retries CPI calls that fail transiently, e.g. on IaaS rate
+// limits. Delays are in seconds and default to 5 and 60. Methods default to
+// create_vm and create_disk. Errors are retried when the CPI marks them
+// ok_to_retry, or when their type is one of ErrorTypes or their message
+// matches one of the ErrorMessages regular expressions.
+type CPIRetries struct {
+	MaxAttempts   int      `yaml:"max_attempts"`
+	InitialDelay  int      `yaml:"initial_delay"`
+	MaxDelay      int      `yaml:"max_delay"`
+	Methods       []string `yaml:"methods"`
+	ErrorTypes    []string `yaml:"error_types"`
+	ErrorMessages []string `yaml:"error_messages"`
+}
+
+func (r CPIRetries) IsEmpty() bool {
+	return r.MaxAttempts == 0 && r.InitialDelay == 0 && r.MaxDelay == 0 &&
+		len(r.Methods) == 0 && len(r.ErrorTypes) == 0 && len(r.ErrorMessages) == 0
+}
+
+// CPIThrottle limits how many CPI calls run at the same time, e.g. to stay
+// below IaaS rate limits. MaxConcurrent limits all calls, Methods the calls
+// of single CPI methods, e.g. create_vm: 1. Zero does not limit.
+type CPIThrottle struct {
+	MaxConcurrent int            `yaml:"max_concurrent"`
+	Methods       map[string]int `yaml:"methods"`
+}
+
+func (t CPIThrottle) IsEmpty() bool {
+	return t.MaxConcurrent == 0 && len(t.Methods) == 0
 }
 
 type ReleaseJobRef struct {
@@ -25,21 +112,109 @@ type ReleaseJobRef struct {
 type Registry struct {
 	Username  string
 	Password  string
-	Host      string
 	Port      int
 	SSHTunnel SSHTunnel
+
+	// Host is the address agents dial, 127.0.0.1 through the SSH tunnel
+	Host string
+
+	// BindAddress is the address the registry listens on, e.g. 0.0.0.0, ::
+	// or the IP of one interface. Empty listens on Host.
+	BindAddress string
+
+	// MaxSettingsSize and MaxInstances limit what the registry accepts,
+	// zero uses the registry defaults
+	MaxSettingsSize int64
+	MaxInstances    int
+
+	// ShutdownTimeout is how many seconds stopping the registry waits for
+	// agent requests in flight, zero uses the registry default
+	ShutdownTimeout int
+
+	// AuditLog is the path of the file every registry request is appended
+	// to, empty only logs requests at debug level
+	AuditLog string
+
+	// SettingsEnvelope is how settings are wrapped in the envelope of the
+	// EC2 registry: wrap (the default), ec2 or none
+	SettingsEnvelope string
+
+	TLS RegistryTLS
+}
+
+// RegistryTLS serves the registry over HTTPS. CA is passed to the CPI
+// as registry.ca_cert so that agents can verify the registry.
+type RegistryTLS struct {
+	Certificate string
+	PrivateKey  string
+	CA          string
+	ClientCA    string
+}
+
+func (t RegistryTLS) IsEmpty() bool {
+	return t == RegistryTLS{}
 }
 
 func (r Registry) IsEmpty() bool {
 	return r == Registry{}
 }
 
+// HasSSHTunnel is false when agents reach the registry directly on Host
+func (r Registry) HasSSHTunnel() bool {
+	return r.SSHTunnel != SSHTunnel{}
+}
+
+// ListenHost returns the address the registry listens on
+func (r Registry) ListenHost() string {
+	if r.BindAddress != "" {
+		return r.BindAddress
+	}
+
+	return r.Host
+}
+
+// LocalHost returns the address the registry is reached on from the machine
+// running it, the loopback address when it listens on all interfaces
+func (r Registry) LocalHost() string {
+	host := r.ListenHost()
+
+	ip := net.ParseIP(host)
+	if ip == nil || !ip.IsUnspecified() {
+		return host
+	}
+
+	if ip.To4() != nil {
+		return "127.0.0.1"
+	}
+
+	return "::1"
+}
+
+// PopulateRegistryTLS makes the CPI hand an https registry endpoint to the agent
+func (m *Manifest) PopulateRegistryTLS(tls RegistryTLS) {
+	m.Registry.TLS = tls
+
+	registryProperties, ok := m.Properties["registry"].(biproperty.Map)
+	if !ok {
+		return
+	}
+
+	registryProperties["protocol"] = "https"
+	if tls.CA != "" {
+		registryProperties["ca_cert"] = tls.CA
+	}
+}
+
 type SSHTunnel struct {
 	User       string
 	Host       string
 	Port       int
 	Password   string
 	PrivateKey string `yaml:"private_key"`
+
+	// KeepAliveInterval is how many seconds apart keepalives are sent to
+	// detect a dropped connection and reconnect, zero uses the default
+	KeepAliveInterval int `yaml:"keepalive_interval"`
 }
 
 func (m *Manifest) PopulateRegistry(username string, password string, host string, port int, sshTunnel SSHTunnel) {
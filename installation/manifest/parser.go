@@ -2,7 +2,9 @@ package manifest
 
 import (
 	"encoding/pem"
+	"net"
 	"strings"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -13,10 +15,19 @@ import (
 	"gopkg.in/yaml.v2"
 
 	biutil "github.com/cloudfoundry/bosh-cli/common/util"
+	bivalidation "github.com/cloudfoundry/bosh-cli/common/validation"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
+	biinstallblob "github.com/cloudfoundry/bosh-cli/installation/blobstore"
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 )
 
+// registryCertificateValidity only has to cover one create-env or delete-env,
+// since a new certificate is generated whenever the manifest is parsed
+const registryCertificateValidity = 7 * 24 * time.Hour
+
+const defaultRegistryPort = 6901
+
 type Parser interface {
 	Parse(string, boshtpl.Variables, patch.Op, birelsetmanifest.Manifest) (Manifest, error)
 }
@@ -35,17 +46,52 @@ type manifest struct {
 }
 
 type installation struct {
-	Template   template
+	Template         template
+	Properties       map[interface{}]interface{}
+	SSHTunnel        SSHTunnel      `yaml:"ssh_tunnel"`
+	Registry         registryConfig `yaml:"registry"`
+	Mbus             string
+	Cert             Certificate
+	PostDeployChecks []PostDeployCheck `yaml:"post_deploy_checks"`
+	CleanupJob       CleanupJob        `yaml:"cleanup_job"`
+	UserData         UserData          `yaml:"user_data"`
+	CPIs             []cpi             `yaml:"cpis"`
+	CPIRetries       CPIRetries        `yaml:"cpi_retries"`
+	CPIThrottle      CPIThrottle       `yaml:"cpi_throttle"`
+
+	CompiledPackagesBlobstore string `yaml:"compiled_packages_blobstore"`
+}
+
+type cpi struct {
+	Name       string
+	AZs        []string `yaml:"azs"`
 	Properties map[interface{}]interface{}
-	SSHTunnel  SSHTunnel `yaml:"ssh_tunnel"`
-	Mbus       string
-	Cert       Certificate
 }
 
 func (i installation) HasSSHTunnel() bool {
 	return i.SSHTunnel != SSHTunnel{}
 }
 
+type registryConfig struct {
+	Host             string      `yaml:"host"`
+	BindAddress      string      `yaml:"bind_address"`
+	Port             int         `yaml:"port"`
+	MaxSettingsSize  int64       `yaml:"max_settings_size"`
+	MaxInstances     int         `yaml:"max_instances"`
+	ShutdownTimeout  int         `yaml:"shutdown_timeout"`
+	AuditLog         string      `yaml:"audit_log"`
+	SettingsEnvelope string      `yaml:"settings_envelope"`
+	TLS              registryTLS `yaml:"tls"`
+}
+
+type registryTLS struct {
+	Generate    bool
+	Certificate string
+	PrivateKey  string `yaml:"private_key"`
+	CA          string
+	ClientCA    string `yaml:"client_ca"`
+}
+
 type template struct {
 	Name    string
 	Release string
@@ -109,14 +155,24 @@ func (p *parser) Parse(path string, vars boshtpl.Variables, op patch.Op, release
 		}
 	}
 
+	// fingerprints are accepted as printed by `openssl x509 -fingerprint -sha256`
+	fingerprint := strings.ToLower(strings.TrimSpace(comboManifest.CloudProvider.Cert.Fingerprint))
+	fingerprint = strings.Replace(strings.TrimPrefix(fingerprint, "sha256:"), ":", "", -1)
+	comboManifest.CloudProvider.Cert.Fingerprint = fingerprint
+
 	installationManifest := Manifest{
 		Name: comboManifest.Name,
 		Template: ReleaseJobRef{
 			Name:    comboManifest.CloudProvider.Template.Name,
 			Release: comboManifest.CloudProvider.Template.Release,
 		},
-		Mbus: comboManifest.CloudProvider.Mbus,
-		Cert: comboManifest.CloudProvider.Cert,
+		Mbus:             comboManifest.CloudProvider.Mbus,
+		Cert:             comboManifest.CloudProvider.Cert,
+		PostDeployChecks: comboManifest.CloudProvider.PostDeployChecks,
+		CleanupJob:       comboManifest.CloudProvider.CleanupJob,
+		UserData:         comboManifest.CloudProvider.UserData,
+		CPIRetries:       comboManifest.CloudProvider.CPIRetries,
+		CPIThrottle:      comboManifest.CloudProvider.CPIThrottle,
 	}
 
 	properties, err := biproperty.BuildMap(comboManifest.CloudProvider.Properties)
@@ -125,18 +181,121 @@ func (p *parser) Parse(path string, vars boshtpl.Variables, op patch.Op, release
 	}
 	installationManifest.Properties = properties
 
-	if comboManifest.CloudProvider.HasSSHTunnel() {
+	for _, rawCPI := range comboManifest.CloudProvider.CPIs {
+		cpiProperties, err := biproperty.BuildMap(rawCPI.Properties)
+		if err != nil {
+			return Manifest{}, bosherr.WrapErrorf(err, "Parsing cloud_provider.cpis '%s' properties: %#v", rawCPI.Name, rawCPI.Properties)
+		}
+
+		installationManifest.CPIs = append(installationManifest.CPIs, CPI{
+			Name:       rawCPI.Name,
+			AZs:        rawCPI.AZs,
+			Properties: cpiProperties,
+		})
+	}
+	installationManifest.populateAgentBlobstore()
+
+	compiledPackagesBlobstore := comboManifest.CloudProvider.CompiledPackagesBlobstore
+	if compiledPackagesBlobstore != "" && !biinstallblob.IsRemote(compiledPackagesBlobstore) {
+		compiledPackagesBlobstore, err = biutil.AbsolutifyPath(path, compiledPackagesBlobstore, p.fs)
+		if err != nil {
+			return Manifest{}, bosherr.WrapErrorf(err, "Expanding compiled_packages_blobstore path")
+		}
+	}
+	installationManifest.CompiledPackagesBlobstore = compiledPackagesBlobstore
+
+	bindAddress := strings.Trim(comboManifest.CloudProvider.Registry.BindAddress, "[]")
+
+	if comboManifest.CloudProvider.HasSSHTunnel() || bindAddress != "" {
 		password, err := p.uuidGenerator.Generate()
 		if err != nil {
 			return Manifest{}, bosherr.WrapError(err, "Generating registry password")
 		}
-		installationManifest.PopulateRegistry("registry", password, "127.0.0.1", 6901, comboManifest.CloudProvider.SSHTunnel)
+
+		registryPort := comboManifest.CloudProvider.Registry.Port
+		if registryPort == 0 {
+			registryPort = defaultRegistryPort
+		}
+
+		registryHost := p.registryHost(comboManifest.CloudProvider, bindAddress)
+
+		installationManifest.PopulateRegistry("registry", password, registryHost, registryPort, comboManifest.CloudProvider.SSHTunnel)
+		installationManifest.Registry.BindAddress = bindAddress
+		installationManifest.Registry.MaxSettingsSize = comboManifest.CloudProvider.Registry.MaxSettingsSize
+		installationManifest.Registry.MaxInstances = comboManifest.CloudProvider.Registry.MaxInstances
+		installationManifest.Registry.ShutdownTimeout = comboManifest.CloudProvider.Registry.ShutdownTimeout
+		installationManifest.Registry.SettingsEnvelope = comboManifest.CloudProvider.Registry.SettingsEnvelope
+
+		if comboManifest.CloudProvider.Registry.AuditLog != "" {
+			installationManifest.Registry.AuditLog, err = biutil.AbsolutifyPath(path, comboManifest.CloudProvider.Registry.AuditLog, p.fs)
+			if err != nil {
+				return Manifest{}, bosherr.WrapErrorf(err, "Expanding registry audit_log path")
+			}
+		}
+
+		registryTLS, err := p.registryTLS(comboManifest.CloudProvider.Registry.TLS, installationManifest.Registry.Host)
+		if err != nil {
+			return Manifest{}, err
+		}
+
+		if !registryTLS.IsEmpty() {
+			installationManifest.PopulateRegistryTLS(registryTLS)
+		}
 	}
 
 	err = p.validator.Validate(installationManifest, releaseSetManifest)
 	if err != nil {
-		return Manifest{}, bosherr.WrapError(err, "Validating installation manifest")
+		return Manifest{}, bivalidation.WrapError(err, "Validating installation manifest")
 	}
 
 	return installationManifest, nil
 }
+
+// registryHost returns the address agents dial to reach the registry: the
+// configured host, the far end of the SSH tunnel, or the address the registry
+// listens on. It is empty when the registry listens on all interfaces and
+// neither a host nor an SSH tunnel is given.
+func (p *parser) registryHost(cloudProvider installation, bindAddress string) string {
+	if cloudProvider.Registry.Host != "" {
+		return strings.Trim(cloudProvider.Registry.Host, "[]")
+	}
+
+	if cloudProvider.HasSSHTunnel() {
+		return "127.0.0.1"
+	}
+
+	if ip := net.ParseIP(bindAddress); ip != nil && ip.IsUnspecified() {
+		return ""
+	}
+
+	return bindAddress
+}
+
+// registryTLS generates a self-signed certificate for the address agents dial
+// when asked to and no certificate is given. The generated certificate is its own CA.
+func (p *parser) registryTLS(rawTLS registryTLS, host string) (RegistryTLS, error) {
+	registryTLS := RegistryTLS{
+		Certificate: rawTLS.Certificate,
+		PrivateKey:  rawTLS.PrivateKey,
+		CA:          rawTLS.CA,
+		ClientCA:    rawTLS.ClientCA,
+	}
+
+	if rawTLS.Generate && rawTLS.Certificate == "" && rawTLS.PrivateKey == "" {
+		hosts := []string{"localhost"}
+		if host != "" {
+			hosts = append([]string{host}, hosts...)
+		}
+
+		certificate, err := bicrypto.GenerateSelfSignedCertificate(hosts, time.Now(), registryCertificateValidity)
+		if err != nil {
+			return RegistryTLS{}, bosherr.WrapError(err, "Generating registry certificate")
+		}
+
+		registryTLS.Certificate = certificate.Certificate
+		registryTLS.PrivateKey = certificate.PrivateKey
+		registryTLS.CA = certificate.Certificate
+	}
+
+	return registryTLS, nil
+}
@@ -0,0 +1,47 @@
+package manifest
+
+import (
+	"net"
+	"net/url"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+)
+
+const (
+	UserDataFormatAWS = "aws"
+	UserDataFormatGCP = "gcp"
+)
+
+var userDataFormats = []string{UserDataFormatAWS, UserDataFormatGCP}
+
+// UserData delivers agent settings through the user data of the VM
+// instead of the registry, for CPIs that pass create_vm env to the IaaS.
+type UserData struct {
+	Format string `yaml:"format"`
+}
+
+func (u UserData) IsEmpty() bool {
+	return u == UserData{}
+}
+
+// AgentMbus is the mbus URL the agent listens on, given as
+// cloud_provider.properties.agent.mbus like for the CPI job. Without it the
+// agent listens on all interfaces at the port of cloud_provider.mbus, whose
+// host is the address the CLI connects to and may not be one of the VM.
+func (m Manifest) AgentMbus() (string, error) {
+	if agent, ok := m.Properties["agent"].(biproperty.Map); ok {
+		if mbus, ok := agent["mbus"].(string); ok && mbus != "" {
+			return mbus, nil
+		}
+	}
+
+	mbusURL, err := url.Parse(m.Mbus)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Parsing cloud_provider.mbus")
+	}
+
+	mbusURL.Host = net.JoinHostPort("0.0.0.0", mbusURL.Port())
+
+	return mbusURL.String(), nil
+}
@@ -1,11 +1,21 @@
 package manifest
 
 import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
 	"strings"
 
+	biinstallblob "github.com/cloudfoundry/bosh-cli/installation/blobstore"
 	birelsetmanifest "github.com/cloudfoundry/bosh-cli/release/set/manifest"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
 )
 
 type Validator interface {
@@ -40,6 +50,88 @@ func (v *validator) Validate(manifest Manifest, releaseSetManifest birelsetmanif
 		errs = append(errs, bosherr.Errorf("cloud_provider.template.release '%s' must refer to a release in releases", cpiReleaseName))
 	}
 
+	if !v.isBlank(manifest.Mbus) {
+		errs = append(errs, v.validateMbus(manifest.Mbus)...)
+	}
+
+	for idx, check := range manifest.PostDeployChecks {
+		errs = append(errs, v.validatePostDeployCheck(idx, check)...)
+	}
+
+	if !manifest.CleanupJob.IsEmpty() {
+		errs = append(errs, v.validateCleanupJob(manifest.CleanupJob)...)
+	}
+
+	if !manifest.CPIRetries.IsEmpty() {
+		errs = append(errs, v.validateCPIRetries(manifest.CPIRetries)...)
+	}
+
+	if !manifest.CPIThrottle.IsEmpty() {
+		errs = append(errs, v.validateCPIThrottle(manifest.CPIThrottle)...)
+	}
+
+	if biinstallblob.IsRemote(manifest.CompiledPackagesBlobstore) {
+		blobstoreURL, err := url.Parse(manifest.CompiledPackagesBlobstore)
+		if err != nil || blobstoreURL.Host == "" {
+			errs = append(errs, bosherr.Errorf("cloud_provider.compiled_packages_blobstore '%s' must be 's3://<bucket>/<prefix>' or a directory", manifest.CompiledPackagesBlobstore))
+		}
+	}
+
+	if manifest.Registry.BindAddress != "" && net.ParseIP(manifest.Registry.BindAddress) == nil {
+		errs = append(errs, bosherr.Errorf("cloud_provider.registry.bind_address '%s' must be an IPv4 or IPv6 address", manifest.Registry.BindAddress))
+	}
+
+	if manifest.Registry.BindAddress != "" && manifest.Registry.Host == "" {
+		errs = append(errs, bosherr.Errorf("cloud_provider.registry.host must be provided when the registry listens on '%s' without cloud_provider.ssh_tunnel", manifest.Registry.BindAddress))
+	}
+
+	if manifest.Registry.Host != "" && (manifest.Registry.Port < 1 || manifest.Registry.Port > 65535) {
+		errs = append(errs, bosherr.Error("cloud_provider.registry.port must be between 1 and 65535"))
+	}
+
+	if manifest.Registry.MaxSettingsSize < 0 {
+		errs = append(errs, bosherr.Error("cloud_provider.registry.max_settings_size must not be negative"))
+	}
+
+	if manifest.Registry.MaxInstances < 0 {
+		errs = append(errs, bosherr.Error("cloud_provider.registry.max_instances must not be negative"))
+	}
+
+	if manifest.Registry.ShutdownTimeout < 0 {
+		errs = append(errs, bosherr.Error("cloud_provider.registry.shutdown_timeout must not be negative"))
+	}
+
+	switch manifest.Registry.SettingsEnvelope {
+	case "", "wrap", "ec2", "none":
+	default:
+		errs = append(errs, bosherr.Errorf("cloud_provider.registry.settings_envelope '%s' must be one of wrap, ec2 or none", manifest.Registry.SettingsEnvelope))
+	}
+
+	if manifest.Registry.SSHTunnel.KeepAliveInterval < 0 {
+		errs = append(errs, bosherr.Error("cloud_provider.ssh_tunnel.keepalive_interval must not be negative"))
+	}
+
+	if !manifest.Registry.TLS.IsEmpty() {
+		errs = append(errs, v.validateRegistryTLS(manifest.Registry.TLS)...)
+	}
+
+	if !manifest.AgentBlobstore.IsEmpty() {
+		errs = append(errs, v.validateAgentBlobstore(manifest.AgentBlobstore)...)
+	}
+
+	if !manifest.UserData.IsEmpty() {
+		errs = append(errs, v.validateUserData(manifest)...)
+	}
+
+	if manifest.Cert.Fingerprint != "" {
+		fingerprint, err := hex.DecodeString(manifest.Cert.Fingerprint)
+		if err != nil || len(fingerprint) != sha256.Size {
+			errs = append(errs, bosherr.Error("cloud_provider.cert.fingerprint must be a SHA-256 fingerprint"))
+		}
+	}
+
+	errs = append(errs, v.validateCPIs(manifest)...)
+
 	if len(errs) > 0 {
 		return bosherr.NewMultiError(errs...)
 	}
@@ -47,6 +139,301 @@ func (v *validator) Validate(manifest Manifest, releaseSetManifest birelsetmanif
 	return nil
 }
 
+func (v *validator) validatePostDeployCheck(idx int, check PostDeployCheck) []error {
+	errs := []error{}
+
+	if check.Type != PostDeployCheckTCP && check.Type != PostDeployCheckHTTP {
+		errs = append(errs, bosherr.Errorf("cloud_provider.post_deploy_checks[%d].type must be '%s' or '%s'", idx, PostDeployCheckTCP, PostDeployCheckHTTP))
+	}
+
+	if check.Port < 1 || check.Port > 65535 {
+		errs = append(errs, bosherr.Errorf("cloud_provider.post_deploy_checks[%d].port must be between 1 and 65535", idx))
+	}
+
+	if check.Scheme != "" && check.Scheme != "http" && check.Scheme != "https" {
+		errs = append(errs, bosherr.Errorf("cloud_provider.post_deploy_checks[%d].scheme must be 'http' or 'https'", idx))
+	}
+
+	if check.InsecureSkipVerify && check.Scheme != "https" {
+		errs = append(errs, bosherr.Errorf("cloud_provider.post_deploy_checks[%d].insecure_skip_verify is only supported with scheme 'https'", idx))
+	}
+
+	if check.ExpectedStatus != 0 && (check.ExpectedStatus < 100 || check.ExpectedStatus > 599) {
+		errs = append(errs, bosherr.Errorf("cloud_provider.post_deploy_checks[%d].expected_status must be a valid HTTP status code", idx))
+	}
+
+	if check.Timeout < 0 {
+		errs = append(errs, bosherr.Errorf("cloud_provider.post_deploy_checks[%d].timeout must not be negative", idx))
+	}
+
+	return errs
+}
+
+// validateMbus rejects mbus URLs the agent client cannot reach, the agent
+// is only talked to over HTTPS and not over NATS
+func (v *validator) validateMbus(mbus string) []error {
+	mbusURL, err := url.Parse(mbus)
+	if err != nil {
+		return []error{bosherr.Error("cloud_provider.mbus must be a URL")}
+	}
+
+	if mbusURL.Scheme != "http" && mbusURL.Scheme != "https" {
+		return []error{bosherr.Errorf("cloud_provider.mbus must be an 'https' URL, agents cannot be reached over '%s'", mbusURL.Scheme)}
+	}
+
+	if mbusURL.Hostname() == "" {
+		return []error{bosherr.Error("cloud_provider.mbus must include the host of the agent")}
+	}
+
+	return nil
+}
+
+func (v *validator) validateCleanupJob(job CleanupJob) []error {
+	var errs []error
+
+	if v.isBlank(job.Script) {
+		errs = append(errs, bosherr.Error("cloud_provider.cleanup_job.script must be provided"))
+	}
+
+	if job.Timeout < 0 {
+		errs = append(errs, bosherr.Error("cloud_provider.cleanup_job.timeout must not be negative"))
+	}
+
+	if job.OnFailure != "" && job.OnFailure != CleanupJobOnFailureFail && job.OnFailure != CleanupJobOnFailureContinue {
+		errs = append(errs, bosherr.Errorf("cloud_provider.cleanup_job.on_failure must be '%s' or '%s'", CleanupJobOnFailureFail, CleanupJobOnFailureContinue))
+	}
+
+	return errs
+}
+
+func (v *validator) validateCPIRetries(retries CPIRetries) []error {
+	var errs []error
+
+	if retries.MaxAttempts < 1 {
+		errs = append(errs, bosherr.Error("cloud_provider.cpi_retries.max_attempts must be at least 1"))
+	}
+
+	if retries.InitialDelay < 0 || retries.MaxDelay < 0 {
+		errs = append(errs, bosherr.Error("cloud_provider.cpi_retries delays must not be negative"))
+	}
+
+	if retries.MaxDelay > 0 && retries.MaxDelay < retries.InitialDelay {
+		errs = append(errs, bosherr.Error("cloud_provider.cpi_retries.max_delay must not be less than initial_delay"))
+	}
+
+	for _, message := range retries.ErrorMessages {
+		_, err := regexp.Compile(message)
+		if err != nil {
+			errs = append(errs, bosherr.Errorf("cloud_provider.cpi_retries.error_messages '%s' must be a regular expression: %s", message, err.Error()))
+		}
+	}
+
+	return errs
+}
+
+func (v *validator) validateCPIThrottle(throttle CPIThrottle) []error {
+	var errs []error
+
+	if throttle.MaxConcurrent < 0 {
+		errs = append(errs, bosherr.Error("cloud_provider.cpi_throttle.max_concurrent must not be negative"))
+	}
+
+	methods := make([]string, 0, len(throttle.Methods))
+	for method := range throttle.Methods {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+
+	for _, method := range methods {
+		if throttle.Methods[method] < 1 {
+			errs = append(errs, bosherr.Errorf("cloud_provider.cpi_throttle.methods '%s' must be at least 1", method))
+		}
+	}
+
+	return errs
+}
+
+func (v *validator) validateUserData(manifest Manifest) []error {
+	var errs []error
+
+	if !v.isValidUserDataFormat(manifest.UserData.Format) {
+		errs = append(errs, bosherr.Errorf("cloud_provider.user_data.format must be one of: %s", strings.Join(userDataFormats, ", ")))
+	}
+
+	if v.isBlank(manifest.Mbus) {
+		errs = append(errs, bosherr.Error("cloud_provider.mbus must be provided when cloud_provider.user_data is used"))
+	}
+
+	return errs
+}
+
+func (v *validator) validateCPIs(manifest Manifest) []error {
+	var errs []error
+
+	names := map[string]struct{}{}
+	azs := map[string]string{}
+
+	for idx, cpi := range manifest.CPIs {
+		if v.isBlank(cpi.Name) {
+			errs = append(errs, bosherr.Errorf("cloud_provider.cpis[%d].name must be provided", idx))
+		} else if _, found := names[cpi.Name]; found {
+			errs = append(errs, bosherr.Errorf("cloud_provider.cpis[%d].name '%s' must be unique", idx, cpi.Name))
+		}
+		names[cpi.Name] = struct{}{}
+
+		if len(cpi.AZs) == 0 {
+			errs = append(errs, bosherr.Errorf("cloud_provider.cpis[%d].azs must be a non-empty array", idx))
+		}
+
+		for azIdx, az := range cpi.AZs {
+			if v.isBlank(az) {
+				errs = append(errs, bosherr.Errorf("cloud_provider.cpis[%d].azs[%d] must be provided", idx, azIdx))
+			} else if other, found := azs[az]; found {
+				errs = append(errs, bosherr.Errorf("cloud_provider.cpis[%d].azs[%d] '%s' is already used by cpi '%s'", idx, azIdx, az, other))
+			}
+			azs[az] = cpi.Name
+		}
+	}
+
+	return errs
+}
+
+func (v *validator) isValidUserDataFormat(format string) bool {
+	for _, userDataFormat := range userDataFormats {
+		if format == userDataFormat {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (v *validator) validateAgentBlobstore(blobstore AgentBlobstore) []error {
+	errs := []error{}
+
+	supportedOptions, found := agentBlobstoreOptions[blobstore.Provider]
+	if !found {
+		return append(errs, bosherr.Errorf("cloud_provider.properties.agent.blobstore.provider must be one of: '%s'", strings.Join(agentBlobstoreProviders(), "', '")))
+	}
+
+	optionNames := []string{}
+	for name := range supportedOptions {
+		optionNames = append(optionNames, name)
+	}
+	for name := range blobstore.Options {
+		if _, found := supportedOptions[name]; !found {
+			optionNames = append(optionNames, name)
+		}
+	}
+	sort.Strings(optionNames)
+
+	for _, name := range optionNames {
+		option, supported := supportedOptions[name]
+		value, present := blobstore.Options[name]
+
+		if !supported {
+			if !blobstore.ProviderDefaulted {
+				errs = append(errs, bosherr.Errorf("cloud_provider.properties.agent.blobstore.options.%s is not supported by provider '%s'", name, blobstore.Provider))
+			}
+			continue
+		}
+
+		if !present {
+			if option.Required && !blobstore.ProviderDefaulted {
+				errs = append(errs, bosherr.Errorf("cloud_provider.properties.agent.blobstore.options.%s must be provided for provider '%s'", name, blobstore.Provider))
+			}
+			continue
+		}
+
+		if !v.hasAgentBlobstoreOptionType(value, option.Type) {
+			errs = append(errs, bosherr.Errorf("cloud_provider.properties.agent.blobstore.options.%s must be %s", name, v.agentBlobstoreOptionTypeName(option.Type)))
+		} else if option.Type == agentBlobstoreString && option.Required && !blobstore.ProviderDefaulted && v.isBlank(value.(string)) {
+			errs = append(errs, bosherr.Errorf("cloud_provider.properties.agent.blobstore.options.%s must be provided for provider '%s'", name, blobstore.Provider))
+		}
+	}
+
+	if blobstore.ProviderDefaulted {
+		return errs
+	}
+
+	credentialsSource, _ := blobstore.Options["credentials_source"].(string)
+
+	switch blobstore.Provider {
+	case AgentBlobstoreS3:
+		if credentialsSource != "" && credentialsSource != "static" && credentialsSource != "env_or_profile" {
+			errs = append(errs, bosherr.Error("cloud_provider.properties.agent.blobstore.options.credentials_source must be 'static' or 'env_or_profile'"))
+		}
+		if credentialsSource == "" || credentialsSource == "static" {
+			for _, name := range []string{"access_key_id", "secret_access_key"} {
+				if _, present := blobstore.Options[name]; !present {
+					errs = append(errs, bosherr.Errorf("cloud_provider.properties.agent.blobstore.options.%s must be provided when using static credentials", name))
+				}
+			}
+		}
+	case AgentBlobstoreGCS:
+		if credentialsSource != "" && credentialsSource != "static" && credentialsSource != "none" && credentialsSource != "application_default" {
+			errs = append(errs, bosherr.Error("cloud_provider.properties.agent.blobstore.options.credentials_source must be 'static', 'application_default' or 'none'"))
+		}
+		if credentialsSource == "static" {
+			if _, present := blobstore.Options["json_key"]; !present {
+				errs = append(errs, bosherr.Error("cloud_provider.properties.agent.blobstore.options.json_key must be provided when using static credentials"))
+			}
+		}
+	}
+
+	return errs
+}
+
+func (v *validator) hasAgentBlobstoreOptionType(value interface{}, optionType agentBlobstoreOptionType) bool {
+	switch optionType {
+	case agentBlobstoreBool:
+		_, ok := value.(bool)
+		return ok
+	case agentBlobstoreInt:
+		_, ok := value.(int)
+		return ok
+	case agentBlobstoreMap:
+		_, ok := value.(biproperty.Map)
+		return ok
+	default:
+		_, ok := value.(string)
+		return ok
+	}
+}
+
+func (v *validator) agentBlobstoreOptionTypeName(optionType agentBlobstoreOptionType) string {
+	switch optionType {
+	case agentBlobstoreBool:
+		return "a boolean"
+	case agentBlobstoreInt:
+		return "an integer"
+	case agentBlobstoreMap:
+		return "a hash"
+	default:
+		return "a string"
+	}
+}
+
 func (v *validator) isBlank(str string) bool {
 	return str == "" || strings.TrimSpace(str) == ""
 }
+
+func (v *validator) validateRegistryTLS(registryTLS RegistryTLS) []error {
+	errs := []error{}
+
+	if registryTLS.Certificate == "" || registryTLS.PrivateKey == "" {
+		errs = append(errs, bosherr.Error("cloud_provider.registry.tls.certificate and cloud_provider.registry.tls.private_key must be provided unless cloud_provider.registry.tls.generate is true"))
+	} else if _, err := tls.X509KeyPair([]byte(registryTLS.Certificate), []byte(registryTLS.PrivateKey)); err != nil {
+		errs = append(errs, bosherr.WrapError(err, "cloud_provider.registry.tls.certificate and cloud_provider.registry.tls.private_key must be a valid PEM encoded key pair"))
+	}
+
+	if registryTLS.CA != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(registryTLS.CA)) {
+		errs = append(errs, bosherr.Error("cloud_provider.registry.tls.ca must be a PEM encoded certificate"))
+	}
+
+	if registryTLS.ClientCA != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(registryTLS.ClientCA)) {
+		errs = append(errs, bosherr.Error("cloud_provider.registry.tls.client_ca must be a PEM encoded certificate"))
+	}
+
+	return errs
+}
@@ -49,6 +49,18 @@ func (mr *MockInstallationMockRecorder) Job() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Job", reflect.TypeOf((*MockInstallation)(nil).Job))
 }
 
+// SetRegistrySettingsOverlay mocks base method
+func (m *MockInstallation) SetRegistrySettingsOverlay(arg0 []byte) error {
+	ret := m.ctrl.Call(m, "SetRegistrySettingsOverlay", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRegistrySettingsOverlay indicates an expected call of SetRegistrySettingsOverlay
+func (mr *MockInstallationMockRecorder) SetRegistrySettingsOverlay(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRegistrySettingsOverlay", reflect.TypeOf((*MockInstallation)(nil).SetRegistrySettingsOverlay), arg0)
+}
+
 // StartRegistry mocks base method
 func (m *MockInstallation) StartRegistry() error {
 	ret := m.ctrl.Call(m, "StartRegistry")
@@ -180,6 +192,18 @@ func (mr *MockInstallerFactoryMockRecorder) NewInstaller(arg0 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewInstaller", reflect.TypeOf((*MockInstallerFactory)(nil).NewInstaller), arg0)
 }
 
+// NewInstallerWithCompiledPackagesBlobstore mocks base method
+func (m *MockInstallerFactory) NewInstallerWithCompiledPackagesBlobstore(arg0 installation.Target, arg1 string) installation.Installer {
+	ret := m.ctrl.Call(m, "NewInstallerWithCompiledPackagesBlobstore", arg0, arg1)
+	ret0, _ := ret[0].(installation.Installer)
+	return ret0
+}
+
+// NewInstallerWithCompiledPackagesBlobstore indicates an expected call of NewInstallerWithCompiledPackagesBlobstore
+func (mr *MockInstallerFactoryMockRecorder) NewInstallerWithCompiledPackagesBlobstore(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NewInstallerWithCompiledPackagesBlobstore", reflect.TypeOf((*MockInstallerFactory)(nil).NewInstallerWithCompiledPackagesBlobstore), arg0, arg1)
+}
+
 // MockUninstaller is a mock of Uninstaller interface
 type MockUninstaller struct {
 	ctrl     *gomock.Controller
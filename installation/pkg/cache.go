@@ -0,0 +1,265 @@
+package pkg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+
+	biinstallblob "github.com/cloudfoundry/bosh-cli/installation/blobstore"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// CompiledPackageCache keeps compiled package tarballs across installations
+// so that installing the same CPI release for another deployment, or again
+// after delete-env removed the installation, does not recompile it
+type CompiledPackageCache interface {
+	Get(birelpkg.Compilable) (path string, found bool)
+	Save(pkg birelpkg.Compilable, tarballPath string) error
+	// WithBlobstore returns a cache that also shares the packages through
+	// shared, e.g. an S3 bucket, and fills itself with the packages found there
+	WithBlobstore(shared biinstallblob.Blobstore) CompiledPackageCache
+	// WithInstallTarget returns a cache for packages compiled into
+	// packagesDir, which compiled packages may refer to by absolute path
+	WithInstallTarget(packagesDir string) CompiledPackageCache
+}
+
+// digestSuffix names the blob holding the SHA-256 digest of a shared package
+const digestSuffix = ".sha256"
+
+type compiledPackageCache struct {
+	dir         string
+	shared      biinstallblob.Blobstore
+	platform    string
+	packagesDir string
+	fs          boshsys.FileSystem
+	logger      boshlog.Logger
+	logTag      string
+}
+
+// NewCompiledPackageCache keys packages by their fingerprint, the
+// fingerprints of their dependencies, the platform of the workstation,
+// which takes the place of the stemcell for packages compiled locally, and
+// the packages dir they are installed into, which is the BOSH_INSTALL_TARGET
+// of the packaging scripts
+func NewCompiledPackageCache(dir string, fs boshsys.FileSystem, logger boshlog.Logger) CompiledPackageCache {
+	return compiledPackageCache{
+		dir:      dir,
+		platform: hostPlatform(fs),
+		fs:       fs,
+		logger:   logger,
+		logTag:   "compiledPackageCache",
+	}
+}
+
+// hostPlatform is the OS and architecture of the workstation, and on Linux
+// the OS release and C library, since packages compiled locally may link
+// against the libraries of the OS, e.g. 'linux-amd64-ubuntu-22.04-glibc'
+func hostPlatform(fs boshsys.FileSystem) string {
+	platform := runtime.GOOS + "-" + runtime.GOARCH
+	if runtime.GOOS != "linux" {
+		return platform
+	}
+
+	if osRelease, err := fs.ReadFileString("/etc/os-release"); err == nil {
+		values := map[string]string{}
+		for _, line := range strings.Split(osRelease, "\n") {
+			parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+			if len(parts) == 2 {
+				values[parts[0]] = strings.Trim(parts[1], `"'`)
+			}
+		}
+
+		if values["ID"] != "" {
+			platform += "-" + values["ID"] + "-" + values["VERSION_ID"]
+		}
+	}
+
+	libc := "glibc"
+	if matches, err := fs.Glob("/lib/ld-musl-*"); err == nil && len(matches) > 0 {
+		libc = "musl"
+	}
+
+	return platform + "-" + libc
+}
+
+func (c compiledPackageCache) WithBlobstore(shared biinstallblob.Blobstore) CompiledPackageCache {
+	c.shared = shared
+	return c
+}
+
+func (c compiledPackageCache) WithInstallTarget(packagesDir string) CompiledPackageCache {
+	c.packagesDir = packagesDir
+	return c
+}
+
+func (c compiledPackageCache) Get(pkg birelpkg.Compilable) (string, bool) {
+	path := c.path(pkg)
+	if !c.fs.FileExists(path) && !c.getShared(pkg, path) {
+		return "", false
+	}
+
+	c.logger.Debug(c.logTag, "Found compiled package '%s/%s' in cache at '%s'", pkg.Name(), pkg.Fingerprint(), path)
+
+	return path, true
+}
+
+// getShared is best effort, packages that cannot be fetched or do not match
+// the digest shared with them are compiled
+func (c compiledPackageCache) getShared(pkg birelpkg.Compilable, path string) bool {
+	if c.shared == nil {
+		return false
+	}
+
+	err := c.fs.MkdirAll(c.dir, os.ModePerm)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Creating compiled package cache '%s': %s", c.dir, err.Error())
+		return false
+	}
+
+	key := filepath.Base(path)
+	tmpPath := path + ".tmp"
+	digestPath := path + digestSuffix + ".tmp"
+
+	defer func() {
+		_ = c.fs.RemoveAll(tmpPath)
+		_ = c.fs.RemoveAll(digestPath)
+	}()
+
+	// packages shared without a digest are ignored, the digest is put last
+	found, err := c.shared.Get(key+digestSuffix, digestPath)
+	if err == nil && found {
+		found, err = c.shared.Get(key, tmpPath)
+	}
+	if err != nil {
+		c.logger.Warn(c.logTag, "Getting compiled package '%s' from '%s': %s", pkg.Name(), c.shared.Location(), err.Error())
+	}
+
+	if err != nil || !found {
+		return false
+	}
+
+	expectedDigest, err := c.fs.ReadFileString(digestPath)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Reading digest of compiled package '%s': %s", pkg.Name(), err.Error())
+		return false
+	}
+
+	digest, err := c.digest(tmpPath)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Calculating digest of compiled package '%s': %s", pkg.Name(), err.Error())
+		return false
+	}
+
+	if digest != strings.TrimSpace(expectedDigest) {
+		c.logger.Warn(c.logTag, "Compiled package '%s' from '%s' does not match its digest, expected '%s', got '%s'",
+			pkg.Name(), c.shared.Location(), strings.TrimSpace(expectedDigest), digest)
+		return false
+	}
+
+	err = c.fs.Rename(tmpPath, path)
+	if err != nil {
+		c.logger.Warn(c.logTag, "Renaming cached compiled package '%s': %s", pkg.Name(), err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (c compiledPackageCache) Save(pkg birelpkg.Compilable, tarballPath string) error {
+	err := c.fs.MkdirAll(c.dir, os.ModePerm)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating compiled package cache '%s'", c.dir)
+	}
+
+	path := c.path(pkg)
+	tmpPath := path + ".tmp"
+
+	err = c.fs.CopyFile(tarballPath, tmpPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Copying compiled package '%s' into cache", pkg.Name())
+	}
+
+	err = c.fs.Rename(tmpPath, path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Renaming cached compiled package '%s'", pkg.Name())
+	}
+
+	c.logger.Debug(c.logTag, "Saved compiled package '%s/%s' in cache at '%s'", pkg.Name(), pkg.Fingerprint(), path)
+
+	if c.shared != nil {
+		err = c.putShared(pkg, path)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Sharing compiled package '%s' through '%s'", pkg.Name(), c.shared.Location())
+		}
+	}
+
+	return nil
+}
+
+// putShared puts the SHA-256 digest of the package after the package so
+// that readers finding the digest find the whole package
+func (c compiledPackageCache) putShared(pkg birelpkg.Compilable, path string) error {
+	digest, err := c.digest(path)
+	if err != nil {
+		return bosherr.WrapError(err, "Calculating digest")
+	}
+
+	digestPath := path + digestSuffix
+
+	err = c.fs.WriteFileString(digestPath, digest)
+	if err != nil {
+		return bosherr.WrapError(err, "Writing digest")
+	}
+
+	defer func() {
+		_ = c.fs.RemoveAll(digestPath)
+	}()
+
+	key := filepath.Base(path)
+
+	err = c.shared.Put(key, path)
+	if err != nil {
+		return err
+	}
+
+	return c.shared.Put(key+digestSuffix, digestPath)
+}
+
+func (c compiledPackageCache) digest(path string) (string, error) {
+	file, err := c.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return "", err
+	}
+
+	defer file.Close()
+
+	hash := sha256.New()
+
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
+
+func (c compiledPackageCache) path(pkg birelpkg.Compilable) string {
+	dependencyKeys := []string{}
+	for _, dependency := range bistatepkg.ResolveDependencies(pkg) {
+		dependencyKeys = append(dependencyKeys, fmt.Sprintf("%s:%s", dependency.Name(), dependency.Fingerprint()))
+	}
+	sort.Strings(dependencyKeys)
+
+	key := strings.Join([]string{pkg.Name(), pkg.Fingerprint(), strings.Join(dependencyKeys, ","), c.platform, c.packagesDir}, "/")
+
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%x.tgz", pkg.Name(), sha256.Sum256([]byte(key))))
+}
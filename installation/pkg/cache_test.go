@@ -0,0 +1,180 @@
+package pkg_test
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biinstallblob "github.com/cloudfoundry/bosh-cli/installation/blobstore"
+	. "github.com/cloudfoundry/bosh-cli/installation/pkg"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+)
+
+var _ = Describe("CompiledPackageCache", func() {
+	var (
+		fs    *fakesys.FakeFileSystem
+		cache CompiledPackageCache
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		cache = NewCompiledPackageCache("/fake-cache", fs, boshlog.NewLogger(boshlog.LevelNone))
+
+		fs.WriteFileString("/compiled.tgz", "fake-compiled-package")
+	})
+
+	newPackage := func(fingerprint string, dependencyFingerprint string) *birelpkg.Package {
+		dependency := birelpkg.NewPackage(NewResourceWithBuiltArchive("dep-name", dependencyFingerprint, "", ""), nil)
+		pkg := birelpkg.NewPackage(NewResourceWithBuiltArchive("pkg-name", fingerprint, "", ""), []string{"dep-name"})
+		pkg.AttachDependencies([]*birelpkg.Package{dependency})
+		return pkg
+	}
+
+	It("returns saved packages with the same fingerprints", func() {
+		err := cache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+		Expect(err).ToNot(HaveOccurred())
+
+		path, found := cache.Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+		Expect(found).To(BeTrue())
+		Expect(fs.ReadFileString(path)).To(Equal("fake-compiled-package"))
+		Expect(fs.FileExists(path + ".tmp")).To(BeFalse())
+	})
+
+	It("does not return packages whose own or dependency fingerprint changed", func() {
+		err := cache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found := cache.Get(newPackage("other-fingerprint", "fake-dep-fingerprint"))
+		Expect(found).To(BeFalse())
+
+		_, found = cache.Get(newPackage("fake-fingerprint", "other-dep-fingerprint"))
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns packages compiled into the same packages dir for another installation", func() {
+		installCache := cache.WithInstallTarget("/fake-installations/fake-installation-id/packages")
+
+		err := installCache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+		Expect(err).ToNot(HaveOccurred())
+
+		otherCache := NewCompiledPackageCache("/fake-cache", fs, boshlog.NewLogger(boshlog.LevelNone)).
+			WithInstallTarget("/fake-installations/fake-installation-id/packages")
+
+		path, found := otherCache.Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+		Expect(found).To(BeTrue())
+		Expect(fs.ReadFileString(path)).To(Equal("fake-compiled-package"))
+	})
+
+	It("does not return packages compiled into another packages dir", func() {
+		err := cache.WithInstallTarget("/fake-installations/fake-installation-id/packages").
+			Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found := cache.WithInstallTarget("/fake-installations/other-installation-id/packages").
+			Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+		Expect(found).To(BeFalse())
+	})
+
+	Context("with a shared blobstore", func() {
+		var (
+			shared biinstallblob.Blobstore
+		)
+
+		BeforeEach(func() {
+			shared = biinstallblob.NewLocalBlobstore("/shared", fs, boshlog.NewLogger(boshlog.LevelNone))
+			cache = cache.WithBlobstore(shared)
+		})
+
+		// sharedKey is the key of a package saved in the cache
+		sharedKey := func(pkg *birelpkg.Package) string {
+			path, found := cache.Get(pkg)
+			Expect(found).To(BeTrue())
+			return filepath.Base(path)
+		}
+
+		It("shares saved packages through the blobstore", func() {
+			err := cache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+			Expect(err).ToNot(HaveOccurred())
+
+			otherCache := NewCompiledPackageCache("/other-cache", fs, boshlog.NewLogger(boshlog.LevelNone)).WithBlobstore(shared)
+
+			path, found := otherCache.Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			Expect(found).To(BeTrue())
+			Expect(path).To(HavePrefix("/other-cache/"))
+			Expect(fs.ReadFileString(path)).To(Equal("fake-compiled-package"))
+			Expect(fs.FileExists(path + ".tmp")).To(BeFalse())
+		})
+
+		It("shares the SHA-256 digest with the package", func() {
+			err := cache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+			Expect(err).ToNot(HaveOccurred())
+
+			key := sharedKey(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			Expect(fs.ReadFileString("/shared/" + key + ".sha256")).To(Equal(fmt.Sprintf("%x", sha256.Sum256([]byte("fake-compiled-package")))))
+		})
+
+		It("does not return shared packages that do not match their digest", func() {
+			err := cache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+			Expect(err).ToNot(HaveOccurred())
+
+			key := sharedKey(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			fs.WriteFileString("/shared/"+key, "fake-corrupted-package")
+
+			otherCache := NewCompiledPackageCache("/other-cache", fs, boshlog.NewLogger(boshlog.LevelNone)).WithBlobstore(shared)
+
+			path, found := otherCache.Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			Expect(found).To(BeFalse())
+			Expect(path).To(BeEmpty())
+			Expect(fs.FileExists("/other-cache/" + key + ".tmp")).To(BeFalse())
+		})
+
+		It("does not return shared packages without a digest", func() {
+			err := cache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+			Expect(err).ToNot(HaveOccurred())
+
+			key := sharedKey(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			Expect(fs.RemoveAll("/shared/" + key + ".sha256")).To(Succeed())
+
+			otherCache := NewCompiledPackageCache("/other-cache", fs, boshlog.NewLogger(boshlog.LevelNone)).WithBlobstore(shared)
+
+			_, found := otherCache.Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return packages compiled on another OS release", func() {
+			if runtime.GOOS != "linux" {
+				Skip("the OS release is only part of the platform on Linux")
+			}
+
+			fs.WriteFileString("/etc/os-release", "ID=ubuntu\nVERSION_ID=\"22.04\"\n")
+			ubuntuCache := NewCompiledPackageCache("/fake-cache", fs, boshlog.NewLogger(boshlog.LevelNone)).WithBlobstore(shared)
+
+			err := ubuntuCache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/compiled.tgz")
+			Expect(err).ToNot(HaveOccurred())
+
+			fs.WriteFileString("/etc/os-release", "ID=alpine\nVERSION_ID=3.18.4\n")
+			alpineCache := NewCompiledPackageCache("/other-cache", fs, boshlog.NewLogger(boshlog.LevelNone)).WithBlobstore(shared)
+
+			_, found := alpineCache.Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			Expect(found).To(BeFalse())
+		})
+
+		It("does not return packages missing from the blobstore", func() {
+			_, found := cache.Get(newPackage("fake-fingerprint", "fake-dep-fingerprint"))
+			Expect(found).To(BeFalse())
+		})
+	})
+
+	It("returns an error when the tarball cannot be copied", func() {
+		err := cache.Save(newPackage("fake-fingerprint", "fake-dep-fingerprint"), "/missing.tgz")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Copying compiled package 'pkg-name' into cache"))
+	})
+})
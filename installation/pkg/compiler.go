@@ -3,7 +3,9 @@ package pkg
 import (
 	"os"
 	"path/filepath"
+	"sync"
 
+	biplatform "github.com/cloudfoundry/bosh-cli/common/platform"
 	"github.com/cloudfoundry/bosh-cli/installation/blobextract"
 	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
@@ -22,8 +24,19 @@ type compiler struct {
 	blobstore           boshblob.DigestBlobstore
 	compiledPackageRepo bistatepkg.CompiledPackageRepo
 	blobExtractor       blobextract.Extractor
+	cache               CompiledPackageCache
 	logger              boshlog.Logger
 	logTag              string
+
+	// lock guards the compiled package repo and the packages dir, which is
+	// shared by packages compiled at the same time
+	lock sync.Mutex
+
+	// compiling counts packages being compiled, the packages dir is removed once none are
+	compiling int
+
+	// installed counts the packages being compiled that depend on an installed package
+	installed map[string]int
 }
 
 func NewPackageCompiler(
@@ -34,8 +47,13 @@ func NewPackageCompiler(
 	blobstore boshblob.DigestBlobstore,
 	compiledPackageRepo bistatepkg.CompiledPackageRepo,
 	blobExtractor blobextract.Extractor,
+	cache CompiledPackageCache,
 	logger boshlog.Logger,
 ) bistatepkg.Compiler {
+	if cache != nil {
+		cache = cache.WithInstallTarget(packagesDir)
+	}
+
 	return &compiler{
 		runner:              runner,
 		packagesDir:         packagesDir,
@@ -44,8 +62,10 @@ func NewPackageCompiler(
 		blobstore:           blobstore,
 		compiledPackageRepo: compiledPackageRepo,
 		blobExtractor:       blobExtractor,
+		cache:               cache,
 		logger:              logger,
 		logTag:              "packageCompiler",
+		installed:           map[string]int{},
 	}
 }
 
@@ -57,26 +77,36 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 
 	c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
+	c.lock.Lock()
 	record, found, err := c.compiledPackageRepo.Find(pkg)
+	c.lock.Unlock()
 	if err != nil {
 		return record, isCompiledPackage, bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
 	} else if found {
 		return record, isCompiledPackage, nil
 	}
 
+	if c.cache != nil {
+		if cachedPath, found := c.cache.Get(pkg); found {
+			c.logger.Debug(c.logTag, "Using cached compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+			record, err = c.saveCompiledPackage(pkg, cachedPath)
+			return record, isCompiledPackage, err
+		}
+	}
+
 	c.logger.Debug(c.logTag, "Installing dependencies of package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
-	err = c.installPackages(pkg.Deps())
+	c.lock.Lock()
+	c.compiling++
+	installedDeps, err := c.installPackages(pkg.Deps())
+	c.lock.Unlock()
+
+	defer c.cleanUpPackagesDir(pkg, installedDeps)
+
 	if err != nil {
 		return record, isCompiledPackage, bosherr.WrapErrorf(err, "Installing dependencies of package '%s'", pkg.Name())
 	}
 
-	defer func() {
-		if err = c.fileSystem.RemoveAll(c.packagesDir); err != nil {
-			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
-		}
-	}()
-
 	c.logger.Debug(c.logTag, "Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 	installDir := filepath.Join(c.packagesDir, pkg.Name())
@@ -92,16 +122,16 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		return record, isCompiledPackage, bosherr.Errorf("Packaging script for package '%s' not found", pkg.Name())
 	}
 
+	env := biplatform.IsolatedEnv("/usr/local/bin:/usr/bin:/bin")
+	env["BOSH_COMPILE_TARGET"] = packageSrcDir
+	env["BOSH_INSTALL_TARGET"] = installDir
+	env["BOSH_PACKAGE_NAME"] = pkg.Name()
+	env["BOSH_PACKAGES_DIR"] = c.packagesDir
+
 	cmd := boshsys.Command{
-		Name: "bash",
-		Args: []string{"-x", "packaging"},
-		Env: map[string]string{
-			"BOSH_COMPILE_TARGET": packageSrcDir,
-			"BOSH_INSTALL_TARGET": installDir,
-			"BOSH_PACKAGE_NAME":   pkg.Name(),
-			"BOSH_PACKAGES_DIR":   c.packagesDir,
-			"PATH":                "/usr/local/bin:/usr/bin:/bin",
-		},
+		Name:           "bash",
+		Args:           []string{"-x", "packaging"},
+		Env:            env,
 		UseIsolatedEnv: true,
 		WorkingDir:     packageSrcDir,
 	}
@@ -122,42 +152,104 @@ func (c *compiler) Compile(pkg birelpkg.Compilable) (bistatepkg.CompiledPackageR
 		}
 	}()
 
+	record, err = c.saveCompiledPackage(pkg, tarball)
+	if err != nil {
+		return record, isCompiledPackage, err
+	}
+
+	// caching is best effort, the package is only compiled again next time
+	if c.cache != nil {
+		err = c.cache.Save(pkg, tarball)
+		if err != nil {
+			c.logger.Warn(c.logTag, "Failed to cache compiled package '%s': %s", pkg.Name(), err.Error())
+		}
+	}
+
+	return record, isCompiledPackage, nil
+}
+
+// cleanUpPackagesDir removes the compiled package and the dependencies no
+// other package being compiled needs, and the packages dir once all are done
+func (c *compiler) cleanUpPackagesDir(pkg birelpkg.Compilable, installedDeps []birelpkg.Compilable) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.compiling--
+
+	paths := []string{filepath.Join(c.packagesDir, pkg.Name())}
+
+	for _, dep := range installedDeps {
+		c.installed[dep.Name()]--
+
+		if c.installed[dep.Name()] == 0 {
+			delete(c.installed, dep.Name())
+			paths = append(paths, filepath.Join(c.packagesDir, dep.Name()))
+		}
+	}
+
+	if c.compiling == 0 {
+		paths = []string{c.packagesDir}
+	}
+
+	for _, path := range paths {
+		if err := c.fileSystem.RemoveAll(path); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove packages dir: %s", err.Error())
+		}
+	}
+}
+
+func (c *compiler) saveCompiledPackage(pkg birelpkg.Compilable, tarball string) (bistatepkg.CompiledPackageRecord, error) {
 	blobID, digest, err := c.blobstore.Create(tarball)
 	if err != nil {
-		return record, isCompiledPackage, bosherr.WrapError(err, "Creating blob")
+		return bistatepkg.CompiledPackageRecord{}, bosherr.WrapError(err, "Creating blob")
 	}
 
-	record = bistatepkg.CompiledPackageRecord{
+	record := bistatepkg.CompiledPackageRecord{
 		BlobID:   blobID,
 		BlobSHA1: digest.String(),
 	}
 
+	c.lock.Lock()
 	err = c.compiledPackageRepo.Save(pkg, record)
+	c.lock.Unlock()
 	if err != nil {
-		return record, isCompiledPackage, bosherr.WrapError(err, "Saving compiled package")
+		return record, bosherr.WrapError(err, "Saving compiled package")
 	}
 
-	return record, isCompiledPackage, nil
+	return record, nil
 }
 
-func (c *compiler) installPackages(packages []birelpkg.Compilable) error {
+// installPackages skips packages already installed for another package being
+// compiled and returns the packages it counted as used, c.lock must be held
+func (c *compiler) installPackages(packages []birelpkg.Compilable) ([]birelpkg.Compilable, error) {
+	installed := []birelpkg.Compilable{}
+
 	for _, pkg := range packages {
+		if c.installed[pkg.Name()] > 0 {
+			c.installed[pkg.Name()]++
+			installed = append(installed, pkg)
+			continue
+		}
+
 		c.logger.Debug(c.logTag, "Checking for compiled package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 		record, found, err := c.compiledPackageRepo.Find(pkg)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
+			return installed, bosherr.WrapErrorf(err, "Attempting to find compiled package '%s'", pkg.Name())
 		} else if !found {
-			return bosherr.Errorf("Finding compiled package '%s'", pkg.Name())
+			return installed, bosherr.Errorf("Finding compiled package '%s'", pkg.Name())
 		}
 
 		c.logger.Debug(c.logTag, "Installing package '%s/%s'", pkg.Name(), pkg.Fingerprint())
 
 		err = c.blobExtractor.Extract(record.BlobID, record.BlobSHA1, filepath.Join(c.packagesDir, pkg.Name()))
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
+			return installed, bosherr.WrapErrorf(err, "Installing package '%s' into '%s'", pkg.Name(), c.packagesDir)
 		}
+
+		c.installed[pkg.Name()]++
+		installed = append(installed, pkg)
 	}
 
-	return nil
+	return installed, nil
 }
@@ -43,6 +43,7 @@ var _ = Describe("PackageCompiler", func() {
 		packagesDir             string
 		blobstore               *fakeblobstore.FakeDigestBlobstore
 		mockCompiledPackageRepo *mock_state_package.MockCompiledPackageRepo
+		cache                   CompiledPackageCache
 
 		fakeExtractor *fakeblobextract.FakeExtractor
 
@@ -71,6 +72,8 @@ var _ = Describe("PackageCompiler", func() {
 		pkg = birelpkg.NewExtractedPackage(NewResource("pkg1-name", "", nil), []string{"pkg-dep1-name", "pkg-dep2-name"}, "/pkg-dir", fs)
 		pkg.AttachDependencies([]*birelpkg.Package{dependency1, dependency2})
 
+		cache = NewCompiledPackageCache("/fake-cache", fs, logger).WithInstallTarget(packagesDir)
+
 		compiler = NewPackageCompiler(
 			runner,
 			packagesDir,
@@ -79,6 +82,7 @@ var _ = Describe("PackageCompiler", func() {
 			blobstore,
 			mockCompiledPackageRepo,
 			fakeExtractor,
+			cache,
 			logger,
 		)
 	})
@@ -143,6 +147,40 @@ var _ = Describe("PackageCompiler", func() {
 			})
 		})
 
+		Context("when the compiled package cache has the package", func() {
+			JustBeforeEach(func() {
+				fs.WriteFileString("/cached-tarball.tgz", "fake-compiled-package")
+				err := cache.Save(pkg, "/cached-tarball.tgz")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("saves the cached package without compiling", func() {
+				expectSave.Times(1)
+
+				record, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(record).To(Equal(bistatepkg.CompiledPackageRecord{BlobID: "fake-blob-id", BlobSHA1: "fakefingerprint"}))
+
+				Expect(runner.RunComplexCommands).To(BeEmpty())
+				Expect(fakeExtractor.ExtractCallCount()).To(Equal(0))
+
+				cachedPath, found := cache.Get(pkg)
+				Expect(found).To(BeTrue())
+				Expect(blobstore.CreateArgsForCall(0)).To(Equal(cachedPath))
+			})
+		})
+
+		It("saves the compiled package in the cache", func() {
+			fs.WriteFileString(compiledPackageTarballPath, "fake-compiled-package")
+
+			_, _, err := compiler.Compile(pkg)
+			Expect(err).ToNot(HaveOccurred())
+
+			cachedPath, found := cache.Get(pkg)
+			Expect(found).To(BeTrue())
+			Expect(fs.ReadFileString(cachedPath)).To(Equal("fake-compiled-package"))
+		})
+
 		It("installs all the dependencies for the package", func() {
 			_, _, err := compiler.Compile(pkg)
 			Expect(err).ToNot(HaveOccurred())
@@ -219,6 +257,50 @@ var _ = Describe("PackageCompiler", func() {
 			Expect(fs.FileExists(packagesDir)).To(BeFalse())
 		})
 
+		Context("when another package is compiled at the same time", func() {
+			var (
+				pkg2 *birelpkg.Package
+			)
+
+			BeforeEach(func() {
+				pkg2 = birelpkg.NewExtractedPackage(NewResource("pkg2-name", "", nil), []string{"pkg-dep1-name"}, "/pkg2-dir", fs)
+				pkg2.AttachDependencies([]*birelpkg.Package{dependency1})
+			})
+
+			JustBeforeEach(func() {
+				fs.WriteFileString("/pkg2-dir/packaging", "")
+
+				mockCompiledPackageRepo.EXPECT().Find(pkg2).Return(bistatepkg.CompiledPackageRecord{}, false, nil)
+				mockCompiledPackageRepo.EXPECT().Save(pkg2, gomock.Any())
+
+				fakeExtractor.ExtractStub = func(blobID, sha1, path string) error {
+					return fs.WriteFileString(filepath.Join(path, "fake-file"), "")
+				}
+
+				// compiles pkg2 while pkg1 is being saved, with its dependencies still installed
+				blobstore.CreateStub = func(string) (string, boshcrypto.MultipleDigest, error) {
+					if blobstore.CreateCallCount() == 1 {
+						_, _, err := compiler.Compile(pkg2)
+						Expect(err).ToNot(HaveOccurred())
+
+						Expect(fs.FileExists(filepath.Join(packagesDir, "pkg-dep1-name"))).To(BeTrue())
+						Expect(fs.FileExists(filepath.Join(packagesDir, "pkg-dep2-name"))).To(BeTrue())
+						Expect(fs.FileExists(filepath.Join(packagesDir, "pkg2-name"))).To(BeFalse())
+					}
+
+					return "fake-blob-id", boshcrypto.MustParseMultipleDigest("fakefingerprint"), nil
+				}
+			})
+
+			It("installs the shared dependencies once and cleans up the packages dir when both are done", func() {
+				_, _, err := compiler.Compile(pkg)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeExtractor.ExtractCallCount()).To(Equal(2))
+				Expect(fs.FileExists(packagesDir)).To(BeFalse())
+			})
+		})
+
 		Context("when dependency installation fails", func() {
 			JustBeforeEach(func() {
 				fakeExtractor.ExtractReturns(errors.New("fake-install-error"))
@@ -5,6 +5,7 @@ import (
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	"github.com/cloudfoundry/bosh-cli/installation/tarball"
 	boshrel "github.com/cloudfoundry/bosh-cli/release"
 	"github.com/cloudfoundry/bosh-cli/release/manifest"
@@ -15,30 +16,51 @@ type ReleaseFetcher struct {
 	tarballProvider tarball.Provider
 	releaseReader   boshrel.Reader
 	releaseManager  ReleaseManager
+	digestVerifier  bicrypto.DigestVerifier
 }
 
 func NewReleaseFetcher(
 	tarballProvider tarball.Provider,
 	releaseReader boshrel.Reader,
 	releaseManager ReleaseManager,
+) ReleaseFetcher {
+	return NewReleaseFetcherWithDigestVerifier(tarballProvider, releaseReader, releaseManager, nil)
+}
+
+// NewReleaseFetcherWithDigestVerifier can verify release tarballs against
+// digests given on the command line with DownloadVerifyAndExtract
+func NewReleaseFetcherWithDigestVerifier(
+	tarballProvider tarball.Provider,
+	releaseReader boshrel.Reader,
+	releaseManager ReleaseManager,
+	digestVerifier bicrypto.DigestVerifier,
 ) ReleaseFetcher {
 	return ReleaseFetcher{
 		tarballProvider: tarballProvider,
 		releaseReader:   releaseReader,
 		releaseManager:  releaseManager,
+		digestVerifier:  digestVerifier,
 	}
 }
 
 func (f ReleaseFetcher) DownloadAndExtract(releaseRef manifest.ReleaseRef, stage ui.Stage) error {
+	return f.DownloadVerifyAndExtract(releaseRef, "", stage)
+}
+
+// DownloadVerifyAndExtract verifies the release tarball against digest,
+// also when it is a local file. Release readers that are a
+// release.VerifyingReader verify it while extracting it, others before.
+// An empty digest skips the verification.
+func (f ReleaseFetcher) DownloadVerifyAndExtract(releaseRef manifest.ReleaseRef, digest string, stage ui.Stage) error {
 	releasePath, err := f.tarballProvider.Get(releaseRef, stage)
 	if err != nil {
 		return err
 	}
 
 	err = stage.Perform(fmt.Sprintf("Validating release '%s'", releaseRef.Name), func() error {
-		release, err := f.releaseReader.Read(releasePath)
+		release, err := f.readRelease(releaseRef, releasePath, digest)
 		if err != nil {
-			return bosherr.WrapErrorf(err, "Extracting release '%s'", releasePath)
+			return err
 		}
 
 		if release.Name() != releaseRef.Name {
@@ -46,6 +68,21 @@ func (f ReleaseFetcher) DownloadAndExtract(releaseRef manifest.ReleaseRef, stage
 			return bosherr.Errorf(errMsg, releaseRef.Name, release.Name())
 		}
 
+		versionConstraint, err := manifest.NewVersionConstraint(releaseRef.Version)
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking version of release '%s'", releaseRef.Name)
+		}
+
+		matches, err := versionConstraint.Check(release.Version())
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Checking version of release '%s'", releaseRef.Name)
+		}
+
+		if !matches {
+			errMsg := "Release '%s' version '%s' in release tarball '%s' does not satisfy version '%s' from the manifest"
+			return bosherr.Errorf(errMsg, releaseRef.Name, release.Version(), releasePath, versionConstraint)
+		}
+
 		f.releaseManager.Add(release)
 
 		return nil
@@ -53,3 +90,32 @@ func (f ReleaseFetcher) DownloadAndExtract(releaseRef manifest.ReleaseRef, stage
 
 	return err
 }
+
+func (f ReleaseFetcher) readRelease(releaseRef manifest.ReleaseRef, releasePath string, digest string) (boshrel.Release, error) {
+	if digest != "" {
+		if f.digestVerifier == nil {
+			return nil, bosherr.Errorf("Verifying release '%s' is not supported", releaseRef.Name)
+		}
+
+		if verifyingReader, ok := f.releaseReader.(boshrel.VerifyingReader); ok {
+			release, err := verifyingReader.ReadWithDigest(releasePath, digest, f.digestVerifier)
+			if err != nil {
+				return nil, bosherr.WrapErrorf(err, "Extracting release '%s'", releasePath)
+			}
+
+			return release, nil
+		}
+
+		err := f.digestVerifier.Verify(releasePath, digest)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Verifying release '%s'", releaseRef.Name)
+		}
+	}
+
+	release, err := f.releaseReader.Read(releasePath)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Extracting release '%s'", releasePath)
+	}
+
+	return release, nil
+}
@@ -0,0 +1,164 @@
+package installation_test
+
+import (
+	"errors"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+	. "github.com/cloudfoundry/bosh-cli/installation"
+	mock_tarball "github.com/cloudfoundry/bosh-cli/installation/tarball/mocks"
+	boshrel "github.com/cloudfoundry/bosh-cli/release"
+	birelmanifest "github.com/cloudfoundry/bosh-cli/release/manifest"
+	fakerel "github.com/cloudfoundry/bosh-cli/release/releasefakes"
+	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("ReleaseFetcher", func() {
+	var (
+		mockCtrl            *gomock.Controller
+		mockTarballProvider *mock_tarball.MockProvider
+		releaseReader       *fakerel.FakeReader
+		release             *fakerel.FakeRelease
+		releaseManager      ReleaseManager
+		fakeStage           *fakebiui.FakeStage
+		releaseFetcher      ReleaseFetcher
+		releaseRef          birelmanifest.ReleaseRef
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockTarballProvider = mock_tarball.NewMockProvider(mockCtrl)
+
+		release = &fakerel.FakeRelease{}
+		release.NameReturns("fake-release-name")
+		release.VersionReturns("45.1")
+
+		releaseReader = &fakerel.FakeReader{}
+		releaseReader.ReadReturns(release, nil)
+
+		releaseManager = NewReleaseManager(boshlog.NewLogger(boshlog.LevelNone))
+		fakeStage = fakebiui.NewFakeStage()
+
+		releaseFetcher = NewReleaseFetcher(mockTarballProvider, releaseReader, releaseManager)
+
+		releaseRef = birelmanifest.ReleaseRef{Name: "fake-release-name", URL: "file://fake-release.tgz"}
+		mockTarballProvider.EXPECT().Get(releaseRef, fakeStage).Return("/fake-release.tgz", nil).AnyTimes()
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("adds the release when no version is specified", func() {
+		err := releaseFetcher.DownloadAndExtract(releaseRef, fakeStage)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(releaseManager.List()).To(HaveLen(1))
+	})
+
+	It("returns an error when the release name does not match", func() {
+		release.NameReturns("other-release-name")
+
+		err := releaseFetcher.DownloadAndExtract(releaseRef, fakeStage)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Release name 'fake-release-name' does not match the name in release tarball 'other-release-name'"))
+	})
+
+	Context("when the manifest specifies a version", func() {
+		It("adds the release when the tarball version satisfies it", func() {
+			releaseRef.Version = "~> 45"
+			mockTarballProvider.EXPECT().Get(releaseRef, fakeStage).Return("/fake-release.tgz", nil)
+
+			err := releaseFetcher.DownloadAndExtract(releaseRef, fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(releaseManager.List()).To(HaveLen(1))
+		})
+
+		It("returns an error with expected and actual versions when the tarball does not satisfy it", func() {
+			releaseRef.Version = "46"
+			mockTarballProvider.EXPECT().Get(releaseRef, fakeStage).Return("/fake-release.tgz", nil)
+
+			err := releaseFetcher.DownloadAndExtract(releaseRef, fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Release 'fake-release-name' version '45.1' in release tarball '/fake-release.tgz' does not satisfy version '46' from the manifest"))
+			Expect(releaseManager.List()).To(BeEmpty())
+		})
+	})
+
+	Describe("DownloadVerifyAndExtract", func() {
+		BeforeEach(func() {
+			fs := fakesys.NewFakeFileSystem()
+			fs.WriteFileString("/fake-release.tgz", "fake-archive-contents")
+
+			releaseFetcher = NewReleaseFetcherWithDigestVerifier(
+				mockTarballProvider, releaseReader, releaseManager, bicrypto.NewDigestVerifier(fs, bicrypto.NewChecksumProvider(false)))
+		})
+
+		It("adds the release when the tarball matches the digest", func() {
+			err := releaseFetcher.DownloadVerifyAndExtract(releaseRef, "4603db250d7b5b78dfe17869649784353177b549", fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(releaseManager.List()).To(HaveLen(1))
+		})
+
+		It("returns an error without extracting when the tarball does not match the digest", func() {
+			err := releaseFetcher.DownloadVerifyAndExtract(releaseRef, "sha256:fakesha256", fakeStage)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying release 'fake-release-name'"))
+			Expect(releaseReader.ReadCallCount()).To(Equal(0))
+			Expect(releaseManager.List()).To(BeEmpty())
+		})
+
+		Context("when the release reader verifies while reading", func() {
+			var verifyingReader *fakeVerifyingReleaseReader
+
+			BeforeEach(func() {
+				verifyingReader = &fakeVerifyingReleaseReader{FakeReader: releaseReader}
+
+				fs := fakesys.NewFakeFileSystem()
+				releaseFetcher = NewReleaseFetcherWithDigestVerifier(
+					mockTarballProvider, verifyingReader, releaseManager, bicrypto.NewDigestVerifier(fs, bicrypto.NewChecksumProvider(false)))
+			})
+
+			It("leaves verifying the tarball to the release reader", func() {
+				verifyingReader.release = release
+
+				err := releaseFetcher.DownloadVerifyAndExtract(releaseRef, "fake-digest", fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(verifyingReader.paths).To(Equal([]string{"/fake-release.tgz"}))
+				Expect(verifyingReader.digests).To(Equal([]string{"fake-digest"}))
+				Expect(releaseReader.ReadCallCount()).To(Equal(0))
+				Expect(releaseManager.List()).To(HaveLen(1))
+			})
+
+			It("returns an error when reading and verifying fails", func() {
+				verifyingReader.err = errors.New("fake-verify-err")
+
+				err := releaseFetcher.DownloadVerifyAndExtract(releaseRef, "fake-digest", fakeStage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Extracting release '/fake-release.tgz'"))
+				Expect(err.Error()).To(ContainSubstring("fake-verify-err"))
+				Expect(releaseManager.List()).To(BeEmpty())
+			})
+		})
+	})
+})
+
+type fakeVerifyingReleaseReader struct {
+	*fakerel.FakeReader
+
+	paths   []string
+	digests []string
+	release boshrel.Release
+	err     error
+}
+
+func (r *fakeVerifyingReleaseReader) ReadWithDigest(path string, digest string, _ bicrypto.DigestVerifier) (boshrel.Release, error) {
+	r.paths = append(r.paths, path)
+	r.digests = append(r.digests, digest)
+	return r.release, r.err
+}
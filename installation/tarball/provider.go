@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
 	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -32,6 +34,8 @@ type provider struct {
 	httpClient       *httpclient.HTTPClient
 	downloadAttempts int
 	delayTimeout     time.Duration
+	checksums        bicrypto.ChecksumProvider
+	attemptReporter  biretrier.AttemptReporter
 	logger           boshlog.Logger
 	logTag           string
 }
@@ -43,6 +47,23 @@ func NewProvider(
 	downloadAttempts int,
 	delayTimeout time.Duration,
 	logger boshlog.Logger,
+) Provider {
+	return NewProviderWithChecksums(
+		cache, fs, httpClient, downloadAttempts, delayTimeout, bicrypto.NewChecksumProvider(false), nil, logger)
+}
+
+// NewProviderWithChecksums verifies downloads only with the digest
+// algorithms that checksums allows. Download retries are reported to
+// attemptReporter when it is not nil.
+func NewProviderWithChecksums(
+	cache Cache,
+	fs boshsys.FileSystem,
+	httpClient *httpclient.HTTPClient,
+	downloadAttempts int,
+	delayTimeout time.Duration,
+	checksums bicrypto.ChecksumProvider,
+	attemptReporter biretrier.AttemptReporter,
+	logger boshlog.Logger,
 ) Provider {
 	return &provider{
 		cache:            cache,
@@ -50,6 +71,8 @@ func NewProvider(
 		httpClient:       httpClient,
 		downloadAttempts: downloadAttempts,
 		delayTimeout:     delayTimeout,
+		checksums:        checksums,
+		attemptReporter:  attemptReporter,
 
 		logTag: "tarballProvider",
 		logger: logger,
@@ -106,54 +129,99 @@ func (p *provider) Get(source Source, stage biui.Stage) (string, error) {
 	return expandedPath, nil
 }
 
+// downloadRetryable reports retries to the attempt reporter and keeps the
+// errors of failed attempts in the debug log
 func (p *provider) downloadRetryable(source Source) boshretry.Retryable {
+	attempt := 0
+
 	return boshretry.NewRetryable(func() (bool, error) {
-		downloadedFile, err := p.fs.TempFile("tarballProvider")
+		attempt++
+		if attempt > 1 && p.attemptReporter != nil {
+			p.attemptReporter.ReportAttempt("download", attempt, p.downloadAttempts)
+		}
+
+		shouldRetry, err := p.download(source)
 		if err != nil {
-			return true, bosherr.WrapError(err, "Unable to create temporary file")
+			p.logger.Debug(p.logTag, "Download attempt %d of %d from '%s' failed: %s", attempt, p.downloadAttempts, source.GetURL(), err.Error())
 		}
 
-		defer func() {
-			downloadedFile.Close()
+		return shouldRetry, err
+	})
+}
 
-			if err = p.fs.RemoveAll(downloadedFile.Name()); err != nil {
-				p.logger.Warn(p.logTag, "Failed to remove downloaded file: %s", err.Error())
-			}
-		}()
+func (p *provider) download(source Source) (bool, error) {
+	digest, err := boshcrypto.ParseMultipleDigest(source.GetSHA1())
+	if err != nil {
+		return true, err
+	}
 
-		response, err := p.httpClient.Get(source.GetURL())
-		if err != nil {
-			return true, bosherr.WrapError(err, "Unable to download")
-		}
+	// checked before downloading since retrying cannot change the outcome
+	digest, err = p.checksums.Verifiable(digest)
+	if err != nil {
+		return false, err
+	}
 
-		defer func() {
-			if err = response.Body.Close(); err != nil {
-				p.logger.Warn(p.logTag, "Failed to close download response body: %s", err.Error())
-			}
-		}()
+	downloadedFile, err := p.fs.TempFile("tarballProvider")
+	if err != nil {
+		return true, bosherr.WrapError(err, "Unable to create temporary file")
+	}
 
-		_, err = io.Copy(downloadedFile, response.Body)
-		if err != nil {
-			return true, bosherr.WrapError(err, "Saving downloaded bits to temporary file")
-		}
+	defer func() {
+		downloadedFile.Close()
 
-		digest, err := boshcrypto.ParseMultipleDigest(source.GetSHA1())
-		if err != nil {
-			return true, err
+		if err = p.fs.RemoveAll(downloadedFile.Name()); err != nil {
+			p.logger.Warn(p.logTag, "Failed to remove downloaded file: %s", err.Error())
 		}
+	}()
 
-		err = digest.VerifyFilePath(downloadedFile.Name(), p.fs)
-		if err != nil {
-			return true, bosherr.WrapError(err, "Verifying digest for downloaded file")
+	response, err := p.httpClient.Get(source.GetURL())
+	if err != nil {
+		return true, bosherr.WrapError(err, "Unable to download")
+	}
+
+	defer func() {
+		if err = response.Body.Close(); err != nil {
+			p.logger.Warn(p.logTag, "Failed to close download response body: %s", err.Error())
 		}
+	}()
 
-		downloadedFile.Close()
+	// Compute the digest while saving instead of reading the file again afterwards
+	fileWriter := &recordingWriter{writer: downloadedFile}
 
-		err = p.cache.Save(downloadedFile.Name(), source)
-		if err != nil {
-			return true, bosherr.WrapError(err, "Saving downloaded file in cache")
-		}
+	err = digest.Verify(io.TeeReader(response.Body, fileWriter))
+	if fileWriter.err != nil {
+		return true, bosherr.WrapError(fileWriter.err, "Saving downloaded bits to temporary file")
+	}
+	if err != nil {
+		return true, bosherr.WrapError(err, "Verifying digest for downloaded file")
+	}
 
-		return false, nil
-	})
+	downloadedFile.Close()
+
+	err = p.cache.Save(downloadedFile.Name(), source)
+	if err != nil {
+		return true, bosherr.WrapError(err, "Saving downloaded file in cache")
+	}
+
+	return false, nil
+}
+
+// recordingWriter keeps the first write error, which io.TeeReader would
+// otherwise report to the digest computation as a read error.
+type recordingWriter struct {
+	writer io.Writer
+	err    error
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+
+	n, err := w.writer.Write(p)
+	if err != nil {
+		w.err = err
+	}
+
+	return n, err
 }
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	. "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 	"github.com/cloudfoundry/bosh-utils/httpclient"
@@ -125,6 +126,8 @@ var _ = Describe("Provider", func() {
 
 				Context("when downloading succeds", func() {
 					BeforeEach(func() {
+						source = newFakeSource(server.URL(), "fab3c263ec568e150550b814e84b7898d477c3c2", "fake-description")
+
 						server.AppendHandlers(
 							ghttp.CombineHandlers(
 								ghttp.RespondWith(200, "fake-body"),
@@ -139,7 +142,7 @@ var _ = Describe("Provider", func() {
 						path, err := provider.Get(source, fakeStage)
 						Expect(err).ToNot(HaveOccurred())
 						shaSum := sha1.Sum([]byte(source.GetURL()))
-						expectedFileName := fmt.Sprintf("%x-da39a3ee5e6b4b0d3255bfef95601890afd80709", string(shaSum[:]))
+						expectedFileName := fmt.Sprintf("%x-fab3c263ec568e150550b814e84b7898d477c3c2", string(shaSum[:]))
 						Expect(path).To(Equal(filepath.Join("/", "fake-base-path", expectedFileName)))
 						Expect(server.ReceivedRequests()).To(HaveLen(1))
 					})
@@ -161,7 +164,7 @@ var _ = Describe("Provider", func() {
 						It("returns an error", func() {
 							_, err := provider.Get(source, fakeStage)
 							Expect(err).To(HaveOccurred())
-							Expect(err.Error()).To(ContainSubstring("Failed to download from '%s': Verifying digest for downloaded file: Expected stream to have digest 'expectedsha1' but was 'da39a3ee5e6b4b0d3255bfef95601890afd80709'", server.URL()))
+							Expect(err.Error()).To(ContainSubstring("Failed to download from '%s': Verifying digest for downloaded file: Expected stream to have digest 'expectedsha1' but was 'fab3c263ec568e150550b814e84b7898d477c3c2'", server.URL()))
 						})
 
 						It("retries downloading up to 3 times", func() {
@@ -180,6 +183,43 @@ var _ = Describe("Provider", func() {
 						})
 					})
 
+					Context("when only SHA-2 checksums are allowed", func() {
+						BeforeEach(func() {
+							logger := boshlog.NewLogger(boshlog.LevelNone)
+							httpClient := httpclient.NewHTTPClient(httpclient.DefaultClient, logger)
+							provider = NewProviderWithChecksums(cache, fs, httpClient, 3, 0, bicrypto.NewFIPSChecksumProvider(), nil, logger)
+						})
+
+						It("returns an error without downloading when the source only has a SHA-1 checksum", func() {
+							_, err := provider.Get(source, fakeStage)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("Checksum 'fab3c263ec568e150550b814e84b7898d477c3c2' has no SHA-256 or SHA-512 digest"))
+							Expect(server.ReceivedRequests()).To(BeEmpty())
+						})
+
+						It("verifies the SHA-256 checksum", func() {
+							source = newFakeSource(server.URL(), "sha1:fab3c263ec568e150550b814e84b7898d477c3c2;sha256:expectedsha256", "fake-description")
+
+							_, err := provider.Get(source, fakeStage)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("Expected stream to have digest 'sha256:expectedsha256'"))
+						})
+					})
+
+					Context("when saving the downloaded bits fails", func() {
+						BeforeEach(func() {
+							for _, tempFile := range fs.ReturnTempFiles {
+								Expect(tempFile.Close()).To(Succeed())
+							}
+						})
+
+						It("returns an error", func() {
+							_, err := provider.Get(source, fakeStage)
+							Expect(err).To(HaveOccurred())
+							Expect(err.Error()).To(ContainSubstring("Saving downloaded bits to temporary file"))
+						})
+					})
+
 					Context("when saving to cache fails", func() {
 						BeforeEach(func() {
 							// Creating cache base directory fails
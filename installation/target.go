@@ -30,6 +30,16 @@ func (t Target) TemplatesIndexPath() string {
 	return filepath.Join(t.path, "templates.json")
 }
 
+// OwnerPath records the deployment state the installation belongs to
+func (t Target) OwnerPath() string {
+	return filepath.Join(t.path, "deployment_state_path")
+}
+
+// RegistryPath keeps the registry settings of the installation across CLI runs
+func (t Target) RegistryPath() string {
+	return filepath.Join(t.path, "registry")
+}
+
 func (t Target) PackagesPath() string {
 	return filepath.Join(t.path, "packages")
 }
@@ -1,10 +1,13 @@
 package installation
 
 import (
+	"os"
 	"path/filepath"
 
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	bistatebackend "github.com/cloudfoundry/bosh-cli/config/statebackend"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 )
 
@@ -16,6 +19,7 @@ type targetProvider struct {
 	deploymentStateService biconfig.DeploymentStateService
 	uuidGenerator          boshuuid.Generator
 	installationsRootPath  string
+	fs                     boshsys.FileSystem
 }
 
 func NewTargetProvider(
@@ -30,6 +34,25 @@ func NewTargetProvider(
 	}
 }
 
+// NewTargetProviderWithOwners keeps the installation of each deployment
+// state to itself. The installation holds the rendered CPI job and the
+// registry settings, which contain the IaaS, mbus and registry credentials,
+// so a state copied from another deployment gets an installation of its own
+// instead of sharing the one of the installation_id it was copied with.
+func NewTargetProviderWithOwners(
+	deploymentStateService biconfig.DeploymentStateService,
+	uuidGenerator boshuuid.Generator,
+	installationsRootPath string,
+	fs boshsys.FileSystem,
+) TargetProvider {
+	return &targetProvider{
+		deploymentStateService: deploymentStateService,
+		uuidGenerator:          uuidGenerator,
+		installationsRootPath:  installationsRootPath,
+		fs:                     fs,
+	}
+}
+
 func (p *targetProvider) NewTarget() (Target, error) {
 	deploymentState, err := p.deploymentStateService.Load()
 	if err != nil {
@@ -37,7 +60,7 @@ func (p *targetProvider) NewTarget() (Target, error) {
 	}
 
 	installationID := deploymentState.InstallationID
-	if installationID == "" {
+	if installationID == "" || p.ownedByOtherDeployment(installationID) {
 		installationID, err = p.uuidGenerator.Generate()
 		if err != nil {
 			return Target{}, bosherr.WrapError(err, "Generating installation ID")
@@ -50,5 +73,61 @@ func (p *targetProvider) NewTarget() (Target, error) {
 		}
 	}
 
-	return NewTarget(filepath.Join(p.installationsRootPath, installationID)), nil
+	target := NewTarget(filepath.Join(p.installationsRootPath, installationID))
+
+	if p.fs != nil {
+		err = p.claim(target)
+		if err != nil {
+			return Target{}, err
+		}
+	}
+
+	return target, nil
+}
+
+// ownedByOtherDeployment tells whether the installation belongs to another
+// deployment state that still exists. Installations of moved states are
+// taken over so that their registry settings are kept. States kept in an
+// object store are not looked up and always count as existing.
+func (p *targetProvider) ownedByOtherDeployment(installationID string) bool {
+	if p.fs == nil {
+		return false
+	}
+
+	owner, err := p.fs.ReadFileString(NewTarget(filepath.Join(p.installationsRootPath, installationID)).OwnerPath())
+	if err != nil {
+		return false
+	}
+
+	if owner == p.deploymentStateService.Path() {
+		return false
+	}
+
+	if bistatebackend.IsRemote(owner) {
+		return true
+	}
+
+	return p.fs.FileExists(owner)
+}
+
+// claim records the deployment state as the owner of the installation and
+// keeps other users out of it. Installations created before owners were
+// recorded are claimed by the first deployment using them.
+func (p *targetProvider) claim(target Target) error {
+	err := p.fs.MkdirAll(target.Path(), os.FileMode(0700))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating installation directory '%s'", target.Path())
+	}
+
+	err = p.fs.Chmod(target.Path(), os.FileMode(0700))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting installation directory '%s' permissions", target.Path())
+	}
+
+	err = p.fs.WriteFileString(target.OwnerPath(), p.deploymentStateService.Path())
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Recording the owner of installation '%s'", target.Path())
+	}
+
+	return nil
 }
@@ -5,6 +5,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"os"
 	"path/filepath"
 
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
@@ -104,4 +105,90 @@ var _ = Describe("TargetProvider", func() {
 			Expect(deploymentState.InstallationID).To(Equal("fake-uuid-1"))
 		})
 	})
+
+	Context("with owners", func() {
+		var (
+			installationPath = filepath.Join("/", ".bosh", "installations", "12345")
+			ownerPath        = filepath.Join(installationPath, "deployment_state_path")
+		)
+
+		BeforeEach(func() {
+			targetProvider = NewTargetProviderWithOwners(deploymentStateService, fakeUUIDGenerator, installationsRootPath, fakeFS)
+
+			err := fakeFS.WriteFileString(configPath, `{"installation_id":"12345"}`)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("records the deployment state as the owner and keeps other users out", func() {
+			target, err := targetProvider.NewTarget()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Path()).To(Equal(installationPath))
+
+			Expect(fakeFS.ReadFileString(ownerPath)).To(Equal(configPath))
+
+			stat, err := fakeFS.Stat(installationPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		})
+
+		It("claims installations created before owners were recorded", func() {
+			err := fakeFS.MkdirAll(installationPath, os.FileMode(0755))
+			Expect(err).ToNot(HaveOccurred())
+
+			target, err := targetProvider.NewTarget()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(target.Path()).To(Equal(installationPath))
+			Expect(fakeFS.ReadFileString(ownerPath)).To(Equal(configPath))
+
+			stat, err := fakeFS.Stat(installationPath)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0700)))
+		})
+
+		Context("when the installation belongs to another deployment state", func() {
+			otherConfigPath := filepath.Join("/", "other-deployment.json")
+
+			BeforeEach(func() {
+				err := fakeFS.WriteFileString(ownerPath, otherConfigPath)
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("generates an installation of its own when the other state exists", func() {
+				err := fakeFS.WriteFileString(otherConfigPath, `{"installation_id":"12345"}`)
+				Expect(err).ToNot(HaveOccurred())
+
+				target, err := targetProvider.NewTarget()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target.Path()).To(Equal(filepath.Join("/", ".bosh", "installations", "fake-uuid-1")))
+
+				deploymentState, err := deploymentStateService.Load()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(deploymentState.InstallationID).To(Equal("fake-uuid-1"))
+
+				Expect(fakeFS.ReadFileString(ownerPath)).To(Equal(otherConfigPath))
+			})
+
+			It("takes the installation over when the other state was moved", func() {
+				target, err := targetProvider.NewTarget()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target.Path()).To(Equal(installationPath))
+				Expect(fakeFS.ReadFileString(ownerPath)).To(Equal(configPath))
+			})
+		})
+
+		Context("when the installation belongs to a deployment state in an object store", func() {
+			BeforeEach(func() {
+				err := fakeFS.WriteFileString(ownerPath, "s3://fake-bucket/other-deployment.json")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("generates an installation of its own without looking the state up on disk", func() {
+				target, err := targetProvider.NewTarget()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(target.Path()).To(Equal(filepath.Join("/", ".bosh", "installations", "fake-uuid-1")))
+
+				Expect(fakeFS.ReadFileString(ownerPath)).To(Equal("s3://fake-bucket/other-deployment.json"))
+			})
+		})
+	})
 })
@@ -7,6 +7,7 @@ import (
 	"text/template"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
@@ -26,14 +27,18 @@ import (
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
 	. "github.com/cloudfoundry/bosh-cli/cmd"
+	cmdconf "github.com/cloudfoundry/bosh-cli/cmd/config"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakebiconfig "github.com/cloudfoundry/bosh-cli/config/fakes"
 	bicpirel "github.com/cloudfoundry/bosh-cli/cpi/release"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	fakebicrypto "github.com/cloudfoundry/bosh-cli/crypto/fakes"
 	bidepl "github.com/cloudfoundry/bosh-cli/deployment"
 	bidisk "github.com/cloudfoundry/bosh-cli/deployment/disk"
 	biinstance "github.com/cloudfoundry/bosh-cli/deployment/instance"
 	mock_instance_state "github.com/cloudfoundry/bosh-cli/deployment/instance/state/mocks"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
+	bipostdeploy "github.com/cloudfoundry/bosh-cli/deployment/postdeploy"
 	bisshtunnel "github.com/cloudfoundry/bosh-cli/deployment/sshtunnel"
 	bidepltpl "github.com/cloudfoundry/bosh-cli/deployment/template"
 	bivm "github.com/cloudfoundry/bosh-cli/deployment/vm"
@@ -54,6 +59,7 @@ import (
 	fakebistemcell "github.com/cloudfoundry/bosh-cli/stemcell/stemcellfakes"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
 	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+	bii18n "github.com/cloudfoundry/bosh-cli/ui/i18n"
 	"github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 )
 
@@ -307,7 +313,7 @@ cloud_provider:
 			installedJob.Name = "fake-cpi-release-job-name"
 			installedJob.Path = filepath.Join(target.JobsPath(), "fake-cpi-release-job-name")
 
-			installation := biinstall.NewInstallation(target, installedJob, installationManifest, registryServerManager)
+			installation := biinstall.NewInstallation(target, installedJob, installationManifest, registryServerManager, nil)
 
 			mockInstallerFactory.EXPECT().NewInstaller(target).Return(mockInstaller).AnyTimes()
 
@@ -389,14 +395,15 @@ cloud_provider:
 
 			deploymentValidator := bideplmanifest.NewValidator(logger)
 
-			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory)
-			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, logger)
+			fakeInstanceStateRepo := fakebiconfig.NewFakeInstanceStateRepo()
+			instanceFactory := biinstance.NewFactory(mockStateBuilderFactory, fakeInstanceStateRepo)
+			instanceManagerFactory := biinstance.NewManagerFactory(sshTunnelFactory, instanceFactory, fakeInstanceStateRepo, logger)
 
 			pingTimeout := 1 * time.Second
 			pingDelay := 100 * time.Millisecond
-			deploymentFactory := bidepl.NewFactory(pingTimeout, pingDelay)
+			deploymentFactory := bidepl.NewFactory(pingTimeout, pingDelay, clock.NewClock())
 
-			ui := biui.NewWriterUI(stdOut, stdErr, logger)
+			ui := biui.NewNonInteractiveUI(biui.NewWriterUI(stdOut, stdErr, logger))
 			doGet := func(deploymentManifestPath string, statePath string, deploymentVars boshtpl.Variables, deploymentOp patch.Op) DeploymentPreparer {
 				// todo: figure this out?
 				deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, biconfig.DeploymentStatePath(deploymentManifestPath, statePath))
@@ -457,8 +464,12 @@ cloud_provider:
 					ui,
 					logger,
 					"deployCmd",
+					clock.NewClock(),
 					deploymentStateService,
 					legacyDeploymentStateMigrator,
+					vmRepo,
+					diskRepo,
+					fakeInstanceStateRepo,
 					releaseManager,
 					deploymentRecord,
 					mockCloudFactory,
@@ -477,10 +488,14 @@ cloud_provider:
 					deploymentManifestParser,
 					tempRootConfigurator,
 					targetProvider,
+					bicrypto.NewSHA512CryptHasher(),
+					bipostdeploy.NewChecker(clock.NewClock(), 1*time.Second, logger),
+					bii18n.NewDefaultCatalog(),
+					NewDestructiveConfirmation(ui, cmdconf.ConfirmationPolicy{}),
 				)
 			}
 
-			return NewCreateEnvCmd(ui, doGet)
+			return NewCreateEnvCmd(ui, doGet, NewDestructiveConfirmation(ui, cmdconf.ConfirmationPolicy{}))
 		}
 
 		var expectDeployFlow = func() {
@@ -502,6 +517,7 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(diskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(diskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{diskCID}, nil),
 
 				mockAgentClient.EXPECT().Apply(applySpec),
 				mockAgentClient.EXPECT().GetState(),
@@ -545,11 +561,13 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(newDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{newDiskCID}, nil),
 				mockAgentClient.EXPECT().MigrateDisk(),
 				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
@@ -593,11 +611,13 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(newDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{newDiskCID}, nil),
 				mockAgentClient.EXPECT().MigrateDisk(),
 				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
@@ -676,11 +696,13 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(newDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{newDiskCID}, nil),
 				mockAgentClient.EXPECT().MigrateDisk().Return(
 					bosherr.Error("fake-migration-error"),
 				),
@@ -716,11 +738,13 @@ cloud_provider:
 				mockCloud.EXPECT().SetDiskMetadata(oldDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(oldDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{oldDiskCID}, nil),
 				mockCloud.EXPECT().CreateDisk(newDiskSize, diskCloudProperties, newVMCID).Return(newDiskCID, nil),
 				mockCloud.EXPECT().AttachDisk(newVMCID, newDiskCID),
 				mockCloud.EXPECT().SetDiskMetadata(newDiskCID, gomock.Any()).Return(nil),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
 				mockAgentClient.EXPECT().MountDisk(newDiskCID),
+				mockAgentClient.EXPECT().ListDisk().Return([]string{newDiskCID}, nil),
 				mockAgentClient.EXPECT().MigrateDisk(),
 				mockCloud.EXPECT().DetachDisk(newVMCID, oldDiskCID),
 				mockAgentClient.EXPECT().Ping().Return("any-state", nil),
@@ -762,8 +786,9 @@ cloud_provider:
 			fakeRepoUUIDGenerator = fakeuuid.NewFakeGenerator()
 
 			mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+			mockCloud.EXPECT().Info().Return(bicloud.Info{}, nil).AnyTimes()
 
-			registryServerManager = biregistry.NewServerManager(logger)
+			registryServerManager = biregistry.NewServerManager(fs, logger)
 
 			releaseReader = &fakerel.FakeReader{}
 			releaseManager = biinstall.NewReleaseManager(logger)
@@ -1000,5 +1025,5 @@ cloud_provider:
 })
 
 func newDeployOpts(manifestPath string, statePath string) CreateEnvOpts {
-	return CreateEnvOpts{StatePath: statePath, Args: CreateEnvArgs{Manifest: FileBytesWithPathArg{Path: manifestPath}}}
+	return CreateEnvOpts{StatePath: statePath, Args: CreateEnvArgs{Manifest: EnvManifestArg{FileBytesWithPathArg: FileBytesWithPathArg{Path: manifestPath}}}}
 }
@@ -13,6 +13,7 @@ import (
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
 	boshcmd "github.com/cloudfoundry/bosh-cli/cmd"
+	bifault "github.com/cloudfoundry/bosh-cli/faultinjection"
 	bilog "github.com/cloudfoundry/bosh-cli/logger"
 	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	boshuifmt "github.com/cloudfoundry/bosh-cli/ui/fmt"
@@ -25,13 +26,27 @@ func main() {
 	ui := boshui.NewConfUI(logger)
 	defer ui.Flush()
 
-	cmdFactory := boshcmd.NewFactory(boshcmd.NewBasicDeps(ui, logger))
+	deps := boshcmd.NewBasicDeps(ui, logger)
+
+	faultInjector, err := bifault.NewInjectorFromEnv(os.Getenv)
+	if err != nil {
+		fail(err, ui, logger)
+	}
+	if faultInjector != nil {
+		deps = deps.WithFaultInjector(faultInjector)
+	}
+
+	cmdFactory := boshcmd.NewFactory(deps)
 
 	cmd, err := cmdFactory.New(os.Args[1:])
 	if err != nil {
 		fail(err, ui, logger)
 	}
 
+	if !cmd.HandlesInterrupts() {
+		go exitOnInterrupt(ui, logger)
+	}
+
 	err = cmd.Execute()
 	if err != nil {
 		fail(err, ui, logger)
@@ -118,10 +133,28 @@ func fail(err error, ui boshui.UI, logger boshlog.Logger) {
 	if err != nil {
 		logger.Error("CLI", err.Error())
 		ui.ErrorLinef(boshuifmt.MultilineError(err))
+		boshui.RecordEvent(ui, boshui.Event{Type: boshui.EventTypeError, Message: err.Error()})
 	}
-	ui.ErrorLinef("Exit code 1")
+	exit(boshcmd.ExitCode(err), ui)
+}
+
+// exitOnInterrupt flushes the output of commands stopped with SIGINT or
+// SIGTERM, which would otherwise be lost, e.g. the events of --json
+func exitOnInterrupt(ui boshui.UI, logger boshlog.Logger) {
+	c := make(chan os.Signal, 1)
+	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
+
+	sig := <-c
+	logger.Error("CLI", "Interrupted by %s", sig)
+	ui.ErrorLinef("Interrupted")
+	boshui.RecordEvent(ui, boshui.Event{Type: boshui.EventTypeError, Message: "Interrupted"})
+	exit(boshcmd.ExitCodeInterrupted, ui)
+}
+
+func exit(code int, ui boshui.UI) {
+	ui.ErrorLinef("Exit code %d", code)
 	ui.Flush() // todo make sure UI is flushed
-	os.Exit(1)
+	os.Exit(code)
 }
 
 func success(ui boshui.UI, logger boshlog.Logger) {
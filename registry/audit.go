@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	// AuditAuthNone is the auth result of requests without credentials,
+	// e.g. agents fetching their settings
+	AuditAuthNone     = "none"
+	AuditAuthAccepted = "accepted"
+	AuditAuthRejected = "rejected"
+)
+
+var auditInstanceIDRegexp = regexp.MustCompile("^/instances/([^/]+)")
+
+// AuditEntry is a line of the registry audit log
+type AuditEntry struct {
+	Time       string `json:"time"`
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	InstanceID string `json:"instance_id,omitempty"`
+	Auth       string `json:"auth"`
+	Status     int    `json:"status"`
+	LatencyMS  int64  `json:"latency_ms"`
+	RemoteAddr string `json:"remote_addr"`
+}
+
+type auditHandler struct {
+	handler      http.Handler
+	isAuthorized func(*http.Request) bool
+
+	// writer is nil when only the debug log is kept
+	writer io.Writer
+	lock   sync.Mutex
+
+	inFlight sync.WaitGroup
+
+	timeService biretrier.Clock
+	logger      boshlog.Logger
+	logTag      string
+}
+
+// newAuditHandler logs every request to handler at debug level, and writes
+// it to writer as a line of JSON when writer is not nil, so that a failed
+// agent bootstrap can be told apart from an agent that never asked
+func newAuditHandler(handler http.Handler, isAuthorized func(*http.Request) bool, writer io.Writer, timeService biretrier.Clock, logger boshlog.Logger) *auditHandler {
+	return &auditHandler{
+		handler:      handler,
+		isAuthorized: isAuthorized,
+		writer:       writer,
+		timeService:  timeService,
+		logger:       logger,
+		logTag:       "registryAudit",
+	}
+}
+
+func (h *auditHandler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	h.inFlight.Add(1)
+	defer h.inFlight.Done()
+
+	startTime := h.timeService.Now()
+
+	recorder := &statusRecorder{ResponseWriter: w}
+	h.handler.ServeHTTP(recorder, req)
+
+	entry := AuditEntry{
+		Time:       startTime.UTC().Format(time.RFC3339Nano),
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		InstanceID: h.instanceID(req),
+		Auth:       h.auth(req),
+		Status:     recorder.status(),
+		LatencyMS:  int64(h.timeService.Now().Sub(startTime) / time.Millisecond),
+		RemoteAddr: req.RemoteAddr,
+	}
+
+	h.logger.Debug(h.logTag, "%s %s instance=%s auth=%s status=%d latency=%dms remote=%s",
+		entry.Method, entry.Path, entry.InstanceID, entry.Auth, entry.Status, entry.LatencyMS, entry.RemoteAddr)
+
+	if h.writer != nil {
+		h.write(entry)
+	}
+}
+
+// wait blocks until the requests being handled are done
+func (h *auditHandler) wait() {
+	h.inFlight.Wait()
+}
+
+func (h *auditHandler) write(entry AuditEntry) {
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Marshalling audit entry: %s", err.Error())
+		return
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	_, err = h.writer.Write(append(bytes, '\n'))
+	if err != nil {
+		h.logger.Warn(h.logTag, "Writing audit entry: %s", err.Error())
+	}
+}
+
+func (h *auditHandler) instanceID(req *http.Request) string {
+	matches := auditInstanceIDRegexp.FindStringSubmatch(req.URL.Path)
+	if len(matches) == 0 {
+		return ""
+	}
+
+	return matches[1]
+}
+
+func (h *auditHandler) auth(req *http.Request) string {
+	if req.Header.Get("Authorization") == "" {
+		return AuditAuthNone
+	}
+
+	if h.isAuthorized(req) {
+		return AuditAuthAccepted
+	}
+
+	return AuditAuthRejected
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (r *statusRecorder) WriteHeader(statusCode int) {
+	if r.statusCode == 0 {
+		r.statusCode = statusCode
+	}
+
+	r.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (r *statusRecorder) Write(bytes []byte) (int, error) {
+	if r.statusCode == 0 {
+		r.statusCode = http.StatusOK
+	}
+
+	return r.ResponseWriter.Write(bytes)
+}
+
+func (r *statusRecorder) status() int {
+	if r.statusCode == 0 {
+		return http.StatusOK
+	}
+
+	return r.statusCode
+}
@@ -0,0 +1,113 @@
+package registry
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+// modificationTimes remembers when registry entries last changed so that
+// agents polling their settings can be answered with 304 Not Modified.
+// Entries loaded from a store have no recorded time and count as modified
+// when the server started.
+type modificationTimes struct {
+	times       map[string]time.Time
+	startedAt   time.Time
+	timeService biretrier.Clock
+	lock        sync.Mutex
+}
+
+func newModificationTimes(timeService biretrier.Clock) *modificationTimes {
+	return &modificationTimes{
+		times:       map[string]time.Time{},
+		startedAt:   timeService.Now(),
+		timeService: timeService,
+	}
+}
+
+func (m *modificationTimes) Touch(key string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.times[key] = m.timeService.Now()
+}
+
+// Latest returns when the most recently changed of keys was modified
+func (m *modificationTimes) Latest(keys ...string) time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	latest := m.startedAt
+	for _, key := range keys {
+		if modTime, found := m.times[key]; found && modTime.After(latest) {
+			latest = modTime
+		}
+	}
+
+	return latest
+}
+
+func (m *modificationTimes) Now() time.Time {
+	return m.timeService.Now()
+}
+
+// isNotModified follows RFC 7232: HTTP dates have a resolution of one
+// second, so If-Modified-Since is compared against the truncated time
+func isNotModified(req *http.Request, modTime time.Time) bool {
+	header := req.Header.Get("If-Modified-Since")
+	if header == "" {
+		return false
+	}
+
+	since, err := http.ParseTime(header)
+	if err != nil {
+		return false
+	}
+
+	return !modTime.Truncate(time.Second).After(since)
+}
+
+// acceptsGzip ignores the q-values of other codings but honours gzip;q=0
+func acceptsGzip(req *http.Request) bool {
+	for _, coding := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		parts := strings.Split(coding, ";")
+		if strings.TrimSpace(parts[0]) != "gzip" {
+			continue
+		}
+
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, "q=") {
+				continue
+			}
+
+			q, err := strconv.ParseFloat(param[len("q="):], 64)
+			if err == nil && q == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+func writeGzipped(w http.ResponseWriter, body []byte) error {
+	w.Header().Set("Content-Encoding", "gzip")
+
+	gzipWriter := gzip.NewWriter(w)
+
+	_, err := gzipWriter.Write(body)
+	if err != nil {
+		gzipWriter.Close()
+		return err
+	}
+
+	return gzipWriter.Close()
+}
@@ -0,0 +1,72 @@
+package fakes
+
+import (
+	"github.com/cloudfoundry/bosh-cli/registry"
+)
+
+type FakeServerManager struct {
+	StartInputs []StartInput
+	StartServer *FakeServer
+	StartErr    error
+}
+
+type StartInput struct {
+	Username  string
+	Password  string
+	Host      string
+	Port      int
+	Limits    registry.Limits
+	ServerTLS registry.TLS
+	Store     registry.Store
+}
+
+type FakeServer struct {
+	SettingsOverlay       []byte
+	SetSettingsOverlayErr error
+
+	StopCalled bool
+	StopErr    error
+}
+
+func NewFakeServerManager() *FakeServerManager {
+	return &FakeServerManager{
+		StartInputs: []StartInput{},
+		StartServer: &FakeServer{},
+	}
+}
+
+func (m *FakeServerManager) Start(
+	username string,
+	password string,
+	host string,
+	port int,
+	limits registry.Limits,
+	serverTLS registry.TLS,
+	store registry.Store,
+) (registry.Server, error) {
+	m.StartInputs = append(m.StartInputs, StartInput{
+		Username:  username,
+		Password:  password,
+		Host:      host,
+		Port:      port,
+		Limits:    limits,
+		ServerTLS: serverTLS,
+		Store:     store,
+	})
+
+	if m.StartErr != nil {
+		return nil, m.StartErr
+	}
+
+	return m.StartServer, nil
+}
+
+func (s *FakeServer) SetSettingsOverlay(overlayJSON []byte) error {
+	s.SettingsOverlay = overlayJSON
+	return s.SetSettingsOverlayErr
+}
+
+func (s *FakeServer) Stop() error {
+	s.StopCalled = true
+	return s.StopErr
+}
@@ -3,33 +3,66 @@ package registry
 import (
 	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"sort"
+	"sync"
+	"time"
 
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
+const settingsTemplateKey = "settings"
+
+const templateModificationKey = "templates/" + settingsTemplateKey
+
+const overlayModificationKey = "overlay"
+
 type instanceHandler struct {
-	username string
-	password string
-	registry Registry
-	logger   boshlog.Logger
-	logTag   string
+	username  string
+	password  string
+	registry  Registry
+	overrides Registry
+	templates Registry
+	metadata  instanceMetadata
+	limits    Limits
+	envelope  SettingsEnvelope
+	modTimes  *modificationTimes
+	logger    boshlog.Logger
+	logTag    string
+
+	overlayLock sync.RWMutex
+	overlay     []byte
 }
 
 func newInstanceHandler(
 	username string,
 	password string,
 	registry Registry,
+	overrides Registry,
+	templates Registry,
+	metadata Registry,
+	limits Limits,
+	envelope SettingsEnvelope,
+	timeService biretrier.Clock,
 	logger boshlog.Logger,
 ) *instanceHandler {
 	return &instanceHandler{
-		username: username,
-		password: password,
-		registry: registry,
-		logger:   logger,
-		logTag:   "registryInstanceHandler",
+		username:  username,
+		password:  password,
+		registry:  registry,
+		overrides: overrides,
+		templates: templates,
+		metadata:  instanceMetadata{registry: metadata, timeService: timeService},
+		limits:    limits,
+		envelope:  envelope,
+		modTimes:  newModificationTimes(timeService),
+		logger:    logger,
+		logTag:    "registryInstanceHandler",
 	}
 }
 
@@ -38,9 +71,15 @@ type SettingsResponse struct {
 	Status   string `json:"status"`
 }
 
+type InstanceSettingsResponse struct {
+	InstanceID string            `json:"instance_id"`
+	Settings   string            `json:"settings"`
+	Metadata   *InstanceMetadata `json:"metadata,omitempty"`
+}
+
 func (h *instanceHandler) HandleFunc(w http.ResponseWriter, req *http.Request) {
 	h.logger.Debug(h.logTag, "Received %s %s", req.Method, req.URL.Path)
-	instanceID, ok := h.getInstanceID(req)
+	instanceID, resource, ok := h.getInstanceID(req)
 	if !ok {
 		h.logger.Debug(h.logTag, "Instance ID not found in request:", req.Method)
 		h.handleNotFound(w)
@@ -49,6 +88,11 @@ func (h *instanceHandler) HandleFunc(w http.ResponseWriter, req *http.Request) {
 
 	h.logger.Debug(h.logTag, "Found instance ID in request: %s", instanceID)
 
+	if resource == "overrides" {
+		h.handleOverrides(instanceID, w, req)
+		return
+	}
+
 	switch req.Method {
 	case "GET":
 		h.HandleGet(instanceID, w, req)
@@ -66,27 +110,57 @@ func (h *instanceHandler) HandleFunc(w http.ResponseWriter, req *http.Request) {
 }
 
 func (h *instanceHandler) HandleGet(instanceID string, w http.ResponseWriter, req *http.Request) {
+	modTime := h.modTimes.Latest("settings/"+instanceID, overlayModificationKey)
+
 	settingsJSON, ok := h.registry.Get(instanceID)
+	if !ok {
+		settingsJSON, ok = h.renderTemplate(instanceID)
+		modTime = h.modTimes.Latest("overrides/"+instanceID, templateModificationKey, overlayModificationKey)
+	}
 	if !ok {
 		h.logger.Debug(h.logTag, "No settings for %s found", instanceID)
 		h.handleNotFound(w)
 		return
 	}
 
+	settingsJSON = h.applyOverlay(instanceID, settingsJSON)
+
 	h.logger.Debug(h.logTag, "Found settings for instance %s: %s", instanceID, string(settingsJSON))
 
-	response := SettingsResponse{
-		Settings: string(settingsJSON),
-		Status:   "ok",
+	if metadata, found := h.metadata.RecordFetch(instanceID, h.requestSource(req)); found {
+		h.logMetadata(instanceID, metadata)
 	}
 
-	responseJSON, err := json.Marshal(response)
+	h.writeSettings(w, req, settingsJSON, modTime)
+}
+
+// writeSettings answers conditional GETs and compresses the response for
+// agents that accept gzip. Last-Modified is only sent once modTime is in a
+// past second, otherwise a change later in the same second would be hidden
+// behind a 304.
+func (h *instanceHandler) writeSettings(w http.ResponseWriter, req *http.Request, settingsJSON []byte, modTime time.Time) {
+	w.Header().Set("Vary", "Accept-Encoding")
+
+	if modTime.Truncate(time.Second).Before(h.modTimes.Now().Truncate(time.Second)) {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+
+		if isNotModified(req, modTime) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	responseJSON, err := h.envelope.wrap(settingsJSON)
 	if err != nil {
 		h.handleBadRequest(w)
 		return
 	}
 
-	_, err = w.Write(responseJSON)
+	if acceptsGzip(req) {
+		err = writeGzipped(w, responseJSON)
+	} else {
+		_, err = w.Write(responseJSON)
+	}
 	if err != nil {
 		h.logger.Warn(h.logTag, "Couldn't write response: %s", err.Error())
 	}
@@ -100,8 +174,18 @@ func (h *instanceHandler) HandlePut(instanceID string, w http.ResponseWriter, re
 		return
 	}
 
-	reqBody, err := ioutil.ReadAll(req.Body)
+	reqBody, ok := h.readBody(w, req)
+	if !ok {
+		return
+	}
+
+	if !h.hasRoomFor(h.registry, instanceID, w) {
+		return
+	}
+
+	reqBody, err := h.envelope.unwrap(reqBody)
 	if err != nil {
+		h.logger.Warn(h.logTag, "Rejecting settings for instance %s: %s", instanceID, err.Error())
 		h.handleBadRequest(w)
 		return
 	}
@@ -109,6 +193,8 @@ func (h *instanceHandler) HandlePut(instanceID string, w http.ResponseWriter, re
 	h.logger.Debug(h.logTag, "Saving settings to registry for instance %s: %s", instanceID, string(reqBody))
 
 	isUpdated := h.registry.Save(instanceID, reqBody)
+	h.modTimes.Touch("settings/" + instanceID)
+	h.logMetadata(instanceID, h.metadata.RecordUpdate(instanceID, h.requestSource(req)))
 	if isUpdated {
 		w.WriteHeader(http.StatusOK)
 		return
@@ -125,6 +211,283 @@ func (h *instanceHandler) HandleDelete(instanceID string, w http.ResponseWriter,
 
 	h.logger.Debug(h.logTag, "Deleting settings for instance %s", instanceID)
 	h.registry.Delete(instanceID)
+	h.overrides.Delete(instanceID)
+	h.metadata.Delete(instanceID)
+	h.modTimes.Touch("settings/" + instanceID)
+	h.modTimes.Touch("overrides/" + instanceID)
+}
+
+// HandleListFunc lists the settings of all instances, including those
+// rendered from overrides, for debugging agent bootstrap. Settings contain
+// agent credentials so unlike settings GETs listing requires authentication.
+func (h *instanceHandler) HandleListFunc(w http.ResponseWriter, req *http.Request) {
+	h.logger.Debug(h.logTag, "Received %s %s", req.Method, req.URL.Path)
+
+	if req.Method != "GET" {
+		h.handleNotFound(w)
+		return
+	}
+
+	if !h.isAuthorized(req) {
+		h.handleUnauthorized(w)
+		return
+	}
+
+	instanceIDs := h.registry.Keys()
+	for _, instanceID := range h.overrides.Keys() {
+		if _, found := h.registry.Get(instanceID); !found {
+			instanceIDs = append(instanceIDs, instanceID)
+		}
+	}
+	sort.Strings(instanceIDs)
+
+	response := []InstanceSettingsResponse{}
+	for _, instanceID := range instanceIDs {
+		settingsJSON, ok := h.registry.Get(instanceID)
+		if !ok {
+			settingsJSON, ok = h.renderTemplate(instanceID)
+		}
+		if !ok {
+			continue
+		}
+
+		settingsJSON = h.applyOverlay(instanceID, settingsJSON)
+
+		instanceResponse := InstanceSettingsResponse{InstanceID: instanceID, Settings: string(settingsJSON)}
+		if metadata, found := h.metadata.Get(instanceID); found {
+			instanceResponse.Metadata = &metadata
+		}
+
+		response = append(response, instanceResponse)
+	}
+
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		h.handleBadRequest(w)
+		return
+	}
+
+	_, err = w.Write(responseJSON)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Couldn't write response: %s", err.Error())
+	}
+}
+
+// renderTemplate synthesizes settings for instances that only registered overrides
+func (h *instanceHandler) renderTemplate(instanceID string) ([]byte, bool) {
+	overridesJSON, ok := h.overrides.Get(instanceID)
+	if !ok {
+		return nil, false
+	}
+
+	templateJSON, ok := h.templates.Get(settingsTemplateKey)
+	if !ok {
+		h.logger.Debug(h.logTag, "Found overrides for instance %s but no settings template", instanceID)
+		return nil, false
+	}
+
+	settingsJSON, err := renderSettings(templateJSON, overridesJSON)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to render settings for instance %s: %s", instanceID, err.Error())
+		return nil, false
+	}
+
+	return settingsJSON, true
+}
+
+func (h *instanceHandler) setOverlay(overlayJSON []byte) {
+	h.overlayLock.Lock()
+	h.overlay = overlayJSON
+	h.overlayLock.Unlock()
+
+	h.modTimes.Touch(overlayModificationKey)
+}
+
+// applyOverlay merges the overlay into the settings served for an instance,
+// the saved settings are left as the CPI sent them
+func (h *instanceHandler) applyOverlay(instanceID string, settingsJSON []byte) []byte {
+	h.overlayLock.RLock()
+	overlayJSON := h.overlay
+	h.overlayLock.RUnlock()
+
+	if overlayJSON == nil {
+		return settingsJSON
+	}
+
+	mergedJSON, err := renderSettings(settingsJSON, overlayJSON)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to apply settings overlay for instance %s: %s", instanceID, err.Error())
+		return settingsJSON
+	}
+
+	return mergedJSON
+}
+
+func (h *instanceHandler) logMetadata(instanceID string, metadata InstanceMetadata) {
+	fetched := "never"
+	if metadata.FetchedAt != nil {
+		fetched = fmt.Sprintf("%s by %s", metadata.FetchedAt.Format(time.RFC3339), metadata.FetchedBy)
+	}
+
+	h.logger.Debug(h.logTag, "Settings of instance %s created at %s, updated at %s by %s, fetched %s",
+		instanceID, metadata.CreatedAt.Format(time.RFC3339), metadata.UpdatedAt.Format(time.RFC3339), metadata.UpdatedBy, fetched)
+}
+
+func (h *instanceHandler) handleOverrides(instanceID string, w http.ResponseWriter, req *http.Request) {
+	switch req.Method {
+	case "PUT":
+		if !h.isAuthorized(req) {
+			h.handleUnauthorized(w)
+			return
+		}
+
+		reqBody, ok := h.readJSONHash(w, req)
+		if !ok {
+			return
+		}
+
+		if !h.hasRoomFor(h.overrides, instanceID, w) {
+			return
+		}
+
+		h.logger.Debug(h.logTag, "Saving overrides to registry for instance %s: %s", instanceID, string(reqBody))
+
+		isUpdated := h.overrides.Save(instanceID, reqBody)
+		h.modTimes.Touch("overrides/" + instanceID)
+		h.logMetadata(instanceID, h.metadata.RecordUpdate(instanceID, h.requestSource(req)))
+		if isUpdated {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		if !h.isAuthorized(req) {
+			h.handleUnauthorized(w)
+			return
+		}
+
+		h.logger.Debug(h.logTag, "Deleting overrides for instance %s", instanceID)
+		h.overrides.Delete(instanceID)
+		h.modTimes.Touch("overrides/" + instanceID)
+	default:
+		h.handleNotFound(w)
+	}
+}
+
+// HandleTemplateFunc serves the settings template used for instances that
+// register overrides instead of fully rendered settings.
+func (h *instanceHandler) HandleTemplateFunc(w http.ResponseWriter, req *http.Request) {
+	h.logger.Debug(h.logTag, "Received %s %s", req.Method, req.URL.Path)
+
+	if req.URL.Path != "/templates/settings" {
+		h.handleNotFound(w)
+		return
+	}
+
+	switch req.Method {
+	case "GET":
+		templateJSON, ok := h.templates.Get(settingsTemplateKey)
+		if !ok {
+			h.handleNotFound(w)
+			return
+		}
+
+		h.writeSettings(w, req, templateJSON, h.modTimes.Latest(templateModificationKey))
+	case "PUT":
+		if !h.isAuthorized(req) {
+			h.handleUnauthorized(w)
+			return
+		}
+
+		reqBody, ok := h.readJSONHash(w, req)
+		if !ok {
+			return
+		}
+
+		h.logger.Debug(h.logTag, "Saving settings template to registry: %s", string(reqBody))
+
+		isUpdated := h.templates.Save(settingsTemplateKey, reqBody)
+		h.modTimes.Touch(templateModificationKey)
+		if isUpdated {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		if !h.isAuthorized(req) {
+			h.handleUnauthorized(w)
+			return
+		}
+
+		h.logger.Debug(h.logTag, "Deleting settings template")
+		h.templates.Delete(settingsTemplateKey)
+		h.modTimes.Touch(templateModificationKey)
+	default:
+		h.handleNotFound(w)
+	}
+}
+
+func (h *instanceHandler) readJSONHash(w http.ResponseWriter, req *http.Request) ([]byte, bool) {
+	reqBody, ok := h.readBody(w, req)
+	if !ok {
+		return nil, false
+	}
+
+	var hash map[string]interface{}
+	err := json.Unmarshal(reqBody, &hash)
+	if err != nil {
+		h.logger.Debug(h.logTag, "Received invalid JSON hash: %s", err.Error())
+		h.handleBadRequest(w)
+		return nil, false
+	}
+
+	return reqBody, true
+}
+
+// readBody reads at most one byte past the size limit so that oversized
+// bodies are rejected without buffering all of them
+func (h *instanceHandler) readBody(w http.ResponseWriter, req *http.Request) ([]byte, bool) {
+	maxSize := h.limits.maxSettingsSize()
+
+	if req.ContentLength > maxSize {
+		h.handleTooLarge(w, req.ContentLength)
+		return nil, false
+	}
+
+	reqBody, err := ioutil.ReadAll(io.LimitReader(req.Body, maxSize+1))
+	if err != nil {
+		h.handleBadRequest(w)
+		return nil, false
+	}
+
+	if int64(len(reqBody)) > maxSize {
+		h.handleTooLarge(w, int64(len(reqBody)))
+		return nil, false
+	}
+
+	return reqBody, true
+}
+
+// hasRoomFor allows updating existing instances even when at the limit
+func (h *instanceHandler) hasRoomFor(registry Registry, instanceID string, w http.ResponseWriter) bool {
+	if _, exists := registry.Get(instanceID); exists {
+		return true
+	}
+
+	if registry.Len() < h.limits.maxInstances() {
+		return true
+	}
+
+	h.logger.Warn(h.logTag, "Rejecting instance %s: registry already holds %d instances", instanceID, registry.Len())
+	h.writeStatus(w, http.StatusTooManyRequests, "too_many_instances")
+	return false
+}
+
+func (h *instanceHandler) handleTooLarge(w http.ResponseWriter, size int64) {
+	h.logger.Warn(h.logTag, "Rejecting settings of at least %d bytes: limit is %d bytes", size, h.limits.maxSettingsSize())
+	h.writeStatus(w, http.StatusRequestEntityTooLarge, "too_large")
 }
 
 func (h *instanceHandler) handleUnauthorized(w http.ResponseWriter) {
@@ -133,15 +496,15 @@ func (h *instanceHandler) handleUnauthorized(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusUnauthorized)
 }
 
-func (h *instanceHandler) getInstanceID(req *http.Request) (string, bool) {
-	re := regexp.MustCompile("/instances/([^/]+)/settings")
+func (h *instanceHandler) getInstanceID(req *http.Request) (string, string, bool) {
+	re := regexp.MustCompile("/instances/([^/]+)/(settings|overrides)")
 	matches := re.FindStringSubmatch(req.URL.Path)
 
 	if len(matches) == 0 {
-		return "", false
+		return "", "", false
 	}
 
-	return matches[1], true
+	return matches[1], matches[2], true
 }
 
 func (h *instanceHandler) isAuthorized(req *http.Request) bool {
@@ -176,3 +539,16 @@ func (h *instanceHandler) handleBadRequest(w http.ResponseWriter) {
 		h.logger.Warn(h.logTag, "Failed to write response: %s", err.Error())
 	}
 }
+
+func (h *instanceHandler) writeStatus(w http.ResponseWriter, statusCode int, status string) {
+	w.WriteHeader(statusCode)
+	settingsJSON, err := json.Marshal(SettingsResponse{Status: status})
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to marshal '%s' settings response %s", status, err.Error())
+		return
+	}
+	_, err = w.Write(settingsJSON)
+	if err != nil {
+		h.logger.Warn(h.logTag, "Failed to write response: %s", err.Error())
+	}
+}
@@ -0,0 +1,106 @@
+package registry
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+const (
+	// SourceCLI is recorded for requests authenticated with the registry
+	// credentials, which the CLI hands to the CPI
+	SourceCLI = "cli"
+
+	// SourceAgent is recorded for unauthenticated requests, which come from
+	// agents fetching their settings
+	SourceAgent = "agent"
+)
+
+// InstanceMetadata records when the settings of an instance were written
+// and fetched, to tell whether an agent that failed to bootstrap ever got
+// its settings
+type InstanceMetadata struct {
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy string    `json:"updated_by"`
+
+	FetchedAt *time.Time `json:"fetched_at,omitempty"`
+	FetchedBy string     `json:"fetched_by,omitempty"`
+}
+
+// instanceMetadata keeps the metadata of each instance as JSON in a
+// registry so that it is persisted with the settings
+type instanceMetadata struct {
+	registry    Registry
+	timeService biretrier.Clock
+}
+
+func (m instanceMetadata) Get(instanceID string) (InstanceMetadata, bool) {
+	var metadata InstanceMetadata
+
+	metadataJSON, found := m.registry.Get(instanceID)
+	if !found {
+		return metadata, false
+	}
+
+	err := json.Unmarshal(metadataJSON, &metadata)
+	if err != nil {
+		return metadata, false
+	}
+
+	return metadata, true
+}
+
+func (m instanceMetadata) RecordUpdate(instanceID string, source string) InstanceMetadata {
+	now := m.timeService.Now().UTC()
+
+	metadata, found := m.Get(instanceID)
+	if !found {
+		metadata = InstanceMetadata{CreatedAt: now}
+	}
+
+	metadata.UpdatedAt = now
+	metadata.UpdatedBy = source
+
+	m.save(instanceID, metadata)
+
+	return metadata
+}
+
+func (m instanceMetadata) RecordFetch(instanceID string, source string) (InstanceMetadata, bool) {
+	metadata, found := m.Get(instanceID)
+	if !found {
+		return metadata, false
+	}
+
+	now := m.timeService.Now().UTC()
+	metadata.FetchedAt = &now
+	metadata.FetchedBy = source
+
+	m.save(instanceID, metadata)
+
+	return metadata, true
+}
+
+func (m instanceMetadata) Delete(instanceID string) {
+	m.registry.Delete(instanceID)
+}
+
+func (m instanceMetadata) save(instanceID string, metadata InstanceMetadata) {
+	metadataJSON, err := json.Marshal(metadata)
+	if err != nil {
+		return
+	}
+
+	m.registry.Save(instanceID, metadataJSON)
+}
+
+func (h *instanceHandler) requestSource(req *http.Request) string {
+	if h.isAuthorized(req) {
+		return SourceCLI
+	}
+
+	return SourceAgent
+}
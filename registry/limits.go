@@ -0,0 +1,46 @@
+package registry
+
+import (
+	"time"
+)
+
+const (
+	DefaultMaxSettingsSize = 1024 * 1024
+	DefaultMaxInstances    = 100
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// Limits protect the registry from payloads that are unlikely to be agent
+// settings, such as multi-MB PUTs produced by misrendered templates.
+// Zero values use the defaults.
+type Limits struct {
+	// MaxSettingsSize is the largest accepted PUT body in bytes
+	MaxSettingsSize int64
+
+	// MaxInstances is how many instances may have settings or overrides
+	MaxInstances int
+
+	// ShutdownTimeout is how long stopping waits for requests in flight
+	ShutdownTimeout time.Duration
+}
+
+func (l Limits) maxSettingsSize() int64 {
+	if l.MaxSettingsSize > 0 {
+		return l.MaxSettingsSize
+	}
+	return DefaultMaxSettingsSize
+}
+
+func (l Limits) maxInstances() int {
+	if l.MaxInstances > 0 {
+		return l.MaxInstances
+	}
+	return DefaultMaxInstances
+}
+
+func (l Limits) shutdownTimeout() time.Duration {
+	if l.ShutdownTimeout > 0 {
+		return l.ShutdownTimeout
+	}
+	return DefaultShutdownTimeout
+}
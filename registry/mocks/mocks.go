@@ -33,6 +33,18 @@ func (m *MockServer) EXPECT() *MockServerMockRecorder {
 	return m.recorder
 }
 
+// SetSettingsOverlay mocks base method
+func (m *MockServer) SetSettingsOverlay(arg0 []byte) error {
+	ret := m.ctrl.Call(m, "SetSettingsOverlay", arg0)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetSettingsOverlay indicates an expected call of SetSettingsOverlay
+func (mr *MockServerMockRecorder) SetSettingsOverlay(arg0 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSettingsOverlay", reflect.TypeOf((*MockServer)(nil).SetSettingsOverlay), arg0)
+}
+
 // Stop mocks base method
 func (m *MockServer) Stop() error {
 	ret := m.ctrl.Call(m, "Stop")
@@ -69,14 +81,14 @@ func (m *MockServerManager) EXPECT() *MockServerManagerMockRecorder {
 }
 
 // Start mocks base method
-func (m *MockServerManager) Start(arg0, arg1, arg2 string, arg3 int) (registry.Server, error) {
-	ret := m.ctrl.Call(m, "Start", arg0, arg1, arg2, arg3)
+func (m *MockServerManager) Start(arg0, arg1, arg2 string, arg3 int, arg4 registry.Limits, arg5 registry.TLS, arg6 registry.Store, arg7 string, arg8 registry.SettingsEnvelope) (registry.Server, error) {
+	ret := m.ctrl.Call(m, "Start", arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 	ret0, _ := ret[0].(registry.Server)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Start indicates an expected call of Start
-func (mr *MockServerManagerMockRecorder) Start(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockServerManager)(nil).Start), arg0, arg1, arg2, arg3)
+func (mr *MockServerManagerMockRecorder) Start(arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockServerManager)(nil).Start), arg0, arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8)
 }
@@ -1,11 +1,17 @@
 package registry
 
+import "sort"
+
 type registry map[string][]byte
 
 type Registry interface {
 	Save(string, []byte) bool
 	Get(string) ([]byte, bool)
 	Delete(string)
+	Len() int
+
+	// Keys returns the saved keys in order
+	Keys() []string
 }
 
 func NewRegistry() Registry {
@@ -28,3 +34,18 @@ func (r registry) Get(key string) ([]byte, bool) {
 func (r registry) Delete(key string) {
 	delete(r, key)
 }
+
+func (r registry) Len() int {
+	return len(r)
+}
+
+func (r registry) Keys() []string {
+	keys := make([]string, 0, len(r))
+	for key := range r {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -1,92 +1,272 @@
 package registry
 
 import (
-	"fmt"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
 
+	"code.cloudfoundry.org/clock"
+	biretrier "github.com/cloudfoundry/bosh-cli/retrier"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	listenAttempts     = 8
+	listenInitialDelay = 100 * time.Millisecond
+	listenMaxDelay     = 2 * time.Second
 )
 
 type ServerManager interface {
-	Start(string, string, string, int) (Server, error)
+	Start(username string, password string, host string, port int, limits Limits, serverTLS TLS, store Store, auditLogPath string, settingsEnvelope SettingsEnvelope) (Server, error)
 }
 
 type serverManager struct {
-	logger boshlog.Logger
-	logTag string
+	fs          boshsys.FileSystem
+	timeService biretrier.Clock
+	logger      boshlog.Logger
+	logTag      string
+}
+
+func NewServerManager(fs boshsys.FileSystem, logger boshlog.Logger) ServerManager {
+	return NewServerManagerWithClock(fs, clock.NewClock(), logger)
 }
 
-func NewServerManager(logger boshlog.Logger) ServerManager {
+func NewServerManagerWithClock(fs boshsys.FileSystem, timeService biretrier.Clock, logger boshlog.Logger) ServerManager {
 	return &serverManager{
-		logger: logger,
-		logTag: "registryServer",
+		fs:          fs,
+		timeService: timeService,
+		logger:      logger,
+		logTag:      "registryServer",
 	}
 }
 
-// Create starts a new server on a goroutine and returns it
+// Start listens on the given address and serves the registry on a goroutine.
 // The returned error is only for starting. Error while running is logged.
-func (s *serverManager) Start(username string, password string, host string, port int) (Server, error) {
-	startedCh := make(chan error)
+// Binding is retried for a while in case a previous registry has not released the port yet.
+// A non-empty serverTLS serves HTTPS instead of HTTP.
+// The registries are kept in store, or in memory when store is nil.
+// Every request is logged at debug level, and appended to the file at
+// auditLogPath as a line of JSON unless auditLogPath is empty.
+// settingsEnvelope selects how settings are wrapped for agents and CPIs.
+func (s *serverManager) Start(username string, password string, host string, port int, limits Limits, serverTLS TLS, store Store, auditLogPath string, settingsEnvelope SettingsEnvelope) (Server, error) {
 	server := &server{
-		logger: s.logger,
-		logTag: "registryServer",
+		limits:      limits,
+		envelope:    settingsEnvelope,
+		timeService: s.timeService,
+		logger:      s.logger,
+		logTag:      "registryServer",
+	}
+
+	if store == nil {
+		store = NewMemoryStore()
+	}
+
+	err := server.loadRegistries(store)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading registry store")
+	}
+
+	if !serverTLS.IsEmpty() {
+		tlsConfig, err := serverTLS.config()
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Configuring registry TLS")
+		}
+		server.tlsConfig = tlsConfig
+	}
+
+	if auditLogPath != "" {
+		server.auditLog, err = s.fs.OpenFile(auditLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Opening registry audit log '%s'", auditLogPath)
+		}
+	}
+
+	listenRetrier := biretrier.NewRetrier(biretrier.Options{
+		MaxAttempts: listenAttempts,
+		Backoff:     biretrier.NewExponentialBackoff(listenInitialDelay, listenMaxDelay, 2),
+	}, s.timeService, s.logger)
+
+	err = listenRetrier.Try(func() (bool, error) {
+		err := server.listen(host, port)
+		return isAddressInUse(err), err
+	})
+	if exhaustedErr, ok := err.(biretrier.ExhaustedError); ok && isAddressInUse(exhaustedErr.LastErr) {
+		server.closeAuditLog()
+		return nil, newPortInUseError(err, host, port)
+	}
+	if err != nil {
+		server.closeAuditLog()
+		return nil, bosherr.WrapError(err, "Starting registry listener")
 	}
+
+	server.httpServer = server.newHTTPServer(username, password)
+
 	go func() {
-		err := server.start(username, password, host, port, startedCh)
+		err := server.httpServer.Serve(server.listener)
 		if err != nil {
 			s.logger.Debug(s.logTag, "Registry error occurred: %s", err.Error())
 		}
 	}()
 
-	// block until started
-	err := <-startedCh
+	return server, nil
+}
+
+// CheckPort fails when another process listens on the port the registry
+// would listen on. Other listen errors are left for Start to report.
+func CheckPort(host string, port int) error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if isAddressInUse(err) {
+		return newPortInUseError(err, host, port)
+	}
 	if err != nil {
-		if stopErr := server.Stop(); stopErr != nil {
-			s.logger.Warn(s.logTag, "Failed to stop server: %s", stopErr.Error())
-		}
+		return nil
 	}
-	return server, err
+
+	return listener.Close()
+}
+
+func isAddressInUse(err error) bool {
+	return err != nil && errors.Is(err, syscall.EADDRINUSE)
+}
+
+func newPortInUseError(err error, host string, port int) error {
+	return bosherr.WrapErrorf(err,
+		"Registry port %d on '%s' is already in use by another process, stop it or change cloud_provider.registry.port",
+		port, host)
 }
 
 type Server interface {
+	// SetSettingsOverlay merges the JSON hash overlayJSON into the settings
+	// served to agents, over the settings saved by the CPI
+	SetSettingsOverlay(overlayJSON []byte) error
+
+	// Stop stops accepting connections and waits for requests in flight
+	// to complete. It returns an error if they did not complete in time.
 	Stop() error
 }
 
 type server struct {
-	listener net.Listener
-	logger   boshlog.Logger
-	logTag   string
+	listener        net.Listener
+	httpServer      *http.Server
+	auditHandler    *auditHandler
+	instanceHandler *instanceHandler
+	settings        Registry
+	overrides       Registry
+	templates       Registry
+	metadata        Registry
+	limits          Limits
+	envelope        SettingsEnvelope
+	tlsConfig       *tls.Config
+	auditLog        boshsys.File
+	timeService     biretrier.Clock
+	logger          boshlog.Logger
+	logTag          string
 }
 
 func NewServer(logger boshlog.Logger) Server {
 	return &server{
-		logger: logger,
-		logTag: "registryServer",
+		timeService: clock.NewClock(),
+		logger:      logger,
+		logTag:      "registryServer",
 	}
 }
 
-func (s *server) start(username string, password string, host string, port int, readyErrCh chan error) error {
-	s.logger.Debug(s.logTag, "Starting registry server at %s:%d", host, port)
+func (s *server) loadRegistries(store Store) error {
 	var err error
-	s.listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", host, port))
+
+	s.settings, err = store.Registry("settings")
 	if err != nil {
-		readyErrCh <- bosherr.WrapError(err, "Starting registry listener")
-		return nil
+		return err
+	}
+
+	s.overrides, err = store.Registry("overrides")
+	if err != nil {
+		return err
+	}
+
+	s.templates, err = store.Registry("templates")
+	if err != nil {
+		return err
+	}
+
+	s.metadata, err = store.Registry("metadata")
+	return err
+}
+
+func (s *server) listen(host string, port int) error {
+	// IPv6 literals have to be bracketed in the address
+	address := net.JoinHostPort(host, strconv.Itoa(port))
+
+	s.logger.Debug(s.logTag, "Starting registry server at %s", address)
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
 	}
 
-	readyErrCh <- nil
+	if s.tlsConfig != nil {
+		listener = tls.NewListener(listener, s.tlsConfig)
+	}
+
+	s.listener = listener
+
+	return nil
+}
 
-	httpServer := http.Server{}
+func (s *server) newHTTPServer(username string, password string) *http.Server {
 	mux := http.NewServeMux()
-	httpServer.Handler = mux
 
-	registry := NewRegistry()
-	instanceHandler := newInstanceHandler(username, password, registry, s.logger)
+	instanceHandler := newInstanceHandler(username, password, s.settings, s.overrides, s.templates, s.metadata, s.limits, s.envelope, s.timeService, s.logger)
+	mux.HandleFunc("/instances", instanceHandler.HandleListFunc)
 	mux.HandleFunc("/instances/", instanceHandler.HandleFunc)
+	mux.HandleFunc("/templates/", instanceHandler.HandleTemplateFunc)
+
+	s.instanceHandler = instanceHandler
+
+	var auditWriter io.Writer
+	if s.auditLog != nil {
+		auditWriter = s.auditLog
+	}
+
+	s.auditHandler = newAuditHandler(mux, instanceHandler.isAuthorized, auditWriter, s.timeService, s.logger)
+
+	return &http.Server{Handler: s.auditHandler}
+}
+
+func (s *server) closeAuditLog() {
+	if s.auditLog != nil {
+		err := s.auditLog.Close()
+		if err != nil {
+			s.logger.Warn(s.logTag, "Closing registry audit log: %s", err.Error())
+		}
+		s.auditLog = nil
+	}
+}
 
-	return httpServer.Serve(s.listener)
+func (s *server) SetSettingsOverlay(overlayJSON []byte) error {
+	if s.instanceHandler == nil {
+		return bosherr.Error("Setting settings overlay of not-started registry server")
+	}
+
+	var overlay map[string]interface{}
+	err := json.Unmarshal(overlayJSON, &overlay)
+	if err != nil {
+		return bosherr.WrapError(err, "Unmarshalling settings overlay")
+	}
+
+	s.instanceHandler.setOverlay(overlayJSON)
+
+	return nil
 }
 
 func (s *server) Stop() error {
@@ -95,8 +275,38 @@ func (s *server) Stop() error {
 	}
 
 	s.logger.Debug(s.logTag, "Stopping registry server")
-	err := s.listener.Close()
-	if err != nil {
+
+	if s.httpServer == nil {
+		err := s.listener.Close()
+		if err != nil {
+			return bosherr.WrapError(err, "Stopping registry server")
+		}
+
+		return nil
+	}
+
+	timeout := s.limits.shutdownTimeout()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(ctx)
+
+	// Serve may not have taken over the listener yet, release the port right away
+	s.listener.Close()
+
+	if err == context.DeadlineExceeded {
+		s.httpServer.Close()
+	}
+
+	// Close returns before the handlers of the closed connections do,
+	// the audit log is closed only once they stopped writing to it
+	s.auditHandler.wait()
+	s.closeAuditLog()
+
+	if err == context.DeadlineExceeded {
+		return bosherr.Errorf("Stopping registry server: requests still in flight after %s", timeout)
+	} else if err != nil {
 		return bosherr.WrapError(err, "Stopping registry server")
 	}
 
@@ -0,0 +1,70 @@
+package registry
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// SettingsEnvelope selects how settings are wrapped in the envelope of the
+// EC2 registry, {"settings": "<settings JSON>", "status": "ok"}
+type SettingsEnvelope string
+
+const (
+	// SettingsEnvelopeWrap wraps the settings agents GET, which is also
+	// what an empty SettingsEnvelope does
+	SettingsEnvelopeWrap SettingsEnvelope = "wrap"
+
+	// SettingsEnvelopeEC2 also unwraps settings PUT in the envelope, for
+	// CPIs that send settings back as the EC2 registry returned them
+	SettingsEnvelopeEC2 SettingsEnvelope = "ec2"
+
+	// SettingsEnvelopeNone serves the settings themselves
+	SettingsEnvelopeNone SettingsEnvelope = "none"
+)
+
+func (e SettingsEnvelope) wrap(settingsJSON []byte) ([]byte, error) {
+	if e == SettingsEnvelopeNone {
+		return settingsJSON, nil
+	}
+
+	return json.Marshal(SettingsResponse{
+		Settings: string(settingsJSON),
+		Status:   "ok",
+	})
+}
+
+// unwrap returns the settings of bodies sent in the envelope, other bodies
+// are the settings themselves. Envelopes whose settings are not a JSON
+// string are rejected, agents of older stemcells could not parse them once
+// they were served wrapped again.
+func (e SettingsEnvelope) unwrap(body []byte) ([]byte, error) {
+	if e != SettingsEnvelopeEC2 {
+		return body, nil
+	}
+
+	var envelope map[string]json.RawMessage
+
+	err := json.Unmarshal(body, &envelope)
+	if err != nil {
+		return body, nil
+	}
+
+	rawSettings, found := envelope["settings"]
+	if !found {
+		return body, nil
+	}
+
+	var settings string
+
+	err = json.Unmarshal(rawSettings, &settings)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unwrapping settings envelope")
+	}
+
+	if !json.Valid([]byte(settings)) {
+		return nil, bosherr.Error("Unwrapping settings envelope: settings are not JSON")
+	}
+
+	return []byte(settings), nil
+}
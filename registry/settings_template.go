@@ -0,0 +1,52 @@
+package registry
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// renderSettings merges per-instance overrides (e.g. agent_id, network IPs) on top of
+// the settings template. Nested hashes are merged key by key, any other override
+// value replaces the template value.
+func renderSettings(templateJSON []byte, overridesJSON []byte) ([]byte, error) {
+	var template map[string]interface{}
+	err := json.Unmarshal(templateJSON, &template)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling settings template")
+	}
+
+	var overrides map[string]interface{}
+	err = json.Unmarshal(overridesJSON, &overrides)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Unmarshalling instance overrides")
+	}
+
+	settingsJSON, err := json.Marshal(mergeSettings(template, overrides))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Marshalling rendered settings")
+	}
+
+	return settingsJSON, nil
+}
+
+func mergeSettings(template map[string]interface{}, overrides map[string]interface{}) map[string]interface{} {
+	merged := map[string]interface{}{}
+
+	for key, value := range template {
+		merged[key] = value
+	}
+
+	for key, value := range overrides {
+		overrideHash, overrideIsHash := value.(map[string]interface{})
+		templateHash, templateIsHash := merged[key].(map[string]interface{})
+
+		if overrideIsHash && templateIsHash {
+			merged[key] = mergeSettings(templateHash, overrideHash)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,175 @@
+package registry
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sync"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Store provides the registries a server keeps instance settings, overrides
+// and templates in, by name
+type Store interface {
+	Registry(name string) (Registry, error)
+}
+
+type memoryStore struct{}
+
+// NewMemoryStore keeps the registries in memory, they are lost when the CLI exits
+func NewMemoryStore() Store {
+	return memoryStore{}
+}
+
+func (memoryStore) Registry(string) (Registry, error) {
+	return NewRegistry(), nil
+}
+
+type fileStore struct {
+	fs     boshsys.FileSystem
+	dir    string
+	logger boshlog.Logger
+}
+
+// NewFileStore keeps each registry in a JSON file in dir so that a re-run
+// after the CLI exited mid-deploy resumes with the existing agent settings
+func NewFileStore(fs boshsys.FileSystem, dir string, logger boshlog.Logger) Store {
+	return fileStore{fs: fs, dir: dir, logger: logger}
+}
+
+func (s fileStore) Registry(name string) (Registry, error) {
+	r := &fileRegistry{
+		entries: registry{},
+		path:    filepath.Join(s.dir, name+".json"),
+		fs:      s.fs,
+		logger:  s.logger,
+		logTag:  "registryFileStore",
+	}
+
+	err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+type fileRegistry struct {
+	entries registry
+	path    string
+	fs      boshsys.FileSystem
+	lock    sync.Mutex
+	logger  boshlog.Logger
+	logTag  string
+}
+
+func (r *fileRegistry) Save(key string, value []byte) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	exists := r.entries.Save(key, value)
+	r.persist()
+
+	return exists
+}
+
+func (r *fileRegistry) Get(key string) ([]byte, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.entries.Get(key)
+}
+
+func (r *fileRegistry) Delete(key string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.entries.Delete(key)
+	r.persist()
+}
+
+func (r *fileRegistry) Len() int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.entries.Len()
+}
+
+func (r *fileRegistry) Keys() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.entries.Keys()
+}
+
+func (r *fileRegistry) load() error {
+	if !r.fs.FileExists(r.path) {
+		return nil
+	}
+
+	contents, err := r.fs.ReadFile(r.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Reading registry file '%s'", r.path)
+	}
+
+	values := map[string]string{}
+	err = json.Unmarshal(contents, &values)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Unmarshalling registry file '%s'", r.path)
+	}
+
+	for key, value := range values {
+		r.entries[key] = []byte(value)
+	}
+
+	r.logger.Debug(r.logTag, "Loaded %d entries from registry file '%s'", len(values), r.path)
+
+	return nil
+}
+
+// persist is best effort since the registry keeps serving from memory;
+// a failure only means the entries would not survive a restart
+func (r *fileRegistry) persist() {
+	err := r.write()
+	if err != nil {
+		r.logger.Warn(r.logTag, "Failed to persist registry file '%s': %s", r.path, err.Error())
+	}
+}
+
+func (r *fileRegistry) write() error {
+	values := map[string]string{}
+	for key, value := range r.entries {
+		values[key] = string(value)
+	}
+
+	contents, err := json.Marshal(values)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling registry entries")
+	}
+
+	err = r.fs.MkdirAll(filepath.Dir(r.path), 0700)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating registry directory '%s'", filepath.Dir(r.path))
+	}
+
+	tmpPath := r.path + ".tmp"
+	err = r.fs.WriteFileQuietly(tmpPath, contents)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Writing registry file '%s'", tmpPath)
+	}
+
+	// settings contain agent credentials
+	err = r.fs.Chmod(tmpPath, 0600)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Setting permissions of registry file '%s'", tmpPath)
+	}
+
+	err = r.fs.Rename(tmpPath, r.path)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Renaming registry file '%s'", tmpPath)
+	}
+
+	return nil
+}
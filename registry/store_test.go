@@ -0,0 +1,96 @@
+package registry_test
+
+import (
+	"errors"
+	"os"
+
+	. "github.com/cloudfoundry/bosh-cli/registry"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileStore", func() {
+	var (
+		fs    *fakesys.FakeFileSystem
+		store Store
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		store = NewFileStore(fs, "/fake-installation/registry", boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	It("writes saved entries to a file only readable by the user", func() {
+		registry, err := store.Registry("settings")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(registry.Save("fake-instance-id", []byte(`{"agent_id":"fake-agent-id"}`))).To(BeFalse())
+		Expect(registry.Save("fake-instance-id", []byte(`{"agent_id":"fake-updated-agent-id"}`))).To(BeTrue())
+
+		contents, err := fs.ReadFileString("/fake-installation/registry/settings.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(MatchJSON(`{"fake-instance-id":"{\"agent_id\":\"fake-updated-agent-id\"}"}`))
+
+		stat, err := fs.Stat("/fake-installation/registry/settings.json")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stat.Mode().Perm()).To(Equal(os.FileMode(0600)))
+		Expect(fs.FileExists("/fake-installation/registry/settings.json.tmp")).To(BeFalse())
+	})
+
+	It("loads the entries saved by a previous registry", func() {
+		registry, err := store.Registry("settings")
+		Expect(err).ToNot(HaveOccurred())
+		registry.Save("fake-instance-id", []byte("fake-settings"))
+		registry.Save("other-instance-id", []byte("other-settings"))
+		registry.Delete("other-instance-id")
+
+		reloaded, err := store.Registry("settings")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(reloaded.Len()).To(Equal(1))
+		Expect(reloaded.Keys()).To(Equal([]string{"fake-instance-id"}))
+
+		settings, found := reloaded.Get("fake-instance-id")
+		Expect(found).To(BeTrue())
+		Expect(string(settings)).To(Equal("fake-settings"))
+	})
+
+	It("keeps registries with different names apart", func() {
+		registry, err := store.Registry("settings")
+		Expect(err).ToNot(HaveOccurred())
+		registry.Save("fake-instance-id", []byte("fake-settings"))
+
+		overrides, err := store.Registry("overrides")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(overrides.Len()).To(Equal(0))
+	})
+
+	It("returns an error when the file cannot be parsed", func() {
+		fs.WriteFileString("/fake-installation/registry/settings.json", "not-json")
+
+		_, err := store.Registry("settings")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Unmarshalling registry file '/fake-installation/registry/settings.json'"))
+	})
+
+	It("keeps serving from memory when the file cannot be written", func() {
+		registry, err := store.Registry("settings")
+		Expect(err).ToNot(HaveOccurred())
+
+		fs.WriteFileError = errors.New("fake-write-error")
+		registry.Save("fake-instance-id", []byte("fake-settings"))
+
+		settings, found := registry.Get("fake-instance-id")
+		Expect(found).To(BeTrue())
+		Expect(string(settings)).To(Equal("fake-settings"))
+	})
+})
+
+var _ = Describe("MemoryStore", func() {
+	It("returns new empty registries", func() {
+		registry, err := NewMemoryStore().Registry("settings")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(registry.Len()).To(Equal(0))
+	})
+})
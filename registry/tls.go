@@ -0,0 +1,47 @@
+package registry
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// TLS serves the registry over HTTPS so that agent settings are not sent
+// in cleartext. An empty TLS serves plain HTTP.
+type TLS struct {
+	// Certificate and PrivateKey are PEM encoded
+	Certificate string
+	PrivateKey  string
+
+	// ClientCA requires clients to present a certificate signed by it
+	ClientCA string
+}
+
+func (t TLS) IsEmpty() bool {
+	return t == TLS{}
+}
+
+func (t TLS) config() (*tls.Config, error) {
+	certificate, err := tls.X509KeyPair([]byte(t.Certificate), []byte(t.PrivateKey))
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Loading registry certificate and private key")
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if t.ClientCA != "" {
+		clientCAs := x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM([]byte(t.ClientCA)) {
+			return nil, bosherr.Error("Loading registry client CA: no PEM certificates found")
+		}
+
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
@@ -1,6 +1,7 @@
 package release
 
 import (
+	"os"
 	"path/filepath"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -8,6 +9,8 @@ import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
 
+	biarchive "github.com/cloudfoundry/bosh-cli/common/archive"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
 	boshlic "github.com/cloudfoundry/bosh-cli/release/license"
 	boshman "github.com/cloudfoundry/bosh-cli/release/manifest"
@@ -46,6 +49,14 @@ func NewArchiveReader(
 }
 
 func (r ArchiveReader) Read(path string) (Release, error) {
+	return r.ReadWithDigest(path, "", nil)
+}
+
+// ReadWithDigest is Read that also verifies the release tarball against
+// digest, computing it while the tarball is extracted when the compressor is
+// an archive.ReaderDecompressor instead of reading the tarball once more
+// beforehand. An empty digest skips the verification.
+func (r ArchiveReader) ReadWithDigest(path string, digest string, digestVerifier bicrypto.DigestVerifier) (Release, error) {
 	extractPath, err := r.fs.TempDir("bosh-release")
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Creating temp directory to extract release '%s'", path)
@@ -53,10 +64,10 @@ func (r ArchiveReader) Read(path string) (Release, error) {
 
 	r.logger.Info(r.logTag, "Extracting release tarball '%s' to '%s'", path, extractPath)
 
-	err = r.compressor.DecompressFileToDir(path, extractPath, boshcmd.CompressorOptions{})
+	err = r.extract(path, digest, digestVerifier, extractPath)
 	if err != nil {
 		r.cleanUp(extractPath)
-		return nil, bosherr.WrapError(err, "Extracting release")
+		return nil, err
 	}
 
 	manifestPath := filepath.Join(extractPath, "release.MF")
@@ -76,6 +87,56 @@ func (r ArchiveReader) Read(path string) (Release, error) {
 	return release, nil
 }
 
+func (r ArchiveReader) extract(path string, digest string, digestVerifier bicrypto.DigestVerifier, extractPath string) error {
+	readerDecompressor, streaming := r.compressor.(biarchive.ReaderDecompressor)
+
+	if digest == "" || !streaming {
+		if digest != "" {
+			err := digestVerifier.Verify(path, digest)
+			if err != nil {
+				return bosherr.WrapError(err, "Verifying release")
+			}
+		}
+
+		err := r.compressor.DecompressFileToDir(path, extractPath, boshcmd.CompressorOptions{})
+		if err != nil {
+			return bosherr.WrapError(err, "Extracting release")
+		}
+
+		return nil
+	}
+
+	file, err := r.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening release tarball '%s'", path)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	verifyingReader, err := digestVerifier.NewVerifyingReader(file, digest)
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying release")
+	}
+
+	defer func() {
+		_ = verifyingReader.Close()
+	}()
+
+	err = readerDecompressor.DecompressReaderToDir(verifyingReader, extractPath, boshcmd.CompressorOptions{})
+	if err != nil {
+		return bosherr.WrapError(err, "Extracting release")
+	}
+
+	err = verifyingReader.Verify()
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying release")
+	}
+
+	return nil
+}
+
 func (r ArchiveReader) cleanUp(extractPath string) {
 	removeErr := r.fs.RemoveAll(extractPath)
 	if removeErr != nil {
@@ -1,16 +1,25 @@
 package release_test
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
 	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	biarchive "github.com/cloudfoundry/bosh-cli/common/archive"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	. "github.com/cloudfoundry/bosh-cli/release"
 	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
 	fakejob "github.com/cloudfoundry/bosh-cli/release/job/jobfakes"
@@ -401,4 +410,98 @@ compiled_packages:
 			})
 		})
 	})
+
+	Describe("ReadWithDigest", func() {
+		var digestVerifier bicrypto.DigestVerifier
+
+		BeforeEach(func() {
+			fs.WriteFileString(filepath.Join("/", "some", "release.tgz"), "fake-archive-contents")
+			fs.WriteFileString(filepath.Join("/", "extracted", "release", "release.MF"), "name: release\nversion: version\n")
+
+			digestVerifier = bicrypto.NewDigestVerifier(fs, bicrypto.NewChecksumProvider(false))
+		})
+
+		It("verifies the tarball before extracting it when the compressor cannot extract a stream", func() {
+			release, err := reader.ReadWithDigest(filepath.Join("/", "some", "release.tgz"), "4603db250d7b5b78dfe17869649784353177b549", digestVerifier)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(release.Name()).To(Equal("release"))
+			Expect(compressor.DecompressFileToDirTarballPaths).To(Equal([]string{filepath.Join("/", "some", "release.tgz")}))
+		})
+
+		It("returns an error without extracting when the tarball does not match the digest", func() {
+			_, err := reader.ReadWithDigest(filepath.Join("/", "some", "release.tgz"), "fake-sha1", digestVerifier)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Verifying release"))
+			Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+			Expect(fs.FileExists(filepath.Join("/", "extracted", "release"))).To(BeFalse())
+		})
+
+		Context("when the compressor extracts streams", func() {
+			var (
+				tmpDir      string
+				tarballPath string
+				osFS        boshsys.FileSystem
+			)
+
+			// writeTarball returns the SHA-1 of the written tarball
+			writeTarball := func(manifest string) string {
+				buffer := &bytes.Buffer{}
+				gzipWriter := gzip.NewWriter(buffer)
+				tarWriter := tar.NewWriter(gzipWriter)
+
+				Expect(tarWriter.WriteHeader(&tar.Header{Name: "./release.MF", Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(manifest))})).To(Succeed())
+				_, err := tarWriter.Write([]byte(manifest))
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(tarWriter.Close()).To(Succeed())
+				Expect(gzipWriter.Close()).To(Succeed())
+
+				Expect(ioutil.WriteFile(tarballPath, buffer.Bytes(), 0644)).To(Succeed())
+
+				sum := sha1.Sum(buffer.Bytes())
+				return hex.EncodeToString(sum[:])
+			}
+
+			BeforeEach(func() {
+				var err error
+				tmpDir, err = ioutil.TempDir("", "release-archive-reader")
+				Expect(err).ToNot(HaveOccurred())
+
+				logger := boshlog.NewLogger(boshlog.LevelNone)
+				osFS = boshsys.NewOsFileSystem(logger)
+				Expect(osFS.ChangeTempRoot(filepath.Join(tmpDir, "temp"))).To(Succeed())
+
+				tarballPath = filepath.Join(tmpDir, "release.tgz")
+
+				digestVerifier = bicrypto.NewDigestVerifier(osFS, bicrypto.NewChecksumProvider(false))
+				reader = NewArchiveReader(jobReader, pkgReader, biarchive.NewParallelCompressor(compressor, 2, logger), osFS, logger)
+			})
+
+			AfterEach(func() {
+				Expect(os.RemoveAll(tmpDir)).To(Succeed())
+			})
+
+			It("extracts the tarball and verifies it in the same read", func() {
+				digest := writeTarball("name: release\nversion: version\n")
+
+				release, err := reader.ReadWithDigest(tarballPath, digest, digestVerifier)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(release.Name()).To(Equal("release"))
+				Expect(release.Version()).To(Equal("version"))
+				Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+			})
+
+			It("returns an error and removes the extracted release when the tarball does not match the digest", func() {
+				writeTarball("name: release\nversion: version\n")
+
+				_, err := reader.ReadWithDigest(tarballPath, "fake-sha1", digestVerifier)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Verifying release"))
+
+				extracted, err := ioutil.ReadDir(filepath.Join(tmpDir, "temp"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(extracted).To(BeEmpty())
+			})
+		})
+	})
 })
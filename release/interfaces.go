@@ -1,6 +1,7 @@
 package release
 
 import (
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	boshjob "github.com/cloudfoundry/bosh-cli/release/job"
 	boshlic "github.com/cloudfoundry/bosh-cli/release/license"
 	boshman "github.com/cloudfoundry/bosh-cli/release/manifest"
@@ -19,6 +20,14 @@ type Reader interface {
 	Read(string) (Release, error)
 }
 
+// VerifyingReader reads a release archive and verifies it against a digest
+// in the same read of the archive
+type VerifyingReader interface {
+	Reader
+
+	ReadWithDigest(path string, digest string, digestVerifier bicrypto.DigestVerifier) (Release, error)
+}
+
 //go:generate counterfeiter . Writer
 
 type Writer interface {
@@ -75,7 +75,19 @@ func (r ArchiveReaderImpl) Read(ref boshman.JobRef, path string) (*Job, error) {
 		}
 
 		job.Properties = properties
+		job.Consumes = links(manifest.Consumes)
+		job.Provides = links(manifest.Provides)
 	}
 
 	return job, nil
 }
+
+func links(definitions []boshjobman.LinkDefinition) []Link {
+	var links []Link
+
+	for _, definition := range definitions {
+		links = append(links, Link(definition))
+	}
+
+	return links
+}
@@ -49,6 +49,10 @@ properties:
   prop:
     description: prop-desc
     default: prop-default
+consumes:
+- {name: db, type: database, optional: true}
+provides:
+- {name: api, type: http, properties: [port]}
 `)
 
 			job, err := reader.Read(ref, "archive-path")
@@ -67,6 +71,8 @@ properties:
 					Default:     biproperty.Property("prop-default"),
 				},
 			}))
+			Expect(job.Consumes).To(Equal([]Link{{Name: "db", Type: "database", Optional: true}}))
+			Expect(job.Provides).To(Equal([]Link{{Name: "api", Type: "http", Properties: []string{"port"}}}))
 
 			Expect(job.ExtractedPath()).To(Equal("/extracted/job"))
 
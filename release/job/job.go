@@ -25,6 +25,9 @@ type Job struct {
 	Packages     []boshpkg.Compilable
 	Properties   map[string]PropertyDefinition
 
+	Consumes []Link
+	Provides []Link
+
 	extractedPath string
 	fs            boshsys.FileSystem
 }
@@ -34,6 +37,13 @@ type PropertyDefinition struct {
 	Default     biproperty.Property
 }
 
+type Link struct {
+	Name       string
+	Type       string
+	Optional   bool
+	Properties []string
+}
+
 func NewJob(resource Resource) *Job {
 	return &Job{resource: resource}
 }
@@ -80,6 +90,8 @@ func (j *Job) RehashWithCalculator(calculator crypto.DigestCalculator, archiveFi
 		PackageNames: j.PackageNames,
 		Packages:     j.Packages,
 		Properties:   j.Properties,
+		Consumes:     j.Consumes,
+		Provides:     j.Provides,
 
 		extractedPath: j.extractedPath,
 		fs:            j.fs,
@@ -107,6 +119,16 @@ func (j *Job) AttachCompilablePackages(packages []boshpkg.Compilable) error {
 	return nil
 }
 
+// FindConsumedLink returns the link the job spec declares under consumes
+func (j Job) FindConsumedLink(name string) (Link, bool) {
+	for _, link := range j.Consumes {
+		if link.Name == name {
+			return link, true
+		}
+	}
+	return Link{}, false
+}
+
 func (j Job) ExtractedPath() string { return j.extractedPath }
 
 func (j Job) CleanUp() error {
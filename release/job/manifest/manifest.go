@@ -11,6 +11,16 @@ type Manifest struct {
 	Templates  map[string]string             `yaml:"templates"`
 	Packages   []string                      `yaml:"packages"`
 	Properties map[string]PropertyDefinition `yaml:"properties"`
+
+	Consumes []LinkDefinition `yaml:"consumes"`
+	Provides []LinkDefinition `yaml:"provides"`
+}
+
+type LinkDefinition struct {
+	Name       string   `yaml:"name"`
+	Type       string   `yaml:"type"`
+	Optional   bool     `yaml:"optional"`
+	Properties []string `yaml:"properties"`
 }
 
 type PropertyDefinition struct {
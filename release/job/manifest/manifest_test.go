@@ -63,6 +63,31 @@ properties:
 		}))
 	})
 
+	It("parses consumed and provided links", func() {
+		contents := `---
+name: name
+consumes:
+- name: db
+  type: database
+  optional: true
+provides:
+- name: api
+  type: http
+  properties: [port]
+`
+
+		fs.WriteFileString("/path", contents)
+
+		manifest, err := NewManifestFromPath("/path", fs)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(manifest.Consumes).To(Equal([]LinkDefinition{
+			{Name: "db", Type: "database", Optional: true},
+		}))
+		Expect(manifest.Provides).To(Equal([]LinkDefinition{
+			{Name: "api", Type: "http", Properties: []string{"port"}},
+		}))
+	})
+
 	It("returns error if manifest is not valid yaml", func() {
 		fs.WriteFileString("/path", "-")
 
@@ -0,0 +1,119 @@
+package manifest
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	semiver "github.com/cppforlife/go-semi-semantic/version"
+)
+
+// VersionConstraint matches release versions against the version given in a
+// manifest: an exact version ("45"), a comparison (">= 45.1") or a
+// pessimistic constraint ("~> 45"). An empty constraint or "latest" matches any version.
+type VersionConstraint struct {
+	operator string
+	version  semiver.Version
+}
+
+var versionConstraintOperators = []string{"~>", ">=", "<=", "!=", ">", "<", "="}
+
+func NewVersionConstraint(constraint string) (VersionConstraint, error) {
+	constraint = strings.TrimSpace(constraint)
+
+	if constraint == "" || constraint == "latest" {
+		return VersionConstraint{}, nil
+	}
+
+	operator := "="
+
+	for _, op := range versionConstraintOperators {
+		if strings.HasPrefix(constraint, op) {
+			operator = op
+			constraint = strings.TrimSpace(strings.TrimPrefix(constraint, op))
+			break
+		}
+	}
+
+	version, err := semiver.NewVersionFromString(constraint)
+	if err != nil {
+		return VersionConstraint{}, bosherr.WrapErrorf(err, "Parsing version constraint '%s'", constraint)
+	}
+
+	if operator == "~>" {
+		_, err = pessimisticUpperBound(version)
+		if err != nil {
+			return VersionConstraint{}, bosherr.WrapErrorf(err, "Parsing version constraint '~> %s'", constraint)
+		}
+	}
+
+	return VersionConstraint{operator: operator, version: version}, nil
+}
+
+func (c VersionConstraint) Any() bool {
+	return c.operator == ""
+}
+
+func (c VersionConstraint) Check(versionStr string) (bool, error) {
+	if c.Any() {
+		return true, nil
+	}
+
+	version, err := semiver.NewVersionFromString(versionStr)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Parsing version '%s'", versionStr)
+	}
+
+	switch c.operator {
+	case "=":
+		return version.IsEq(c.version), nil
+	case "!=":
+		return !version.IsEq(c.version), nil
+	case ">":
+		return version.IsGt(c.version), nil
+	case ">=":
+		return !version.IsLt(c.version), nil
+	case "<":
+		return version.IsLt(c.version), nil
+	case "<=":
+		return !version.IsGt(c.version), nil
+	case "~>":
+		upperBound, err := pessimisticUpperBound(c.version)
+		if err != nil {
+			return false, err
+		}
+		return !version.IsLt(c.version) && version.IsLt(upperBound), nil
+	}
+
+	return false, bosherr.Errorf("Unknown version constraint operator '%s'", c.operator)
+}
+
+func (c VersionConstraint) String() string {
+	if c.Any() {
+		return "latest"
+	}
+	if c.operator == "=" {
+		return c.version.String()
+	}
+	return c.operator + " " + c.version.String()
+}
+
+// pessimisticUpperBound returns the exclusive upper bound for '~>':
+// '~> 45' allows versions below 46 and '~> 45.1' allows versions below 46.
+func pessimisticUpperBound(version semiver.Version) (semiver.Version, error) {
+	components := version.Release.Components
+	if len(components) > 1 {
+		components = components[:len(components)-1]
+	}
+
+	segment, err := semiver.NewVersionSegment(components)
+	if err != nil {
+		return semiver.Version{}, err
+	}
+
+	segment, err = segment.Increment()
+	if err != nil {
+		return semiver.Version{}, err
+	}
+
+	return semiver.NewVersion(segment, semiver.VersionSegment{}, semiver.VersionSegment{})
+}
@@ -0,0 +1,74 @@
+package manifest_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/release/manifest"
+)
+
+var _ = Describe("VersionConstraint", func() {
+	type example struct {
+		desc, constraint, version string
+		expected                  bool
+	}
+
+	examples := []example{
+		{"empty matches anything", "", "45", true},
+		{"latest matches anything", "latest", "45", true},
+		{"exact version matches", "45", "45", true},
+		{"exact version matches with trailing zeros", "45", "45.0", true},
+		{"exact version does not match other versions", "45", "46", false},
+		{"= matches", "= 45.1", "45.1", true},
+		{"!= rejects equal version", "!= 45", "45", false},
+		{">= matches equal version", ">= 45", "45", true},
+		{">= matches greater version", ">= 45", "46.2", true},
+		{">= rejects smaller version", ">= 45", "44", false},
+		{"> rejects equal version", "> 45", "45", false},
+		{"< matches smaller version", "< 45", "44.9", true},
+		{"<= rejects greater version", "<= 45", "45.1", false},
+		{"~> with one component matches within major", "~> 45", "45.9", true},
+		{"~> with one component rejects next major", "~> 45", "46", false},
+		{"~> with one component rejects smaller", "~> 45", "44", false},
+		{"~> with two components matches next minor", "~> 45.1", "45.3", true},
+		{"~> with two components rejects smaller minor", "~> 45.1", "45.0", false},
+		{"~> with two components rejects next major", "~> 45.1", "46", false},
+		{"~> without space", "~>45", "45.2", true},
+	}
+
+	for _, e := range examples {
+		e := e
+		It("checks: "+e.desc, func() {
+			c, err := NewVersionConstraint(e.constraint)
+			Expect(err).ToNot(HaveOccurred())
+
+			matches, err := c.Check(e.version)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(matches).To(Equal(e.expected))
+		})
+	}
+
+	It("returns an error for an invalid constraint", func() {
+		_, err := NewVersionConstraint(">= not a version!")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Parsing version constraint"))
+	})
+
+	It("returns an error when the checked version is invalid", func() {
+		c, err := NewVersionConstraint("~> 45")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, err = c.Check("not a version!")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("describes itself", func() {
+		c, err := NewVersionConstraint("~>45")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.String()).To(Equal("~> 45"))
+
+		c, err = NewVersionConstraint("45.1")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(c.String()).To(Equal("45.1"))
+	})
+})
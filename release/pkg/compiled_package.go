@@ -103,7 +103,7 @@ func (p *CompiledPackage) RehashWithCalculator(digestCalculator crypto.DigestCal
 		return nil, err
 	}
 
-	digest, err := crypto2.ParseMultipleDigest(p.archiveDigest)
+	digest, err := crypto.ParseVerifiableDigest(p.archiveDigest)
 	if err != nil {
 		return nil, err
 	}
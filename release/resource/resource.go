@@ -130,7 +130,7 @@ func (r *ResourceImpl) RehashWithCalculator(calculator crypto.DigestCalculator,
 	}
 	defer archiveFile.Close()
 
-	digest, err := crypto2.ParseMultipleDigest(r.archiveDigest)
+	digest, err := crypto.ParseVerifiableDigest(r.archiveDigest)
 	if err != nil {
 		return &ResourceImpl{}, err
 	}
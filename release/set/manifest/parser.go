@@ -8,6 +8,7 @@ import (
 	"gopkg.in/yaml.v2"
 
 	biutil "github.com/cloudfoundry/bosh-cli/common/util"
+	bivalidation "github.com/cloudfoundry/bosh-cli/common/validation"
 	boshtpl "github.com/cloudfoundry/bosh-cli/director/template"
 	birelmanifest "github.com/cloudfoundry/bosh-cli/release/manifest"
 )
@@ -73,7 +74,7 @@ func (p *parser) Parse(path string, vars boshtpl.Variables, op patch.Op) (Manife
 
 	err = p.validator.Validate(releaseSetManifest)
 	if err != nil {
-		return Manifest{}, bosherr.WrapError(err, "Validating release set manifest")
+		return Manifest{}, bivalidation.WrapError(err, "Validating release set manifest")
 	}
 
 	return releaseSetManifest, nil
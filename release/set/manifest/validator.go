@@ -6,6 +6,8 @@ import (
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	birelmanifest "github.com/cloudfoundry/bosh-cli/release/manifest"
 )
 
 type Validator interface {
@@ -49,6 +51,11 @@ func (v *validator) Validate(manifest Manifest) error {
 		if strings.HasPrefix(release.URL, "http") && v.isBlank(release.SHA1) {
 			errs = append(errs, bosherr.Errorf("releases[%d].sha1 must be provided for http URL", releaseIdx))
 		}
+
+		_, err = birelmanifest.NewVersionConstraint(release.Version)
+		if err != nil {
+			errs = append(errs, bosherr.Errorf("releases[%d].version '%s' must be a version or version constraint", releaseIdx, release.Version))
+		}
 	}
 
 	if len(errs) > 0 {
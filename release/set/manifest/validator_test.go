@@ -116,6 +116,31 @@ var _ = Describe("Validator", func() {
 			Expect(err.Error()).To(ContainSubstring("releases[0].url must be a valid URL (file:// or http(s)://)"))
 		})
 
+		It("accepts versions and version constraints", func() {
+			manifest := Manifest{
+				Releases: []boshman.ReleaseRef{
+					{Name: "fake-release-name-1", URL: "file://fake-url", Version: "45"},
+					{Name: "fake-release-name-2", URL: "file://fake-url", Version: "~> 45.1"},
+					{Name: "fake-release-name-3", URL: "file://fake-url", Version: "latest"},
+				},
+			}
+
+			err := validator.Validate(manifest)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("validates release versions", func() {
+			manifest := Manifest{
+				Releases: []boshman.ReleaseRef{
+					{Name: "fake-release-name", URL: "file://fake-url", Version: ">= not a version!"},
+				},
+			}
+
+			err := validator.Validate(manifest)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("releases[0].version '>= not a version!' must be a version or version constraint"))
+		})
+
 		It("validates releases are unique", func() {
 			manifest := Manifest{
 				Releases: []boshman.ReleaseRef{
@@ -0,0 +1,216 @@
+package release
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	"gopkg.in/yaml.v2"
+
+	boshjobman "github.com/cloudfoundry/bosh-cli/release/job/manifest"
+	boshman "github.com/cloudfoundry/bosh-cli/release/manifest"
+)
+
+// TarballInspection describes a release tarball without extracting it
+type TarballInspection struct {
+	Name    string
+	Version string
+
+	Jobs     []InspectedJob
+	Packages []InspectedPackage
+
+	// Size is the size of the tarball, ExtractedSize the total size of
+	// the job, package and license archives in it
+	Size          int64
+	ExtractedSize int64
+}
+
+type InspectedJob struct {
+	Name       string
+	Properties map[string]boshjobman.PropertyDefinition
+	Size       int64
+}
+
+type InspectedPackage struct {
+	Name         string
+	Dependencies []string
+
+	// Stemcell is the stemcell a compiled package was compiled for
+	Stemcell string
+	Size     int64
+}
+
+type TarballInspector struct {
+	fs boshsys.FileSystem
+}
+
+// NewTarballInspector reads release tarballs in a single pass, keeping only
+// the release and job manifests in memory
+func NewTarballInspector(fs boshsys.FileSystem) TarballInspector {
+	return TarballInspector{fs: fs}
+}
+
+func (i TarballInspector) Inspect(path string) (TarballInspection, error) {
+	var inspection TarballInspection
+
+	stat, err := i.fs.Stat(path)
+	if err != nil {
+		return inspection, bosherr.WrapErrorf(err, "Checking release tarball '%s'", path)
+	}
+
+	inspection.Size = stat.Size()
+
+	file, err := i.fs.OpenFile(path, os.O_RDONLY, 0)
+	if err != nil {
+		return inspection, bosherr.WrapErrorf(err, "Opening release tarball '%s'", path)
+	}
+
+	defer file.Close()
+
+	gzipReader, err := gzip.NewReader(file)
+	if err != nil {
+		return inspection, bosherr.WrapErrorf(err, "Reading release tarball '%s'", path)
+	}
+
+	defer gzipReader.Close()
+
+	var manifestBytes []byte
+	jobs := map[string]InspectedJob{}
+	sizes := map[string]int64{}
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return inspection, bosherr.WrapErrorf(err, "Reading next entry of release tarball '%s'", path)
+		}
+
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+
+		name := strings.TrimPrefix(header.Name, "./")
+
+		if name == "release.MF" {
+			manifestBytes, err = ioutil.ReadAll(tarReader)
+			if err != nil {
+				return inspection, bosherr.WrapError(err, "Reading 'release.MF'")
+			}
+
+			continue
+		}
+
+		inspection.ExtractedSize += header.Size
+		sizes[name] = header.Size
+
+		// job archives are small, package archives are skipped unread
+		if strings.HasPrefix(name, "jobs/") {
+			job, err := i.readJob(tarReader)
+			if err != nil {
+				return inspection, bosherr.WrapErrorf(err, "Reading job archive '%s'", name)
+			}
+
+			job.Size = header.Size
+			jobs[name] = job
+		}
+	}
+
+	if manifestBytes == nil {
+		return inspection, bosherr.Errorf("Missing 'release.MF' in release tarball '%s'", path)
+	}
+
+	var manifest boshman.Manifest
+
+	err = yaml.Unmarshal(manifestBytes, &manifest)
+	if err != nil {
+		return inspection, bosherr.WrapError(err, "Unmarshalling 'release.MF'")
+	}
+
+	inspection.Name = manifest.Name
+	inspection.Version = manifest.Version
+
+	for _, jobRef := range manifest.Jobs {
+		job, found := jobs["jobs/"+jobRef.Name+".tgz"]
+		if !found {
+			return inspection, bosherr.Errorf("Missing job archive of job '%s'", jobRef.Name)
+		}
+
+		job.Name = jobRef.Name
+		inspection.Jobs = append(inspection.Jobs, job)
+	}
+
+	for _, pkgRef := range manifest.Packages {
+		inspection.Packages = append(inspection.Packages, InspectedPackage{
+			Name:         pkgRef.Name,
+			Dependencies: pkgRef.Dependencies,
+			Size:         sizes["packages/"+pkgRef.Name+".tgz"],
+		})
+	}
+
+	for _, pkgRef := range manifest.CompiledPkgs {
+		inspection.Packages = append(inspection.Packages, InspectedPackage{
+			Name:         pkgRef.Name,
+			Dependencies: pkgRef.Dependencies,
+			Stemcell:     pkgRef.OSVersionSlug,
+			Size:         sizes["compiled_packages/"+pkgRef.Name+".tgz"],
+		})
+	}
+
+	return inspection, nil
+}
+
+// readJob reads the job spec from a job archive
+func (i TarballInspector) readJob(reader io.Reader) (InspectedJob, error) {
+	archive, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return InspectedJob{}, err
+	}
+
+	gzipReader, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return InspectedJob{}, err
+	}
+
+	defer gzipReader.Close()
+
+	tarReader := tar.NewReader(gzipReader)
+
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return InspectedJob{}, bosherr.Error("Missing 'job.MF'")
+		}
+		if err != nil {
+			return InspectedJob{}, err
+		}
+
+		if path.Clean(header.Name) != "job.MF" {
+			continue
+		}
+
+		manifestBytes, err := ioutil.ReadAll(tarReader)
+		if err != nil {
+			return InspectedJob{}, bosherr.WrapError(err, "Reading 'job.MF'")
+		}
+
+		var manifest boshjobman.Manifest
+
+		err = yaml.Unmarshal(manifestBytes, &manifest)
+		if err != nil {
+			return InspectedJob{}, bosherr.WrapError(err, "Unmarshalling 'job.MF'")
+		}
+
+		return InspectedJob{Name: manifest.Name, Properties: manifest.Properties}, nil
+	}
+}
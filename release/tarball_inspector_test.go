@@ -0,0 +1,130 @@
+package release_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/release"
+	boshjobman "github.com/cloudfoundry/bosh-cli/release/job/manifest"
+)
+
+var _ = Describe("TarballInspector", func() {
+	type tarballEntry struct {
+		name     string
+		contents []byte
+	}
+
+	var (
+		tmpDir      string
+		tarballPath string
+		inspector   TarballInspector
+	)
+
+	tgz := func(entries ...tarballEntry) []byte {
+		buffer := &bytes.Buffer{}
+		gzipWriter := gzip.NewWriter(buffer)
+		tarWriter := tar.NewWriter(gzipWriter)
+
+		for _, entry := range entries {
+			header := &tar.Header{Name: entry.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(entry.contents))}
+			Expect(tarWriter.WriteHeader(header)).To(Succeed())
+
+			_, err := tarWriter.Write(entry.contents)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(tarWriter.Close()).To(Succeed())
+		Expect(gzipWriter.Close()).To(Succeed())
+
+		return buffer.Bytes()
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "tarball-inspector")
+		Expect(err).ToNot(HaveOccurred())
+
+		tarballPath = filepath.Join(tmpDir, "release.tgz")
+
+		inspector = NewTarballInspector(boshsys.NewOsFileSystem(boshlog.NewLogger(boshlog.LevelNone)))
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("reads the release name, jobs with their properties, packages and sizes", func() {
+		jobArchive := tgz(
+			tarballEntry{name: "./monit", contents: []byte("fake-monit")},
+			tarballEntry{name: "./job.MF", contents: []byte(`---
+name: fake-job
+properties:
+  port:
+    description: Port to listen on
+    default: 8080
+`)},
+		)
+		pkgArchive := []byte("fake-package-archive")
+
+		tarball := tgz(
+			tarballEntry{name: "./jobs/fake-job.tgz", contents: jobArchive},
+			tarballEntry{name: "./packages/fake-pkg.tgz", contents: pkgArchive},
+			tarballEntry{name: "./release.MF", contents: []byte(`---
+name: fake-release
+version: "1"
+jobs:
+- name: fake-job
+packages:
+- name: fake-pkg
+  dependencies: [fake-dep]
+`)},
+		)
+		Expect(ioutil.WriteFile(tarballPath, tarball, 0644)).To(Succeed())
+
+		inspection, err := inspector.Inspect(tarballPath)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(inspection).To(Equal(TarballInspection{
+			Name:    "fake-release",
+			Version: "1",
+			Jobs: []InspectedJob{
+				{
+					Name: "fake-job",
+					Properties: map[string]boshjobman.PropertyDefinition{
+						"port": {Description: "Port to listen on", Default: 8080},
+					},
+					Size: int64(len(jobArchive)),
+				},
+			},
+			Packages: []InspectedPackage{
+				{Name: "fake-pkg", Dependencies: []string{"fake-dep"}, Size: int64(len(pkgArchive))},
+			},
+			Size:          int64(len(tarball)),
+			ExtractedSize: int64(len(jobArchive) + len(pkgArchive)),
+		}))
+	})
+
+	It("returns an error when the release manifest is missing", func() {
+		Expect(ioutil.WriteFile(tarballPath, tgz(tarballEntry{name: "./packages/fake-pkg.tgz"}), 0644)).To(Succeed())
+
+		_, err := inspector.Inspect(tarballPath)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Missing 'release.MF'"))
+	})
+
+	It("returns an error when a job archive is missing", func() {
+		Expect(ioutil.WriteFile(tarballPath, tgz(tarballEntry{name: "./release.MF", contents: []byte("jobs:\n- name: fake-job\n")}), 0644)).To(Succeed())
+
+		_, err := inspector.Inspect(tarballPath)
+		Expect(err).To(MatchError("Missing job archive of job 'fake-job'"))
+	})
+})
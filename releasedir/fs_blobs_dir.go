@@ -332,7 +332,7 @@ func (d FSBlobsDir) checkBlobExistence(dstPath string, digest boshcrypto.Multipl
 func (d FSBlobsDir) downloadBlob(blob Blob) error {
 	dstPath := filepath.Join(d.dirPath, blob.Path)
 
-	digest, err := boshcrypto.ParseMultipleDigest(blob.SHA1)
+	digest, err := bicrypto.ParseVerifiableDigest(blob.SHA1)
 	if err != nil {
 		return bosherr.WrapErrorf(
 			err, "Generating multi digest for blob '%s' for path '%s' with digest string '%s'", blob.BlobstoreID, blob.Path, blob.SHA1)
@@ -5,8 +5,8 @@ import (
 	"os"
 	"path/filepath"
 
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	boshblob "github.com/cloudfoundry/bosh-utils/blobstore"
-	boshcrypto "github.com/cloudfoundry/bosh-utils/crypto"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -43,7 +43,7 @@ func (c FSIndexBlobs) Get(name string, blobID string, digestString string) (stri
 	}
 
 	if c.fs.FileExists(dstPath) {
-		digest, err := boshcrypto.ParseMultipleDigest(digestString)
+		digest, err := bicrypto.ParseVerifiableDigest(digestString)
 		if err != nil {
 			return "", err
 		}
@@ -60,7 +60,7 @@ func (c FSIndexBlobs) Get(name string, blobID string, digestString string) (stri
 	if c.blobstore != nil && len(blobID) > 0 {
 		desc := fmt.Sprintf("sha1=%s", digestString)
 
-		digest, err := boshcrypto.ParseMultipleDigest(digestString)
+		digest, err := bicrypto.ParseVerifiableDigest(digestString)
 		if err != nil {
 			return "", bosherr.WrapErrorf(err, "Downloading blob '%s' with digest '%s'", blobID, digestString)
 		}
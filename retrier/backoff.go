@@ -0,0 +1,61 @@
+package retrier
+
+import (
+	"time"
+)
+
+type Backoff interface {
+	// Delay returns how long to wait before the given retry,
+	// where 1 is the retry following the first failed attempt.
+	Delay(retry int) time.Duration
+}
+
+type constantBackoff struct {
+	delay time.Duration
+}
+
+func NewConstantBackoff(delay time.Duration) Backoff {
+	return constantBackoff{delay: delay}
+}
+
+func (b constantBackoff) Delay(int) time.Duration {
+	return b.delay
+}
+
+type exponentialBackoff struct {
+	initial    time.Duration
+	max        time.Duration
+	multiplier float64
+}
+
+// NewExponentialBackoff multiplies the delay by multiplier after every retry,
+// never waiting longer than max. A zero max leaves the delay uncapped.
+func NewExponentialBackoff(initial, max time.Duration, multiplier float64) Backoff {
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	return exponentialBackoff{
+		initial:    initial,
+		max:        max,
+		multiplier: multiplier,
+	}
+}
+
+func (b exponentialBackoff) Delay(retry int) time.Duration {
+	delay := float64(b.initial)
+
+	for i := 1; i < retry; i++ {
+		delay *= b.multiplier
+
+		if b.max > 0 && delay >= float64(b.max) {
+			return b.max
+		}
+	}
+
+	if b.max > 0 && delay > float64(b.max) {
+		return b.max
+	}
+
+	return time.Duration(delay)
+}
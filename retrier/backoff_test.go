@@ -0,0 +1,44 @@
+package retrier_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+var _ = Describe("Backoff", func() {
+	Describe("NewConstantBackoff", func() {
+		It("always returns the same delay", func() {
+			backoff := NewConstantBackoff(2 * time.Second)
+			Expect(backoff.Delay(1)).To(Equal(2 * time.Second))
+			Expect(backoff.Delay(10)).To(Equal(2 * time.Second))
+		})
+	})
+
+	Describe("NewExponentialBackoff", func() {
+		It("multiplies the delay on every retry up to the maximum", func() {
+			backoff := NewExponentialBackoff(100*time.Millisecond, time.Second, 2)
+			delays := []time.Duration{}
+			for retry := 1; retry <= 6; retry++ {
+				delays = append(delays, backoff.Delay(retry))
+			}
+
+			Expect(delays).To(Equal([]time.Duration{
+				100 * time.Millisecond,
+				200 * time.Millisecond,
+				400 * time.Millisecond,
+				800 * time.Millisecond,
+				time.Second,
+				time.Second,
+			}))
+		})
+
+		It("does not cap the delay without a maximum", func() {
+			backoff := NewExponentialBackoff(time.Second, 0, 3)
+			Expect(backoff.Delay(3)).To(Equal(9 * time.Second))
+		})
+	})
+})
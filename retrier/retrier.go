@@ -0,0 +1,147 @@
+package retrier
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+type Clock interface {
+	Sleep(time.Duration)
+	Now() time.Time
+}
+
+// Options configure when a Retrier gives up. Zero MaxAttempts and zero
+// Timeout retry until the attempt succeeds or the context is cancelled.
+type Options struct {
+	MaxAttempts int
+	Timeout     time.Duration
+	Backoff     Backoff
+
+	// Reporter is told about every retry of the operation called Name,
+	// e.g. to show attempt counters instead of the errors of each attempt
+	Name     string
+	Reporter AttemptReporter
+}
+
+type AttemptReporter interface {
+	// ReportAttempt is called before attempt of the operation called name
+	// is made, maxAttempts is zero when only a timeout stops the retries
+	ReportAttempt(name string, attempt int, maxAttempts int)
+}
+
+type Retrier interface {
+	// Try calls attempt until it returns shouldRetry false, or until the
+	// options or the context stop it. Giving up returns an ExhaustedError.
+	Try(attempt func() (shouldRetry bool, err error)) error
+	TryContext(ctx context.Context, attempt func() (shouldRetry bool, err error)) error
+}
+
+type ExhaustedError struct {
+	Attempts int
+
+	// Elapsed is only tracked when a Timeout is configured
+	Elapsed time.Duration
+
+	// Cancelled is the context error when the context stopped the retries
+	Cancelled error
+
+	LastErr error
+}
+
+func (e ExhaustedError) Error() string {
+	attempts := "attempts"
+	if e.Attempts == 1 {
+		attempts = "attempt"
+	}
+
+	msg := fmt.Sprintf("Giving up after %d %s", e.Attempts, attempts)
+	if e.Cancelled != nil {
+		msg = fmt.Sprintf("Cancelled after %d %s (%s)", e.Attempts, attempts, e.Cancelled.Error())
+	}
+
+	if e.Elapsed > 0 {
+		msg += fmt.Sprintf(" over %s", e.Elapsed)
+	}
+
+	if e.LastErr != nil {
+		msg += ": " + e.LastErr.Error()
+	}
+
+	return msg
+}
+
+type retrier struct {
+	opts        Options
+	timeService Clock
+	logger      boshlog.Logger
+	logTag      string
+}
+
+func NewRetrier(opts Options, timeService Clock, logger boshlog.Logger) Retrier {
+	if opts.Backoff == nil {
+		opts.Backoff = NewConstantBackoff(0)
+	}
+
+	return retrier{
+		opts:        opts,
+		timeService: timeService,
+		logger:      logger,
+		logTag:      "retrier",
+	}
+}
+
+func (r retrier) Try(attempt func() (bool, error)) error {
+	return r.TryContext(context.Background(), attempt)
+}
+
+func (r retrier) TryContext(ctx context.Context, attempt func() (bool, error)) error {
+	var start time.Time
+	if r.opts.Timeout > 0 {
+		start = r.timeService.Now()
+	}
+
+	var lastErr error
+
+	for attempts := 1; ; attempts++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ExhaustedError{Attempts: attempts - 1, Cancelled: ctxErr, LastErr: lastErr}
+		}
+
+		r.logger.Debug(r.logTag, "Making attempt #%d", attempts)
+
+		shouldRetry, err := attempt()
+		if !shouldRetry {
+			return err
+		}
+
+		lastErr = err
+
+		if r.opts.MaxAttempts > 0 && attempts >= r.opts.MaxAttempts {
+			return ExhaustedError{Attempts: attempts, LastErr: lastErr}
+		}
+
+		delay := r.opts.Backoff.Delay(attempts)
+
+		if r.opts.Timeout > 0 {
+			now := r.timeService.Now()
+
+			// Stop early when the next attempt would start past the deadline
+			if now.Add(delay).After(start.Add(r.opts.Timeout)) {
+				return ExhaustedError{Attempts: attempts, Elapsed: now.Sub(start), LastErr: lastErr}
+			}
+		}
+
+		if err != nil {
+			r.logger.Debug(r.logTag, "Attempt #%d failed, retrying in %s: %s", attempts, delay, err.Error())
+		}
+
+		r.timeService.Sleep(delay)
+
+		if r.opts.Reporter != nil {
+			r.opts.Reporter.ReportAttempt(r.opts.Name, attempts+1, r.opts.MaxAttempts)
+		}
+	}
+}
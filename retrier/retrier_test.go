@@ -0,0 +1,120 @@
+package retrier_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/retrier"
+)
+
+var _ = Describe("Retrier", func() {
+	var (
+		timeService *fakeClock
+		logger      boshlog.Logger
+		attempts    int
+	)
+
+	BeforeEach(func() {
+		timeService = &fakeClock{now: time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC)}
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		attempts = 0
+	})
+
+	failingAttempt := func(succeedOn int) func() (bool, error) {
+		return func() (bool, error) {
+			attempts++
+			if attempts == succeedOn {
+				return false, nil
+			}
+			return true, errors.New("fake-attempt-error")
+		}
+	}
+
+	It("stops retrying once the attempt succeeds", func() {
+		retrier := NewRetrier(Options{MaxAttempts: 5, Backoff: NewConstantBackoff(time.Second)}, timeService, logger)
+
+		err := retrier.Try(failingAttempt(3))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(attempts).To(Equal(3))
+		Expect(timeService.SleepCalls).To(Equal([]time.Duration{time.Second, time.Second}))
+	})
+
+	It("returns errors that should not be retried right away", func() {
+		retrier := NewRetrier(Options{MaxAttempts: 5}, timeService, logger)
+
+		err := retrier.Try(func() (bool, error) {
+			attempts++
+			return false, errors.New("fake-fatal-error")
+		})
+		Expect(err).To(MatchError("fake-fatal-error"))
+		Expect(attempts).To(Equal(1))
+	})
+
+	It("reports the attempts and the last error after the maximum number of attempts", func() {
+		retrier := NewRetrier(Options{MaxAttempts: 3, Backoff: NewExponentialBackoff(time.Second, 0, 2)}, timeService, logger)
+
+		err := retrier.Try(failingAttempt(0))
+		Expect(err).To(MatchError("Giving up after 3 attempts: fake-attempt-error"))
+		Expect(err.(ExhaustedError).LastErr).To(MatchError("fake-attempt-error"))
+		Expect(timeService.SleepCalls).To(Equal([]time.Duration{time.Second, 2 * time.Second}))
+	})
+
+	It("reports every retry to the reporter", func() {
+		reporter := &fakeAttemptReporter{}
+		retrier := NewRetrier(Options{MaxAttempts: 3, Name: "fake-operation", Reporter: reporter}, timeService, logger)
+
+		err := retrier.Try(failingAttempt(0))
+		Expect(err).To(HaveOccurred())
+		Expect(reporter.Attempts).To(Equal([]string{"fake-operation 2/3", "fake-operation 3/3"}))
+	})
+
+	It("gives up when the next attempt would start after the timeout", func() {
+		retrier := NewRetrier(Options{Timeout: 10 * time.Second, Backoff: NewExponentialBackoff(time.Second, 0, 2)}, timeService, logger)
+
+		err := retrier.Try(failingAttempt(0))
+		Expect(err).To(MatchError("Giving up after 4 attempts over 7s: fake-attempt-error"))
+		Expect(attempts).To(Equal(4))
+	})
+
+	It("stops when the context is cancelled", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		retrier := NewRetrier(Options{}, timeService, logger)
+
+		err := retrier.TryContext(ctx, func() (bool, error) {
+			attempts++
+			if attempts == 2 {
+				cancel()
+			}
+			return true, errors.New("fake-attempt-error")
+		})
+		Expect(err).To(MatchError("Cancelled after 2 attempts (context canceled): fake-attempt-error"))
+	})
+})
+
+type fakeClock struct {
+	now        time.Time
+	SleepCalls []time.Duration
+}
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	c.SleepCalls = append(c.SleepCalls, d)
+	c.now = c.now.Add(d)
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+type fakeAttemptReporter struct {
+	Attempts []string
+}
+
+func (r *fakeAttemptReporter) ReportAttempt(name string, attempt int, maxAttempts int) {
+	r.Attempts = append(r.Attempts, fmt.Sprintf("%s %d/%d", name, attempt, maxAttempts))
+}
@@ -20,6 +20,10 @@ type Client interface {
 
 	Dial(net, addr string) (net.Conn, error)
 	Listen(net, addr string) (net.Listener, error)
+
+	// KeepAlive sends a request the server has to reply to, to detect a
+	// connection that was dropped without being closed
+	KeepAlive() error
 }
 
 type ClientImpl struct {
@@ -104,6 +108,19 @@ func (s *ClientImpl) Listen(n, addr string) (net.Listener, error) {
 	return s.client.Listen(n, addr)
 }
 
+func (s *ClientImpl) KeepAlive() error {
+	if s.client == nil {
+		return bosherr.Error("SSH client is not started")
+	}
+
+	_, _, err := s.client.SendRequest("keepalive@openssh.com", true, nil)
+	if err != nil {
+		return bosherr.WrapError(err, "Sending keepalive")
+	}
+
+	return nil
+}
+
 func (s *ClientImpl) Stop() error {
 	if s.client != nil {
 		return s.client.Close()
@@ -3,6 +3,7 @@ package job
 import (
 	"fmt"
 	"strings"
+	"sync"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
@@ -26,14 +27,23 @@ type DependencyCompiler interface {
 
 type dependencyCompiler struct {
 	packageCompiler bistatepkg.Compiler
+	concurrency     int
 
 	logTag string
 	logger boshlog.Logger
 }
 
 func NewDependencyCompiler(packageCompiler bistatepkg.Compiler, logger boshlog.Logger) DependencyCompiler {
+	return NewDependencyCompilerWithConcurrency(packageCompiler, 1, logger)
+}
+
+// NewDependencyCompilerWithConcurrency compiles up to concurrency packages
+// at the same time once their dependencies are compiled. The packageCompiler
+// must be safe for concurrent use when concurrency is greater than one.
+func NewDependencyCompilerWithConcurrency(packageCompiler bistatepkg.Compiler, concurrency int, logger boshlog.Logger) DependencyCompiler {
 	return &dependencyCompiler{
 		packageCompiler: packageCompiler,
+		concurrency:     concurrency,
 
 		logTag: "dependencyCompiler",
 		logger: logger,
@@ -47,7 +57,13 @@ func (c *dependencyCompiler) Compile(jobs []bireljob.Job, stage biui.Stage) ([]C
 		return nil, bosherr.WrapError(err, "Resolving job package dependencies")
 	}
 
-	compiledPackageRefs, err := c.compilePackages(compileOrderReleasePackages, stage)
+	var compiledPackageRefs []CompiledPackageRef
+
+	if c.concurrency > 1 && len(compileOrderReleasePackages) > 1 {
+		compiledPackageRefs, err = c.compilePackagesConcurrently(compileOrderReleasePackages, stage)
+	} else {
+		compiledPackageRefs, err = c.compilePackages(compileOrderReleasePackages, stage)
+	}
 	if err != nil {
 		return nil, bosherr.WrapError(err, "Compiling job package dependencies")
 	}
@@ -109,34 +125,115 @@ func (c *dependencyCompiler) compilePackages(requiredPackages []birelpkg.Compila
 	packageRefs := make([]CompiledPackageRef, 0, len(requiredPackages))
 
 	for _, pkg := range requiredPackages {
-		stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
-
-		err := stage.Perform(stepName, func() error {
-			compiledPackageRecord, isAlreadyCompiled, err := c.packageCompiler.Compile(pkg)
-			if err != nil {
-				return err
-			}
-
-			packageRef := CompiledPackageRef{
-				Name:        pkg.Name(),
-				Version:     pkg.Fingerprint(),
-				BlobstoreID: compiledPackageRecord.BlobID,
-				SHA1:        compiledPackageRecord.BlobSHA1,
-			}
-			packageRefs = append(packageRefs, packageRef)
-
-			if isAlreadyCompiled {
-				return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
-			}
-
-			return nil
-		})
+		packageRef, err := c.compilePackage(pkg, stage)
 		if err != nil {
 			return nil, err
 		}
+
+		packageRefs = append(packageRefs, packageRef)
 	}
 
 	return packageRefs, nil
 }
 
+// compilePackagesConcurrently compiles each package as soon as its dependencies
+// are compiled, at most c.concurrency at a time. The references are returned
+// in the order of requiredPackages.
+func (c *dependencyCompiler) compilePackagesConcurrently(requiredPackages []birelpkg.Compilable, stage biui.Stage) ([]CompiledPackageRef, error) {
+	packageRefs := make([]CompiledPackageRef, len(requiredPackages))
+	workers := make(chan struct{}, c.concurrency)
+
+	// done channels are closed once a package is compiled, or failed to compile
+	done := map[string]chan struct{}{}
+	failed := map[string]bool{}
+	var failedLock sync.Mutex
+
+	for _, pkg := range requiredPackages {
+		done[c.pkgKey(pkg)] = make(chan struct{})
+	}
+
+	tasks := make([]biui.ParallelTask, len(requiredPackages))
+
+	for i, pkg := range requiredPackages {
+		i, pkg := i, pkg
+
+		tasks[i] = biui.ParallelTask{
+			Name: pkg.Name(),
+			Closure: func(stage biui.Stage) error {
+				success := false
+
+				defer func() {
+					if !success {
+						failedLock.Lock()
+						failed[c.pkgKey(pkg)] = true
+						failedLock.Unlock()
+					}
+
+					close(done[c.pkgKey(pkg)])
+				}()
+
+				for _, dep := range pkg.Deps() {
+					<-done[c.pkgKey(dep)]
+
+					failedLock.Lock()
+					depFailed := failed[c.pkgKey(dep)]
+					failedLock.Unlock()
+
+					if depFailed {
+						return bosherr.Errorf("Dependency '%s' of package '%s' failed to compile", dep.Name(), pkg.Name())
+					}
+				}
+
+				workers <- struct{}{}
+				defer func() { <-workers }()
+
+				packageRef, err := c.compilePackage(pkg, stage)
+				if err != nil {
+					return err
+				}
+
+				packageRefs[i] = packageRef
+				success = true
+
+				return nil
+			},
+		}
+	}
+
+	err := stage.PerformParallel("compiling packages", tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	return packageRefs, nil
+}
+
+func (c *dependencyCompiler) compilePackage(pkg birelpkg.Compilable, stage biui.Stage) (CompiledPackageRef, error) {
+	var packageRef CompiledPackageRef
+
+	stepName := fmt.Sprintf("Compiling package '%s/%s'", pkg.Name(), pkg.Fingerprint())
+
+	err := stage.Perform(stepName, func() error {
+		compiledPackageRecord, isAlreadyCompiled, err := c.packageCompiler.Compile(pkg)
+		if err != nil {
+			return err
+		}
+
+		packageRef = CompiledPackageRef{
+			Name:        pkg.Name(),
+			Version:     pkg.Fingerprint(),
+			BlobstoreID: compiledPackageRecord.BlobID,
+			SHA1:        compiledPackageRecord.BlobSHA1,
+		}
+
+		if isAlreadyCompiled {
+			return biui.NewSkipStageError(bosherr.Error(fmt.Sprintf("Package '%s' is already compiled. Skipped compilation", pkg.Name())), "Package already compiled")
+		}
+
+		return nil
+	})
+
+	return packageRef, err
+}
+
 func (c *dependencyCompiler) pkgKey(pkg birelpkg.Compilable) string { return pkg.Name() }
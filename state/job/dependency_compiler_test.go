@@ -1,6 +1,12 @@
 package job_test
 
 import (
+	"bytes"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"code.cloudfoundry.org/clock"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"github.com/golang/mock/gomock"
 	. "github.com/onsi/ginkgo"
@@ -12,6 +18,7 @@ import (
 	. "github.com/cloudfoundry/bosh-cli/state/job"
 	bistatepkg "github.com/cloudfoundry/bosh-cli/state/pkg"
 	mock_state_package "github.com/cloudfoundry/bosh-cli/state/pkg/mocks"
+	biui "github.com/cloudfoundry/bosh-cli/ui"
 	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
 
@@ -215,4 +222,125 @@ var _ = Describe("DependencyCompiler", func() {
 			Expect(err).ToNot(HaveOccurred())
 		})
 	})
+
+	Context("when compiling packages concurrently", func() {
+		var (
+			pkg3              *boshrelpkg.Package
+			expectCompilePkg3 *gomock.Call
+		)
+
+		BeforeEach(func() {
+			dependencyCompiler = NewDependencyCompilerWithConcurrency(mockPackageCompiler, 2, logger)
+
+			pkg3 = newPkg("pkg3-name", "pkg3-fp", nil)
+
+			job.PackageNames = append(job.PackageNames, pkg3.Name())
+			job.AttachPackages([]*boshrelpkg.Package{pkg2, pkg3})
+			jobs = []boshreljob.Job{*job}
+		})
+
+		JustBeforeEach(func() {
+			compiledPackageRecord3 := bistatepkg.CompiledPackageRecord{
+				BlobID:   "fake-compiled-package-blobstore-id-3",
+				BlobSHA1: "fake-compiled-package-sha1-3",
+			}
+			expectCompilePkg3 = mockPackageCompiler.EXPECT().Compile(pkg3).Return(compiledPackageRecord3, false, nil).AnyTimes()
+		})
+
+		It("compiles packages after their dependencies and returns references in compilation order", func() {
+			expectCompilePkg1.Times(1)
+			expectCompilePkg2.Times(1).After(expectCompilePkg1)
+			expectCompilePkg3.Times(1)
+
+			compiledPackageRefs, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).ToNot(HaveOccurred())
+
+			names := []string{}
+			for _, ref := range compiledPackageRefs {
+				names = append(names, ref.Name)
+			}
+			Expect(names).To(ConsistOf("pkg1-name", "pkg2-name", "pkg3-name"))
+
+			// pkg3 has no dependencies and may finish at any time
+			indexOf := func(name string) int {
+				for i, n := range names {
+					if n == name {
+						return i
+					}
+				}
+				return -1
+			}
+			Expect(indexOf("pkg1-name")).To(BeNumerically("<", indexOf("pkg2-name")))
+		})
+
+		It("logs a compile stage per package", func() {
+			_, err := dependencyCompiler.Compile(jobs, stage)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(stage.PerformCalls).To(HaveLen(1))
+			Expect(stage.PerformCalls[0].Name).To(Equal("compiling packages"))
+
+			packageStages := stage.PerformCalls[0].Stage.PerformCalls
+			Expect(packageStages).To(HaveLen(3))
+
+			for _, packageStage := range packageStages {
+				Expect(packageStage.Stage.PerformCalls).To(Equal([]*fakeui.PerformCall{
+					{Name: "Compiling package '" + packageStage.Name + "/" + packageStage.Name[:4] + "-fp'"},
+				}))
+			}
+		})
+
+		It("compiles independent packages at the same time", func() {
+			var running, maxRunning int32
+
+			compile := func(record bistatepkg.CompiledPackageRecord) func(interface{}) (bistatepkg.CompiledPackageRecord, bool, error) {
+				return func(interface{}) (bistatepkg.CompiledPackageRecord, bool, error) {
+					current := atomic.AddInt32(&running, 1)
+					defer atomic.AddInt32(&running, -1)
+
+					for {
+						max := atomic.LoadInt32(&maxRunning)
+						if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+							break
+						}
+					}
+
+					time.Sleep(100 * time.Millisecond)
+
+					return record, false, nil
+				}
+			}
+
+			mockCtrl = gomock.NewController(GinkgoT())
+			mockPackageCompiler = mock_state_package.NewMockCompiler(mockCtrl)
+			mockPackageCompiler.EXPECT().Compile(pkg1).DoAndReturn(compile(bistatepkg.CompiledPackageRecord{BlobID: "blob-1"}))
+			mockPackageCompiler.EXPECT().Compile(pkg2).DoAndReturn(compile(bistatepkg.CompiledPackageRecord{BlobID: "blob-2"}))
+			mockPackageCompiler.EXPECT().Compile(pkg3).DoAndReturn(compile(bistatepkg.CompiledPackageRecord{BlobID: "blob-3"}))
+
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			ui := biui.NewWriterUI(bytes.NewBufferString(""), bytes.NewBufferString(""), logger)
+
+			dependencyCompiler = NewDependencyCompilerWithConcurrency(mockPackageCompiler, 2, logger)
+
+			_, err := dependencyCompiler.Compile(jobs, biui.NewStage(ui, clock.NewClock(), logger))
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(atomic.LoadInt32(&maxRunning)).To(Equal(int32(2)))
+		})
+
+		Context("when a package fails to compile", func() {
+			BeforeEach(func() {
+				mockPackageCompiler.EXPECT().Compile(pkg1).Return(bistatepkg.CompiledPackageRecord{}, false, errors.New("fake-compile-error"))
+			})
+
+			It("does not compile the packages depending on it", func() {
+				expectCompilePkg2.Times(0)
+				expectCompilePkg3.Times(1)
+
+				_, err := dependencyCompiler.Compile(jobs, stage)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-compile-error"))
+			})
+		})
+	})
 })
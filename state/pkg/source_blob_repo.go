@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	biindex "github.com/cloudfoundry/bosh-cli/index"
+	birelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// SourceBlobRepo tracks the blobstore IDs of uploaded package archives.
+// Archives are found by their SHA digest, so a package is only uploaded
+// again when its archive changed.
+type SourceBlobRepo interface {
+	Save(birelpkg.Compilable, string) error
+	Find(birelpkg.Compilable) (string, bool, error)
+}
+
+type sourceBlobRepo struct {
+	index biindex.Index
+}
+
+func NewSourceBlobRepo(index biindex.Index) SourceBlobRepo {
+	return &sourceBlobRepo{index: index}
+}
+
+type packageToSourceBlobKey struct {
+	ArchiveDigest string
+}
+
+type sourceBlobRecord struct {
+	BlobID string
+}
+
+func (r *sourceBlobRepo) Save(pkg birelpkg.Compilable, blobID string) error {
+	err := r.index.Save(r.pkgKey(pkg), sourceBlobRecord{BlobID: blobID})
+	if err != nil {
+		return bosherr.WrapError(err, "Saving source blob")
+	}
+
+	return nil
+}
+
+func (r *sourceBlobRepo) Find(pkg birelpkg.Compilable) (string, bool, error) {
+	var record sourceBlobRecord
+
+	err := r.index.Find(r.pkgKey(pkg), &record)
+	if err != nil {
+		if err == biindex.ErrNotFound {
+			return "", false, nil
+		}
+
+		return "", false, bosherr.WrapError(err, "Finding source blob")
+	}
+
+	return record.BlobID, true, nil
+}
+
+func (r *sourceBlobRepo) pkgKey(pkg birelpkg.Compilable) packageToSourceBlobKey {
+	return packageToSourceBlobKey{
+		ArchiveDigest: pkg.ArchiveDigest(),
+	}
+}
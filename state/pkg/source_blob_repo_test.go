@@ -0,0 +1,54 @@
+package pkg_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biindex "github.com/cloudfoundry/bosh-cli/index"
+	boshrelpkg "github.com/cloudfoundry/bosh-cli/release/pkg"
+	. "github.com/cloudfoundry/bosh-cli/release/resource"
+	. "github.com/cloudfoundry/bosh-cli/state/pkg"
+)
+
+var _ = Describe("SourceBlobRepo", func() {
+	var (
+		sourceBlobRepo SourceBlobRepo
+	)
+
+	BeforeEach(func() {
+		sourceBlobRepo = NewSourceBlobRepo(biindex.NewInMemoryIndex())
+	})
+
+	newPkgWithDigest := func(name, fp, digest string) *boshrelpkg.Package {
+		return boshrelpkg.NewPackage(NewResourceWithBuiltArchive(name, fp, "", digest), nil)
+	}
+
+	It("finds the blob ID saved for a package archive", func() {
+		err := sourceBlobRepo.Save(newPkgWithDigest("pkg-name", "pkg-fp", "pkg-digest"), "blob-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		blobID, found, err := sourceBlobRepo.Find(newPkgWithDigest("pkg-name", "pkg-fp", "pkg-digest"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(blobID).To(Equal("blob-id"))
+	})
+
+	It("does not find the blob ID once the package archive changed", func() {
+		err := sourceBlobRepo.Save(newPkgWithDigest("pkg-name", "pkg-fp", "pkg-digest"), "blob-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		_, found, err := sourceBlobRepo.Find(newPkgWithDigest("pkg-name", "pkg-fp", "new-pkg-digest"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("finds the blob ID by the archive digest alone", func() {
+		err := sourceBlobRepo.Save(newPkgWithDigest("pkg-name", "pkg-fp", "pkg-digest"), "blob-id")
+		Expect(err).ToNot(HaveOccurred())
+
+		blobID, found, err := sourceBlobRepo.Find(newPkgWithDigest("renamed-pkg-name", "other-pkg-fp", "pkg-digest"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(blobID).To(Equal("blob-id"))
+	})
+})
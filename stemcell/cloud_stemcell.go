@@ -6,6 +6,11 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
+// ExistingStemcellName is the record name used for stemcells that were
+// provided by CID (--stemcell-cid) rather than uploaded by the CLI.
+// The record version is the CID. Such stemcells are never deleted from the IAAS.
+const ExistingStemcellName = "existing-stemcell"
+
 type CloudStemcell interface {
 	CID() string
 	Name() string
@@ -15,11 +20,12 @@ type CloudStemcell interface {
 }
 
 type cloudStemcell struct {
-	cid     string
-	name    string
-	version string
-	repo    biconfig.StemcellRepo
-	cloud   bicloud.Cloud
+	cid      string
+	name     string
+	version  string
+	imported bool
+	repo     biconfig.StemcellRepo
+	cloud    bicloud.Cloud
 }
 
 func NewCloudStemcell(
@@ -28,11 +34,12 @@ func NewCloudStemcell(
 	cloud bicloud.Cloud,
 ) CloudStemcell {
 	return &cloudStemcell{
-		cid:     stemcellRecord.CID,
-		name:    stemcellRecord.Name,
-		version: stemcellRecord.Version,
-		repo:    repo,
-		cloud:   cloud,
+		cid:      stemcellRecord.CID,
+		name:     stemcellRecord.Name,
+		version:  stemcellRecord.Version,
+		imported: stemcellRecord.Imported,
+		repo:     repo,
+		cloud:    cloud,
 	}
 }
 
@@ -67,12 +74,18 @@ func (s *cloudStemcell) PromoteAsCurrent() error {
 }
 
 func (s *cloudStemcell) Delete() error {
-	deleteErr := s.cloud.DeleteStemcell(s.cid)
-	if deleteErr != nil {
-		// allow StemcellNotFoundError for idempotency
-		cloudErr, ok := deleteErr.(bicloud.Error)
-		if !ok || cloudErr.Type() != bicloud.StemcellNotFoundError {
-			return bosherr.WrapError(deleteErr, "Deleting stemcell from cloud")
+	var deleteErr error
+
+	// imported stemcells are owned by another environment, only their
+	// record is deleted
+	if s.name != ExistingStemcellName && !s.imported {
+		deleteErr = s.cloud.DeleteStemcell(s.cid)
+		if deleteErr != nil {
+			// allow StemcellNotFoundError for idempotency
+			cloudErr, ok := deleteErr.(bicloud.Error)
+			if !ok || cloudErr.Type() != bicloud.StemcellNotFoundError {
+				return bosherr.WrapError(deleteErr, "Deleting stemcell from cloud")
+			}
 		}
 	}
 
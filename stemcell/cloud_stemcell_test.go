@@ -92,6 +92,48 @@ var _ = Describe("CloudStemcell", func() {
 			Expect(stemcellRecords).To(BeEmpty())
 		})
 
+		Context("when the stemcell was imported from another environment", func() {
+			BeforeEach(func() {
+				stemcellRecord, err := stemcellRepo.SaveImported("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
+				Expect(err).ToNot(HaveOccurred())
+				cloudStemcell = NewCloudStemcell(stemcellRecord, stemcellRepo, fakeCloud)
+			})
+
+			It("only deletes the stemcell from the repo", func() {
+				err := cloudStemcell.Delete()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeCloud.DeleteStemcellInputs).To(BeEmpty())
+
+				stemcellRecords, err := stemcellRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stemcellRecords).To(BeEmpty())
+			})
+		})
+
+		Context("when the stemcell was provided by CID", func() {
+			BeforeEach(func() {
+				stemcellRecord := biconfig.StemcellRecord{
+					CID:     "fake-existing-stemcell-cid",
+					Name:    ExistingStemcellName,
+					Version: "fake-existing-stemcell-cid",
+				}
+				cloudStemcell = NewCloudStemcell(stemcellRecord, stemcellRepo, fakeCloud)
+
+				_, err := stemcellRepo.Save(ExistingStemcellName, "fake-existing-stemcell-cid", "fake-existing-stemcell-cid")
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("only deletes the stemcell from the repo", func() {
+				err := cloudStemcell.Delete()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(fakeCloud.DeleteStemcellInputs).To(BeEmpty())
+
+				stemcellRecords, err := stemcellRepo.All()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(stemcellRecords).To(BeEmpty())
+			})
+		})
+
 		Context("when deleted stemcell is the current stemcell", func() {
 			BeforeEach(func() {
 				stemcellRecord, err := stemcellRepo.Save("fake-stemcell-name", "fake-stemcell-version", "fake-stemcell-cid")
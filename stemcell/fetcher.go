@@ -1,6 +1,10 @@
 package stemcell
 
 import (
+	"fmt"
+	"strings"
+
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
 	bideplmanifest "github.com/cloudfoundry/bosh-cli/deployment/manifest"
 	bitarball "github.com/cloudfoundry/bosh-cli/installation/tarball"
 	biui "github.com/cloudfoundry/bosh-cli/ui"
@@ -10,9 +14,23 @@ import (
 type Fetcher struct {
 	TarballProvider   bitarball.Provider
 	StemcellExtractor Extractor
+
+	// ImageConverter is used when the manifest asks for a stemcell.disk_format
+	ImageConverter ImageConverter
+
+	// DigestVerifier is used by GetStemcellWithDigest, unless the
+	// StemcellExtractor is a VerifyingExtractor
+	DigestVerifier bicrypto.DigestVerifier
 }
 
 func (s Fetcher) GetStemcell(deploymentManifest bideplmanifest.Manifest, stage biui.Stage) (ExtractedStemcell, error) {
+	return s.GetStemcellWithDigest(deploymentManifest, "", stage)
+}
+
+// GetStemcellWithDigest verifies the stemcell tarball against digest before
+// extracting it, also when it is a local file. An empty digest skips the
+// verification.
+func (s Fetcher) GetStemcellWithDigest(deploymentManifest bideplmanifest.Manifest, digest string, stage biui.Stage) (ExtractedStemcell, error) {
 	stemcell, err := deploymentManifest.Stemcell(deploymentManifest.JobName())
 	if err != nil {
 		return nil, err
@@ -25,6 +43,26 @@ func (s Fetcher) GetStemcell(deploymentManifest bideplmanifest.Manifest, stage b
 
 	var extractedStemcell ExtractedStemcell
 	err = stage.Perform("Validating stemcell", func() error {
+		if verifyingExtractor, ok := s.StemcellExtractor.(VerifyingExtractor); ok {
+			extractedStemcell, err = verifyingExtractor.ExtractWithDigest(stemcellTarballPath, digest)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Extracting stemcell from '%s'", stemcellTarballPath)
+			}
+
+			return nil
+		}
+
+		if digest != "" {
+			if s.DigestVerifier == nil {
+				return bosherr.Error("Verifying stemcells is not supported")
+			}
+
+			err := s.DigestVerifier.Verify(stemcellTarballPath, digest)
+			if err != nil {
+				return bosherr.WrapError(err, "Verifying stemcell")
+			}
+		}
+
 		extractedStemcell, err = s.StemcellExtractor.Extract(stemcellTarballPath)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "Extracting stemcell from '%s'", stemcellTarballPath)
@@ -36,5 +74,78 @@ func (s Fetcher) GetStemcell(deploymentManifest bideplmanifest.Manifest, stage b
 		return nil, err
 	}
 
+	if stemcell.DiskFormat != "" {
+		err = stage.Perform(fmt.Sprintf("Converting stemcell image to '%s'", stemcell.DiskFormat), func() error {
+			if s.ImageConverter == nil {
+				return bosherr.Errorf("Converting stemcell images is not supported")
+			}
+
+			return s.ImageConverter.Convert(extractedStemcell, stemcell.DiskFormat)
+		})
+		if err != nil {
+			_ = extractedStemcell.Cleanup()
+			return nil, err
+		}
+	}
+
 	return extractedStemcell, nil
 }
+
+// ExtractStemcells extracts stemcell tarballs given on the command line so
+// that resource pools can refer to them by name and version. Stemcells given
+// as URLs are downloaded like manifest stemcells.
+func (s Fetcher) ExtractStemcells(paths []string, stage biui.Stage) ([]ExtractedStemcell, error) {
+	extractedStemcells := []ExtractedStemcell{}
+
+	cleanup := func() {
+		for _, extractedStemcell := range extractedStemcells {
+			_ = extractedStemcell.Cleanup()
+		}
+	}
+
+	for _, path := range paths {
+		stemcellRef, err := stemcellArgRef(path)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		tarballPath, err := s.TarballProvider.Get(stemcellRef, stage)
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+
+		err = stage.Perform(fmt.Sprintf("Validating stemcell '%s'", stemcellRef.URL), func() error {
+			extractedStemcell, err := s.StemcellExtractor.Extract(tarballPath)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Extracting stemcell from '%s'", tarballPath)
+			}
+
+			extractedStemcells = append(extractedStemcells, extractedStemcell)
+			return nil
+		})
+		if err != nil {
+			cleanup()
+			return nil, err
+		}
+	}
+
+	return extractedStemcells, nil
+}
+
+// stemcellArgRef turns a stemcell given on the command line into a stemcell
+// reference. There is no manifest to give the sha1 of remote stemcells in,
+// so it follows the URL after '#', e.g. https://example.com/stemcell.tgz#sha256:abc...
+func stemcellArgRef(path string) (bideplmanifest.StemcellRef, error) {
+	if !strings.HasPrefix(path, "http://") && !strings.HasPrefix(path, "https://") {
+		return bideplmanifest.StemcellRef{URL: path}, nil
+	}
+
+	idx := strings.LastIndex(path, "#")
+	if idx == -1 || idx == len(path)-1 {
+		return bideplmanifest.StemcellRef{}, bosherr.Errorf("Stemcell URL '%s' must be followed by '#' and the sha1 of the stemcell", path)
+	}
+
+	return bideplmanifest.StemcellRef{URL: path[:idx], SHA1: path[idx+1:]}, nil
+}
@@ -0,0 +1,136 @@
+package stemcell
+
+import (
+	"encoding/json"
+	"path/filepath"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+const (
+	qemuImgCommand = "qemu-img"
+
+	// imageDiskFileName is the disk inside the image tarball of stemcells
+	// whose image formats can be converted (e.g. OpenStack stemcells)
+	imageDiskFileName = "root.img"
+)
+
+// ImageConverter converts the disk inside a stemcell image to another format
+// so that one stemcell artifact can be used with CPIs expecting different formats
+type ImageConverter interface {
+	Convert(stemcell ExtractedStemcell, diskFormat string) error
+}
+
+type qemuImgConverter struct {
+	cmdRunner  boshsys.CmdRunner
+	compressor boshfu.Compressor
+	fs         boshsys.FileSystem
+	logger     boshlog.Logger
+	logTag     string
+}
+
+// NewQemuImgConverter converts images with qemu-img, which has to be on the PATH
+func NewQemuImgConverter(cmdRunner boshsys.CmdRunner, compressor boshfu.Compressor, fs boshsys.FileSystem, logger boshlog.Logger) ImageConverter {
+	return qemuImgConverter{
+		cmdRunner:  cmdRunner,
+		compressor: compressor,
+		fs:         fs,
+		logger:     logger,
+		logTag:     "qemuImgConverter",
+	}
+}
+
+// Convert replaces the stemcell image with one holding a disk in diskFormat
+// and records the format in the disk_format cloud property.
+// Nothing is converted when the disk is already in diskFormat.
+func (c qemuImgConverter) Convert(stemcell ExtractedStemcell, diskFormat string) error {
+	if !c.cmdRunner.CommandExists(qemuImgCommand) {
+		return bosherr.Errorf("Converting stemcell image to '%s' requires '%s' to be installed and on the PATH", diskFormat, qemuImgCommand)
+	}
+
+	imagePath := filepath.Join(stemcell.GetExtractedPath(), "image")
+
+	imageDir, err := c.fs.TempDir("stemcell-image-conversion")
+	if err != nil {
+		return bosherr.WrapError(err, "Creating temp dir for stemcell image conversion")
+	}
+
+	defer func() {
+		if err := c.fs.RemoveAll(imageDir); err != nil {
+			c.logger.Warn(c.logTag, "Failed to remove stemcell image conversion dir '%s': %s", imageDir, err.Error())
+		}
+	}()
+
+	err = c.compressor.DecompressFileToDir(imagePath, imageDir, boshfu.CompressorOptions{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Extracting stemcell image '%s'", imagePath)
+	}
+
+	diskPath := filepath.Join(imageDir, imageDiskFileName)
+	if !c.fs.FileExists(diskPath) {
+		return bosherr.Errorf("Expected stemcell image '%s' to contain '%s' to convert it to '%s'", imagePath, imageDiskFileName, diskFormat)
+	}
+
+	currentFormat, err := c.detectFormat(diskPath)
+	if err != nil {
+		return err
+	}
+
+	if currentFormat == diskFormat {
+		c.logger.Debug(c.logTag, "Stemcell image '%s' is already in format '%s'", imagePath, diskFormat)
+		stemcell.SetCloudProperties(biproperty.Map{"disk_format": diskFormat})
+		return nil
+	}
+
+	convertedPath := diskPath + ".converted"
+
+	_, stderr, _, err := c.cmdRunner.RunCommand(qemuImgCommand, "convert", "-f", currentFormat, "-O", diskFormat, diskPath, convertedPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Converting stemcell image from '%s' to '%s': %s", currentFormat, diskFormat, stderr)
+	}
+
+	err = c.fs.Rename(convertedPath, diskPath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Replacing stemcell disk '%s'", diskPath)
+	}
+
+	convertedImagePath, err := c.compressor.CompressFilesInDir(imageDir)
+	if err != nil {
+		return bosherr.WrapError(err, "Packing converted stemcell image")
+	}
+
+	err = boshfu.NewFileMover(c.fs).Move(convertedImagePath, imagePath)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Moving converted stemcell image to '%s'", imagePath)
+	}
+
+	stemcell.SetCloudProperties(biproperty.Map{"disk_format": diskFormat})
+
+	return nil
+}
+
+func (c qemuImgConverter) detectFormat(diskPath string) (string, error) {
+	stdout, stderr, _, err := c.cmdRunner.RunCommand(qemuImgCommand, "info", "--output=json", diskPath)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Detecting format of stemcell disk '%s': %s", diskPath, stderr)
+	}
+
+	var info struct {
+		Format string `json:"format"`
+	}
+
+	err = json.Unmarshal([]byte(stdout), &info)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Unmarshalling '%s info' output", qemuImgCommand)
+	}
+
+	if info.Format == "" {
+		return "", bosherr.Errorf("Detecting format of stemcell disk '%s': '%s info' did not report a format", diskPath, qemuImgCommand)
+	}
+
+	return info.Format, nil
+}
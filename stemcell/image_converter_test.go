@@ -0,0 +1,137 @@
+package stemcell_test
+
+import (
+	. "github.com/cloudfoundry/bosh-cli/stemcell"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"errors"
+
+	boshcmdfakes "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+)
+
+var _ = Describe("qemuImgConverter", func() {
+	var (
+		converter  ImageConverter
+		stemcell   ExtractedStemcell
+		fakefs     *fakesys.FakeFileSystem
+		cmdRunner  *fakesys.FakeCmdRunner
+		compressor *boshcmdfakes.FakeCompressor
+		infoStdout string
+	)
+
+	BeforeEach(func() {
+		infoStdout = `{"format": "qcow2", "virtual-size": 3221225472}`
+
+		fakefs = fakesys.NewFakeFileSystem()
+		fakefs.TempDirDir = "/conversion-dir"
+		cmdRunner = fakesys.NewFakeCmdRunner()
+		cmdRunner.AvailableCommands["qemu-img"] = true
+		compressor = boshcmdfakes.NewFakeCompressor()
+
+		compressor.DecompressFileToDirCallBack = func() {
+			err := fakefs.WriteFileString("/conversion-dir/root.img", "qcow2-disk")
+			Expect(err).ToNot(HaveOccurred())
+		}
+		compressor.CompressFilesInDirTarballPath = "/converted-image.tgz"
+
+		err := fakefs.WriteFileString("/extracted-stemcell/image", "qcow2-image")
+		Expect(err).ToNot(HaveOccurred())
+
+		err = fakefs.WriteFileString("/converted-image.tgz", "converted-image")
+		Expect(err).ToNot(HaveOccurred())
+
+		convertCmd := "qemu-img convert -f qcow2 -O raw /conversion-dir/root.img /conversion-dir/root.img.converted"
+		cmdRunner.SetCmdCallback(convertCmd, func() {
+			err := fakefs.WriteFileString("/conversion-dir/root.img.converted", "raw-disk")
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		stemcell = NewExtractedStemcell(
+			Manifest{Name: "fake-stemcell", CloudProperties: biproperty.Map{"disk_format": "qcow2", "other": "value"}},
+			"/extracted-stemcell",
+			compressor,
+			fakefs,
+		)
+
+		converter = NewQemuImgConverter(cmdRunner, compressor, fakefs, boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	JustBeforeEach(func() {
+		cmdRunner.AddCmdResult("qemu-img info --output=json /conversion-dir/root.img", fakesys.FakeCmdResult{
+			Stdout: infoStdout,
+		})
+	})
+
+	It("converts the disk in the image and records the new disk format", func() {
+		err := converter.Convert(stemcell, "raw")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(compressor.DecompressFileToDirTarballPaths).To(Equal([]string{"/extracted-stemcell/image"}))
+		Expect(cmdRunner.RunCommands).To(Equal([][]string{
+			{"qemu-img", "info", "--output=json", "/conversion-dir/root.img"},
+			{"qemu-img", "convert", "-f", "qcow2", "-O", "raw", "/conversion-dir/root.img", "/conversion-dir/root.img.converted"},
+		}))
+		Expect(compressor.CompressFilesInDirDir).To(Equal("/conversion-dir"))
+
+		contents, err := fakefs.ReadFileString("/extracted-stemcell/image")
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal("converted-image"))
+
+		Expect(stemcell.Manifest().CloudProperties).To(Equal(biproperty.Map{"disk_format": "raw", "other": "value"}))
+		Expect(fakefs.FileExists("/conversion-dir")).To(BeFalse())
+	})
+
+	It("does not convert a disk that is already in the format", func() {
+		err := converter.Convert(stemcell, "qcow2")
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(cmdRunner.RunCommands).To(HaveLen(1))
+		Expect(compressor.CompressFilesInDirDir).To(BeEmpty())
+		Expect(stemcell.Manifest().CloudProperties["disk_format"]).To(Equal("qcow2"))
+	})
+
+	It("returns an error when qemu-img is not available", func() {
+		cmdRunner.AvailableCommands = map[string]bool{}
+
+		err := converter.Convert(stemcell, "raw")
+		Expect(err).To(MatchError("Converting stemcell image to 'raw' requires 'qemu-img' to be installed and on the PATH"))
+		Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+	})
+
+	It("returns an error when the image does not contain a disk", func() {
+		compressor.DecompressFileToDirCallBack = nil
+
+		err := converter.Convert(stemcell, "raw")
+		Expect(err).To(MatchError("Expected stemcell image '/extracted-stemcell/image' to contain 'root.img' to convert it to 'raw'"))
+	})
+
+	It("returns an error when qemu-img fails to convert the disk", func() {
+		cmdRunner.AddCmdResult("qemu-img convert -f qcow2 -O raw /conversion-dir/root.img /conversion-dir/root.img.converted", fakesys.FakeCmdResult{
+			Stderr: "fake-stderr",
+			Error:  errors.New("fake-convert-err"),
+		})
+
+		err := converter.Convert(stemcell, "raw")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Converting stemcell image from 'qcow2' to 'raw': fake-stderr"))
+		Expect(err.Error()).To(ContainSubstring("fake-convert-err"))
+		Expect(stemcell.Manifest().CloudProperties["disk_format"]).To(Equal("qcow2"))
+	})
+
+	Context("when the disk format cannot be detected", func() {
+		BeforeEach(func() {
+			infoStdout = `{}`
+		})
+
+		It("returns an error", func() {
+			err := converter.Convert(stemcell, "raw")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'qemu-img info' did not report a format"))
+		})
+	})
+})
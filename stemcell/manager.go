@@ -2,7 +2,6 @@ package stemcell
 
 import (
 	"fmt"
-	"path/filepath"
 
 	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
@@ -13,8 +12,12 @@ import (
 type Manager interface {
 	FindCurrent() ([]CloudStemcell, error)
 	Upload(ExtractedStemcell, biui.Stage) (CloudStemcell, error)
+	UseExisting(cid string, stage biui.Stage) (CloudStemcell, error)
 	FindUnused() ([]CloudStemcell, error)
 	DeleteUnused(biui.Stage) error
+
+	// DeleteUnusedExcept keeps inUse stemcells even when they are not current
+	DeleteUnusedExcept(stage biui.Stage, inUse []CloudStemcell) error
 }
 
 type manager struct {
@@ -62,7 +65,12 @@ func (m *manager) Upload(extractedStemcell ExtractedStemcell, uploadStage biui.S
 			return biui.NewSkipStageError(bosherr.Errorf("Found stemcell: %#v", foundStemcellRecord), "Stemcell already uploaded")
 		}
 
-		cid, err := m.cloud.CreateStemcell(filepath.Join(extractedStemcell.GetExtractedPath(), "image"), manifest.CloudProperties)
+		image, err := extractedStemcell.Image()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Preparing stemcell image (%s %s)", manifest.Name, manifest.Version)
+		}
+
+		cid, err := m.cloud.CreateStemcell(image.Path, image.CloudProperties)
 		if err != nil {
 			return bosherr.WrapErrorf(err, "creating stemcell (%s %s)", manifest.Name, manifest.Version)
 		}
@@ -83,10 +91,34 @@ func (m *manager) Upload(extractedStemcell ExtractedStemcell, uploadStage biui.S
 	return cloudStemcell, nil
 }
 
+// UseExisting records a stemcell that is already present in the IAAS so it
+// can be deployed without extracting or uploading a stemcell tarball.
+func (m *manager) UseExisting(cid string, stage biui.Stage) (cloudStemcell CloudStemcell, err error) {
+	err = stage.Perform(fmt.Sprintf("Using existing stemcell '%s'", cid), func() error {
+		stemcellRecord, found, err := m.repo.Find(ExistingStemcellName, cid)
+		if err != nil {
+			return bosherr.WrapError(err, "Finding existing stemcell record in repo")
+		}
+
+		if !found {
+			stemcellRecord, err = m.repo.Save(ExistingStemcellName, cid, cid)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "saving stemcell record in repo (cid=%s)", cid)
+			}
+		}
+
+		cloudStemcell = NewCloudStemcell(stemcellRecord, m.repo, m.cloud)
+		return nil
+	})
+
+	return cloudStemcell, err
+}
+
 func (m *manager) FindUnused() ([]CloudStemcell, error) {
 	unusedStemcells := []CloudStemcell{}
 
-	stemcellRecords, err := m.repo.All()
+	// stemcells of other CPIs cannot be deleted with this one
+	stemcellRecords, err := m.repo.AllForCurrentCPI()
 	if err != nil {
 		return unusedStemcells, bosherr.WrapError(err, "Getting all stemcell records")
 	}
@@ -97,6 +129,11 @@ func (m *manager) FindUnused() ([]CloudStemcell, error) {
 	}
 
 	for _, stemcellRecord := range stemcellRecords {
+		// imported stemcells are kept for the environments sharing them
+		if stemcellRecord.Imported {
+			continue
+		}
+
 		if !found || stemcellRecord.ID != currentStemcellRecord.ID {
 			stemcell := NewCloudStemcell(stemcellRecord, m.repo, m.cloud)
 			unusedStemcells = append(unusedStemcells, stemcell)
@@ -107,12 +144,20 @@ func (m *manager) FindUnused() ([]CloudStemcell, error) {
 }
 
 func (m *manager) DeleteUnused(deleteStage biui.Stage) error {
+	return m.DeleteUnusedExcept(deleteStage, nil)
+}
+
+func (m *manager) DeleteUnusedExcept(deleteStage biui.Stage, inUse []CloudStemcell) error {
 	stemcells, err := m.FindUnused()
 	if err != nil {
 		return bosherr.WrapError(err, "Finding unused stemcells")
 	}
 
 	for _, stemcell := range stemcells {
+		if m.contains(inUse, stemcell) {
+			continue
+		}
+
 		stepName := fmt.Sprintf("Deleting unused stemcell '%s'", stemcell.CID())
 		err = deleteStage.Perform(stepName, func() error {
 			err := stemcell.Delete()
@@ -129,3 +174,13 @@ func (m *manager) DeleteUnused(deleteStage biui.Stage) error {
 
 	return nil
 }
+
+func (m *manager) contains(stemcells []CloudStemcell, stemcell CloudStemcell) bool {
+	for _, s := range stemcells {
+		if s.CID() == stemcell.CID() {
+			return true
+		}
+	}
+
+	return false
+}
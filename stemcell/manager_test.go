@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 
 	biconfig "github.com/cloudfoundry/bosh-cli/config"
+	fakeboshcmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
@@ -21,15 +22,16 @@ import (
 
 var _ = Describe("Manager", func() {
 	var (
-		stemcellRepo        biconfig.StemcellRepo
-		fakeUUIDGenerator   *fakeuuid.FakeGenerator
-		manager             Manager
-		fs                  *fakesys.FakeFileSystem
-		reader              *fakebistemcell.FakeStemcellReader
-		fakeCloud           *fakebicloud.FakeCloud
-		fakeStage           *fakebiui.FakeStage
-		stemcellTarballPath string
-		tempExtractionDir   string
+		stemcellRepo           biconfig.StemcellRepo
+		deploymentStateService biconfig.DeploymentStateService
+		fakeUUIDGenerator      *fakeuuid.FakeGenerator
+		manager                Manager
+		fs                     *fakesys.FakeFileSystem
+		reader                 *fakebistemcell.FakeStemcellReader
+		fakeCloud              *fakebicloud.FakeCloud
+		fakeStage              *fakebiui.FakeStage
+		stemcellTarballPath    string
+		tempExtractionDir      string
 
 		expectedExtractedStemcell ExtractedStemcell
 	)
@@ -39,7 +41,7 @@ var _ = Describe("Manager", func() {
 		reader = fakebistemcell.NewFakeReader()
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fakeUUIDGenerator = &fakeuuid.FakeGenerator{}
-		deploymentStateService := biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, filepath.Join("/", "fake", "path"))
+		deploymentStateService = biconfig.NewFileSystemDeploymentStateService(fs, fakeUUIDGenerator, logger, filepath.Join("/", "fake", "path"))
 		fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-1"
 		stemcellRepo = biconfig.NewStemcellRepo(deploymentStateService, fakeUUIDGenerator)
 		fakeStage = fakebiui.NewFakeStage()
@@ -94,6 +96,42 @@ var _ = Describe("Manager", func() {
 			}))
 		})
 
+		Context("when the stemcell only comes as OVF files", func() {
+			BeforeEach(func() {
+				err := fs.MkdirAll(tempExtractionDir, 0755)
+				Expect(err).ToNot(HaveOccurred())
+
+				expectedExtractedStemcell = NewExtractedStemcell(
+					Manifest{
+						Name:            "fake-stemcell-name",
+						Version:         "fake-stemcell-version",
+						StemcellFormats: []string{"vsphere-ovf"},
+						CloudProperties: biproperty.Map{
+							"fake-prop-key": "fake-prop-value",
+						},
+					},
+					tempExtractionDir,
+					&fakeboshcmd.FakeCompressor{},
+					fs,
+				)
+			})
+
+			It("passes the unpacked image directory and the layout to the CPI", func() {
+				_, err := manager.Upload(expectedExtractedStemcell, fakeStage)
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(fakeCloud.CreateStemcellInputs).To(Equal([]fakebicloud.CreateStemcellInput{
+					{
+						ImagePath: filepath.Join(tempExtractionDir, "image-contents"),
+						CloudProperties: biproperty.Map{
+							"fake-prop-key": "fake-prop-value",
+							"image_layout":  "directory",
+						},
+					},
+				}))
+			})
+		})
+
 		It("saves the stemcell record in the stemcellRepo", func() {
 			cloudStemcell, err := manager.Upload(expectedExtractedStemcell, fakeStage)
 			Expect(err).ToNot(HaveOccurred())
@@ -216,6 +254,37 @@ var _ = Describe("Manager", func() {
 		})
 	})
 
+	Describe("UseExisting", func() {
+		It("saves a record for the existing stemcell without uploading", func() {
+			cloudStemcell, err := manager.UseExisting("fake-existing-cid", fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(cloudStemcell.CID()).To(Equal("fake-existing-cid"))
+			Expect(cloudStemcell.Name()).To(Equal(ExistingStemcellName))
+			Expect(fakeCloud.CreateStemcellInputs).To(BeEmpty())
+
+			stemcellRecord, found, err := stemcellRepo.Find(ExistingStemcellName, "fake-existing-cid")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(stemcellRecord.CID).To(Equal("fake-existing-cid"))
+
+			Expect(fakeStage.PerformCalls).To(Equal([]*fakebiui.PerformCall{
+				{Name: "Using existing stemcell 'fake-existing-cid'"},
+			}))
+		})
+
+		It("reuses an existing record", func() {
+			existingRecord, err := stemcellRepo.Save(ExistingStemcellName, "fake-existing-cid", "fake-existing-cid")
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = manager.UseExisting("fake-existing-cid", fakeStage)
+			Expect(err).ToNot(HaveOccurred())
+
+			stemcellRecords, err := stemcellRepo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stemcellRecords).To(Equal([]biconfig.StemcellRecord{existingRecord}))
+		})
+	})
+
 	Describe("FindUnused", func() {
 		var (
 			firstStemcell  CloudStemcell
@@ -238,6 +307,18 @@ var _ = Describe("Manager", func() {
 			secondStemcellRecord, err := stemcellRepo.Save("fake-stemcell-name-3", "fake-stemcell-version-3", "fake-stemcell-cid-3")
 			Expect(err).ToNot(HaveOccurred())
 			secondStemcell = NewCloudStemcell(secondStemcellRecord, stemcellRepo, fakeCloud)
+
+			fakeUUIDGenerator.GeneratedUUID = "fake-stemcell-id-4"
+			_, err = stemcellRepo.SaveImported("fake-stemcell-name-4", "fake-stemcell-version-4", "fake-stemcell-cid-4")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = deploymentStateService.Update(func(state *biconfig.DeploymentState) error {
+				state.Stemcells = append(state.Stemcells, biconfig.StemcellRecord{
+					ID: "fake-stemcell-id-5", Name: "fake-stemcell-name-5", Version: "fake-stemcell-version-5", CID: "fake-stemcell-cid-5", CPI: "fake-other-cpi",
+				})
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
 		})
 
 		It("returns unused stemcells", func() {
@@ -295,5 +376,26 @@ var _ = Describe("Manager", func() {
 				secondStemcellRecord,
 			}))
 		})
+
+		It("keeps unused stemcells that are still in use", func() {
+			thirdRecord, found, err := stemcellRepo.Find("fake-stemcell-name-3", "fake-stemcell-version-3")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(found).To(BeTrue())
+
+			inUse := []CloudStemcell{NewCloudStemcell(thirdRecord, stemcellRepo, fakeCloud)}
+			err = manager.DeleteUnusedExcept(fakeStage, inUse)
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(fakeCloud.DeleteStemcellInputs).To(Equal([]fakebicloud.DeleteStemcellInput{
+				{StemcellCID: "fake-stemcell-cid-1"},
+			}))
+
+			records, err := stemcellRepo.All()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(records).To(Equal([]biconfig.StemcellRecord{
+				secondStemcellRecord,
+				thirdRecord,
+			}))
+		})
 	})
 })
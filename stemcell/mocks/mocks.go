@@ -129,6 +129,18 @@ func (mr *MockManagerMockRecorder) DeleteUnused(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnused", reflect.TypeOf((*MockManager)(nil).DeleteUnused), arg0)
 }
 
+// DeleteUnusedExcept mocks base method
+func (m *MockManager) DeleteUnusedExcept(arg0 ui.Stage, arg1 []stemcell.CloudStemcell) error {
+	ret := m.ctrl.Call(m, "DeleteUnusedExcept", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteUnusedExcept indicates an expected call of DeleteUnusedExcept
+func (mr *MockManagerMockRecorder) DeleteUnusedExcept(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUnusedExcept", reflect.TypeOf((*MockManager)(nil).DeleteUnusedExcept), arg0, arg1)
+}
+
 // FindCurrent mocks base method
 func (m *MockManager) FindCurrent() ([]stemcell.CloudStemcell, error) {
 	ret := m.ctrl.Call(m, "FindCurrent")
@@ -167,3 +179,16 @@ func (m *MockManager) Upload(arg0 stemcell.ExtractedStemcell, arg1 ui.Stage) (st
 func (mr *MockManagerMockRecorder) Upload(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upload", reflect.TypeOf((*MockManager)(nil).Upload), arg0, arg1)
 }
+
+// UseExisting mocks base method
+func (m *MockManager) UseExisting(arg0 string, arg1 ui.Stage) (stemcell.CloudStemcell, error) {
+	ret := m.ctrl.Call(m, "UseExisting", arg0, arg1)
+	ret0, _ := ret[0].(stemcell.CloudStemcell)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UseExisting indicates an expected call of UseExisting
+func (mr *MockManagerMockRecorder) UseExisting(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseExisting", reflect.TypeOf((*MockManager)(nil).UseExisting), arg0, arg1)
+}
@@ -2,7 +2,9 @@ package stemcell
 
 import (
 	"fmt"
+	"strings"
 
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshfu "github.com/cloudfoundry/bosh-utils/fileutil"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
 	boshsys "github.com/cloudfoundry/bosh-utils/system"
@@ -20,7 +22,13 @@ type ExtractedStemcell interface {
 	SetVersion(string)
 	SetFormat([]string)
 	SetCloudProperties(biproperty.Map)
+
+	// MergeCloudProperties deep merges cloud properties over the ones of the
+	// stemcell, replacing values other than hashes
+	MergeCloudProperties(biproperty.Map)
+
 	GetExtractedPath() string
+	Image() (Image, error)
 	Pack(string) error
 	EmptyImage() error
 	fmt.Stringer
@@ -43,6 +51,21 @@ type Manifest struct {
 	CloudProperties biproperty.Map `yaml:"cloud_properties"`
 }
 
+// ImageLayoutDirectory is passed to create_stemcell as image_layout with the
+// directory holding the unpacked image, e.g. OVF files, for stemcells whose
+// only formats are OVF ones; CPIs of such stemcells cannot unpack the tarball
+// themselves. Stemcells that also come in other formats, e.g. vsphere-ova,
+// pass the image tarball.
+const ImageLayoutDirectory = "directory"
+
+const ovfFormatSuffix = "-ovf"
+
+// Image is what create_stemcell receives for a stemcell
+type Image struct {
+	Path            string
+	CloudProperties biproperty.Map
+}
+
 func NewExtractedStemcell(
 	manifest Manifest,
 	extractedPath string,
@@ -84,11 +107,40 @@ func (s *extractedStemcell) SetFormat(newFormats []string) {
 }
 
 func (s *extractedStemcell) SetCloudProperties(newCloudProperties biproperty.Map) {
+	if s.manifest.CloudProperties == nil {
+		s.manifest.CloudProperties = biproperty.Map{}
+	}
+
 	for key, value := range newCloudProperties {
 		s.manifest.CloudProperties[key] = value
 	}
 }
 
+func (s *extractedStemcell) MergeCloudProperties(cloudProperties biproperty.Map) {
+	s.manifest.CloudProperties = mergeCloudProperties(s.manifest.CloudProperties, cloudProperties)
+}
+
+func mergeCloudProperties(base biproperty.Map, overrides biproperty.Map) biproperty.Map {
+	merged := biproperty.Map{}
+
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range overrides {
+		overrideHash, overrideIsHash := value.(biproperty.Map)
+		baseHash, baseIsHash := merged[key].(biproperty.Map)
+
+		if overrideIsHash && baseIsHash {
+			merged[key] = mergeCloudProperties(baseHash, overrideHash)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
 func (s *extractedStemcell) Pack(destinationPath string) error {
 	defer s.Cleanup()
 
@@ -118,6 +170,78 @@ func (s *extractedStemcell) GetExtractedPath() string {
 	return s.extractedPath
 }
 
+// Image unpacks the image tarball when the stemcell only comes as OVF files.
+// The unpacked image lives in the extracted path and is removed by Cleanup.
+func (s *extractedStemcell) Image() (Image, error) {
+	imagePath := filepath.Join(s.extractedPath, "image")
+
+	cloudProperties := biproperty.Map{}
+	for key, value := range s.manifest.CloudProperties {
+		cloudProperties[key] = value
+	}
+
+	if !s.onlyOVF() {
+		return Image{Path: imagePath, CloudProperties: cloudProperties}, nil
+	}
+
+	imageDir := filepath.Join(s.extractedPath, "image-contents")
+
+	if !s.fs.FileExists(imageDir) {
+		err := s.unpackImage(imagePath, imageDir)
+		if err != nil {
+			return Image{}, err
+		}
+	}
+
+	cloudProperties["image_layout"] = ImageLayoutDirectory
+
+	return Image{Path: imageDir, CloudProperties: cloudProperties}, nil
+}
+
+func (s *extractedStemcell) onlyOVF() bool {
+	if len(s.manifest.StemcellFormats) == 0 {
+		return false
+	}
+
+	for _, format := range s.manifest.StemcellFormats {
+		if !strings.HasSuffix(format, ovfFormatSuffix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unpackImage unpacks into a sibling directory that is only moved into place
+// once unpacking succeeded, so that a partly unpacked image is never used
+func (s *extractedStemcell) unpackImage(imagePath string, imageDir string) error {
+	unpackDir := imageDir + ".partial"
+
+	err := s.fs.RemoveAll(unpackDir)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Removing partly extracted stemcell image '%s'", unpackDir)
+	}
+
+	err = s.fs.MkdirAll(unpackDir, 0755)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Creating stemcell image directory '%s'", unpackDir)
+	}
+
+	err = s.compressor.DecompressFileToDir(imagePath, unpackDir, boshfu.CompressorOptions{})
+	if err != nil {
+		_ = s.fs.RemoveAll(unpackDir)
+		return bosherr.WrapErrorf(err, "Extracting stemcell image '%s'", imagePath)
+	}
+
+	err = s.fs.Rename(unpackDir, imageDir)
+	if err != nil {
+		_ = s.fs.RemoveAll(unpackDir)
+		return bosherr.WrapErrorf(err, "Moving stemcell image into '%s'", imageDir)
+	}
+
+	return nil
+}
+
 func (s *extractedStemcell) save() error {
 	stemcellMfPath := filepath.Join(s.extractedPath, "stemcell.MF")
 	contents, _ := yaml.Marshal(s.manifest)
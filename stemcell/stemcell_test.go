@@ -8,6 +8,7 @@ import (
 
 	"errors"
 	"os"
+	"path/filepath"
 
 	boshcmdfakes "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
 	biproperty "github.com/cloudfoundry/bosh-utils/property"
@@ -215,6 +216,70 @@ var _ = Describe("Stemcell", func() {
 		})
 	})
 
+	Describe("MergeCloudProperties", func() {
+		BeforeEach(func() {
+			manifest = Manifest{
+				CloudProperties: biproperty.Map{
+					"disk_format": "qcow2",
+					"image": biproperty.Map{
+						"family":     "fake-family",
+						"controller": "ide",
+					},
+				},
+			}
+
+			stemcell = NewExtractedStemcell(
+				manifest,
+				extractedPath,
+				compressor,
+				fakefs,
+			)
+		})
+
+		It("deep merges the properties over the existing properties", func() {
+			stemcell.MergeCloudProperties(biproperty.Map{
+				"image": biproperty.Map{
+					"controller": "scsi",
+				},
+				"new_property": "fake-value",
+			})
+
+			Expect(stemcell.Manifest().CloudProperties).To(Equal(biproperty.Map{
+				"disk_format": "qcow2",
+				"image": biproperty.Map{
+					"family":     "fake-family",
+					"controller": "scsi",
+				},
+				"new_property": "fake-value",
+			}))
+		})
+
+		It("replaces values that are not hashes", func() {
+			stemcell.MergeCloudProperties(biproperty.Map{
+				"disk_format": biproperty.Map{"type": "raw"},
+				"image":       "fake-image",
+			})
+
+			Expect(stemcell.Manifest().CloudProperties).To(Equal(biproperty.Map{
+				"disk_format": biproperty.Map{"type": "raw"},
+				"image":       "fake-image",
+			}))
+		})
+
+		It("does not modify the properties it was created with", func() {
+			stemcell.MergeCloudProperties(biproperty.Map{
+				"image": biproperty.Map{
+					"controller": "scsi",
+				},
+			})
+
+			Expect(manifest.CloudProperties["image"]).To(Equal(biproperty.Map{
+				"family":     "fake-family",
+				"controller": "ide",
+			}))
+		})
+	})
+
 	Describe("EmptyImage", func() {
 		var (
 			imagePath string
@@ -259,6 +324,120 @@ var _ = Describe("Stemcell", func() {
 
 	})
 
+	Describe("Image", func() {
+		BeforeEach(func() {
+			manifest = Manifest{
+				Name:            "fake-stemcell-name",
+				CloudProperties: biproperty.Map{"fake-prop-key": "fake-prop-value"},
+			}
+
+			err := fakefs.MkdirAll("extracted-path", 0755)
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		JustBeforeEach(func() {
+			stemcell = NewExtractedStemcell(
+				manifest,
+				"extracted-path",
+				compressor,
+				fakefs,
+			)
+		})
+
+		It("returns the image tarball path and the manifest cloud properties", func() {
+			image, err := stemcell.Image()
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(image).To(Equal(Image{
+				Path:            filepath.Join("extracted-path", "image"),
+				CloudProperties: biproperty.Map{"fake-prop-key": "fake-prop-value"},
+			}))
+			Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+		})
+
+		Context("when the stemcell also comes in other formats than OVF", func() {
+			BeforeEach(func() {
+				manifest.StemcellFormats = []string{"vsphere-ovf", "vsphere-ova"}
+			})
+
+			It("returns the image tarball path", func() {
+				image, err := stemcell.Image()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(image.Path).To(Equal(filepath.Join("extracted-path", "image")))
+				Expect(compressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+			})
+		})
+
+		Context("when the stemcell only comes as OVF files", func() {
+			BeforeEach(func() {
+				manifest.StemcellFormats = []string{"vsphere-ovf"}
+			})
+
+			It("unpacks the image and returns its directory with the layout in the cloud properties", func() {
+				image, err := stemcell.Image()
+				Expect(err).ToNot(HaveOccurred())
+
+				imageDir := filepath.Join("extracted-path", "image-contents")
+				Expect(image).To(Equal(Image{
+					Path: imageDir,
+					CloudProperties: biproperty.Map{
+						"fake-prop-key": "fake-prop-value",
+						"image_layout":  "directory",
+					},
+				}))
+				Expect(compressor.DecompressFileToDirTarballPaths).To(Equal([]string{filepath.Join("extracted-path", "image")}))
+				Expect(compressor.DecompressFileToDirDirs).To(Equal([]string{imageDir + ".partial"}))
+				Expect(fakefs.FileExists(imageDir)).To(BeTrue())
+				Expect(fakefs.FileExists(imageDir + ".partial")).To(BeFalse())
+			})
+
+			It("does not modify the manifest cloud properties", func() {
+				_, err := stemcell.Image()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(stemcell.Manifest().CloudProperties).To(Equal(biproperty.Map{"fake-prop-key": "fake-prop-value"}))
+			})
+
+			It("unpacks the image only once", func() {
+				_, err := stemcell.Image()
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = stemcell.Image()
+				Expect(err).ToNot(HaveOccurred())
+
+				Expect(compressor.DecompressFileToDirTarballPaths).To(HaveLen(1))
+			})
+
+			It("returns an error when unpacking fails", func() {
+				compressor.DecompressFileToDirErr = errors.New("fake-decompress-error")
+
+				_, err := stemcell.Image()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Extracting stemcell image"))
+				Expect(err.Error()).To(ContainSubstring("fake-decompress-error"))
+			})
+
+			It("unpacks the image again after unpacking failed", func() {
+				compressor.DecompressFileToDirErr = errors.New("fake-decompress-error")
+
+				_, err := stemcell.Image()
+				Expect(err).To(HaveOccurred())
+
+				imageDir := filepath.Join("extracted-path", "image-contents")
+				Expect(fakefs.FileExists(imageDir)).To(BeFalse())
+				Expect(fakefs.FileExists(imageDir + ".partial")).To(BeFalse())
+
+				compressor.DecompressFileToDirErr = nil
+
+				image, err := stemcell.Image()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(image.Path).To(Equal(imageDir))
+				Expect(compressor.DecompressFileToDirTarballPaths).To(HaveLen(2))
+			})
+		})
+	})
+
 	Describe("SetFormat", func() {
 		var newStemcellFormat []string
 
@@ -56,6 +56,11 @@ type FakeExtractedStemcell struct {
 	setCloudPropertiesArgsForCall []struct {
 		arg1 biproperty.Map
 	}
+	MergeCloudPropertiesStub        func(biproperty.Map)
+	mergeCloudPropertiesMutex       sync.RWMutex
+	mergeCloudPropertiesArgsForCall []struct {
+		arg1 biproperty.Map
+	}
 	GetExtractedPathStub        func() string
 	getExtractedPathMutex       sync.RWMutex
 	getExtractedPathArgsForCall []struct{}
@@ -65,6 +70,17 @@ type FakeExtractedStemcell struct {
 	getExtractedPathReturnsOnCall map[int]struct {
 		result1 string
 	}
+	ImageStub        func() (stemcell.Image, error)
+	imageMutex       sync.RWMutex
+	imageArgsForCall []struct{}
+	imageReturns     struct {
+		result1 stemcell.Image
+		result2 error
+	}
+	imageReturnsOnCall map[int]struct {
+		result1 stemcell.Image
+		result2 error
+	}
 	PackStub        func(string) error
 	packMutex       sync.RWMutex
 	packArgsForCall []struct {
@@ -319,6 +335,30 @@ func (fake *FakeExtractedStemcell) SetCloudPropertiesArgsForCall(i int) biproper
 	return fake.setCloudPropertiesArgsForCall[i].arg1
 }
 
+func (fake *FakeExtractedStemcell) MergeCloudProperties(arg1 biproperty.Map) {
+	fake.mergeCloudPropertiesMutex.Lock()
+	fake.mergeCloudPropertiesArgsForCall = append(fake.mergeCloudPropertiesArgsForCall, struct {
+		arg1 biproperty.Map
+	}{arg1})
+	fake.recordInvocation("MergeCloudProperties", []interface{}{arg1})
+	fake.mergeCloudPropertiesMutex.Unlock()
+	if fake.MergeCloudPropertiesStub != nil {
+		fake.MergeCloudPropertiesStub(arg1)
+	}
+}
+
+func (fake *FakeExtractedStemcell) MergeCloudPropertiesCallCount() int {
+	fake.mergeCloudPropertiesMutex.RLock()
+	defer fake.mergeCloudPropertiesMutex.RUnlock()
+	return len(fake.mergeCloudPropertiesArgsForCall)
+}
+
+func (fake *FakeExtractedStemcell) MergeCloudPropertiesArgsForCall(i int) biproperty.Map {
+	fake.mergeCloudPropertiesMutex.RLock()
+	defer fake.mergeCloudPropertiesMutex.RUnlock()
+	return fake.mergeCloudPropertiesArgsForCall[i].arg1
+}
+
 func (fake *FakeExtractedStemcell) GetExtractedPath() string {
 	fake.getExtractedPathMutex.Lock()
 	ret, specificReturn := fake.getExtractedPathReturnsOnCall[len(fake.getExtractedPathArgsForCall)]
@@ -359,6 +399,49 @@ func (fake *FakeExtractedStemcell) GetExtractedPathReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *FakeExtractedStemcell) Image() (stemcell.Image, error) {
+	fake.imageMutex.Lock()
+	ret, specificReturn := fake.imageReturnsOnCall[len(fake.imageArgsForCall)]
+	fake.imageArgsForCall = append(fake.imageArgsForCall, struct{}{})
+	fake.recordInvocation("Image", []interface{}{})
+	fake.imageMutex.Unlock()
+	if fake.ImageStub != nil {
+		return fake.ImageStub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fake.imageReturns.result1, fake.imageReturns.result2
+}
+
+func (fake *FakeExtractedStemcell) ImageCallCount() int {
+	fake.imageMutex.RLock()
+	defer fake.imageMutex.RUnlock()
+	return len(fake.imageArgsForCall)
+}
+
+func (fake *FakeExtractedStemcell) ImageReturns(result1 stemcell.Image, result2 error) {
+	fake.ImageStub = nil
+	fake.imageReturns = struct {
+		result1 stemcell.Image
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeExtractedStemcell) ImageReturnsOnCall(i int, result1 stemcell.Image, result2 error) {
+	fake.ImageStub = nil
+	if fake.imageReturnsOnCall == nil {
+		fake.imageReturnsOnCall = make(map[int]struct {
+			result1 stemcell.Image
+			result2 error
+		})
+	}
+	fake.imageReturnsOnCall[i] = struct {
+		result1 stemcell.Image
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeExtractedStemcell) Pack(arg1 string) error {
 	fake.packMutex.Lock()
 	ret, specificReturn := fake.packReturnsOnCall[len(fake.packArgsForCall)]
@@ -504,8 +587,12 @@ func (fake *FakeExtractedStemcell) Invocations() map[string][][]interface{} {
 	defer fake.setFormatMutex.RUnlock()
 	fake.setCloudPropertiesMutex.RLock()
 	defer fake.setCloudPropertiesMutex.RUnlock()
+	fake.mergeCloudPropertiesMutex.RLock()
+	defer fake.mergeCloudPropertiesMutex.RUnlock()
 	fake.getExtractedPathMutex.RLock()
 	defer fake.getExtractedPathMutex.RUnlock()
+	fake.imageMutex.RLock()
+	defer fake.imageMutex.RUnlock()
 	fake.packMutex.RLock()
 	defer fake.packMutex.RUnlock()
 	fake.emptyImageMutex.RLock()
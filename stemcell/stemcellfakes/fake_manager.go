@@ -12,10 +12,14 @@ type FakeManager struct {
 	UploadInputs   []UploadInput
 	uploadBehavior map[UploadInput]uploadOutput
 
+	UseExistingInputs []UseExistingInput
+	useExistingOutput uploadOutput
+
 	findUnusedOutput findUnusedOutput
 
 	DeleteUnusedCalledTimes int
 	DeleteUnusedErr         error
+	DeleteUnusedInUse       []bistemcell.CloudStemcell
 }
 
 type UploadInput struct {
@@ -23,6 +27,11 @@ type UploadInput struct {
 	Stage    biui.Stage
 }
 
+type UseExistingInput struct {
+	CID   string
+	Stage biui.Stage
+}
+
 type uploadOutput struct {
 	stemcell bistemcell.CloudStemcell
 	err      error
@@ -58,6 +67,11 @@ func (m *FakeManager) Upload(stemcell bistemcell.ExtractedStemcell, stage biui.S
 	return output.stemcell, output.err
 }
 
+func (m *FakeManager) UseExisting(cid string, stage biui.Stage) (bistemcell.CloudStemcell, error) {
+	m.UseExistingInputs = append(m.UseExistingInputs, UseExistingInput{CID: cid, Stage: stage})
+	return m.useExistingOutput.stemcell, m.useExistingOutput.err
+}
+
 func (m *FakeManager) FindUnused() ([]bistemcell.CloudStemcell, error) {
 	return m.findUnusedOutput.stemcells, m.findUnusedOutput.err
 }
@@ -67,6 +81,12 @@ func (m *FakeManager) DeleteUnused(eventLoggerStage biui.Stage) error {
 	return m.DeleteUnusedErr
 }
 
+func (m *FakeManager) DeleteUnusedExcept(eventLoggerStage biui.Stage, inUse []bistemcell.CloudStemcell) error {
+	m.DeleteUnusedCalledTimes++
+	m.DeleteUnusedInUse = inUse
+	return m.DeleteUnusedErr
+}
+
 func (m *FakeManager) SetUploadBehavior(
 	extractedStemcell bistemcell.ExtractedStemcell,
 	stage biui.Stage,
@@ -80,6 +100,10 @@ func (m *FakeManager) SetUploadBehavior(
 	m.uploadBehavior[input] = uploadOutput{stemcell: cloudStemcell, err: err}
 }
 
+func (m *FakeManager) SetUseExistingBehavior(cloudStemcell bistemcell.CloudStemcell, err error) {
+	m.useExistingOutput = uploadOutput{stemcell: cloudStemcell, err: err}
+}
+
 func (m *FakeManager) SetFindUnusedBehavior(
 	stemcells []bistemcell.CloudStemcell,
 	err error,
@@ -0,0 +1,128 @@
+package stemcell
+
+import (
+	"os"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshcmd "github.com/cloudfoundry/bosh-utils/fileutil"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+
+	biarchive "github.com/cloudfoundry/bosh-cli/common/archive"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+)
+
+// VerifyingExtractor extracts a stemcell tarball and verifies it against a
+// digest in the same read of the tarball
+type VerifyingExtractor interface {
+	Extractor
+
+	ExtractWithDigest(tarballPath string, digest string) (ExtractedStemcell, error)
+}
+
+type streamingExtractor struct {
+	compressor     boshcmd.Compressor
+	digestVerifier bicrypto.DigestVerifier
+	fs             boshsys.FileSystem
+	logger         boshlog.Logger
+	logTag         string
+}
+
+// NewStreamingExtractor computes the digest of a stemcell tarball while the
+// compressor extracts it, instead of reading the multi-GB tarball once more
+// beforehand. Compressors that cannot extract from a stream, i.e. that are
+// not an archive.ReaderDecompressor, verify the tarball before extracting it.
+func NewStreamingExtractor(
+	compressor boshcmd.Compressor,
+	digestVerifier bicrypto.DigestVerifier,
+	fs boshsys.FileSystem,
+	logger boshlog.Logger,
+) VerifyingExtractor {
+	return &streamingExtractor{
+		compressor:     compressor,
+		digestVerifier: digestVerifier,
+		fs:             fs,
+		logger:         logger,
+		logTag:         "streamingStemcellExtractor",
+	}
+}
+
+func (e *streamingExtractor) Extract(tarballPath string) (ExtractedStemcell, error) {
+	return e.ExtractWithDigest(tarballPath, "")
+}
+
+// ExtractWithDigest is Extract that also verifies the tarball against digest,
+// an empty digest skips the verification. Nothing is left extracted when the
+// digest does not match.
+func (e *streamingExtractor) ExtractWithDigest(tarballPath string, digest string) (ExtractedStemcell, error) {
+	tmpDir, err := e.fs.TempDir("stemcell-manager")
+	if err != nil {
+		return nil, bosherr.WrapError(err, "creating temp dir for stemcell extraction")
+	}
+
+	err = e.extract(tarballPath, digest, tmpDir)
+	if err != nil {
+		_ = e.fs.RemoveAll(tmpDir)
+		return nil, err
+	}
+
+	stemcell, err := readExtractedStemcell(tmpDir, e.compressor, e.fs)
+	if err != nil {
+		_ = e.fs.RemoveAll(tmpDir)
+		return nil, bosherr.WrapErrorf(err, "reading extracted stemcell manifest in '%s'", tmpDir)
+	}
+
+	return stemcell, nil
+}
+
+func (e *streamingExtractor) extract(tarballPath string, digest string, dir string) error {
+	readerDecompressor, streaming := e.compressor.(biarchive.ReaderDecompressor)
+
+	if digest == "" || !streaming {
+		if digest != "" {
+			e.logger.Debug(e.logTag, "Verifying stemcell '%s' before extracting it", tarballPath)
+
+			err := e.digestVerifier.Verify(tarballPath, digest)
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Verifying stemcell '%s'", tarballPath)
+			}
+		}
+
+		err := e.compressor.DecompressFileToDir(tarballPath, dir, boshcmd.CompressorOptions{})
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Extracting stemcell from '%s' to '%s'", tarballPath, dir)
+		}
+
+		return nil
+	}
+
+	file, err := e.fs.OpenFile(tarballPath, os.O_RDONLY, 0)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Opening stemcell tarball '%s'", tarballPath)
+	}
+
+	defer func() {
+		_ = file.Close()
+	}()
+
+	verifyingReader, err := e.digestVerifier.NewVerifyingReader(file, digest)
+	if err != nil {
+		return bosherr.WrapError(err, "Verifying stemcell")
+	}
+
+	defer func() {
+		_ = verifyingReader.Close()
+	}()
+
+	err = readerDecompressor.DecompressReaderToDir(verifyingReader, dir, boshcmd.CompressorOptions{})
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Extracting stemcell from '%s' to '%s'", tarballPath, dir)
+	}
+
+	err = verifyingReader.Verify()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Verifying stemcell '%s'", tarballPath)
+	}
+
+	return nil
+}
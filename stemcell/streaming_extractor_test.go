@@ -0,0 +1,186 @@
+package stemcell_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	fakecmd "github.com/cloudfoundry/bosh-utils/fileutil/fakes"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biarchive "github.com/cloudfoundry/bosh-cli/common/archive"
+	bicrypto "github.com/cloudfoundry/bosh-cli/crypto"
+	. "github.com/cloudfoundry/bosh-cli/stemcell"
+)
+
+var _ = Describe("StreamingExtractor", func() {
+	type tarballEntry struct {
+		name     string
+		contents string
+		dir      bool
+	}
+
+	var (
+		fs             boshsys.FileSystem
+		logger         boshlog.Logger
+		fakeCompressor *fakecmd.FakeCompressor
+		digestVerifier bicrypto.DigestVerifier
+		tmpDir         string
+		tarballPath    string
+		extractor      VerifyingExtractor
+
+		manifestEntry = tarballEntry{name: "stemcell.MF", contents: "name: fake-stemcell-name\nversion: '1'\n"}
+	)
+
+	// writeTarball returns the SHA-1 of the written tarball
+	writeTarball := func(gzipped bool, entries ...tarballEntry) string {
+		buffer := &bytes.Buffer{}
+		tarWriter := tar.NewWriter(buffer)
+
+		for _, entry := range entries {
+			header := &tar.Header{Name: entry.name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(entry.contents))}
+			if entry.dir {
+				header = &tar.Header{Name: entry.name, Typeflag: tar.TypeDir, Mode: 0755}
+			}
+
+			Expect(tarWriter.WriteHeader(header)).To(Succeed())
+
+			_, err := tarWriter.Write([]byte(entry.contents))
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(tarWriter.Close()).To(Succeed())
+
+		contents := buffer.Bytes()
+
+		if gzipped {
+			gzippedBuffer := &bytes.Buffer{}
+			gzipWriter := gzip.NewWriter(gzippedBuffer)
+			_, err := gzipWriter.Write(contents)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(gzipWriter.Close()).To(Succeed())
+
+			contents = gzippedBuffer.Bytes()
+		}
+
+		Expect(ioutil.WriteFile(tarballPath, contents, 0644)).To(Succeed())
+
+		sum := sha1.Sum(contents)
+		return hex.EncodeToString(sum[:])
+	}
+
+	BeforeEach(func() {
+		var err error
+		tmpDir, err = ioutil.TempDir("", "streaming-extractor")
+		Expect(err).ToNot(HaveOccurred())
+
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		fs = boshsys.NewOsFileSystem(logger)
+		Expect(fs.ChangeTempRoot(filepath.Join(tmpDir, "temp"))).To(Succeed())
+
+		tarballPath = filepath.Join(tmpDir, "stemcell.tgz")
+
+		fakeCompressor = fakecmd.NewFakeCompressor()
+		digestVerifier = bicrypto.NewDigestVerifier(fs, bicrypto.NewChecksumProvider(false))
+
+		extractor = NewStreamingExtractor(biarchive.NewParallelCompressor(fakeCompressor, 4, logger), digestVerifier, fs, logger)
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(tmpDir)).To(Succeed())
+	})
+
+	It("extracts gzipped tarballs and verifies them in the same read", func() {
+		digest := writeTarball(true,
+			tarballEntry{name: "./", dir: true},
+			manifestEntry,
+			tarballEntry{name: "./image", contents: "fake-image"},
+			tarballEntry{name: "dir/nested", contents: "fake-nested"},
+		)
+
+		stemcell, err := extractor.ExtractWithDigest(tarballPath, digest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcell.Manifest().Name).To(Equal("fake-stemcell-name"))
+
+		contents, err := fs.ReadFileString(filepath.Join(stemcell.GetExtractedPath(), "image"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal("fake-image"))
+
+		contents, err = fs.ReadFileString(filepath.Join(stemcell.GetExtractedPath(), "dir", "nested"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(contents).To(Equal("fake-nested"))
+	})
+
+	It("extracts plain tarballs with a digest in the same read", func() {
+		digest := writeTarball(false, manifestEntry, tarballEntry{name: "image", contents: "fake-image"})
+
+		stemcell, err := extractor.ExtractWithDigest(tarballPath, digest)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(fs.FileExists(filepath.Join(stemcell.GetExtractedPath(), "image"))).To(BeTrue())
+		Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+	})
+
+	It("leaves tarballs without a digest to the compressor", func() {
+		writeTarball(true, manifestEntry)
+
+		fakeCompressor.DecompressFileToDirCallBack = func() {
+			dir := fakeCompressor.DecompressFileToDirDirs[0]
+			Expect(fs.WriteFileString(filepath.Join(dir, "stemcell.MF"), manifestEntry.contents)).To(Succeed())
+		}
+
+		extractor = NewStreamingExtractor(fakeCompressor, digestVerifier, fs, logger)
+
+		stemcell, err := extractor.Extract(tarballPath)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(stemcell.Manifest().Name).To(Equal("fake-stemcell-name"))
+		Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(Equal([]string{tarballPath}))
+	})
+
+	It("verifies tarballs before extracting them when the compressor cannot extract from a stream", func() {
+		writeTarball(true, manifestEntry)
+
+		extractor = NewStreamingExtractor(fakeCompressor, digestVerifier, fs, logger)
+
+		_, err := extractor.ExtractWithDigest(tarballPath, "sha256:7fc7c4986b7c2167816f3f1459755c3e9488014455ef06a77b96cf27e40f09e7")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Verifying stemcell"))
+		Expect(fakeCompressor.DecompressFileToDirTarballPaths).To(BeEmpty())
+	})
+
+	It("removes what was extracted when the digest does not match", func() {
+		writeTarball(true, manifestEntry, tarballEntry{name: "image", contents: "fake-image"})
+
+		_, err := extractor.ExtractWithDigest(tarballPath, "sha256:7fc7c4986b7c2167816f3f1459755c3e9488014455ef06a77b96cf27e40f09e7")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Verifying stemcell"))
+
+		extracted, err := ioutil.ReadDir(filepath.Join(tmpDir, "temp"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(extracted).To(BeEmpty())
+	})
+
+	It("rejects entries outside of the extraction directory", func() {
+		digest := writeTarball(true, tarballEntry{name: "../outside", contents: "fake-outside"})
+
+		_, err := extractor.ExtractWithDigest(tarballPath, digest)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Tarball entry '../outside' is outside of the extraction directory"))
+		Expect(filepath.Join(tmpDir, "temp", "outside")).ToNot(BeAnExistingFile())
+	})
+
+	It("returns an error when the tarball has no stemcell manifest", func() {
+		digest := writeTarball(true, tarballEntry{name: "image", contents: "fake-image"})
+
+		_, err := extractor.ExtractWithDigest(tarballPath, digest)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Reading stemcell manifest"))
+	})
+})
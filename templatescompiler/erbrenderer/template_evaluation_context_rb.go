@@ -43,11 +43,12 @@ class TemplateEvaluationContext
 
     @properties = openstruct(properties)
     @raw_properties = properties
+    @links = spec['links'] || {}
     @spec = openstruct(spec)
   end
 
   def get_binding
-    binding.taint
+    binding
   end
 
   def p(*args)
@@ -73,12 +74,40 @@ class TemplateEvaluationContext
     InactiveElseBlock.new
   end
 
+  def link(name)
+    link_spec = @links[name]
+    raise UnknownLink.new(name) if link_spec.nil? || !link_spec.has_key?('instances')
+
+    create_evaluation_link(link_spec)
+  end
+
   def if_link(name)
-    false
+    link_spec = @links[name]
+    if link_spec.nil? || !link_spec.has_key?('instances')
+      return ActiveElseBlock.new(self)
+    end
+
+    yield create_evaluation_link(link_spec)
+    InactiveElseBlock.new
   end
 
   private
 
+  def create_evaluation_link(link_spec)
+    instances = link_spec['instances'].map do |instance|
+      EvaluationLinkInstance.new(
+        instance['name'],
+        instance['index'],
+        instance['id'],
+        instance['az'],
+        instance['address'],
+        instance['bootstrap']
+      )
+    end
+
+    EvaluationLink.new(instances, link_spec['properties'] || {}, link_spec['address'])
+  end
+
   def copy_property(dst, src, name, default = nil)
     keys = name.split(".")
     src_ref = src
@@ -131,6 +160,75 @@ class TemplateEvaluationContext
     end
   end
 
+  class UnknownLink < StandardError
+    attr_reader :name
+
+    def initialize(name)
+      @name = name
+      super("Can't find link '#{name}'")
+    end
+  end
+
+  class EvaluationLinkInstance
+    attr_reader :name, :index, :id, :az, :address, :bootstrap
+
+    def initialize(name, index, id, az, address, bootstrap)
+      @name = name
+      @index = index
+      @id = id
+      @az = az
+      @address = address
+      @bootstrap = bootstrap
+    end
+  end
+
+  class EvaluationLink
+    attr_reader :instances, :properties, :address
+
+    def initialize(instances, properties, address)
+      @instances = instances
+      @properties = properties
+      @address = address
+    end
+
+    def p(*args)
+      names = Array(args[0])
+
+      names.each do |name|
+        result = lookup_property(@properties, name)
+        return result unless result.nil?
+      end
+
+      return args[1] if args.length == 2
+      raise UnknownProperty.new(names)
+    end
+
+    def if_p(*names)
+      values = names.map do |name|
+        value = lookup_property(@properties, name)
+        return ActiveElseBlock.new(self) if value.nil?
+        value
+      end
+
+      yield *values
+      InactiveElseBlock.new
+    end
+
+    private
+
+    def lookup_property(collection, name)
+      keys = name.split(".")
+      ref = collection
+
+      keys.each do |key|
+        ref = ref[key]
+        return nil if ref.nil?
+      end
+
+      ref
+    end
+  end
+
   class ActiveElseBlock
     def initialize(template)
       @context = template
@@ -174,7 +272,7 @@ class ERBRenderer
   end
 
   def render(src_path, dst_path)
-    erb = ERB.new(File.read(src_path), safe_level = nil, trim_mode = "-")
+    erb = ERB.new(File.read(src_path), trim_mode: "-")
     erb.filename = src_path
 
     File.open(dst_path, "w") do |f|
@@ -14,6 +14,7 @@ import (
 type jobEvaluationContext struct {
 	releaseJob           bireljob.Job
 	releaseJobProperties *biproperty.Map
+	releaseJobLinks      map[string]biproperty.Map
 	jobProperties        biproperty.Map
 	globalProperties     biproperty.Map
 	deploymentName       string
@@ -42,6 +43,9 @@ type RootContext struct {
 	ClusterProperties biproperty.Map  `json:"cluster_properties"` // values from instance group (deployment job) properties
 	JobProperties     *biproperty.Map `json:"job_properties"`     // values from release job (aka template) properties
 	DefaultProperties biproperty.Map  `json:"default_properties"` // values from release's job's spec
+
+	// Only manually specified link values are available, usually accessed with <%= link("db").address %>
+	Links map[string]biproperty.Map `json:"links"`
 }
 
 type jobContext struct {
@@ -57,6 +61,7 @@ type networkContext struct {
 func NewJobEvaluationContext(
 	releaseJob bireljob.Job,
 	releaseJobProperties *biproperty.Map,
+	releaseJobLinks map[string]biproperty.Map,
 	jobProperties biproperty.Map,
 	globalProperties biproperty.Map,
 	deploymentName string,
@@ -67,6 +72,7 @@ func NewJobEvaluationContext(
 	return jobEvaluationContext{
 		releaseJob:           releaseJob,
 		releaseJobProperties: releaseJobProperties,
+		releaseJobLinks:      releaseJobLinks,
 		jobProperties:        jobProperties,
 		globalProperties:     globalProperties,
 		deploymentName:       deploymentName,
@@ -92,6 +98,7 @@ func (ec jobEvaluationContext) MarshalJSON() ([]byte, error) {
 		ClusterProperties: ec.jobProperties,
 		JobProperties:     ec.releaseJobProperties,
 		DefaultProperties: defaultProperties,
+		Links:             ec.buildLinks(),
 	}
 
 	if len(ec.address) > 0 {
@@ -121,6 +128,35 @@ func (ec jobEvaluationContext) propertyDefaults(properties map[string]bireljob.P
 	return result
 }
 
+// buildLinks fills in what the director would otherwise provide: a single
+// instance at the link address and empty link properties
+func (ec jobEvaluationContext) buildLinks() map[string]biproperty.Map {
+	links := map[string]biproperty.Map{}
+
+	for linkName, manualLink := range ec.releaseJobLinks {
+		link := biproperty.Map{}
+		for key, value := range manualLink {
+			link[key] = value
+		}
+
+		if _, found := link["instances"]; !found {
+			instances := []interface{}{}
+			if address, found := link["address"]; found {
+				instances = append(instances, biproperty.Map{"address": address})
+			}
+			link["instances"] = instances
+		}
+
+		if _, found := link["properties"]; !found {
+			link["properties"] = biproperty.Map{}
+		}
+
+		links[linkName] = link
+	}
+
+	return links
+}
+
 func (ec jobEvaluationContext) buildNetworkContexts() map[string]networkContext {
 	// IP is being returned by agent
 	return map[string]networkContext{
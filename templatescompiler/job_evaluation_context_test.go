@@ -29,6 +29,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		deploymentProperties    biproperty.Map
 		erbRenderer             erbrenderer.ERBRenderer
 		jobEvaluationContext    bierbrenderer.TemplateEvaluationContext
+		releaseJobLinks         map[string]biproperty.Map
 		uuidGen                 *fakeuuid.FakeGenerator
 	)
 
@@ -49,6 +50,7 @@ var _ = Describe("JobEvaluationContext", func() {
 
 		uuidGen = fakeuuid.NewFakeGenerator()
 		jobProperties = nil
+		releaseJobLinks = nil
 	})
 
 	JustBeforeEach(func() {
@@ -57,6 +59,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		jobEvaluationContext = NewJobEvaluationContext(
 			*releaseJob,
 			jobProperties,
+			releaseJobLinks,
 			instanceGroupProperties,
 			deploymentProperties,
 			"fake-deployment-name",
@@ -103,6 +106,40 @@ var _ = Describe("JobEvaluationContext", func() {
 		generatedContext := act()
 		Expect(generatedContext.Bootstrap).To(Equal(true))
 	})
+	It("it has no links by default", func() {
+		generatedContext := act()
+		Expect(generatedContext.Links).To(BeEmpty())
+	})
+
+	Context("when manual link values are given", func() {
+		BeforeEach(func() {
+			releaseJobLinks = map[string]biproperty.Map{
+				"db": biproperty.Map{
+					"address":    "db.example.com",
+					"properties": biproperty.Map{"port": 5432},
+				},
+				"api": biproperty.Map{
+					"instances": []interface{}{map[string]interface{}{"address": "10.0.0.1"}},
+				},
+			}
+		})
+
+		It("it has the links available in the spec with an instance at the link address", func() {
+			generatedContext := act()
+			Expect(generatedContext.Links).To(Equal(map[string]biproperty.Map{
+				"db": biproperty.Map{
+					"address":    "db.example.com",
+					"instances":  []interface{}{map[string]interface{}{"address": "db.example.com"}},
+					"properties": map[string]interface{}{"port": float64(5432)},
+				},
+				"api": biproperty.Map{
+					"instances":  []interface{}{map[string]interface{}{"address": "10.0.0.1"}},
+					"properties": map[string]interface{}{},
+				},
+			}))
+		})
+	})
+
 	Context("when the UUID generator raise an error", func() {
 		It("it raises an error", func() {
 			uuidGen.GenerateError = errors.Error("boom")
@@ -112,7 +149,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		})
 	})
 
-	getValueFor := func(key string) string {
+	render := func(erbContents string) string {
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 		fs := boshsys.NewOsFileSystem(logger)
 		commandRunner := boshsys.NewExecCmdRunner(logger)
@@ -122,7 +159,6 @@ var _ = Describe("JobEvaluationContext", func() {
 		Expect(err).ToNot(HaveOccurred())
 		defer os.Remove(srcFile.Name())
 
-		erbContents := fmt.Sprintf("<%%= p('%s') %%>", key)
 		_, err = srcFile.WriteString(erbContents)
 		Expect(err).ToNot(HaveOccurred())
 
@@ -135,6 +171,7 @@ var _ = Describe("JobEvaluationContext", func() {
 		jobEvaluationContext := NewJobEvaluationContext(
 			*releaseJob,
 			jobProperties,
+			releaseJobLinks,
 			instanceGroupProperties,
 			deploymentProperties,
 			"fake-deployment-name",
@@ -150,6 +187,30 @@ var _ = Describe("JobEvaluationContext", func() {
 		return (string)(contents)
 	}
 
+	getValueFor := func(key string) string {
+		return render(fmt.Sprintf("<%%= p('%s') %%>", key))
+	}
+
+	Context("when a job consumes a manually specified link", func() {
+		BeforeEach(func() {
+			releaseJobLinks = map[string]biproperty.Map{
+				"db": biproperty.Map{
+					"address":    "db.example.com",
+					"properties": biproperty.Map{"port": 5432},
+				},
+			}
+		})
+
+		It("makes the link available to templates", func() {
+			Expect(render("<%= link('db').address %>:<%= link('db').p('port') %>:<%= link('db').instances.map(&:address).join(',') %>")).To(Equal("db.example.com:5432:db.example.com"))
+		})
+
+		It("only yields links that are present", func() {
+			Expect(render("<% if_link('db') do |db| %><%= db.address %><% end.else do %>none<% end %>")).To(Equal("db.example.com"))
+			Expect(render("<% if_link('cache') do |cache| %><%= cache.address %><% end.else do %>none<% end %>")).To(Equal("none"))
+		})
+	})
+
 	Context("when a deployment and instance group set a property", func() {
 		BeforeEach(func() {
 			deploymentProperties = biproperty.Map{
@@ -11,6 +11,7 @@ type JobListRenderer interface {
 	Render(
 		releaseJobs []bireljob.Job,
 		releaseJobProperties map[string]*biproperty.Map,
+		releaseJobLinks map[string]map[string]biproperty.Map,
 		jobProperties biproperty.Map,
 		globalProperties biproperty.Map,
 		deploymentName string,
@@ -38,6 +39,7 @@ func NewJobListRenderer(
 func (r *jobListRenderer) Render(
 	releaseJobs []bireljob.Job,
 	releaseJobProperties map[string]*biproperty.Map,
+	releaseJobLinks map[string]map[string]biproperty.Map,
 	jobProperties biproperty.Map,
 	globalProperties biproperty.Map,
 	deploymentName string,
@@ -48,7 +50,7 @@ func (r *jobListRenderer) Render(
 
 	// render all the jobs' templates
 	for _, releaseJob := range releaseJobs {
-		renderedJob, err := r.jobRenderer.Render(releaseJob, releaseJobProperties[releaseJob.Name()], jobProperties, globalProperties, deploymentName, address)
+		renderedJob, err := r.jobRenderer.Render(releaseJob, releaseJobProperties[releaseJob.Name()], releaseJobLinks[releaseJob.Name()], jobProperties, globalProperties, deploymentName, address)
 		if err != nil {
 			defer renderedJobList.DeleteSilently()
 			return renderedJobList, bosherr.WrapErrorf(err, "Rendering templates for job '%s/%s'", releaseJob.Name(), releaseJob.Fingerprint())
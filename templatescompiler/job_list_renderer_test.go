@@ -32,6 +32,7 @@ var _ = Describe("JobListRenderer", func() {
 
 		releaseJobs          []boshreljob.Job
 		releaseJobProperties map[string]*biproperty.Map
+		releaseJobLinks      map[string]map[string]biproperty.Map
 		jobProperties        biproperty.Map
 		globalProperties     biproperty.Map
 		deploymentName       string
@@ -61,6 +62,12 @@ var _ = Describe("JobListRenderer", func() {
 			"fake-release-job-name-1": &biproperty.Map{},
 		}
 
+		releaseJobLinks = map[string]map[string]biproperty.Map{
+			"fake-release-job-name-0": {
+				"fake-link": biproperty.Map{"address": "fake-link-address"},
+			},
+		}
+
 		jobProperties = biproperty.Map{
 			"fake-key": "fake-job-value",
 		}
@@ -81,13 +88,13 @@ var _ = Describe("JobListRenderer", func() {
 	})
 
 	JustBeforeEach(func() {
-		mockJobRenderer.EXPECT().Render(releaseJobs[0], releaseJobProperties[releaseJobs[0].Name()], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[0], nil)
-		expectRender1 = mockJobRenderer.EXPECT().Render(releaseJobs[1], releaseJobProperties[releaseJobs[1].Name()], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[1], nil)
+		mockJobRenderer.EXPECT().Render(releaseJobs[0], releaseJobProperties[releaseJobs[0].Name()], releaseJobLinks[releaseJobs[0].Name()], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[0], nil)
+		expectRender1 = mockJobRenderer.EXPECT().Render(releaseJobs[1], releaseJobProperties[releaseJobs[1].Name()], releaseJobLinks[releaseJobs[1].Name()], jobProperties, globalProperties, deploymentName, address).Return(renderedJobs[1], nil)
 	})
 
 	Describe("Render", func() {
 		It("returns a new RenderedJobList with all the RenderedJobs", func() {
-			renderedJobList, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, deploymentName, address)
+			renderedJobList, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, releaseJobLinks, jobProperties, globalProperties, deploymentName, address)
 			Expect(err).ToNot(HaveOccurred())
 			Expect(renderedJobList.All()).To(Equal([]RenderedJob{
 				renderedJobs[0],
@@ -103,7 +110,7 @@ var _ = Describe("JobListRenderer", func() {
 			It("returns an error and cleans up any sucessfully rendered jobs", func() {
 				renderedJobs[0].EXPECT().DeleteSilently()
 
-				_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, jobProperties, globalProperties, deploymentName, address)
+				_, err := jobListRenderer.Render(releaseJobs, releaseJobProperties, releaseJobLinks, jobProperties, globalProperties, deploymentName, address)
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-render-error"))
 			})
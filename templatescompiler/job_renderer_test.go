@@ -56,7 +56,7 @@ var _ = Describe("JobRenderer", func() {
 
 		logger := boshlog.NewLogger(boshlog.LevelNone)
 
-		context = NewJobEvaluationContext(*job, &releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4", nil, logger)
+		context = NewJobEvaluationContext(*job, &releaseJobProperties, nil, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4", nil, logger)
 
 		fakeERBRenderer = fakebirender.NewFakeERBRender()
 
@@ -87,7 +87,7 @@ var _ = Describe("JobRenderer", func() {
 
 	Describe("Render", func() {
 		It("renders job templates", func() {
-			renderedjob, err := jobRenderer.Render(*job, &releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
+			renderedjob, err := jobRenderer.Render(*job, &releaseJobProperties, nil, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
 			Expect(err).ToNot(HaveOccurred())
 
 			Expect(fakeERBRenderer.RenderInputs).To(Equal([]fakebirender.RenderInput{
@@ -115,10 +115,21 @@ var _ = Describe("JobRenderer", func() {
 			})
 
 			It("returns an error", func() {
-				_, err := jobRenderer.Render(*job, &releaseJobProperties, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
+				_, err := jobRenderer.Render(*job, &releaseJobProperties, nil, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
 				Expect(err).To(HaveOccurred())
 				Expect(err.Error()).To(ContainSubstring("fake-template-render-error"))
 			})
 		})
+
+		Context("when manual link values are given for a link the job does not consume", func() {
+			It("returns an error", func() {
+				links := map[string]biproperty.Map{"fake-link": biproperty.Map{"address": "fake-address"}}
+
+				_, err := jobRenderer.Render(*job, &releaseJobProperties, links, jobProperties, globalProperties, "fake-deployment-name", "1.2.3.4")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Job 'cpi' does not consume link 'fake-link'"))
+				Expect(fakeERBRenderer.RenderInputs).To(BeEmpty())
+			})
+		})
 	})
 })
@@ -0,0 +1,143 @@
+package tracing
+
+import (
+	biagentclient "github.com/cloudfoundry/bosh-agent/agentclient"
+	bias "github.com/cloudfoundry/bosh-agent/agentclient/applyspec"
+	bihttpagent "github.com/cloudfoundry/bosh-agent/agentclient/http"
+)
+
+type agentClientFactory struct {
+	factory bihttpagent.AgentClientFactory
+	tracer  Tracer
+}
+
+// NewAgentClientFactory records a client span for every agent request made by the created clients.
+func NewAgentClientFactory(factory bihttpagent.AgentClientFactory, tracer Tracer) bihttpagent.AgentClientFactory {
+	return agentClientFactory{factory: factory, tracer: tracer}
+}
+
+func (f agentClientFactory) NewAgentClient(directorID, mbusURL, caCert string) (biagentclient.AgentClient, error) {
+	client, err := f.factory.NewAgentClient(directorID, mbusURL, caCert)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewAgentClient(client, f.tracer), nil
+}
+
+type agentClient struct {
+	client biagentclient.AgentClient
+	tracer Tracer
+}
+
+func NewAgentClient(client biagentclient.AgentClient, tracer Tracer) biagentclient.AgentClient {
+	return agentClient{client: client, tracer: tracer}
+}
+
+func (c agentClient) start(method string) Span {
+	return c.tracer.StartSpan("agent "+method, SpanKindClient, map[string]string{"bosh.agent.method": method})
+}
+
+func (c agentClient) Ping() (string, error) {
+	span := c.start("ping")
+	response, err := c.client.Ping()
+	span.End(err)
+	return response, err
+}
+
+func (c agentClient) Stop() error {
+	span := c.start("stop")
+	err := c.client.Stop()
+	span.End(err)
+	return err
+}
+
+func (c agentClient) Drain(drainType string) (int64, error) {
+	span := c.start("drain")
+	span.SetAttribute("bosh.agent.drain_type", drainType)
+	result, err := c.client.Drain(drainType)
+	span.End(err)
+	return result, err
+}
+
+func (c agentClient) Apply(spec bias.ApplySpec) error {
+	span := c.start("apply")
+	err := c.client.Apply(spec)
+	span.End(err)
+	return err
+}
+
+func (c agentClient) Start() error {
+	span := c.start("start")
+	err := c.client.Start()
+	span.End(err)
+	return err
+}
+
+func (c agentClient) GetState() (biagentclient.AgentState, error) {
+	span := c.start("get_state")
+	state, err := c.client.GetState()
+	span.SetAttribute("bosh.agent.job_state", state.JobState)
+	span.End(err)
+	return state, err
+}
+
+func (c agentClient) MountDisk(diskCID string) error {
+	span := c.start("mount_disk")
+	span.SetAttribute("bosh.disk.cid", diskCID)
+	err := c.client.MountDisk(diskCID)
+	span.End(err)
+	return err
+}
+
+func (c agentClient) UnmountDisk(diskCID string) error {
+	span := c.start("unmount_disk")
+	span.SetAttribute("bosh.disk.cid", diskCID)
+	err := c.client.UnmountDisk(diskCID)
+	span.End(err)
+	return err
+}
+
+func (c agentClient) ListDisk() ([]string, error) {
+	span := c.start("list_disk")
+	disks, err := c.client.ListDisk()
+	span.End(err)
+	return disks, err
+}
+
+func (c agentClient) MigrateDisk() error {
+	span := c.start("migrate_disk")
+	err := c.client.MigrateDisk()
+	span.End(err)
+	return err
+}
+
+func (c agentClient) CompilePackage(packageSource biagentclient.BlobRef, compiledPackageDependencies []biagentclient.BlobRef) (biagentclient.BlobRef, error) {
+	span := c.start("compile_package")
+	span.SetAttribute("bosh.package.name", packageSource.Name)
+	ref, err := c.client.CompilePackage(packageSource, compiledPackageDependencies)
+	span.End(err)
+	return ref, err
+}
+
+func (c agentClient) DeleteARPEntries(ips []string) error {
+	span := c.start("delete_arp_entries")
+	err := c.client.DeleteARPEntries(ips)
+	span.End(err)
+	return err
+}
+
+func (c agentClient) SyncDNS(blobID, sha1 string, version uint64) (string, error) {
+	span := c.start("sync_dns")
+	response, err := c.client.SyncDNS(blobID, sha1, version)
+	span.End(err)
+	return response, err
+}
+
+func (c agentClient) RunScript(scriptName string, options map[string]interface{}) error {
+	span := c.start("run_script")
+	span.SetAttribute("bosh.agent.script", scriptName)
+	err := c.client.RunScript(scriptName, options)
+	span.End(err)
+	return err
+}
@@ -0,0 +1,151 @@
+package tracing
+
+import (
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+
+	bicloud "github.com/cloudfoundry/bosh-cli/cloud"
+	biinstall "github.com/cloudfoundry/bosh-cli/installation"
+)
+
+type cloudFactory struct {
+	factory bicloud.Factory
+	tracer  Tracer
+}
+
+// NewCloudFactory records a client span for every CPI call made by the created clouds.
+func NewCloudFactory(factory bicloud.Factory, tracer Tracer) bicloud.Factory {
+	return cloudFactory{factory: factory, tracer: tracer}
+}
+
+func (f cloudFactory) NewCloud(installation biinstall.Installation, directorID string) (bicloud.Cloud, error) {
+	cloud, err := f.factory.NewCloud(installation, directorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCloud(cloud, f.tracer), nil
+}
+
+func (f cloudFactory) NewCloudWithProperties(installation biinstall.Installation, directorID string, cpiProperties biproperty.Map) (bicloud.Cloud, error) {
+	cloud, err := f.factory.NewCloudWithProperties(installation, directorID, cpiProperties)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewCloud(cloud, f.tracer), nil
+}
+
+type cloud struct {
+	cloud  bicloud.Cloud
+	tracer Tracer
+}
+
+func NewCloud(c bicloud.Cloud, tracer Tracer) bicloud.Cloud {
+	return cloud{cloud: c, tracer: tracer}
+}
+
+func (c cloud) start(method string, attributes map[string]string) Span {
+	spanAttributes := map[string]string{"bosh.cpi.method": method}
+	for key, value := range attributes {
+		spanAttributes[key] = value
+	}
+	return c.tracer.StartSpan("cpi "+method, SpanKindClient, spanAttributes)
+}
+
+func (c cloud) Info() (bicloud.Info, error) {
+	span := c.start("info", nil)
+	info, err := c.cloud.Info()
+	span.End(err)
+	return info, err
+}
+
+func (c cloud) CreateStemcell(imagePath string, cloudProperties biproperty.Map) (string, error) {
+	span := c.start("create_stemcell", nil)
+	cid, err := c.cloud.CreateStemcell(imagePath, cloudProperties)
+	span.SetAttribute("bosh.stemcell.cid", cid)
+	span.End(err)
+	return cid, err
+}
+
+func (c cloud) DeleteStemcell(stemcellCID string) error {
+	span := c.start("delete_stemcell", map[string]string{"bosh.stemcell.cid": stemcellCID})
+	err := c.cloud.DeleteStemcell(stemcellCID)
+	span.End(err)
+	return err
+}
+
+func (c cloud) HasVM(vmCID string) (bool, error) {
+	span := c.start("has_vm", map[string]string{"bosh.vm.cid": vmCID})
+	found, err := c.cloud.HasVM(vmCID)
+	span.End(err)
+	return found, err
+}
+
+func (c cloud) HasDisk(diskCID string) (bool, error) {
+	span := c.start("has_disk", map[string]string{"bosh.disk.cid": diskCID})
+	found, err := c.cloud.HasDisk(diskCID)
+	span.End(err)
+	return found, err
+}
+
+func (c cloud) CreateVM(agentID string, stemcellCID string, cloudProperties biproperty.Map, networksInterfaces map[string]biproperty.Map, env biproperty.Map) (string, error) {
+	span := c.start("create_vm", map[string]string{"bosh.agent.id": agentID, "bosh.stemcell.cid": stemcellCID})
+	cid, err := c.cloud.CreateVM(agentID, stemcellCID, cloudProperties, networksInterfaces, env)
+	span.SetAttribute("bosh.vm.cid", cid)
+	span.End(err)
+	return cid, err
+}
+
+func (c cloud) SetVMMetadata(vmCID string, metadata bicloud.VMMetadata) error {
+	span := c.start("set_vm_metadata", map[string]string{"bosh.vm.cid": vmCID})
+	err := c.cloud.SetVMMetadata(vmCID, metadata)
+	span.End(err)
+	return err
+}
+
+func (c cloud) SetDiskMetadata(diskCID string, metadata bicloud.DiskMetadata) error {
+	span := c.start("set_disk_metadata", map[string]string{"bosh.disk.cid": diskCID})
+	err := c.cloud.SetDiskMetadata(diskCID, metadata)
+	span.End(err)
+	return err
+}
+
+func (c cloud) DeleteVM(vmCID string) error {
+	span := c.start("delete_vm", map[string]string{"bosh.vm.cid": vmCID})
+	err := c.cloud.DeleteVM(vmCID)
+	span.End(err)
+	return err
+}
+
+func (c cloud) CreateDisk(size int, cloudProperties biproperty.Map, vmCID string) (string, error) {
+	span := c.start("create_disk", map[string]string{"bosh.vm.cid": vmCID})
+	cid, err := c.cloud.CreateDisk(size, cloudProperties, vmCID)
+	span.SetAttribute("bosh.disk.cid", cid)
+	span.End(err)
+	return cid, err
+}
+
+func (c cloud) AttachDisk(vmCID, diskCID string) error {
+	span := c.start("attach_disk", map[string]string{"bosh.vm.cid": vmCID, "bosh.disk.cid": diskCID})
+	err := c.cloud.AttachDisk(vmCID, diskCID)
+	span.End(err)
+	return err
+}
+
+func (c cloud) DetachDisk(vmCID, diskCID string) error {
+	span := c.start("detach_disk", map[string]string{"bosh.vm.cid": vmCID, "bosh.disk.cid": diskCID})
+	err := c.cloud.DetachDisk(vmCID, diskCID)
+	span.End(err)
+	return err
+}
+
+func (c cloud) DeleteDisk(diskCID string) error {
+	span := c.start("delete_disk", map[string]string{"bosh.disk.cid": diskCID})
+	err := c.cloud.DeleteDisk(diskCID)
+	span.End(err)
+	return err
+}
+
+func (c cloud) String() string {
+	return c.cloud.String()
+}
@@ -0,0 +1,66 @@
+package tracing_test
+
+import (
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	biproperty "github.com/cloudfoundry/bosh-utils/property"
+	"github.com/golang/mock/gomock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	mock_cloud "github.com/cloudfoundry/bosh-cli/cloud/mocks"
+
+	. "github.com/cloudfoundry/bosh-cli/tracing"
+)
+
+var _ = Describe("Cloud", func() {
+	var (
+		mockCtrl  *gomock.Controller
+		mockCloud *mock_cloud.MockCloud
+		exporter  *fakeExporter
+		tracer    Tracer
+	)
+
+	BeforeEach(func() {
+		mockCtrl = gomock.NewController(GinkgoT())
+		mockCloud = mock_cloud.NewMockCloud(mockCtrl)
+		exporter = &fakeExporter{}
+		tracer = NewTracer(exporter, fakeclock.NewFakeClock(time.Now()))
+	})
+
+	AfterEach(func() {
+		mockCtrl.Finish()
+	})
+
+	It("records a client span for each CPI call", func() {
+		createErr := errors.New("fake-create-error")
+		mockCloud.EXPECT().CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, map[string]biproperty.Map{}, biproperty.Map{}).Return("fake-vm-cid", nil)
+		mockCloud.EXPECT().CreateDisk(1024, biproperty.Map{}, "fake-vm-cid").Return("", createErr)
+
+		cloud := NewCloud(mockCloud, tracer)
+
+		cid, err := cloud.CreateVM("fake-agent-id", "fake-stemcell-cid", biproperty.Map{}, map[string]biproperty.Map{}, biproperty.Map{})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(cid).To(Equal("fake-vm-cid"))
+
+		_, err = cloud.CreateDisk(1024, biproperty.Map{}, "fake-vm-cid")
+		Expect(err).To(Equal(createErr))
+
+		Expect(tracer.Shutdown()).To(Succeed())
+		Expect(exporter.Spans).To(HaveLen(2))
+
+		Expect(exporter.Spans[0].Name).To(Equal("cpi create_vm"))
+		Expect(exporter.Spans[0].Kind).To(Equal(SpanKindClient))
+		Expect(exporter.Spans[0].Attributes).To(Equal(map[string]string{
+			"bosh.cpi.method":   "create_vm",
+			"bosh.agent.id":     "fake-agent-id",
+			"bosh.stemcell.cid": "fake-stemcell-cid",
+			"bosh.vm.cid":       "fake-vm-cid",
+		}))
+
+		Expect(exporter.Spans[1].Name).To(Equal("cpi create_disk"))
+		Expect(exporter.Spans[1].Err).To(Equal(createErr))
+	})
+})
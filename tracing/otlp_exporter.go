@@ -0,0 +1,179 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	otlpTracesPath   = "/v1/traces"
+	otlpServiceName  = "bosh-cli"
+	otlpStatusOK     = 1
+	otlpStatusError  = 2
+	otlpContentType  = "application/json"
+	otlpErrorBodyMax = 512
+)
+
+type otlpExporter struct {
+	endpoint   string
+	httpClient *http.Client
+	logger     boshlog.Logger
+	logTag     string
+}
+
+// NewOTLPExporter exports spans to an OpenTelemetry collector using the
+// OTLP/HTTP JSON encoding. Endpoints without a path get '/v1/traces' appended.
+func NewOTLPExporter(endpoint string, httpClient *http.Client, logger boshlog.Logger) (Exporter, error) {
+	endpointURL, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Parsing OTLP endpoint '%s'", endpoint)
+	}
+
+	if endpointURL.Scheme != "http" && endpointURL.Scheme != "https" {
+		return nil, bosherr.Errorf("Expected OTLP endpoint '%s' to be an http or https URL", endpoint)
+	}
+
+	if endpointURL.Path == "" || endpointURL.Path == "/" {
+		endpointURL.Path = otlpTracesPath
+	}
+
+	return otlpExporter{
+		endpoint:   endpointURL.String(),
+		httpClient: httpClient,
+		logger:     logger,
+		logTag:     "otlpExporter",
+	}, nil
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpAttribute struct {
+	Key   string             `json:"key"`
+	Value otlpAttributeValue `json:"value"`
+}
+
+type otlpAttributeValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+func (e otlpExporter) Export(spans []SpanData) error {
+	body, err := json.Marshal(e.request(spans))
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling OTLP request")
+	}
+
+	e.logger.Debug(e.logTag, "Exporting %d spans to '%s'", len(spans), e.endpoint)
+
+	resp, err := e.httpClient.Post(e.endpoint, otlpContentType, bytes.NewReader(body))
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Sending spans to '%s'", e.endpoint)
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		if len(respBody) > otlpErrorBodyMax {
+			respBody = respBody[:otlpErrorBodyMax]
+		}
+		return bosherr.Errorf("Sending spans to '%s' returned status %d: %s", e.endpoint, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	return nil
+}
+
+func (e otlpExporter) request(spans []SpanData) otlpRequest {
+	otlpSpans := []otlpSpan{}
+
+	for _, span := range spans {
+		status := otlpStatus{Code: otlpStatusOK}
+		if span.Err != nil {
+			status = otlpStatus{Code: otlpStatusError, Message: span.Err.Error()}
+		}
+
+		otlpSpans = append(otlpSpans, otlpSpan{
+			TraceID:           span.TraceID,
+			SpanID:            span.SpanID,
+			ParentSpanID:      span.ParentSpanID,
+			Name:              span.Name,
+			Kind:              span.Kind,
+			StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+			Attributes:        e.attributes(span.Attributes),
+			Status:            status,
+		})
+	}
+
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource: otlpResource{
+				Attributes: e.attributes(map[string]string{"service.name": otlpServiceName}),
+			},
+			ScopeSpans: []otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpServiceName},
+				Spans: otlpSpans,
+			}},
+		}},
+	}
+}
+
+func (e otlpExporter) attributes(attributes map[string]string) []otlpAttribute {
+	keys := []string{}
+	for key := range attributes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := []otlpAttribute{}
+	for _, key := range keys {
+		result = append(result, otlpAttribute{Key: key, Value: otlpAttributeValue{StringValue: attributes[key]}})
+	}
+
+	return result
+}
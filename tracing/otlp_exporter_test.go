@@ -0,0 +1,114 @@
+package tracing_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/tracing"
+)
+
+var _ = Describe("OTLPExporter", func() {
+	var (
+		server      *httptest.Server
+		statusCode  int
+		requestPath string
+		requestBody map[string]interface{}
+		logger      boshlog.Logger
+	)
+
+	BeforeEach(func() {
+		statusCode = http.StatusOK
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestPath = r.URL.Path
+			Expect(r.Header.Get("Content-Type")).To(Equal("application/json"))
+
+			body, err := ioutil.ReadAll(r.Body)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(json.Unmarshal(body, &requestBody)).To(Succeed())
+
+			w.WriteHeader(statusCode)
+			w.Write([]byte("fake-response"))
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	spans := []SpanData{
+		{
+			TraceID:      "fake-trace-id",
+			SpanID:       "fake-span-id",
+			ParentSpanID: "fake-parent-id",
+			Name:         "fake-span",
+			Kind:         SpanKindClient,
+			Start:        time.Unix(1, 0),
+			End:          time.Unix(2, 0),
+			Attributes:   map[string]string{"b": "2", "a": "1"},
+			Err:          errors.New("fake-error"),
+		},
+	}
+
+	It("posts the spans as OTLP JSON to the traces path", func() {
+		exporter, err := NewOTLPExporter(server.URL, http.DefaultClient, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = exporter.Export(spans)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(requestPath).To(Equal("/v1/traces"))
+
+		resourceSpans := requestBody["resourceSpans"].([]interface{})[0].(map[string]interface{})
+		scopeSpans := resourceSpans["scopeSpans"].([]interface{})[0].(map[string]interface{})
+		span := scopeSpans["spans"].([]interface{})[0].(map[string]interface{})
+
+		Expect(span).To(Equal(map[string]interface{}{
+			"traceId":           "fake-trace-id",
+			"spanId":            "fake-span-id",
+			"parentSpanId":      "fake-parent-id",
+			"name":              "fake-span",
+			"kind":              float64(SpanKindClient),
+			"startTimeUnixNano": "1000000000",
+			"endTimeUnixNano":   "2000000000",
+			"attributes": []interface{}{
+				map[string]interface{}{"key": "a", "value": map[string]interface{}{"stringValue": "1"}},
+				map[string]interface{}{"key": "b", "value": map[string]interface{}{"stringValue": "2"}},
+			},
+			"status": map[string]interface{}{"code": float64(2), "message": "fake-error"},
+		}))
+	})
+
+	It("keeps an explicit endpoint path", func() {
+		exporter, err := NewOTLPExporter(server.URL+"/custom/traces", http.DefaultClient, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(exporter.Export(spans)).To(Succeed())
+		Expect(requestPath).To(Equal("/custom/traces"))
+	})
+
+	It("returns an error when the collector does not accept the spans", func() {
+		statusCode = http.StatusBadRequest
+
+		exporter, err := NewOTLPExporter(server.URL, http.DefaultClient, logger)
+		Expect(err).ToNot(HaveOccurred())
+
+		err = exporter.Export(spans)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("returned status 400: fake-response"))
+	})
+
+	It("returns an error when the endpoint is not an http URL", func() {
+		_, err := NewOTLPExporter("grpc://localhost:4317", http.DefaultClient, logger)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("to be an http or https URL"))
+	})
+})
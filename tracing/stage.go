@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+type stage struct {
+	stage  biui.Stage
+	tracer Tracer
+}
+
+// NewStage records a span for every step performed on stage and its sub-stages.
+func NewStage(s biui.Stage, tracer Tracer) biui.Stage {
+	return stage{stage: s, tracer: tracer}
+}
+
+func (s stage) Perform(name string, closure func() error) error {
+	return s.stage.Perform(name, func() error {
+		span := s.tracer.StartSpan(name, SpanKindInternal, map[string]string{"bosh.stage.type": "step"})
+		err := closure()
+		span.End(err)
+		return err
+	})
+}
+
+func (s stage) PerformComplex(name string, closure func(biui.Stage) error) error {
+	return s.stage.PerformComplex(name, func(subStage biui.Stage) error {
+		span := s.tracer.StartSpan(name, SpanKindInternal, map[string]string{"bosh.stage.type": "stage"})
+		err := closure(NewStage(subStage, s.tracer))
+		span.End(err)
+		return err
+	})
+}
+
+func (s stage) PerformParallel(name string, tasks []biui.ParallelTask) error {
+	span := s.tracer.StartSpan(name, SpanKindInternal, map[string]string{"bosh.stage.type": "parallel"})
+
+	tracedTasks := make([]biui.ParallelTask, len(tasks))
+
+	for i, task := range tasks {
+		closure := task.Closure
+		name := task.Name
+
+		tracedTasks[i] = biui.ParallelTask{
+			Name: name,
+			Closure: func(subStage biui.Stage) error {
+				// tasks run concurrently, so each one nests its spans under the
+				// parallel stage explicitly instead of under the latest active span
+				taskTracer := s.tracer.ChildTracer(span)
+				taskSpan := taskTracer.StartSpan(name, SpanKindInternal, map[string]string{"bosh.stage.type": "stage"})
+				err := closure(NewStage(subStage, taskTracer))
+				taskSpan.End(err)
+				return err
+			},
+		}
+	}
+
+	err := s.stage.PerformParallel(name, tracedTasks)
+	span.End(err)
+	return err
+}
@@ -0,0 +1,135 @@
+package tracing_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	biui "github.com/cloudfoundry/bosh-cli/ui"
+	fakebiui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+
+	. "github.com/cloudfoundry/bosh-cli/tracing"
+)
+
+var _ = Describe("Stage", func() {
+	var (
+		exporter  *fakeExporter
+		tracer    Tracer
+		fakeStage *fakebiui.FakeStage
+		stage     biui.Stage
+	)
+
+	BeforeEach(func() {
+		exporter = &fakeExporter{}
+		tracer = NewTracer(exporter, fakeclock.NewFakeClock(time.Now()))
+		fakeStage = fakebiui.NewFakeStage()
+		stage = NewStage(fakeStage, tracer)
+	})
+
+	It("records a span for every step, nested under its complex stage", func() {
+		stepErr := errors.New("fake-step-error")
+
+		err := stage.PerformComplex("deploying", func(subStage biui.Stage) error {
+			return subStage.Perform("Creating VM", func() error { return stepErr })
+		})
+		Expect(err).To(Equal(stepErr))
+
+		Expect(fakeStage.PerformCalls).To(HaveLen(1))
+		Expect(fakeStage.PerformCalls[0].Name).To(Equal("deploying"))
+		Expect(fakeStage.PerformCalls[0].Stage.PerformCalls[0].Name).To(Equal("Creating VM"))
+
+		Expect(tracer.Shutdown()).To(Succeed())
+		Expect(exporter.Spans).To(HaveLen(2))
+
+		step, complex := exporter.Spans[0], exporter.Spans[1]
+		Expect(step.Name).To(Equal("Creating VM"))
+		Expect(step.ParentSpanID).To(Equal(complex.SpanID))
+		Expect(step.Err).To(Equal(stepErr))
+		Expect(complex.Name).To(Equal("deploying"))
+		Expect(complex.Attributes).To(Equal(map[string]string{"bosh.stage.type": "stage"}))
+	})
+
+	It("records a span for every parallel task, nested under the parallel stage", func() {
+		err := stage.PerformParallel("compiling", []biui.ParallelTask{
+			{Name: "pkg-a", Closure: func(biui.Stage) error { return nil }},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(tracer.Shutdown()).To(Succeed())
+		Expect(exporter.Spans).To(HaveLen(2))
+
+		task, parallel := exporter.Spans[0], exporter.Spans[1]
+		Expect(task.Name).To(Equal("pkg-a"))
+		Expect(task.ParentSpanID).To(Equal(parallel.SpanID))
+		Expect(parallel.Name).To(Equal("compiling"))
+		Expect(parallel.Attributes).To(Equal(map[string]string{"bosh.stage.type": "parallel"}))
+	})
+
+	It("nests spans of concurrently running tasks under their own task", func() {
+		stage = NewStage(concurrentStage{fakeStage}, tracer)
+
+		stepStarted := make(chan struct{})
+		secondTaskDone := make(chan struct{})
+
+		err := stage.PerformParallel("compiling", []biui.ParallelTask{
+			{Name: "pkg-a", Closure: func(subStage biui.Stage) error {
+				return subStage.Perform("compiling pkg-a", func() error {
+					close(stepStarted)
+					<-secondTaskDone
+					return nil
+				})
+			}},
+			{Name: "pkg-b", Closure: func(subStage biui.Stage) error {
+				defer close(secondTaskDone)
+				<-stepStarted
+				return subStage.Perform("compiling pkg-b", func() error { return nil })
+			}},
+		})
+		Expect(err).ToNot(HaveOccurred())
+
+		Expect(tracer.Shutdown()).To(Succeed())
+
+		spans := map[string]SpanData{}
+		for _, span := range exporter.Spans {
+			spans[span.Name] = span
+		}
+		Expect(spans).To(HaveLen(5))
+
+		Expect(spans["pkg-a"].ParentSpanID).To(Equal(spans["compiling"].SpanID))
+		Expect(spans["pkg-b"].ParentSpanID).To(Equal(spans["compiling"].SpanID))
+		Expect(spans["compiling pkg-a"].ParentSpanID).To(Equal(spans["pkg-a"].SpanID))
+		Expect(spans["compiling pkg-b"].ParentSpanID).To(Equal(spans["pkg-b"].SpanID))
+	})
+})
+
+// concurrentStage runs parallel tasks concurrently like the real stage does
+type concurrentStage struct {
+	*fakebiui.FakeStage
+}
+
+func (s concurrentStage) PerformParallel(name string, tasks []biui.ParallelTask) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(tasks))
+
+	for i, task := range tasks {
+		wg.Add(1)
+		go func(i int, task biui.ParallelTask) {
+			defer wg.Done()
+			errs[i] = task.Closure(fakebiui.NewFakeStage())
+		}(i, task)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
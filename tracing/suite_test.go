@@ -0,0 +1,24 @@
+package tracing_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"testing"
+
+	. "github.com/cloudfoundry/bosh-cli/tracing"
+)
+
+func TestReg(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "tracing")
+}
+
+type fakeExporter struct {
+	Spans     []SpanData
+	ExportErr error
+}
+
+func (e *fakeExporter) Export(spans []SpanData) error {
+	e.Spans = append(e.Spans, spans...)
+	return e.ExportErr
+}
@@ -0,0 +1,230 @@
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/clock"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const (
+	SpanKindInternal = 1
+	SpanKindClient   = 3
+)
+
+type Tracer interface {
+	// StartSpan starts a span as a child of the most recently started span
+	// that has not ended yet. The CLI runs its deploy pipeline sequentially,
+	// so this tracks nesting without threading a context through every call.
+	StartSpan(name string, kind int, attributes map[string]string) Span
+
+	// ChildTracer returns a tracer that nests spans under the most recently
+	// started active span it started itself, or under parent. Work running
+	// concurrently, e.g. parallel tasks, uses one child tracer each so that
+	// their spans do not nest under each other.
+	ChildTracer(parent Span) Tracer
+
+	// Shutdown exports all ended spans.
+	Shutdown() error
+}
+
+type Span interface {
+	SetAttribute(key, value string)
+	End(err error)
+}
+
+type SpanData struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	Kind         int
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	Err          error
+}
+
+type Exporter interface {
+	Export([]SpanData) error
+}
+
+type tracer struct {
+	exporter    Exporter
+	timeService clock.Clock
+	random      io.Reader
+
+	traceID string
+	active  []*span
+	ended   []SpanData
+	lock    sync.Mutex
+}
+
+func NewTracer(exporter Exporter, timeService clock.Clock) Tracer {
+	return NewTracerWithRandom(exporter, timeService, rand.Reader)
+}
+
+func NewTracerWithRandom(exporter Exporter, timeService clock.Clock, random io.Reader) Tracer {
+	return &tracer{
+		exporter:    exporter,
+		timeService: timeService,
+		random:      random,
+	}
+}
+
+func (t *tracer) StartSpan(name string, kind int, attributes map[string]string) Span {
+	return t.startSpan(&t.active, "", name, kind, attributes)
+}
+
+func (t *tracer) ChildTracer(parent Span) Tracer {
+	parentSpan, ok := parent.(*span)
+	if !ok || parentSpan.tracer != t {
+		return t
+	}
+
+	return &childTracer{tracer: t, parentSpanID: parentSpan.data.SpanID}
+}
+
+// startSpan parents the span to the last span in active, or to
+// parentSpanID when none is active
+func (t *tracer) startSpan(active *[]*span, parentSpanID string, name string, kind int, attributes map[string]string) Span {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.traceID == "" {
+		t.traceID = t.newID(16)
+	}
+
+	data := SpanData{
+		TraceID:      t.traceID,
+		SpanID:       t.newID(8),
+		ParentSpanID: parentSpanID,
+		Name:         name,
+		Kind:         kind,
+		Start:        t.timeService.Now(),
+		Attributes:   map[string]string{},
+	}
+
+	if len(*active) > 0 {
+		data.ParentSpanID = (*active)[len(*active)-1].data.SpanID
+	}
+
+	for key, value := range attributes {
+		data.Attributes[key] = value
+	}
+
+	s := &span{tracer: t, active: active, data: data}
+	*active = append(*active, s)
+
+	return s
+}
+
+func (t *tracer) Shutdown() error {
+	t.lock.Lock()
+	spans := t.ended
+	t.ended = nil
+	t.lock.Unlock()
+
+	if len(spans) == 0 {
+		return nil
+	}
+
+	err := t.exporter.Export(spans)
+	if err != nil {
+		return bosherr.WrapError(err, "Exporting tracing spans")
+	}
+
+	return nil
+}
+
+func (t *tracer) end(s *span, err error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	active := *s.active
+	for i, activeSpan := range active {
+		if activeSpan == s {
+			*s.active = append(active[:i], active[i+1:]...)
+			break
+		}
+	}
+
+	s.data.End = t.timeService.Now()
+	s.data.Err = err
+	t.ended = append(t.ended, s.data)
+}
+
+func (t *tracer) newID(length int) string {
+	bytes := make([]byte, length)
+
+	_, err := io.ReadFull(t.random, bytes)
+	if err != nil {
+		// IDs only need to be unique within the trace, so fall back to the clock
+		now := uint64(t.timeService.Now().UnixNano()) + uint64(len(t.ended)+len(t.active))
+		for i := range bytes {
+			bytes[i] = byte(now >> (uint(i%8) * 8))
+		}
+	}
+
+	return hex.EncodeToString(bytes)
+}
+
+type childTracer struct {
+	tracer       *tracer
+	parentSpanID string
+	active       []*span
+}
+
+func (c *childTracer) StartSpan(name string, kind int, attributes map[string]string) Span {
+	return c.tracer.startSpan(&c.active, c.parentSpanID, name, kind, attributes)
+}
+
+func (c *childTracer) ChildTracer(parent Span) Tracer {
+	return c.tracer.ChildTracer(parent)
+}
+
+func (c *childTracer) Shutdown() error {
+	return c.tracer.Shutdown()
+}
+
+type span struct {
+	tracer *tracer
+	active *[]*span
+	data   SpanData
+	ended  bool
+}
+
+func (s *span) SetAttribute(key, value string) {
+	s.tracer.lock.Lock()
+	defer s.tracer.lock.Unlock()
+
+	s.data.Attributes[key] = value
+}
+
+func (s *span) End(err error) {
+	if s.ended {
+		return
+	}
+	s.ended = true
+
+	s.tracer.end(s, err)
+}
+
+type noopTracer struct{}
+
+func NewNoopTracer() Tracer {
+	return noopTracer{}
+}
+
+func (noopTracer) StartSpan(string, int, map[string]string) Span { return noopSpan{} }
+func (t noopTracer) ChildTracer(Span) Tracer                     { return t }
+func (noopTracer) Shutdown() error                               { return nil }
+
+type noopSpan struct{}
+
+func (noopSpan) SetAttribute(string, string) {}
+func (noopSpan) End(error)                   {}
@@ -0,0 +1,108 @@
+package tracing_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/tracing"
+)
+
+var _ = Describe("Tracer", func() {
+	var (
+		exporter  *fakeExporter
+		fakeClock *fakeclock.FakeClock
+		tracer    Tracer
+	)
+
+	BeforeEach(func() {
+		exporter = &fakeExporter{}
+		fakeClock = fakeclock.NewFakeClock(time.Unix(100, 0))
+		tracer = NewTracerWithRandom(exporter, fakeClock, bytes.NewReader(bytes.Repeat([]byte{1, 2, 3, 4}, 100)))
+	})
+
+	It("nests spans under the most recently started active span", func() {
+		parent := tracer.StartSpan("parent", SpanKindInternal, map[string]string{"key": "value"})
+		fakeClock.Increment(time.Second)
+		child := tracer.StartSpan("child", SpanKindClient, nil)
+		child.SetAttribute("child-key", "child-value")
+		fakeClock.Increment(time.Second)
+		child.End(nil)
+		sibling := tracer.StartSpan("sibling", SpanKindInternal, nil)
+		sibling.End(errors.New("fake-error"))
+		parent.End(nil)
+
+		Expect(tracer.Shutdown()).To(Succeed())
+		Expect(exporter.Spans).To(HaveLen(3))
+
+		childData, siblingData, parentData := exporter.Spans[0], exporter.Spans[1], exporter.Spans[2]
+		Expect(parentData.Name).To(Equal("parent"))
+		Expect(parentData.ParentSpanID).To(BeEmpty())
+		Expect(parentData.Attributes).To(Equal(map[string]string{"key": "value"}))
+		Expect(parentData.TraceID).To(HaveLen(32))
+		Expect(parentData.SpanID).To(HaveLen(16))
+
+		Expect(childData.ParentSpanID).To(Equal(parentData.SpanID))
+		Expect(childData.TraceID).To(Equal(parentData.TraceID))
+		Expect(childData.Kind).To(Equal(SpanKindClient))
+		Expect(childData.Attributes).To(Equal(map[string]string{"child-key": "child-value"}))
+		Expect(childData.Start).To(Equal(time.Unix(101, 0)))
+		Expect(childData.End).To(Equal(time.Unix(102, 0)))
+
+		Expect(siblingData.ParentSpanID).To(Equal(parentData.SpanID))
+		Expect(siblingData.Err).To(MatchError("fake-error"))
+	})
+
+	It("nests spans of child tracers under their parent instead of each other", func() {
+		parent := tracer.StartSpan("parent", SpanKindInternal, nil)
+		first := tracer.ChildTracer(parent)
+		second := tracer.ChildTracer(parent)
+
+		firstTask := first.StartSpan("first-task", SpanKindInternal, nil)
+		secondTask := second.StartSpan("second-task", SpanKindInternal, nil)
+		firstStep := first.StartSpan("first-step", SpanKindInternal, nil)
+		firstStep.End(nil)
+		firstTask.End(nil)
+		secondTask.End(nil)
+		parent.End(nil)
+
+		Expect(tracer.Shutdown()).To(Succeed())
+		Expect(exporter.Spans).To(HaveLen(4))
+
+		firstStepData, firstTaskData, secondTaskData, parentData := exporter.Spans[0], exporter.Spans[1], exporter.Spans[2], exporter.Spans[3]
+		Expect(firstTaskData.ParentSpanID).To(Equal(parentData.SpanID))
+		Expect(secondTaskData.ParentSpanID).To(Equal(parentData.SpanID))
+		Expect(firstStepData.ParentSpanID).To(Equal(firstTaskData.SpanID))
+	})
+
+	It("records a span only once when ended twice", func() {
+		span := tracer.StartSpan("span", SpanKindInternal, nil)
+		span.End(nil)
+		span.End(errors.New("fake-error"))
+
+		Expect(tracer.Shutdown()).To(Succeed())
+		Expect(exporter.Spans).To(HaveLen(1))
+		Expect(exporter.Spans[0].Err).ToNot(HaveOccurred())
+	})
+
+	It("does not export spans that have not ended", func() {
+		tracer.StartSpan("span", SpanKindInternal, nil)
+
+		Expect(tracer.Shutdown()).To(Succeed())
+		Expect(exporter.Spans).To(BeEmpty())
+	})
+
+	It("returns an error when exporting fails", func() {
+		exporter.ExportErr = errors.New("fake-export-error")
+		tracer.StartSpan("span", SpanKindInternal, nil).End(nil)
+
+		err := tracer.Shutdown()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Exporting tracing spans"))
+		Expect(err.Error()).To(ContainSubstring("fake-export-error"))
+	})
+})
@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// AttemptsUI is implemented by UIs that keep the attempts of retried
+// operations for the stages printed with them
+type AttemptsUI interface {
+	Attempts() *Attempts
+}
+
+// AttemptsOf returns the attempts kept by ui, or nil when it keeps none
+func AttemptsOf(ui UI) *Attempts {
+	if attemptsUI, ok := ui.(AttemptsUI); ok {
+		return attemptsUI.Attempts()
+	}
+
+	return nil
+}
+
+// Attempts collects the retries of CPI calls, downloads and agent pings so
+// that the running stage can show one attempt counter per operation in its
+// line, e.g. "create_vm attempt 3/5", instead of every failed attempt. The
+// errors of the attempts are only logged. A nil Attempts drops the reports.
+type Attempts struct {
+	lock     sync.Mutex
+	seq      int
+	names    []string
+	attempts map[string]attempt
+}
+
+type attempt struct {
+	seq         int
+	number      int
+	maxAttempts int
+}
+
+func NewAttempts() *Attempts {
+	return &Attempts{attempts: map[string]attempt{}}
+}
+
+// ReportAttempt implements retrier.AttemptReporter
+func (a *Attempts) ReportAttempt(name string, number int, maxAttempts int) {
+	if a == nil {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	a.seq++
+
+	if _, found := a.attempts[name]; !found {
+		a.names = append(a.names, name)
+	}
+
+	a.attempts[name] = attempt{seq: a.seq, number: number, maxAttempts: maxAttempts}
+}
+
+// Mark is the point after which Summary reports attempts
+func (a *Attempts) Mark() int {
+	if a == nil {
+		return 0
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return a.seq
+}
+
+// Summary describes the latest attempt of each operation retried after mark
+func (a *Attempts) Summary(mark int) string {
+	if a == nil {
+		return ""
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var summaries []string
+
+	for _, name := range a.names {
+		attempt := a.attempts[name]
+		if attempt.seq <= mark {
+			continue
+		}
+
+		if attempt.maxAttempts > 0 {
+			summaries = append(summaries, fmt.Sprintf("%s attempt %d/%d", name, attempt.number, attempt.maxAttempts))
+		} else {
+			summaries = append(summaries, fmt.Sprintf("%s attempt %d", name, attempt.number))
+		}
+	}
+
+	return strings.Join(summaries, ", ")
+}
@@ -0,0 +1,46 @@
+package ui_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+var _ = Describe("Attempts", func() {
+	var (
+		attempts *Attempts
+	)
+
+	BeforeEach(func() {
+		attempts = NewAttempts()
+	})
+
+	Describe("Summary", func() {
+		It("describes the latest attempt of each operation in the order they were first retried", func() {
+			attempts.ReportAttempt("create_vm", 2, 5)
+			attempts.ReportAttempt("agent ping", 2, 0)
+			attempts.ReportAttempt("create_vm", 3, 5)
+
+			Expect(attempts.Summary(0)).To(Equal("create_vm attempt 3/5, agent ping attempt 2"))
+		})
+
+		It("only describes attempts reported after the mark", func() {
+			attempts.ReportAttempt("create_vm", 2, 5)
+			attempts.ReportAttempt("agent ping", 2, 0)
+
+			mark := attempts.Mark()
+			Expect(attempts.Summary(mark)).To(BeEmpty())
+
+			attempts.ReportAttempt("create_vm", 3, 5)
+			Expect(attempts.Summary(mark)).To(Equal("create_vm attempt 3/5"))
+		})
+	})
+
+	It("ignores reports when it is nil", func() {
+		var nilAttempts *Attempts
+
+		nilAttempts.ReportAttempt("create_vm", 2, 5)
+		Expect(nilAttempts.Summary(nilAttempts.Mark())).To(BeEmpty())
+	})
+})
@@ -86,6 +86,14 @@ func (ui *ColorUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
 
+func (ui *ColorUI) RecordEvent(event Event) {
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *ColorUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
 func (ui *ColorUI) Flush() {
 	ui.parent.Flush()
 }
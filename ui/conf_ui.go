@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"io"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	. "github.com/cloudfoundry/bosh-cli/ui/table"
@@ -8,9 +10,11 @@ import (
 
 type ConfUI struct {
 	parent      UI
+	writerUI    *WriterUI
 	isTTY       bool
 	logger      boshlog.Logger
 	showColumns []Header
+	attempts    *Attempts
 }
 
 func NewConfUI(logger boshlog.Logger) *ConfUI {
@@ -20,17 +24,20 @@ func NewConfUI(logger boshlog.Logger) *ConfUI {
 	ui = NewPaddingUI(writerUI)
 
 	return &ConfUI{
-		parent: ui,
-		isTTY:  writerUI.IsTTY(),
-		logger: logger,
+		parent:   ui,
+		writerUI: writerUI,
+		isTTY:    writerUI.IsTTY(),
+		logger:   logger,
+		attempts: NewAttempts(),
 	}
 }
 
 func NewWrappingConfUI(parent UI, logger boshlog.Logger) *ConfUI {
 	return &ConfUI{
-		parent: parent,
-		isTTY:  true,
-		logger: logger,
+		parent:   parent,
+		isTTY:    true,
+		logger:   logger,
+		attempts: NewAttempts(),
 	}
 }
 
@@ -40,6 +47,19 @@ func (ui *ConfUI) EnableTTY(force bool) {
 	}
 }
 
+// EnableOutputLog copies output to writer in addition to the console.
+// It has no effect on a wrapping ConfUI.
+func (ui *ConfUI) EnableOutputLog(writer io.Writer) {
+	if ui.writerUI != nil {
+		ui.writerUI.TeeTo(writer)
+	}
+}
+
+// EnableEventLog writes events to writer as they are recorded
+func (ui *ConfUI) EnableEventLog(writer io.Writer) {
+	ui.parent = NewEventLogUI(ui.parent, writer, ui.logger)
+}
+
 func (ui *ConfUI) EnableColor() {
 	ui.parent = NewColorUI(ui.parent)
 }
@@ -111,6 +131,19 @@ func (ui *ConfUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
 
+func (ui *ConfUI) RecordEvent(event Event) {
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *ConfUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
+// Attempts keeps the retries of operations for the stages printed with ui
+func (ui *ConfUI) Attempts() *Attempts {
+	return ui.attempts
+}
+
 func (ui *ConfUI) Flush() {
 	ui.parent.Flush()
 }
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+type eventLogUI struct {
+	parent UI
+	writer io.Writer
+	lock   sync.Mutex
+
+	logTag string
+	logger boshlog.Logger
+}
+
+// NewEventLogUI writes every event to writer as a line of JSON as soon as
+// it is recorded, so that tools can follow the stages of a long running
+// command instead of waiting for the --json output at its end.
+func NewEventLogUI(parent UI, writer io.Writer, logger boshlog.Logger) UI {
+	return &eventLogUI{
+		parent: parent,
+		writer: writer,
+
+		logTag: "eventLogUI",
+		logger: logger,
+	}
+}
+
+func (ui *eventLogUI) ErrorLinef(pattern string, args ...interface{}) {
+	ui.parent.ErrorLinef(pattern, args...)
+}
+
+func (ui *eventLogUI) PrintLinef(pattern string, args ...interface{}) {
+	ui.parent.PrintLinef(pattern, args...)
+}
+
+func (ui *eventLogUI) BeginLinef(pattern string, args ...interface{}) {
+	ui.parent.BeginLinef(pattern, args...)
+}
+
+func (ui *eventLogUI) EndLinef(pattern string, args ...interface{}) {
+	ui.parent.EndLinef(pattern, args...)
+}
+
+func (ui *eventLogUI) PrintBlock(block []byte) {
+	ui.parent.PrintBlock(block)
+}
+
+func (ui *eventLogUI) PrintErrorBlock(block string) {
+	ui.parent.PrintErrorBlock(block)
+}
+
+func (ui *eventLogUI) PrintTable(table Table) {
+	ui.parent.PrintTable(table)
+}
+
+func (ui *eventLogUI) AskForText(label string) (string, error) {
+	return ui.parent.AskForText(label)
+}
+
+func (ui *eventLogUI) AskForChoice(label string, options []string) (int, error) {
+	return ui.parent.AskForChoice(label, options)
+}
+
+func (ui *eventLogUI) AskForPassword(label string) (string, error) {
+	return ui.parent.AskForPassword(label)
+}
+
+func (ui *eventLogUI) AskForConfirmation() error {
+	return ui.parent.AskForConfirmation()
+}
+
+func (ui *eventLogUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+// RecordEvent does not fail the command when the event log cannot be
+// written, the event is still handed to the parent
+func (ui *eventLogUI) RecordEvent(event Event) {
+	bytes, err := json.Marshal(event)
+	if err != nil {
+		ui.logger.Error(ui.logTag, "Marshalling event: %s", err.Error())
+	} else {
+		ui.lock.Lock()
+		_, err = ui.writer.Write(append(bytes, '\n'))
+		ui.lock.Unlock()
+
+		if err != nil {
+			ui.logger.Error(ui.logTag, "Writing event: %s", err.Error())
+		}
+	}
+
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *eventLogUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
+func (ui *eventLogUI) Flush() {
+	ui.parent.Flush()
+}
@@ -0,0 +1,73 @@
+package ui_test
+
+import (
+	"bytes"
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("fake-write-err")
+}
+
+var _ = Describe("EventLogUI", func() {
+	var (
+		parentUI *fakeui.FakeUI
+		writer   *bytes.Buffer
+		logger   boshlog.Logger
+		ui       UI
+	)
+
+	BeforeEach(func() {
+		parentUI = &fakeui.FakeUI{}
+		writer = bytes.NewBufferString("")
+		logger = boshlog.NewLogger(boshlog.LevelNone)
+		ui = NewEventLogUI(parentUI, writer, logger)
+	})
+
+	Describe("RecordEvent", func() {
+		It("writes each event as a line of JSON and hands it to the parent UI", func() {
+			started := Event{Type: "stage", Stage: "Creating VM", State: "started", Time: "2026-01-02T03:04:05.000Z"}
+			finished := Event{Type: "stage", Stage: "Creating VM", State: "finished", Time: "2026-01-02T03:05:05.000Z", Duration: "00:01:00"}
+
+			RecordEvent(ui, started)
+			RecordEvent(ui, finished)
+
+			Expect(writer.String()).To(Equal(
+				`{"type":"stage","stage":"Creating VM","state":"started","time":"2026-01-02T03:04:05.000Z"}` + "\n" +
+					`{"type":"stage","stage":"Creating VM","state":"finished","time":"2026-01-02T03:05:05.000Z","duration":"00:01:00"}` + "\n",
+			))
+			Expect(parentUI.Events).To(Equal([]Event{started, finished}))
+		})
+
+		It("still hands events to the parent UI when they cannot be written", func() {
+			ui = NewEventLogUI(parentUI, failingWriter{}, logger)
+
+			RecordEvent(ui, Event{Type: "error", Message: "fake-err"})
+			Expect(parentUI.Events).To(Equal([]Event{{Type: "error", Message: "fake-err"}}))
+		})
+	})
+
+	Describe("PrintLinef", func() {
+		It("delegates to the parent UI without writing events", func() {
+			ui.PrintLinef("fake-line")
+			Expect(parentUI.Said).To(Equal([]string{"fake-line"}))
+			Expect(writer.String()).To(BeEmpty())
+		})
+	})
+
+	Describe("IsInteractive", func() {
+		It("delegates to the parent UI", func() {
+			parentUI.Interactive = true
+			Expect(ui.IsInteractive()).To(BeTrue())
+		})
+	})
+})
@@ -71,3 +71,24 @@ func (s *FakeStage) PerformComplex(name string, closure func(biui.Stage) error)
 
 	return err
 }
+
+// PerformParallel runs the tasks one after another and records them like
+// complex stages nested in a complex stage named after the parallel stage
+func (s *FakeStage) PerformParallel(name string, tasks []biui.ParallelTask) error {
+	return s.PerformComplex(name, func(stage biui.Stage) error {
+		var errs []error
+
+		for _, task := range tasks {
+			err := stage.PerformComplex(task.Name, task.Closure)
+			if err != nil {
+				errs = append(errs, err)
+			}
+		}
+
+		if len(errs) > 0 {
+			return errs[0]
+		}
+
+		return nil
+	})
+}
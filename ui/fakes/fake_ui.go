@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 
+	boshui "github.com/cloudfoundry/bosh-cli/ui"
 	. "github.com/cloudfoundry/bosh-cli/ui/table"
 )
 
@@ -16,6 +17,8 @@ type FakeUI struct {
 	Table  Table
 	Tables []Table
 
+	Events []boshui.Event
+
 	AskedTextLabels []string
 	AskedText       []Answer
 
@@ -29,6 +32,7 @@ type FakeUI struct {
 	AskedChoiceErrs    []error
 
 	AskedConfirmationCalled bool
+	AskedConfirmationCount  int
 	AskedConfirmationErr    error
 
 	Interactive bool
@@ -136,6 +140,7 @@ func (ui *FakeUI) AskForConfirmation() error {
 	defer ui.mutex.Unlock()
 
 	ui.AskedConfirmationCalled = true
+	ui.AskedConfirmationCount++
 	return ui.AskedConfirmationErr
 }
 
@@ -146,6 +151,13 @@ func (ui *FakeUI) IsInteractive() bool {
 	return ui.Interactive
 }
 
+func (ui *FakeUI) RecordEvent(event boshui.Event) {
+	ui.mutex.Lock()
+	defer ui.mutex.Unlock()
+
+	ui.Events = append(ui.Events, event)
+}
+
 func (ui *FakeUI) Flush() {
 	ui.mutex.Lock()
 	defer ui.mutex.Unlock()
@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/table"
+)
+
+// groupingUI is used by stages running concurrently. It only writes whole
+// lines, prefixed with the group name, so that lines of different groups
+// do not interleave. Groups sharing a parent must share the lock.
+type groupingUI struct {
+	parent UI
+	prefix string
+	lock   sync.Locker
+
+	pending string
+}
+
+func NewGroupingUI(parent UI, group string, lock sync.Locker) UI {
+	return &groupingUI{parent: parent, prefix: "[" + group + "] ", lock: lock}
+}
+
+func (ui *groupingUI) ErrorLinef(pattern string, args ...interface{}) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.parent.ErrorLinef("%s%s", ui.prefix, fmt.Sprintf(pattern, args...))
+}
+
+func (ui *groupingUI) PrintLinef(pattern string, args ...interface{}) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.printLine(fmt.Sprintf(pattern, args...))
+}
+
+// BeginLinef holds on to the text until its line is complete
+func (ui *groupingUI) BeginLinef(pattern string, args ...interface{}) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.pending += fmt.Sprintf(pattern, args...)
+
+	for {
+		idx := strings.Index(ui.pending, "\n")
+		if idx == -1 {
+			return
+		}
+
+		line := ui.pending[:idx]
+		ui.pending = ui.pending[idx+1:]
+
+		if strings.TrimSpace(line) != "" {
+			ui.printLine(line)
+		}
+	}
+}
+
+func (ui *groupingUI) EndLinef(pattern string, args ...interface{}) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	line := ui.pending + fmt.Sprintf(pattern, args...)
+	ui.pending = ""
+
+	ui.printLine(line)
+}
+
+func (ui *groupingUI) printLine(line string) {
+	ui.parent.PrintLinef("%s%s", ui.prefix, line)
+}
+
+func (ui *groupingUI) PrintBlock(block []byte) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.parent.PrintBlock(block)
+}
+
+func (ui *groupingUI) PrintErrorBlock(block string) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.parent.PrintErrorBlock(block)
+}
+
+func (ui *groupingUI) PrintTable(table Table) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.parent.PrintTable(table)
+}
+
+func (ui *groupingUI) AskForText(label string) (string, error) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	return ui.parent.AskForText(ui.prefix + label)
+}
+
+func (ui *groupingUI) AskForChoice(label string, options []string) (int, error) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	return ui.parent.AskForChoice(ui.prefix+label, options)
+}
+
+func (ui *groupingUI) AskForPassword(label string) (string, error) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	return ui.parent.AskForPassword(ui.prefix + label)
+}
+
+func (ui *groupingUI) AskForConfirmation() error {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	return ui.parent.AskForConfirmation()
+}
+
+func (ui *groupingUI) IsInteractive() bool {
+	return ui.parent.IsInteractive()
+}
+
+func (ui *groupingUI) RecordEvent(event Event) {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *groupingUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
+func (ui *groupingUI) Flush() {
+	ui.lock.Lock()
+	defer ui.lock.Unlock()
+
+	ui.parent.Flush()
+}
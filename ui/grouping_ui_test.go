@@ -0,0 +1,82 @@
+package ui_test
+
+import (
+	"bytes"
+	"sync"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
+)
+
+var _ = Describe("GroupingUI", func() {
+	var (
+		uiOut, uiErr *bytes.Buffer
+		lock         *sync.Mutex
+		parentUI     UI
+	)
+
+	BeforeEach(func() {
+		uiOut = bytes.NewBufferString("")
+		uiErr = bytes.NewBufferString("")
+		lock = &sync.Mutex{}
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		parentUI = NewWriterUI(uiOut, uiErr, logger)
+	})
+
+	Describe("ErrorLinef", func() {
+		It("delegates to the parent UI with the group prefix", func() {
+			NewGroupingUI(parentUI, "group-a", lock).ErrorLinef("fake-error-line")
+			Expect(uiErr.String()).To(Equal("[group-a] fake-error-line\n"))
+		})
+	})
+
+	Describe("PrintLinef", func() {
+		It("delegates to the parent UI with the group prefix", func() {
+			NewGroupingUI(parentUI, "group-a", lock).PrintLinef("fake-line")
+			Expect(uiOut.String()).To(Equal("[group-a] fake-line\n"))
+		})
+	})
+
+	Describe("BeginLinef and EndLinef", func() {
+		It("writes the line only once it is complete", func() {
+			groupA := NewGroupingUI(parentUI, "group-a", lock)
+			groupB := NewGroupingUI(parentUI, "group-b", lock)
+
+			groupA.BeginLinef("fake-start-a...")
+			groupB.BeginLinef("fake-start-b...")
+			Expect(uiOut.String()).To(BeEmpty())
+
+			groupB.EndLinef(" fake-end-b")
+			groupA.EndLinef(" fake-end-a")
+
+			Expect(uiOut.String()).To(Equal("[group-b] fake-start-b... fake-end-b\n[group-a] fake-start-a... fake-end-a\n"))
+		})
+
+		It("writes lines ended by a line break in BeginLinef and skips empty ones", func() {
+			ui := NewGroupingUI(parentUI, "group-a", lock)
+
+			ui.BeginLinef("\n")
+			ui.BeginLinef("Started fake-stage\nfake-next...")
+			Expect(uiOut.String()).To(Equal("[group-a] Started fake-stage\n"))
+
+			ui.EndLinef(" done")
+			Expect(uiOut.String()).To(Equal("[group-a] Started fake-stage\n[group-a] fake-next... done\n"))
+		})
+	})
+
+	Describe("AskForText", func() {
+		It("prefixes the label", func() {
+			parentFakeUI := &fakeui.FakeUI{AskedText: []fakeui.Answer{{Text: "fake-answer"}}}
+
+			answer, err := NewGroupingUI(parentFakeUI, "group-a", lock).AskForText("fake-label")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(answer).To(Equal("fake-answer"))
+			Expect(parentFakeUI.AskedTextLabels).To(Equal([]string{"[group-a] fake-label"}))
+		})
+	})
+})
@@ -0,0 +1,82 @@
+package i18n
+
+import (
+	"fmt"
+)
+
+type MessageID string
+
+const (
+	DeploymentStatePath          MessageID = "deployment_state_path"
+	MigratedLegacyDeploymentFile MessageID = "migrated_legacy_deployment_file"
+	SkippingUnchangedDeploy      MessageID = "skipping_unchanged_deploy"
+	PlaintextPasswordWarning     MessageID = "plaintext_password_warning"
+	UnverifiedConvergenceWarning MessageID = "unverified_convergence_warning"
+	CloudPropertyWarning         MessageID = "cloud_property_warning"
+	CPIHungWarning               MessageID = "cpi_hung_warning"
+	QuotaWarning                 MessageID = "quota_warning"
+	PlannedDeletion              MessageID = "planned_deletion"
+)
+
+// DefaultLocale is used when no locale is configured and for messages
+// that are missing from a localized catalog.
+const DefaultLocale = "en"
+
+var defaultMessages = map[MessageID]string{
+	DeploymentStatePath:          "Deployment state: '%s'",
+	MigratedLegacyDeploymentFile: "Migrated legacy deployments file: '%s'",
+	SkippingUnchangedDeploy:      "No deployment, stemcell or release changes. Skipping deploy.",
+	PlaintextPasswordWarning:     "Warning: resource pool '%s' specifies a plaintext env.bosh.password, hashing it with sha512-crypt. Provide a pre-hashed password to avoid this warning.",
+	UnverifiedConvergenceWarning: "Warning: convergence policy '%s' did not verify that the deployment converged.",
+	CloudPropertyWarning:         "Warning: %s",
+	CPIHungWarning:               "CPI appears hung (call: %s, elapsed: %s)",
+	QuotaWarning:                 "Warning: %s quota may be exceeded, the deploy needs %d but only %d of %d are available.",
+	PlannedDeletion:              "Deploying calls %s on '%s': %s",
+}
+
+type Catalog interface {
+	Locale() string
+	T(id MessageID, args ...interface{}) string
+}
+
+type catalog struct {
+	locale   string
+	messages map[MessageID]string
+}
+
+// NewCatalog returns a catalog for locale that uses messages where present
+// and falls back to the English defaults otherwise.
+func NewCatalog(locale string, messages map[MessageID]string) Catalog {
+	merged := map[MessageID]string{}
+
+	for id, msg := range defaultMessages {
+		merged[id] = msg
+	}
+
+	for id, msg := range messages {
+		merged[id] = msg
+	}
+
+	return catalog{locale: locale, messages: merged}
+}
+
+func NewDefaultCatalog() Catalog {
+	return NewCatalog(DefaultLocale, nil)
+}
+
+func (c catalog) Locale() string { return c.locale }
+
+// T formats the message with args; unknown IDs are returned as-is
+// so that missing translations are visible rather than empty.
+func (c catalog) T(id MessageID, args ...interface{}) string {
+	msg, found := c.messages[id]
+	if !found {
+		msg = string(id)
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
@@ -0,0 +1,30 @@
+package i18n_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/i18n"
+)
+
+var _ = Describe("Catalog", func() {
+	It("formats English defaults", func() {
+		catalog := NewDefaultCatalog()
+		Expect(catalog.Locale()).To(Equal("en"))
+		Expect(catalog.T(DeploymentStatePath, "/fake-state.json")).To(Equal("Deployment state: '/fake-state.json'"))
+		Expect(catalog.T(SkippingUnchangedDeploy)).To(Equal("No deployment, stemcell or release changes. Skipping deploy."))
+	})
+
+	It("uses localized messages and falls back to defaults for missing ones", func() {
+		catalog := NewCatalog("de", map[MessageID]string{
+			SkippingUnchangedDeploy: "Keine Änderungen.",
+		})
+		Expect(catalog.Locale()).To(Equal("de"))
+		Expect(catalog.T(SkippingUnchangedDeploy)).To(Equal("Keine Änderungen."))
+		Expect(catalog.T(DeploymentStatePath, "/fake-state.json")).To(Equal("Deployment state: '/fake-state.json'"))
+	})
+
+	It("returns unknown message IDs as-is", func() {
+		Expect(NewDefaultCatalog().T(MessageID("fake-unknown-id"))).To(Equal("fake-unknown-id"))
+	})
+})
@@ -0,0 +1,120 @@
+package i18n
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	boshsys "github.com/cloudfoundry/bosh-utils/system"
+)
+
+// Environment variables that configure message catalogs.
+// BOSH_LOCALE takes precedence over the standard LC_ALL, LC_MESSAGES and LANG.
+// BOSH_I18N_PATH is a directory of catalogs named <locale>.json, each a
+// JSON object mapping message IDs to format strings.
+const (
+	LocaleEnvVar      = "BOSH_LOCALE"
+	CatalogPathEnvVar = "BOSH_I18N_PATH"
+)
+
+var localeEnvVars = []string{LocaleEnvVar, "LC_ALL", "LC_MESSAGES", "LANG"}
+
+type Loader struct {
+	fs     boshsys.FileSystem
+	logTag string
+	logger boshlog.Logger
+}
+
+func NewLoader(fs boshsys.FileSystem, logger boshlog.Logger) Loader {
+	return Loader{fs: fs, logTag: "i18nLoader", logger: logger}
+}
+
+// LoadFromEnv loads the catalog selected by the environment.
+// Any problem loading a catalog is logged and the English defaults are used,
+// since a broken translation should never prevent the CLI from running.
+func (l Loader) LoadFromEnv(getenv func(string) string) Catalog {
+	dir := getenv(CatalogPathEnvVar)
+	locale := LocaleFromEnv(getenv)
+
+	if dir == "" || locale == DefaultLocale {
+		return NewDefaultCatalog()
+	}
+
+	catalog, err := l.Load(dir, locale)
+	if err != nil {
+		l.logger.Warn(l.logTag, "Falling back to default messages: %s", err.Error())
+		return NewDefaultCatalog()
+	}
+
+	return catalog
+}
+
+// Load reads <dir>/<locale>.json, falling back to the language-only catalog
+// (e.g. de.json for de-AT) and finally to the English defaults.
+func (l Loader) Load(dir, locale string) (Catalog, error) {
+	for _, candidate := range localeCandidates(locale) {
+		path := filepath.Join(dir, candidate+".json")
+
+		if !l.fs.FileExists(path) {
+			continue
+		}
+
+		contents, err := l.fs.ReadFile(path)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Reading message catalog '%s'", path)
+		}
+
+		var messages map[MessageID]string
+
+		err = json.Unmarshal(contents, &messages)
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Unmarshalling message catalog '%s'", path)
+		}
+
+		l.logger.Debug(l.logTag, "Loaded message catalog '%s'", path)
+
+		return NewCatalog(candidate, messages), nil
+	}
+
+	l.logger.Debug(l.logTag, "No message catalog found for locale '%s' in '%s'", locale, dir)
+
+	return NewDefaultCatalog(), nil
+}
+
+// LocaleFromEnv returns a normalized locale (e.g. "de-DE") from the first
+// set locale environment variable, or the default locale.
+func LocaleFromEnv(getenv func(string) string) string {
+	for _, name := range localeEnvVars {
+		value := getenv(name)
+		if value != "" {
+			return normalizeLocale(value)
+		}
+	}
+
+	return DefaultLocale
+}
+
+func normalizeLocale(value string) string {
+	// Strip encoding and modifier, e.g. "de_DE.UTF-8@euro"
+	if idx := strings.IndexAny(value, ".@"); idx >= 0 {
+		value = value[:idx]
+	}
+
+	if value == "" || value == "C" || value == "POSIX" {
+		return DefaultLocale
+	}
+
+	return strings.Replace(value, "_", "-", -1)
+}
+
+func localeCandidates(locale string) []string {
+	candidates := []string{locale}
+
+	if idx := strings.Index(locale, "-"); idx > 0 {
+		candidates = append(candidates, locale[:idx])
+	}
+
+	return candidates
+}
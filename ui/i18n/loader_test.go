@@ -0,0 +1,117 @@
+package i18n_test
+
+import (
+	"errors"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakesys "github.com/cloudfoundry/bosh-utils/system/fakes"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui/i18n"
+)
+
+var _ = Describe("Loader", func() {
+	var (
+		fs     *fakesys.FakeFileSystem
+		loader Loader
+		env    map[string]string
+	)
+
+	BeforeEach(func() {
+		fs = fakesys.NewFakeFileSystem()
+		loader = NewLoader(fs, boshlog.NewLogger(boshlog.LevelNone))
+		env = map[string]string{}
+	})
+
+	getenv := func(name string) string { return env[name] }
+
+	Describe("LocaleFromEnv", func() {
+		It("defaults to English", func() {
+			Expect(LocaleFromEnv(getenv)).To(Equal("en"))
+		})
+
+		It("normalizes LANG", func() {
+			env["LANG"] = "de_DE.UTF-8"
+			Expect(LocaleFromEnv(getenv)).To(Equal("de-DE"))
+		})
+
+		It("treats the C locale as English", func() {
+			env["LC_ALL"] = "C"
+			Expect(LocaleFromEnv(getenv)).To(Equal("en"))
+		})
+
+		It("prefers BOSH_LOCALE", func() {
+			env["LANG"] = "de_DE.UTF-8"
+			env["BOSH_LOCALE"] = "fr"
+			Expect(LocaleFromEnv(getenv)).To(Equal("fr"))
+		})
+	})
+
+	Describe("Load", func() {
+		It("loads the catalog for the locale", func() {
+			fs.WriteFileString("/fake-i18n/de-DE.json", `{"skipping_unchanged_deploy": "Keine Änderungen."}`)
+
+			catalog, err := loader.Load("/fake-i18n", "de-DE")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(catalog.Locale()).To(Equal("de-DE"))
+			Expect(catalog.T(SkippingUnchangedDeploy)).To(Equal("Keine Änderungen."))
+		})
+
+		It("falls back to the language catalog", func() {
+			fs.WriteFileString("/fake-i18n/de.json", `{"skipping_unchanged_deploy": "Keine Änderungen."}`)
+
+			catalog, err := loader.Load("/fake-i18n", "de-AT")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(catalog.Locale()).To(Equal("de"))
+			Expect(catalog.T(SkippingUnchangedDeploy)).To(Equal("Keine Änderungen."))
+		})
+
+		It("falls back to the defaults when there is no catalog", func() {
+			catalog, err := loader.Load("/fake-i18n", "de-AT")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(catalog.Locale()).To(Equal("en"))
+		})
+
+		It("returns an error when the catalog is invalid", func() {
+			fs.WriteFileString("/fake-i18n/de.json", `not-json`)
+
+			_, err := loader.Load("/fake-i18n", "de")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling message catalog '/fake-i18n/de.json'"))
+		})
+
+		It("returns an error when the catalog cannot be read", func() {
+			fs.WriteFileString("/fake-i18n/de.json", `{}`)
+			fs.ReadFileError = errors.New("fake-read-error")
+
+			_, err := loader.Load("/fake-i18n", "de")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-read-error"))
+		})
+	})
+
+	Describe("LoadFromEnv", func() {
+		It("loads the catalog selected by the environment", func() {
+			fs.WriteFileString("/fake-i18n/de.json", `{"skipping_unchanged_deploy": "Keine Änderungen."}`)
+			env["BOSH_I18N_PATH"] = "/fake-i18n"
+			env["LANG"] = "de_DE.UTF-8"
+
+			Expect(loader.LoadFromEnv(getenv).T(SkippingUnchangedDeploy)).To(Equal("Keine Änderungen."))
+		})
+
+		It("uses the defaults when no catalog path is set", func() {
+			env["LANG"] = "de_DE.UTF-8"
+
+			Expect(loader.LoadFromEnv(getenv).T(SkippingUnchangedDeploy)).To(Equal("No deployment, stemcell or release changes. Skipping deploy."))
+		})
+
+		It("uses the defaults when the catalog is invalid", func() {
+			fs.WriteFileString("/fake-i18n/de.json", `not-json`)
+			env["BOSH_I18N_PATH"] = "/fake-i18n"
+			env["LANG"] = "de"
+
+			Expect(loader.LoadFromEnv(getenv).T(SkippingUnchangedDeploy)).To(Equal("No deployment, stemcell or release changes. Skipping deploy."))
+		})
+	})
+})
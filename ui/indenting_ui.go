@@ -62,6 +62,14 @@ func (ui *indentingUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
 
+func (ui *indentingUI) RecordEvent(event Event) {
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *indentingUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
 func (ui *indentingUI) Flush() {
 	ui.parent.Flush()
 }
@@ -27,3 +27,62 @@ type UI interface {
 
 	Flush()
 }
+
+// EventUI is implemented by UIs that keep structured events, e.g. for
+// machine readable output, in addition to the lines that describe them
+type EventUI interface {
+	RecordEvent(Event)
+}
+
+const (
+	EventTypeStage  = "stage"
+	EventTypeError  = "error"
+	EventTypeResult = "result"
+
+	EventStateStarted  = "started"
+	EventStateFinished = "finished"
+	EventStateSkipped  = "skipped"
+	EventStateFailed   = "failed"
+
+	// EventTimeFormat is the format of the time a stage changed its state
+	EventTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+)
+
+// Event describes a stage changing its state, an error or a result of a
+// command such as the CID of the created VM
+type Event struct {
+	Type string `json:"type"`
+
+	Stage    string `json:"stage,omitempty"`
+	State    string `json:"state,omitempty"`
+	Time     string `json:"time,omitempty"`
+	Duration string `json:"duration,omitempty"`
+
+	Message string `json:"message,omitempty"`
+
+	Name  string      `json:"name,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
+
+// RecordEvent hands the event to ui when it keeps events, and drops it otherwise
+func RecordEvent(ui UI, event Event) {
+	if eventUI, ok := ui.(EventUI); ok {
+		eventUI.RecordEvent(event)
+	}
+}
+
+// PromptUI is implemented by UIs that know whether they can ask for input.
+// UIs writing JSON cannot, even when input is interactive.
+type PromptUI interface {
+	CanAskForInput() bool
+}
+
+// CanAskForInput reports whether ui can ask for input, UIs that do not
+// know are assumed to be able to
+func CanAskForInput(ui UI) bool {
+	if promptUI, ok := ui.(PromptUI); ok {
+		return promptUI.CanAskForInput()
+	}
+
+	return true
+}
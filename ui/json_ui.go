@@ -23,6 +23,7 @@ type uiResp struct {
 	Tables []tableResp
 	Blocks []string
 	Lines  []string
+	Events []Event `json:",omitempty"`
 }
 
 type tableResp struct {
@@ -100,6 +101,12 @@ func (ui *jsonUI) PrintTable(table Table) {
 	ui.uiResp.Tables = append(ui.uiResp.Tables, resp)
 }
 
+// RecordEvent keeps the event for the final output so that stages, errors
+// and results can be read without parsing Lines
+func (ui *jsonUI) RecordEvent(event Event) {
+	ui.uiResp.Events = append(ui.uiResp.Events, event)
+}
+
 func (ui *jsonUI) AskForText(_ string) (string, error) {
 	panic("Cannot ask for input in JSON UI")
 }
@@ -120,6 +127,10 @@ func (ui *jsonUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
 
+func (ui *jsonUI) CanAskForInput() bool {
+	return false
+}
+
 func (ui *jsonUI) Flush() {
 	defer ui.parent.Flush()
 
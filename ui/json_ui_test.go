@@ -35,6 +35,7 @@ var _ = Describe("JSONUI", func() {
 		Tables []tableResp
 		Blocks []string
 		Lines  []string
+		Events []Event
 	}
 
 	finalOutput := func() uiResp {
@@ -110,6 +111,27 @@ var _ = Describe("JSONUI", func() {
 		})
 	})
 
+	Describe("RecordEvent", func() {
+		It("includes in Events", func() {
+			ui.BeginLinef("fake-stage...")
+			RecordEvent(ui, Event{Type: EventTypeStage, Stage: "fake-stage", State: EventStateFinished, Duration: "00:00:01"})
+			RecordEvent(ui, Event{Type: EventTypeResult, Name: "vm_cid", Value: "fake-vm-cid"})
+			Expect(finalOutput()).To(Equal(uiResp{
+				Lines: []string{"fake-stage..."},
+				Events: []Event{
+					{Type: "stage", Stage: "fake-stage", State: "finished", Duration: "00:00:01"},
+					{Type: "result", Name: "vm_cid", Value: "fake-vm-cid"},
+				},
+			}))
+		})
+
+		It("omits Events when there are none", func() {
+			ui.PrintLinef("fake-line1")
+			ui.Flush()
+			Expect(parentUI.Blocks[0]).ToNot(ContainSubstring("Events"))
+		})
+	})
+
 	Describe("PrintTable", func() {
 		It("includes table response in Tables", func() {
 			table := Table{
@@ -316,6 +338,13 @@ var _ = Describe("JSONUI", func() {
 		})
 	})
 
+	Describe("CanAskForInput", func() {
+		It("returns false since prompts would mix with the JSON output", func() {
+			parentUI.Interactive = true
+			Expect(CanAskForInput(ui)).To(BeFalse())
+		})
+	})
+
 	Describe("IsInteractive", func() {
 		It("delegates to the parent UI", func() {
 			parentUI.Interactive = true
@@ -61,6 +61,14 @@ func (ui *nonInteractiveUI) IsInteractive() bool {
 	return false
 }
 
+func (ui *nonInteractiveUI) RecordEvent(event Event) {
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *nonInteractiveUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
 func (ui *nonInteractiveUI) Flush() {
 	ui.parent.Flush()
 }
@@ -1,6 +1,7 @@
 package ui_test
 
 import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -105,6 +106,15 @@ var _ = Describe("NonInteractiveUI", func() {
 		})
 	})
 
+	Describe("CanAskForInput", func() {
+		It("delegates to the parent UI", func() {
+			Expect(CanAskForInput(ui)).To(BeTrue())
+
+			jsonUI := NewJSONUI(parentUI, boshlog.NewLogger(boshlog.LevelNone))
+			Expect(CanAskForInput(NewNonInteractiveUI(jsonUI))).To(BeFalse())
+		})
+	})
+
 	Describe("Flush", func() {
 		It("delegates to the parent UI", func() {
 			ui.Flush()
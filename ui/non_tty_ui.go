@@ -59,6 +59,14 @@ func (ui *NonTTYUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
 
+func (ui *NonTTYUI) RecordEvent(event Event) {
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *NonTTYUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
 func (ui *NonTTYUI) Flush() {
 	ui.parent.Flush()
 }
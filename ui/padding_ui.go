@@ -81,6 +81,14 @@ func (ui *paddingUI) IsInteractive() bool {
 	return ui.parent.IsInteractive()
 }
 
+func (ui *paddingUI) RecordEvent(event Event) {
+	RecordEvent(ui.parent, event)
+}
+
+func (ui *paddingUI) CanAskForInput() bool {
+	return CanAskForInput(ui.parent)
+}
+
 func (ui *paddingUI) Flush() {
 	ui.parent.Flush()
 }
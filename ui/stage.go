@@ -1,16 +1,27 @@
 package ui
 
 import (
+	"sync"
 	"time"
 
 	"code.cloudfoundry.org/clock"
 	biuifmt "github.com/cloudfoundry/bosh-cli/ui/fmt"
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 )
 
 type Stage interface {
 	Perform(name string, closure func() error) error
 	PerformComplex(name string, closure func(Stage) error) error
+
+	// PerformParallel runs all tasks concurrently, prefixing their lines
+	// with the task name, and summarizes the result of each task at the end
+	PerformParallel(name string, tasks []ParallelTask) error
+}
+
+type ParallelTask struct {
+	Name    string
+	Closure func(Stage) error
 }
 
 type stage struct {
@@ -21,9 +32,26 @@ type stage struct {
 	logger boshlog.Logger
 
 	simpleMode bool
+
+	keepaliveInterval time.Duration
+
+	// attempts are shown instead of the errors of retried operations
+	attempts *Attempts
 }
 
 func NewStage(ui UI, timeService clock.Clock, logger boshlog.Logger) Stage {
+	return NewStageWithKeepalive(ui, timeService, logger, 0)
+}
+
+// NewStageWithKeepalive prints a progress line every keepaliveInterval while
+// a simple stage is running, so that CI systems that kill jobs without
+// output for a while do not kill long compilations or uploads.
+// Zero interval disables keepalive lines.
+func NewStageWithKeepalive(ui UI, timeService clock.Clock, logger boshlog.Logger, keepaliveInterval time.Duration) Stage {
+	return newStage(ui, timeService, logger, keepaliveInterval, AttemptsOf(ui))
+}
+
+func newStage(ui UI, timeService clock.Clock, logger boshlog.Logger, keepaliveInterval time.Duration, attempts *Attempts) *stage {
 	return &stage{
 		ui:          ui,
 		timeService: timeService,
@@ -32,6 +60,10 @@ func NewStage(ui UI, timeService clock.Clock, logger boshlog.Logger) Stage {
 		logger: logger,
 
 		simpleMode: true,
+
+		keepaliveInterval: keepaliveInterval,
+
+		attempts: attempts,
 	}
 }
 
@@ -44,17 +76,27 @@ func (s *stage) Perform(name string, closure func() error) error {
 
 	s.ui.BeginLinef("%s...", name)
 	startTime := s.timeService.Now()
+	s.recordStage(name, EventStateStarted, "", startTime)
+	attemptsMark := s.attempts.Mark()
+	stopKeepalive := s.startKeepalive(name, startTime, attemptsMark)
 	err := closure()
+	stopKeepalive()
 	if err != nil {
 		if skipErr, ok := err.(SkipStageError); ok {
-			s.ui.EndLinef(" Skipped [%s] (%s)", skipErr.SkipMessage(), s.elapsedSince(startTime))
+			elapsed := s.elapsedSince(startTime)
+			s.ui.EndLinef(" Skipped [%s] (%s)", skipErr.SkipMessage(), elapsed)
+			s.recordStage(name, EventStateSkipped, elapsed, s.timeService.Now())
 			s.logger.Info(s.logTag, "Skipped stage '%s': %s", name, skipErr.Error())
 			return nil
 		}
-		s.ui.EndLinef(" Failed (%s)", s.elapsedSince(startTime))
+		elapsed := s.elapsedSince(startTime)
+		s.ui.EndLinef(" Failed%s (%s)", s.attemptsSince(attemptsMark), elapsed)
+		s.recordStage(name, EventStateFailed, elapsed, s.timeService.Now())
 		return err
 	}
-	s.ui.EndLinef(" Finished (%s)", s.elapsedSince(startTime))
+	elapsed := s.elapsedSince(startTime)
+	s.ui.EndLinef(" Finished%s (%s)", s.attemptsSince(attemptsMark), elapsed)
+	s.recordStage(name, EventStateFinished, elapsed, s.timeService.Now())
 	return nil
 }
 
@@ -65,21 +107,155 @@ func (s *stage) PerformComplex(name string, closure func(Stage) error) error {
 
 	s.ui.BeginLinef("Started %s\n", name)
 	startTime := s.timeService.Now()
+	s.recordStage(name, EventStateStarted, "", startTime)
 	err := closure(s.newSubStage())
+	elapsed := s.elapsedSince(startTime)
 	if err != nil {
-		s.ui.BeginLinef("Failed %s (%s)\n", name, s.elapsedSince(startTime))
+		s.ui.BeginLinef("Failed %s (%s)\n", name, elapsed)
+		s.recordStage(name, EventStateFailed, elapsed, s.timeService.Now())
 		return err
 	}
-	s.ui.BeginLinef("Finished %s (%s)\n", name, s.elapsedSince(startTime))
+	s.ui.BeginLinef("Finished %s (%s)\n", name, elapsed)
+	s.recordStage(name, EventStateFinished, elapsed, s.timeService.Now())
+	return nil
+}
+
+func (s *stage) PerformParallel(name string, tasks []ParallelTask) error {
+	s.ui.BeginLinef("\n")
+	s.simpleMode = false
+
+	s.ui.BeginLinef("Started %s\n", name)
+	startTime := s.timeService.Now()
+	s.recordStage(name, EventStateStarted, "", startTime)
+
+	lock := &sync.Mutex{}
+	taskErrs := make([]error, len(tasks))
+	taskTimes := make([]string, len(tasks))
+
+	var wg sync.WaitGroup
+
+	for i, task := range tasks {
+		wg.Add(1)
+
+		go func(i int, task ParallelTask) {
+			defer wg.Done()
+
+			taskUI := NewGroupingUI(NewIndentingUI(s.ui), task.Name, lock)
+			taskStage := newStage(taskUI, s.timeService, s.logger, s.keepaliveInterval, s.attempts)
+
+			// events are recorded as tasks start and stop, through the
+			// grouping UI so that concurrent tasks take turns
+			taskStartTime := s.timeService.Now()
+			taskStage.recordStage(task.Name, EventStateStarted, "", taskStartTime)
+
+			taskErrs[i] = task.Closure(taskStage)
+
+			taskStopTime := s.timeService.Now()
+			taskTimes[i] = biuifmt.Duration(taskStopTime.Sub(taskStartTime))
+			taskStage.recordStage(task.Name, parallelTaskState(taskErrs[i]), taskTimes[i], taskStopTime)
+		}(i, task)
+	}
+
+	wg.Wait()
+
+	var errs []error
+
+	for i, task := range tasks {
+		// tasks are summarized once all finished
+		err := taskErrs[i]
+		if err == nil {
+			s.ui.BeginLinef("  %s... Finished (%s)\n", task.Name, taskTimes[i])
+		} else if skipErr, ok := err.(SkipStageError); ok {
+			s.ui.BeginLinef("  %s... Skipped [%s] (%s)\n", task.Name, skipErr.SkipMessage(), taskTimes[i])
+			s.logger.Info(s.logTag, "Skipped stage '%s': %s", task.Name, skipErr.Error())
+		} else {
+			s.ui.BeginLinef("  %s... Failed (%s)\n", task.Name, taskTimes[i])
+			errs = append(errs, bosherr.WrapErrorf(err, "%s", task.Name))
+		}
+	}
+
+	elapsed := s.elapsedSince(startTime)
+
+	if len(errs) > 0 {
+		s.ui.BeginLinef("Failed %s (%s)\n", name, elapsed)
+		s.recordStage(name, EventStateFailed, elapsed, s.timeService.Now())
+		return bosherr.NewMultiError(errs...)
+	}
+
+	s.ui.BeginLinef("Finished %s (%s)\n", name, elapsed)
+	s.recordStage(name, EventStateFinished, elapsed, s.timeService.Now())
 	return nil
 }
 
+func (s *stage) startKeepalive(name string, startTime time.Time, attemptsMark int) func() {
+	if s.keepaliveInterval <= 0 {
+		return func() {}
+	}
+
+	ticker := s.timeService.NewTicker(s.keepaliveInterval)
+	doneCh := make(chan struct{})
+	stoppedCh := make(chan struct{})
+
+	go func() {
+		defer close(stoppedCh)
+
+		for {
+			select {
+			case <-ticker.C():
+				s.ui.EndLinef(" Still running%s (%s)", s.attemptsSince(attemptsMark), s.elapsedSince(startTime))
+				s.ui.BeginLinef("%s...", name)
+			case <-doneCh:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		ticker.Stop()
+		close(doneCh)
+		<-stoppedCh
+	}
+}
+
+func parallelTaskState(err error) string {
+	if err == nil {
+		return EventStateFinished
+	}
+
+	if _, ok := err.(SkipStageError); ok {
+		return EventStateSkipped
+	}
+
+	return EventStateFailed
+}
+
+func (s *stage) recordStage(name, state, duration string, at time.Time) {
+	RecordEvent(s.ui, Event{
+		Type:     EventTypeStage,
+		Stage:    name,
+		State:    state,
+		Time:     at.UTC().Format(EventTimeFormat),
+		Duration: duration,
+	})
+}
+
 func (s *stage) elapsedSince(startTime time.Time) string {
 	stopTime := s.timeService.Now()
 	duration := stopTime.Sub(startTime)
 	return biuifmt.Duration(duration)
 }
 
+// attemptsSince folds the retries made since mark into one bracket,
+// e.g. " [create_vm attempt 3/5]"
+func (s *stage) attemptsSince(mark int) string {
+	summary := s.attempts.Summary(mark)
+	if summary == "" {
+		return ""
+	}
+
+	return " [" + summary + "]"
+}
+
 func (s *stage) newSubStage() Stage {
-	return NewStage(NewIndentingUI(s.ui), s.timeService, s.logger)
+	return newStage(NewIndentingUI(s.ui), s.timeService, s.logger, s.keepaliveInterval, s.attempts)
 }
@@ -12,6 +12,9 @@ import (
 	"code.cloudfoundry.org/clock/fakeclock"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"github.com/onsi/gomega/gbytes"
+
+	fakeui "github.com/cloudfoundry/bosh-cli/ui/fakes"
 )
 
 var _ = Describe("Stage", func() {
@@ -90,6 +93,110 @@ var _ = Describe("Stage", func() {
 			Expect(logOutBuffer.String()).To(ContainSubstring("fake-skip-message: fake-skip-error"))
 			Expect(actionsPerformed).To(Equal([]string{"1"}))
 		})
+
+		Context("when the ui keeps attempts of retried operations", func() {
+			var confUI *ConfUI
+
+			BeforeEach(func() {
+				confUI = NewWrappingConfUI(ui, logger)
+				stage = NewStage(confUI, fakeTimeService, logger)
+			})
+
+			It("folds the retries made while the stage runs into its line", func() {
+				confUI.Attempts().ReportAttempt("create_vm", 2, 5)
+
+				err := stage.Perform("Simple stage 1", func() error {
+					confUI.Attempts().ReportAttempt("create_vm", 2, 5)
+					confUI.Attempts().ReportAttempt("create_vm", 3, 5)
+					confUI.Attempts().ReportAttempt("agent ping", 4, 0)
+					fakeTimeService.Increment(time.Minute)
+					return nil
+				})
+				Expect(err).ToNot(HaveOccurred())
+
+				err = stage.Perform("Simple stage 2", func() error {
+					return bosherr.Error("fake-stage-2-error")
+				})
+				Expect(err).To(HaveOccurred())
+
+				Expect(uiOut.String()).To(Equal(
+					"Simple stage 1... Finished [create_vm attempt 3/5, agent ping attempt 4] (00:01:00)\n" +
+						"Simple stage 2... Failed (00:00:00)\n"))
+			})
+
+			It("folds the retries of sub-stages into their lines", func() {
+				err := stage.PerformComplex("Complex stage 1", func(stage Stage) error {
+					return stage.Perform("Simple stage A", func() error {
+						confUI.Attempts().ReportAttempt("download", 2, 3)
+						return bosherr.Error("fake-stage-a-error")
+					})
+				})
+				Expect(err).To(HaveOccurred())
+
+				Expect(uiOut.String()).To(ContainSubstring("  Simple stage A... Failed [download attempt 2/3] (00:00:00)\n"))
+			})
+		})
+	})
+
+	Describe("keepalive", func() {
+		var (
+			keepaliveOut *gbytes.Buffer
+		)
+
+		BeforeEach(func() {
+			keepaliveOut = gbytes.NewBuffer()
+			ui = NewWriterUI(keepaliveOut, uiErr, logger)
+			stage = NewStageWithKeepalive(ui, fakeTimeService, logger, 30*time.Second)
+		})
+
+		It("prints a line at every interval while a simple stage is running", func() {
+			err := stage.Perform("Simple stage 1", func() error {
+				fakeTimeService.WaitForWatcherAndIncrement(30 * time.Second)
+				Eventually(keepaliveOut).Should(gbytes.Say("Simple stage 1... Still running \\(00:00:30\\)\n"))
+
+				fakeTimeService.WaitForWatcherAndIncrement(30 * time.Second)
+				Eventually(keepaliveOut).Should(gbytes.Say("Simple stage 1... Still running \\(00:01:00\\)\n"))
+
+				fakeTimeService.Increment(10 * time.Second)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(keepaliveOut.Contents())).To(Equal(
+				"Simple stage 1... Still running (00:00:30)\n" +
+					"Simple stage 1... Still running (00:01:00)\n" +
+					"Simple stage 1... Finished (00:01:10)\n",
+			))
+			Expect(fakeTimeService.WatcherCount()).To(Equal(0))
+		})
+
+		It("prints keepalive lines for stages nested in complex stages", func() {
+			err := stage.PerformComplex("Complex stage 1", func(stage Stage) error {
+				return stage.Perform("Simple stage A", func() error {
+					fakeTimeService.WaitForWatcherAndIncrement(30 * time.Second)
+					Eventually(keepaliveOut).Should(gbytes.Say("Still running"))
+					return nil
+				})
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(keepaliveOut.Contents())).To(Equal(`
+Started Complex stage 1
+  Simple stage A... Still running (00:00:30)
+  Simple stage A... Finished (00:00:30)
+Finished Complex stage 1 (00:00:30)
+`))
+		})
+
+		It("does not print keepalive lines for stages finishing within the interval", func() {
+			err := stage.Perform("Simple stage 1", func() error {
+				fakeTimeService.Increment(10 * time.Second)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(string(keepaliveOut.Contents())).To(Equal("Simple stage 1... Finished (00:00:10)\n"))
+		})
 	})
 
 	Describe("PerformComplex", func() {
@@ -203,4 +310,198 @@ Failed Complex stage 1 (00:01:00)
 			Expect(actionsPerformed).To(Equal([]string{"1"}))
 		})
 	})
+
+	Describe("PerformParallel", func() {
+		It("prefixes the lines of each task and summarizes them", func() {
+			err := stage.PerformParallel("Parallel stage 1", []ParallelTask{
+				{Name: "task-a", Closure: func(stage Stage) error {
+					return stage.Perform("Simple stage A", func() error {
+						fakeTimeService.Increment(time.Minute)
+						return nil
+					})
+				}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			expectedOutput := `
+Started Parallel stage 1
+  [task-a] Simple stage A... Finished (00:01:00)
+  task-a... Finished (00:01:00)
+Finished Parallel stage 1 (00:01:00)
+`
+			Expect(uiOut.String()).To(Equal(expectedOutput))
+		})
+
+		It("runs the tasks concurrently", func() {
+			bStarted := make(chan struct{})
+
+			err := stage.PerformParallel("Parallel stage 1", []ParallelTask{
+				{Name: "task-a", Closure: func(stage Stage) error {
+					<-bStarted
+					return nil
+				}},
+				{Name: "task-b", Closure: func(stage Stage) error {
+					close(bStarted)
+					return nil
+				}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(uiOut.String()).To(ContainSubstring("  task-a... Finished (00:00:00)\n  task-b... Finished (00:00:00)\n"))
+		})
+
+		It("keeps the lines of concurrent tasks whole", func() {
+			err := stage.PerformParallel("Parallel stage 1", []ParallelTask{
+				{Name: "task-a", Closure: func(stage Stage) error {
+					return stage.Perform("Simple stage A", func() error { return nil })
+				}},
+				{Name: "task-b", Closure: func(stage Stage) error {
+					return stage.PerformComplex("Complex stage B", func(stage Stage) error {
+						return stage.Perform("Simple stage X", func() error { return nil })
+					})
+				}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(uiOut.String()).To(ContainSubstring("  [task-a] Simple stage A... Finished (00:00:00)\n"))
+			Expect(uiOut.String()).To(ContainSubstring("  [task-b] Started Complex stage B\n"))
+			Expect(uiOut.String()).To(ContainSubstring("  [task-b]   Simple stage X... Finished (00:00:00)\n"))
+			Expect(uiOut.String()).To(ContainSubstring("  [task-b] Finished Complex stage B (00:00:00)\n"))
+		})
+
+		It("waits for all tasks and fails when any of them fails", func() {
+			actionsPerformed := make(chan string, 2)
+
+			err := stage.PerformParallel("Parallel stage 1", []ParallelTask{
+				{Name: "task-a", Closure: func(stage Stage) error {
+					actionsPerformed <- "A"
+					return bosherr.Error("fake-task-a-error")
+				}},
+				{Name: "task-b", Closure: func(stage Stage) error {
+					actionsPerformed <- "B"
+					return nil
+				}},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("task-a: fake-task-a-error"))
+			Expect(actionsPerformed).To(HaveLen(2))
+
+			expectedOutput := `
+Started Parallel stage 1
+  task-a... Failed (00:00:00)
+  task-b... Finished (00:00:00)
+Failed Parallel stage 1 (00:00:00)
+`
+			Expect(uiOut.String()).To(Equal(expectedOutput))
+		})
+	})
+
+	Describe("events", func() {
+		var eventUI *fakeui.FakeUI
+
+		BeforeEach(func() {
+			eventUI = &fakeui.FakeUI{}
+			fakeTimeService = fakeclock.NewFakeClock(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+			stage = NewStage(eventUI, fakeTimeService, logger)
+		})
+
+		It("records the state of simple stages", func() {
+			err := stage.Perform("Simple stage 1", func() error {
+				fakeTimeService.Increment(time.Minute)
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			err = stage.Perform("Simple stage 2", func() error {
+				return bosherr.Error("fake-stage-2-error")
+			})
+			Expect(err).To(HaveOccurred())
+
+			Expect(eventUI.Events).To(Equal([]Event{
+				{Type: "stage", Stage: "Simple stage 1", State: "started", Time: "2026-01-02T03:04:05.000Z"},
+				{Type: "stage", Stage: "Simple stage 1", State: "finished", Time: "2026-01-02T03:05:05.000Z", Duration: "00:01:00"},
+				{Type: "stage", Stage: "Simple stage 2", State: "started", Time: "2026-01-02T03:05:05.000Z"},
+				{Type: "stage", Stage: "Simple stage 2", State: "failed", Time: "2026-01-02T03:05:05.000Z", Duration: "00:00:00"},
+			}))
+		})
+
+		It("records skipped stages", func() {
+			err := stage.Perform("Simple stage 1", func() error {
+				return NewSkipStageError(bosherr.Error("fake-skip-error"), "fake-skip-message")
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(eventUI.Events).To(ContainElement(
+				Event{Type: "stage", Stage: "Simple stage 1", State: "skipped", Time: "2026-01-02T03:04:05.000Z", Duration: "00:00:00"},
+			))
+		})
+
+		It("records nested stages of complex stages", func() {
+			err := stage.PerformComplex("Complex stage 1", func(stage Stage) error {
+				return stage.Perform("Simple stage A", func() error { return nil })
+			})
+			Expect(err).ToNot(HaveOccurred())
+
+			Expect(eventUI.Events).To(Equal([]Event{
+				{Type: "stage", Stage: "Complex stage 1", State: "started", Time: "2026-01-02T03:04:05.000Z"},
+				{Type: "stage", Stage: "Simple stage A", State: "started", Time: "2026-01-02T03:04:05.000Z"},
+				{Type: "stage", Stage: "Simple stage A", State: "finished", Time: "2026-01-02T03:04:05.000Z", Duration: "00:00:00"},
+				{Type: "stage", Stage: "Complex stage 1", State: "finished", Time: "2026-01-02T03:04:05.000Z", Duration: "00:00:00"},
+			}))
+		})
+
+		It("records the tasks of parallel stages", func() {
+			err := stage.PerformParallel("Parallel stage 1", []ParallelTask{
+				{Name: "task-a", Closure: func(stage Stage) error {
+					return stage.Perform("Simple stage A", func() error { return nil })
+				}},
+				{Name: "task-b", Closure: func(stage Stage) error {
+					return bosherr.Error("fake-task-b-error")
+				}},
+			})
+			Expect(err).To(HaveOccurred())
+
+			Expect(eventUI.Events).To(ContainElement(Event{Type: "stage", Stage: "Simple stage A", State: "finished", Time: "2026-01-02T03:04:05.000Z", Duration: "00:00:00"}))
+			Expect(eventUI.Events).To(ContainElement(Event{Type: "stage", Stage: "task-a", State: "started", Time: "2026-01-02T03:04:05.000Z"}))
+			Expect(eventUI.Events).To(ContainElement(Event{Type: "stage", Stage: "task-a", State: "finished", Time: "2026-01-02T03:04:05.000Z", Duration: "00:00:00"}))
+			Expect(eventUI.Events).To(ContainElement(Event{Type: "stage", Stage: "task-b", State: "failed", Time: "2026-01-02T03:04:05.000Z", Duration: "00:00:00"}))
+			Expect(eventUI.Events[len(eventUI.Events)-1]).To(Equal(
+				Event{Type: "stage", Stage: "Parallel stage 1", State: "failed", Time: "2026-01-02T03:04:05.000Z", Duration: "00:00:00"},
+			))
+		})
+
+		It("records the events of each task as it starts and stops", func() {
+			events := make(chan Event, 10)
+			stage = NewStage(channelEventUI{FakeUI: eventUI, events: events}, fakeTimeService, logger)
+
+			err := stage.PerformParallel("Parallel stage 1", []ParallelTask{
+				{Name: "task-a", Closure: func(stage Stage) error {
+					return nil
+				}},
+				{Name: "task-b", Closure: func(stage Stage) error {
+					for {
+						select {
+						case event := <-events:
+							if event.Stage == "task-a" && event.State == EventStateFinished {
+								return nil
+							}
+						case <-time.After(5 * time.Second):
+							return bosherr.Error("task-a was not recorded as finished while task-b was running")
+						}
+					}
+				}},
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
 })
+
+// channelEventUI passes events on as they are recorded
+type channelEventUI struct {
+	*fakeui.FakeUI
+	events chan Event
+}
+
+func (ui channelEventUI) RecordEvent(event Event) {
+	ui.events <- event
+}
@@ -0,0 +1,55 @@
+package ui
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"sync"
+
+	"code.cloudfoundry.org/clock"
+)
+
+const timestampWriterFormat = "2006-01-02T15:04:05.000Z07:00"
+
+var colorCodesRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+type timestampWriter struct {
+	writer      io.Writer
+	timeService clock.Clock
+	onNewLine   bool
+	lock        sync.Mutex
+}
+
+// NewTimestampWriter prefixes every line written to writer with the time
+// its first byte was written. Color codes are dropped, since the writer is
+// meant for log files rather than terminals.
+func NewTimestampWriter(writer io.Writer, timeService clock.Clock) io.Writer {
+	return &timestampWriter{writer: writer, timeService: timeService, onNewLine: true}
+}
+
+func (w *timestampWriter) Write(data []byte) (int, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	var buf bytes.Buffer
+
+	for _, line := range bytes.SplitAfter(colorCodesRegexp.ReplaceAll(data, nil), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+
+		if w.onNewLine {
+			buf.WriteString("[" + w.timeService.Now().UTC().Format(timestampWriterFormat) + "] ")
+		}
+
+		buf.Write(line)
+		w.onNewLine = line[len(line)-1] == '\n'
+	}
+
+	_, err := w.writer.Write(buf.Bytes())
+	if err != nil {
+		return 0, err
+	}
+
+	return len(data), nil
+}
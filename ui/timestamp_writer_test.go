@@ -0,0 +1,50 @@
+package ui_test
+
+import (
+	"bytes"
+	"io"
+	"time"
+
+	"code.cloudfoundry.org/clock/fakeclock"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/cloudfoundry/bosh-cli/ui"
+)
+
+var _ = Describe("TimestampWriter", func() {
+	var (
+		buffer    *bytes.Buffer
+		fakeClock *fakeclock.FakeClock
+		w         io.Writer
+	)
+
+	BeforeEach(func() {
+		buffer = bytes.NewBufferString("")
+		fakeClock = fakeclock.NewFakeClock(time.Date(2017, time.March, 1, 12, 0, 0, 0, time.UTC))
+		w = NewTimestampWriter(buffer, fakeClock)
+	})
+
+	It("prefixes every line with the time it was started", func() {
+		n, err := w.Write([]byte("line1\nline2"))
+		Expect(err).ToNot(HaveOccurred())
+		Expect(n).To(Equal(11))
+
+		fakeClock.Increment(1500 * time.Millisecond)
+
+		w.Write([]byte(" continued\n"))
+		w.Write([]byte("line3\n"))
+
+		Expect(buffer.String()).To(Equal(
+			"[2017-03-01T12:00:00.000Z] line1\n" +
+				"[2017-03-01T12:00:00.000Z] line2 continued\n" +
+				"[2017-03-01T12:00:01.500Z] line3\n",
+		))
+	})
+
+	It("drops color codes", func() {
+		w.Write([]byte("\x1b[32mSucceeded\x1b[0m\n"))
+
+		Expect(buffer.String()).To(Equal("[2017-03-01T12:00:00.000Z] Succeeded\n"))
+	})
+})
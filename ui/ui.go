@@ -35,6 +35,12 @@ func NewWriterUI(outWriter, errWriter io.Writer, logger boshlog.Logger) *WriterU
 	}
 }
 
+// TeeTo copies all output, including errors, to writer
+func (ui *WriterUI) TeeTo(writer io.Writer) {
+	ui.outWriter = io.MultiWriter(ui.outWriter, writer)
+	ui.errWriter = io.MultiWriter(ui.errWriter, writer)
+}
+
 func (ui *WriterUI) IsTTY() bool {
 	file, ok := ui.outWriter.(*os.File)
 
@@ -217,4 +217,20 @@ note2
 			Expect(func() { ui.Flush() }).ToNot(Panic())
 		})
 	})
+
+	Describe("TeeTo", func() {
+		It("copies output and errors to the writer", func() {
+			teeBuffer := bytes.NewBufferString("")
+
+			writerUI := NewWriterUI(uiOut, uiErr, logger)
+			writerUI.TeeTo(teeBuffer)
+
+			writerUI.PrintLinef("fake-line")
+			writerUI.ErrorLinef("fake-error-line")
+
+			Expect(uiOutBuffer.String()).To(Equal("fake-line\n"))
+			Expect(uiErrBuffer.String()).To(Equal("fake-error-line\n"))
+			Expect(teeBuffer.String()).To(Equal("fake-line\nfake-error-line\n"))
+		})
+	})
 })